@@ -9,7 +9,6 @@ import (
 	"fmt"
 	"github.com/omegasuite/btcd/blockchain/chainutil"
 	"github.com/omegasuite/btcd/database"
-	"github.com/omegasuite/btcd/wire"
 	"github.com/omegasuite/btcutil"
 )
 
@@ -41,13 +40,14 @@ func (b *BlockChain) maybeAcceptBlock(block *btcutil.Block, flags BehaviorFlags)
 		return true, nil, -1
 	}
 
-	if block.MsgBlock().Header.Nonce <= -wire.MINER_RORATE_FREQ {
+	freq := b.ChainParams.Committee(block.MsgBlock().Header.Version).RotateFreq
+	if block.MsgBlock().Header.Nonce <= -freq {
 		// make sure the rotate in Miner block is there
-		if prevNode.Data.GetNonce() != -wire.MINER_RORATE_FREQ+1 {
-			return false, fmt.Errorf("this is a rotation node and previous nonce is not %d", -wire.MINER_RORATE_FREQ+1), -1
+		if prevNode.Data.GetNonce() != -freq+1 {
+			return false, fmt.Errorf("this is a rotation node and previous nonce is not %d", -freq+1), -1
 		}
-		if mb, err := b.Miners.BlockByHeight(-block.MsgBlock().Header.Nonce - wire.MINER_RORATE_FREQ); err != nil || mb == nil {
-			return false, err, -block.MsgBlock().Header.Nonce - wire.MINER_RORATE_FREQ
+		if mb, err := b.Miners.BlockByHeight(-block.MsgBlock().Header.Nonce - freq); err != nil || mb == nil {
+			return false, err, -block.MsgBlock().Header.Nonce - freq
 		}
 	}
 
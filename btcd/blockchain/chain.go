@@ -33,8 +33,8 @@ const (
 	// queued.
 	maxOrphanBlocks = 2 * wire.MaxBlocksPerMsg
 
-	// time (blocks) to hold miner account
-	MinerHoldingPeriod = 3 * wire.MINER_RORATE_FREQ
+	// time (blocks) to hold miner account. 3 rotations of the default committee
+	MinerHoldingPeriod = 3 * 200
 )
 
 // BestState houses information about the current best block and other info
@@ -54,7 +54,7 @@ type BestState struct {
 	MedianTime  time.Time      // Median time as per CalcPastMedianTime.
 	LastRotation uint32		   // height of the last rotate in Miner. normally
 							   // it is nonce in last rotation block.
-							   // for every POW block, it increase by CommitteeSize
+							   // for every POW block, it increase by POWRotate
 							   // to phase out the last committee EVEN it means to pass the
 							   // end of Miner chain (for consistency among nodes
 
@@ -178,7 +178,6 @@ type BlockChain struct {
 	notifications     []NotificationCallback
 
 	Miner []btcutil.Address
-	PrivKey []*btcec.PrivateKey
 
 	// records about miner's performance
 	MinerTPH map[[20]byte]*TPHRecord
@@ -399,7 +398,7 @@ func (b *BlockChain) TotalRotate(lst * list.List) int32 {
 		n := e.Value.(*chainutil.BlockNode)
 		if n.Data.GetNonce() > 0 {
 			s += wire.POWRotate
-		} else if n.Data.GetNonce() <= -wire.MINER_RORATE_FREQ {
+		} else if n.Data.GetNonce() <= -b.RotateFreq(n) {
 			s++
 		}
 	}
@@ -486,8 +485,8 @@ func (b *BlockChain) getReorganizeNodes(node *chainutil.BlockNode) (*list.List,
 //	}
 
 	for p := node; p != nil && p != forkNode; p = p.Parent {
-		if p.Data.GetNonce() <= -wire.MINER_RORATE_FREQ {
-			h := -p.Data.GetNonce() - wire.MINER_RORATE_FREQ
+		if p.Data.GetNonce() <= -b.RotateFreq(p) {
+			h := -p.Data.GetNonce() - b.RotateFreq(p)
 			mb, _ := b.Miners.BlockByHeight(h)
 			if mb == nil {
 				node = p.Parent
@@ -538,7 +537,7 @@ func (s * BlockChain) GetRollbackList(h int32) * list.List {
 	detachNodes := list.New()
 
 	for n := s.BestChain.Tip(); n != nil; n = n.Parent {
-		if n.Data.GetNonce() > -wire.MINER_RORATE_FREQ || n.Data.GetNonce() < -(h + wire.MINER_RORATE_FREQ) {
+		if n.Data.GetNonce() > -s.RotateFreq(n) || n.Data.GetNonce() < -(h + s.RotateFreq(n)) {
 			detachNodes.PushBack(n)
 		} else {
 			detachNodes.PushBack(n)
@@ -563,7 +562,7 @@ func (s * BlockChain) GetRollbackList(h int32) * list.List {
 func (b *BlockChain) connectBlock(node *chainutil.BlockNode, block *btcutil.Block,
 	view *viewpoint.ViewPointSet, stxos []viewpoint.SpentTxOut, vm * ovm.OVM) error {
 
-	if block.MsgBlock().Header.Nonce < 0 && len(block.MsgBlock().Transactions[0].SignatureScripts) <= wire.CommitteeSigs {
+	if block.MsgBlock().Header.Nonce < 0 && len(block.MsgBlock().Transactions[0].SignatureScripts) <= int(b.ChainParams.Committee(block.MsgBlock().Header.Version).Sigs) {
 		return fmt.Errorf("insifficient signatures")
 	}
 	if block.MsgBlock().Header.Nonce < 0 && len(block.MsgBlock().Transactions[0].SignatureScripts[1]) < btcec.PubKeyBytesLenCompressed {
@@ -606,8 +605,8 @@ func (b *BlockChain) connectBlock(node *chainutil.BlockNode, block *btcutil.Bloc
 		state.LastRotation += wire.POWRotate
 		m = wire.POWRotate
 		log.Infof("Update LastRotation to %d", state.LastRotation)
-	} else if node.Data.GetNonce() <= -wire.MINER_RORATE_FREQ {
-		state.LastRotation = uint32(-node.Data.GetNonce() - wire.MINER_RORATE_FREQ)
+	} else if node.Data.GetNonce() <= -b.RotateFreq(node) {
+		state.LastRotation = uint32(-node.Data.GetNonce() - b.RotateFreq(node))
 		m = 1
 		log.Infof("Update LastRotation to %d", state.LastRotation)
 	}
@@ -642,6 +641,31 @@ func (b *BlockChain) connectBlock(node *chainutil.BlockNode, block *btcutil.Bloc
 			return err
 		}
 
+		// Prune the geometry last held by the outputs spent by the block
+		// at the reorg-safe depth and no longer used.
+		if block.MsgBlock().Header.Version >= chaincfg.Version6 &&
+			node.Height > viewpoint.GeometryPruneDepth {
+			old, err := dbFetchBlockByNode(dbTx, node.Ancestor(node.Height - viewpoint.GeometryPruneDepth))
+			if err != nil {
+				return err
+			}
+			spent, err := dbFetchSpendJournalEntry(dbTx, old)
+			if err != nil {
+				return err
+			}
+			err = viewpoint.DbPruneGeometry(dbTx, view, node.Height, spent)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Record the events emitted by contracts called by the block
+		// before the indexes see it.
+		err = vm.PutEvents(dbTx)
+		if err != nil {
+			return err
+		}
+
 		// Allow the index manager to call each of the currently active
 		// optional indexes with the block being connected so they can
 		// update themselves accordingly.
@@ -748,18 +772,18 @@ func (b *BlockChain) disconnectBlock(node *chainutil.BlockNode, block *btcutil.B
 	blockSize := uint64(prevBlock.MsgBlock().SerializeSize())
 	newTotalTxns := curTotalTxns - uint64(len(block.MsgBlock().Transactions))
 /*
-	if node.Data.GetNonce() <= -wire.MINER_RORATE_FREQ {
+	if node.Data.GetNonce() <= -b.RotateFreq(node) {
 		// the removed block was the first of a series rotated-in block, difficulty should be
 		// in its previous block
 		p := node.Parent
 
-		for p != nil && p.Data.GetNonce() > -wire.MINER_RORATE_FREQ {
+		for p != nil && p.Data.GetNonce() > -b.RotateFreq(p) {
 			p = p.Parent
 		}
 
 		realheight := int32(0)
 		if p != nil {
-			realheight = -p.Data.GetNonce() - wire.MINER_RORATE_FREQ
+			realheight = -p.Data.GetNonce() - b.RotateFreq(p)
 		}
 
 		// the real Miner block height of the previous Miner block
@@ -775,7 +799,7 @@ func (b *BlockChain) disconnectBlock(node *chainutil.BlockNode, block *btcutil.B
 	if node.Data.GetNonce() >= 0 {
 		rotation -= wire.POWRotate
 		m = wire.POWRotate
-	} else if node.Data.GetNonce() <= -wire.MINER_RORATE_FREQ {
+	} else if node.Data.GetNonce() <= -b.RotateFreq(node) {
 		rotation--
 		m = 1
 	}
@@ -810,6 +834,12 @@ func (b *BlockChain) disconnectBlock(node *chainutil.BlockNode, block *btcutil.B
 			return err
 		}
 
+		// The geometry pruned by the block has been restored with the view.
+		err = viewpoint.DbRemoveGeometryArchive(dbTx, node.Height)
+		if err != nil {
+			return err
+		}
+
 		// Before we delete the spend journal entry for this back,
 		// we'll fetch it as is so the indexers can utilize if needed.
 		stxos, err := dbFetchSpendJournalEntry(dbTx, block)
@@ -834,6 +864,14 @@ func (b *BlockChain) disconnectBlock(node *chainutil.BlockNode, block *btcutil.B
 			}
 		}
 
+		// Remove the events emitted by contracts called by the block.
+		for _, tx := range block.Transactions() {
+			err = ovm.DbRemoveTxEvents(dbTx, tx.Hash())
+			if err != nil {
+				return err
+			}
+		}
+
 		return nil
 	})
 	if err != nil {
@@ -894,7 +932,7 @@ func (b *BlockChain) Advance(x * list.Element) int32 {
 	shift := int32(0)
 	if m.Data.GetNonce() > 0 {
 		shift = wire.POWRotate
-	} else if m.Data.GetNonce() <= -wire.MINER_RORATE_FREQ {
+	} else if m.Data.GetNonce() <= -b.RotateFreq(m) {
 		shift = 1
 	}
 	return shift
@@ -1096,7 +1134,7 @@ func (b *BlockChain) doReorganizeChain(detachNodes, attachNodes *list.List) (int
 			return 0, 0, err
 		}
 
-		if n.Data.GetNonce() <= -wire.MINER_RORATE_FREQ {
+		if n.Data.GetNonce() <= -b.RotateFreq(n) {
 			rotate--
 		} else if n.Data.GetNonce() > 0 {
 			rotate -= wire.POWRotate
@@ -1126,14 +1164,9 @@ func (b *BlockChain) doReorganizeChain(detachNodes, attachNodes *list.List) (int
 	// issues before ever modifying the chain.
 
 	// examine signers are in committee
-	miners := make([]*[20]byte, wire.CommitteeSize)
-	for i := int32(0); i < wire.CommitteeSize; i++ {
-		if blk, _ := b.Miners.BlockByHeight(int32(rotate) - wire.CommitteeSize + i + 1); blk != nil {
-			if _,err := b.CheckCollateral(blk, &newBest.Hash, BFNone); err != nil {
-				continue
-			}
-			miners[i] = &blk.MsgBlock().Miner
-		}
+	miners, err := b.committeeMiners(int32(rotate), &newBest.Hash)
+	if err != nil {
+		return 0, 0, err
 	}
 
 //	prevNode := forkNode
@@ -1165,29 +1198,37 @@ func (b *BlockChain) doReorganizeChain(detachNodes, attachNodes *list.List) (int
 		shift := 0
 		if n.Data.GetNonce() > 0 {
 			shift = wire.POWRotate
-		} else if n.Data.GetNonce() <= -wire.MINER_RORATE_FREQ {
+		} else if n.Data.GetNonce() <= -b.RotateFreq(n) {
 			shift = 1
 		}
 		if shift > 0 {
-			j := 0
-			for k := shift; k < wire.CommitteeSize; k++ {
-				miners[j] = miners[k]
-				j++
-			}
-
+			size := len(miners)
 			for k := 0; k < shift; k++ {
 				rotate++
 				if blk, _ := b.Miners.BlockByHeight(int32(rotate)); blk != nil {
 					if _,err := b.CheckCollateral(blk, &newBest.Hash, BFNone); err != nil {
-						miners[j] = nil
+						miners = append(miners, nil)
 						continue
 					}
-					miners[j] = &blk.MsgBlock().Miner
+					miners = append(miners, &blk.MsgBlock().Miner)
 				} else if shift == 1 {
 					log.Infof("Incorrect rotation")
 					return detachable, attachable, fmt.Errorf("Incorrect rotation")
+				} else {
+					miners = append(miners, nil)
+				}
+			}
+			miners = miners[len(miners)-size:]
+
+			committee, err := b.Committee(int32(rotate))
+			if err != nil {
+				return detachable, attachable, err
+			}
+			if committee.Size != int32(size) {
+				// the committee is resized by the rotation
+				if miners, err = b.committeeMiners(int32(rotate), &newBest.Hash); err != nil {
+					return detachable, attachable, err
 				}
-				j++
 			}
 		}
 
@@ -2539,7 +2580,6 @@ type Config struct {
 	IndexManager IndexManager
 
 	Miner	[]btcutil.Address
-	PrivKey []*btcec.PrivateKey
 
 	// HashCache defines a transaction hash mid-state cache to use when
 	// validating transactions. This cache has the potential to greatly
@@ -2602,7 +2642,6 @@ func New(config *Config) (*BlockChain, error) {
 		BestChain:           chainutil.NewChainView(nil),
 		Orphans:             chainutil.NewOrphanMgr(),
 		Miner:               config.Miner,
-		PrivKey:             config.PrivKey,
 //		BlackedList:         make([]*wire.Violations,0),
 		MinerTPH:          make(map[[20]byte]*TPHRecord),
 		ConsensusRange:    [2]int32{-1,-1},
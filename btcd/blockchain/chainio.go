@@ -20,7 +20,7 @@ import (
 	"github.com/omegasuite/btcd/database"
 	"github.com/omegasuite/btcd/wire"
 	"github.com/omegasuite/btcutil"
-	//	"github.com/omegasuite/omega/ovm"
+	"github.com/omegasuite/omega/ovm"
 	"github.com/omegasuite/omega/token"
 	"github.com/omegasuite/omega/viewpoint"
 )
@@ -848,11 +848,45 @@ func (b *BlockChain) createChainState() error {
 			return err
 		}
 
+		// Create the bucket that houses the polyhedron hash to definition
+		if _, err = meta.CreateBucket(viewpoint.PolyhedronSetBucketName); err != nil {
+			return err
+		}
+
+		// Create the buckets that index geometry by the geometry defined on
+		// it and archive pruned geometry
+		if _, err = meta.CreateBucket(viewpoint.GeometryRefsBucketName); err != nil {
+			return err
+		}
+		if _, err = meta.CreateBucket(viewpoint.GeometryArchiveBucketName); err != nil {
+			return err
+		}
+
+		// Create the bucket that indexes contract storage changes by
+		// contract, key and height
+		if _, err = meta.CreateBucket(ovm.StorageHistoryBucketName); err != nil {
+			return err
+		}
+
 		// Create the bucket that houses the right hash to definition
 		if _, err = meta.CreateBucket(rightSetBucketName); err != nil {
 			return err
 		}
 
+		// Create the bucket that indexes the utxo set by address
+		if _, err = meta.CreateBucket(viewpoint.AddrUtxoBucketName); err != nil {
+			return err
+		}
+
+		// Create the buckets that index rights by father and right sets
+		// by the rights in them
+		if _, err = meta.CreateBucket(viewpoint.RightChildrenBucketName); err != nil {
+			return err
+		}
+		if _, err = meta.CreateBucket(viewpoint.RightSetMembersBucketName); err != nil {
+			return err
+		}
+
 		// Create the bucket that houses the miner tps records
 		if _, err = meta.CreateBucket(minerTPSBucketName); err != nil {
 			return err
@@ -906,7 +940,7 @@ func (b *BlockChain) createChainState() error {
 func (b *BlockChain) initChainState() error {
 	// Determine the state of the chain database. We may need to initialize
 	// everything from scratch or upgrade certain buckets.
-	var initialized, hasBlockIndex, hasminertps, hascomptx, hasaddrusage bool
+	var initialized, hasBlockIndex, hasminertps, hascomptx, hasaddrusage, hasrightindex, hasaddrutxo, haspolyhedra, hasgeometryrefs, hasstoragehistory bool
 	var addrUseIndexKey = []byte("usebyaddridx")
 
 	err := b.db.Update(func(dbTx database.Tx) error {
@@ -915,6 +949,12 @@ func (b *BlockChain) initChainState() error {
 		hasminertps = dbTx.Metadata().Bucket(minerTPSBucketName) != nil
 		hascomptx = dbTx.Metadata().Bucket(compendatedBucketName) != nil
 		hasaddrusage = dbTx.Metadata().Bucket(addrUseIndexKey) != nil
+		hasrightindex = dbTx.Metadata().Bucket(viewpoint.RightChildrenBucketName) != nil &&
+			dbTx.Metadata().Bucket(viewpoint.RightSetMembersBucketName) != nil
+		hasaddrutxo = dbTx.Metadata().Bucket(viewpoint.AddrUtxoBucketName) != nil
+		haspolyhedra = dbTx.Metadata().Bucket(viewpoint.PolyhedronSetBucketName) != nil
+		hasgeometryrefs = dbTx.Metadata().Bucket(viewpoint.GeometryRefsBucketName) != nil
+		hasstoragehistory = dbTx.Metadata().Bucket(ovm.StorageHistoryBucketName) != nil
 		return nil
 	})
 	if err != nil {
@@ -982,6 +1022,58 @@ func (b *BlockChain) initChainState() error {
 		}
 	}
 
+	if !haspolyhedra {
+		err := b.db.Update(func(dbTx database.Tx) error {
+			if _, err = dbTx.Metadata().CreateBucket(viewpoint.PolyhedronSetBucketName); err != nil {
+				return err
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if !hasgeometryrefs {
+		log.Infof("Indexing geometry references")
+		err := b.db.Update(func(dbTx database.Tx) error {
+			return viewpoint.DbBuildGeometryRefs(dbTx)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if !hasstoragehistory {
+		log.Infof("Indexing contract storage history")
+		err := b.db.Update(func(dbTx database.Tx) error {
+			return ovm.DbBuildStorageHistory(dbTx)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if !hasaddrutxo {
+		log.Infof("Indexing unspent transaction outputs by address")
+		err := b.db.Update(func(dbTx database.Tx) error {
+			return viewpoint.DbBuildAddrUtxoIndex(dbTx)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if !hasrightindex {
+		log.Infof("Indexing rights by father and right sets by rights")
+		err := b.db.Update(func(dbTx database.Tx) error {
+			return viewpoint.DbBuildRightIndex(dbTx)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	unloaded := make(map[chainhash.Hash]int32)
 	buffer := make([]struct {
 		hash chainhash.Hash
@@ -1741,6 +1833,51 @@ func (b *BlockChain) FetchRightEntry(hash chainhash.Hash) (*viewpoint.RightEntry
 	return entry, nil
 }
 
+// FetchAddressUtxos returns the unspent outputs paid to addr, the net id and
+// hash of an address as returned by ScriptNetAddress, from the point of view
+// of the end of the main chain.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) FetchAddressUtxos(addr []byte) (map[wire.OutPoint]*viewpoint.UtxoEntry, error) {
+	b.ChainLock.RLock()
+	defer b.ChainLock.RUnlock()
+
+	var utxos map[wire.OutPoint]*viewpoint.UtxoEntry
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		utxos, err = viewpoint.DbFetchAddrUtxos(dbTx, addr)
+		return err
+	})
+	return utxos, err
+}
+
+// FetchGeometryPruneStats returns the statistics of geometry pruning.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) FetchGeometryPruneStats() (*viewpoint.GeometryPruneStats, error) {
+	b.ChainLock.RLock()
+	defer b.ChainLock.RUnlock()
+
+	var stats *viewpoint.GeometryPruneStats
+	err := b.db.View(func(dbTx database.Tx) error {
+		stats = viewpoint.DbFetchGeometryPruneStats(dbTx)
+		return nil
+	})
+	return stats, err
+}
+
+// FetchRightTree returns the ancestors and the descendants of the right of
+// hash, down to maxDepth levels below it unless maxDepth is 0, from the point
+// of view of the end of the main chain.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) FetchRightTree(hash chainhash.Hash, maxDepth int32) (*viewpoint.RightTree, error) {
+	b.ChainLock.RLock()
+	defer b.ChainLock.RUnlock()
+
+	return b.NewViewPointSet().RightTree(hash, maxDepth)
+}
+
 func (b *BlockChain) FetchRightSetEntry(hash chainhash.Hash) (*viewpoint.RightSetEntry, error) {
 	b.ChainLock.RLock()
 	defer b.ChainLock.RUnlock()
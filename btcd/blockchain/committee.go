@@ -0,0 +1,58 @@
+// Copyright (c) 2018-2021 The Omegasuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+
+	"github.com/omegasuite/btcd/blockchain/chainutil"
+	"github.com/omegasuite/btcd/chaincfg"
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+)
+
+// RotateFreq returns the number of blocks signed by a committee before it
+// rotates, for the committee that signed the block of node. It is the nonce
+// of a block that tells whether it rotates the committee, and the nonce is
+// read with the parameters of the version of the block.
+func (b *BlockChain) RotateFreq(node *chainutil.BlockNode) int32 {
+	return b.ChainParams.Committee(node.Data.GetVersion()).RotateFreq
+}
+
+// Committee returns the parameters of the committee of rotation, the one whose
+// most junior member is the miner block at height rotation. They are those of
+// the version of that miner block, which is also the version of the tx blocks
+// the committee signs. It is an error if the miner block is not known, as the
+// committee can not be sized then.
+func (b *BlockChain) Committee(rotation int32) (*chaincfg.CommitteeParams, error) {
+	mb, err := b.Miners.BlockByHeight(rotation)
+	if err != nil {
+		return nil, err
+	}
+	if mb == nil {
+		return nil, fmt.Errorf("miner block of rotation %d is not known", rotation)
+	}
+	return b.ChainParams.Committee(mb.MsgBlock().Version), nil
+}
+
+// committeeMiners returns the miners of the committee of rotation, the most
+// senior first. The place of a miner whose miner block is not known, or whose
+// collateral is not valid at block best, is nil.
+func (b *BlockChain) committeeMiners(rotation int32, best *chainhash.Hash) ([]*[20]byte, error) {
+	committee, err := b.Committee(rotation)
+	if err != nil {
+		return nil, err
+	}
+	size := committee.Size
+	miners := make([]*[20]byte, size)
+	for i := int32(0); i < size; i++ {
+		if blk, _ := b.Miners.BlockByHeight(rotation - size + i + 1); blk != nil {
+			if _, err := b.CheckCollateral(blk, best, BFNone); err != nil {
+				continue
+			}
+			miners[i] = &blk.MsgBlock().Miner
+		}
+	}
+	return miners, nil
+}
@@ -36,8 +36,8 @@ func (g *BlockChain) CompTxs(prevNode *chainutil.BlockNode, views *viewpoint.Vie
 	// prevNode is the node in tx chain just before us. it normally is tip of chain
 
 	// we only do compensation in the first block after rotation. i.e., nonce of prev block
-	// is either positive or less than MINER_RORATE_FREQ
-	nonce := prevNode.Data.GetNonce()
+	// is either positive or less than -RotateFreq
+	nonce, freq := prevNode.Data.GetNonce(), g.RotateFreq(prevNode)
 	var pmh, rbase int32
 
 	reportee := make(map[int32]*wire.MinerBlock)
@@ -45,8 +45,8 @@ func (g *BlockChain) CompTxs(prevNode *chainutil.BlockNode, views *viewpoint.Vie
 
 	// determine the violator that should be processed. the reporting deadline is 100 (ViolationReportDeadline)
 	// MR blocks. the violator is the 100-th block (or two blocks) before the just-rotated-in MR block
-	if nonce < -wire.MINER_RORATE_FREQ {
-		pmh = -(nonce + wire.MINER_RORATE_FREQ)
+	if nonce < -freq {
+		pmh = -(nonce + freq)
 		if pmh <= g.ChainParams.ViolationReportDeadline {
 			return nil, nil
 		}
@@ -59,13 +59,13 @@ func (g *BlockChain) CompTxs(prevNode *chainutil.BlockNode, views *viewpoint.Vie
 		rbase = pmh - g.ChainParams.ViolationReportDeadline
 	} else if nonce > 0 {
 		q, m := prevNode, 0
-		for ; q != nil && q.Data.GetNonce() > -wire.MINER_RORATE_FREQ; q = q.Parent {
+		for ; q != nil && q.Data.GetNonce() > -g.RotateFreq(q); q = q.Parent {
 			if q.Data.GetNonce() > 0 {
 				m += wire.POWRotate
 			}
 		}
 		if q != nil {
-			pmh = - (q.Data.GetNonce() + wire.MINER_RORATE_FREQ) + int32(m)
+			pmh = - (q.Data.GetNonce() + g.RotateFreq(q)) + int32(m)
 			prevminer = g.Miners.NodeByHeight(pmh - 1)
 			rbase = pmh - g.ChainParams.ViolationReportDeadline - int32(m)
 			for j, h := 0, rbase; j < m; j++ {
@@ -181,8 +181,8 @@ func (g *BlockChain) CompTxs(prevNode *chainutil.BlockNode, views *viewpoint.Vie
 
 		if avgtx < 0 {
 			// get 200 block avergae txs in the reporting period. we will decide allocation unit based on this
-			// reporting period = ViolationReportDeadline (100) * MINER_RORATE_FREQ (200)
-			for i, p := 0, prevNode; i < int(g.ChainParams.ViolationReportDeadline) * wire.MINER_RORATE_FREQ; i++ {
+			// reporting period = ViolationReportDeadline (100) * RotateFreq (200)
+			for i, p := 0, prevNode; i < int(g.ChainParams.ViolationReportDeadline * freq); i++ {
 				t,_ := g.BlockByHash(&p.Hash)
 				if t == nil {
 					return nil, nil
@@ -579,15 +579,15 @@ func (g *BlockChain) comptx(tx *txfee) []byte {
 // this function returns side chain violating blocks that should be compensated
 // in the block after prevNode for each violating miner (upto 2).
 func (b *BlockChain) PrepForfeit(prevNode *chainutil.BlockNode) ([]reportedblk, int32, error) {
-	nonce := prevNode.Data.GetNonce()
+	nonce, freq := prevNode.Data.GetNonce(), b.RotateFreq(prevNode)
 	var pmh, rbase int32
 	reportee := make(map[int32]*wire.MinerBlock)
 	var prevminer *chainutil.BlockNode
 
 	forfeiture := make([]reportedblk, 0)
 
-	if nonce < -wire.MINER_RORATE_FREQ {
-		pmh = -(nonce + wire.MINER_RORATE_FREQ)
+	if nonce < -freq {
+		pmh = -(nonce + freq)
 		mb,err := b.Miners.BlockByHeight(pmh - b.ChainParams.ViolationReportDeadline)
 		if err != nil {
 			return nil, 0, err
@@ -597,13 +597,13 @@ func (b *BlockChain) PrepForfeit(prevNode *chainutil.BlockNode) ([]reportedblk,
 		prevminer = b.Miners.NodeByHeight(pmh - 1)
 	} else if nonce > 0	{
 		q, m := prevNode, wire.POWRotate
-		for ; q != nil && q.Data.GetNonce() > -wire.MINER_RORATE_FREQ; q = q.Parent {
+		for ; q != nil && q.Data.GetNonce() > -b.RotateFreq(q); q = q.Parent {
 			if q.Data.GetNonce() > 0 {
 				m += wire.POWRotate
 			}
 		}
 		if q != nil {
-			pmh = int32(m + 1) - (q.Data.GetNonce() + wire.MINER_RORATE_FREQ)
+			pmh = int32(m + 1) - (q.Data.GetNonce() + b.RotateFreq(q))
 			prevminer = b.Miners.NodeByHeight(pmh - 1)
 			rbase = pmh - b.ChainParams.ViolationReportDeadline - wire.POWRotate + 1
 			for j, h := 0, rbase; j < wire.POWRotate; j++ {
@@ -657,7 +657,7 @@ func (b *BlockChain) PrepForfeit(prevNode *chainutil.BlockNode) ([]reportedblk,
 
 func (b *BlockChain) CheckForfeit(block *btcutil.Block, prevNode *chainutil.BlockNode, views *viewpoint.ViewPointSet) error {
 	nonce := prevNode.Data.GetNonce()
-	if nonce < 0 && nonce > -wire.MINER_RORATE_FREQ {
+	if nonce < 0 && nonce > -b.RotateFreq(prevNode) {
 		// this is not a block after rotation, make sure there is no Forfeit tx
 		for _,tx := range block.MsgBlock().Transactions[1:] {
 			if tx.IsForfeit() {
@@ -0,0 +1,224 @@
+// Copyright (c) 2018-2021 The Omegasuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+
+	"github.com/omegasuite/btcd/database"
+	"github.com/omegasuite/btcutil"
+	"github.com/omegasuite/omega/ovm"
+	"github.com/omegasuite/omega/viewpoint"
+)
+
+const (
+	// contractEventIndexName is the human-readable name for the index.
+	contractEventIndexName = "contract event index"
+
+	// contractEventKeySize is the size of an index key: contract address,
+	// topic, block height, position of the tx in the block and position
+	// of the event in the tx.
+	contractEventKeySize = 20 + 4 + 4 + 4 + 4
+)
+
+var (
+	// contractEventIndexKey is the key of the contract event index and the
+	// db bucket used to house it.
+	contractEventIndexKey = []byte("contracteventidx")
+)
+
+// ContractEvent is an event found through the contract event index.
+type ContractEvent struct {
+	ovm.Event
+	Height  int32  // height of the block of the transaction
+	TxIndex uint32 // position of the transaction in the block
+	Index   uint32 // position of the event among those of the transaction
+}
+
+// ContractEventIndex implements an index of the events emitted by contracts
+// by contract address and topic. The events themselves are kept with the
+// transactions emitting them by the OVM.
+//
+// An index key is the contract address followed by the topic, block height,
+// transaction position and event position, all big-endian so that the events
+// of a contract and topic are in chain order. The value is the transaction
+// hash.
+type ContractEventIndex struct {
+	// The following fields are set when the instance is created and can't
+	// be changed afterwards, so there is no need to protect them with a
+	// separate mutex.
+	db database.DB
+}
+
+// Ensure the ContractEventIndex type implements the Indexer interface.
+var _ Indexer = (*ContractEventIndex)(nil)
+
+// Init is only provided to satisfy the Indexer interface as there is nothing
+// to initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *ContractEventIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *ContractEventIndex) Key() []byte {
+	return contractEventIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *ContractEventIndex) Name() string {
+	return contractEventIndexName
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the index.
+//
+// This is part of the Indexer interface.
+func (idx *ContractEventIndex) Create(dbTx database.Tx) error {
+	_, err := dbTx.Metadata().CreateBucket(contractEventIndexKey)
+	return err
+}
+
+func contractEventKey(e *ovm.Event, height int32, txIndex, index uint32) []byte {
+	key := make([]byte, contractEventKeySize)
+	copy(key, e.Contract[:])
+	binary.BigEndian.PutUint32(key[20:], e.Topic)
+	binary.BigEndian.PutUint32(key[24:], uint32(height))
+	binary.BigEndian.PutUint32(key[28:], txIndex)
+	binary.BigEndian.PutUint32(key[32:], index)
+	return key
+}
+
+// forEachEvent calls fn with the index key of each event emitted by the
+// transactions of block.
+func forEachEvent(dbTx database.Tx, block *btcutil.Block, fn func(key []byte, tx *btcutil.Tx) error) error {
+	for i, tx := range block.Transactions() {
+		events, err := ovm.FetchTxEvents(dbTx, tx.Hash())
+		if err != nil {
+			return err
+		}
+		for j := range events {
+			if err := fn(contractEventKey(&events[j], block.Height(), uint32(i), uint32(j)), tx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer adds a key for each event the
+// contracts called by the transactions in the block emitted.
+//
+// This is part of the Indexer interface.
+func (idx *ContractEventIndex) ConnectBlock(dbTx database.Tx, block *btcutil.Block,
+	stxos []viewpoint.SpentTxOut) error {
+	bucket := dbTx.Metadata().Bucket(contractEventIndexKey)
+	return forEachEvent(dbTx, block, func(key []byte, tx *btcutil.Tx) error {
+		return bucket.Put(key, tx.Hash()[:])
+	})
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer removes the keys of the
+// events of the block.
+//
+// This is part of the Indexer interface.
+func (idx *ContractEventIndex) DisconnectBlock(dbTx database.Tx, block *btcutil.Block,
+	stxos []viewpoint.SpentTxOut) error {
+	bucket := dbTx.Metadata().Bucket(contractEventIndexKey)
+	return forEachEvent(dbTx, block, func(key []byte, tx *btcutil.Tx) error {
+		return bucket.Delete(key)
+	})
+}
+
+// Events returns the events emitted by contract in blocks at or above
+// fromHeight, in chain order and at most max of them. If topic is not nil,
+// only events of the topic are returned.
+func (idx *ContractEventIndex) Events(contract ovm.Address, topic *uint32, fromHeight int32, max int) ([]ContractEvent, error) {
+	prefix := contract[:]
+	if topic != nil {
+		prefix = make([]byte, 24)
+		copy(prefix, contract[:])
+		binary.BigEndian.PutUint32(prefix[20:], *topic)
+	}
+
+	var events []ContractEvent
+	err := idx.db.View(func(dbTx database.Tx) error {
+		c := dbTx.Metadata().Bucket(contractEventIndexKey).Cursor()
+		for ok := c.Seek(prefix); ok && bytes.HasPrefix(c.Key(), prefix); ok = c.Next() {
+			key := c.Key()
+			if len(key) != contractEventKeySize {
+				continue
+			}
+			e := ContractEvent{
+				Height:  int32(binary.BigEndian.Uint32(key[24:])),
+				TxIndex: binary.BigEndian.Uint32(key[28:]),
+				Index:   binary.BigEndian.Uint32(key[32:]),
+			}
+			if e.Height < fromHeight {
+				continue
+			}
+			copy(e.TxHash[:], c.Value())
+			events = append(events, e)
+		}
+
+		// events of several topics are merged into chain order before
+		// being cut to max
+		sort.SliceStable(events, func(i, j int) bool {
+			a, b := &events[i], &events[j]
+			if a.Height != b.Height {
+				return a.Height < b.Height
+			}
+			if a.TxIndex != b.TxIndex {
+				return a.TxIndex < b.TxIndex
+			}
+			return a.Index < b.Index
+		})
+		if max > 0 && len(events) > max {
+			events = events[:max]
+		}
+
+		for i := range events {
+			e := &events[i]
+			txEvents, err := ovm.FetchTxEvents(dbTx, &e.TxHash)
+			if err != nil {
+				return err
+			}
+			if int(e.Index) >= len(txEvents) {
+				return errDeserialize("contract event index entry refers " +
+					"to a missing event")
+			}
+			e.Event = txEvents[e.Index]
+		}
+		return nil
+	})
+	return events, err
+}
+
+// NewContractEventIndex returns a new instance of an indexer that is used to
+// create a mapping of contracts and topics to the events emitted by the
+// contracts.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewContractEventIndex(db database.DB) *ContractEventIndex {
+	return &ContractEventIndex{db: db}
+}
+
+// DropContractEventIndex drops the contract event index from the provided
+// database if it exists.
+func DropContractEventIndex(db database.DB, interrupt <-chan struct{}) error {
+	return dropIndex(db, contractEventIndexKey, contractEventIndexName, interrupt)
+}
@@ -0,0 +1,469 @@
+// Copyright (c) 2018-2021 The Omegasuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/database"
+	"github.com/omegasuite/btcd/wire"
+	"github.com/omegasuite/btcutil"
+	"github.com/omegasuite/omega/token"
+	"github.com/omegasuite/omega/viewpoint"
+)
+
+const (
+	// polygonIndexName is the human-readable name for the index.
+	polygonIndexName = "polygon index"
+
+	// polygonTokenType is the token type of polygon tokens.
+	polygonTokenType = 3
+
+	// polygonOutpointSize is the size of an outpoint in the index: the tx
+	// hash followed by the big-endian output index.
+	polygonOutpointSize = chainhash.HashSize + 4
+)
+
+var (
+	// polygonIndexKey is the key of the polygon index and the db bucket
+	// used to house it.
+	polygonIndexKey = []byte("polygonidx")
+
+	// polygonUtxoBucketName is the name of the bucket mapping a polygon and
+	// an outpoint to a placeholder, for each unspent output of the polygon.
+	polygonUtxoBucketName = []byte("utxo")
+
+	// polygonOutpointBucketName is the name of the bucket mapping an unspent
+	// outpoint to the polygon of its token and the bound of the polygon. The
+	// bound is kept so that the polygon can be taken out of the quadtree
+	// after a disconnected block removed its definition.
+	polygonOutpointBucketName = []byte("outpoint")
+
+	// polygonBoxBucketName is the name of the root bucket of the quadtree of
+	// polygon bounding boxes.
+	polygonBoxBucketName = []byte("box")
+)
+
+// IndexedPolygon is a polygon found through the polygon index.
+type IndexedPolygon struct {
+	Hash  chainhash.Hash
+	Bound viewpoint.BoundingBox
+}
+
+// PolygonIndex implements an index of the polygons of the unspent polygon
+// tokens. It allows to find the polygons whose bounding box contains a point
+// or intersects a box, and the unspent outputs of a polygon.
+//
+// The bounding boxes are kept in a quadtree of nested buckets. The root bucket
+// covers the whole coordinate space, and each nested bucket, keyed by the
+// quadrant (0 to 3), one quarter of its parent. A polygon is stored, keyed by
+// its hash, in the smallest quadrant containing its bounding box. A polygon is
+// in the tree as long as it has an unspent output.
+type PolygonIndex struct {
+	// The following fields are set when the instance is created and can't
+	// be changed afterwards, so there is no need to protect them with a
+	// separate mutex.
+	db database.DB
+}
+
+// Ensure the PolygonIndex type implements the Indexer interface.
+var _ Indexer = (*PolygonIndex)(nil)
+
+// Init is only provided to satisfy the Indexer interface as there is nothing
+// to initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *PolygonIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *PolygonIndex) Key() []byte {
+	return polygonIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *PolygonIndex) Name() string {
+	return polygonIndexName
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the index
+// and its nested buckets.
+//
+// This is part of the Indexer interface.
+func (idx *PolygonIndex) Create(dbTx database.Tx) error {
+	bucket, err := dbTx.Metadata().CreateBucket(polygonIndexKey)
+	if err != nil {
+		return err
+	}
+	for _, name := range [][]byte{polygonUtxoBucketName,
+		polygonOutpointBucketName, polygonBoxBucketName} {
+		if _, err := bucket.CreateBucket(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// quadCoord maps a coordinate to an unsigned one of the same order.
+func quadCoord(c int32) uint32 {
+	return uint32(c) + 0x80000000
+}
+
+// quadLevel returns the level of the smallest quadrant containing box. A
+// quadrant of level l is 2^l wide, level 32 being the whole space.
+func quadLevel(box *viewpoint.BoundingBox) int {
+	d := (quadCoord(box.West()) ^ quadCoord(box.East())) |
+		(quadCoord(box.South()) ^ quadCoord(box.North()))
+	return bits.Len32(d)
+}
+
+// quadrant returns the key of the nested bucket of level level-1 containing
+// the point x, y in a bucket of level level.
+func quadrant(x, y uint32, level int) []byte {
+	bit := uint(level - 1)
+	return []byte{byte((x>>bit)&1 | ((y>>bit)&1)<<1)}
+}
+
+func serializeBound(box *viewpoint.BoundingBox) []byte {
+	var s [16]byte
+	binary.BigEndian.PutUint32(s[:], uint32(box.West()))
+	binary.BigEndian.PutUint32(s[4:], uint32(box.East()))
+	binary.BigEndian.PutUint32(s[8:], uint32(box.South()))
+	binary.BigEndian.PutUint32(s[12:], uint32(box.North()))
+	return s[:]
+}
+
+func deserializeBound(s []byte) (viewpoint.BoundingBox, error) {
+	var box viewpoint.BoundingBox
+	if len(s) != 16 {
+		return box, errDeserialize("unexpected polygon bound size")
+	}
+	box.Reset()
+	box.Expand(int32(binary.BigEndian.Uint32(s[8:])), int32(binary.BigEndian.Uint32(s[:])))
+	box.Expand(int32(binary.BigEndian.Uint32(s[12:])), int32(binary.BigEndian.Uint32(s[4:])))
+	return box, nil
+}
+
+// addPolygonBox stores the bound of polygon in the quadtree.
+func addPolygonBox(root database.Bucket, polygon *chainhash.Hash, box *viewpoint.BoundingBox) error {
+	x, y := quadCoord(box.West()), quadCoord(box.South())
+	bucket := root
+	for level := 32; level > quadLevel(box); level-- {
+		var err error
+		if bucket, err = bucket.CreateBucketIfNotExists(quadrant(x, y, level)); err != nil {
+			return err
+		}
+	}
+	return bucket.Put(polygon[:], serializeBound(box))
+}
+
+// removePolygonBox removes polygon from the quadtree bucket of level level,
+// along with the nested buckets left empty.
+func removePolygonBox(bucket database.Bucket, polygon *chainhash.Hash, box *viewpoint.BoundingBox, level int) error {
+	if level == quadLevel(box) {
+		return bucket.Delete(polygon[:])
+	}
+
+	key := quadrant(quadCoord(box.West()), quadCoord(box.South()), level)
+	child := bucket.Bucket(key)
+	if child == nil {
+		return nil
+	}
+	if err := removePolygonBox(child, polygon, box, level-1); err != nil {
+		return err
+	}
+
+	empty := true
+	child.ForEach(func(k, v []byte) error {
+		empty = false
+		return nil
+	})
+	child.ForEachBucket(func(k []byte) error {
+		empty = false
+		return nil
+	})
+	if empty {
+		return bucket.DeleteBucket(key)
+	}
+	return nil
+}
+
+func polygonOutpointKey(op *wire.OutPoint) []byte {
+	key := make([]byte, polygonOutpointSize)
+	copy(key, op.Hash[:])
+	binary.BigEndian.PutUint32(key[chainhash.HashSize:], op.Index)
+	return key
+}
+
+// hasPolygonUtxo returns whether polygon has an unspent output in the index.
+func hasPolygonUtxo(utxos database.Bucket, polygon *chainhash.Hash) bool {
+	c := utxos.Cursor()
+	return c.Seek(polygon[:]) && bytes.HasPrefix(c.Key(), polygon[:])
+}
+
+// addUtxo adds the unspent output op of a token of polygon to the index.
+func addUtxo(dbTx database.Tx, op *wire.OutPoint, polygon *chainhash.Hash) error {
+	bucket := dbTx.Metadata().Bucket(polygonIndexKey)
+	utxos := bucket.Bucket(polygonUtxoBucketName)
+
+	plg, err := viewpoint.DbFetchPolygon(dbTx, polygon)
+	if err != nil {
+		return err
+	}
+	if !hasPolygonUtxo(utxos, polygon) {
+		if err := addPolygonBox(bucket.Bucket(polygonBoxBucketName), polygon, &plg.Bound); err != nil {
+			return err
+		}
+	}
+
+	opKey := polygonOutpointKey(op)
+	value := append(append([]byte{}, polygon[:]...), serializeBound(&plg.Bound)...)
+	if err := bucket.Bucket(polygonOutpointBucketName).Put(opKey, value); err != nil {
+		return err
+	}
+	return utxos.Put(append(append([]byte{}, polygon[:]...), opKey...), []byte{1})
+}
+
+// removeUtxo removes the output op from the index if it is the output of a
+// polygon token.
+func removeUtxo(dbTx database.Tx, op *wire.OutPoint) error {
+	bucket := dbTx.Metadata().Bucket(polygonIndexKey)
+	outpoints := bucket.Bucket(polygonOutpointBucketName)
+	utxos := bucket.Bucket(polygonUtxoBucketName)
+
+	opKey := polygonOutpointKey(op)
+	p := outpoints.Get(opKey)
+	if p == nil {
+		return nil
+	}
+	if len(p) != chainhash.HashSize+16 {
+		return errDeserialize("unexpected polygon outpoint entry size")
+	}
+	var polygon chainhash.Hash
+	copy(polygon[:], p)
+	bound, err := deserializeBound(p[chainhash.HashSize:])
+	if err != nil {
+		return err
+	}
+
+	if err := outpoints.Delete(opKey); err != nil {
+		return err
+	}
+	if err := utxos.Delete(append(polygon[:], opKey...)); err != nil {
+		return err
+	}
+	if hasPolygonUtxo(utxos, &polygon) {
+		return nil
+	}
+
+	return removePolygonBox(bucket.Bucket(polygonBoxBucketName), &polygon, &bound, 32)
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer removes the outputs spent by the
+// block and adds the polygon token outputs it creates.
+//
+// This is part of the Indexer interface.
+func (idx *PolygonIndex) ConnectBlock(dbTx database.Tx, block *btcutil.Block,
+	stxos []viewpoint.SpentTxOut) error {
+	for txIdx, tx := range block.Transactions() {
+		if txIdx != 0 {
+			for _, txIn := range tx.MsgTx().TxIn {
+				if txIn.PreviousOutPoint.Hash.IsEqual(&zerohash) {
+					continue
+				}
+				if err := removeUtxo(dbTx, &txIn.PreviousOutPoint); err != nil {
+					return err
+				}
+			}
+		}
+
+		for i, txOut := range tx.MsgTx().TxOut {
+			if txOut.IsSeparator() || txOut.TokenType != polygonTokenType {
+				continue
+			}
+			op := wire.OutPoint{Hash: *tx.Hash(), Index: uint32(i)}
+			if err := addUtxo(dbTx, &op, &txOut.Value.(*token.HashToken).Hash); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer removes the polygon token
+// outputs created by the block and restores those it spent.
+//
+// This is part of the Indexer interface.
+func (idx *PolygonIndex) DisconnectBlock(dbTx database.Tx, block *btcutil.Block,
+	stxos []viewpoint.SpentTxOut) error {
+	// the spent outputs are in the order of the inputs of the block, so
+	// work out which of them belongs to each transaction first.
+	txs := block.Transactions()
+	stxoStart := make([]int, len(txs))
+	stxoIndex := 0
+	for txIdx, tx := range txs {
+		stxoStart[txIdx] = stxoIndex
+		if txIdx == 0 {
+			continue
+		}
+		for _, txIn := range tx.MsgTx().TxIn {
+			if !txIn.PreviousOutPoint.Hash.IsEqual(&zerohash) {
+				stxoIndex++
+			}
+		}
+	}
+
+	for txIdx := len(txs) - 1; txIdx >= 0; txIdx-- {
+		tx := txs[txIdx]
+		for i, txOut := range tx.MsgTx().TxOut {
+			if txOut.IsSeparator() || txOut.TokenType != polygonTokenType {
+				continue
+			}
+			op := wire.OutPoint{Hash: *tx.Hash(), Index: uint32(i)}
+			if err := removeUtxo(dbTx, &op); err != nil {
+				return err
+			}
+		}
+
+		if txIdx == 0 {
+			continue
+		}
+		stxoIndex = stxoStart[txIdx]
+		for _, txIn := range tx.MsgTx().TxIn {
+			if txIn.PreviousOutPoint.Hash.IsEqual(&zerohash) {
+				continue
+			}
+			if stxoIndex >= len(stxos) {
+				return AssertError(fmt.Sprintf("missing spent output "+
+					"of %v", txIn.PreviousOutPoint))
+			}
+			stxo := &stxos[stxoIndex]
+			stxoIndex++
+			if stxo.TokenType != polygonTokenType {
+				continue
+			}
+			if err := addUtxo(dbTx, &txIn.PreviousOutPoint, &stxo.Amount.(*token.HashToken).Hash); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// searchPolygons appends to res the polygons in the quadtree bucket of level
+// level whose origin is x, y and in its nested buckets whose bounding boxes
+// intersect box. Nested buckets not intersecting box are skipped.
+func searchPolygons(bucket database.Bucket, x, y uint64, level int, box *viewpoint.BoundingBox, res []IndexedPolygon) ([]IndexedPolygon, error) {
+	err := bucket.ForEach(func(k, v []byte) error {
+		if len(k) != chainhash.HashSize {
+			return nil
+		}
+		bound, err := deserializeBound(v)
+		if err != nil {
+			return err
+		}
+		if bound.Intersects(box, true) {
+			p := IndexedPolygon{Bound: bound}
+			copy(p.Hash[:], k)
+			res = append(res, p)
+		}
+		return nil
+	})
+	if err != nil || level == 0 {
+		return res, err
+	}
+
+	half := uint64(1) << uint(level-1)
+	for q := byte(0); q < 4; q++ {
+		child := bucket.Bucket([]byte{q})
+		if child == nil {
+			continue
+		}
+		cx, cy := x+uint64(q&1)*half, y+uint64(q>>1)*half
+		if cx > uint64(quadCoord(box.East())) || cx+half <= uint64(quadCoord(box.West())) ||
+			cy > uint64(quadCoord(box.North())) || cy+half <= uint64(quadCoord(box.South())) {
+			continue
+		}
+		if res, err = searchPolygons(child, cx, cy, level-1, box, res); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// PolygonsInBox returns the polygons with an unspent output whose bounding
+// boxes intersect box, at most max of them if max is positive.
+func (idx *PolygonIndex) PolygonsInBox(box *viewpoint.BoundingBox, max int) ([]IndexedPolygon, error) {
+	var res []IndexedPolygon
+	err := idx.db.View(func(dbTx database.Tx) error {
+		root := dbTx.Metadata().Bucket(polygonIndexKey).Bucket(polygonBoxBucketName)
+		var err error
+		res, err = searchPolygons(root, 0, 0, 32, box, nil)
+		return err
+	})
+	if max > 0 && len(res) > max {
+		res = res[:max]
+	}
+	return res, err
+}
+
+// PolygonsAt returns the polygons with an unspent output whose bounding boxes
+// contain the point at lat, lng.
+func (idx *PolygonIndex) PolygonsAt(lat, lng int32) ([]IndexedPolygon, error) {
+	var box viewpoint.BoundingBox
+	box.Reset()
+	box.Expand(lat, lng)
+	return idx.PolygonsInBox(&box, 0)
+}
+
+// Utxos returns the unspent outputs of the tokens of polygon.
+func (idx *PolygonIndex) Utxos(polygon *chainhash.Hash) ([]wire.OutPoint, error) {
+	var ops []wire.OutPoint
+	err := idx.db.View(func(dbTx database.Tx) error {
+		c := dbTx.Metadata().Bucket(polygonIndexKey).Bucket(polygonUtxoBucketName).Cursor()
+		for ok := c.Seek(polygon[:]); ok && bytes.HasPrefix(c.Key(), polygon[:]); ok = c.Next() {
+			key := c.Key()[chainhash.HashSize:]
+			if len(key) != polygonOutpointSize {
+				return errDeserialize("unexpected polygon outpoint size")
+			}
+			var op wire.OutPoint
+			copy(op.Hash[:], key)
+			op.Index = binary.BigEndian.Uint32(key[chainhash.HashSize:])
+			ops = append(ops, op)
+		}
+		return nil
+	})
+	return ops, err
+}
+
+// NewPolygonIndex returns a new instance of an indexer that is used to create
+// a spatial index of the polygons of unspent polygon tokens and a mapping of
+// the polygons to their unspent outputs.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewPolygonIndex(db database.DB) *PolygonIndex {
+	return &PolygonIndex{db: db}
+}
+
+// DropPolygonIndex drops the polygon index from the provided database if it
+// exists.
+func DropPolygonIndex(db database.DB, interrupt <-chan struct{}) error {
+	return dropIndex(db, polygonIndexKey, polygonIndexName, interrupt)
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2018-2021 The Omegasuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/database"
+	_ "github.com/omegasuite/btcd/database/ffldb"
+	"github.com/omegasuite/btcd/wire/common"
+	"github.com/omegasuite/omega/viewpoint"
+)
+
+func testBox(west, east, south, north int32) viewpoint.BoundingBox {
+	var box viewpoint.BoundingBox
+	box.Reset()
+	box.Expand(south, west)
+	box.Expand(north, east)
+	return box
+}
+
+// TestPolygonQuadtree ensures polygons added to the quadtree are found by
+// point and box searches and that removing them prunes the tree.
+func TestPolygonQuadtree(t *testing.T) {
+	dbPath := filepath.Join(os.TempDir(), "polygonidx-quadtree")
+	_ = os.RemoveAll(dbPath)
+	db, err := database.Create("ffldb", dbPath, common.MainNet)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+	defer db.Close()
+
+	idx := NewPolygonIndex(db)
+	polygons := []struct {
+		hash chainhash.Hash
+		box  viewpoint.BoundingBox
+	}{
+		{chainhash.Hash{1}, testBox(-100, 100, -100, 100)},
+		{chainhash.Hash{2}, testBox(1000, 1010, 2000, 2010)},
+		{chainhash.Hash{3}, testBox(-5000, -4000, 300, 400)},
+		{chainhash.Hash{4}, testBox(7, 7, 9, 9)},
+	}
+
+	err = db.Update(func(dbTx database.Tx) error {
+		if err := idx.Create(dbTx); err != nil {
+			return err
+		}
+		root := dbTx.Metadata().Bucket(polygonIndexKey).Bucket(polygonBoxBucketName)
+		for i := range polygons {
+			p := &polygons[i]
+			if err := addPolygonBox(root, &p.hash, &p.box); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		box  viewpoint.BoundingBox
+		want []byte
+	}{
+		{"point in two", testBox(7, 7, 9, 9), []byte{1, 4}},
+		{"point in one", testBox(1005, 1005, 2005, 2005), []byte{2}},
+		{"point in none", testBox(500, 500, 500, 500), nil},
+		{"box over two", testBox(-6000, 0, 50, 360), []byte{1, 3}},
+		{"box over all", testBox(-1<<31, 1<<31-1, -1<<31, 1<<31-1), []byte{1, 2, 3, 4}},
+	}
+	for _, test := range tests {
+		found, err := idx.PolygonsInBox(&test.box, 0)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		got := make(map[byte]struct{})
+		for _, p := range found {
+			got[p.Hash[0]] = struct{}{}
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("%s: found %d polygons, want %d", test.name, len(got), len(test.want))
+			continue
+		}
+		for _, w := range test.want {
+			if _, ok := got[w]; !ok {
+				t.Errorf("%s: polygon %d not found", test.name, w)
+			}
+		}
+	}
+
+	err = db.Update(func(dbTx database.Tx) error {
+		root := dbTx.Metadata().Bucket(polygonIndexKey).Bucket(polygonBoxBucketName)
+		for i := range polygons {
+			p := &polygons[i]
+			if err := removePolygonBox(root, &p.hash, &p.box, 32); err != nil {
+				return err
+			}
+		}
+		buckets := 0
+		root.ForEachBucket(func(k []byte) error {
+			buckets++
+			return nil
+		})
+		if buckets != 0 {
+			t.Errorf("%d quadrants left after removing all polygons", buckets)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+}
@@ -0,0 +1,508 @@
+// Copyright (c) 2018-2021 The Omegasuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/omegasuite/btcd/database"
+	"github.com/omegasuite/btcd/wire"
+	"github.com/omegasuite/btcutil"
+	"github.com/omegasuite/omega/token"
+	"github.com/omegasuite/omega/viewpoint"
+)
+
+const (
+	// tokenIndexName is the human-readable name for the index.
+	tokenIndexName = "token registry index"
+
+	// contractNetID is the first byte of the pkScript of an output paid to
+	// a contract.
+	contractNetID = 0x88
+
+	// mintScriptSize is the size of the pkScript of the outputs a contract
+	// mints: the contract net id followed by the contract address.
+	mintScriptSize = 21
+
+	// tokenHolderSize is the size of the part of a pkScript identifying a
+	// holder: the net id followed by the address hash.
+	tokenHolderSize = 21
+
+	// tokenEntrySize is the size of a token entry: the issuing contract,
+	// the total minted, the circulating supply, the number of holders and
+	// the height of the first mint.
+	tokenEntrySize = 20 + 8 + 8 + 8 + 4
+)
+
+var (
+	// tokenIndexKey is the key of the token registry index and the db
+	// bucket used to house it.
+	tokenIndexKey = []byte("tokenregidx")
+
+	// tokenTypeBucketName is the name of the bucket mapping a token type
+	// to its entry.
+	tokenTypeBucketName = []byte("type")
+
+	// tokenIssuerBucketName is the name of the bucket mapping a token type
+	// and a height to the contract that became the issuer of the token type
+	// at that height. It allows the issuer to be restored when a block is
+	// disconnected.
+	tokenIssuerBucketName = []byte("issuer")
+
+	// tokenHolderBucketName is the name of the bucket mapping a token type
+	// and a holder to the number of unspent outputs of the token type the
+	// holder has.
+	tokenHolderBucketName = []byte("holder")
+)
+
+// TokenInfo is the registry entry of a token type issued by a contract.
+// Minted and Supply are amounts for numeric token types and numbers of
+// tokens for hash token types.
+type TokenInfo struct {
+	TokenType uint64
+	Issuer    [20]byte
+	Minted    uint64
+	Supply    uint64
+	Holders   uint64
+	Height    int32
+}
+
+func serializeTokenInfo(info *TokenInfo) []byte {
+	s := make([]byte, tokenEntrySize)
+	copy(s, info.Issuer[:])
+	binary.BigEndian.PutUint64(s[20:], info.Minted)
+	binary.BigEndian.PutUint64(s[28:], info.Supply)
+	binary.BigEndian.PutUint64(s[36:], info.Holders)
+	binary.BigEndian.PutUint32(s[44:], uint32(info.Height))
+	return s
+}
+
+func deserializeTokenInfo(tokenType uint64, s []byte) (*TokenInfo, error) {
+	if len(s) != tokenEntrySize {
+		return nil, errDeserialize("unexpected token entry size")
+	}
+	info := &TokenInfo{TokenType: tokenType}
+	copy(info.Issuer[:], s)
+	info.Minted = binary.BigEndian.Uint64(s[20:])
+	info.Supply = binary.BigEndian.Uint64(s[28:])
+	info.Holders = binary.BigEndian.Uint64(s[36:])
+	info.Height = int32(binary.BigEndian.Uint32(s[44:]))
+	return info, nil
+}
+
+func tokenTypeKey(tokenType uint64) []byte {
+	var key [8]byte
+	binary.BigEndian.PutUint64(key[:], tokenType)
+	return key[:]
+}
+
+// tokenAmount returns the amount an output of a token type adds to the
+// supply of the token type.
+func tokenAmount(tokenType uint64, value token.TokenValue) uint64 {
+	if tokenType&1 == 1 {
+		return 1
+	}
+	return uint64(value.(*token.NumToken).Val)
+}
+
+// TokenIndex implements a registry of the token types issued by contracts.
+// For each token type it keeps the contract issuing it, the total minted, the
+// circulating supply, that is the total of the unspent outputs, and the number
+// of holders of unspent outputs.
+//
+// Mints are recognized as the outputs following the separator in a coinbase
+// paid to a contract.
+type TokenIndex struct {
+	// The following fields are set when the instance is created and can't
+	// be changed afterwards, so there is no need to protect them with a
+	// separate mutex.
+	db database.DB
+}
+
+// Ensure the TokenIndex type implements the Indexer interface.
+var _ Indexer = (*TokenIndex)(nil)
+
+// Init is only provided to satisfy the Indexer interface as there is nothing
+// to initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *TokenIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *TokenIndex) Key() []byte {
+	return tokenIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *TokenIndex) Name() string {
+	return tokenIndexName
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the index
+// and its nested buckets.
+//
+// This is part of the Indexer interface.
+func (idx *TokenIndex) Create(dbTx database.Tx) error {
+	bucket, err := dbTx.Metadata().CreateBucket(tokenIndexKey)
+	if err != nil {
+		return err
+	}
+	for _, name := range [][]byte{tokenTypeBucketName,
+		tokenIssuerBucketName, tokenHolderBucketName} {
+		if _, err := bucket.CreateBucket(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tokenUpdate is the change to the index made by a block.
+type tokenUpdate struct {
+	bucket database.Bucket
+	infos  map[uint64]*TokenInfo
+}
+
+// info returns the entry of tokenType, or nil if the token type has not been
+// minted.
+func (u *tokenUpdate) info(tokenType uint64) (*TokenInfo, error) {
+	if info, ok := u.infos[tokenType]; ok {
+		return info, nil
+	}
+	s := u.bucket.Bucket(tokenTypeBucketName).Get(tokenTypeKey(tokenType))
+	if s == nil {
+		return nil, nil
+	}
+	info, err := deserializeTokenInfo(tokenType, s)
+	if err != nil {
+		return nil, err
+	}
+	u.infos[tokenType] = info
+	return info, nil
+}
+
+// holding changes the unspent outputs of the token type of info held by the
+// holder of pkScript by one up or down and counts the holders.
+func (u *tokenUpdate) holding(info *TokenInfo, pkScript []byte, up bool) error {
+	if len(pkScript) < tokenHolderSize {
+		return nil
+	}
+	holders := u.bucket.Bucket(tokenHolderBucketName)
+	key := append(tokenTypeKey(info.TokenType), pkScript[:tokenHolderSize]...)
+
+	var n uint64
+	if s := holders.Get(key); len(s) == 8 {
+		n = binary.BigEndian.Uint64(s)
+	}
+	switch {
+	case up:
+		n++
+		if n == 1 {
+			info.Holders++
+		}
+	case n == 0:
+		return AssertError(fmt.Sprintf("token 0x%x holder %x has no outputs",
+			info.TokenType, pkScript[:tokenHolderSize]))
+	default:
+		n--
+		if n == 0 {
+			info.Holders--
+			return holders.Delete(key)
+		}
+	}
+
+	var s [8]byte
+	binary.BigEndian.PutUint64(s[:], n)
+	return holders.Put(key, s[:])
+}
+
+// output adds an output of a token type to the supply of the token type and
+// the holdings of its holder, or removes it if up is not set. Outputs of token
+// types that have not been minted are ignored.
+func (u *tokenUpdate) output(tokenType uint64, value token.TokenValue, pkScript []byte, up bool) error {
+	info, err := u.info(tokenType)
+	if info == nil || err != nil {
+		return err
+	}
+	if up {
+		info.Supply += tokenAmount(tokenType, value)
+	} else {
+		info.Supply -= tokenAmount(tokenType, value)
+	}
+	return u.holding(info, pkScript, up)
+}
+
+// mint records a mint of txOut by a contract at height. The contract becomes
+// the issuer of the token type.
+func (u *tokenUpdate) mint(txOut *wire.TxOut, height int32) error {
+	info, err := u.info(txOut.TokenType)
+	if err != nil {
+		return err
+	}
+	if info == nil {
+		info = &TokenInfo{TokenType: txOut.TokenType, Height: height}
+		u.infos[txOut.TokenType] = info
+	} else if bytes.Equal(info.Issuer[:], txOut.PkScript[1:mintScriptSize]) {
+		info.Minted += tokenAmount(txOut.TokenType, txOut.Value)
+		return nil
+	}
+	info.Minted += tokenAmount(txOut.TokenType, txOut.Value)
+	copy(info.Issuer[:], txOut.PkScript[1:mintScriptSize])
+
+	var h [4]byte
+	binary.BigEndian.PutUint32(h[:], uint32(height))
+	key := append(tokenTypeKey(txOut.TokenType), h[:]...)
+	return u.bucket.Bucket(tokenIssuerBucketName).Put(key, info.Issuer[:])
+}
+
+// unmint reverses a mint of txOut at height. The token type is removed from
+// the registry when all its mints are reversed.
+func (u *tokenUpdate) unmint(txOut *wire.TxOut, height int32) error {
+	info, err := u.info(txOut.TokenType)
+	if info == nil || err != nil {
+		return err
+	}
+	info.Minted -= tokenAmount(txOut.TokenType, txOut.Value)
+
+	issuers := u.bucket.Bucket(tokenIssuerBucketName)
+	var h [4]byte
+	binary.BigEndian.PutUint32(h[:], uint32(height))
+	prefix := tokenTypeKey(txOut.TokenType)
+	if err := issuers.Delete(append(prefix, h[:]...)); err != nil {
+		return err
+	}
+
+	if info.Minted == 0 {
+		u.infos[txOut.TokenType] = nil
+		return nil
+	}
+
+	// restore the issuer before the block
+	c := issuers.Cursor()
+	for ok := c.Seek(prefix); ok && bytes.HasPrefix(c.Key(), prefix); ok = c.Next() {
+		copy(info.Issuer[:], c.Value())
+	}
+	return nil
+}
+
+// write stores the changed entries.
+func (u *tokenUpdate) write() error {
+	types := u.bucket.Bucket(tokenTypeBucketName)
+	for tokenType, info := range u.infos {
+		var err error
+		if info == nil {
+			err = types.Delete(tokenTypeKey(tokenType))
+		} else {
+			err = types.Put(tokenTypeKey(tokenType), serializeTokenInfo(info))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mintStart returns the index of the first output of the coinbase that may be
+// minted by a contract, that is the one following the first separator.
+func mintStart(coinbase *wire.MsgTx) int {
+	for i, txOut := range coinbase.TxOut {
+		if txOut.IsSeparator() {
+			return i + 1
+		}
+	}
+	return len(coinbase.TxOut)
+}
+
+// isMint returns whether txOut, an output of a coinbase following its
+// separator, is minted by a contract.
+func isMint(txOut *wire.TxOut) bool {
+	return len(txOut.PkScript) == mintScriptSize && txOut.PkScript[0] == contractNetID
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer records the mints of the block
+// and updates the supply and holders of the token types spent and paid by
+// the block.
+//
+// This is part of the Indexer interface.
+func (idx *TokenIndex) ConnectBlock(dbTx database.Tx, block *btcutil.Block,
+	stxos []viewpoint.SpentTxOut) error {
+	u := &tokenUpdate{
+		bucket: dbTx.Metadata().Bucket(tokenIndexKey),
+		infos:  make(map[uint64]*TokenInfo),
+	}
+
+	mints := mintStart(block.Transactions()[0].MsgTx())
+	stxoIndex := 0
+	for txIdx, tx := range block.Transactions() {
+		if txIdx != 0 {
+			for _, txIn := range tx.MsgTx().TxIn {
+				if txIn.PreviousOutPoint.Hash.IsEqual(&zerohash) {
+					continue
+				}
+				if stxoIndex >= len(stxos) {
+					return AssertError(fmt.Sprintf("missing spent output "+
+						"of %v", txIn.PreviousOutPoint))
+				}
+				stxo := &stxos[stxoIndex]
+				stxoIndex++
+				if err := u.output(stxo.TokenType, stxo.Amount, stxo.PkScript, false); err != nil {
+					return err
+				}
+			}
+		}
+
+		for i, txOut := range tx.MsgTx().TxOut {
+			if txOut.IsSeparator() || txOut.IsNopaying() {
+				continue
+			}
+			if txIdx == 0 && i >= mints && isMint(txOut) {
+				if err := u.mint(txOut, block.Height()); err != nil {
+					return err
+				}
+			}
+			if err := u.output(txOut.TokenType, txOut.Value, txOut.PkScript, true); err != nil {
+				return err
+			}
+		}
+	}
+	return u.write()
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer reverses the mints of the
+// block and the changes it made to the supply and holders of token types.
+//
+// This is part of the Indexer interface.
+func (idx *TokenIndex) DisconnectBlock(dbTx database.Tx, block *btcutil.Block,
+	stxos []viewpoint.SpentTxOut) error {
+	u := &tokenUpdate{
+		bucket: dbTx.Metadata().Bucket(tokenIndexKey),
+		infos:  make(map[uint64]*TokenInfo),
+	}
+
+	// the spent outputs are in the order of the inputs of the block, so
+	// work out which of them belongs to each transaction first.
+	txs := block.Transactions()
+	mints := mintStart(txs[0].MsgTx())
+	stxoStart := make([]int, len(txs))
+	stxoIndex := 0
+	for txIdx, tx := range txs {
+		stxoStart[txIdx] = stxoIndex
+		if txIdx == 0 {
+			continue
+		}
+		for _, txIn := range tx.MsgTx().TxIn {
+			if !txIn.PreviousOutPoint.Hash.IsEqual(&zerohash) {
+				stxoIndex++
+			}
+		}
+	}
+
+	for txIdx := len(txs) - 1; txIdx >= 0; txIdx-- {
+		tx := txs[txIdx]
+		for i := len(tx.MsgTx().TxOut) - 1; i >= 0; i-- {
+			txOut := tx.MsgTx().TxOut[i]
+			if txOut.IsSeparator() || txOut.IsNopaying() {
+				continue
+			}
+			if err := u.output(txOut.TokenType, txOut.Value, txOut.PkScript, false); err != nil {
+				return err
+			}
+			if txIdx == 0 && i >= mints && isMint(txOut) {
+				if err := u.unmint(txOut, block.Height()); err != nil {
+					return err
+				}
+			}
+		}
+
+		if txIdx == 0 {
+			continue
+		}
+		stxoIndex = stxoStart[txIdx]
+		for _, txIn := range tx.MsgTx().TxIn {
+			if txIn.PreviousOutPoint.Hash.IsEqual(&zerohash) {
+				continue
+			}
+			if stxoIndex >= len(stxos) {
+				return AssertError(fmt.Sprintf("missing spent output "+
+					"of %v", txIn.PreviousOutPoint))
+			}
+			stxo := &stxos[stxoIndex]
+			stxoIndex++
+			if err := u.output(stxo.TokenType, stxo.Amount, stxo.PkScript, true); err != nil {
+				return err
+			}
+		}
+	}
+	return u.write()
+}
+
+// Token returns the registry entry of tokenType, or nil if no contract has
+// minted it.
+func (idx *TokenIndex) Token(tokenType uint64) (*TokenInfo, error) {
+	var info *TokenInfo
+	err := idx.db.View(func(dbTx database.Tx) error {
+		s := dbTx.Metadata().Bucket(tokenIndexKey).Bucket(tokenTypeBucketName).Get(tokenTypeKey(tokenType))
+		if s == nil {
+			return nil
+		}
+		var err error
+		info, err = deserializeTokenInfo(tokenType, s)
+		return err
+	})
+	return info, err
+}
+
+// Tokens returns the registry entries of the token types minted by contracts
+// in the order of their types, skipping the first skip entries and returning
+// at most count of them if count is positive.
+func (idx *TokenIndex) Tokens(skip, count int) ([]TokenInfo, error) {
+	var infos []TokenInfo
+	err := idx.db.View(func(dbTx database.Tx) error {
+		c := dbTx.Metadata().Bucket(tokenIndexKey).Bucket(tokenTypeBucketName).Cursor()
+		for ok := c.First(); ok && (count <= 0 || len(infos) < count); ok = c.Next() {
+			if skip > 0 {
+				skip--
+				continue
+			}
+			if len(c.Key()) != 8 {
+				return errDeserialize("unexpected token type size")
+			}
+			info, err := deserializeTokenInfo(binary.BigEndian.Uint64(c.Key()), c.Value())
+			if err != nil {
+				return err
+			}
+			infos = append(infos, *info)
+		}
+		return nil
+	})
+	return infos, err
+}
+
+// NewTokenIndex returns a new instance of an indexer that is used to create a
+// registry of the token types issued by contracts with their supply.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewTokenIndex(db database.DB) *TokenIndex {
+	return &TokenIndex{db: db}
+}
+
+// DropTokenIndex drops the token registry index from the provided database if
+// it exists.
+func DropTokenIndex(db database.DB, interrupt <-chan struct{}) error {
+	return dropIndex(db, tokenIndexKey, tokenIndexName, interrupt)
+}
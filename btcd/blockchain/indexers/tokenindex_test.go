@@ -0,0 +1,180 @@
+// Copyright (c) 2018-2021 The Omegasuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/omegasuite/btcd/database"
+	_ "github.com/omegasuite/btcd/database/ffldb"
+	"github.com/omegasuite/btcd/wire"
+	"github.com/omegasuite/btcd/wire/common"
+	"github.com/omegasuite/btcutil"
+	"github.com/omegasuite/omega/token"
+	"github.com/omegasuite/omega/viewpoint"
+)
+
+// testPayee returns the pkScript of a pay to public key hash output to an
+// address whose hash starts with id.
+func testPayee(id byte) []byte {
+	s := make([]byte, 25)
+	s[1] = id
+	s[21] = 0x41
+	return s
+}
+
+// testContract returns the pkScript of the outputs minted by a contract whose
+// address starts with id.
+func testContract(id byte) []byte {
+	s := make([]byte, mintScriptSize)
+	s[0] = contractNetID
+	s[1] = id
+	return s
+}
+
+// testCoinbase returns a coinbase with a reward and the given mints.
+func testCoinbase(height int32, mints ...*wire.TxOut) *wire.MsgTx {
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&zerohash, uint32(height)), 0))
+	tx.AddTxOut(wire.NewTxOut(0, &token.NumToken{Val: 50}, nil, testPayee(0xFF)))
+	if len(mints) > 0 {
+		tx.AddTxOut(&wire.TxOut{Token: token.Token{TokenType: token.DefTypeSeparator}})
+		for _, m := range mints {
+			tx.AddTxOut(m)
+		}
+	}
+	return tx
+}
+
+func testBlock(height int32, txs ...*wire.MsgTx) *btcutil.Block {
+	msg := wire.NewMsgBlock(&wire.BlockHeader{})
+	for _, tx := range txs {
+		msg.AddTransaction(tx)
+	}
+	block := btcutil.NewBlock(msg)
+	block.SetHeight(height)
+	return block
+}
+
+// TestTokenIndex ensures mints, transfers and issuer changes are reflected in
+// the registry and undone when their blocks are disconnected.
+func TestTokenIndex(t *testing.T) {
+	dbPath := filepath.Join(os.TempDir(), "tokenidx")
+	_ = os.RemoveAll(dbPath)
+	db, err := database.Create("ffldb", dbPath, common.MainNet)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+	defer db.Close()
+
+	idx := NewTokenIndex(db)
+	if err := db.Update(idx.Create); err != nil {
+		t.Fatalf("Create index: %v", err)
+	}
+
+	const tokenType = 0x100
+	mint := wire.NewTxOut(tokenType, &token.NumToken{Val: 1000}, nil, testContract(1))
+	coinbase1 := testCoinbase(1, mint)
+	block1 := testBlock(1, coinbase1)
+
+	// the contract pays 600 to A and 400 to B
+	mintHash := coinbase1.TxHash()
+	transfer := wire.NewMsgTx(wire.TxVersion)
+	transfer.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&mintHash, 2), 0))
+	transfer.AddTxOut(wire.NewTxOut(tokenType, &token.NumToken{Val: 600}, nil, testPayee(0xA)))
+	transfer.AddTxOut(wire.NewTxOut(tokenType, &token.NumToken{Val: 400}, nil, testPayee(0xB)))
+	block2 := testBlock(2, testCoinbase(2), transfer)
+	stxos2 := []viewpoint.SpentTxOut{{
+		TokenType: tokenType,
+		Amount:    mint.Value,
+		PkScript:  mint.PkScript,
+		Height:    1,
+	}}
+
+	// another contract takes over the token type and mints 200
+	block3 := testBlock(3, testCoinbase(3,
+		wire.NewTxOut(tokenType, &token.NumToken{Val: 200}, nil, testContract(2))))
+
+	blocks := []struct {
+		block  *btcutil.Block
+		stxos  []viewpoint.SpentTxOut
+		issuer byte
+		minted uint64
+		supply uint64
+		holder uint64
+	}{
+		{block1, nil, 1, 1000, 1000, 1},
+		{block2, stxos2, 1, 1000, 1000, 2},
+		{block3, nil, 2, 1200, 1200, 3},
+	}
+
+	check := func(stage string, issuer byte, minted, supply, holders uint64) {
+		info, err := idx.Token(tokenType)
+		if err != nil {
+			t.Fatalf("%s: Token: %v", stage, err)
+		}
+		if info == nil {
+			t.Fatalf("%s: token not found", stage)
+		}
+		if info.Issuer[0] != issuer || info.Minted != minted || info.Supply != supply ||
+			info.Holders != holders || info.Height != 1 {
+			t.Errorf("%s: issuer %x, minted %d, supply %d, holders %d, height %d, "+
+				"want %x, %d, %d, %d, 1", stage, info.Issuer[0], info.Minted,
+				info.Supply, info.Holders, info.Height, issuer, minted, supply, holders)
+		}
+	}
+
+	for i, b := range blocks {
+		err := db.Update(func(dbTx database.Tx) error {
+			return idx.ConnectBlock(dbTx, b.block, b.stxos)
+		})
+		if err != nil {
+			t.Fatalf("ConnectBlock %d: %v", i+1, err)
+		}
+		check("connect", b.issuer, b.minted, b.supply, b.holder)
+	}
+
+	infos, err := idx.Tokens(0, 0)
+	if err != nil {
+		t.Fatalf("Tokens: %v", err)
+	}
+	if len(infos) != 1 || infos[0].TokenType != tokenType {
+		t.Errorf("Tokens returned %v, want only token type 0x%x", infos, tokenType)
+	}
+
+	for i := len(blocks) - 1; i >= 0; i-- {
+		b := blocks[i]
+		err := db.Update(func(dbTx database.Tx) error {
+			return idx.DisconnectBlock(dbTx, b.block, b.stxos)
+		})
+		if err != nil {
+			t.Fatalf("DisconnectBlock %d: %v", i+1, err)
+		}
+		if i > 0 {
+			p := blocks[i-1]
+			check("disconnect", p.issuer, p.minted, p.supply, p.holder)
+		}
+	}
+
+	if info, err := idx.Token(tokenType); info != nil || err != nil {
+		t.Errorf("token left after disconnecting all blocks: %v, %v", info, err)
+	}
+	err = db.View(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(tokenIndexKey)
+		for _, name := range [][]byte{tokenIssuerBucketName, tokenHolderBucketName} {
+			bucket.Bucket(name).ForEach(func(k, v []byte) error {
+				t.Errorf("%s entry %x left after disconnecting all blocks", name, k)
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("View: %v", err)
+	}
+}
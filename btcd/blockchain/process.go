@@ -401,12 +401,12 @@ func (b *BlockChain) ProcessBlock(block *btcutil.Block, flags BehaviorFlags) (bo
 	} else {
 		switch {
 		case block.MsgBlock().Header.Nonce == -1:
-			if prevNode.Data.GetNonce() > -wire.MINER_RORATE_FREQ && prevNode.Data.GetNonce() < 0 {
+			if prevNode.Data.GetNonce() > -b.RotateFreq(prevNode) && prevNode.Data.GetNonce() < 0 {
 				return isMainChain, false, fmt.Errorf("Bad nonce sequence"), -1, nil
 			}
 
-		case block.MsgBlock().Header.Nonce < -wire.MINER_RORATE_FREQ:
-			if 1 - wire.MINER_RORATE_FREQ != prevNode.Data.GetNonce() {
+		case block.MsgBlock().Header.Nonce < -b.RotateFreq(prevNode):
+			if 1 - b.RotateFreq(prevNode) != prevNode.Data.GetNonce() {
 				return isMainChain, false, fmt.Errorf("Bad nonce sequence"), -1, nil
 			}
 		}
@@ -435,7 +435,7 @@ func (b *BlockChain) ProcessBlock(block *btcutil.Block, flags BehaviorFlags) (bo
 				case p.Data.GetNonce() > 0:
 					rotate -= wire.POWRotate
 
-				case p.Data.GetNonce() <= -wire.MINER_RORATE_FREQ:
+				case p.Data.GetNonce() <= -b.RotateFreq(p):
 					rotate--
 				}
 			}
@@ -451,8 +451,13 @@ func (b *BlockChain) ProcessBlock(block *btcutil.Block, flags BehaviorFlags) (bo
 				rt := rotate
 				if _,ok := signers[name]; ok {
 					// double signer
+					committee, err := b.Committee(int32(rotate))
+					if err != nil {
+						log.Infof("Unable to find the double signer: %s", err.Error())
+						continue
+					}
 					mb,_ := b.Miners.BlockByHeight(int32(rt))
-					for i := 0; i < wire.CommitteeSize; i++ {
+					for i := int32(0); i < committee.Size; i++ {
 						if mb.MsgBlock().Miner == name {
 							b.Miners.DSReport(&wire.Violations{
 								Height: block.Height(),				// Height of Tx blocks
@@ -485,14 +490,15 @@ func (b *BlockChain) ProcessBlock(block *btcutil.Block, flags BehaviorFlags) (bo
 
 func (b *BlockChain) consistent(block *btcutil.Block, parent * chainutil.BlockNode) bool {
 //	state := b.BestSnapshot()
-	if block.MsgBlock().Header.Nonce <= -wire.MINER_RORATE_FREQ {
+	committee := b.ChainParams.Committee(block.MsgBlock().Header.Version)
+	if block.MsgBlock().Header.Nonce <= -committee.RotateFreq {
 		mstate := b.Miners.BestSnapshot()
-		if -block.MsgBlock().Header.Nonce - wire.MINER_RORATE_FREQ > mstate.Height {
+		if -block.MsgBlock().Header.Nonce - committee.RotateFreq > mstate.Height {
 			return false
 		}
 	}
 
-	if wire.CommitteeSize == 1 || block.MsgBlock().Header.Nonce > 0 {
+	if committee.Size == 1 || block.MsgBlock().Header.Nonce > 0 {
 		return true
 	}
 
@@ -512,7 +518,7 @@ func (b *BlockChain) consistent(block *btcutil.Block, parent * chainutil.BlockNo
 			case p.Data.GetNonce() > 0:
 				rotate -= wire.POWRotate
 
-			case p.Data.GetNonce() <= -wire.MINER_RORATE_FREQ:
+			case p.Data.GetNonce() <= -b.RotateFreq(p):
 				rotate--
 			}
 		}
@@ -521,7 +527,7 @@ func (b *BlockChain) consistent(block *btcutil.Block, parent * chainutil.BlockNo
 			case p.Data.GetNonce() > 0:
 				rotate += wire.POWRotate
 
-			case p.Data.GetNonce() <= -wire.MINER_RORATE_FREQ:
+			case p.Data.GetNonce() <= -b.RotateFreq(p):
 				rotate++
 			}
 		}
@@ -530,7 +536,7 @@ func (b *BlockChain) consistent(block *btcutil.Block, parent * chainutil.BlockNo
 	// examine signers are in committee
 	miners := make(map[[20]byte]struct{})
 
-	for i := int32(0); i < wire.CommitteeSize; i++ {
+	for i := int32(0); i < committee.Size; i++ {
 		blk, _ := b.Miners.BlockByHeight(int32(rotate) - i)
 		if blk == nil {
 			return false
@@ -8,7 +8,6 @@ import (
 	"bytes"
 	"github.com/omegasuite/btcd/btcec"
 	"github.com/omegasuite/btcd/database"
-	"github.com/omegasuite/btcd/wire"
 	"github.com/omegasuite/btcutil"
 	"time"
 )
@@ -137,7 +136,12 @@ func (b *BlockChain) TphNotice(t *Notification) {
 
 		h := uint32(block.Height())
 		rot := b.Rotation(block.MsgBlock().Header.PrevBlock)
-		if rot <= wire.CommitteeSize {
+		committee, err := b.Committee(rot)
+		if err != nil {
+			return
+		}
+		size := committee.Size
+		if rot <= size {
 			return
 		}
 
@@ -147,16 +151,16 @@ func (b *BlockChain) TphNotice(t *Notification) {
 				return
 			}
 
-			punishable := make([][20]uint8, 0, wire.CommitteeSize)
+			punishable := make([][20]uint8, 0, size)
 
-			if prev.Data.GetNonce() < -wire.MINER_RORATE_FREQ {
+			if prev.Data.GetNonce() < -b.RotateFreq(prev) {
 				// if stall immediately after a rotation, we blame the new committee member
-				mb, _ := b.Miners.BlockByHeight(-prev.Data.GetNonce() - wire.MINER_RORATE_FREQ)
+				mb, _ := b.Miners.BlockByHeight(-prev.Data.GetNonce() - b.RotateFreq(prev))
 				punishable = append(punishable, mb.MsgBlock().Miner)
 			} else {
 				// if interrupted by a POW node, it mean the committee is stalling.
 				// all members gets lowest score as punishment
-				for i := 0; i < wire.CommitteeSize; i++ {
+				for i := int32(0); i < size; i++ {
 					mb, _ := b.Miners.BlockByHeight(rot)
 					rot--
 
@@ -177,7 +181,7 @@ func (b *BlockChain) TphNotice(t *Notification) {
 			return
 		}
 
-		for i := 0; i < wire.CommitteeSize; i++ {
+		for i := int32(0); i < size; i++ {
 			mb,_ := b.Miners.BlockByHeight(rot)
 			rot--
 
@@ -7,7 +7,6 @@ package blockchain
 
 import (
 	"bytes"
-	"encoding/binary"
 	"encoding/hex"
 	"fmt"
 	"github.com/omegasuite/btcd/blockchain/chainutil"
@@ -27,6 +26,7 @@ import (
 	//	"sort"
 	"github.com/omegasuite/omega/validate"
 	"github.com/omegasuite/omega/viewpoint"
+	"github.com/omegasuite/omega/signer"
 )
 
 const (
@@ -325,8 +325,8 @@ func (b * BlockChain) Rotation(hash chainhash.Hash) int32 {
 		case p.Data.GetNonce() > 0:
 			rotate -= wire.POWRotate
 
-		case p.Data.GetNonce() <= -wire.MINER_RORATE_FREQ:
-			rotate = -(p.Data.GetNonce() + wire.MINER_RORATE_FREQ) - 1
+		case p.Data.GetNonce() <= -b.RotateFreq(p):
+			rotate = -(p.Data.GetNonce() + b.RotateFreq(p)) - 1
 		}
 	}
 	if p == nil {
@@ -373,7 +373,7 @@ func (b *BlockChain) checkProofOfWork(block *btcutil.Block, parent * chainutil.B
 			case p.Data.GetNonce() > 0:
 				rotate -= wire.POWRotate
 
-			case p.Data.GetNonce() <= -wire.MINER_RORATE_FREQ:
+			case p.Data.GetNonce() <= -b.RotateFreq(p):
 				rotate--
 			}
 		}
@@ -382,7 +382,7 @@ func (b *BlockChain) checkProofOfWork(block *btcutil.Block, parent * chainutil.B
 			case p.Data.GetNonce() > 0:
 				rotate += wire.POWRotate
 
-			case p.Data.GetNonce() <= -wire.MINER_RORATE_FREQ:
+			case p.Data.GetNonce() <= -b.RotateFreq(p):
 				rotate++
 			}
 		}
@@ -419,10 +419,10 @@ func (b *BlockChain) checkProofOfWork(block *btcutil.Block, parent * chainutil.B
 				s, _ := b.Miners.BlockByHeight(int32(best.LastRotation))
 				blk := b.NodeByHash(&s.MsgBlock().BestBlock)
 
-				for blk != nil && blk.Data.GetNonce() > -wire.MINER_RORATE_FREQ {
+				for blk != nil && blk.Data.GetNonce() > -b.RotateFreq(blk) {
 					blk = blk.Parent
 				}
-				pows := int32(best.LastRotation) + (blk.Data.GetNonce() + wire.MINER_RORATE_FREQ) - wire.DESIRABLE_MINER_CANDIDATES
+				pows := int32(best.LastRotation) + (blk.Data.GetNonce() + b.RotateFreq(blk)) - b.Committee(int32(best.LastRotation)).DesirableCandidates
 				if pows < 0 {
 					pows = 0
 				}
@@ -441,6 +441,7 @@ func (b *BlockChain) checkProofOfWork(block *btcutil.Block, parent * chainutil.B
 		}
 
 		pnonce := parent.Data.GetNonce()
+		params := b.ChainParams.Committee(header.Version)
 
 		// examine nonce
 		if pnonce > 0 {
@@ -450,15 +451,15 @@ func (b *BlockChain) checkProofOfWork(block *btcutil.Block, parent * chainutil.B
 			}
 		} else {
 			switch {
-			case pnonce == -wire.MINER_RORATE_FREQ+1:
+			case pnonce == -b.RotateFreq(parent)+1:
 				// this is a rotation block, nonce must be -(height of next Miner block)
-				if header.Nonce != - int32(rotate+1+wire.MINER_RORATE_FREQ) {
-						//					str := fmt.Sprintf("The this is a rotation block, nonce %d must be height of next Miner block %d.", -header.Nonce, rotate + 1 + wire.MINER_RORATE_FREQ)
-						return fmt.Errorf("The this is a rotation block, nonce %d must be height of next Miner block %d.", -header.Nonce, rotate+1+wire.MINER_RORATE_FREQ), true
+				if header.Nonce != -(int32(rotate+1) + params.RotateFreq) {
+						//					str := fmt.Sprintf("The this is a rotation block, nonce %d must be height of next Miner block %d.", -header.Nonce, int32(rotate + 1) + params.RotateFreq)
+						return fmt.Errorf("The this is a rotation block, nonce %d must be height of next Miner block %d.", -header.Nonce, int32(rotate+1)+params.RotateFreq), true
 						// ruleError(ErrHighHash, str)
 					}
 
-			case pnonce <= -wire.MINER_RORATE_FREQ:
+			case pnonce <= -b.RotateFreq(parent):
 				// previous block is a rotation block, this block none must be -1
 				if header.Nonce != -1 {
 						//					str := fmt.Sprintf("Previous block is a rotation block, this block nonce must be -1.")
@@ -474,11 +475,11 @@ func (b *BlockChain) checkProofOfWork(block *btcutil.Block, parent * chainutil.B
 			}
 		}
 
-		if wire.CommitteeSize > 1 && flags&(BFNoConnect|BFSubmission) != 0 {
+		if params.Size > 1 && flags&(BFNoConnect|BFSubmission) != 0 {
 			return fmt.Errorf("Unexpected flags"), true
 		}
 
-		if len(block.MsgBlock().Transactions[0].SignatureScripts) <= wire.CommitteeSigs {
+		if len(block.MsgBlock().Transactions[0].SignatureScripts) <= int(params.Sigs) {
 			return fmt.Errorf("Insufficient signature"), false
 		}
 		if len(block.MsgBlock().Transactions[0].SignatureScripts[1]) < btcec.PubKeyBytesLenCompressed {
@@ -502,7 +503,7 @@ func (b *BlockChain) checkProofOfWork(block *btcutil.Block, parent * chainutil.B
 			awardto[tw] = struct{}{}
 		}
 
-		if len(awardto) != wire.CommitteeSize && block.MsgBlock().Header.Version < chaincfg.Version2 {
+		if len(awardto) != int(params.Size) && block.MsgBlock().Header.Version < chaincfg.Version2 {
 			return fmt.Errorf("Version error."), false
 		}
 
@@ -515,18 +516,17 @@ func (b *BlockChain) checkProofOfWork(block *btcutil.Block, parent * chainutil.B
 		var imin = false
 		var meme btcutil.Address
 
-		var mbs [3]*wire.MinerBlock
+		mbs := make([]*wire.MinerBlock, params.Size)
 
-		for i := rotate - wire.CommitteeSize + 1; i <= rotate; i++ {
-			mb, _ := b.Miners.BlockByHeight(int32(i))
+		for i := range mbs {
+			mb, _ := b.Miners.BlockByHeight(int32(rotate) - params.Size + int32(i) + 1)
 			if mb == nil {
 				return nil, true
 			}
-			mbs[i - (rotate - wire.CommitteeSize + 1)] = mb
+			mbs[i] = mb
 		}
 
-		for i := rotate - wire.CommitteeSize + 1; i <= rotate; i++ {
-			mb := mbs[i - (rotate - wire.CommitteeSize + 1)]
+		for _, mb := range mbs {
 			if _,err := b.CheckCollateral(mb, &parent.Hash, BFNone); err != nil {
 				if _,ok := awardto[mb.MsgBlock().Miner]; ok {
 					return fmt.Errorf("Coinbase award to miner with insufficient collateral."), false
@@ -555,7 +555,7 @@ func (b *BlockChain) checkProofOfWork(block *btcutil.Block, parent * chainutil.B
 			return nil, true
 		}
 
-		tbr := make([]int, 0, 3)
+		tbr := make([]int, 0, params.Size)
 
 		for k, sign := range block.MsgBlock().Transactions[0].SignatureScripts[1:] {
 			signer, err := btcutil.VerifySigScript(sign, hash, b.ChainParams)
@@ -580,7 +580,7 @@ func (b *BlockChain) checkProofOfWork(block *btcutil.Block, parent * chainutil.B
 			append(block.MsgBlock().Transactions[0].SignatureScripts[:tbr[k]], block.MsgBlock().Transactions[0].SignatureScripts[tbr[k]+1:]...)
 		}
 
-		if nsigned < wire.CommitteeSigs {
+		if nsigned < int(params.Sigs) {
 			return fmt.Errorf("Insufficient number of Miner signatures."), false
 		}
 	}
@@ -654,14 +654,7 @@ func CountSigOps(tx *btcutil.Tx) int {
 }
 
 func MakeMinerSigHash(height int32, hash chainhash.Hash) []byte {
-	s1 := "Omega chain Miner block "
-	s2 := " at height "
-	lenth := 36 + len(s1) + len(s2)
-	t := make([]byte, lenth)
-	copy(t[:], []byte(s1))
-	copy(t[len(s1):], hash[:])
-	binary.LittleEndian.PutUint32(t[len(s1)+32:], uint32(height))
-	return chainhash.DoubleHashB(t[:])
+	return signer.BlockSigHash(height, hash)
 }
 
 // checkBlockHeaderSanity performs some preliminary checks on a block header to
@@ -752,6 +745,10 @@ func checkBlockSanity(block *btcutil.Block, powLimit *big.Int, timeSource chainu
 		if err != nil {
 			return err
 		}
+
+		if err := validate.CheckDefinitionsVersion(tx.MsgTx(), block.MsgBlock().Header.Version); err != nil {
+			return err
+		}
 	}
 
 	// There are two hashes in a full block. One in header and one as coinbase's first
@@ -836,7 +833,7 @@ func (b *BlockChain) checkBlockHeaderContext(header *wire.BlockHeader, prevNode
 	} else {
 		dr := int32(0)
 		p := prevNode
-		for ; p != nil && p.Data.GetNonce() > -wire.MINER_RORATE_FREQ; p = p.Parent {
+		for ; p != nil && p.Data.GetNonce() > -b.RotateFreq(p); p = p.Parent {
 			if p.Data.GetNonce() > 0 {
 				dr += wire.POWRotate
 			}
@@ -844,7 +841,7 @@ func (b *BlockChain) checkBlockHeaderContext(header *wire.BlockHeader, prevNode
 		if p == nil {
 			rotate = dr
 		} else {
-			rotate = -p.Data.GetNonce() - wire.MINER_RORATE_FREQ + dr
+			rotate = -p.Data.GetNonce() - b.RotateFreq(p) + dr
 		}
 	}
 
@@ -1143,7 +1140,7 @@ func CheckAdditionalTransactionInputs(tx *btcutil.Tx, txHeight int32, views * vi
 	return nil
 }
 
-func CheckAdditionalDefinitions(tx *btcutil.Tx, txHeight int32, views * viewpoint.ViewPointSet, chainParams *chaincfg.Params) error {
+func CheckAdditionalDefinitions(tx *btcutil.Tx, txHeight int32, version uint32, views * viewpoint.ViewPointSet, chainParams *chaincfg.Params) error {
 	// check definitions in TxOuts
 	// recheck CheckDefinitions
 
@@ -1190,7 +1187,7 @@ func CheckAdditionalDefinitions(tx *btcutil.Tx, txHeight int32, views * viewpoin
 					return ruleError(1, str)
 				}
 			}
-			if !views.AddRight(rt.(*token.RightDef)) {
+			if !views.AddRight(rt.(*token.RightDef), version) {
 				str := fmt.Sprintf("Invalid right definition in tx %s.", tx.MsgTx().TxHash().String())
 				return ruleError(1, str)
 			}
@@ -1296,6 +1293,10 @@ func CheckTransactionIntegrity(tx *btcutil.Tx,  views * viewpoint.ViewPointSet,
 		return nil
 	}
 
+	if err := validate.CheckDefinitionsVersion(tx.MsgTx(), version); err != nil {
+		return err
+	}
+
 	// Check numeric token w/ rights. If no new geometry is introduced, we can
 	// make quick check geometry too by treating them as numeric token.
 
@@ -1374,7 +1375,7 @@ func CheckTransactionIntegrity(tx *btcutil.Tx,  views * viewpoint.ViewPointSet,
 	}
 
 	// check geometry integrity
-	if !validate.CheckGeometryIntegrity(tx, views) {
+	if !validate.CheckGeometryIntegrity(tx, views, version) {
 		str := fmt.Sprintf("The Tx is not geometrically integral")
 		return ruleError(ErrSpendTooHigh, str)
 	}
@@ -1685,7 +1686,7 @@ func (b *BlockChain) checkConnectBlock(node *chainutil.BlockNode, block *btcutil
 
 	for i, tx := range transactions[1:] {
 		if runScripts {
-			err = ovm.VerifySigs(tx, b.ChainParams, 0, views)
+			err = ovm.VerifySigs(tx, b.ChainParams, 0, views, block.MsgBlock().Header.Version)
 			if err != nil {
 				return err
 			}
@@ -1726,6 +1727,7 @@ func (b *BlockChain) checkConnectBlock(node *chainutil.BlockNode, block *btcutil
 
 	views.Rights = viewpoint.NewRightViewpoint()
 	views.Polygon = viewpoint.NewPolygonViewpoint()
+	views.Polyhedron = viewpoint.NewPolyhedronViewpoint()
 	views.Border = viewpoint.NewBorderViewpoint()
 
 	err = CheckTransactionInputs(oldcoinBase, node.Height, views, b.ChainParams)
@@ -1733,7 +1735,7 @@ func (b *BlockChain) checkConnectBlock(node *chainutil.BlockNode, block *btcutil
 		return err
 	}
 
-	err = CheckAdditionalDefinitions(oldcoinBase, node.Height, views, b.ChainParams)
+	err = CheckAdditionalDefinitions(oldcoinBase, node.Height, block.MsgBlock().Header.Version, views, b.ChainParams)
 	if err != nil {
 		return err
 	}
@@ -1754,7 +1756,7 @@ func (b *BlockChain) checkConnectBlock(node *chainutil.BlockNode, block *btcutil
 			return err
 		}
 
-		err = CheckAdditionalDefinitions(tx, node.Height, views, b.ChainParams)
+		err = CheckAdditionalDefinitions(tx, node.Height, block.MsgBlock().Header.Version, views, b.ChainParams)
 		if err != nil {
 			return err
 		}
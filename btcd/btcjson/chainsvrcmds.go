@@ -81,6 +81,12 @@ type Polygon struct {
 	Loops	[][]string  `json:"loops"`
 }
 
+type Polyhedron struct {
+	Polygon string  `json:"polygon"`
+	Floor int32  `json:"floor"`
+	Ceiling int32  `json:"ceiling"`
+}
+
 type Right struct {
 	Father string  `json:"father"`
 	Desc string  `json:"desc"`
@@ -145,6 +151,22 @@ func (t * Definition) ConvertTo() token.Definition {
 		}
 		return &t
 
+	case token.DefTypePolyhedron:
+		p := Polyhedron{}
+		if err := mapstructure.Decode(t.DefData, &p); err != nil {
+			return nil
+		}
+		h,err := chainhash.NewHashFromStr(p.Polygon)
+		if err != nil {
+			h = &chainhash.Hash{}
+			copy(h[:], p.Polygon[:])
+		}
+		t := token.NewPolyhedronDef(*h, p.Floor, p.Ceiling)
+		if t == nil {
+			return nil
+		}
+		return t
+
 	case token.DefTypeRight:
 		r := Right{}
 		if err := mapstructure.Decode(t.DefData, &r); err != nil {
@@ -309,17 +331,23 @@ type GetBlockCmd struct {
 	VerboseTx *bool `jsonrpcdefault:"false"`
 }
 
+// SearchBorderCmd defines the searchborder JSON-RPC command. The box is given
+// in WGS84 degrees: Left and Right are longitudes, Bottom and Top latitudes.
 type SearchBorderCmd struct {
-	Left int32
-	Right int32
-	Bottom int32
-	Top int32
+	Left float64
+	Right float64
+	Bottom float64
+	Top float64
 	Lod byte
 }
 
+// ContractCallCmd defines the contractcall JSON-RPC command. Input is either
+// the call data in hex or a method signature such as "minted()uint64", in
+// which case Args are the arguments of the method.
 type ContractCallCmd struct {
 	Contract string
 	Input string
+	Args *[]interface{}
 }
 
 type GetBlockTxHashesCmd struct {
@@ -377,14 +405,22 @@ func NewGetBlockTxHasesCmd(hash string) *GetBlockTxHashesCmd {
 	}
 }
 
-func NewContractCallCmd(contract, input string) *ContractCallCmd {
+// NewContractCallCmd returns a new instance which can be used to issue a
+// contractcall JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewContractCallCmd(contract, input string, args *[]interface{}) *ContractCallCmd {
 	return &ContractCallCmd{
 		Contract: contract,
 		Input: input,
+		Args: args,
 	}
 }
 
-func NewSearchBorderCmd(left, right, bottom, top int32, lod byte) *SearchBorderCmd {
+// NewSearchBorderCmd returns a new instance which can be used to issue a
+// searchborder JSON-RPC command.
+func NewSearchBorderCmd(left, right, bottom, top float64, lod byte) *SearchBorderCmd {
 	return &SearchBorderCmd{
 		Left: left,
 		Right: right,
@@ -794,6 +830,28 @@ func NewListUtxosCmd(begin * int32, run * uint32, m * int64) *ListUtxosCmd {
 	}
 }
 
+// GetBalancesCmd defines the getbalances JSON-RPC command.
+type GetBalancesCmd struct {
+	Address string
+}
+
+// NewGetBalancesCmd returns a new instance which can be used to issue a
+// getbalances JSON-RPC command.
+func NewGetBalancesCmd(address string) *GetBalancesCmd {
+	return &GetBalancesCmd{
+		Address: address,
+	}
+}
+
+// GetGeometryPruneInfoCmd defines the getgeometrypruneinfo JSON-RPC command.
+type GetGeometryPruneInfoCmd struct{}
+
+// NewGetGeometryPruneInfoCmd returns a new instance which can be used to issue
+// a getgeometrypruneinfo JSON-RPC command.
+func NewGetGeometryPruneInfoCmd() *GetGeometryPruneInfoCmd {
+	return &GetGeometryPruneInfoCmd{}
+}
+
 // GetDefineCmd defines the GetDefine JSON-RPC command.
 type GetDefineCmd struct {
 	Kind           uint32
@@ -814,6 +872,26 @@ func NewGetDefineCmd(kind uint32, hash string, recursive bool) *GetDefineCmd {
 	}
 }
 
+// GetRightTreeCmd defines the getrighttree JSON-RPC command. Descendants
+// deeper than Depth levels below the right are not returned unless Depth is
+// 0.
+type GetRightTreeCmd struct {
+	Hash  string
+	Depth *int32 `jsonrpcdefault:"0"`
+}
+
+// NewGetRightTreeCmd returns a new instance which can be used to issue a
+// getrighttree JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetRightTreeCmd(hash string, depth *int32) *GetRightTreeCmd {
+	return &GetRightTreeCmd{
+		Hash:  hash,
+		Depth: depth,
+	}
+}
+
 // GetTxOutProofCmd defines the gettxoutproof JSON-RPC command.
 type GetTxOutProofCmd struct {
 	TxIDs     []string
@@ -962,9 +1040,46 @@ func NewTokenAddressCmd(tokentype uint64) *TokenAddressCmd {
 	}
 }
 
-// TryContractCmd defines the trycontract JSON-RPC command.
+// ListTokensCmd defines the listtokens JSON-RPC command.
+type ListTokensCmd struct {
+	Skip  *int `jsonrpcdefault:"0"`
+	Count *int `jsonrpcdefault:"100"`
+}
+
+// NewListTokensCmd returns a new instance which can be used to issue a
+// listtokens JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewListTokensCmd(skip, count *int) *ListTokensCmd {
+	return &ListTokensCmd{
+		Skip:  skip,
+		Count: count,
+	}
+}
+
+// GetTokenInfoCmd defines the gettokeninfo JSON-RPC command.
+type GetTokenInfoCmd struct {
+	TokenType uint64
+}
+
+// NewGetTokenInfoCmd returns a new instance which can be used to issue a
+// gettokeninfo JSON-RPC command.
+func NewGetTokenInfoCmd(tokentype uint64) *GetTokenInfoCmd {
+	return &GetTokenInfoCmd{
+		TokenType: tokentype,
+	}
+}
+
+// TryContractCmd defines the trycontract JSON-RPC command. When Method, a
+// method signature such as "minted()uint64", is given, the return data of
+// the contract is decoded as its results, and Args, if given, are packed as
+// the call data of the first contract call in the transaction.
 type TryContractCmd struct {
 	HexTx         string
+	Trace         *bool `jsonrpcdefault:"false"`
+	Method        *string
+	Args          *[]interface{}
 }
 
 // NewTryContractCmd returns a new instance which can be used to issue a
@@ -972,9 +1087,199 @@ type TryContractCmd struct {
 //
 // The parameters which are pointers indicate they are optional.  Passing nil
 // for optional parameters will use the default value.
-func NewTryContractCmd(hexTx string) *TryContractCmd {
+func NewTryContractCmd(hexTx string, trace *bool, method *string, args *[]interface{}) *TryContractCmd {
 	return &TryContractCmd{
 		HexTx:         hexTx,
+		Trace:         trace,
+		Method:        method,
+		Args:          args,
+	}
+}
+
+// GetContractStateCmd defines the getcontractstate JSON-RPC command. The
+// state is that after the block at Height, or the block BlockHash if Height
+// is not given, and the best block if neither is.
+type GetContractStateCmd struct {
+	Contract  string
+	Keys      *[]string
+	Height    *int32
+	BlockHash *string
+}
+
+// NewGetContractStateCmd returns a new instance which can be used to issue a
+// getcontractstate JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for keys returns the whole storage of the contract.
+func NewGetContractStateCmd(contract string, keys *[]string, height *int32, blockHash *string) *GetContractStateCmd {
+	return &GetContractStateCmd{
+		Contract:  contract,
+		Keys:      keys,
+		Height:    height,
+		BlockHash: blockHash,
+	}
+}
+
+// GetContractEventsCmd defines the getcontractevents JSON-RPC command.
+type GetContractEventsCmd struct {
+	Contract   string
+	Topic      *uint32
+	FromHeight *int32 `jsonrpcdefault:"0"`
+	Count      *int   `jsonrpcdefault:"100"`
+}
+
+// NewGetContractEventsCmd returns a new instance which can be used to issue a
+// getcontractevents JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetContractEventsCmd(contract string, topic *uint32, fromHeight *int32, count *int) *GetContractEventsCmd {
+	return &GetContractEventsCmd{
+		Contract:   contract,
+		Topic:      topic,
+		FromHeight: fromHeight,
+		Count:      count,
+	}
+}
+
+// PolygonsAtCmd defines the polygonsat JSON-RPC command. Lat and Lng are in
+// WGS84 degrees.
+type PolygonsAtCmd struct {
+	Lat float64
+	Lng float64
+}
+
+// NewPolygonsAtCmd returns a new instance which can be used to issue a
+// polygonsat JSON-RPC command.
+func NewPolygonsAtCmd(lat, lng float64) *PolygonsAtCmd {
+	return &PolygonsAtCmd{
+		Lat: lat,
+		Lng: lng,
+	}
+}
+
+// PolygonsInBoxCmd defines the polygonsinbox JSON-RPC command. The box is
+// given in WGS84 degrees.
+type PolygonsInBoxCmd struct {
+	West  float64
+	East  float64
+	South float64
+	North float64
+	Count *int `jsonrpcdefault:"100"`
+}
+
+// NewPolygonsInBoxCmd returns a new instance which can be used to issue a
+// polygonsinbox JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewPolygonsInBoxCmd(west, east, south, north float64, count *int) *PolygonsInBoxCmd {
+	return &PolygonsInBoxCmd{
+		West:  west,
+		East:  east,
+		South: south,
+		North: north,
+		Count: count,
+	}
+}
+
+// ExportPolygonCmd defines the exportpolygon JSON-RPC command. Format is either
+// "geojson" or "wkt".
+type ExportPolygonCmd struct {
+	Polygon string
+	Format  *string `jsonrpcdefault:"\"geojson\""`
+}
+
+// NewExportPolygonCmd returns a new instance which can be used to issue an
+// exportpolygon JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewExportPolygonCmd(polygon string, format *string) *ExportPolygonCmd {
+	return &ExportPolygonCmd{
+		Polygon: polygon,
+		Format:  format,
+	}
+}
+
+// BuildPolygonTxCmd defines the buildpolygontx JSON-RPC command. Geometry is
+// a GeoJSON Polygon or MultiPolygon, or a WKT text if Format is "wkt". The
+// transaction pays a token of the polygon with Rights to Address.
+type BuildPolygonTxCmd struct {
+	Geometry string
+	Address  string
+	Rights   string
+	Inputs   *[]TransactionInput
+	Format   *string `jsonrpcdefault:"\"geojson\""`
+	LockTime *int64
+}
+
+// NewBuildPolygonTxCmd returns a new instance which can be used to issue a
+// buildpolygontx JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewBuildPolygonTxCmd(geometry, address, rights string, inputs *[]TransactionInput,
+	format *string, lockTime *int64) *BuildPolygonTxCmd {
+	return &BuildPolygonTxCmd{
+		Geometry: geometry,
+		Address:  address,
+		Rights:   rights,
+		Inputs:   inputs,
+		Format:   format,
+		LockTime: lockTime,
+	}
+}
+
+// PolygonInfoCmd defines the polygoninfo JSON-RPC command. When Other is
+// given, the result also has the overlap of the two polygons.
+type PolygonInfoCmd struct {
+	Polygon string
+	Other   *string
+}
+
+// NewPolygonInfoCmd returns a new instance which can be used to issue a
+// polygoninfo JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewPolygonInfoCmd(polygon string, other *string) *PolygonInfoCmd {
+	return &PolygonInfoCmd{
+		Polygon: polygon,
+		Other:   other,
+	}
+}
+
+// SplitPolygonCmd defines the splitpolygon JSON-RPC command. Cut is a
+// polyline of [longitude, latitude] positions in degrees with ends at vertices
+// of the outer boundary of the polygon of the output and the other positions
+// inside it.
+type SplitPolygonCmd struct {
+	Txid string
+	Vout uint32
+	Cut  [][]float64
+}
+
+// NewSplitPolygonCmd returns a new instance which can be used to issue a
+// splitpolygon JSON-RPC command.
+func NewSplitPolygonCmd(txid string, vout uint32, cut [][]float64) *SplitPolygonCmd {
+	return &SplitPolygonCmd{
+		Txid: txid,
+		Vout: vout,
+		Cut:  cut,
+	}
+}
+
+// MergePolygonsCmd defines the mergepolygons JSON-RPC command.
+type MergePolygonsCmd struct {
+	Inputs []TransactionInput
+}
+
+// NewMergePolygonsCmd returns a new instance which can be used to issue a
+// mergepolygons JSON-RPC command.
+func NewMergePolygonsCmd(inputs []TransactionInput) *MergePolygonsCmd {
+	return &MergePolygonsCmd{
+		Inputs: inputs,
 	}
 }
 
@@ -1155,9 +1460,20 @@ func init() {
 	MustRegisterCmd("getblock", (*GetBlockCmd)(nil), flags)
 	MustRegisterCmd("getblocktxhashes", (*GetBlockTxHashesCmd)(nil), flags)
 	MustRegisterCmd("searchborder", (*SearchBorderCmd)(nil), flags)
+	MustRegisterCmd("polygonsat", (*PolygonsAtCmd)(nil), flags)
+	MustRegisterCmd("polygonsinbox", (*PolygonsInBoxCmd)(nil), flags)
+	MustRegisterCmd("exportpolygon", (*ExportPolygonCmd)(nil), flags)
+	MustRegisterCmd("buildpolygontx", (*BuildPolygonTxCmd)(nil), flags)
+	MustRegisterCmd("polygoninfo", (*PolygonInfoCmd)(nil), flags)
+	MustRegisterCmd("splitpolygon", (*SplitPolygonCmd)(nil), flags)
+	MustRegisterCmd("mergepolygons", (*MergePolygonsCmd)(nil), flags)
 	MustRegisterCmd("contractcall", (*ContractCallCmd)(nil), flags)
 	MustRegisterCmd("tokenaddress", (*TokenAddressCmd)(nil), flags)
+	MustRegisterCmd("listtokens", (*ListTokensCmd)(nil), flags)
+	MustRegisterCmd("gettokeninfo", (*GetTokenInfoCmd)(nil), flags)
 	MustRegisterCmd("trycontract", (*TryContractCmd)(nil), flags)
+	MustRegisterCmd("getcontractstate", (*GetContractStateCmd)(nil), flags)
+	MustRegisterCmd("getcontractevents", (*GetContractEventsCmd)(nil), flags)
 	MustRegisterCmd("getminerblock", (*GetMinerBlockCmd)(nil), flags)
 	MustRegisterCmd("getblockchaininfo", (*GetBlockChainInfoCmd)(nil), flags)
 	MustRegisterCmd("addminingkey", (*AddMiningKeyCmd)(nil), flags)
@@ -1189,7 +1505,10 @@ func init() {
 	MustRegisterCmd("getrawtransaction", (*GetRawTransactionCmd)(nil), flags)
 	MustRegisterCmd("gettxout", (*GetTxOutCmd)(nil), flags)
 	MustRegisterCmd("listutxos", (*ListUtxosCmd)(nil), flags)
+	MustRegisterCmd("getbalances", (*GetBalancesCmd)(nil), flags)
+	MustRegisterCmd("getgeometrypruneinfo", (*GetGeometryPruneInfoCmd)(nil), flags)
 	MustRegisterCmd("getdefine", (*GetDefineCmd)(nil), flags)
+	MustRegisterCmd("getrighttree", (*GetRightTreeCmd)(nil), flags)
 	MustRegisterCmd("gettxoutproof", (*GetTxOutProofCmd)(nil), flags)
 	MustRegisterCmd("gettxoutsetinfo", (*GetTxOutSetInfoCmd)(nil), flags)
 	MustRegisterCmd("getwork", (*GetWorkCmd)(nil), flags)
@@ -352,6 +352,83 @@ type GetDefineResult struct {
 	Definition         map[string]interface{}   `json:"definition"`		// a wire.Vertex, Border, Polygon, or Right
 }
 
+// TokenInfoResult models the data from the gettokeninfo command and the
+// tokens in the result of the listtokens command. Minted and Supply are
+// amounts for numeric token types and numbers of tokens for hash token types.
+type TokenInfoResult struct {
+	TokenType uint64 `json:"tokentype"`
+	Issuer    string `json:"issuer"`
+	Minted    uint64 `json:"minted"`
+	Supply    uint64 `json:"supply"`
+	Holders   uint64 `json:"holders"`
+	Height    int32  `json:"height"`
+}
+
+// TokenBalance models the balance of a numeric token type in the result of
+// the getbalances command.
+type TokenBalance struct {
+	TokenType uint64 `json:"tokentype"`
+	Value     int64  `json:"value"`
+	Outputs   int    `json:"outputs"`
+}
+
+// HashTokenOutput models an unspent hash token output in the result of the
+// getbalances command.
+type HashTokenOutput struct {
+	Txid      string `json:"txid"`
+	Vout      uint32 `json:"vout"`
+	TokenType uint64 `json:"tokentype"`
+	Hash      string `json:"hash"`
+	Rights    string `json:"rights,omitempty"`
+	Height    int32  `json:"height"`
+}
+
+// GetBalancesResult models the data from the getbalances command.
+type GetBalancesResult struct {
+	Address  string            `json:"address"`
+	Balances []TokenBalance    `json:"balances"`
+	Tokens   []HashTokenOutput `json:"tokens"`
+}
+
+// GetGeometryPruneInfoResult models the data from the getgeometrypruneinfo
+// command.
+type GetGeometryPruneInfoResult struct {
+	Height         int32  `json:"height"`
+	PruneDepth     int32  `json:"prunedepth"`
+	ArchiveDepth   int32  `json:"archivedepth"`
+	Borders        uint64 `json:"borders"`
+	Polygons       uint64 `json:"polygons"`
+	ReclaimedBytes uint64 `json:"reclaimedbytes"`
+	Archived       uint64 `json:"archived"`
+	ArchivedBytes  uint64 `json:"archivedbytes"`
+}
+
+// RightTreeNode models a right in the result of the getrighttree command.
+type RightTreeNode struct {
+	Hash         string   `json:"hash"`
+	Father       string   `json:"father"`
+	Root         string   `json:"root"`
+	Depth        int32    `json:"depth"`
+	Desc         string   `json:"desc"`
+	Attrib       uint32   `json:"attrib"`
+	Negative     bool     `json:"negative"`
+	Unsplittable bool     `json:"unsplittable"`
+	Monitored    bool     `json:"monitored"`
+	Monitor      bool     `json:"monitor"`
+	MonitorCall  bool     `json:"monitorcall"`
+	Sibling      string   `json:"sibling"`
+	Monitoring   string   `json:"monitoring,omitempty"`
+	Children     []string `json:"children"`
+	Sets         []string `json:"sets"`
+}
+
+// GetRightTreeResult models the data from the getrighttree command.
+type GetRightTreeResult struct {
+	Right       RightTreeNode   `json:"right"`
+	Ancestors   []RightTreeNode `json:"ancestors"`
+	Descendants []RightTreeNode `json:"descendants"`
+}
+
 // GetNetTotalsResult models the data returned from the getnettotals command.
 type GetNetTotalsResult struct {
 	TotalBytesRecv uint64 `json:"totalbytesrecv"`
@@ -602,6 +679,144 @@ type MultiSigAddr struct {
 type TryResult struct {
 	Result	     string `json:"result"`
 	Tx	     	 string `json:"tx"`
+
+	// Outputs are the decoded results when a method is given.
+	Outputs []interface{} `json:"outputs,omitempty"`
+
+	// Monitors are the calls of the monitors of monitored rights spent.
+	Monitors []TryMonitorResult `json:"monitors,omitempty"`
+
+	// Fields below are set when trace is requested.
+	Error    string               `json:"error,omitempty"`
+	Steps    int64                `json:"steps,omitempty"`
+	Trace    []TraceStepResult    `json:"trace,omitempty"`
+	HotSpots []TraceHotSpotResult `json:"hotspots,omitempty"`
+}
+
+// ContractCallResult models the data from the contractcall command when the
+// input is a method signature.
+type ContractCallResult struct {
+	Result  string        `json:"result"`
+	Outputs []interface{} `json:"outputs"`
+}
+
+// TryMonitorResult models a call of the monitor of a monitored right carried
+// by an input in the result of trycontract.
+type TryMonitorResult struct {
+	TxIn     int    `json:"txin"`
+	Right    string `json:"right"`
+	Monitor  string `json:"monitor"`
+	Contract string `json:"contract"`
+	Method   string `json:"method"`
+	Approved bool   `json:"approved"`
+	Result   string `json:"result"`
+	Error    string `json:"error,omitempty"`
+}
+
+// TraceStepResult models an instruction executed in the trace returned by
+// trycontract.
+type TraceStepResult struct {
+	Contract  string             `json:"contract"`
+	Depth     int                `json:"depth"`
+	Frame     int32              `json:"frame"`
+	PC        int                `json:"pc"`
+	Op        string             `json:"op"`
+	Operands  string             `json:"operands,omitempty"`
+	StepsLeft int64              `json:"stepsleft"`
+	State     []TraceStateResult `json:"state,omitempty"`
+}
+
+// TraceStateResult models an access of contract state by an instruction.
+type TraceStateResult struct {
+	Op    string `json:"op"`
+	Key   string `json:"key"`
+	Value string `json:"value,omitempty"`
+}
+
+// TraceHotSpotResult models the number of times an instruction was executed.
+type TraceHotSpotResult struct {
+	Contract string `json:"contract"`
+	PC       int    `json:"pc"`
+	Op       string `json:"op"`
+	Count    int64  `json:"count"`
+}
+
+// ContractStateResult models the data from the getcontractstate command.
+// Storage maps keys in hex to values in hex.
+type ContractStateResult struct {
+	Height   int32             `json:"height"`
+	Hash     string            `json:"hash"`
+	Contract string            `json:"contract"`
+	Storage  map[string]string `json:"storage"`
+}
+
+// ContractEventResult models an event emitted by a contract as returned by the
+// getcontractevents command and the contractevent notification.
+type ContractEventResult struct {
+	TxID     string `json:"txid"`
+	Height   int32  `json:"height"`
+	TxIndex  uint32 `json:"txindex"`
+	Index    uint32 `json:"index"`
+	Contract string `json:"contract"`
+	Topic    uint32 `json:"topic"`
+	Data     string `json:"data"`
+}
+
+// PolygonUtxoResult models an unspent output of a polygon token as returned by
+// the polygonsat and polygonsinbox commands.
+type PolygonUtxoResult struct {
+	TxID      string   `json:"txid"`
+	Vout      uint32   `json:"vout"`
+	Height    int32    `json:"height"`
+	Rights    string   `json:"rights,omitempty"`
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+// PolygonResult models a polygon, its bounding box in WGS84 degrees and the
+// unspent outputs of its tokens as returned by the polygonsat and
+// polygonsinbox commands.
+type PolygonResult struct {
+	Polygon string              `json:"polygon"`
+	West    float64             `json:"west"`
+	East    float64             `json:"east"`
+	South   float64             `json:"south"`
+	North   float64             `json:"north"`
+	Utxos   []PolygonUtxoResult `json:"utxos"`
+}
+
+// BuildPolygonTxResult models the data from the buildpolygontx command.
+type BuildPolygonTxResult struct {
+	Hex     string   `json:"hex"`
+	Polygon string   `json:"polygon"`
+	Borders []string `json:"borders"`
+}
+
+// PolygonTxResult models the data from the splitpolygon and mergepolygons
+// commands.
+type PolygonTxResult struct {
+	Hex      string   `json:"hex"`
+	Polygons []string `json:"polygons"`
+	Borders  []string `json:"borders"`
+}
+
+// PolygonOverlapResult models the overlap of two polygons in the data from
+// the polygoninfo command. Areas are in square meters and Perimeter in meters.
+type PolygonOverlapResult struct {
+	Polygon      string  `json:"polygon"`
+	Area         float64 `json:"area"`
+	Perimeter    float64 `json:"perimeter"`
+	Intersection float64 `json:"intersection"`
+	Contains     bool    `json:"contains"`
+	Within       bool    `json:"within"`
+}
+
+// PolygonInfoResult models the data from the polygoninfo command. Area is in
+// square meters and Perimeter in meters.
+type PolygonInfoResult struct {
+	Polygon   string                `json:"polygon"`
+	Area      float64               `json:"area"`
+	Perimeter float64               `json:"perimeter"`
+	Overlap   *PolygonOverlapResult `json:"overlap,omitempty"`
 }
 
 // SearchRawTransactionsResult models the data from the searchrawtransaction
@@ -221,6 +221,87 @@ func NewRescanBlocksCmd(blockHashes []string) *RescanBlocksCmd {
 	return &RescanBlocksCmd{BlockHashes: blockHashes}
 }
 
+// VMDebugAttachCmd defines the vmdebugattach JSON-RPC command.
+type VMDebugAttachCmd struct {
+	Address     *string
+	Source      *string
+	IdleTimeout *int
+}
+
+// NewVMDebugAttachCmd returns a new instance which can be used to issue a
+// vmdebugattach JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for address debugs any contract, and nil for idleTimeout closes a session
+// idle for the default time of the server.
+func NewVMDebugAttachCmd(address, source *string, idleTimeout *int) *VMDebugAttachCmd {
+	return &VMDebugAttachCmd{
+		Address:     address,
+		Source:      source,
+		IdleTimeout: idleTimeout,
+	}
+}
+
+// VMDebugControlCmd defines the vmdebugcontrol JSON-RPC command.
+type VMDebugControlCmd struct {
+	Session uint32
+	DbgCmd  string `jsonrpcusage:"\"go|step|up|stop|timeout|breakpoint|breakline|clearbreakpoint|watch|unwatch|getdata|getstack|evaluate\""`
+	Param   *string
+	Value   *int
+}
+
+// NewVMDebugControlCmd returns a new instance which can be used to issue a
+// vmdebugcontrol JSON-RPC command.
+func NewVMDebugControlCmd(session uint32, dbgCmd string, param *string, value *int) *VMDebugControlCmd {
+	return &VMDebugControlCmd{
+		Session: session,
+		DbgCmd:  dbgCmd,
+		Param:   param,
+		Value:   value,
+	}
+}
+
+// VMDebugDetachCmd defines the vmdebugdetach JSON-RPC command.
+type VMDebugDetachCmd struct {
+	Session uint32
+}
+
+// NewVMDebugDetachCmd returns a new instance which can be used to issue a
+// vmdebugdetach JSON-RPC command.
+func NewVMDebugDetachCmd(session uint32) *VMDebugDetachCmd {
+	return &VMDebugDetachCmd{Session: session}
+}
+
+// NotifyContractEventsCmd defines the notifycontractevents JSON-RPC command.
+// Events of any of Contracts, or of any contract if it is empty, are
+// notified. Topics further restricts the events to those of the topics.
+type NotifyContractEventsCmd struct {
+	Contracts []string
+	Topics    *[]uint32
+}
+
+// NewNotifyContractEventsCmd returns a new instance which can be used to issue
+// a notifycontractevents JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for topics notifies events of all topics.
+func NewNotifyContractEventsCmd(contracts []string, topics *[]uint32) *NotifyContractEventsCmd {
+	return &NotifyContractEventsCmd{
+		Contracts: contracts,
+		Topics:    topics,
+	}
+}
+
+// StopNotifyContractEventsCmd defines the stopnotifycontractevents JSON-RPC
+// command.
+type StopNotifyContractEventsCmd struct{}
+
+// NewStopNotifyContractEventsCmd returns a new instance which can be used to
+// issue a stopnotifycontractevents JSON-RPC command.
+func NewStopNotifyContractEventsCmd() *StopNotifyContractEventsCmd {
+	return &StopNotifyContractEventsCmd{}
+}
+
 func init() {
 	// The commands in this file are only usable by websockets.
 	flags := UFWebsocketOnly
@@ -231,11 +312,16 @@ func init() {
 	MustRegisterCmd("notifynewtransactions", (*NotifyNewTransactionsCmd)(nil), flags)
 	MustRegisterCmd("notifyreceived", (*NotifyReceivedCmd)(nil), flags)
 	MustRegisterCmd("notifyspent", (*NotifySpentCmd)(nil), flags)
+	MustRegisterCmd("notifycontractevents", (*NotifyContractEventsCmd)(nil), flags)
 	MustRegisterCmd("session", (*SessionCmd)(nil), flags)
 	MustRegisterCmd("stopnotifyblocks", (*StopNotifyBlocksCmd)(nil), flags)
 	MustRegisterCmd("stopnotifynewtransactions", (*StopNotifyNewTransactionsCmd)(nil), flags)
 	MustRegisterCmd("stopnotifyspent", (*StopNotifySpentCmd)(nil), flags)
 	MustRegisterCmd("stopnotifyreceived", (*StopNotifyReceivedCmd)(nil), flags)
+	MustRegisterCmd("stopnotifycontractevents", (*StopNotifyContractEventsCmd)(nil), flags)
 	MustRegisterCmd("rescan", (*RescanCmd)(nil), flags)
 	MustRegisterCmd("rescanblocks", (*RescanBlocksCmd)(nil), flags)
+	MustRegisterCmd("vmdebugattach", (*VMDebugAttachCmd)(nil), flags)
+	MustRegisterCmd("vmdebugcontrol", (*VMDebugControlCmd)(nil), flags)
+	MustRegisterCmd("vmdebugdetach", (*VMDebugDetachCmd)(nil), flags)
 }
@@ -77,6 +77,15 @@ const (
 	// from the chain server that inform a client that a transaction that
 	// matches the loaded filter was accepted by the mempool.
 	RelevantTxAcceptedNtfnMethod = "relevanttxaccepted"
+
+	// VMDebugEventNtfnMethod is the method used for notifications of
+	// contract debug sessions started by vmdebugattach.
+	VMDebugEventNtfnMethod = "vmdebugevent"
+
+	// ContractEventNtfnMethod is the method used for notifications of
+	// events emitted by contracts in connected blocks requested by
+	// notifycontractevents.
+	ContractEventNtfnMethod = "contractevent"
 )
 
 // BlockConnectedNtfn defines the blockconnected JSON-RPC notification.
@@ -315,6 +324,32 @@ func NewRelevantTxAcceptedNtfn(txHex string) *RelevantTxAcceptedNtfn {
 	return &RelevantTxAcceptedNtfn{Transaction: txHex}
 }
 
+// VMDebugEventNtfn defines the vmdebugevent JSON-RPC notification.
+type VMDebugEventNtfn struct {
+	Session uint32
+	Event   VMDebugEvent
+}
+
+// NewVMDebugEventNtfn returns a new instance which can be used to issue a
+// vmdebugevent JSON-RPC notification.
+func NewVMDebugEventNtfn(session uint32, event VMDebugEvent) *VMDebugEventNtfn {
+	return &VMDebugEventNtfn{
+		Session: session,
+		Event:   event,
+	}
+}
+
+// ContractEventNtfn defines the contractevent JSON-RPC notification.
+type ContractEventNtfn struct {
+	Event ContractEventResult
+}
+
+// NewContractEventNtfn returns a new instance which can be used to issue a
+// contractevent JSON-RPC notification.
+func NewContractEventNtfn(event ContractEventResult) *ContractEventNtfn {
+	return &ContractEventNtfn{Event: event}
+}
+
 func init() {
 	// The commands in this file are only usable by websockets and are
 	// notifications.
@@ -333,4 +368,6 @@ func init() {
 	MustRegisterCmd(TxAcceptedNtfnMethod, (*TxAcceptedNtfn)(nil), flags)
 	MustRegisterCmd(TxAcceptedVerboseNtfnMethod, (*TxAcceptedVerboseNtfn)(nil), flags)
 	MustRegisterCmd(RelevantTxAcceptedNtfnMethod, (*RelevantTxAcceptedNtfn)(nil), flags)
+	MustRegisterCmd(VMDebugEventNtfnMethod, (*VMDebugEventNtfn)(nil), flags)
+	MustRegisterCmd(ContractEventNtfnMethod, (*ContractEventNtfn)(nil), flags)
 }
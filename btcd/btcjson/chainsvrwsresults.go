@@ -19,3 +19,24 @@ type RescannedBlock struct {
 	Hash         string   `json:"hash"`
 	Transactions []string `json:"transactions"`
 }
+
+// VMDebugWatch models the value of a watch expression in a vmdebugevent
+// notification.
+type VMDebugWatch struct {
+	Expr  string `json:"expr"`
+	Value string `json:"value,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// VMDebugEvent models the event data of a vmdebugevent notification.
+type VMDebugEvent struct {
+	Kind     string         `json:"kind"`
+	Contract string         `json:"contract"`
+	Creation bool           `json:"creation"`
+	PC       int            `json:"pc"`
+	Line     int            `json:"line,omitempty"`
+	Stack    []int          `json:"stack,omitempty"`
+	Watches  []VMDebugWatch `json:"watches,omitempty"`
+	Result   string         `json:"result,omitempty"`
+	Error    string         `json:"error,omitempty"`
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2018-2021 The Omegasuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package chaincfg
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/omegasuite/btcd/wire"
+)
+
+// CommitteeParams defines the committees that sign tx blocks and the rotation
+// of miners through them.
+type CommitteeParams struct {
+	// Version is the block version from which on the parameters apply. The
+	// committee signing a tx block is the one of the rotation it is in, so
+	// it is the version of the miner block at the rotation that counts.
+	Version uint32
+
+	// Size is the number of members of a committee.
+	Size int32
+
+	// Sigs is the number of signatures required for a signed block. It is
+	// just over half of Size, and can not be higher, or a minority of the
+	// committee could stall it.
+	Sigs int32
+
+	// MinerGap is the number of miner blocks a miner must wait between two
+	// candidacies. It should not be less than Size - 1.
+	MinerGap int32
+
+	// RotateFreq is the number of tx blocks signed by a committee before it
+	// rotates.
+	RotateFreq int32
+
+	// DesirableCandidates is the number of miner candidates we want to have.
+	// The difficulty of the miner chain is adjusted toward it.
+	DesirableCandidates int32
+}
+
+// DefaultCommittee is the committee of all standard networks from the genesis
+// block on. It is also used by parameters that do not define Committees.
+var DefaultCommittee = CommitteeParams{
+	Version:             0,
+	Size:                3,
+	Sigs:                2,
+	MinerGap:            3,
+	RotateFreq:          defaultRotateFreq,
+	DesirableCandidates: 40,
+}
+
+// defaultRotateFreq is the RotateFreq of DefaultCommittee. Parameters counted
+// in rotations are defined with it.
+const defaultRotateFreq = 200
+
+// ErrInvalidCommittee describes an error where the committees of a network
+// are out of order or not consistent.
+var ErrInvalidCommittee = errors.New("invalid committee parameters")
+
+// NewCommittee returns the parameters of committees of size members from block
+// version on. The signatures required and the miner gap are derived from the
+// size, the other parameters are those of DefaultCommittee.
+func NewCommittee(version uint32, size int32) CommitteeParams {
+	c := DefaultCommittee
+	c.Version = version
+	c.Size = size
+	c.Sigs = size/2 + 1
+	if c.MinerGap < size-1 {
+		c.MinerGap = size - 1
+	}
+	return c
+}
+
+// SetCommittees replaces the committees of the network. It is meant for test
+// networks whose committees are set by configuration, as all nodes of a
+// network must agree on them. It errors with ErrInvalidCommittee if the
+// committees are not consistent, and leaves those of the network unchanged.
+func (p *Params) SetCommittees(committees []CommitteeParams) error {
+	q := Params{Committees: committees}
+	if err := q.checkCommittees(); err != nil {
+		return err
+	}
+	p.Committees = committees
+	return nil
+}
+
+// Committee returns the committee parameters in effect for blocks of version.
+// The feature bits in the low 16 bits of version are ignored.
+func (p *Params) Committee(version uint32) *CommitteeParams {
+	if len(p.Committees) == 0 {
+		return &DefaultCommittee
+	}
+	version &^= 0xFFFF
+	c := &p.Committees[0]
+	for i := 1; i < len(p.Committees) && p.Committees[i].Version <= version; i++ {
+		c = &p.Committees[i]
+	}
+	return c
+}
+
+// MaxCommitteeSize returns the size of the largest committee of the network.
+func (p *Params) MaxCommitteeSize() int32 {
+	size := DefaultCommittee.Size
+	if len(p.Committees) > 0 {
+		size = 0
+	}
+	for _, c := range p.Committees {
+		if c.Size > size {
+			size = c.Size
+		}
+	}
+	return size
+}
+
+// checkCommittees returns an error if the committees of p are not ordered by
+// version from version 0 on, or if any of them is not consistent.
+func (p *Params) checkCommittees() error {
+	for i, c := range p.Committees {
+		switch {
+		case i == 0 && c.Version != 0:
+			return fmt.Errorf("%v: the first committee must start at version 0", ErrInvalidCommittee)
+
+		case i > 0 && c.Version <= p.Committees[i-1].Version:
+			return fmt.Errorf("%v: committee %d is not after committee %d", ErrInvalidCommittee, i, i-1)
+
+		case c.Size < 1 || c.Size > wire.MaxCommitteeSize:
+			return fmt.Errorf("%v: committee size %d is not in [1, %d]", ErrInvalidCommittee, c.Size, wire.MaxCommitteeSize)
+
+		case c.Sigs != c.Size/2+1:
+			return fmt.Errorf("%v: %d signatures is not just over half of %d", ErrInvalidCommittee, c.Sigs, c.Size)
+
+		case c.MinerGap < c.Size-1:
+			return fmt.Errorf("%v: miner gap %d is less than %d", ErrInvalidCommittee, c.MinerGap, c.Size-1)
+
+		case c.RotateFreq < 2:
+			return fmt.Errorf("%v: committee %d rotates every %d blocks", ErrInvalidCommittee, i, c.RotateFreq)
+
+		case c.DesirableCandidates < 2:
+			return fmt.Errorf("%v: %d desirable candidates", ErrInvalidCommittee, c.DesirableCandidates)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2018-2021 The Omegasuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package chaincfg
+
+import "testing"
+
+// TestCommittee ensures the committee in effect for a block version is the
+// last one defined at or before it, ignoring the feature bits.
+func TestCommittee(t *testing.T) {
+	t.Parallel()
+
+	large := DefaultCommittee
+	large.Version = Version2
+	large.Size, large.Sigs, large.MinerGap = 7, 4, 6
+
+	params := Params{Committees: []CommitteeParams{DefaultCommittee, large}}
+	if err := params.checkCommittees(); err != nil {
+		t.Fatalf("checkCommittees: %v", err)
+	}
+
+	tests := []struct {
+		version uint32
+		size    int32
+	}{
+		{0, 3},
+		{1, 3},
+		{Version2 - 1, 3},
+		{Version2, 7},
+		{Version2 | 0xFF, 7},
+		{Version2 + 0x10000, 7},
+	}
+	for _, test := range tests {
+		if size := params.Committee(test.version).Size; size != test.size {
+			t.Errorf("Committee(%#x): size %d, want %d", test.version, size, test.size)
+		}
+	}
+	if size := params.MaxCommitteeSize(); size != 7 {
+		t.Errorf("MaxCommitteeSize: %d, want 7", size)
+	}
+
+	var none Params
+	if c := none.Committee(Version2); *c != DefaultCommittee {
+		t.Errorf("Committee of params without committees: %v, want %v", *c, DefaultCommittee)
+	}
+}
+
+// TestCheckCommittees ensures inconsistent committees are rejected.
+func TestCheckCommittees(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		modify func(c []CommitteeParams)
+	}{
+		{"first not at version 0", func(c []CommitteeParams) { c[0].Version = 1 }},
+		{"out of order", func(c []CommitteeParams) { c[1].Version = 0 }},
+		{"empty", func(c []CommitteeParams) { c[1].Size, c[1].Sigs, c[1].MinerGap = 0, 1, 0 }},
+		{"too large", func(c []CommitteeParams) { c[1].Size, c[1].Sigs = 65, 33 }},
+		{"minority signs", func(c []CommitteeParams) { c[1].Sigs = 3 }},
+		{"short gap", func(c []CommitteeParams) { c[1].MinerGap = 5 }},
+		{"no rotation", func(c []CommitteeParams) { c[1].RotateFreq = 1 }},
+		{"no candidates", func(c []CommitteeParams) { c[1].DesirableCandidates = 0 }},
+	}
+	for _, test := range tests {
+		large := DefaultCommittee
+		large.Version = Version2
+		large.Size, large.Sigs, large.MinerGap = 7, 4, 6
+
+		params := Params{Committees: []CommitteeParams{DefaultCommittee, large}}
+		test.modify(params.Committees)
+		if err := params.checkCommittees(); err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+}
+
+// TestSetCommittees ensures committees derived from a size are consistent, and
+// inconsistent committees leave those of the network unchanged.
+func TestSetCommittees(t *testing.T) {
+	t.Parallel()
+
+	params := Params{Committees: []CommitteeParams{DefaultCommittee}}
+	for _, size := range []int32{1, 4, 7, 21, 64} {
+		if err := params.SetCommittees([]CommitteeParams{NewCommittee(0, size)}); err != nil {
+			t.Errorf("SetCommittees of size %d: %v", size, err)
+			continue
+		}
+		if c := params.Committee(0); c.Size != size || c.Sigs != size/2+1 {
+			t.Errorf("committee of size %d: %v", size, *c)
+		}
+	}
+
+	params.Committees = []CommitteeParams{DefaultCommittee}
+	for _, size := range []int32{0, 65} {
+		if err := params.SetCommittees([]CommitteeParams{NewCommittee(0, size)}); err == nil {
+			t.Errorf("SetCommittees of size %d: no error", size)
+		}
+	}
+	if err := params.SetCommittees([]CommitteeParams{NewCommittee(Version2, 7)}); err == nil {
+		t.Errorf("SetCommittees without a committee at version 0: no error")
+	}
+	if c := params.Committee(0); *c != DefaultCommittee {
+		t.Errorf("committee after failed SetCommittees: %v, want %v", *c, DefaultCommittee)
+	}
+}
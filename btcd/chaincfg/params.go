@@ -121,6 +121,16 @@ const (
 	// collateral provided in the previous adj. period)
 	DeploymentVersion5
 
+	// DeploymentVersion6 includes: pruning of borders and polygons no longer
+	// held by any utxo nor used by other geometry
+	DeploymentVersion6
+
+	// DeploymentVersion7 includes: EVENT OVM instruction; upgrade and upgradedelay
+	// system methods of contracts declaring an upgrade delay; geometry integrity check matching divided
+	// borders with their children; polyhedron definitions; monitor call ABI
+	// for monitored rights
+	DeploymentVersion7
+
 	// DefinedDeployments is the number of currently defined deployments.
 	// It must always come last since it is used to determine how many
 	// defined deployments there currently are.
@@ -133,6 +143,8 @@ const (
 	Version3 = 0x30000
 	Version4 = 0x40000
 	Version5 = 0x50000
+	Version6 = 0x60000
+	Version7 = 0x70000
 )
 
 type forfeitureContract struct {
@@ -242,6 +254,10 @@ type Params struct {
 	MinerConfirmationWindow       uint32
 	Deployments                   [DefinedDeployments]ConsensusDeployment
 
+	// Committees are the committee parameters ordered by the block version
+	// they are activated at. The first applies from the genesis block on.
+	Committees []CommitteeParams
+
 	// Mempool parameters
 	RelayNonStdTxs bool
 
@@ -300,8 +316,8 @@ var MainNetParams = Params{
 	GenesisMinerHash:         &genesisMinerHash,
 	PowLimit:                 mainPowLimit,
 	PowLimitBits:             0x1e00fff0,
-	CoinbaseMaturity:         100 * wire.MINER_RORATE_FREQ,
-	SubsidyReductionInterval: 105000 * wire.MINER_RORATE_FREQ,
+	CoinbaseMaturity:         100 * defaultRotateFreq,
+	SubsidyReductionInterval: 105000 * defaultRotateFreq,
 	MinimalAward: 			  1171875,
 	TargetTimespan:           time.Hour * 24 * 14, // 14 days
 	TargetTimePerBlock:       time.Minute * 10,    // 10 minutes
@@ -353,7 +369,20 @@ var MainNetParams = Params{
 			StartTime:   uint64(time.Date(2022, 3, 2, 0, 0, 0, 0, time.UTC).Unix()),
 			ExpireTime:  uint64(time.Date(2023, 5, 2, 0, 0, 0, 0, time.UTC).Unix()),
 		},
+		DeploymentVersion6: {
+			PrevVersion: 0x50000,
+			FeatureMask: 0x10,
+			StartTime:   uint64(time.Date(2027, 3, 1, 0, 0, 0, 0, time.UTC).Unix()),
+			ExpireTime:  uint64(time.Date(2027, 9, 1, 0, 0, 0, 0, time.UTC).Unix()),
+		},
+		DeploymentVersion7: {
+			PrevVersion: 0x60000,
+			FeatureMask: 0x20,
+			StartTime:   uint64(time.Date(2027, 9, 1, 0, 0, 0, 0, time.UTC).Unix()),
+			ExpireTime:  uint64(time.Date(2028, 3, 1, 0, 0, 0, 0, time.UTC).Unix()),
+		},
 	},
+	Committees: []CommitteeParams{DefaultCommittee},
 
 	// Mempool parameters
 	RelayNonStdTxs: false,
@@ -409,7 +438,7 @@ var RegressionNetParams = Params{
 	PowLimit:                 regressionPowLimit,
 	PowLimitBits:             0x207fffff,
 	CoinbaseMaturity:         10,
-	SubsidyReductionInterval: 150 * wire.MINER_RORATE_FREQ,
+	SubsidyReductionInterval: 150 * defaultRotateFreq,
 	MinimalAward: 			  1171875,
 	TargetTimespan:           time.Hour * 24 * 14, // 14 days
 	TargetTimePerBlock:       time.Minute * 10,    // 10 minutes
@@ -460,7 +489,20 @@ var RegressionNetParams = Params{
 			StartTime:   uint64(time.Date(2022, 3, 2, 0, 0, 0, 0, time.UTC).Unix()),
 			ExpireTime:  math.MaxInt64, // Never expires
 		},
+		DeploymentVersion6: {
+			PrevVersion: 0x50000,
+			FeatureMask: 0x10,
+			StartTime:   uint64(time.Date(2027, 3, 1, 0, 0, 0, 0, time.UTC).Unix()),
+			ExpireTime:  math.MaxInt64, // Never expires
+		},
+		DeploymentVersion7: {
+			PrevVersion: 0x60000,
+			FeatureMask: 0x20,
+			StartTime:   uint64(time.Date(2027, 9, 1, 0, 0, 0, 0, time.UTC).Unix()),
+			ExpireTime:  math.MaxInt64, // Never expires
+		},
 	},
+	Committees: []CommitteeParams{DefaultCommittee},
 
 	// Mempool parameters
 	RelayNonStdTxs: true,
@@ -519,7 +561,7 @@ var TestNet3Params = Params{
 	PowLimit:                 testNet3PowLimit,
 	PowLimitBits:             0x1f0fffff,	// 0x1d3fffff
 	CoinbaseMaturity:         10,
-	SubsidyReductionInterval: 210000 * wire.MINER_RORATE_FREQ,
+	SubsidyReductionInterval: 210000 * defaultRotateFreq,
 	MinimalAward: 			  1171875,
 	TargetTimespan:           time.Hour * 2, // 2 hours
 	TargetTimePerBlock:       time.Minute * 4,    // 4 minutes
@@ -571,7 +613,20 @@ var TestNet3Params = Params{
 			StartTime:   uint64(time.Date(2022, 3, 2, 0, 0, 0, 0, time.UTC).Unix()),
 			ExpireTime:  math.MaxInt64, // Never expires
 		},
+		DeploymentVersion6: {
+			PrevVersion: 0x50000,
+			FeatureMask: 0x10,
+			StartTime:   uint64(time.Date(2027, 3, 1, 0, 0, 0, 0, time.UTC).Unix()),
+			ExpireTime:  math.MaxInt64, // Never expires
+		},
+		DeploymentVersion7: {
+			PrevVersion: 0x60000,
+			FeatureMask: 0x20,
+			StartTime:   uint64(time.Date(2027, 9, 1, 0, 0, 0, 0, time.UTC).Unix()),
+			ExpireTime:  math.MaxInt64, // Never expires
+		},
 	},
+	Committees: []CommitteeParams{DefaultCommittee},
 
 	// Mempool parameters
 	RelayNonStdTxs: true,
@@ -631,8 +686,8 @@ var SimNetParams = Params{
 	GenesisMinerHash:         &simNetGenesisMinerHash,
 	PowLimit:                 simNetPowLimit,
 	PowLimitBits:             0x207fffff,
-	CoinbaseMaturity:         100 * wire.MINER_RORATE_FREQ,
-	SubsidyReductionInterval: 210000 * wire.MINER_RORATE_FREQ,
+	CoinbaseMaturity:         100 * defaultRotateFreq,
+	SubsidyReductionInterval: 210000 * defaultRotateFreq,
 	MinimalAward: 			  1171875,
 	TargetTimespan:           time.Hour * 24 * 14, // 14 days
 	TargetTimePerBlock:       time.Minute * 10,    // 10 minutes
@@ -683,7 +738,20 @@ var SimNetParams = Params{
 			StartTime:   uint64(time.Date(2022, 3, 2, 0, 0, 0, 0, time.UTC).Unix()),
 			ExpireTime:  math.MaxInt64, // Never expires
 		},
+		DeploymentVersion6: {
+			PrevVersion: 0x50000,
+			FeatureMask: 0x10,
+			StartTime:   uint64(time.Date(2027, 3, 1, 0, 0, 0, 0, time.UTC).Unix()),
+			ExpireTime:  math.MaxInt64, // Never expires
+		},
+		DeploymentVersion7: {
+			PrevVersion: 0x60000,
+			FeatureMask: 0x20,
+			StartTime:   uint64(time.Date(2027, 9, 1, 0, 0, 0, 0, time.UTC).Unix()),
+			ExpireTime:  math.MaxInt64, // Never expires
+		},
 	},
+	Committees: []CommitteeParams{DefaultCommittee},
 
 	// Mempool parameters
 	RelayNonStdTxs: true,
@@ -736,7 +804,7 @@ var (
 )
 
 var (
-	registeredNets       = make(map[common.OmegaNet]struct{})
+	registeredNets       = make(map[common.OmegaNet]*Params)
 	pubKeyHashAddrIDs    = make(map[byte]struct{})
 	multisigAddrIDs      = make(map[byte]struct{})
 	contractAddrIDs      = make(map[byte]struct{})
@@ -759,11 +827,17 @@ func (d DNSSeed) String() string {
 // as early as possible.  Then, library packages may lookup networks or network
 // parameters based on inputs and work regardless of the network being standard
 // or not.
+//
+// It errors with ErrInvalidCommittee if the committees of the network are not
+// consistent.
 func Register(params *Params) error {
 	if _, ok := registeredNets[params.Net]; ok {
 		return ErrDuplicateNet
 	}
-	registeredNets[params.Net] = struct{}{}
+	if err := params.checkCommittees(); err != nil {
+		return err
+	}
+	registeredNets[params.Net] = params
 	pubKeyHashAddrIDs[params.PubKeyHashAddrID] = struct{}{}
 	multisigAddrIDs[params.MultiSigAddrID] = struct{}{}
 	contractAddrIDs[params.ContractAddrID] = struct{}{}
@@ -776,6 +850,12 @@ func Register(params *Params) error {
 	return nil
 }
 
+// NetParams returns the parameters registered for the network net, or nil if
+// none are.
+func NetParams(net common.OmegaNet) *Params {
+	return registeredNets[net]
+}
+
 // mustRegister performs the same function as Register except it panics if there
 // is an error.  This should only be called from package init functions.
 func mustRegister(params *Params) {
@@ -506,3 +506,15 @@ func TestRegister(t *testing.T) {
 		}
 	}
 }
+
+// TestNetParams ensures the parameters of a network are found by its net.
+func TestNetParams(t *testing.T) {
+	for _, params := range []*Params{&MainNetParams, &RegressionNetParams, &TestNet3Params, &SimNetParams} {
+		if NetParams(params.Net) != params {
+			t.Errorf("NetParams: %s is not found by its net", params.Name)
+		}
+	}
+	if p := NetParams(1<<32 - 2); p != nil {
+		t.Errorf("NetParams: unregistered net has parameters %s", p.Name)
+	}
+}
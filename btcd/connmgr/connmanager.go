@@ -8,7 +8,6 @@ package connmgr
 import (
 	"errors"
 	"fmt"
-	"github.com/omegasuite/btcd/wire"
 	"net"
 	"sync"
 	"sync/atomic"
@@ -37,6 +36,10 @@ var (
 	// defaultTargetOutbound is the default number of outbound connections to
 	// maintain.
 	defaultTargetOutbound = uint32(8)
+
+	// defaultCommitteeSize is the default size of the largest committee of
+	// the network.
+	defaultCommitteeSize = int32(3)
 )
 
 // ConnState represents the state of the requested connection.
@@ -133,6 +136,11 @@ type Config struct {
 	// maintain. Defaults to 8 + committee size.
 	TargetOutbound uint32
 
+	// CommitteeSize is the size of the largest committee of the network.
+	// Connections to members of recent committees are retried. Defaults
+	// to 3.
+	CommitteeSize int32
+
 	// RetryDuration is the duration to wait before retrying connection
 	// requests. Defaults to 5s.
 	RetryDuration time.Duration
@@ -353,7 +361,7 @@ out:
 				// subsequent processing of connections and
 				// failures do not ignore the request.
 				if uint32(len(conns)) < cm.cfg.TargetOutbound ||
-					connReq.Permanent || connReq.Committee >= cm.Committee - wire.CommitteeSize {
+					connReq.Permanent || connReq.Committee >= cm.Committee - cm.cfg.CommitteeSize {
 					connReq.updateState(ConnPending)
 					log.Debugf("Reconnecting to %v", connReq)
 
@@ -642,8 +650,11 @@ func New(cfg *Config) (*ConnManager, error) {
 	if cfg.RetryDuration <= 0 {
 		cfg.RetryDuration = defaultRetryDuration
 	}
+	if cfg.CommitteeSize <= 0 {
+		cfg.CommitteeSize = defaultCommitteeSize
+	}
 	if cfg.TargetOutbound == 0 {
-		cfg.TargetOutbound = defaultTargetOutbound + wire.CommitteeSize
+		cfg.TargetOutbound = defaultTargetOutbound + uint32(cfg.CommitteeSize)
 	}
 	cm := ConnManager{
 		cfg:      *cfg, // Copy so caller can't mutate
@@ -14,6 +14,7 @@ import (
 	"sort"
 	"sync"
 
+	"github.com/omegasuite/btcd/chaincfg"
 	"github.com/omegasuite/btcd/chaincfg/chainhash"
 	"github.com/omegasuite/btcd/database"
 	"github.com/omegasuite/btcd/database/internal/treap"
@@ -1178,7 +1179,7 @@ func (tx *transaction) StoreBlock(block *btcutil.Block) error {
 	blockBytes := w.Bytes()
 
 	// we really shout not be doing it here
-	if block.MsgBlock().Header.Nonce < 0 && len(block.MsgBlock().Transactions[0].SignatureScripts) <= wire.CommitteeSigs {
+	if block.MsgBlock().Header.Nonce < 0 && len(block.MsgBlock().Transactions[0].SignatureScripts) <= int(tx.db.chainParams.Committee(block.MsgBlock().Header.Version).Sigs) {
 		panic(fmt.Sprintf("insifficient signatures for block %s", block.Hash().String()))
 		os.Exit(-8)
 		return makeDbErr(database.ErrTxNotWritable, "insifficient signatures", nil)
@@ -1803,6 +1804,10 @@ type db struct {
 	closed    bool         // Is the database closed?
 	store     *blockStore  // Handles read/writing blocks to flat files.
 	cache     *dbCache     // Cache layer which wraps underlying leveldb DB.
+
+	// chainParams are the parameters of the network of the database. The
+	// committee parameters tell how many signatures a block must carry.
+	chainParams *chaincfg.Params
 }
 
 // Enforce db implements the database.DB interface.
@@ -2054,6 +2059,12 @@ func initDB(ldb *leveldb.DB) error {
 // openDB opens the database at the provided path.  database.ErrDbDoesNotExist
 // is returned if the database doesn't exist and the create flag is not set.
 func openDB(dbPath string, network common.OmegaNet, create bool) (database.DB, error) {
+	chainParams := chaincfg.NetParams(network)
+	if chainParams == nil {
+		str := fmt.Sprintf("network %v is not registered", network)
+		return nil, makeDbErr(database.ErrInvalid, str, nil)
+	}
+
 	// Error if the database doesn't exist and the create flag is not set.
 	metadataDbPath := filepath.Join(dbPath, metadataDbName)
 	dbExists := fileExists(metadataDbPath)
@@ -2089,7 +2100,7 @@ func openDB(dbPath string, network common.OmegaNet, create bool) (database.DB, e
 	// write caching.
 	store := newBlockStore(dbPath, network)
 	cache := newDbCache(ldb, store, defaultCacheSize, defaultFlushSecs)
-	pdb := &db{store: store, cache: cache}
+	pdb := &db{store: store, cache: cache, chainParams: chainParams}
 
 	// Perform any reconciliation needed between the block and metadata as
 	// well as database initialization, if needed.
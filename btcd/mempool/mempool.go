@@ -676,6 +676,19 @@ func (mp *TxPool) FetchTransaction(txHash *chainhash.Hash) (*btcutil.Tx, error)
 	return nil, fmt.Errorf("transaction is not in the pool")
 }
 
+// monitorVersion returns the block version the monitors of transactions are
+// checked for: Version7 once its deployment is active, in which case monitors
+// are called as CheckMonitors does, and Version4 otherwise.
+func (mp *TxPool) monitorVersion() uint32 {
+	if mp.cfg.IsDeploymentActive == nil {
+		return wire.Version4
+	}
+	if active, err := mp.cfg.IsDeploymentActive(chaincfg.DeploymentVersion7); err != nil || !active {
+		return wire.Version4
+	}
+	return wire.Version7
+}
+
 // maybeAcceptTransaction is the internal function which implements the public
 // MaybeAcceptTransaction.  See the comment for MaybeAcceptTransaction for
 // more details.
@@ -1007,7 +1020,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 		}
 	}
 	if fulllValidate {
-		err = ovm.VerifySigs(tx, mp.cfg.ChainParams, 0, views)
+		err = ovm.VerifySigs(tx, mp.cfg.ChainParams, 0, views, mp.monitorVersion())
 		if err != nil {
 			return nil,nil, err
 		}
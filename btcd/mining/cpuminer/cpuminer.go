@@ -26,6 +26,7 @@ import (
 	"github.com/omegasuite/btcd/mining"
 	"github.com/omegasuite/btcd/wire"
 	"github.com/omegasuite/btcutil"
+	"github.com/omegasuite/omega/signer"
 	"github.com/omegasuite/omega/token"
 )
 
@@ -73,7 +74,10 @@ type Config struct {
 	// blocks.  Each generated block will randomly choose one of them.
 	MiningAddrs      []btcutil.Address
 	SignAddress      []btcutil.Address
-	PrivKeys         []*btcec.PrivateKey
+
+	// Signers sign blocks for the addresses of SignAddress, in the same
+	// order.
+	Signers          []signer.Signer
 	DisablePOWMining bool
 	EnablePOWMining  bool
 
@@ -102,6 +106,12 @@ type Config struct {
 	AppendPrivKey	  func (*btcec.PrivateKey) bool
 
 	Generate	bool
+
+	// Consensus is the consensus engine of the node. It stops the miner
+	// from solving a block when the committee has started to sign one, and
+	// serves the blocks being signed. It is nil when the node does not take
+	// part in the committee.
+	Consensus *consensus.Engine
 }
 
 // CPUMiner provides facilities for solving blocks (mining) using the CPU in
@@ -202,7 +212,7 @@ func (m *CPUMiner) submitBlock(block *btcutil.Block) bool {
 	// nodes.  This will in turn relay it to the network like normal.
 	flag := blockchain.BFNone
 
-	if block.MsgBlock().Header.Nonce < 0 && wire.CommitteeSize > 1 {
+	if block.MsgBlock().Header.Nonce < 0 && m.g.Chain.ChainParams.Committee(block.MsgBlock().Header.Version).Size > 1 {
 		flag = blockchain.BFSubmission | blockchain.BFNoConnect
 	}
 	coinbaseTx := block.MsgBlock().Transactions[0].TxOut[0]
@@ -266,10 +276,10 @@ func (m *CPUMiner) solveBlock(template *mining.BlockTemplate, blockHeight int32,
 		s, _ := m.g.Chain.Miners.BlockByHeight(int32(st.LastRotation))
 		blk := m.g.Chain.NodeByHash(&s.MsgBlock().BestBlock)
 
-		for blk != nil && blk.Data.GetNonce() > -wire.MINER_RORATE_FREQ {
+		for blk != nil && blk.Data.GetNonce() > -m.g.Chain.RotateFreq(blk) {
 			blk = blk.Parent
 		}
-		pows := int32(st.LastRotation) + (blk.Data.GetNonce() + wire.MINER_RORATE_FREQ) - wire.DESIRABLE_MINER_CANDIDATES
+		pows := int32(st.LastRotation) + (blk.Data.GetNonce() + m.g.Chain.RotateFreq(blk)) - m.g.Chain.Committee(int32(st.LastRotation)).DesirableCandidates
 		if pows < 0 {
 			pows = 0
 		}
@@ -305,7 +315,7 @@ func (m *CPUMiner) solveBlock(template *mining.BlockTemplate, blockHeight int32,
 			case <-m.connch:
 				return 0
 
-			case <-consensus.POWStopper:
+			case <-m.cfg.Consensus.POWStopper():
 				return 0
 
 			case <-ticker.C:
@@ -406,7 +416,7 @@ func (m *CPUMiner) CurrentBlock(h * chainhash.Hash) * btcutil.Block {
 			return m.minedBlock
 		}
 	}
-	return consensus.ServeBlock(h)
+	return m.cfg.Consensus.ServeBlock(h)
 }
 
 func (m *CPUMiner) AddMiningKey(miningAddr *btcec.PrivateKey) bool {
@@ -457,7 +467,7 @@ out:
 			case <-m.quit:
 				break out
 				
-			case <-consensus.POWStopper:
+			case <-m.cfg.Consensus.POWStopper():
 
 			case k := <- m.miningkeys:
 				pkaddr, err := btcutil.NewAddressPubKey(k.PubKey().SerializeCompressed(), m.cfg.ChainParams)
@@ -475,7 +485,7 @@ out:
 						m.addkeyresult <- true
 					} else {
 						if m.cfg.AppendPrivKey(k) {
-							m.cfg.PrivKeys = append(m.cfg.PrivKeys, k)
+							m.cfg.Signers = append(m.cfg.Signers, signer.NewKeySigner(k))
 							m.cfg.SignAddress = append(m.cfg.SignAddress, addr)
 							m.addkeyresult <- true
 						} else {
@@ -540,21 +550,27 @@ out:
 		tip := m.g.Chain.BestChain.Tip()
 		pb := tip.Data.GetNonce()
 		height := tip.Height
+		params, err := m.g.Chain.Committee(int32(bs.LastRotation))
+		if err != nil {
+			log.Infof("generateBlocks: sleep because the committee is not known: %s", err.Error())
+			time.Sleep(time.Second * 5)
+			continue
+		}
 
 		committee, in := m.g.Committee()
 
 		var adr [20]byte
 		powMode := true
-		var sigaddr *btcec.PrivateKey
+		var sigaddr signer.Signer
 
 		log.Infof("committee size = %d. I am %v", len(committee), in)
 
-		if len(m.cfg.SignAddress) != 0 && len(committee) == wire.CommitteeSize && in {
+		if len(m.cfg.SignAddress) != 0 && len(committee) == int(params.Size) && in {
 			for j,pt := range m.cfg.SignAddress {
 				copy(adr[:], pt.ScriptAddress())
 				if _, ok := committee[adr]; ok {
 					payToAddr = m.cfg.SignAddress[j]
-					sigaddr = m.cfg.PrivKeys[j]
+					sigaddr = m.cfg.Signers[j]
 					powMode = false
 					break
 				}
@@ -582,11 +598,11 @@ out:
 		if !powMode {
 //			log.Infof("Non-POW mode")
 
-			if nonce >= 0 || nonce <= -wire.MINER_RORATE_FREQ {
+			if nonce >= 0 || nonce <= -m.g.Chain.RotateFreq(tip) {
 				nonce = -1
-			} else if nonce == -wire.MINER_RORATE_FREQ+1 {
+			} else if nonce == -params.RotateFreq+1 {
 				h := int32(bs.LastRotation) + 1
-				nonce = -h - wire.MINER_RORATE_FREQ
+				nonce = -h - params.RotateFreq
 				if mb, err := m.g.Chain.Miners.BlockByHeight(h); err != nil || mb == nil {
 					log.Infof("generateBlocks: sleep because MR block %d is not available", h)
 					time.Sleep(time.Second * 5)
@@ -596,7 +612,7 @@ out:
 				nonce--
 			}
 
-			if wire.CommitteeSize > 1 {
+			if params.Size > 1 {
 				// check collateral. kick out those not qualified.
 				payToAddress = m.coinbaseByCommittee(payToAddr)
 				if len(payToAddress) == 0 {
@@ -606,7 +622,7 @@ out:
 					payToAddr = m.cfg.MiningAddrs[rand.Int() % len(m.cfg.MiningAddrs)]
 					payToAddress = []btcutil.Address{payToAddr}
 					nonce = 1
-				} else if len(payToAddress) <= wire.CommitteeSize / 2 {
+				} else if len(payToAddress) <= int(params.Size / 2) {
 					// impossible to form a qualified consensus
 					log.Infof("Change to POW mining because insufficient committee members.")
 					powMode = true
@@ -643,9 +659,12 @@ out:
 //		log.Infof("New template with %d txs", len(template.Block.(*wire.MsgBlock).Transactions))
 
 		if !powMode {
-			if wire.CommitteeSize == 1 {
+			if params.Size == 1 {
 				// solo miner, add signature to coinbase, otherwise will add after committee decides
-				mining.AddSignature(block, sigaddr)
+				if err := mining.AddSignature(block, sigaddr); err != nil {
+					log.Errorf("Unable to sign block: %v", err)
+					continue
+				}
 			} else {
 //				block.ClearSize()
 				block.MsgBlock().Transactions[0].SignatureScripts = append(block.MsgBlock().Transactions[0].SignatureScripts, adr[:])
@@ -677,7 +696,7 @@ out:
 
 			lastblkgen = time.Now().Unix()
 
-			if template.Height > m.g.Chain.ConsensusRange[1] + wire.MINER_RORATE_FREQ / 2 {
+			if template.Height > m.g.Chain.ConsensusRange[1] + params.RotateFreq / 2 {
 				m.g.Chain.ConsensusRange[0] = template.Height
 			}
 			m.g.Chain.ConsensusRange[1] = template.Height
@@ -701,7 +720,7 @@ out:
 					}
 					lastblkrcv = time.Now().Unix()
 					
-				case _,ok := <-consensus.POWStopper:
+				case _,ok := <-m.cfg.Consensus.POWStopper():
 					if !ok {
 						break connected
 					}
@@ -717,7 +736,7 @@ out:
 					}
 
 					log.Infof("cpuminer waiting for consus to finish block %d", block.Height())
-//					consensus.DebugInfo()
+//					m.cfg.Consensus.DebugInfo()
  */
 				}
 			}
@@ -753,7 +772,7 @@ out:
 		case <-m.connch:
 			continue
 
-		case <-consensus.POWStopper:
+		case <-m.cfg.Consensus.POWStopper():
 			continue
 
 		case <-m.quit:
@@ -782,7 +801,7 @@ out:
 				lastblkrcv = time.Now().Unix()
 				continue
 
-			case <-consensus.POWStopper:
+			case <-m.cfg.Consensus.POWStopper():
 				continue
 
 			case <-m.quit:
@@ -825,8 +844,14 @@ func (m *CPUMiner) coinbaseByCommittee(me btcutil.Address) []btcutil.Address {
 	// check collateral, any miner who has collateral spent will not
 	// be qualified for award, his signature will not be accepted. award
 	// will be distributed only among those whose collateral are intact.
+	params, err := m.g.Chain.Committee(int32(bh))
+	if err != nil {
+		log.Infof("coinbaseByCommittee: %s", err.Error())
+		return nil
+	}
+
 	qualified := false
-	for i := -int32(wire.CommitteeSize - 1); i <= 0; i++ {
+	for i := 1 - params.Size; i <= 0; i++ {
 		if mb, _ := m.g.Chain.Miners.BlockByHeight(int32(bh) + i); mb != nil {
 			if _,err := m.g.Chain.CheckCollateral(mb, nil, blockchain.BFNone); err != nil {
 				log.Infof("CheckCollateral failed")
@@ -842,7 +867,7 @@ func (m *CPUMiner) coinbaseByCommittee(me btcutil.Address) []btcutil.Address {
 		}
 	}
 
-	if qualified && good >= wire.CommitteeSigs {
+	if qualified && good >= int64(params.Sigs) {
 		return addresses
 	} else {
 		return nil
@@ -870,8 +895,6 @@ func (m *CPUMiner) Start() {
 //	go m.speedMonitor()
 	go m.generateBlocks()
 
-	consensus.POWStopper = make(chan struct{}, 3 * wire.MINER_RORATE_FREQ)
-
 	m.started = true
 	log.Infof("CPU miner started")
 }
@@ -1084,8 +1107,6 @@ func New(cfg *Config) *CPUMiner {
 		addkeyresult:	   make(chan bool),
 	}
 
-	consensus.POWStopper = make(chan struct{}, 3 * wire.MINER_RORATE_FREQ)
-
 	m.g.Chain.Subscribe(m.Notice)	// Miners.(*minerchain.MinerChain).
 	m.g.Chain.Miners.Subscribe(m.Notice)	// Miners.(*minerchain.MinerChain).
 	return m
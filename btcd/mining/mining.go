@@ -17,12 +17,12 @@ import (
 //	"encoding/hex"
 
 	"github.com/omegasuite/btcd/blockchain"
-	"github.com/omegasuite/btcd/btcec"
 	"github.com/omegasuite/btcd/chaincfg"
 	"github.com/omegasuite/btcd/chaincfg/chainhash"
 	"github.com/omegasuite/btcd/wire"
 	"github.com/omegasuite/btcd/wire/common"
 	"github.com/omegasuite/btcutil"
+	"github.com/omegasuite/omega/signer"
 	"github.com/omegasuite/omega/token"
 	"github.com/omegasuite/omega/viewpoint"
 )
@@ -893,7 +893,7 @@ skiprest:
 			continue
 		}
 
-		vmerr := ovm.VerifySigs(tx, g.chainParams, 0, views)
+		vmerr := ovm.VerifySigs(tx, g.chainParams, 0, views, s.MsgBlock().Version &^ 0xFFFF)
 		if vmerr != nil {
 			g.txSource.RemoveTransaction(tx, true)
 			g.Chain.SendNotification(blockchain.NTBlockRejected, tx)
@@ -1345,7 +1345,11 @@ func (g *BlkTmplGenerator) TxSource() TxSource {
 
 func (g *BlkTmplGenerator) ActiveMiner(address btcutil.Address) bool {
 	h := g.BestSnapshot().LastRotation		// .Chain.LastRotation(g.BestSnapshot().Hash)
-	n := h - wire.CommitteeSize
+	committee, err := g.Chain.Committee(int32(h))
+	if err != nil {
+		return false
+	}
+	n := h - uint32(committee.Size)
 	for n < h {
 		n++
 		m,_ := g.Chain.Miners.BlockByHeight(int32(n))
@@ -1363,9 +1367,15 @@ func (g *BlkTmplGenerator) Committee() (map[[20]byte]struct{}, bool) {
 
 	log.Infof("Get committee at last rotation = %d", h)
 
+	committee, err := g.Chain.Committee(int32(h))
+	if err != nil {
+		log.Infof("Committee: %s", err.Error())
+		return nil, false
+	}
+
 	adrs, in := make(map[[20]byte]struct{}), false
 
-	for n := h - wire.CommitteeSize + 1; n <= h; n++ {
+	for n := h - uint32(committee.Size) + 1; n <= h; n++ {
 		if m,_ := g.Chain.Miners.BlockByHeight(int32(n)); m != nil {
 			adrs[m.MsgBlock().Miner] = struct{}{}
 			for _, ip := range g.chainParams.ExternalIPs {
@@ -1383,16 +1393,16 @@ func (g *BlkTmplGenerator) Committee() (map[[20]byte]struct{}, bool) {
 	return adrs, in
 }
 
-func AddSignature(block * btcutil.Block, privkey *btcec.PrivateKey) {
-	hash := blockchain.MakeMinerSigHash(block.Height(), *block.Hash())
-
-	sig,_ := privkey.Sign(hash)
-	pubk := privkey.PubKey().SerializeCompressed()	// 33 bytes always
-	st := sig.Serialize()
+func AddSignature(block * btcutil.Block, s signer.Signer) error {
+	sig, err := signer.Sign(s, signer.BlockRequest(block.Height(), *block.Hash()))
+	if err != nil {
+		return err
+	}
 	if block.MsgBlock().Transactions[0].SignatureScripts == nil || len(block.MsgBlock().Transactions[0].SignatureScripts) == 0 {
 		// signature 0 of coinbase is for signature merkle root
 		block.MsgBlock().Transactions[0].SignatureScripts = make([][]byte, 1)
 		block.MsgBlock().Transactions[0].SignatureScripts[0] = []byte{}
 	}
-	block.MsgBlock().Transactions[0].SignatureScripts = append(block.MsgBlock().Transactions[0].SignatureScripts, append(pubk, st...))
+	block.MsgBlock().Transactions[0].SignatureScripts = append(block.MsgBlock().Transactions[0].SignatureScripts, sig)
+	return nil
 }
\ No newline at end of file
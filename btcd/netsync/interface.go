@@ -12,6 +12,7 @@ import (
 	"github.com/omegasuite/btcd/peer"
 	"github.com/omegasuite/btcd/wire"
 	"github.com/omegasuite/btcutil"
+	"github.com/omegasuite/omega/consensus"
 )
 
 // PeerNotifier exposes methods to notify peers of status changes to
@@ -40,4 +41,8 @@ type Config struct {
 	MaxPeers           int
 
 	FeeEstimator *mempool.FeeEstimator
+
+	// Consensus is the consensus engine blocks being signed are passed to.
+	// It is nil when the node does not take part in the committee.
+	Consensus *consensus.Engine
 }
@@ -204,6 +204,7 @@ type SyncManager struct {
 	chain          *blockchain.BlockChain
 	txMemPool      *mempool.TxPool
 	chainParams    *chaincfg.Params
+	consensus      *consensus.Engine
 	progressLogger *blockProgressLogger
 	msgChan        chan interface{}
 	wg             sync.WaitGroup
@@ -449,13 +450,18 @@ func (sm *SyncManager) startSync(avoid *peerpkg.Peer) bool {
 		}
 		if bestPeer != nil && peer != avoid {
 			if sm.chain.IsCurrent() {
-				// peer priority: select by committe first, length of chain
+				// peer priority: select by committe first, length of chain.
+				// if the committee is not known, by length of chain only
+				size := int32(0)
+				if committee, err := sm.chain.Committee(int32(best.LastRotation)); err == nil {
+					size = committee.Size
+				}
 				cd := int32(best.LastRotation) - bestPeer.Committee
 				cp := int32(best.LastRotation) - peer.Committee
-				if cd >= 0 && cd < wire.CommitteeSize && (cp < 0 || cp >= wire.CommitteeSize) {
+				if cd >= 0 && cd < size && (cp < 0 || cp >= size) {
 					continue
 				}
-				if !(cp >= 0 && cp < wire.CommitteeSize && (cd < 0 || cd >= wire.CommitteeSize)) &&
+				if !(cp >= 0 && cp < size && (cd < 0 || cd >= size)) &&
 					peer.LastBlock() < bestPeer.LastBlock() {
 					continue
 				}
@@ -788,8 +794,9 @@ func (sm *SyncManager) handleBlockMsg(bmsg *blockMsg) {
 
 	// if it is a block being processed by the committee, veryfy it is from the peer
 	// producing, i.e. the address in coinbase signature is the peer's
-	if wire.CommitteeSize > 1 && bmsg.block.MsgBlock().Header.Nonce < 0 &&
-		len(bmsg.block.MsgBlock().Transactions[0].SignatureScripts) <= wire.CommitteeSigs {
+	committee := sm.chainParams.Committee(bmsg.block.MsgBlock().Header.Version)
+	if committee.Size > 1 && bmsg.block.MsgBlock().Header.Nonce < 0 &&
+		len(bmsg.block.MsgBlock().Transactions[0].SignatureScripts) <= int(committee.Sigs) {
 		if len(bmsg.block.MsgBlock().Transactions[0].SignatureScripts) < 2 {
 			log.Errorf("handleBlockMsg: blocked because of insufficient signatures. Require 2 items in coinbase signatures.")
 			return
@@ -799,7 +806,7 @@ func (sm *SyncManager) handleBlockMsg(bmsg *blockMsg) {
 			// not the same
 //			return
 //		}
-//		if peer.Committee < int32(sm.chain.BestSnapshot().LastRotation) - wire.CommitteeSize {
+//		if peer.Committee < int32(sm.chain.BestSnapshot().LastRotation) - committee.Size {
 //			log.Infof("handleBlockMsg: blocked for out of committee")
 //			return
 //		}
@@ -923,7 +930,7 @@ func (sm *SyncManager) handleBlockMsg(bmsg *blockMsg) {
 	if behaviorFlags & blockchain.BFNoConnect == blockchain.BFNoConnect {
 		// passing it consus
 		sm.cachedBlocks[*blockHash] = ht
-		consensus.ProcessBlock(bmsg.block, behaviorFlags)
+		sm.consensus.ProcessBlock(bmsg.block, behaviorFlags)
 		return
 	}
 
@@ -1935,7 +1942,7 @@ out:
 				}
 
 			case processConsusMsg:
-				consensus.ProcessBlock(msg.block, msg.flags)
+				sm.consensus.ProcessBlock(msg.block, msg.flags)
 
 			case processMinerBlockMsg:
 				if sm.chainParams.Net == common.TestNet || sm.chainParams.Net == common.SimNet|| sm.chainParams.Net == common.RegNet {
@@ -2251,7 +2258,8 @@ func (sm *SyncManager) SyncPeerID() int32 {
 func (sm *SyncManager) ProcessBlock(block *btcutil.Block, flags blockchain.BehaviorFlags) (bool, error) {
 	reply := make(chan processBlockResponse, 1)
 
-	if block.MsgBlock().Header.Nonce < 0 && wire.CommitteeSize > 1 && len(block.MsgBlock().Transactions[0].SignatureScripts) <= wire.CommitteeSigs {
+	committee := sm.chainParams.Committee(block.MsgBlock().Header.Version)
+	if block.MsgBlock().Header.Nonce < 0 && committee.Size > 1 && len(block.MsgBlock().Transactions[0].SignatureScripts) <= int(committee.Sigs) {
 		log.Debugf("procssing a comittee block, height = %d", block.Height())
 		sm.msgChan <- processBlockMsg{block: block, flags: flags | blockchain.BFSubmission, reply: reply}
 
@@ -2309,6 +2317,7 @@ func New(config *Config) (*SyncManager, error) {
 		chain:           config.Chain,
 		txMemPool:       config.TxMemPool,
 		chainParams:     config.ChainParams,
+		consensus:       config.Consensus,
 		rejectedTxns:    make(map[chainhash.Hash]struct{}),
 		requestedTxns:   make(map[chainhash.Hash]struct{}),
 		requestedBlocks: make(map[chainhash.Hash]int),
@@ -286,6 +286,11 @@ type Config struct {
 	// TrickleInterval is the duration of the ticker which trickles down the
 	// inventory to a peer.
 	TrickleInterval time.Duration
+
+	// Consensus is the consensus engine consensus messages are handed to.
+	// This field can be omitted when the node does not take part in the
+	// committee.
+	Consensus *consensus.Engine
 }
 
 // minUint32 is a helper function to return the minimum of two uint32s.
@@ -1709,7 +1714,7 @@ out:
 			log.Debugf("inHandler consensus.Message %s", msg.Command())
 			var ea [20]byte
 			if p.Inbound() && bytes.Compare(p.Miner[:], ea[:]) == 0 {
-				sender := consensus.Sender(msg)
+				sender := p.cfg.Consensus.Sender(msg)
 				if sender == nil {
 					log.Debugf("inHandler consensus.Message %s sender unknown", msg.Command())
 				} else {
@@ -1718,7 +1723,7 @@ out:
 				}
 			}
 
-			push, h := consensus.HandleMessage(msg)
+			push, h := p.cfg.Consensus.HandleMessage(msg)
 			if push && p.cfg.Listeners.PushGetBlock != nil {
 				log.Debugf("inHandler consensus.Message asks PushGetBlock")
 				p.cfg.Listeners.PushGetBlock(p)
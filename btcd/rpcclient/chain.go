@@ -14,6 +14,8 @@ import (
 	"github.com/omegasuite/btcd/btcjson"
 	"github.com/omegasuite/btcd/chaincfg/chainhash"
 	"github.com/omegasuite/btcd/wire"
+	"github.com/omegasuite/btcutil"
+	"github.com/omegasuite/omega/ovm/abi"
 )
 
 // FutureGetBestBlockHashResult is a future promise to deliver the result of a
@@ -117,7 +119,9 @@ func (r FutureGetBlockResult) Receive() (*wire.MsgBlock, error) {
 	return &msgBlock, nil
 }
 
-func (c *Client) SearchBorder(west, east, south, north int32, lod byte) ([]chainhash.Hash, error) {
+// SearchBorder returns the borders of the polygons of unspent polygon tokens
+// intersecting a box given in WGS84 degrees.
+func (c *Client) SearchBorder(west, east, south, north float64, lod byte) ([]chainhash.Hash, error) {
 	return c.SearchBorderAsync(west, east, south, north, lod).Receive()
 }
 
@@ -149,23 +153,20 @@ func (r FutureSearchBorderResult) Receive() ([]chainhash.Hash, error) {
 		return nil, err
 	}
 
-	// Unmarshal result as a string.
-	var blockHex string
-	err = json.Unmarshal(res, &blockHex)
+	// Unmarshal result as an array of hash strings.
+	var hashes []string
+	err = json.Unmarshal(res, &hashes)
 	if err != nil {
 		return nil, err
 	}
 
-	// Decode the serialized block hex to raw bytes.
-	serializedBlock, err := hex.DecodeString(blockHex)
-	if err != nil {
-		return nil, err
-	}
-
-	// Deserialize the block and return it.
-	h := make([]chainhash.Hash, len(serializedBlock) / chainhash.HashSize)
-	for i := 0; i < len(serializedBlock); i += chainhash.HashSize {
-		copy(h[i / chainhash.HashSize][:], serializedBlock[i:])
+	h := make([]chainhash.Hash, len(hashes))
+	for i, s := range hashes {
+		hash, err := chainhash.NewHashFromStr(s)
+		if err != nil {
+			return nil, err
+		}
+		h[i] = *hash
 	}
 	return h, nil
 }
@@ -250,16 +251,453 @@ func (c *Client) GetBlockTxHashesAsync(blockHash *chainhash.Hash) FutureGetBlock
 	return c.sendCmd(cmd)
 }
 
-func (c *Client) SearchBorderAsync(west, east, south, north int32, lod byte) FutureSearchBorderResult {
+func (c *Client) SearchBorderAsync(west, east, south, north float64, lod byte) FutureSearchBorderResult {
 	cmd := btcjson.NewSearchBorderCmd(west, east, south, north, lod)
 	return c.sendCmd(cmd)
 }
 
+// ContractCallAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See ContractCall for the blocking version and more details.
 func (c *Client) ContractCallAsync(contract, input string) FutureContractCallResult {
-	cmd := btcjson.NewContractCallCmd(contract, input)
+	cmd := btcjson.NewContractCallCmd(contract, input, nil)
+	return c.sendCmd(cmd)
+}
+
+// ContractCall calls a contract function with the call data input in hex and
+// returns the return data. Changes to contract states are discarded.
+func (c *Client) ContractCall(contract, input string) ([]byte, error) {
+	return c.ContractCallAsync(contract, input).Receive()
+}
+
+// FutureContractCallMethodResult is a future promise to deliver the result of
+// a ContractCallMethodAsync RPC invocation (or an applicable error).
+type FutureContractCallMethodResult struct {
+	method *abi.Method
+	result FutureContractCallResult
+}
+
+// Receive waits for the response promised by the future and returns the
+// return values of the method.
+func (r FutureContractCallMethodResult) Receive() ([]interface{}, error) {
+	data, err := r.result.Receive()
+	if err != nil {
+		return nil, err
+	}
+	return r.method.Unpack(data)
+}
+
+// ContractCallMethodAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See ContractCallMethod for the blocking version and more details.
+func (c *Client) ContractCallMethodAsync(contract string, method *abi.Method, args ...interface{}) FutureContractCallMethodResult {
+	input, err := method.Pack(args...)
+	if err != nil {
+		return FutureContractCallMethodResult{method, newFutureError(err)}
+	}
+	return FutureContractCallMethodResult{method, c.ContractCallAsync(contract, hex.EncodeToString(input))}
+}
+
+// ContractCallMethod calls method of a contract with args and returns the
+// decoded return values. See the abi package for the Go types of arguments
+// and return values.
+func (c *Client) ContractCallMethod(contract string, method *abi.Method, args ...interface{}) ([]interface{}, error) {
+	return c.ContractCallMethodAsync(contract, method, args...).Receive()
+}
+
+// FutureTryContractResult is a future promise to deliver the result of a
+// TryContractAsync RPC invocation (or an applicable error).
+type FutureTryContractResult chan *Response
+
+// Receive waits for the response promised by the future and returns the
+// result of running the contracts called by the transaction.
+func (r FutureTryContractResult) Receive() (*btcjson.TryResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result btcjson.TryResult
+	if err := json.Unmarshal(res, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// tryContractAsync sends a trycontract command for tx.
+func (c *Client) tryContractAsync(tx *wire.MsgTx, trace bool, method *string, args *[]interface{}) FutureTryContractResult {
+	buf := bytes.NewBuffer(make([]byte, 0, tx.SerializeSize()))
+	if err := tx.OmcEncode(buf, 0, wire.SignatureEncoding); err != nil {
+		return newFutureError(err)
+	}
+
+	cmd := btcjson.NewTryContractCmd(hex.EncodeToString(buf.Bytes()), &trace, method, args)
+	return c.sendCmd(cmd)
+}
+
+// TryContractAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See TryContract for the blocking version and more details.
+func (c *Client) TryContractAsync(tx *wire.MsgTx, trace bool) FutureTryContractResult {
+	return c.tryContractAsync(tx, trace, nil, nil)
+}
+
+// TryContract runs the contracts called by tx without adding it to the
+// mempool. With trace, the instructions executed are returned.
+func (c *Client) TryContract(tx *wire.MsgTx, trace bool) (*btcjson.TryResult, error) {
+	return c.TryContractAsync(tx, trace).Receive()
+}
+
+// FutureTryContractMethodResult is a future promise to deliver the result of
+// a TryContractMethodAsync RPC invocation (or an applicable error).
+type FutureTryContractMethodResult struct {
+	method *abi.Method
+	result FutureTryContractResult
+}
+
+// Receive waits for the response promised by the future and returns the
+// result of running the contracts called by the transaction and the return
+// values of the method.
+func (r FutureTryContractMethodResult) Receive() (*btcjson.TryResult, []interface{}, error) {
+	result, err := r.result.Receive()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := hex.DecodeString(result.Result)
+	if err != nil {
+		return nil, nil, err
+	}
+	values, err := r.method.Unpack(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, values, nil
+}
+
+// TryContractMethodAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See TryContractMethod for the blocking version and more details.
+func (c *Client) TryContractMethodAsync(tx *wire.MsgTx, method *abi.Method, args ...interface{}) FutureTryContractMethodResult {
+	if _, err := method.Pack(args...); err != nil {
+		return FutureTryContractMethodResult{method, newFutureError(err)}
+	}
+
+	jsonArgs := abi.JSONValues(args)
+	return FutureTryContractMethodResult{method, c.tryContractAsync(tx, false, &method.Sig, &jsonArgs)}
+}
+
+// TryContractMethod runs the first contract call in tx as a call of method
+// with args, without adding tx to the mempool, and returns the result and the
+// decoded return values. See the abi package for the Go types of arguments and
+// return values.
+func (c *Client) TryContractMethod(tx *wire.MsgTx, method *abi.Method, args ...interface{}) (*btcjson.TryResult, []interface{}, error) {
+	return c.TryContractMethodAsync(tx, method, args...).Receive()
+}
+
+// FutureGetContractStateResult is a future promise to deliver the result of a
+// GetContractStateAsync RPC invocation (or an applicable error).
+type FutureGetContractStateResult chan *Response
+
+// Receive waits for the response promised by the future and returns the
+// storage of the contract.
+func (r FutureGetContractStateResult) Receive() (*btcjson.ContractStateResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var state btcjson.ContractStateResult
+	if err := json.Unmarshal(res, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// GetContractStateAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See GetContractState for the blocking version and more details.
+func (c *Client) GetContractStateAsync(contract string, keys []string, height int32) FutureGetContractStateResult {
+	var k *[]string
+	if keys != nil {
+		k = &keys
+	}
+	cmd := btcjson.NewGetContractStateCmd(contract, k, &height, nil)
+	return c.sendCmd(cmd)
+}
+
+// GetContractState returns the storage entries of a contract under keys in
+// hex, or its whole storage if keys is nil, as it was after the block at
+// height.
+func (c *Client) GetContractState(contract string, keys []string, height int32) (*btcjson.ContractStateResult, error) {
+	return c.GetContractStateAsync(contract, keys, height).Receive()
+}
+
+// FutureGetContractEventsResult is a future promise to deliver the result of a
+// GetContractEventsAsync RPC invocation (or an applicable error).
+type FutureGetContractEventsResult chan *Response
+
+// Receive waits for the response promised by the future and returns the
+// events of the contract.
+func (r FutureGetContractEventsResult) Receive() ([]btcjson.ContractEventResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []btcjson.ContractEventResult
+	if err := json.Unmarshal(res, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetContractEventsAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See GetContractEvents for the blocking version and more details.
+func (c *Client) GetContractEventsAsync(contract string, topic *uint32, fromHeight int32, count int) FutureGetContractEventsResult {
+	cmd := btcjson.NewGetContractEventsCmd(contract, topic, &fromHeight, &count)
+	return c.sendCmd(cmd)
+}
+
+// GetContractEvents returns up to count events emitted by a contract in blocks
+// at or above fromHeight, only those of topic if it is not nil.
+func (c *Client) GetContractEvents(contract string, topic *uint32, fromHeight int32, count int) ([]btcjson.ContractEventResult, error) {
+	return c.GetContractEventsAsync(contract, topic, fromHeight, count).Receive()
+}
+
+// FuturePolygonsResult is a future promise to deliver the result of a
+// PolygonsAtAsync or PolygonsInBoxAsync RPC invocation (or an applicable
+// error).
+type FuturePolygonsResult chan *Response
+
+// Receive waits for the response promised by the future and returns the
+// polygons found.
+func (r FuturePolygonsResult) Receive() ([]btcjson.PolygonResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var polygons []btcjson.PolygonResult
+	if err := json.Unmarshal(res, &polygons); err != nil {
+		return nil, err
+	}
+	return polygons, nil
+}
+
+// PolygonsAtAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See PolygonsAt for the blocking version and more details.
+func (c *Client) PolygonsAtAsync(lat, lng float64) FuturePolygonsResult {
+	cmd := btcjson.NewPolygonsAtCmd(lat, lng)
+	return c.sendCmd(cmd)
+}
+
+// PolygonsAt returns the polygons of unspent polygon tokens covering a point
+// given in WGS84 degrees, with the outputs of their tokens.
+func (c *Client) PolygonsAt(lat, lng float64) ([]btcjson.PolygonResult, error) {
+	return c.PolygonsAtAsync(lat, lng).Receive()
+}
+
+// PolygonsInBoxAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See PolygonsInBox for the blocking version and more details.
+func (c *Client) PolygonsInBoxAsync(west, east, south, north float64, count int) FuturePolygonsResult {
+	cmd := btcjson.NewPolygonsInBoxCmd(west, east, south, north, &count)
+	return c.sendCmd(cmd)
+}
+
+// PolygonsInBox returns up to count polygons of unspent polygon tokens whose
+// bounding boxes intersect a box given in WGS84 degrees, with the outputs of
+// their tokens.
+func (c *Client) PolygonsInBox(west, east, south, north float64, count int) ([]btcjson.PolygonResult, error) {
+	return c.PolygonsInBoxAsync(west, east, south, north, count).Receive()
+}
+
+// FutureExportPolygonResult is a future promise to deliver the result of an
+// ExportPolygonAsync RPC invocation (or an applicable error).
+type FutureExportPolygonResult chan *Response
+
+// Receive waits for the response promised by the future and returns the
+// GeoJSON or WKT text of the polygon.
+func (r FutureExportPolygonResult) Receive() (string, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return "", err
+	}
+
+	var text string
+	if err := json.Unmarshal(res, &text); err != nil {
+		return "", err
+	}
+	return text, nil
+}
+
+// ExportPolygonAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See ExportPolygon for the blocking version and more details.
+func (c *Client) ExportPolygonAsync(polygon *chainhash.Hash, format string) FutureExportPolygonResult {
+	cmd := btcjson.NewExportPolygonCmd(polygon.String(), &format)
 	return c.sendCmd(cmd)
 }
 
+// ExportPolygon returns the shape of a polygon defined in the chain as
+// "geojson" or "wkt" text.
+func (c *Client) ExportPolygon(polygon *chainhash.Hash, format string) (string, error) {
+	return c.ExportPolygonAsync(polygon, format).Receive()
+}
+
+// FutureBuildPolygonTxResult is a future promise to deliver the result of a
+// BuildPolygonTxAsync RPC invocation (or an applicable error).
+type FutureBuildPolygonTxResult chan *Response
+
+// Receive waits for the response promised by the future and returns the
+// unsigned transaction with the polygon and borders it defines.
+func (r FutureBuildPolygonTxResult) Receive() (*btcjson.BuildPolygonTxResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result btcjson.BuildPolygonTxResult
+	if err := json.Unmarshal(res, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// BuildPolygonTxAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See BuildPolygonTx for the blocking version and more details.
+func (c *Client) BuildPolygonTxAsync(geometry, format, address string, rights *chainhash.Hash,
+	inputs []btcjson.TransactionInput, lockTime *int64) FutureBuildPolygonTxResult {
+	cmd := btcjson.NewBuildPolygonTxCmd(geometry, address, rights.String(), &inputs, &format, lockTime)
+	return c.sendCmd(cmd)
+}
+
+// BuildPolygonTx returns an unsigned transaction defining a polygon given as
+// "geojson" or "wkt" text and paying a token of it to address.
+func (c *Client) BuildPolygonTx(geometry, format, address string, rights *chainhash.Hash,
+	inputs []btcjson.TransactionInput, lockTime *int64) (*btcjson.BuildPolygonTxResult, error) {
+	return c.BuildPolygonTxAsync(geometry, format, address, rights, inputs, lockTime).Receive()
+}
+
+// FuturePolygonInfoResult is a future promise to deliver the result of a
+// PolygonInfoAsync RPC invocation (or an applicable error).
+type FuturePolygonInfoResult chan *Response
+
+// Receive waits for the response promised by the future and returns the
+// measures of the polygon.
+func (r FuturePolygonInfoResult) Receive() (*btcjson.PolygonInfoResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result btcjson.PolygonInfoResult
+	if err := json.Unmarshal(res, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// PolygonInfoAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See PolygonInfo for the blocking version and more details.
+func (c *Client) PolygonInfoAsync(polygon, other *chainhash.Hash) FuturePolygonInfoResult {
+	var o *string
+	if other != nil {
+		o = btcjson.String(other.String())
+	}
+	cmd := btcjson.NewPolygonInfoCmd(polygon.String(), o)
+	return c.sendCmd(cmd)
+}
+
+// PolygonInfo returns the area and perimeter of a polygon and, if other is
+// not nil, how it overlaps polygon other.
+func (c *Client) PolygonInfo(polygon, other *chainhash.Hash) (*btcjson.PolygonInfoResult, error) {
+	return c.PolygonInfoAsync(polygon, other).Receive()
+}
+
+// FuturePolygonTxResult is a future promise to deliver the result of a
+// SplitPolygonAsync or MergePolygonsAsync RPC invocation (or an applicable
+// error).
+type FuturePolygonTxResult chan *Response
+
+// Receive waits for the response promised by the future and returns the
+// unsigned transaction with the polygons it pays and the borders it defines.
+func (r FuturePolygonTxResult) Receive() (*btcjson.PolygonTxResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result btcjson.PolygonTxResult
+	if err := json.Unmarshal(res, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SplitPolygonAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See SplitPolygon for the blocking version and more details.
+func (c *Client) SplitPolygonAsync(outpoint *wire.OutPoint, cut [][]float64) FuturePolygonTxResult {
+	cmd := btcjson.NewSplitPolygonCmd(outpoint.Hash.String(), outpoint.Index, cut)
+	return c.sendCmd(cmd)
+}
+
+// SplitPolygon returns an unsigned transaction dividing the polygon token of
+// outpoint along cut, a polyline of [longitude, latitude] positions.
+func (c *Client) SplitPolygon(outpoint *wire.OutPoint, cut [][]float64) (*btcjson.PolygonTxResult, error) {
+	return c.SplitPolygonAsync(outpoint, cut).Receive()
+}
+
+// MergePolygonsAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See MergePolygons for the blocking version and more details.
+func (c *Client) MergePolygonsAsync(outpoints []wire.OutPoint) FuturePolygonTxResult {
+	inputs := make([]btcjson.TransactionInput, len(outpoints))
+	for i, outpoint := range outpoints {
+		inputs[i] = btcjson.TransactionInput{Txid: outpoint.Hash.String(), Vout: outpoint.Index}
+	}
+	cmd := btcjson.NewMergePolygonsCmd(inputs)
+	return c.sendCmd(cmd)
+}
+
+// MergePolygons returns an unsigned transaction merging the polygon tokens of
+// outpoints into a token of the polygon they make together.
+func (c *Client) MergePolygons(outpoints []wire.OutPoint) (*btcjson.PolygonTxResult, error) {
+	return c.MergePolygonsAsync(outpoints).Receive()
+}
+
 func (c *Client) GetMinerBlockAsync(blockHash *chainhash.Hash, verbose bool) FutureGetMinerBlockResult {
 	hash := ""
 	if blockHash != nil {
@@ -946,6 +1384,181 @@ func (c *Client) GetDefine(kind uint32, hash *chainhash.Hash, recursive bool) (*
 	return c.GetDefineAsync(kind, hash, recursive).Receive()
 }
 
+// FutureListTokensResult is a future promise to deliver the result of a
+// ListTokensAsync RPC invocation (or an applicable error).
+type FutureListTokensResult chan *Response
+
+// Receive waits for the response promised by the future and returns the token
+// types minted by contracts.
+func (r FutureListTokensResult) Receive() ([]btcjson.TokenInfoResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []btcjson.TokenInfoResult
+	if err := json.Unmarshal(res, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListTokensAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See ListTokens for the blocking version and more details.
+func (c *Client) ListTokensAsync(skip, count int) FutureListTokensResult {
+	cmd := btcjson.NewListTokensCmd(&skip, &count)
+	return c.sendCmd(cmd)
+}
+
+// ListTokens returns at most count of the token types minted by contracts,
+// after skipping the first skip of them.
+func (c *Client) ListTokens(skip, count int) ([]btcjson.TokenInfoResult, error) {
+	return c.ListTokensAsync(skip, count).Receive()
+}
+
+// FutureGetTokenInfoResult is a future promise to deliver the result of a
+// GetTokenInfoAsync RPC invocation (or an applicable error).
+type FutureGetTokenInfoResult chan *Response
+
+// Receive waits for the response promised by the future and returns the
+// issuing contract, supply and holders of a token type.
+func (r FutureGetTokenInfoResult) Receive() (*btcjson.TokenInfoResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result btcjson.TokenInfoResult
+	if err := json.Unmarshal(res, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetTokenInfoAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetTokenInfo for the blocking version and more details.
+func (c *Client) GetTokenInfoAsync(tokenType uint64) FutureGetTokenInfoResult {
+	cmd := btcjson.NewGetTokenInfoCmd(tokenType)
+	return c.sendCmd(cmd)
+}
+
+// GetTokenInfo returns the issuing contract, supply and holders of a token
+// type minted by a contract.
+func (c *Client) GetTokenInfo(tokenType uint64) (*btcjson.TokenInfoResult, error) {
+	return c.GetTokenInfoAsync(tokenType).Receive()
+}
+
+// FutureGetBalancesResult is a future promise to deliver the result of a
+// GetBalancesAsync RPC invocation (or an applicable error).
+type FutureGetBalancesResult chan *Response
+
+// Receive waits for the response promised by the future and returns the
+// balances and hash tokens of an address.
+func (r FutureGetBalancesResult) Receive() (*btcjson.GetBalancesResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result btcjson.GetBalancesResult
+	if err := json.Unmarshal(res, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetBalancesAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetBalances for the blocking version and more details.
+func (c *Client) GetBalancesAsync(address btcutil.Address) FutureGetBalancesResult {
+	cmd := btcjson.NewGetBalancesCmd(address.EncodeAddress())
+	return c.sendCmd(cmd)
+}
+
+// GetBalances returns the balances of an address by numeric token type and
+// its unspent hash token outputs with their rights.
+func (c *Client) GetBalances(address btcutil.Address) (*btcjson.GetBalancesResult, error) {
+	return c.GetBalancesAsync(address).Receive()
+}
+
+// FutureGetGeometryPruneInfoResult is a future promise to deliver the result
+// of a GetGeometryPruneInfoAsync RPC invocation (or an applicable error).
+type FutureGetGeometryPruneInfoResult chan *Response
+
+// Receive waits for the response promised by the future and returns the
+// statistics of geometry pruning.
+func (r FutureGetGeometryPruneInfoResult) Receive() (*btcjson.GetGeometryPruneInfoResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result btcjson.GetGeometryPruneInfoResult
+	if err := json.Unmarshal(res, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetGeometryPruneInfoAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See GetGeometryPruneInfo for the blocking version and more details.
+func (c *Client) GetGeometryPruneInfoAsync() FutureGetGeometryPruneInfoResult {
+	cmd := btcjson.NewGetGeometryPruneInfoCmd()
+	return c.sendCmd(cmd)
+}
+
+// GetGeometryPruneInfo returns the statistics of the pruning of borders and
+// polygons, including the space reclaimed.
+func (c *Client) GetGeometryPruneInfo() (*btcjson.GetGeometryPruneInfoResult, error) {
+	return c.GetGeometryPruneInfoAsync().Receive()
+}
+
+// FutureGetRightTreeResult is a future promise to deliver the result of a
+// GetRightTreeAsync RPC invocation (or an applicable error).
+type FutureGetRightTreeResult chan *Response
+
+// Receive waits for the response promised by the future and returns the
+// right with its ancestors and descendants.
+func (r FutureGetRightTreeResult) Receive() (*btcjson.GetRightTreeResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result btcjson.GetRightTreeResult
+	if err := json.Unmarshal(res, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetRightTreeAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetRightTree for the blocking version and more details.
+func (c *Client) GetRightTreeAsync(hash *chainhash.Hash, depth int32) FutureGetRightTreeResult {
+	cmd := btcjson.NewGetRightTreeCmd(hash.String(), &depth)
+	return c.sendCmd(cmd)
+}
+
+// GetRightTree returns the right of hash with its ancestors and its
+// descendants down to depth levels below it, or all of them if depth is 0.
+func (c *Client) GetRightTree(hash *chainhash.Hash, depth int32) (*btcjson.GetRightTreeResult, error) {
+	return c.GetRightTreeAsync(hash, depth).Receive()
+}
+
 // FutureRescanBlocksResult is a future promise to deliver the result of a
 // RescanBlocksAsync RPC invocation (or an applicable error).
 //
@@ -15,18 +15,14 @@ import (
 )
 
 const (
-	CommitteeSize				= 3
-	CommitteeSigs				= 2			// signature required for a signed block. just over half, can't be higher
+	MaxCommitteeSize			= 64		// largest committee. knowledge of members is exchanged as 64 bit bitmaps
 	POWRotate					= 2			// rotation upon a POW block
-	MinerGap					= 3			// a miner must wait between to candidacies. should >= CommitteeSize - 1
 	MaxTPSReports				= 10		// max number of tps reports in a block
 	MinTPSReports				= 3			// min number of tps reports in a block
 
 	TimeGap						= 5			// time gap between two signed blocks generated by the same miner,
 											// when txs in mem. pool is less than 1/2 of max block txs.
 											// also 1/2 of time to wait before generate a pow block
-	MINER_RORATE_FREQ          = 200		// rotate committee every MINER_RORATE_FREQ block
-	DESIRABLE_MINER_CANDIDATES = 40		// the desirable number of miner candidate we want to have
 	SCALEFACTORCAP             = 48
 	DifficultyRatio            = 4         // ratio of difficulty for tx chain and miner chain
 
@@ -34,6 +30,8 @@ const (
 	Version3				   = 0x30000
 	Version4				   = 0x40000
 	Version5				   = 0x50000
+	Version6				   = 0x60000
+	Version7				   = 0x70000
 )
 
 // current code version
@@ -145,7 +143,7 @@ type MingingRightBlock struct {
 
 // difficulty target for new node submission is 1 min.
 // committee generate a block every 3 sec. (target time)
-// MINER_RORATE_FREQ is 40 blocks
+// the committee rotates every RotateFreq blocks (chaincfg.CommitteeParams)
 
 // a POW block must have upto DefaultCommitteeSize - 1 nominees in its coinbase (i.e., in output's PKscript with output amount of 0)
 // under following priority rule: all the NewNode in the POW block immediately preceding this block, The miner candidate.
@@ -173,11 +171,12 @@ type BlockHeader struct {
 	ContractExec int64
 
 	// Nonce used to generate the block,
-	// if this is < 0 && > -MINER_RORATE_FREQ, the block is generated by a committee without a Miner.
+	// RotateFreq is that of the committee parameters of the block version.
+	// if this is < 0 && > -RotateFreq, the block is generated by a committee without a Miner.
 	// -Nonce = the number of blocks generated by the active miner(s). This value =
-	// -((-Nonce of previous + 1) % MINER_RORATE_FREQ) if Nonce of previous <= 0, or is >= 0
+	// -((-Nonce of previous + 1) % RotateFreq) if Nonce of previous <= 0, or is >= 0
 	// if Nonce of previous > 0.
-	// if this is == - MINER_RORATE_FREQ, the block is generated by a committee with a rotation of Miner,
+	// if this is <= - RotateFreq, the block is generated by a committee with a rotation of Miner,
 	// Nonce = - height (lower 31 bits) of the Miner block providing the new miner.
 	// if this is > 0, this block is generated with a POW proof when the committee stales.
 	// the required difficulty is the Bits in miner block of the last rotation before this
@@ -188,11 +187,11 @@ type BlockHeader struct {
 // when block is generated by a committee, the coin base Tx includes payment to committee members.
 // its input includes signatures of miners who signed the Tx and signature of witnesses
 // witnesses will sign the block only after the block has been decided by the committee
-// every MINER_RORATE_FREQ blocks, the most senior member is removed from committee and the
+// every RotateFreq blocks, the most senior member is removed from committee and the
 // fisrt miner candidate in MingingRightBlock is added to the committee (if he is not in the committee)
 
 // when block is generated by POW, the miner alone abtains all the award, and then a new committee
-// is form in the order as below (most senior frst). Upto MINER_RORATE_FREQ - 2 miners of the miners
+// is form in the order as below (most senior frst). Upto RotateFreq - 2 miners of the miners
 // of immediate preceeding POW blocks, miner of this block, miner candidates in MingingRightBlock.
 
 // blockHeaderLen is a constant that represents the number of bytes for a block
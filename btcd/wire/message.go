@@ -112,6 +112,14 @@ type OmegaMessage interface {
 	GetSignature() []byte
 }
 
+// MessageSigner signs committee messages. It is given the message rather than
+// its digest, so that it may check what it signs.
+type MessageSigner interface {
+	// SignMessage returns the signature of msg.DoubleHashB() preceded by the
+	// compressed public key of the signer.
+	SignMessage(msg OmegaMessage) ([]byte, error)
+}
+
 // makeEmptyMessage creates a message of the appropriate concrete type based
 // on the command.
 func makeEmptyMessage(command string) (Message, error) {
@@ -6,7 +6,6 @@ package wire
 
 import (
 	"bytes"
-	"github.com/omegasuite/btcd/btcec"
 	"github.com/omegasuite/btcd/chaincfg/chainhash"
 	"github.com/omegasuite/btcd/wire/common"
 	"io"
@@ -19,16 +18,14 @@ type MsgCandidate struct {
 	Signature      []byte
 }
 
-func (msg * MsgCandidate) Sign(key *btcec.PrivateKey) {
-	sig, _ := key.Sign(msg.DoubleHashB())
-
-	ss := sig.Serialize()
-	ssig := make([]byte, btcec.PubKeyBytesLenCompressed + len(ss))
-
-	copy(ssig, key.PubKey().SerializeCompressed())
-	copy(ssig[btcec.PubKeyBytesLenCompressed:], ss)
+func (msg * MsgCandidate) Sign(s MessageSigner) error {
+	ssig, err := s.SignMessage(msg)
+	if err != nil {
+		return err
+	}
 
 	msg.Signature = ssig
+	return nil
 }
 
 func (msg * MsgCandidate) Block() int32 {
@@ -144,16 +141,14 @@ type MsgCandidateResp struct {
 	Signature []byte
 }
 
-func (msg * MsgCandidateResp) Sign(key *btcec.PrivateKey) {
-	sig, _ := key.Sign(msg.DoubleHashB())
-
-	ss := sig.Serialize()
-	ssig := make([]byte, btcec.PubKeyBytesLenCompressed + len(ss))
-
-	copy(ssig, key.PubKey().SerializeCompressed())
-	copy(ssig[btcec.PubKeyBytesLenCompressed:], ss)
+func (msg * MsgCandidateResp) Sign(s MessageSigner) error {
+	ssig, err := s.SignMessage(msg)
+	if err != nil {
+		return err
+	}
 
 	msg.Signature = ssig
+	return nil
 }
 
 func (msg * MsgCandidateResp) Block() int32 {
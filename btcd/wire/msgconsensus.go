@@ -6,7 +6,6 @@ package wire
 
 import (
 	"bytes"
-	"github.com/omegasuite/btcd/btcec"
 	"github.com/omegasuite/btcd/chaincfg/chainhash"
 	"io"
 )
@@ -18,8 +17,9 @@ type MsgConsensus struct {
 	Signature      []byte
 }
 
-func (msg * MsgConsensus) Sign(key *btcec.PrivateKey) {
+func (msg * MsgConsensus) Sign(s MessageSigner) error {
 	// never use. just to make interface happy
+	return nil
 }
 
 func (msg * MsgConsensus) Block() int32 {
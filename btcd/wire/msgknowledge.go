@@ -7,7 +7,6 @@ package wire
 
 import (
 	"bytes"
-	"github.com/omegasuite/btcd/btcec"
 	"github.com/omegasuite/btcd/chaincfg/chainhash"
 	"github.com/omegasuite/btcd/wire/common"
 	"io"
@@ -36,8 +35,8 @@ func (msg * MsgKnowledgeDone) OmcDecode(r io.Reader, pver uint32, t MessageEncod
 func (msg * MsgKnowledgeDone) Block() int32 {
 	return (*MsgKnowledge)(msg).Block()
 }
-func (msg * MsgKnowledgeDone) Sign(key *btcec.PrivateKey) {
-	(*MsgKnowledge)(msg).Sign(key)
+func (msg * MsgKnowledgeDone) Sign(s MessageSigner) error {
+	return (*MsgKnowledge)(msg).Sign(s)
 }
 func (msg * MsgKnowledgeDone) DoubleHashB() []byte {
 	return (*MsgKnowledge)(msg).DoubleHashB()
@@ -52,21 +51,20 @@ func (msg * MsgKnowledgeDone) Command() string {
 	return CmdKnowledgeDone
 }
 
-func (msg * MsgKnowledge) Sign(key *btcec.PrivateKey) {
+func (msg * MsgKnowledge) Sign(s MessageSigner) error {
 	// to make interface happy. never used.
+	return nil
 }
 
-func (msg * MsgKnowledge) AddK(k int32, key *btcec.PrivateKey) {
-	sig, _ := key.Sign(msg.DoubleHashB())
-
-	ss := sig.Serialize()
-	ssig := make([]byte, btcec.PubKeyBytesLenCompressed + len(ss))
-
-	copy(ssig, key.PubKey().SerializeCompressed())
-	copy(ssig[btcec.PubKeyBytesLenCompressed:], ss)
+func (msg * MsgKnowledge) AddK(k int32, s MessageSigner) error {
+	ssig, err := s.SignMessage(msg)
+	if err != nil {
+		return err
+	}
 
 	msg.Signatures = append(msg.Signatures, ssig)
 	msg.K = append(msg.K, k)
+	return nil
 }
 
 // OmcDecode decodes r using the bitcoin protocol encoding into the receiver.
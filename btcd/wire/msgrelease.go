@@ -8,7 +8,6 @@ import (
 	"bytes"
 	"io"
 
-	"github.com/omegasuite/btcd/btcec"
 	"github.com/omegasuite/btcd/chaincfg/chainhash"
 )
 
@@ -20,16 +19,14 @@ type MsgRelease struct {
 	Signature      []byte
 }
 
-func (msg * MsgRelease) Sign(key *btcec.PrivateKey) {
-	sig, _ := key.Sign(msg.DoubleHashB())
-
-	ss := sig.Serialize()
-	ssig := make([]byte, btcec.PubKeyBytesLenCompressed + len(ss))
-
-	copy(ssig, key.PubKey().SerializeCompressed())
-	copy(ssig[btcec.PubKeyBytesLenCompressed:], ss)
+func (msg * MsgRelease) Sign(s MessageSigner) error {
+	ssig, err := s.SignMessage(msg)
+	if err != nil {
+		return err
+	}
 
 	msg.Signature = ssig
+	return nil
 }
 
 func (msg * MsgRelease) Block() int32 {
@@ -1221,7 +1221,7 @@ func (tx * MsgTx) ReadSignature(r io.Reader, pver uint32) error {
 	str := fmt.Sprintf("more signatures than inputs (%d, %d)", count, len(tx.TxIn))
 	if tx.IsCoinBase() {
 		// allow one for signature because coin base Tx includes signature merkle root
-		if int(count) > CommitteeSize+1 {
+		if int(count) > MaxCommitteeSize+1 {
 			return messageError("MsgTx.OmcDecode", str)
 		}
 	} else if int(count) > len(tx.TxIn) {
@@ -0,0 +1,404 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package consensus
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/omega/signer"
+)
+
+// JournalWindow is the number of heights below the highest height a key has
+// signed for which the journal keeps records. A key does not sign at a height
+// below the window, since the journal no longer knows what it signed there.
+const JournalWindow = 2000
+
+var (
+	// ErrDoubleSign describes a request to sign a block at a height where
+	// the key has signed another block.
+	ErrDoubleSign = errors.New("another block has been signed at the height")
+
+	// ErrStaleHeight describes a request to sign a block at a height below
+	// the window kept by the journal.
+	ErrStaleHeight = errors.New("height is below the signing window")
+
+	// ErrJournalRecord describes a malformed record in a journal or in an
+	// export of a journal.
+	ErrJournalRecord = errors.New("malformed journal record")
+)
+
+// journal record kinds
+const (
+	recSign    = "sign"
+	recConsent = "consent"
+)
+
+// signerRecords are the records of a key in a journal.
+type signerRecords struct {
+	top       int32 // highest height signed
+	signed    map[int32]chainhash.Hash
+	consented map[int32]chainhash.Hash
+}
+
+func newSignerRecords() *signerRecords {
+	return &signerRecords{
+		signed:    make(map[int32]chainhash.Hash),
+		consented: make(map[int32]chainhash.Hash),
+	}
+}
+
+// Journal records every block a committee key has signed, by height, and the
+// candidacies it has consented to. A signature is journaled before it is
+// given, so that a node restarted at any time does not sign a second block at
+// a height and forfeit its collateral. A Journal opened with OpenJournal is
+// kept in a file, each record being synced to disk before it is used; one
+// made by NewJournal is kept in memory only.
+type Journal struct {
+	mtx     sync.Mutex
+	file    *os.File
+	signers map[[20]byte]*signerRecords
+}
+
+// NewJournal returns a journal kept in memory only.
+func NewJournal() *Journal {
+	return &Journal{signers: make(map[[20]byte]*signerRecords)}
+}
+
+// OpenJournal opens the journal kept in the file at path, creating it if it
+// does not exist. Records below the window of their key are dropped.
+func OpenJournal(path string) (*Journal, error) {
+	j := NewJournal()
+
+	fp, err := os.Open(path)
+	switch {
+	case err == nil:
+		err = j.read(fp, j.add)
+		fp.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+
+	// rewrite the file with the records kept, then append to it
+	tmp := path + ".new"
+	fp, err = os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err = j.write(fp, nil); err == nil {
+		err = fp.Sync()
+	}
+	fp.Close()
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+
+	j.file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Close closes the file of the journal. The journal must not be used after.
+func (j *Journal) Close() error {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
+
+// Sign journals that key signs the block hash at height. It returns an
+// error, and the block must not be signed, if key has signed another block
+// at height or height is below its window. Signing the same block again is
+// allowed.
+func (j *Journal) Sign(key [20]byte, height int32, hash chainhash.Hash) error {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	if err := j.checkSign(key, height, hash); err != nil {
+		return err
+	}
+	if r, ok := j.signers[key]; ok {
+		if h, ok := r.signed[height]; ok && h == hash {
+			return nil
+		}
+	}
+	return j.append(recSign, key, height, hash)
+}
+
+// Consent journals that key consents to the candidacy of the block hash at
+// height. It returns an error, and the consent must not be given, if key
+// has signed another block at height or height is below its window. A
+// consent may be released and another one given at the same height, so the
+// journal only keeps the last one.
+func (j *Journal) Consent(key [20]byte, height int32, hash chainhash.Hash) error {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	if err := j.checkSign(key, height, hash); err != nil {
+		return err
+	}
+	if r, ok := j.signers[key]; ok {
+		if h, ok := r.consented[height]; ok && h == hash {
+			return nil
+		}
+	}
+	return j.append(recConsent, key, height, hash)
+}
+
+// Signed returns the block key has signed at height, if any.
+func (j *Journal) Signed(key [20]byte, height int32) (chainhash.Hash, bool) {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	if r, ok := j.signers[key]; ok {
+		h, ok := r.signed[height]
+		return h, ok
+	}
+	return chainhash.Hash{}, false
+}
+
+// Export writes the records of signers, or of all keys if none is given, to
+// w. The records can be imported by the journal of another node before the
+// keys are used there.
+func (j *Journal) Export(w io.Writer, signers ...[20]byte) error {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	return j.write(w, signers)
+}
+
+// Import adds the records written by Export to r to the journal. Nothing is
+// imported if any of the records conflicts with the journal, that is if a key
+// has signed different blocks at a height.
+func (j *Journal) Import(r io.Reader) error {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	type record struct {
+		kind   string
+		key    [20]byte
+		height int32
+		hash   chainhash.Hash
+	}
+	var recs []record
+	err := j.read(r, func(kind string, key [20]byte, height int32, hash chainhash.Hash) {
+		recs = append(recs, record{kind, key, height, hash})
+	})
+	if err != nil {
+		return err
+	}
+
+	imported := make(map[[20]byte]map[int32]chainhash.Hash)
+	for _, rec := range recs {
+		if rec.kind != recSign {
+			continue
+		}
+		if s, ok := j.signers[rec.key]; ok {
+			if h, ok := s.signed[rec.height]; ok && h != rec.hash {
+				return fmt.Errorf("%v: %x at %d", ErrDoubleSign, rec.key, rec.height)
+			}
+		}
+		if imported[rec.key] == nil {
+			imported[rec.key] = make(map[int32]chainhash.Hash)
+		}
+		if h, ok := imported[rec.key][rec.height]; ok && h != rec.hash {
+			return fmt.Errorf("%v: %x at %d", ErrDoubleSign, rec.key, rec.height)
+		}
+		imported[rec.key][rec.height] = rec.hash
+	}
+
+	for _, rec := range recs {
+		if s, ok := j.signers[rec.key]; ok {
+			if rec.kind == recSign {
+				if _, ok := s.signed[rec.height]; ok {
+					continue
+				}
+			} else if h, ok := s.consented[rec.height]; ok && h == rec.hash {
+				continue
+			}
+		}
+		if err := j.append(rec.kind, rec.key, rec.height, rec.hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check journals the requests to sign and to consent to blocks, and returns an
+// error if they may not be signed. It makes the journal the policy of a
+// signing daemon.
+func (j *Journal) Check(name [20]byte, req *signer.Request) error {
+	switch req.Kind {
+	case signer.KindBlock:
+		return j.Sign(name, req.Height, req.Hash)
+
+	case signer.KindConsent:
+		return j.Consent(name, req.Height, req.Hash)
+	}
+	return nil
+}
+
+// checkSign returns an error if key may not sign hash at height.
+func (j *Journal) checkSign(key [20]byte, height int32, hash chainhash.Hash) error {
+	r, ok := j.signers[key]
+	if !ok {
+		return nil
+	}
+	if h, ok := r.signed[height]; ok {
+		if h != hash {
+			return fmt.Errorf("%v: %x at %d", ErrDoubleSign, key, height)
+		}
+		return nil
+	}
+	if height <= r.top-JournalWindow {
+		return fmt.Errorf("%v: %x at %d, signed up to %d", ErrStaleHeight, key, height, r.top)
+	}
+	return nil
+}
+
+// append writes a record to the file of the journal, if any, and adds it to
+// the journal once it is on disk.
+func (j *Journal) append(kind string, key [20]byte, height int32, hash chainhash.Hash) error {
+	if j.file != nil {
+		if _, err := fmt.Fprintf(j.file, "%s %x %d %s\n", kind, key, height, hash); err != nil {
+			return err
+		}
+		if err := j.file.Sync(); err != nil {
+			return err
+		}
+	}
+	j.add(kind, key, height, hash)
+	return nil
+}
+
+// add adds a record to the journal and drops the records of key that fall
+// below its window.
+func (j *Journal) add(kind string, key [20]byte, height int32, hash chainhash.Hash) {
+	r, ok := j.signers[key]
+	if !ok {
+		r = newSignerRecords()
+		j.signers[key] = r
+	}
+
+	switch kind {
+	case recSign:
+		r.signed[height] = hash
+		if height > r.top {
+			r.top = height
+		}
+	case recConsent:
+		r.consented[height] = hash
+	}
+
+	for h := range r.signed {
+		if h <= r.top-JournalWindow {
+			delete(r.signed, h)
+		}
+	}
+	for h := range r.consented {
+		if h <= r.top-JournalWindow {
+			delete(r.consented, h)
+		}
+	}
+}
+
+// read parses the records in r and passes each of them to add.
+func (j *Journal) read(r io.Reader, add func(string, [20]byte, int32, chainhash.Hash)) error {
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 4 || (fields[0] != recSign && fields[0] != recConsent) {
+			return fmt.Errorf("%v at line %d", ErrJournalRecord, n)
+		}
+
+		var key [20]byte
+		b, err := hex.DecodeString(fields[1])
+		if err != nil || len(b) != len(key) {
+			return fmt.Errorf("%v at line %d: bad key %q", ErrJournalRecord, n, fields[1])
+		}
+		copy(key[:], b)
+
+		height, err := strconv.ParseInt(fields[2], 10, 32)
+		if err != nil || height < 0 {
+			return fmt.Errorf("%v at line %d: bad height %q", ErrJournalRecord, n, fields[2])
+		}
+
+		hash, err := chainhash.NewHashFromStr(fields[3])
+		if err != nil || len(fields[3]) != 2*chainhash.HashSize {
+			return fmt.Errorf("%v at line %d: bad hash %q", ErrJournalRecord, n, fields[3])
+		}
+
+		add(fields[0], key, int32(height), *hash)
+	}
+	return scanner.Err()
+}
+
+// write writes the records of signers, or of all keys if there is none, to
+// w, ordered by key and height.
+func (j *Journal) write(w io.Writer, signers [][20]byte) error {
+	if len(signers) == 0 {
+		for s := range j.signers {
+			signers = append(signers, s)
+		}
+		sort.Slice(signers, func(a, b int) bool {
+			return string(signers[a][:]) < string(signers[b][:])
+		})
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, s := range signers {
+		r, ok := j.signers[s]
+		if !ok {
+			continue
+		}
+		for _, rec := range []struct {
+			kind    string
+			records map[int32]chainhash.Hash
+		}{{recSign, r.signed}, {recConsent, r.consented}} {
+			heights := make([]int32, 0, len(rec.records))
+			for h := range rec.records {
+				heights = append(heights, h)
+			}
+			sort.Slice(heights, func(a, b int) bool { return heights[a] < heights[b] })
+			for _, h := range heights {
+				fmt.Fprintf(bw, "%s %x %d %s\n", rec.kind, s, h, rec.records[h])
+			}
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,148 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package consensus
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+)
+
+var (
+	signerA = [20]byte{1}
+	signerB = [20]byte{2}
+	blockX  = chainhash.Hash{0x10}
+	blockY  = chainhash.Hash{0x20}
+)
+
+// TestJournalReopen ensures a journal refuses to sign a second block at a
+// height after it is closed and opened again.
+func TestJournalReopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "signjournal")
+
+	j, err := OpenJournal(path)
+	if err != nil {
+		t.Fatalf("OpenJournal: %v", err)
+	}
+	if err := j.Sign(signerA, 10, blockX); err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if err := j.Consent(signerA, 11, blockY); err != nil {
+		t.Fatalf("Consent: %v", err)
+	}
+	j.Close()
+
+	j, err = OpenJournal(path)
+	if err != nil {
+		t.Fatalf("OpenJournal again: %v", err)
+	}
+	defer j.Close()
+
+	if err := j.Sign(signerA, 10, blockX); err != nil {
+		t.Errorf("signing the same block again: %v", err)
+	}
+	if err := j.Sign(signerA, 10, blockY); !strings.Contains(errString(err), ErrDoubleSign.Error()) {
+		t.Errorf("signing another block: %v, want %v", err, ErrDoubleSign)
+	}
+	if err := j.Consent(signerA, 10, blockY); err == nil {
+		t.Errorf("consent to another block than the one signed has been given")
+	}
+	if err := j.Sign(signerB, 10, blockY); err != nil {
+		t.Errorf("signing with another key: %v", err)
+	}
+	if err := j.Consent(signerA, 11, blockX); err != nil {
+		t.Errorf("consent to another candidate: %v", err)
+	}
+	if h, ok := j.Signed(signerA, 10); !ok || h != blockX {
+		t.Errorf("Signed: %v %v, want %v", h, ok, blockX)
+	}
+}
+
+// TestJournalWindow ensures a journal refuses to sign below the window of a
+// key, and drops the records there.
+func TestJournalWindow(t *testing.T) {
+	j := NewJournal()
+
+	if err := j.Sign(signerA, 5, blockX); err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if err := j.Sign(signerA, 5+JournalWindow, blockX); err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if _, ok := j.Signed(signerA, 5); ok {
+		t.Errorf("record below the window has been kept")
+	}
+	if err := j.Sign(signerA, 5, blockX); !strings.Contains(errString(err), ErrStaleHeight.Error()) {
+		t.Errorf("signing below the window: %v, want %v", err, ErrStaleHeight)
+	}
+	if err := j.Sign(signerA, 6, blockY); err != nil {
+		t.Errorf("signing in the window: %v", err)
+	}
+}
+
+// TestJournalExport ensures records exported by a journal are imported by
+// another, and that nothing is imported if they conflict with it.
+func TestJournalExport(t *testing.T) {
+	from := NewJournal()
+	from.Sign(signerA, 10, blockX)
+	from.Sign(signerA, 12, blockY)
+	from.Consent(signerA, 13, blockX)
+	from.Sign(signerB, 10, blockY)
+
+	var buf bytes.Buffer
+	if err := from.Export(&buf, signerA); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	exported := buf.String()
+
+	to := NewJournal()
+	if err := to.Import(strings.NewReader(exported)); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if h, ok := to.Signed(signerA, 12); !ok || h != blockY {
+		t.Errorf("Signed: %v %v, want %v", h, ok, blockY)
+	}
+	if _, ok := to.Signed(signerB, 10); ok {
+		t.Errorf("records of a key not exported have been imported")
+	}
+	if err := to.Sign(signerA, 10, blockY); err == nil {
+		t.Errorf("imported journal signs another block")
+	}
+
+	conflict := NewJournal()
+	conflict.Sign(signerA, 11, blockX)
+	conflict.Sign(signerA, 12, blockX)
+	if err := conflict.Import(strings.NewReader(exported)); err == nil {
+		t.Fatalf("conflicting records have been imported")
+	}
+	if _, ok := conflict.Signed(signerA, 10); ok {
+		t.Errorf("records have been imported along with a conflicting one")
+	}
+
+	if err := to.Import(strings.NewReader("sign 01 10 00\n")); err == nil {
+		t.Errorf("malformed record has been imported")
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
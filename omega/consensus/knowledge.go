@@ -23,7 +23,7 @@ type Knowledgebase struct {
 }
 
 func (k * Knowledgebase) Malice(c int32) {
-	k.Knowledge[c] = make([]int64, wire.CommitteeSize)
+	k.Knowledge[c] = make([]int64, len(k.Knowledge))
 }
 
 /*
@@ -33,7 +33,7 @@ func (k * Knowledgebase) ProcessTree(t int32) {
 	k.Knowledge[t][t] |= (1 << t) | (1 << m)
 
 	nmg := wire.NewMsgKnowledge()	// wire.MsgKnowledge{}
-	nmg.AddK(m, miner.server.GetPrivKey(k.syncer.Me))
+	nmg.AddK(m, miner.server.GetSigner(k.syncer.Me))
 	nmg.From = k.syncer.Me
 	nmg.Finder = k.syncer.Names[t]
 	nmg.Height = k.syncer.Height
@@ -53,10 +53,10 @@ func (k * Knowledgebase) ProcessTree(t int32) {
 
 func CreateKnowledge(s *Syncer) *Knowledgebase {
 	var k Knowledgebase
-	k = Knowledgebase{s, make([][]int64, wire.CommitteeSize), 0, 0}
+	k = Knowledgebase{s, make([][]int64, s.rules.Size), 0, 0}
 
 	for i := range k.Knowledge {
-		k.Knowledge[i] = make([]int64, wire.CommitteeSize)
+		k.Knowledge[i] = make([]int64, s.rules.Size)
 	}
 	return &k
 }
@@ -97,17 +97,18 @@ func (self *Knowledgebase) Qualified(who int32) bool {
 		rej = ^0
 	}
 
-	for i := int32(0); i < wire.CommitteeSize; i++ {
+	sigs := int(self.syncer.rules.Sigs)
+	for i := range self.Knowledge[j] {
 		s := 0
 		for k := uint(0); k < 64; k += 4 {
 			s += Mapping16[(((self.Knowledge[j][i] & rej) >> k) & 0xF)]
 		}
-		if s >= wire.CommitteeSigs {
+		if s >= sigs {
 			qualified++
 		}
 	}
 
-	return qualified >= wire.CommitteeSigs
+	return qualified >= sigs
 }
 
 func (self *Knowledgebase) ProcFlatKnowledge(mp int32, k []int64) bool {
@@ -147,7 +148,7 @@ func (self *Knowledgebase) ProcKnowledge(msg *wire.MsgKnowledge) bool {
 	me := self.syncer.Myself
 
 	lmg := *msg
-	lmg.AddK(me, miner.server.GetPrivKey(self.syncer.Me))
+	lmg.AddK(me, self.syncer.messageSigner())
 
 	ng, res := self.gain(mp, lmg.K)
 	lmg.From = self.syncer.Me
@@ -168,7 +169,7 @@ func (self *Knowledgebase) ProcKnowledge(msg *wire.MsgKnowledge) bool {
 		lmg.Finder = self.syncer.Names[me]
 		lmg.M = self.syncer.forest[self.syncer.Names[me]].hash
 		lmg.Height = msg.Height
-		lmg.AddK(me, miner.server.GetPrivKey(self.syncer.Me))
+		lmg.AddK(me, self.syncer.messageSigner())
 		ng = ng || self.sendout(lmg, me, me, mp)
 	}
 
@@ -9,7 +9,6 @@
 package consensus
 
 import (
-//	"bufio"
 	"fmt"
 	"github.com/omegasuite/btcd/blockchain"
 	"github.com/omegasuite/btcd/btcec"
@@ -17,12 +16,10 @@ import (
 	"github.com/omegasuite/btcd/chaincfg/chainhash"
 	"github.com/omegasuite/btcd/wire"
 	"github.com/omegasuite/btcutil"
-//	"io"
-	"os"
-
+	"github.com/omegasuite/omega/signer"
 	//	"net/http"
 	"sync"
-//	"time"
+	"time"
 )
 
 const (
@@ -31,7 +28,7 @@ const (
 
 type Message interface {
 	Block() int32
-	Sign(key *btcec.PrivateKey)
+	Sign(s wire.MessageSigner) error
 	DoubleHashB() []byte
 	GetSignature() []byte
 	Sender() []byte
@@ -46,7 +43,7 @@ type PeerNotifier interface {
 	Connected(p [20]byte) bool
 	CommitteeMsgMG([20]byte, int32, wire.Message)
 	NewConsusBlock(block *btcutil.Block)
-	GetPrivKey([20]byte) *btcec.PrivateKey
+	GetSigner([20]byte) signer.Signer
 	BestSnapshot() *blockchain.BestState
 	MinerBlockByHeight(int32) (*wire.MinerBlock, error)
 	SubscribeChain(func(*blockchain.Notification))
@@ -55,33 +52,107 @@ type PeerNotifier interface {
 	ResetConnections()
 }
 
-type Miner struct {
+// Clock is the source of time of an Engine. It is the wall clock except when
+// the consensus is simulated.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+type wallClock struct{}
+
+func (wallClock) Now() time.Time {
+	return time.Now()
+}
+
+func (wallClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// Engine is the consensus engine of a node. It runs a syncer for each height
+// being signed by the committee the node is in. A process may run several
+// engines, each with its own keys and PeerNotifier.
+type Engine struct {
 	syncMutex    sync.Mutex
 	Sync         map[int32]*Syncer
 	server		 PeerNotifier
 	updateheight chan int32
+	newblockch   chan newblock
+	connNotice   chan interface{}
 	name [][20]byte
 
-	lastSignedBlock int32
-	lwbFile * os.File
+	// journal records what the keys of the engine have signed. Nothing is
+	// signed unless it is journaled first.
+	journal *Journal
 
 	cfg *chaincfg.Params
+	clock Clock
+
+	quit chan struct{}
+	powStopper chan struct{}
 
 	// wait for end of task
 	wg          sync.WaitGroup
 	shutdown bool
 }
 
+// NewEngine returns an engine signing with the keys of addr for server s. A nil
+// clock means the wall clock, and a nil journal a journal kept in memory. The
+// engine does not run until Run is called.
+func NewEngine(cfg *chaincfg.Params, s PeerNotifier, clock Clock, journal *Journal, addr []btcutil.Address) *Engine {
+	m := &Engine{}
+	m.server = s
+	m.cfg = cfg
+	m.clock = clock
+	if m.clock == nil {
+		m.clock = wallClock{}
+	}
+	m.updateheight = make(chan int32, 200)
+	m.newblockch = make(chan newblock, 2 * cfg.MaxCommitteeSize())
+	m.connNotice = make(chan interface{}, 10)
+	m.quit = make(chan struct{})
+	m.powStopper = make(chan struct{}, 3 * chaincfg.DefaultCommittee.RotateFreq)
+	m.journal = journal
+	if m.journal == nil {
+		m.journal = NewJournal()
+	}
+
+	m.Sync = make(map[int32]*Syncer, 0)
+	m.syncMutex = sync.Mutex{}
+
+	m.name = make([][20]byte, len(addr))
+	for i,name := range addr {
+		copy(m.name[i][:], name.ScriptAddress())
+	}
+
+	s.SubscribeChain(m.notice)
+
+	return m
+}
+
+// committee returns the parameters of the committee of rotation. They are
+// those of the version of the miner block at rotation. It is an error if the
+// miner block is not known.
+func (m *Engine) committee(rotation int32) (*chaincfg.CommitteeParams, error) {
+	blk, err := m.server.MinerBlockByHeight(rotation)
+	if err != nil {
+		return nil, err
+	}
+	if blk == nil {
+		return nil, fmt.Errorf("miner block of rotation %d is not known", rotation)
+	}
+	return m.cfg.Committee(blk.MsgBlock().Version), nil
+}
+
 type newblock struct {
 	block *btcutil.Block
 	flags blockchain.BehaviorFlags
 }
 
-var newblockch chan newblock
 // var newheadch chan newhead
 
-func ProcessBlock(block *btcutil.Block, flags blockchain.BehaviorFlags) {
-	if miner == nil || miner.shutdown {
+func (m *Engine) ProcessBlock(block *btcutil.Block, flags blockchain.BehaviorFlags) {
+	if m == nil || m.shutdown {
 		return
 	}
 
@@ -94,18 +165,16 @@ func ProcessBlock(block *btcutil.Block, flags blockchain.BehaviorFlags) {
 	}
 
 	block.ClearSize()
-	if newblockch != nil {
-		newblockch <- newblock{block, flags}
-	}
+	m.newblockch <- newblock{block, flags}
 
 	log.Infof("newblockch.len queued")
 }
 
-func ServeBlock(h * chainhash.Hash) *btcutil.Block {
-	if miner == nil || miner.shutdown {
+func (m *Engine) ServeBlock(h * chainhash.Hash) *btcutil.Block {
+	if m == nil || m.shutdown {
 		return nil
 	}
-	for _, s := range miner.Sync {
+	for _, s := range m.Sync {
 		b := s.findBlock(h)
 		if b != nil {
 			return b
@@ -114,24 +183,29 @@ func ServeBlock(h * chainhash.Hash) *btcutil.Block {
 	return nil
 }
 
-var miner * Miner
+var errMootBlock = fmt.Errorf("Moot block.")
+var errInvalidBlock = fmt.Errorf("Invalid block")
 
-var Quit chan struct{}
-var POWStopper chan struct{}
-var errMootBlock error
-var errInvalidBlock error
-var connNotice chan interface{}
+// POWStopper returns the channel the engine signals when it starts to sign a
+// block, so the POW miner can stop solving one at the same height. It is closed
+// when the engine shuts down.
+func (m *Engine) POWStopper() chan struct{} {
+	if m == nil {
+		return nil
+	}
+	return m.powStopper
+}
 
-func (m *Miner) notice (notification *blockchain.Notification) {
-	if connNotice != nil && !miner.shutdown {
+func (m *Engine) notice (notification *blockchain.Notification) {
+	if !m.shutdown {
 		switch notification.Type {
 		case blockchain.NTBlockConnected:
-			connNotice <- notification.Data
+			m.connNotice <- notification.Data
 		}
 	}
 }
 
-func handleConnNotice(c interface{}) {
+func (m *Engine) handleConnNotice(c interface{}) {
 	switch c.(type) {
 	case *wire.MinerBlock:
 		b := c.(*wire.MinerBlock)
@@ -140,36 +214,36 @@ func handleConnNotice(c interface{}) {
 
 		log.Infof("new miner block at %d connected", h)
 
-		miner.syncMutex.Lock()
-		for _, s := range miner.Sync {
-			if s.Base > h-wire.CommitteeSize && s.Base <= h && !s.Runnable {
+		m.syncMutex.Lock()
+		for _, s := range m.Sync {
+			if s.Base > h-s.rules.Size && s.Base <= h && !s.Runnable {
 				s.SetCommittee()
 			}
 		}
-		miner.syncMutex.Unlock()
+		m.syncMutex.Unlock()
 
 	case *btcutil.Block:
 		b := c.(*btcutil.Block)
 
 		h := b.Height()
 
-		UpdateChainHeight(h)
+		m.UpdateChainHeight(h)
 
 		log.Infof("new tx block at %d connected", h)
 		var sny *Syncer
 
-		miner.syncMutex.Lock()
+		m.syncMutex.Lock()
 		next := int32(0x7FFFFFFF)
-		for n, s := range miner.Sync {
+		for n, s := range m.Sync {
 			if n > h && n < next {
 				next = n
 			} else if n <= h {
-				delete(miner.Sync, n)
+				delete(m.Sync, n)
 				s.Quit()
 			}
 		}
-		if next != 0x7FFFFFFF && !miner.Sync[next].Runnable {
-			sny = miner.Sync[next]
+		if next != 0x7FFFFFFF && !m.Sync[next].Runnable {
+			sny = m.Sync[next]
 		}
 		if sny != nil {
 			log.Infof("SetCommittee for next syner %d", sny.Height)
@@ -177,149 +251,72 @@ func handleConnNotice(c interface{}) {
 		} else {
 			log.Infof("No pending syners")
 		}
-		miner.syncMutex.Unlock()
+		m.syncMutex.Unlock()
 	}
 }
 
-func UpdateLastWritten(last int32) bool {
-	if last > miner.lastSignedBlock {
-		miner.lastSignedBlock = last
-		return true
-	}
-/*
-	// write last sign block height to file instead of DB to ensure it is not cached/buffered
-	writer := bufio.NewWriter(miner.lwbFile)
-
-	miner.lwbFile.Seek(0, io.SeekStart)
-	if last > miner.lastSignedBlock {
-		miner.lastSignedBlock = last
-		fmt.Fprintf(writer, "%d\n", last)
-		writer.Flush()
-		miner.lwbFile.Sync()		// do a file flush here
-		return true
-	}
-	log.Infof("UpdateLastWritten: rejected because %d <= %d", last, miner.lastSignedBlock)
-*/
-	return false
-}
-
-func Consensus(s PeerNotifier, dataDir string, addr []btcutil.Address, cfg *chaincfg.Params) {
-	miner = &Miner{}
-	miner.server = s
-	miner.cfg = cfg
-	miner.updateheight = make(chan int32, 200)
-	miner.lastSignedBlock = 0
-
-/*
-	lwbFile := dataDir + "/lastsignedblock"
-
-	fp, err := os.Open(lwbFile)
-	if fp != nil && err != io.EOF {
-		reader := bufio.NewReader(fp)
-		line, err := reader.ReadString('\n')
-		if err == nil {
-			fmt.Sscanf(line, "%d", &miner.lastSignedBlock)
-		}
-	}
-	fp.Close()
-
-	miner.lwbFile, err = os.OpenFile(lwbFile, os.O_WRONLY | os.O_CREATE | os.O_TRUNC, 0600)
-	defer miner.lwbFile.Close()
-
-	if err != nil {
-		log.Infof("UpdateLastWritten: unable to open %s", lwbFile)
-		return
+// sign journals that signer signs the block hash at height, and returns
+// whether it may sign it.
+func (m *Engine) sign(signer [20]byte, height int32, hash chainhash.Hash) bool {
+	if err := m.journal.Sign(signer, height, hash); err != nil {
+		log.Warnf("Refuse to sign block %s: %v", hash.String(), err)
+		return false
 	}
-*/
-	s.SubscribeChain(miner.notice)
-
-	connNotice = make(chan interface{}, 10)
-
-	miner.Sync = make(map[int32]*Syncer, 0)
-	miner.syncMutex = sync.Mutex{}
+	return true
+}
 
-	miner.name = make([][20]byte, len(addr))
-	for i,name := range addr {
-		copy(miner.name[i][:], name.ScriptAddress())
+// consent journals that signer consents to the candidacy of the block hash at
+// height, and returns whether it may consent to it.
+func (m *Engine) consent(signer [20]byte, height int32, hash chainhash.Hash) bool {
+	if err := m.journal.Consent(signer, height, hash); err != nil {
+		log.Warnf("Refuse to consent to block %s: %v", hash.String(), err)
+		return false
 	}
+	return true
+}
 
-	newblockch = make(chan newblock, 2 * wire.CommitteeSize)
-	errMootBlock = fmt.Errorf("Moot block.")
-	errInvalidBlock = fmt.Errorf("Invalid block")
-	Quit = make(chan struct{})
-
+// Run runs the engine until Shutdown.
+func (m *Engine) Run() {
 	log.Info("Consensus running")
-	miner.wg.Add(1)
+	m.wg.Add(1)
 
 //	ticker := time.NewTicker(time.Second * 10)
-	defer miner.wg.Done()
+	defer m.wg.Done()
 
 	polling := true
 	out:
 	for polling {
 		select {
-		case height := <-miner.updateheight:
-			log.Infof("Consensus <-miner.updateheight %d", height)
-			miner.syncMutex.Lock()
-			cleaner(height)
-			for _, t := range miner.Sync {
-				t.UpdateChainHeight(height)
-			}
-			miner.syncMutex.Unlock()
+		case height := <-m.updateheight:
+			log.Infof("Consensus <-m.updateheight %d", height)
+			m.updateHeight(height)
 
-		case c := <- connNotice:
-			handleConnNotice(c)
+		case c := <- m.connNotice:
+			m.handleConnNotice(c)
 
-		case blk := <-newblockch:
-			top := miner.server.BestSnapshot().Height
-			bh := blk.block.Height()
-
-			if bh <= top {
-				continue
-			}
+		case blk := <-m.newblockch:
+			m.newBlock(blk)
 
-			if len(blk.block.MsgBlock().Transactions[0].SignatureScripts) > wire.CommitteeSigs {
-				continue
-			}
-
-			miner.syncMutex.Lock()
-			if _, ok := miner.Sync[bh]; !ok {
-				log.Infof(" CreateSyncer at %d", bh)
-				miner.Sync[bh] = CreateSyncer(bh)
-			}
-			log.Infof(" BlockInit at %d for block %s", bh, blk.block.Hash().String())
-			snr := miner.Sync[bh]
-			miner.syncMutex.Unlock()
-
-			if POWStopper != nil {
-				if len(POWStopper) < wire.CommitteeSize {
-					POWStopper <- struct{}{}
-				} else {
-					log.Infof("len(POWStopper) = %d", len(POWStopper))
-				}
-			}
-			snr.BlockInit(blk.block)
-
-		case <- Quit:
+		case <- m.quit:
 			polling = false
 			break out
 		}
 	}
 	log.Info("Consensus quitting")
 
-	miner.syncMutex.Lock()
-	for i, t := range miner.Sync {
+	m.syncMutex.Lock()
+	for i, t := range m.Sync {
 		log.Infof("Sync %d to Quit", i)
-		delete(miner.Sync, i)
+		delete(m.Sync, i)
 		t.Quit()
 	}
-	miner.syncMutex.Unlock()
+	m.syncMutex.Unlock()
 
 	for true {
 		select {
-		case <-miner.updateheight:
-		case <-newblockch:
-		case <-connNotice:
+		case <-m.updateheight:
+		case <-m.newblockch:
+		case <-m.connNotice:
 
 		default:
 			log.Info("consensus quits")
@@ -328,7 +325,46 @@ func Consensus(s PeerNotifier, dataDir string, addr []btcutil.Address, cfg *chai
 	}
 }
 
-func HandleMessage(m Message) (bool, * chainhash.Hash) {
+func (m *Engine) updateHeight(height int32) {
+	m.syncMutex.Lock()
+	m.cleaner(height)
+	for _, t := range m.Sync {
+		t.UpdateChainHeight(height)
+	}
+	m.syncMutex.Unlock()
+}
+
+func (m *Engine) newBlock(blk newblock) {
+	top := m.server.BestSnapshot().Height
+	bh := blk.block.Height()
+
+	if bh <= top {
+		return
+	}
+
+	rules := m.cfg.Committee(blk.block.MsgBlock().Header.Version)
+	if len(blk.block.MsgBlock().Transactions[0].SignatureScripts) > int(rules.Sigs) {
+		return
+	}
+
+	m.syncMutex.Lock()
+	if _, ok := m.Sync[bh]; !ok {
+		log.Infof(" CreateSyncer at %d", bh)
+		m.Sync[bh] = m.CreateSyncer(bh)
+	}
+	log.Infof(" BlockInit at %d for block %s", bh, blk.block.Hash().String())
+	snr := m.Sync[bh]
+	m.syncMutex.Unlock()
+
+	if len(m.powStopper) < int(rules.Size) {
+		m.powStopper <- struct{}{}
+	} else {
+		log.Infof("len(POWStopper) = %d", len(m.powStopper))
+	}
+	snr.BlockInit(blk.block)
+}
+
+func (miner *Engine) HandleMessage(m Message) (bool, * chainhash.Hash) {
 	if miner == nil || miner.shutdown {
 		return false, nil
 	}
@@ -345,7 +381,7 @@ func HandleMessage(m Message) (bool, * chainhash.Hash) {
 	s, ok := miner.Sync[h]
 
 	if !ok {
-		miner.Sync[h] = CreateSyncer(h)
+		miner.Sync[h] = miner.CreateSyncer(h)
 		s = miner.Sync[h]
 	} else if miner.Sync[h].Done {
 		miner.syncMutex.Unlock()
@@ -385,13 +421,13 @@ func HandleMessage(m Message) (bool, * chainhash.Hash) {
 			hash = nil
 		}
 
-		if len(s.commands) > (wire.CommitteeSize - 1) * 10 {
+		if len(s.commands) > int(s.rules.Size - 1) * 10 {
 			log.Infof("too many messages are queued. Discard oldest one.")
 			<- s.commands
 		}
 	}
 
-	if len(s.commands) > (wire.CommitteeSize - 1) * 10 {
+	if len(s.commands) > int(s.rules.Size - 1) * 10 {
 		log.Infof("Runnable syner %d has too many (%d) messages queued. Discard oldest one.", s.Height, len(s.commands))
 		<- s.commands
 		s.DebugInfo()
@@ -403,32 +439,32 @@ func HandleMessage(m Message) (bool, * chainhash.Hash) {
 	return false, hash
 }
 
-func UpdateChainHeight(latestHeight int32) {
-	if miner.shutdown {
+func (m *Engine) UpdateChainHeight(latestHeight int32) {
+	if m == nil || m.shutdown {
 		return
 	}
-	if miner != nil {
-		miner.updateheight <- latestHeight
-	}
+	m.updateheight <- latestHeight
 }
 
-func cleaner(top int32) {
-	if miner.shutdown {
+func (m *Engine) cleaner(top int32) {
+	if m.shutdown {
 		return
 	}
-	for i, t := range miner.Sync {
+	for i, t := range m.Sync {
 		if i < top {
-			delete(miner.Sync, i)
+			delete(m.Sync, i)
 			t.Quit()
 		}
 	}
 }
 
-func Shutdown() {
-	miner.shutdown = true
+// Shutdown stops the engine and all its syncers. An engine can not be run
+// again once it has been shut down, a new one is created instead.
+func (m *Engine) Shutdown() {
+	m.shutdown = true
 
 	log.Infof("Syners:")
-	for h,s := range miner.Sync {
+	for h,s := range m.Sync {
 		log.Infof("%d Runnable = %v", h, s.Runnable)
 		s.Quit()
 	}
@@ -436,15 +472,14 @@ func Shutdown() {
 //	DebugInfo()
 
 	select {
-	case <-Quit:
+	case <-m.quit:
+		return
 	default:
-		close(Quit)
+		close(m.quit)
 	}
-	miner.wg.Wait()
+	m.wg.Wait()
 
-	if POWStopper != nil {
-		close(POWStopper)
-	}
+	close(m.powStopper)
 
 	log.Infof("Consensus Shutdown completed")
 }
@@ -458,28 +493,28 @@ func VerifyMsg(msg wire.OmegaMessage, pubkey * btcec.PublicKey) bool {
 	return valid
 }
 
-func DebugInfo() {
+func (m *Engine) DebugInfo() {
 	top := int32(0)
-	if miner == nil {
+	if m == nil {
 		return
 	}
-	miner.syncMutex.Lock()
-	for h,_ := range miner.Sync {
+	m.syncMutex.Lock()
+	for h,_ := range m.Sync {
 		if h > top {
 			top = h
 		}
 	}
-	log.Infof("\nMiner has %d Syncers\n\nThe top syncer is %d:", len(miner.Sync), top)
-	for h,s := range miner.Sync {
+	log.Infof("\nMiner has %d Syncers\n\nThe top syncer is %d:", len(m.Sync), top)
+	for h,s := range m.Sync {
 		if h < top - 2 {
-			delete(miner.Sync, h)
+			delete(m.Sync, h)
 			log.Infof("\nStopping %d", h)
 			s.Quit()
 		}
 	}
 	log.Infof("\nDone examing syner heights")
-	if s,ok := miner.Sync[top]; ok {
+	if s,ok := m.Sync[top]; ok {
 		s.DebugInfo()
 	}
-	miner.syncMutex.Unlock()
+	m.syncMutex.Unlock()
 }
\ No newline at end of file
@@ -0,0 +1,128 @@
+//go:build simulation
+// +build simulation
+
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package consensus
+
+import (
+	"sort"
+)
+
+// In a build with the simulation tag, syncers do not run in goroutines of
+// their own and engines are not Run. The owner of the engines drives them in
+// one goroutine by calling Step and Tick, so a committee can be simulated on a
+// virtual clock. Engines must not be driven from several goroutines.
+
+// running are the syncers started and not finished of each engine.
+var running = make(map[*Engine][]*Syncer)
+
+// start queues the syncer to be stepped by its engine.
+func (self *Syncer) start() {
+	self.begin = self.engine.clock.Now()
+	running[self.engine] = append(running[self.engine], self)
+}
+
+// step handles the commands queued until none is left, or finishes the syncer
+// if it is done. It returns true when the syncer has finished.
+func (self *Syncer) step() bool {
+	for {
+		self.forestLock.Lock()
+
+		select {
+		case <-self.quit:
+			self.forestLock.Unlock()
+			self.finish()
+			return true
+
+		case cmd := <-self.commands:
+			if self.handle(cmd) {
+				self.forestLock.Unlock()
+				self.finish()
+				return true
+			}
+
+		default:
+			self.forestLock.Unlock()
+			return false
+		}
+		self.forestLock.Unlock()
+		self.handeling = ""
+	}
+}
+
+// Step does what Run does with the blocks, notifications and heights passed
+// in, then handles the messages queued for the running syncers, in the order
+// of their heights, until none is left.
+func (m *Engine) Step() {
+	for polling := true; polling; {
+		select {
+		case height := <-m.updateheight:
+			m.updateHeight(height)
+
+		case c := <-m.connNotice:
+			m.handleConnNotice(c)
+
+		case blk := <-m.newblockch:
+			m.newBlock(blk)
+
+		default:
+			polling = false
+		}
+	}
+
+	finished := make(map[*Syncer]struct{})
+	for _, s := range m.syncers() {
+		if s.step() {
+			finished[s] = struct{}{}
+		}
+	}
+
+	var left []*Syncer
+	for _, s := range running[m] {
+		if _, ok := finished[s]; !ok {
+			left = append(left, s)
+		}
+	}
+	if len(left) == 0 {
+		delete(running, m)
+	} else {
+		running[m] = left
+	}
+}
+
+// Tick does what the one second ticker of a running syncer does: it runs the
+// repeater of each syncer that is not done, then Steps.
+func (m *Engine) Tick() {
+	for _, s := range m.syncers() {
+		if s.Done {
+			continue
+		}
+		s.forestLock.Lock()
+		s.repeater()
+		s.forestLock.Unlock()
+	}
+	m.Step()
+}
+
+// Halt drops the running syncers of the engine without finishing them, as a
+// crash of the node would.
+func (m *Engine) Halt() {
+	m.shutdown = true
+	delete(running, m)
+}
+
+func (m *Engine) syncers() []*Syncer {
+	rs := make([]*Syncer, len(running[m]))
+	copy(rs, running[m])
+	sort.Slice(rs, func(i, j int) bool {
+		return rs[i].Height < rs[j].Height
+	})
+	return rs
+}
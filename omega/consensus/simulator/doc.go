@@ -0,0 +1,40 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+// Package simulator runs a committee of consensus engines in one goroutine
+// on a virtual clock. The engines talk to each other over a message bus that
+// delays, drops and reorders messages, partitions the network and crashes
+// and restarts nodes as scripted. Every decision of the bus is derived from
+// the seed of the run and the content of the message, so a run is fully
+// determined by its Config and a failing seed can be replayed.
+//
+// The first nodes, as many as the size of the committee of the parameters of
+// the run, form the committee, the others follow the chain. The committee
+// does not rotate. Each member submits a candidate block for the next height
+// whenever it connects a block, and the signed blocks published by the
+// engines are relayed and connected by all nodes.
+//
+// A run checks safety: no two different blocks are signed at a height, and
+// every signed block carries enough valid signatures of the committee. It
+// measures liveness as the virtual time taken by the nodes that are up to
+// reach the height asked for. A member that consented to a candidate does
+// not withdraw the consent when the candidate disappears, so a member that
+// crashes for good may stall the committee until it rotates. Liveness is
+// therefore expected only of runs where crashed members restart.
+//
+// A restarted node runs a new engine on the chain and signing journal it has,
+// so it does not sign a second block at a height. It submits the candidate it
+// submitted before the crash again, as a member submitting two candidates for
+// a height is taken as malicious.
+//
+// The engines are stepped by the simulator instead of running in goroutines
+// of their own, which the consensus package supports in builds with the
+// simulation tag only:
+//
+//	go test -tags simulation ./omega/consensus/simulator/
+package simulator
@@ -0,0 +1,436 @@
+//go:build simulation
+// +build simulation
+
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package simulator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	"github.com/omegasuite/btcd/blockchain"
+	"github.com/omegasuite/btcd/btcec"
+	"github.com/omegasuite/btcd/chaincfg"
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/wire"
+	"github.com/omegasuite/btcd/wire/common"
+	"github.com/omegasuite/btcutil"
+	"github.com/omegasuite/omega/consensus"
+	"github.com/omegasuite/omega/signer"
+	"github.com/omegasuite/omega/token"
+)
+
+// gossipTicks is the number of ticks between announcements of the best block.
+const gossipTicks = 5
+
+// Node is a simulated full node. It is the PeerNotifier of its consensus
+// engine.
+type Node struct {
+	net    *network
+	index  int
+	signer signer.Signer
+	name   [20]byte
+	params chaincfg.Params
+
+	engine     *consensus.Engine
+	journal    *consensus.Journal // kept across restarts, as on disk
+	subscriber func(*blockchain.Notification)
+
+	up          bool
+	incarnation int
+
+	best       blockchain.BestState
+	chain      []chainhash.Hash
+	blocks     map[chainhash.Hash][]byte // connected blocks and own candidates
+	candidates map[int32]chainhash.Hash  // own candidates by height
+	orphans    map[chainhash.Hash][]byte // blocks waiting for their parent
+	lastPushed map[int]time.Duration
+	ticks      int
+}
+
+func newNode(net *network, index int) *Node {
+	seed := sha256.Sum256([]byte(fmt.Sprintf("simulated miner %d", index)))
+	key, _ := btcec.PrivKeyFromBytes(btcec.S256(), seed[:])
+
+	n := &Node{
+		net:        net,
+		index:      index,
+		signer:     signer.NewKeySigner(key),
+		params:     *net.params,
+		blocks:     make(map[chainhash.Hash][]byte),
+		candidates: make(map[int32]chainhash.Hash),
+		orphans:    make(map[chainhash.Hash][]byte),
+		lastPushed: make(map[int]time.Duration),
+		journal:    consensus.NewJournal(),
+	}
+	copy(n.name[:], btcutil.Hash160(key.PubKey().SerializeCompressed()))
+	n.params.ExternalIPs = []string{n.ip()}
+
+	n.best = blockchain.BestState{
+		Height:       0,
+		LastRotation: uint32(net.committee.Size - 1),
+	}
+	if net.params.GenesisHash != nil {
+		n.best.Hash = *net.params.GenesisHash
+	}
+	n.chain = []chainhash.Hash{n.best.Hash}
+
+	return n
+}
+
+// ip is the connection of the node recorded in its miner block.
+func (n *Node) ip() string {
+	return fmt.Sprintf("10.0.0.%d:8383", n.index+1)
+}
+
+func (n *Node) member() bool {
+	return n.index < int(n.net.committee.Size)
+}
+
+func (n *Node) start() {
+	n.up = true
+	n.incarnation++
+	n.engine = consensus.NewEngine(&n.params, n, clock{n.net}, n.journal, []btcutil.Address{n.address()})
+
+	n.scheduleTick()
+	n.scheduleMining()
+}
+
+func (n *Node) address() btcutil.Address {
+	addr, _ := btcutil.NewAddressPubKeyHash(n.name[:], &n.params)
+	return addr
+}
+
+func (n *Node) crash() {
+	if !n.up {
+		return
+	}
+	n.up = false
+	n.engine.Halt()
+	n.engine = nil
+	n.subscriber = nil
+	n.orphans = make(map[chainhash.Hash][]byte)
+}
+
+func (n *Node) restart() {
+	if n.up {
+		return
+	}
+	n.start()
+}
+
+// scheduleTick schedules the one second ticker of the engine. Nodes tick at
+// different phases.
+func (n *Node) scheduleTick() {
+	incarnation := n.incarnation
+	phase := time.Duration(n.net.rand(uint64(time.Second), "phase", n.index, incarnation))
+	at := n.net.now - n.net.now%time.Second + phase
+	if at <= n.net.now {
+		at += time.Second
+	}
+	n.net.schedule(at, n.net.key("tick", n.index, at), func() {
+		if !n.up || n.incarnation != incarnation {
+			return
+		}
+		n.engine.Tick()
+		n.gossip()
+		n.scheduleTick()
+	})
+}
+
+// gossip announces the best block to a peer every few seconds, so that nodes
+// that have missed blocks catch up.
+func (n *Node) gossip() {
+	n.ticks++
+	if n.ticks%gossipTicks != 0 || n.best.Height == 0 {
+		return
+	}
+	to := int(n.net.rand(uint64(len(n.net.nodes)), "gossip", n.index, n.net.now))
+	if to != n.index {
+		n.sendBlock(to, n.blocks[n.best.Hash])
+	}
+}
+
+// scheduleMining schedules the submission of a candidate for the next height.
+func (n *Node) scheduleMining() {
+	if !n.member() || n.best.Height >= n.net.cfg.Heights {
+		return
+	}
+	height := n.best.Height + 1
+	incarnation := n.incarnation
+	at := n.net.now + time.Duration(n.net.rand(uint64(n.net.cfg.MineDelay)+1, "mine", n.index, height, incarnation))
+	n.net.schedule(at, n.net.key("mine", n.index, height), func() {
+		if !n.up || n.incarnation != incarnation || n.best.Height+1 != height {
+			return
+		}
+		n.mine(height)
+	})
+}
+
+// fees returns the fees of the candidate of member i at height. They are
+// often equal so ties are broken.
+func (net *network) fees(i int, height int32) int64 {
+	return 1000 + int64(net.rand(3, "fees", i, height))
+}
+
+// mine submits a candidate for height. A member submits one candidate for a
+// height, the engines of the others take a second one as malice, so after a
+// restart the candidate submitted before is submitted again.
+func (n *Node) mine(height int32) {
+	if hash, ok := n.candidates[height]; ok {
+		n.processBlock(n.blocks[hash])
+		return
+	}
+
+	coinbase := wire.NewMsgTx(wire.TxVersion)
+	coinbase.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: uint32(height)},
+		Sequence:         0xFFFFFFFF,
+		SignatureIndex:   0xFFFFFFFF,
+	})
+	fees := n.net.fees(n.index, height)
+	for _, m := range n.net.minerBlocks {
+		pkScript := make([]byte, 25)
+		pkScript[0] = n.params.PubKeyHashAddrID
+		copy(pkScript[1:], m.MsgBlock().Miner[:])
+		coinbase.AddTxOut(wire.NewTxOut(0, &token.NumToken{Val: fees}, nil, pkScript))
+	}
+	coinbase.SignatureScripts = [][]byte{[]byte(fmt.Sprintf("height %d", height)), n.name[:]}
+
+	block := &wire.MsgBlock{
+		Header: wire.BlockHeader{
+			Version:    1,
+			PrevBlock:  n.best.Hash,
+			MerkleRoot: coinbase.TxHash(),
+			Timestamp:  epoch.Add(n.net.now).Truncate(time.Second),
+			Nonce:      int32(n.index),
+		},
+		Transactions: []*wire.MsgTx{coinbase},
+	}
+
+	var w bytes.Buffer
+	block.Serialize(&w)
+	n.blocks[block.BlockHash()] = w.Bytes()
+	n.candidates[height] = block.BlockHash()
+
+	n.processBlock(w.Bytes())
+}
+
+// processBlock passes a candidate to the engine.
+func (n *Node) processBlock(b []byte) {
+	block, err := btcutil.NewBlockFromBytes(b)
+	if err != nil {
+		return
+	}
+	block.SetHeight(blockHeight(block.MsgBlock()))
+	n.engine.ProcessBlock(block, blockchain.BFNone)
+	n.engine.Step()
+}
+
+// receive handles a message from node from.
+func (n *Node) receive(from int, msg wire.Message) {
+	switch m := msg.(type) {
+	case *wire.MsgBlock:
+		var w bytes.Buffer
+		m.Serialize(&w)
+		n.acceptBlock(from, w.Bytes())
+
+	case *wire.MsgGetData:
+		for _, inv := range m.InvList {
+			if b, ok := n.blocks[inv.Hash]; ok {
+				n.sendBlock(from, b)
+			} else if block := n.engine.ServeBlock(&inv.Hash); block != nil {
+				n.net.send(n.index, from, block.MsgBlock())
+			}
+		}
+
+	case consensus.Message:
+		push, hash := n.engine.HandleMessage(m)
+		n.engine.Step()
+		if push {
+			// the sender is behind, tell it our best block
+			if last, ok := n.lastPushed[from]; !ok || n.net.now-last >= time.Second {
+				n.lastPushed[from] = n.net.now
+				n.sendBlock(from, n.blocks[n.best.Hash])
+			}
+		} else if hash != nil {
+			n.getData(from, *hash)
+		}
+	}
+}
+
+func (n *Node) sendBlock(to int, b []byte) {
+	if b == nil {
+		return
+	}
+	var block wire.MsgBlock
+	if err := block.Deserialize(bytes.NewReader(b)); err != nil {
+		return
+	}
+	n.net.send(n.index, to, &block)
+}
+
+func (n *Node) getData(to int, hash chainhash.Hash) bool {
+	msg := wire.MsgGetData{InvList: []*wire.InvVect{{Type: common.InvTypeWitnessBlock, Hash: hash}}}
+	return n.net.send(n.index, to, &msg)
+}
+
+// acceptBlock handles a block received from node from. A signed block is
+// connected, a candidate is passed to the engine.
+func (n *Node) acceptBlock(from int, b []byte) {
+	var block wire.MsgBlock
+	if err := block.Deserialize(bytes.NewReader(b)); err != nil {
+		return
+	}
+	height := blockHeight(&block)
+	hash := block.BlockHash()
+
+	if len(block.Transactions[0].SignatureScripts) <= int(n.net.committee.Sigs) {
+		n.processBlock(b)
+		return
+	}
+
+	if err := n.net.verify(&block, height); err != nil {
+		return
+	}
+
+	if height <= n.best.Height {
+		if n.chain[height] != hash {
+			n.net.violation("node %d received block %s at %d where it has connected %s",
+				n.index, hash, height, n.chain[height])
+		}
+		return
+	}
+
+	if block.Header.PrevBlock != n.best.Hash {
+		n.orphans[block.Header.PrevBlock] = b
+		n.getData(from, block.Header.PrevBlock)
+		return
+	}
+
+	n.connect(b)
+}
+
+// connect connects a signed block extending the best chain, and the orphans
+// waiting for it.
+func (n *Node) connect(b []byte) {
+	for b != nil {
+		block, err := btcutil.NewBlockFromBytes(b)
+		if err != nil {
+			return
+		}
+		height := blockHeight(block.MsgBlock())
+		hash := *block.Hash()
+		block.SetHeight(height)
+
+		n.chain = append(n.chain, hash)
+		n.blocks[hash] = b
+		n.best.Hash = hash
+		n.best.Height = height
+
+		for i := range n.net.nodes {
+			if i != n.index {
+				n.sendBlock(i, b)
+			}
+		}
+
+		if n.subscriber != nil {
+			n.subscriber(&blockchain.Notification{Type: blockchain.NTBlockConnected, Data: block})
+			n.engine.Step()
+		}
+
+		n.scheduleMining()
+
+		b = n.orphans[hash]
+		delete(n.orphans, hash)
+	}
+}
+
+// MyPlaceInCommittee is part of the PeerNotifier interface.
+func (n *Node) MyPlaceInCommittee(r int32) int32 {
+	return int32(n.index)
+}
+
+// CommitteeMsg is part of the PeerNotifier interface.
+func (n *Node) CommitteeMsg(p [20]byte, h int32, m wire.Message) bool {
+	to, ok := n.net.names[p]
+	return ok && n.net.send(n.index, to, m)
+}
+
+// Connected is part of the PeerNotifier interface.
+func (n *Node) Connected(p [20]byte) bool {
+	to, ok := n.net.names[p]
+	return ok && n.net.linked(n.index, to)
+}
+
+// CommitteeMsgMG is part of the PeerNotifier interface.
+func (n *Node) CommitteeMsgMG(p [20]byte, h int32, m wire.Message) {
+	n.CommitteeMsg(p, h, m)
+}
+
+// NewConsusBlock is part of the PeerNotifier interface. The block is checked
+// and connected after the engine returns.
+func (n *Node) NewConsusBlock(block *btcutil.Block) {
+	n.net.published(n, block)
+
+	var w bytes.Buffer
+	block.MsgBlock().Serialize(&w)
+	b := w.Bytes()
+	incarnation := n.incarnation
+	n.net.schedule(n.net.now, n.net.key("publish", n.index, b), func() {
+		if n.up && n.incarnation == incarnation {
+			n.acceptBlock(n.index, b)
+		}
+	})
+}
+
+// GetSigner is part of the PeerNotifier interface.
+func (n *Node) GetSigner(name [20]byte) signer.Signer {
+	if name == n.name {
+		return n.signer
+	}
+	return nil
+}
+
+// BestSnapshot is part of the PeerNotifier interface.
+func (n *Node) BestSnapshot() *blockchain.BestState {
+	best := n.best
+	return &best
+}
+
+// MinerBlockByHeight is part of the PeerNotifier interface.
+func (n *Node) MinerBlockByHeight(h int32) (*wire.MinerBlock, error) {
+	if h < 0 || int(h) >= len(n.net.minerBlocks) {
+		return nil, fmt.Errorf("no miner block at %d", h)
+	}
+	return n.net.minerBlocks[h], nil
+}
+
+// SubscribeChain is part of the PeerNotifier interface.
+func (n *Node) SubscribeChain(fn func(*blockchain.Notification)) {
+	n.subscriber = fn
+}
+
+// CommitteePolling is part of the PeerNotifier interface.
+func (n *Node) CommitteePolling() {
+}
+
+// ChainSync is part of the PeerNotifier interface.
+func (n *Node) ChainSync(hash chainhash.Hash, from [20]byte) {
+	if to, ok := n.net.names[from]; ok {
+		n.getData(to, hash)
+	}
+}
+
+// ResetConnections is part of the PeerNotifier interface.
+func (n *Node) ResetConnections() {
+}
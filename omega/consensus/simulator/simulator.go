@@ -0,0 +1,541 @@
+//go:build simulation
+// +build simulation
+
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package simulator
+
+import (
+	"bytes"
+	"container/heap"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/omegasuite/btcd/blockchain"
+	"github.com/omegasuite/btcd/chaincfg"
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/wire"
+	"github.com/omegasuite/btcutil"
+)
+
+// epoch is the wall clock time at the start of every run.
+var epoch = time.Unix(1600000000, 0)
+
+// Config describes a run.
+type Config struct {
+	Seed int64
+
+	// Nodes is the number of nodes, at least the size of the committee.
+	Nodes int
+
+	// Heights is the number of blocks to be signed.
+	Heights int32
+
+	// Deadline is the virtual time the run may take.
+	Deadline time.Duration
+
+	// Messages take from MinLatency to MaxLatency to be delivered, so
+	// messages sent close in time may arrive out of order. A message is
+	// lost with the probability DropRate.
+	MinLatency, MaxLatency time.Duration
+	DropRate               float64
+
+	// MineDelay is the longest time a member takes to submit its candidate
+	// after connecting a block.
+	MineDelay time.Duration
+
+	Partitions []Partition
+	Crashes    []Crash
+
+	// Intercept, if set, is called for every message sent over the bus after
+	// the bus has decided its delay and whether to drop it. It may change
+	// both.
+	Intercept func(e *Envelope)
+
+	// Params defaults to the regression net parameters. The committee is
+	// the one of the miner blocks of version minerVersion.
+	Params *chaincfg.Params
+}
+
+// minerVersion is the version of the miner blocks of the committee.
+const minerVersion = 1
+
+// committee returns the parameters of the committee of a run of cfg.
+func (cfg *Config) committee() *chaincfg.CommitteeParams {
+	if cfg.Params == nil {
+		return chaincfg.RegressionNetParams.Committee(minerVersion)
+	}
+	return cfg.Params.Committee(minerVersion)
+}
+
+// Partition splits the network from From to To. Nodes in different groups
+// can not talk to each other. A node in no group is isolated.
+type Partition struct {
+	From, To time.Duration
+	Groups   [][]int
+}
+
+// Crash stops Node at At and restarts it at Restart, or never if Restart is
+// not after At. A restarted node keeps its chain and signing journal but runs
+// a new engine.
+type Crash struct {
+	Node        int
+	At, Restart time.Duration
+}
+
+// Envelope is a message on the bus.
+type Envelope struct {
+	From, To int
+	Msg      wire.Message
+	Sent     time.Duration
+	Delay    time.Duration
+	Drop     bool
+}
+
+// Result is the outcome of a run.
+type Result struct {
+	Seed int64
+
+	// Heights is the best height of each node at the end of the run.
+	Heights []int32
+
+	// Reached is the virtual time when all nodes up reached the height asked
+	// for, zero if they did not.
+	Reached time.Duration
+
+	// Signed is the block signed at each height from 1 on.
+	Signed []chainhash.Hash
+
+	// Violations of safety found.
+	Violations []string
+
+	Sent, Dropped, Delivered int
+
+	// Trace is a digest of all events of the run. Runs of the same Config
+	// have the same Trace.
+	Trace chainhash.Hash
+}
+
+// Safe returns whether no violation of safety has been found.
+func (r *Result) Safe() bool {
+	return len(r.Violations) == 0
+}
+
+// Live returns whether the height asked for has been reached in time.
+func (r *Result) Live() bool {
+	return r.Reached > 0
+}
+
+func (r *Result) String() string {
+	return fmt.Sprintf("seed %d: heights %v reached at %v, %d messages sent, %d dropped, %d delivered, violations %v",
+		r.Seed, r.Heights, r.Reached, r.Sent, r.Dropped, r.Delivered, r.Violations)
+}
+
+// Scenario returns the config of a run signing heights blocks on a network
+// with faults derived from seed: latency up to a second, up to a fifth of the
+// messages lost, possibly a partition that heals, and possibly a member of
+// the committee crashing and restarting.
+func Scenario(seed int64, heights int32) Config {
+	r := rand.New(rand.NewSource(seed))
+
+	size := int(chaincfg.RegressionNetParams.Committee(minerVersion).Size)
+
+	cfg := Config{
+		Seed:       seed,
+		Nodes:      size + r.Intn(2),
+		Heights:    heights,
+		MinLatency: time.Duration(r.Intn(50)) * time.Millisecond,
+		DropRate:   float64(r.Intn(20)) / 100,
+		MineDelay:  time.Duration(r.Intn(2000)) * time.Millisecond,
+	}
+	cfg.MaxLatency = cfg.MinLatency + time.Duration(r.Intn(1000))*time.Millisecond
+	cfg.Deadline = time.Duration(heights) * 5 * time.Minute
+
+	if r.Intn(2) == 0 {
+		p := Partition{From: time.Duration(r.Intn(30)) * time.Second}
+		p.To = p.From + time.Duration(1+r.Intn(60))*time.Second
+		p.Groups = make([][]int, 2)
+		for i := 0; i < cfg.Nodes; i++ {
+			g := r.Intn(2)
+			p.Groups[g] = append(p.Groups[g], i)
+		}
+		cfg.Partitions = append(cfg.Partitions, p)
+		cfg.Deadline += p.To
+	}
+
+	if r.Intn(2) == 0 {
+		c := Crash{Node: r.Intn(size), At: time.Duration(r.Intn(30)) * time.Second}
+		c.Restart = c.At + time.Duration(1+r.Intn(60))*time.Second
+		cfg.Deadline += c.Restart
+		cfg.Crashes = append(cfg.Crashes, c)
+	}
+
+	return cfg
+}
+
+type event struct {
+	at  time.Duration
+	key chainhash.Hash // orders events at the same time
+	seq uint64
+	run func()
+}
+
+type eventQueue []*event
+
+func (q eventQueue) Len() int {
+	return len(q)
+}
+
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].at != q[j].at {
+		return q[i].at < q[j].at
+	}
+	if c := bytes.Compare(q[i].key[:], q[j].key[:]); c != 0 {
+		return c < 0
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q eventQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
+
+func (q *eventQueue) Push(x interface{}) {
+	*q = append(*q, x.(*event))
+}
+
+func (q *eventQueue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+// clock is the virtual clock of a network.
+type clock struct {
+	net *network
+}
+
+func (c clock) Now() time.Time {
+	return epoch.Add(c.net.now)
+}
+
+// Sleep returns at once. Virtual time passes only between events.
+func (c clock) Sleep(d time.Duration) {
+}
+
+type network struct {
+	cfg       Config
+	params    *chaincfg.Params
+	committee *chaincfg.CommitteeParams
+	now       time.Duration
+	events    eventQueue
+	seq       uint64
+
+	nodes       []*Node
+	names       map[[20]byte]int
+	minerBlocks []*wire.MinerBlock
+
+	result *Result
+	trace  chainhash.Hash
+}
+
+// Run runs the simulation described by cfg.
+func Run(cfg Config) *Result {
+	committee := cfg.committee()
+	if cfg.Nodes < int(committee.Size) {
+		cfg.Nodes = int(committee.Size)
+	}
+	if cfg.Heights <= 0 {
+		cfg.Heights = 1
+	}
+	if cfg.Deadline <= 0 {
+		cfg.Deadline = time.Duration(cfg.Heights) * 10 * time.Minute
+	}
+	if cfg.MaxLatency < cfg.MinLatency {
+		cfg.MaxLatency = cfg.MinLatency
+	}
+
+	net := &network{
+		cfg:       cfg,
+		params:    cfg.Params,
+		committee: committee,
+		names:     make(map[[20]byte]int),
+		result:    &Result{Seed: cfg.Seed},
+	}
+	if net.params == nil {
+		net.params = &chaincfg.RegressionNetParams
+	}
+
+	for i := 0; i < cfg.Nodes; i++ {
+		n := newNode(net, i)
+		net.nodes = append(net.nodes, n)
+		net.names[n.name] = i
+	}
+
+	for i := 0; i < int(committee.Size); i++ {
+		mb := wire.NewMinerBlock(&wire.MingingRightBlock{
+			Version:    minerVersion,
+			Timestamp:  epoch,
+			Nonce:      int32(i),
+			Miner:      net.nodes[i].name,
+			Connection: []byte(net.nodes[i].ip()),
+		})
+		mb.SetHeight(int32(i))
+		net.minerBlocks = append(net.minerBlocks, mb)
+	}
+
+	for _, c := range cfg.Crashes {
+		c := c
+		if c.Node < 0 || c.Node >= cfg.Nodes {
+			continue
+		}
+		net.schedule(c.At, net.key("crash", c.Node), func() {
+			net.nodes[c.Node].crash()
+		})
+		if c.Restart > c.At {
+			net.schedule(c.Restart, net.key("restart", c.Node), func() {
+				net.nodes[c.Node].restart()
+			})
+		}
+	}
+
+	for _, n := range net.nodes {
+		n.start()
+	}
+
+	for net.events.Len() > 0 {
+		e := heap.Pop(&net.events).(*event)
+		if e.at > cfg.Deadline {
+			break
+		}
+		net.now = e.at
+		net.record(e.key[:])
+		e.run()
+
+		if net.reached() {
+			net.result.Reached = net.now
+			break
+		}
+	}
+
+	for _, n := range net.nodes {
+		net.result.Heights = append(net.result.Heights, n.best.Height)
+	}
+	net.result.Trace = net.trace
+
+	return net.result
+}
+
+// rand returns a number in [0, n) derived from the seed and parts. It is the
+// only source of randomness of a run.
+func (net *network) rand(n uint64, parts ...interface{}) uint64 {
+	if n == 0 {
+		return 0
+	}
+	k := net.key(parts...)
+	return binary.LittleEndian.Uint64(k[:]) % n
+}
+
+// key returns the digest of the seed and parts.
+func (net *network) key(parts ...interface{}) chainhash.Hash {
+	h := sha256.New()
+	binary.Write(h, binary.LittleEndian, net.cfg.Seed)
+	for _, p := range parts {
+		switch v := p.(type) {
+		case string:
+			h.Write([]byte(v))
+		case []byte:
+			h.Write(v)
+		case int:
+			binary.Write(h, binary.LittleEndian, int64(v))
+		case int32:
+			binary.Write(h, binary.LittleEndian, v)
+		case time.Duration:
+			binary.Write(h, binary.LittleEndian, int64(v))
+		case chainhash.Hash:
+			h.Write(v[:])
+		}
+	}
+	var k chainhash.Hash
+	copy(k[:], h.Sum(nil))
+	return k
+}
+
+func (net *network) schedule(at time.Duration, key chainhash.Hash, run func()) {
+	net.seq++
+	heap.Push(&net.events, &event{at: at, key: key, seq: net.seq, run: run})
+}
+
+// record adds an event to the trace of the run.
+func (net *network) record(k []byte) {
+	var w bytes.Buffer
+	w.Write(net.trace[:])
+	binary.Write(&w, binary.LittleEndian, int64(net.now))
+	w.Write(k)
+	net.trace = chainhash.HashH(w.Bytes())
+}
+
+func (net *network) violation(format string, args ...interface{}) {
+	net.result.Violations = append(net.result.Violations,
+		fmt.Sprintf("at %v: ", net.now)+fmt.Sprintf(format, args...))
+}
+
+// linked returns whether nodes a and b can talk to each other now.
+func (net *network) linked(a, b int) bool {
+	if !net.nodes[a].up || !net.nodes[b].up {
+		return false
+	}
+	for _, p := range net.cfg.Partitions {
+		if net.now < p.From || net.now >= p.To {
+			continue
+		}
+		ga, gb := -1, -1
+		for g, nodes := range p.Groups {
+			for _, n := range nodes {
+				if n == a {
+					ga = g
+				}
+				if n == b {
+					gb = g
+				}
+			}
+		}
+		if ga < 0 || ga != gb {
+			return false
+		}
+	}
+	return true
+}
+
+// send puts msg on the bus. It returns false if there is no connection from
+// node from to node to. A message dropped by the bus is considered sent.
+func (net *network) send(from, to int, msg wire.Message) bool {
+	if from == to || to < 0 || to >= len(net.nodes) || !net.linked(from, to) {
+		return false
+	}
+
+	var w bytes.Buffer
+	if _, err := wire.WriteMessageWithEncodingN(&w, msg, wire.ProtocolVersion,
+		net.params.Net, wire.SignatureEncoding); err != nil {
+		net.violation("node %d failed to encode %s: %v", from, msg.Command(), err)
+		return false
+	}
+	payload := w.Bytes()
+
+	net.result.Sent++
+
+	e := &Envelope{From: from, To: to, Msg: msg, Sent: net.now}
+	if spread := net.cfg.MaxLatency - net.cfg.MinLatency; spread > 0 {
+		e.Delay = net.cfg.MinLatency + time.Duration(net.rand(uint64(spread)+1, "delay", from, to, payload, net.now))
+	} else {
+		e.Delay = net.cfg.MinLatency
+	}
+	if net.cfg.DropRate > 0 {
+		e.Drop = net.rand(1000000, "drop", from, to, payload, net.now) < uint64(net.cfg.DropRate*1000000)
+	}
+	if net.cfg.Intercept != nil {
+		net.cfg.Intercept(e)
+	}
+	if e.Drop {
+		net.result.Dropped++
+		return true
+	}
+
+	dest := net.nodes[to]
+	incarnation := dest.incarnation
+	net.schedule(net.now+e.Delay, net.key("deliver", from, to, payload), func() {
+		if !dest.up || dest.incarnation != incarnation {
+			net.result.Dropped++
+			return
+		}
+		_, m, _, err := wire.ReadMessageWithEncodingN(bytes.NewReader(payload),
+			wire.ProtocolVersion, net.params.Net, wire.SignatureEncoding)
+		if err != nil {
+			net.violation("node %d failed to decode a message from %d: %v", to, from, err)
+			return
+		}
+		net.result.Delivered++
+		dest.receive(from, m)
+	})
+
+	return true
+}
+
+// published checks a block signed by the committee and published by node n.
+func (net *network) published(n *Node, block *btcutil.Block) {
+	height := blockHeight(block.MsgBlock())
+	hash := block.MsgBlock().BlockHash()
+
+	if err := net.verify(block.MsgBlock(), height); err != nil {
+		net.violation("node %d published invalid block %s at %d: %v", n.index, hash, height, err)
+		return
+	}
+
+	if height < 1 || height > int32(len(net.result.Signed))+1 {
+		net.violation("node %d published block %s at %d past the chain", n.index, hash, height)
+		return
+	}
+	if height <= int32(len(net.result.Signed)) {
+		if net.result.Signed[height-1] != hash {
+			net.violation("node %d published block %s at %d where %s has been signed",
+				n.index, hash, height, net.result.Signed[height-1])
+		}
+		return
+	}
+	net.result.Signed = append(net.result.Signed, hash)
+}
+
+// verify returns an error unless block carries more signature scripts than
+// the signatures required by the committee, and signatures of at least as many
+// members of the committee.
+func (net *network) verify(block *wire.MsgBlock, height int32) error {
+	sigs := block.Transactions[0].SignatureScripts
+	if len(sigs) <= int(net.committee.Sigs) {
+		return fmt.Errorf("%d signature scripts", len(sigs))
+	}
+
+	hash := blockchain.MakeMinerSigHash(height, block.BlockHash())
+	signers := make(map[[20]byte]struct{})
+	for _, sig := range sigs[1:] {
+		signer, err := btcutil.VerifySigScript(sig, hash, net.params)
+		if err != nil {
+			return err
+		}
+		var name [20]byte
+		copy(name[:], signer.ScriptAddress())
+		if i, ok := net.names[name]; !ok || i >= int(net.committee.Size) {
+			return fmt.Errorf("signed by %x who is not in the committee", name)
+		}
+		signers[name] = struct{}{}
+	}
+	if len(signers) < int(net.committee.Sigs) {
+		return fmt.Errorf("signed by %d members", len(signers))
+	}
+	return nil
+}
+
+// reached returns whether all nodes up have reached the height asked for.
+func (net *network) reached() bool {
+	for _, n := range net.nodes {
+		if n.up && n.best.Height < net.cfg.Heights {
+			return false
+		}
+	}
+	return true
+}
+
+// blockHeight returns the height of block, stored in the index of the
+// outpoint of the coinbase.
+func blockHeight(block *wire.MsgBlock) int32 {
+	return int32(block.Transactions[0].TxIn[0].PreviousOutPoint.Index)
+}
@@ -0,0 +1,197 @@
+//go:build simulation
+// +build simulation
+
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package simulator
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/omegasuite/btcd/chaincfg"
+	"github.com/omegasuite/btcd/wire"
+)
+
+var seeds = flag.Int("seeds", 0, "number of seeded scenarios run by TestScenarios, "+
+	"100 by default and 20 in short mode")
+
+// TestDeterminism ensures a run is determined by its config.
+func TestDeterminism(t *testing.T) {
+	cfg := Scenario(7, 2)
+
+	a, b := Run(cfg), Run(cfg)
+	if a.Trace != b.Trace || a.Sent != b.Sent || a.Delivered != b.Delivered {
+		t.Fatalf("runs of the same config differ:\n%v\n%v", a, b)
+	}
+	for i := range a.Signed {
+		if a.Signed[i] != b.Signed[i] {
+			t.Fatalf("runs of the same config signed different blocks at %d", i+1)
+		}
+	}
+
+	cfg.Seed++
+	if c := Run(cfg); c.Trace == a.Trace {
+		t.Errorf("runs of different seeds have the same trace")
+	}
+}
+
+// TestReliableNetwork ensures the committee signs one block after another on
+// a network without faults.
+func TestReliableNetwork(t *testing.T) {
+	r := Run(Config{
+		Seed:       1,
+		Nodes:      int(chaincfg.RegressionNetParams.Committee(minerVersion).Size) + 1,
+		Heights:    10,
+		MinLatency: 10 * time.Millisecond,
+		MaxLatency: 100 * time.Millisecond,
+		MineDelay:  time.Second,
+		Deadline:   10 * time.Minute,
+	})
+	if !r.Safe() || !r.Live() {
+		t.Fatalf("%v", r)
+	}
+	if len(r.Signed) != 10 {
+		t.Errorf("%d blocks signed, want 10", len(r.Signed))
+	}
+	for i, h := range r.Heights {
+		if h != 10 {
+			t.Errorf("node %d is at %d, want 10", i, h)
+		}
+	}
+}
+
+// TestPartition ensures the majority of the committee keeps signing blocks
+// while a member is cut off, and the member catches up when the partition
+// heals.
+func TestPartition(t *testing.T) {
+	r := Run(Config{
+		Seed:       2,
+		Heights:    6,
+		MinLatency: 10 * time.Millisecond,
+		MaxLatency: 200 * time.Millisecond,
+		MineDelay:  time.Second,
+		Deadline:   30 * time.Minute,
+		Partitions: []Partition{{
+			From:   0,
+			To:     5 * time.Minute,
+			Groups: [][]int{{0, 1}, {2}},
+		}},
+	})
+	if !r.Safe() || !r.Live() {
+		t.Fatalf("%v", r)
+	}
+}
+
+// TestIntercept ensures the bus applies the decisions of Intercept.
+func TestIntercept(t *testing.T) {
+	delayed := 0
+	r := Run(Config{
+		Seed:       3,
+		Heights:    2,
+		MinLatency: 10 * time.Millisecond,
+		MaxLatency: 100 * time.Millisecond,
+		Intercept: func(e *Envelope) {
+			// member 0 is slow to talk, and its candidacies are lost
+			if e.From == 0 {
+				e.Delay += 2 * time.Second
+				delayed++
+			}
+			if _, ok := e.Msg.(*wire.MsgCandidate); ok && e.From == 0 {
+				e.Drop = true
+			}
+		},
+	})
+	if !r.Safe() || !r.Live() {
+		t.Fatalf("%v", r)
+	}
+	if delayed == 0 || r.Dropped == 0 {
+		t.Errorf("intercept has not been applied: %v", r)
+	}
+}
+
+// TestScenarios runs seeded scenarios with lost and reordered messages,
+// partitions and crashes, and checks safety and liveness of each. Run it with
+// -seeds to run thousands of them.
+func TestScenarios(t *testing.T) {
+	n := *seeds
+	if n <= 0 {
+		n = 100
+		if testing.Short() {
+			n = 20
+		}
+	}
+
+	for seed := int64(0); seed < int64(n); seed++ {
+		r := Run(Scenario(seed, 3))
+		if !r.Safe() {
+			t.Errorf("unsafe: %v", r)
+		} else if !r.Live() {
+			t.Errorf("not live: %v", r)
+		}
+	}
+}
+
+// TestCrash ensures no two blocks are signed at a height when a member of
+// the committee crashes for good, or while it is down.
+func TestCrash(t *testing.T) {
+	size := int(chaincfg.RegressionNetParams.Committee(minerVersion).Size)
+	for i := 0; i < size; i++ {
+		r := Run(Config{
+			Seed:       int64(i),
+			Heights:    4,
+			MinLatency: 10 * time.Millisecond,
+			MaxLatency: 300 * time.Millisecond,
+			MineDelay:  time.Second,
+			Deadline:   10 * time.Minute,
+			Crashes: []Crash{
+				{Node: i, At: time.Duration(i+2) * time.Second},
+				{Node: (i + 1) % size, At: 20 * time.Second, Restart: 40 * time.Second},
+			},
+		})
+		if !r.Safe() {
+			t.Errorf("%v", r)
+		}
+	}
+}
+
+// TestCommitteeSize ensures a committee of the size given by the parameters of
+// the network signs blocks, with a member partitioned off for a while.
+func TestCommitteeSize(t *testing.T) {
+	params := chaincfg.RegressionNetParams
+	params.Committees = []chaincfg.CommitteeParams{{
+		Size:                5,
+		Sigs:                3,
+		MinerGap:            5,
+		RotateFreq:          chaincfg.DefaultCommittee.RotateFreq,
+		DesirableCandidates: chaincfg.DefaultCommittee.DesirableCandidates,
+	}}
+
+	r := Run(Config{
+		Seed:       5,
+		Heights:    4,
+		MinLatency: 10 * time.Millisecond,
+		MaxLatency: 200 * time.Millisecond,
+		MineDelay:  time.Second,
+		Deadline:   30 * time.Minute,
+		Params:     &params,
+		Partitions: []Partition{{
+			From:   0,
+			To:     2 * time.Minute,
+			Groups: [][]int{{0, 1, 2, 3}, {4}},
+		}},
+	})
+	if !r.Safe() || !r.Live() {
+		t.Fatalf("%v", r)
+	}
+	if len(r.Heights) != 5 {
+		t.Errorf("%d nodes, want 5", len(r.Heights))
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !simulation
+// +build !simulation
+
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package consensus
+
+// start runs the syncer in a goroutine of its own.
+func (self *Syncer) start() {
+	go self.run()
+}
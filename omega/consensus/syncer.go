@@ -13,10 +13,12 @@ import (
 	"fmt"
 	"github.com/omegasuite/btcd/blockchain"
 	"github.com/omegasuite/btcd/btcec"
+	"github.com/omegasuite/btcd/chaincfg"
 	"github.com/omegasuite/btcd/chaincfg/chainhash"
 	"github.com/omegasuite/btcd/wire"
 	"github.com/omegasuite/btcd/wire/common"
 	"github.com/omegasuite/btcutil"
+	"github.com/omegasuite/omega/signer"
 	"github.com/omegasuite/omega/token"
 	"time"
 	"sync"
@@ -59,7 +61,9 @@ type Syncer struct {
 	// a node collected more than 1/2 agrees may annouce the fact by broadcasting the agreements it
 	// collected.
 
-	asked    [wire.CommitteeSize]bool					// those who have asked to be a candidate, and not released
+	rules    *chaincfg.CommitteeParams	// parameters of the committee
+
+	asked    []bool					// those who have asked to be a candidate, and not released
 	agrees   map[int32]struct{}		// those who I have agree to be a candidate
 	agreed   int32			// the one who I have agreed. can not back out until released by
 	sigGiven int32			// who I have given my signature. can never change once given.
@@ -73,10 +77,13 @@ type Syncer struct {
 
 	knowledges *Knowledgebase
 
+	engine *Engine
+
 	commands chan interface{}
 	quit chan struct{}
 
 	Done bool
+	begin time.Time
 
 	Height int32
 
@@ -98,7 +105,7 @@ type Syncer struct {
 
 func (self *Syncer) CommitteeMsgMG(p [20]byte, m wire.Message) {
 	if h, ok := self.Members[p]; ok {
-		miner.server.CommitteeMsgMG(p, h + self.Base, m)
+		self.engine.server.CommitteeMsgMG(p, h + self.Base, m)
 	} else {
 		log.Infof("Msg not sent because %s is not a memnber", p)
 	}
@@ -106,7 +113,7 @@ func (self *Syncer) CommitteeMsgMG(p [20]byte, m wire.Message) {
 
 func (self *Syncer) CommitteeMsg(p [20]byte, m wire.Message) bool {
 	h, ok := self.Members[p]
-	return ok && miner.server.CommitteeMsg(p, h + self.Base, m)
+	return ok && self.engine.server.CommitteeMsg(p, h + self.Base, m)
 }
 
 func (self *Syncer) CommitteeCastMG(msg wire.Message) {
@@ -134,7 +141,7 @@ func (self *Syncer) repeater() {
 		log.Infof("Repeater: exit\n")
 	}()
 
-	miner.server.CommitteePolling()
+	self.engine.server.CommitteePolling()
 
 	if !self.Runnable || self.Done {
 		return
@@ -145,7 +152,7 @@ func (self *Syncer) repeater() {
 	self.repeats++
 	if (self.repeats % 3)  == 0 {
 		// reset connections
-		miner.server.ResetConnections()
+		self.engine.server.ResetConnections()
 		log.Infof("ResetConnections after repeating %s times", self.repeats)
 /*
 		if self.sigGiven == -1 {
@@ -168,10 +175,10 @@ func (self *Syncer) repeater() {
 				}
 			}
 
-			self.knowRevd = make([]int32, wire.CommitteeSize)
-			self.candRevd = make([]int32, wire.CommitteeSize)
-			self.consRevd = make([]int32, wire.CommitteeSize)
-			for i := 0; i < wire.CommitteeSize; i++ {
+			self.knowRevd = make([]int32, self.rules.Size)
+			self.candRevd = make([]int32, self.rules.Size)
+			self.consRevd = make([]int32, self.rules.Size)
+			for i := range self.knowRevd {
 				self.knowRevd[i], self.candRevd[i], self.consRevd[i] = -1, -1, -1
 			}
 			self.knowledges = CreateKnowledge(self)
@@ -180,7 +187,7 @@ func (self *Syncer) repeater() {
 	}
 
 	// enough sigs to conclude?
-	if self.agreed != -1 && len(self.signed) >= wire.CommitteeSigs && !self.Done {
+	if self.agreed != -1 && len(self.signed) >= int(self.rules.Sigs) && !self.Done {
 		self.Done = true
 		close(self.quit)
 		return
@@ -188,14 +195,14 @@ func (self *Syncer) repeater() {
 /*
 	// if peer is disconnected, clear its knowledge falgs
 	for m,i := range self.Members {
-		if i != self.Myself && !miner.server.Connected(m) {
+		if i != self.Myself && !self.engine.server.Connected(m) {
 			if self.sigGiven != i && self.agreed == i {
 				self.agreed = -1
 			}
-			for j := 0; j < wire.CommitteeSize; j++ {
+			for j := int32(0); j < self.rules.Size; j++ {
 				self.knowledges.Knowledge[i][j] = 0
 				self.knowledges.Knowledge[j][i] = 0
-				for k := 0; k < wire.CommitteeSize; k++ {
+				for k := int32(0); k < self.rules.Size; k++ {
 					self.knowledges.Knowledge[j][k] &= ^(0x1 << i)
 				}
 			}
@@ -220,7 +227,7 @@ func (self *Syncer) repeater() {
 			// send it
 			pp := *p
 			//		pp.K = append(pp.K, self.Myself)
-			pp.AddK(self.Myself, miner.server.GetPrivKey(self.Me))
+			pp.AddK(self.Myself, self.messageSigner())
 			to := pp.From
 			pp.From = self.Me
 
@@ -236,13 +243,13 @@ func (self *Syncer) repeater() {
 		}
 	}
 
-	if tree,ok := self.forest[self.Me]; ok && len(self.commands) < (wire.CommitteeSize - 1) * 10 {
+	if tree,ok := self.forest[self.Me]; ok && len(self.commands) < int(self.rules.Size - 1) * 10 {
 		k := wire.NewMsgKnowledge()
 		k.From = self.Me
 		k.Height = self.Height
 		k.Finder = self.Me
 		k.M = tree.hash
-		k.AddK(self.Myself, miner.server.GetPrivKey(self.Me))
+		k.AddK(self.Myself, self.messageSigner())
 
 		self.commands <- k
 
@@ -267,14 +274,14 @@ func (self *Syncer) repeater() {
 		// check if we should agree with someone else
 		best := self.best()
 
-		if best >= 0 && best != self.Myself && self.asked[best] && self.knowledges.Qualified(best) && miner.server.Connected(self.Names[best]) {
+		if best >= 0 && best != self.Myself && self.asked[best] && self.knowledges.Qualified(best) && self.engine.server.Connected(self.Names[best]) {
 			self.agreed = best
 		} else {
 			self.candidacy()
 		}
 	}
 
-	if self.agreed == self.Myself && len(self.agrees) < wire.CommitteeSigs - 1 {
+	if self.agreed == self.Myself && len(self.agrees) < int(self.rules.Sigs) - 1 {
 		for i,b := range self.asked {
 			if !b || int32(i) == self.Myself || !self.better(int32(i), self.Myself) {
 				continue
@@ -299,10 +306,10 @@ func (self *Syncer) repeater() {
 
 		d := wire.MsgCandidateResp{Height: self.Height, K: []int64{}, From: self.Me, M: self.forest[from].hash}
 
-		if self.sigGiven == -1 {
+		if self.sigGiven == -1 && self.engine.consent(self.Me, self.Height, d.M) {
 			d.Reply = "cnst"
 			d.Better = fmp
-			d.Sign(miner.server.GetPrivKey(self.Me))
+			d.Sign(self.messageSigner())
 
 			log.Infof("Repeater: Consent candicacy by %x", from)
 
@@ -316,18 +323,13 @@ func (self *Syncer) repeater() {
 		self.idles = 0
 		if self.sigGiven != -1 {
 			// resend signatures
-			privKey := miner.server.GetPrivKey(self.Me)
-			if privKey == nil {
-				return
-			}
-
 			log.Infof("Repeater: resend signature %d", self.sigGiven)
 
 			from := self.Names[self.agreed]
-			hash := blockchain.MakeMinerSigHash(self.Height, self.forest[from].hash)
-
-			sig, _ := privKey.Sign(hash)
-			s := sig.Serialize()
+			sig := self.signBlock(self.forest[from].hash)
+			if sig == nil {
+				return
+			}
 
 			sigmsg := wire.MsgSignature {
 				For:	   from,
@@ -336,35 +338,35 @@ func (self *Syncer) repeater() {
 				Height:    self.Height,
 				From:      self.Me,
 				M:		   self.forest[from].hash,
-				Signature: make([]byte, btcec.PubKeyBytesLenCompressed + len(s)),
+				Signature: sig,
 			}
 
-			copy(sigmsg.Signature[:], privKey.PubKey().SerializeCompressed())
-			copy(sigmsg.Signature[btcec.PubKeyBytesLenCompressed:], s)
-
 			self.CommitteeCastMG(&sigmsg)
 		} else if self.ckconsensus() {
 			return
 		} else if self.agreed == self.Myself {
 			log.Infof("Repeater: cast my candidacy %d", self.agreed)
 			msg := wire.NewMsgCandidate(self.Height, self.Me, self.forest[self.Me].hash)
-			msg.Sign(miner.server.GetPrivKey(self.Me))
+			msg.Sign(self.messageSigner())
 			self.CommitteeCastMG(msg)
 		} else if self.agreed != -1 {
 			// resend agreement
 			M := self.forest[self.Names[self.agreed]].hash
+			if !self.engine.consent(self.Me, self.Height, M) {
+				return
+			}
 			d := wire.MsgCandidateResp{Height: self.Height, K: []int64{}, From: self.Me, M: M}
 
 			d.Reply = "cnst"
 			d.Better = self.agreed
-			d.Sign(miner.server.GetPrivKey(self.Me))
+			d.Sign(self.messageSigner())
 
 			self.CommitteeMsgMG(self.Names[self.agreed], &d)
 		} else if _,ok := self.forest[self.Me]; ok {
 			// no agreement has reached, volunteer for it
 			log.Infof("Repeater: volunteer for candidacy")
 			msg := wire.NewMsgCandidate(self.Height, self.Me, self.forest[self.Me].hash)
-			msg.Sign(miner.server.GetPrivKey(self.Me))
+			msg.Sign(self.messageSigner())
 			self.CommitteeCastMG(msg)
 		} else {
 			for k, ok := range self.asked {
@@ -397,7 +399,7 @@ func (self *Syncer) Release(msg * wire.MsgRelease) {
 			d := wire.MsgCandidateResp{Height: msg.Height, K: self.makeAbout(msg.Better).K,
 				M:self.makeAbout(msg.Better).M, Better: msg.Better,
 				From: self.Me, Reply:"cnst"}
-			miner.server.CommitteeMsg(msg.Better, &d)
+			self.engine.server.CommitteeMsg(msg.Better, &d)
 		*/
 	}
 }
@@ -406,11 +408,11 @@ type debugtype struct {
 }
 
 func (self *Syncer) run() {
-	miner.wg.Add(1)
-	defer miner.wg.Done()
+	self.engine.wg.Add(1)
+	defer self.engine.wg.Done()
 
 	ticker := time.NewTicker(time.Second * 1)
-	begin := time.Now()
+	self.begin = self.engine.clock.Now()
 
 loop:
 	for {
@@ -422,223 +424,220 @@ loop:
 			break loop
 
 		case cmd := <-self.commands:
-			switch cmd.(type) {
-			case *debugtype:
-				self.debugging()
-
-			case *tree:
-				tree := cmd.(*tree)
-				if self.sigGiven >= 0 {
-					self.forestLock.Unlock()
-					continue
-				}
-				if tree.block != nil {
-					log.Infof("newtree %s at %d width %d txs", tree.hash.String(), self.Height, len(tree.block.MsgBlock().Transactions))
-				} else {
-					log.Infof("newtree %s at %d", tree.hash.String(), self.Height)
-				}
+			if self.handle(cmd) {
+				self.forestLock.Unlock()
+				break loop
+			}
 
-				if !self.validateMsg(tree.creator, nil, nil) {
-					log.Infof("tree creator %x is not a member of committee", tree.creator)
-					self.forestLock.Unlock()
-					continue
-				}
+		case <-ticker.C:
+			for len(ticker.C) > 0 {
+				<-ticker.C
+			}
+			self.repeater()
+		}
+		self.forestLock.Unlock()
+		self.handeling = ""
+	}
 
-				if tree.block != nil &&
-					len(tree.block.MsgBlock().Transactions) > 1 &&
-					len(tree.block.MsgBlock().Transactions[1].TxIn) > 1 &&
-					tree.block.MsgBlock().Transactions[1].TxIn[0].SignatureIndex == 0xFFFFFFFF {
-					log.Errorf("Incorrect tree. I generated dup tree hash at %d", self.Height)
-				}
+	ticker.Stop()
 
-				self.handeling = "New tree"
-				c := self.Members[tree.creator]
-
-				if _, ok := self.forest[tree.creator]; !ok || self.forest[tree.creator].block == nil {
-					// each creator may submit only one tree
-					self.forest[tree.creator] = tree
-					self.repeats = 0
-				} else if (self.forest[tree.creator].hash != chainhash.Hash{}) && tree.hash != self.forest[tree.creator].hash {
-					if self.Me == tree.creator {
-						log.Errorf("Incorrect tree. I generated dup tree hash at %d", self.Height)
-						self.forestLock.Unlock()
-						continue
-					}
-					self.Malice[tree.creator] = struct{}{}
-					delete(self.forest, tree.creator)
-					self.knowledges.Malice(c)
-				}
+	self.finish()
+}
 
-				if tree.block != nil {
-					if _, ok := self.pulltime[c]; ok {
-						delete(self.pulltime, c)
-						delete(self.pulling, c)
-					}
-				}
+// handle handles a command. It returns true when the syncer is done.
+func (self *Syncer) handle(cmd interface{}) bool {
+	switch cmd.(type) {
+	case *debugtype:
+		self.debugging()
 
-				if bytes.Compare(tree.creator[:], self.Me[:]) == 0 {
-					k := wire.NewMsgKnowledge()
-					k.From = self.Me
-					k.Height = self.Height
-					k.Finder = self.Me
-					k.M = tree.hash
-					k.AddK(self.Myself, miner.server.GetPrivKey(self.Me))
-					self.commands <- k
-				}
-				self.print()
+	case *tree:
+		tree := cmd.(*tree)
+		if self.sigGiven >= 0 {
+			return false
+		}
+		if tree.block != nil {
+			log.Infof("newtree %s at %d width %d txs", tree.hash.String(), self.Height, len(tree.block.MsgBlock().Transactions))
+		} else {
+			log.Infof("newtree %s at %d", tree.hash.String(), self.Height)
+		}
 
-			case Message:
-				m := cmd.(Message)
-				self.handeling = m.Command()
+		if !self.validateMsg(tree.creator, nil, nil) {
+			log.Infof("tree creator %x is not a member of committee", tree.creator)
+			return false
+		}
 
-				switch m.(type) {
-				case *wire.MsgKnowledge: // passing knowledge
-					if self.sigGiven >= 0 {
-						self.forestLock.Unlock()
-						continue
-					}
+		if tree.block != nil &&
+			len(tree.block.MsgBlock().Transactions) > 1 &&
+			len(tree.block.MsgBlock().Transactions[1].TxIn) > 1 &&
+			tree.block.MsgBlock().Transactions[1].TxIn[0].SignatureIndex == 0xFFFFFFFF {
+			log.Errorf("Incorrect tree. I generated dup tree hash at %d", self.Height)
+		}
 
-					k := m.(*wire.MsgKnowledge)
+		self.handeling = "New tree"
+		c := self.Members[tree.creator]
 
-					self.knowRevd[self.Members[k.From]] = self.Members[k.From]
+		if _, ok := self.forest[tree.creator]; !ok || self.forest[tree.creator].block == nil {
+			// each creator may submit only one tree
+			self.forest[tree.creator] = tree
+			self.repeats = 0
+		} else if (self.forest[tree.creator].hash != chainhash.Hash{}) && tree.hash != self.forest[tree.creator].hash {
+			if self.Me == tree.creator {
+				log.Errorf("Incorrect tree. I generated dup tree hash at %d", self.Height)
+				return false
+			}
+			self.Malice[tree.creator] = struct{}{}
+			delete(self.forest, tree.creator)
+			self.knowledges.Malice(c)
+		}
 
-					if !self.validateMsg(k.Finder, &k.M, m) {
-						log.Infof("MsgKnowledge invalid")
-						self.forestLock.Unlock()
-						continue
-					}
+		if tree.block != nil {
+			if _, ok := self.pulltime[c]; ok {
+				delete(self.pulltime, c)
+				delete(self.pulling, c)
+			}
+		}
 
-					if _, ok := self.forest[k.Finder]; !ok || self.forest[k.Finder].block == nil {
-						self.pull(k.M, self.Members[k.Finder])
-					}
+		if bytes.Compare(tree.creator[:], self.Me[:]) == 0 {
+			k := wire.NewMsgKnowledge()
+			k.From = self.Me
+			k.Height = self.Height
+			k.Finder = self.Me
+			k.M = tree.hash
+			k.AddK(self.Myself, self.messageSigner())
+			self.commands <- k
+		}
+		self.print()
 
-					if self.knowledges.ProcKnowledge(k) {
-						self.candidacy()
+	case Message:
+		m := cmd.(Message)
+		self.handeling = m.Command()
 
-						if self.knows[k.Finder] == nil {
-							self.knows[k.Finder] = make([]*wire.MsgKnowledge, 0)
-						}
-						self.knows[k.Finder] = append(self.knows[k.Finder], k)
-						self.repeats = 0
-					}
+		switch m.(type) {
+		case *wire.MsgKnowledge: // passing knowledge
+			if self.sigGiven >= 0 {
+				return false
+			}
 
-				case *wire.MsgKnowledgeDone:
-					if self.sigGiven >= 0 {
-						self.forestLock.Unlock()
-						continue
-					}
+			k := m.(*wire.MsgKnowledge)
 
-					k := m.(*wire.MsgKnowledgeDone)
+			self.knowRevd[self.Members[k.From]] = self.Members[k.From]
 
-					if self.knowledges.ProcKnowledgeDone((*wire.MsgKnowledge)(k)) {
-						self.candidacy()
-						self.repeats = 0
-					}
+			if !self.validateMsg(k.Finder, &k.M, m) {
+				log.Infof("MsgKnowledge invalid")
+				return false
+			}
 
-				case *wire.MsgCandidate: // announce candidacy
-					k := m.(*wire.MsgCandidate)
+			if _, ok := self.forest[k.Finder]; !ok || self.forest[k.Finder].block == nil {
+				self.pull(k.M, self.Members[k.Finder])
+			}
 
-					if self.sigGiven >= 0 && self.Names[self.sigGiven] != k.F {
-						log.Infof("MsgCandidate declined. sig already given to %d", self.sigGiven)
-						self.forestLock.Unlock()
-						continue
-					}
+			if self.knowledges.ProcKnowledge(k) {
+				self.candidacy()
 
-					self.candRevd[self.Members[k.F]] = self.Members[k.F]
+				if self.knows[k.Finder] == nil {
+					self.knows[k.Finder] = make([]*wire.MsgKnowledge, 0)
+				}
+				self.knows[k.Finder] = append(self.knows[k.Finder], k)
+				self.repeats = 0
+			}
 
-					if !self.validateMsg(k.F, &k.M, m) {
-						log.Infof("Invalid MsgCandidate message")
-						self.forestLock.Unlock()
-						continue
-					}
+		case *wire.MsgKnowledgeDone:
+			if self.sigGiven >= 0 {
+				return false
+			}
 
-					if _, ok := self.forest[k.F]; !ok || self.forest[k.F].block == nil {
-						self.pull(k.M, self.Members[k.F])
-					} else {
-						self.Candidate(k)
-					}
+			k := m.(*wire.MsgKnowledgeDone)
 
-				case *wire.MsgCandidateResp: // response to candidacy announcement
-					if self.sigGiven >= 0 {
-						self.forestLock.Unlock()
-						continue
-					}
+			if self.knowledges.ProcKnowledgeDone((*wire.MsgKnowledge)(k)) {
+				self.candidacy()
+				self.repeats = 0
+			}
 
-					k := m.(*wire.MsgCandidateResp)
-					if !self.validateMsg(k.From, nil, m) {
-						self.forestLock.Unlock()
-						continue
-					}
+		case *wire.MsgCandidate: // announce candidacy
+			k := m.(*wire.MsgCandidate)
 
-					self.candidateResp(k)
+			if self.sigGiven >= 0 && self.Names[self.sigGiven] != k.F {
+				log.Infof("MsgCandidate declined. sig already given to %d", self.sigGiven)
+				return false
+			}
 
-				case *wire.MsgRelease: // grant a release from duty
-					if self.sigGiven >= 0 {
-						self.forestLock.Unlock()
-						continue
-					}
-					k := m.(*wire.MsgRelease)
-					if !self.validateMsg(k.From, nil, m) {
-						self.forestLock.Unlock()
-						continue
-					}
+			self.candRevd[self.Members[k.F]] = self.Members[k.F]
 
-					self.Release(k)
-					self.repeats = 0
+			if !self.validateMsg(k.F, &k.M, m) {
+				log.Infof("Invalid MsgCandidate message")
+				return false
+			}
 
-				case *wire.MsgConsensus: // announce consensus reached
-					if self.sigGiven >= 0 {
-						self.forestLock.Unlock()
-						continue
-					}
-					k := m.(*wire.MsgConsensus)
+			if _, ok := self.forest[k.F]; !ok || self.forest[k.F].block == nil {
+				self.pull(k.M, self.Members[k.F])
+			} else {
+				self.Candidate(k)
+			}
 
-					if !self.validateMsg(k.From, nil, m) {
-						self.forestLock.Unlock()
-						continue
-					}
+		case *wire.MsgCandidateResp: // response to candidacy announcement
+			if self.sigGiven >= 0 {
+				return false
+			}
 
-					if _, ok := self.forest[k.From]; !ok || self.forest[k.From].block == nil {
-						self.pull(k.M, self.Members[k.From])
-					}
+			k := m.(*wire.MsgCandidateResp)
+			if !self.validateMsg(k.From, nil, m) {
+				return false
+			}
 
-					self.consRevd[self.Members[k.From]] = self.Members[k.From]
-					self.repeats = 0
-					if self.Consensus(k) {
-						self.forestLock.Unlock()
-						break loop
-					}
+			self.candidateResp(k)
 
-				case *wire.MsgSignature: // received signature
-					k := m.(*wire.MsgSignature)
-					self.repeats = 0
-
-					if self.Signature(k) {
-						if len(self.signed) == wire.CommitteeSize || time.Now().Sub(begin) >= time.Second {
-							self.forestLock.Unlock()
-							break loop
-						} else {
-							time.Sleep(500 * time.Millisecond) // wait 500 millisecond to allow all members to sign
-						}
-					}
+		case *wire.MsgRelease: // grant a release from duty
+			if self.sigGiven >= 0 {
+				return false
+			}
+			k := m.(*wire.MsgRelease)
+			if !self.validateMsg(k.From, nil, m) {
+				return false
+			}
 
-				default:
-					log.Infof("unable to handle message type %s at %d", m.Command(), m.Block())
-				}
+			self.Release(k)
+			self.repeats = 0
+
+		case *wire.MsgConsensus: // announce consensus reached
+			if self.sigGiven >= 0 {
+				return false
 			}
+			k := m.(*wire.MsgConsensus)
 
-		case <-ticker.C:
-			for len(ticker.C) > 0 {
-				<-ticker.C
+			if !self.validateMsg(k.From, nil, m) {
+				return false
 			}
-			self.repeater()
+
+			if _, ok := self.forest[k.From]; !ok || self.forest[k.From].block == nil {
+				self.pull(k.M, self.Members[k.From])
+			}
+
+			self.consRevd[self.Members[k.From]] = self.Members[k.From]
+			self.repeats = 0
+			if self.Consensus(k) {
+				return true
+			}
+
+		case *wire.MsgSignature: // received signature
+			k := m.(*wire.MsgSignature)
+			self.repeats = 0
+
+			if self.Signature(k) {
+				if len(self.signed) == int(self.rules.Size) || self.engine.clock.Now().Sub(self.begin) >= time.Second {
+					return true
+				} else {
+					self.engine.clock.Sleep(500 * time.Millisecond) // wait 500 millisecond to allow all members to sign
+				}
+			}
+
+		default:
+			log.Infof("unable to handle message type %s at %d", m.Command(), m.Block())
 		}
-		self.forestLock.Unlock()
-		self.handeling = ""
 	}
+	return false
+}
 
-	ticker.Stop()
-
+func (self *Syncer) finish() {
 	for true {
 		select {		// drain all msgs
 		case m := <- self.commands:
@@ -651,8 +650,8 @@ loop:
 			if self.sigGiven != -1 {
 				owner := self.Names[self.sigGiven]
 				if self.Runnable && self.forest[owner] != nil && self.forest[owner].block != nil &&
-					len(self.forest[owner].block.MsgBlock().Transactions[0].SignatureScripts) > wire.CommitteeSigs {
-					miner.server.NewConsusBlock(self.forest[owner].block)
+					len(self.forest[owner].block.MsgBlock().Transactions[0].SignatureScripts) > int(self.rules.Sigs) {
+					self.engine.server.NewConsusBlock(self.forest[owner].block)
 				}
 			}
 
@@ -666,8 +665,10 @@ loop:
 	}
 }
 
-func Sender(msg Message) []byte {
-	if msg == nil || miner == nil {
+// Sender returns the name of the miner that signed msg, or nil when it can not
+// be verified.
+func (m *Engine) Sender(msg Message) []byte {
+	if msg == nil || m == nil {
 		return nil
 	}
 	switch msg.(type) {
@@ -682,7 +683,7 @@ func Sender(msg Message) []byte {
 		for j,i := range msg.(*wire.MsgKnowledge).K {
 			sig := msg.(*wire.MsgKnowledge).Signatures[j]
 
-			signer, err := btcutil.VerifySigScript(sig, tmsg.DoubleHashB(), miner.cfg)
+			signer, err := btcutil.VerifySigScript(sig, tmsg.DoubleHashB(), m.cfg)
 			if err != nil {
 				log.Infof("MsgKnowledge VerifySigScript fail")
 				return nil
@@ -698,7 +699,7 @@ func Sender(msg Message) []byte {
  */
 
 	case *wire.MsgCandidate, *wire.MsgCandidateResp, *wire.MsgRelease:
-		signer, err := btcutil.VerifySigScript(msg.GetSignature(), msg.DoubleHashB(), miner.cfg)
+		signer, err := btcutil.VerifySigScript(msg.GetSignature(), msg.DoubleHashB(), m.cfg)
 		if err != nil {
 			log.Infof("%s VerifySigScript fail", msg.Command())
 			return nil
@@ -710,7 +711,7 @@ func Sender(msg Message) []byte {
 		if err != nil {
 			return nil
 		}
-		pk, _ := btcutil.NewAddressPubKeyPubKey(*k, miner.cfg)
+		pk, _ := btcutil.NewAddressPubKeyPubKey(*k, m.cfg)
 		pk.SetFormat(btcutil.PKFCompressed)
 		return pk.ScriptAddress()
 
@@ -719,7 +720,7 @@ func Sender(msg Message) []byte {
 		if err != nil {
 			return nil
 		}
-		pk, _ := btcutil.NewAddressPubKeyPubKey(*k, miner.cfg)
+		pk, _ := btcutil.NewAddressPubKeyPubKey(*k, m.cfg)
 		pk.SetFormat(btcutil.PKFCompressed)
 		return pk.ScriptAddress()
 	}
@@ -735,7 +736,7 @@ func (self *Syncer) Signature(msg * wire.MsgSignature) bool {
 	}
 
 	if _, ok := self.signed[msg.From]; ok {
-		return len(self.signed) >= wire.CommitteeSigs
+		return len(self.signed) >= int(self.rules.Sigs)
 	}
 
 	tree := int32(-1)
@@ -769,16 +770,16 @@ func (self *Syncer) Signature(msg * wire.MsgSignature) bool {
 		return false
 	}
 
+	if !self.engine.sign(self.Me, self.Height, self.forest[owner].hash) {	// never sign if another block has been signed at the height
+		return false
+	}
+
 	if self.sigGiven == -1 {	// len(self.forest[owner].block.MsgBlock().Transactions[0].SignatureScripts[1]) <= 20 {
 		// remove the sig 1 that contained the miner's name
 		self.forest[owner].block.MsgBlock().Transactions[0].SignatureScripts =
 			self.forest[owner].block.MsgBlock().Transactions[0].SignatureScripts[:1]
 	}
 
-	if !UpdateLastWritten(self.Height) && self.sigGiven != tree {	// nenver sign if height is not higher than last signed block
-		return false
-	}
-
 	self.sigGiven = tree
 
 	self.forest[owner].block.MsgBlock().Transactions[0].SignatureScripts = append(
@@ -786,7 +787,7 @@ func (self *Syncer) Signature(msg * wire.MsgSignature) bool {
 		msg.Signature[:])
 	self.signed[msg.From] = struct{}{}
 
-	return len(self.signed) >= wire.CommitteeSigs
+	return len(self.signed) >= int(self.rules.Sigs)
 }
 
 func (self *Syncer) Consensus(msg * wire.MsgConsensus) bool {
@@ -811,14 +812,11 @@ func (self *Syncer) Consensus(msg * wire.MsgConsensus) bool {
 		return false
 	}
 
-	privKey := miner.server.GetPrivKey(self.Me)
-	if privKey == nil {
+	sig := self.signBlock(self.forest[msg.From].hash)	// never sign if another block has been signed at the height
+	if sig == nil {
 		return false
 	}
 
-	sig, _ := privKey.Sign(hash)
-	sgs := sig.Serialize()
-
 	sigmsg := wire.MsgSignature {
 		For:	   msg.From,
 	}
@@ -826,20 +824,14 @@ func (self *Syncer) Consensus(msg * wire.MsgConsensus) bool {
 		Height:    self.Height,
 		From:      self.Me,
 		M:		   msg.M,
-		Signature: make([]byte, btcec.PubKeyBytesLenCompressed + len(sgs)),
+		Signature: sig,
 	}
 
-	copy(sigmsg.Signature[:], privKey.PubKey().SerializeCompressed())
-	copy(sigmsg.Signature[btcec.PubKeyBytesLenCompressed:], sgs)
-
 //	log.Infof("Consensus: cast signature")
 
 	self.CommitteeCastMG(&sigmsg)
 
 	if self.sigGiven == -1 {
-		if !UpdateLastWritten(self.Height) && self.sigGiven != self.agreed {	// nenver sign if height is not higher than last signed block
-			return false
-		}
 		self.sigGiven = self.agreed
 		if self.forest[msg.From].block != nil {
 			// remove the sig 1 that contained the miner's name
@@ -861,17 +853,17 @@ func (self *Syncer) Consensus(msg * wire.MsgConsensus) bool {
 			msg.Signature[:])
 		self.signed[msg.From] = struct{}{}
 
-		if len(self.forest[msg.From].block.MsgBlock().Transactions[0].SignatureScripts) > wire.CommitteeSigs {
+		if len(self.forest[msg.From].block.MsgBlock().Transactions[0].SignatureScripts) > int(self.rules.Sigs) {
 			return true
 //			log.Info("passing NewConsusBlock & quit")
-//			miner.server.NewConsusBlock(self.forest[msg.From].block)
+//			self.engine.server.NewConsusBlock(self.forest[msg.From].block)
 		}
 	}
 	return false
 }
 
 func (self *Syncer) reckconsensus() {
-	if self.agreed != self.Myself || self.sigGiven != self.Myself || len(self.agrees) + 1 < wire.CommitteeSigs {
+	if self.agreed != self.Myself || self.sigGiven != self.Myself || len(self.agrees) + 1 < int(self.rules.Sigs) {
 		return
 	}
 
@@ -883,21 +875,14 @@ func (self *Syncer) reckconsensus() {
 		return
 	}
 
-	hash := blockchain.MakeMinerSigHash(self.Height, self.forest[self.Me].hash)
-
-	if privKey := miner.server.GetPrivKey(self.Me); privKey != nil && self.sigGiven == self.Myself {
-		sig, _ := privKey.Sign(hash)
-		ss := sig.Serialize()
+	if sig := self.signBlock(self.forest[self.Me].hash); sig != nil {
 		msg := wire.MsgConsensus{
 			Height:    self.Height,
 			From:      self.Me,
 			M:		   self.forest[self.Me].hash,
-			Signature: make([]byte, btcec.PubKeyBytesLenCompressed + len(ss)),
+			Signature: sig,
 		}
 
-		copy(msg.Signature[:], privKey.PubKey().SerializeCompressed())
-		copy(msg.Signature[btcec.PubKeyBytesLenCompressed:], ss)
-
 		//		log.Infof("reckconsensus: cast Consensus")
 
 		self.CommitteeCastMG(&msg)
@@ -905,7 +890,7 @@ func (self *Syncer) reckconsensus() {
 }
 
 func (self *Syncer) ckconsensus() bool {
-	if self.agreed != self.Myself || len(self.agrees) + 1 < wire.CommitteeSigs {
+	if self.agreed != self.Myself || len(self.agrees) + 1 < int(self.rules.Sigs) {
 		return false
 	}
 
@@ -913,26 +898,21 @@ func (self *Syncer) ckconsensus() bool {
 		return false
 	}
 
-	hash := blockchain.MakeMinerSigHash(self.Height, self.forest[self.Me].hash)
+	if self.sigGiven != -1 {
+		return false
+	}
 
-	if privKey := miner.server.GetPrivKey(self.Me); privKey != nil && self.sigGiven == -1 {
-		if !UpdateLastWritten(self.Height) && self.sigGiven != self.Myself {	// nenver sign if height is not higher than last signed block
-			return false
-		}
+	// never sign if another block has been signed at the height
+	if sig := self.signBlock(self.forest[self.Me].hash); sig != nil {
 		self.sigGiven = self.Myself
 
-		sig, _ := privKey.Sign(hash)
-		ss := sig.Serialize()
 		msg := wire.MsgConsensus{
 			Height:    self.Height,
 			From:      self.Me,
 			M:		   self.forest[self.Me].hash,
-			Signature: make([]byte, btcec.PubKeyBytesLenCompressed + len(ss)),
+			Signature: sig,
 		}
 
-		copy(msg.Signature[:], privKey.PubKey().SerializeCompressed())
-		copy(msg.Signature[btcec.PubKeyBytesLenCompressed:], ss)
-
 		self.forest[self.Me].block.MsgBlock().Transactions[0].SignatureScripts =
 			self.forest[self.Me].block.MsgBlock().Transactions[0].SignatureScripts[:1]
 
@@ -951,6 +931,28 @@ func (self *Syncer) ckconsensus() bool {
 	return false
 }
 
+// messageSigner returns the signer of the committee messages of the syncer.
+func (self *Syncer) messageSigner() wire.MessageSigner {
+	return signer.ForMessages(self.engine.server.GetSigner(self.Me))
+}
+
+// signBlock journals that the block hash is signed at the height of the syncer
+// and signs it. It returns the signature preceded by the public key, or nil
+// if the block may not be signed.
+func (self *Syncer) signBlock(hash chainhash.Hash) []byte {
+	s := self.engine.server.GetSigner(self.Me)
+	if s == nil || !self.engine.sign(self.Me, self.Height, hash) {
+		return nil
+	}
+
+	sig, err := signer.Sign(s, signer.BlockRequest(self.Height, hash))
+	if err != nil {
+		log.Warnf("Unable to sign block %s: %v", hash.String(), err)
+		return nil
+	}
+	return sig
+}
+
 func (self *Syncer) makeRelease(better int32) *wire.MsgRelease {
 	var h chainhash.Hash
 	if better != -1 {
@@ -962,7 +964,7 @@ func (self *Syncer) makeRelease(better int32) *wire.MsgRelease {
 		Height: self.Height,
 		From:   self.Me,
 	}
-	d.Sign(miner.server.GetPrivKey(self.Me))
+	d.Sign(self.messageSigner())
 	return d
 }
 
@@ -979,7 +981,7 @@ func (self *Syncer) dupKnowledge(fmp int32) {
 			}
 
 			t := *ks
-			t.AddK(self.Myself, miner.server.GetPrivKey(self.Me))
+			t.AddK(self.Myself, self.messageSigner())
 
 			if ng,_ := self.knowledges.gain(self.agreed, t.K); ng {
 				if self.CommitteeMsg(self.Names[fmp], &t) {
@@ -1002,19 +1004,20 @@ func (self *Syncer) yield(better int32) bool {
 		rls := self.makeRelease(better)
 		for r, _ := range self.agrees {
 			if r != self.Myself {
-				rls.Sign(miner.server.GetPrivKey(self.Me))
+				rls.Sign(self.messageSigner())
 				self.CommitteeMsgMG(self.Names[r], rls)
 			}
 		}
 		self.agrees = make(map[int32]struct{})
 		self.agreed = -1
-		if self.asked[better] && better != self.Myself {
+		if self.asked[better] && better != self.Myself &&
+			self.engine.consent(self.Me, self.Height, self.forest[self.Names[better]].hash) {
 			// give a consent to Better
 			d := wire.MsgCandidateResp{Height: self.Height, K: []int64{}, From: self.Me}
 			d.Reply = "cnst"
 			d.Better = better
 			d.M = self.forest[self.Names[better]].hash
-			d.Sign(miner.server.GetPrivKey(self.Me))
+			d.Sign(self.messageSigner())
 
 //			log.Infof("yield: yield to %x", self.Names[better])
 
@@ -1066,7 +1069,7 @@ func (self *Syncer) candidateResp(msg *wire.MsgCandidateResp) {
 //				self.dupKnowledge(self.Members[msg.From])
 				if self.agreed == self.Myself {
 					msg := wire.NewMsgCandidate(self.Height, self.Me, self.forest[self.Me].hash)
-					msg.Sign(miner.server.GetPrivKey(self.Me))
+					msg.Sign(self.messageSigner())
 
 //					log.Infof("candidateResp: reaffirm candidacy")
 
@@ -1088,7 +1091,7 @@ func (self *Syncer) candidateResp(msg *wire.MsgCandidateResp) {
 
 				delete(self.asked, self.Me)
 				for r, _ := range self.agrees {
-					miner.server.CommitteeMsg(r, self.makeRelease(msg.Better))
+					self.engine.server.CommitteeMsg(r, self.makeRelease(msg.Better))
 				}
 
 				self.agreed = msg.Better
@@ -1097,7 +1100,7 @@ func (self *Syncer) candidateResp(msg *wire.MsgCandidateResp) {
 					M: self.makeAbout(msg.Better).M,
 					Better: msg.Better,
 					From: self.Me, Reply:"cnst"}
-				miner.server.CommitteeMsg(msg.Better, &d)
+				self.engine.server.CommitteeMsg(msg.Better, &d)
 
 				self.agrees = make(map[int32]struct{})
 				return
@@ -1115,7 +1118,7 @@ func (self *Syncer) candidacy() {
 
 	better := self.Myself
 
-	for i := 0; i < wire.CommitteeSize; i++ {
+	for i := range self.asked {
 		if self.asked[int32(i)] && self.better(int32(i), better) && self.knowledges.Qualified(int32(i)) {
 			// there is a better candidate
 			// someone else is the best, send him knowledge about him that he does not know
@@ -1138,7 +1141,7 @@ func (self *Syncer) candidacy() {
 			continue
 		}
 		if d,ok := self.pulltime[self.Members[m]]; ok {
-			if time.Now().Unix() < 30 + d {
+			if self.engine.clock.Now().Unix() < 30 + d {
 				ready = false
 			}
 		} else {
@@ -1161,7 +1164,7 @@ func (self *Syncer) candidacy() {
 
 	self.asked[self.Myself] = true
 
-	msg.Sign(miner.server.GetPrivKey(self.Me))
+	msg.Sign(self.messageSigner())
 
 //	log.Infof("candidacy: Announce candicacy")
 
@@ -1182,7 +1185,7 @@ func (self *Syncer) Candidate(msg *wire.MsgCandidate) {
 	if self.sigGiven != -1 && self.sigGiven != fmp {
 		d.Reply = "rjct"
 		d.Better = -1
-		d.Sign(miner.server.GetPrivKey(self.Me))
+		d.Sign(self.messageSigner())
 
 //		log.Infof("Candidate: Reject candicacy by %x", self.Names[fmp])
 
@@ -1205,7 +1208,7 @@ func (self *Syncer) Candidate(msg *wire.MsgCandidate) {
 /*
 		d.Reply = "rjct"
 		d.Better = -2
-		d.Sign(miner.server.GetPrivKey(self.Me))
+		d.Sign(self.messageSigner())
 
 		log.Infof("Candidate: Reject candicacy by %x", self.Names[fmp])
 
@@ -1221,11 +1224,20 @@ func (self *Syncer) Candidate(msg *wire.MsgCandidate) {
 	}
 
 	if self.agreed == -1 || self.agreed == fmp {
+		if !self.engine.consent(self.Me, self.Height, msg.M) {
+			// reject because signed another block at the height
+			d.Reply = "rjct"
+			d.Better = -1
+			d.Sign(self.messageSigner())
+			self.CommitteeMsgMG(self.Names[fmp], &d)
+			return
+		}
+
 //		log.Infof("consent given by %x to %d", self.Me, fmp)
 		d.Reply = "cnst"
 		d.Better = fmp
 		self.agreed = fmp
-		d.Sign(miner.server.GetPrivKey(self.Me))
+		d.Sign(self.messageSigner())
 
 //		log.Infof("Candidate: Consent candicacy by %x", self.Names[fmp])
 
@@ -1246,16 +1258,17 @@ func (self *Syncer) Candidate(msg *wire.MsgCandidate) {
 	//		}
 	d.Better = self.agreed
 	d.M = self.forest[self.Names[self.agreed]].hash
-	d.Sign(miner.server.GetPrivKey(self.Me))
+	d.Sign(self.messageSigner())
 
 //	log.Infof("Candidate: Reject candicacy by %x", self.Names[fmp])
 
 	self.CommitteeMsgMG(self.Names[fmp], &d)
 }
 
-func CreateSyncer(h int32) *Syncer {
+func (m *Engine) CreateSyncer(h int32) *Syncer {
 	p := Syncer{}
 
+	p.engine = m
 	p.commands = make(chan interface{}, 100)
 	p.quit = make(chan struct{})
 	p.Height = h
@@ -1279,23 +1292,43 @@ func CreateSyncer(h int32) *Syncer {
 	p.handeling = ""
 //	p.mutex = sync.Mutex{}
 
-//	p.consents = make(map[[20]byte]int32, wire.CommitteeSize)
-	p.forest = make(map[[20]byte]*tree, wire.CommitteeSize)
+	// the size is provisional until the committee is set when the syncer
+	// becomes runnable. no message is handled before that
+	rules, err := m.committee(int32(m.server.BestSnapshot().LastRotation))
+	if err != nil {
+		rules = m.cfg.Committee(0)
+	}
+	p.sizeCommittee(rules)
+
+//	p.consents = make(map[[20]byte]int32, p.rules.Size)
+	p.forest = make(map[[20]byte]*tree, p.rules.Size)
 
 	p.Runnable = false
-//	p.Me = miner.name
+//	p.Me = self.engine.name
 
 //	p.SetCommittee()
-	p.knowRevd = make([]int32, wire.CommitteeSize)
-	p.candRevd = make([]int32, wire.CommitteeSize)
-	p.consRevd = make([]int32, wire.CommitteeSize)
-	for i := 0; i < wire.CommitteeSize; i++ {
-		p.knowRevd[i], p.candRevd[i], p.consRevd[i] = -1, -1, -1
-	}
 
 	return &p
 }
 
+// sizeCommittee sets the parameters of the committee of the syncer to rules
+// and sizes what it keeps for each member to the committee.
+func (self *Syncer) sizeCommittee(rules *chaincfg.CommitteeParams) {
+	self.rules = rules
+	if len(self.asked) == int(rules.Size) {
+		return
+	}
+
+	self.asked = make([]bool, rules.Size)
+
+	self.knowRevd = make([]int32, rules.Size)
+	self.candRevd = make([]int32, rules.Size)
+	self.consRevd = make([]int32, rules.Size)
+	for i := range self.knowRevd {
+		self.knowRevd[i], self.candRevd[i], self.consRevd[i] = -1, -1, -1
+	}
+}
+
 func (self *Syncer) validateMsg(finder [20]byte, m * chainhash.Hash, msg Message) bool {
 	if !self.Runnable || self.Done {
 		if !self.Runnable {
@@ -1330,7 +1363,7 @@ func (self *Syncer) validateMsg(finder [20]byte, m * chainhash.Hash, msg Message
 		for j,i := range msg.(*wire.MsgKnowledge).K {
 			sig := msg.(*wire.MsgKnowledge).Signatures[j]
 
-			signer, err := btcutil.VerifySigScript(sig, tmsg.DoubleHashB(), miner.cfg)
+			signer, err := btcutil.VerifySigScript(sig, tmsg.DoubleHashB(), self.engine.cfg)
 			if err != nil {
 				log.Infof("MsgKnowledge VerifySigScript fail")
 				return false
@@ -1348,7 +1381,7 @@ func (self *Syncer) validateMsg(finder [20]byte, m * chainhash.Hash, msg Message
 		}
 
 	case *wire.MsgCandidate, *wire.MsgCandidateResp, *wire.MsgRelease:
-		signer, err := btcutil.VerifySigScript(msg.GetSignature(), msg.DoubleHashB(), miner.cfg)
+		signer, err := btcutil.VerifySigScript(msg.GetSignature(), msg.DoubleHashB(), self.engine.cfg)
 		if err != nil {
 			log.Infof("%s VerifySigScript fail", msg.Command())
 			return false
@@ -1391,7 +1424,7 @@ func (self *Syncer) setCommittee() {
 		return
 	}
 
-	best := miner.server.BestSnapshot()
+	best := self.engine.server.BestSnapshot()
 	self.Runnable = self.Height == best.Height + 1
 
 	if !self.Runnable {
@@ -1401,23 +1434,32 @@ func (self *Syncer) setCommittee() {
 
 	c := int32(best.LastRotation)
 
+	rules, err := self.engine.committee(c)
+	if err != nil {
+		// try again when the miner block is connected
+		log.Infof("committee of syncer %d is not known: %s", self.Height, err.Error())
+		self.Runnable = false
+		return
+	}
+	self.sizeCommittee(rules)
+
 	self.Committee = c
-	self.Base = c - wire.CommitteeSize + 1
+	self.Base = c - self.rules.Size + 1
 
 	in := false
 
-	for i := c - wire.CommitteeSize + 1; i <= c; i++ {
-		blk,_ := miner.server.MinerBlockByHeight(i)
+	for i := self.Base; i <= c; i++ {
+		blk,_ := self.engine.server.MinerBlockByHeight(i)
 		if blk == nil {
 			continue
 		}
 
-		who := i - (c - wire.CommitteeSize + 1)
+		who := i - self.Base
 
-		for _,n := range miner.name {
+		for _,n := range self.engine.name {
 			if bytes.Compare(n[:], blk.MsgBlock().Miner[:]) == 0 {
 				inc := false
-				for _, ip := range miner.cfg.ExternalIPs {
+				for _, ip := range self.engine.cfg.ExternalIPs {
 					if ip == string(blk.MsgBlock().Connection) {
 						inc = true
 					}
@@ -1440,7 +1482,7 @@ func (self *Syncer) setCommittee() {
 
 	if in {
 		log.Infof("Run consensus protocol at %d", self.Height)
-		go self.run()
+		self.start()
 	} else {
 		self.Runnable = false
 	}
@@ -1468,7 +1510,7 @@ func (self *Syncer) BlockInit(block *btcutil.Block) {
 		log.Errorf("block does not contain enough signatures. %d", len(block.MsgBlock().Transactions[0].SignatureScripts))
 		return
 	}
-	if len(block.MsgBlock().Transactions[0].SignatureScripts) > wire.CommitteeSigs {
+	if len(block.MsgBlock().Transactions[0].SignatureScripts) > int(self.rules.Sigs) {
 		log.Infof("it is a consensus block. Skip it.")
 		return
 	}
@@ -1493,7 +1535,7 @@ func (self *Syncer) BlockInit(block *btcutil.Block) {
 		}
 	}
 
-	if len(block.MsgBlock().Transactions[0].TxOut) < wire.CommitteeSigs {
+	if len(block.MsgBlock().Transactions[0].TxOut) < int(self.rules.Sigs) {
 		return
 	}
 
@@ -1511,8 +1553,8 @@ func (self *Syncer) BlockInit(block *btcutil.Block) {
 	}
 	self.forestLock.Unlock()
 
-	if miner.server.BestSnapshot().Hash != block.MsgBlock().Header.PrevBlock {
-		miner.server.ChainSync(block.MsgBlock().Header.PrevBlock, adr)
+	if self.engine.server.BestSnapshot().Hash != block.MsgBlock().Header.PrevBlock {
+		self.engine.server.ChainSync(block.MsgBlock().Header.PrevBlock, adr)
 	}
 }
 
@@ -1525,7 +1567,7 @@ func (self *Syncer) pull(hash chainhash.Hash, from int32) {
 		if self.CommitteeMsg(self.Names[from], &msg) {
 //			log.Infof("Pull request sent to %d", from)
 			self.pulling[from] = 5
-			self.pulltime[from] = time.Now().Unix()
+			self.pulltime[from] = self.engine.clock.Now().Unix()
 		} else {
 			log.Infof("Fail to Pull !!!!!!!!")
 		}
@@ -1560,7 +1602,7 @@ func (self *Syncer) print() {
 	candRevd := "Candidacy anouncement received from: "
 	consRevd := "Consensus anouncement received from: "
 
-	for i := 0; i < wire.CommitteeSize; i++ {
+	for i := range self.knowRevd {
 		knowRevd += fmt.Sprintf("%d ", self.knowRevd[i])
 		candRevd += fmt.Sprintf("%d ", self.candRevd[i])
 		consRevd += fmt.Sprintf("%d ", self.consRevd[i])
@@ -1575,7 +1617,7 @@ func (self *Syncer) print() {
 }
 
 func (self *Syncer) DebugInfo() {
-	if !self.Done && len(self.commands) < (wire.CommitteeSize - 1) * 10 {
+	if !self.Done && len(self.commands) < int(self.rules.Size - 1) * 10 {
 		self.commands <- &debugtype{}
 	}
 }
@@ -346,6 +346,14 @@ const (
 	// serialized in a compressed format.
 	ErrWitnessPubKeyType
 
+	// -------------------------------------
+	// Failures related to monitored rights.
+	// -------------------------------------
+
+	// ErrMonitorRejected is returned if the monitor contract of a monitored
+	// right carried by an input does not approve the transaction.
+	ErrMonitorRejected
+
 	// numErrorCodes is the maximum error code number used in tests.  This
 	// entry MUST be the last entry in the enum.
 	numErrorCodes
@@ -417,6 +425,7 @@ var errorCodeStrings = map[ErrorCode]string{
 	ErrMinimalIf:                          "ErrMinimalIf",
 	ErrWitnessPubKeyType:                  "ErrWitnessPubKeyType",
 	ErrDiscourageUpgradableWitnessProgram: "ErrDiscourageUpgradableWitnessProgram",
+	ErrMonitorRejected:                    "ErrMonitorRejected",
 }
 
 // String returns the ErrorCode as a human-readable name.
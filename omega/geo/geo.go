@@ -0,0 +1,248 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+// Package geo converts polygons between the GIS interchange formats GeoJSON
+// and WKT and the border and polygon definitions of the chain.
+//
+// Coordinates in the interchange formats are WGS84 degrees, longitude first.
+// They are converted to the fixed point coordinates of vertices through
+// token.CoordPrecision.
+package geo
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/omega/token"
+	"github.com/omegasuite/omega/viewpoint"
+)
+
+// Position is a point as longitude and latitude in degrees.
+type Position [2]float64
+
+// Ring is a closed line. The last position may repeat the first one.
+type Ring []Position
+
+// Polygon is an outer ring followed by the rings of its holes.
+type Polygon []Ring
+
+// MultiPolygon is a set of polygons.
+type MultiPolygon []Polygon
+
+// vertex converts p to a vertex.
+func vertex(p Position) (token.VertexDef, error) {
+	var v token.VertexDef
+	if p[0] < -180 || p[0] > 180 || p[1] < -90 || p[1] > 90 {
+		return v, fmt.Errorf("position %v is out of range", p)
+	}
+	v.SetLat(int32(p[1] * token.CoordPrecision))
+	v.SetLng(int32(p[0] * token.CoordPrecision))
+	return v, nil
+}
+
+// position converts v to a position.
+func position(v token.VertexDef) Position {
+	return Position{float64(v.Lng()) / token.CoordPrecision,
+		float64(v.Lat()) / token.CoordPrecision}
+}
+
+// ringVertices returns the distinct vertices of ring in order, without the
+// closing one.
+func ringVertices(ring Ring) ([]token.VertexDef, error) {
+	vertices := make([]token.VertexDef, 0, len(ring))
+	for _, p := range ring {
+		v, err := vertex(p)
+		if err != nil {
+			return nil, err
+		}
+		if n := len(vertices); n > 0 && vertices[n-1].IsEqual(&v) {
+			continue
+		}
+		vertices = append(vertices, v)
+	}
+	if n := len(vertices); n > 1 && vertices[0].IsEqual(&vertices[n-1]) {
+		vertices = vertices[:n-1]
+	}
+	if len(vertices) < 3 {
+		return nil, fmt.Errorf("ring has less than 3 distinct vertices")
+	}
+	return vertices, nil
+}
+
+// ccw returns whether the vertices are in counter clockwise order.
+func ccw(vertices []token.VertexDef) bool {
+	area := big.NewInt(0)
+	tmp := big.NewInt(0)
+	p := vertices[len(vertices)-1]
+	for _, q := range vertices {
+		tmp.SetInt64(int64(p.Lng())*int64(q.Lat()) - int64(q.Lng())*int64(p.Lat()))
+		area.Add(area, tmp)
+		p = q
+	}
+	return area.Sign() > 0
+}
+
+// borderExists returns whether border hash is defined in the chain.
+func borderExists(views *viewpoint.ViewPointSet, hash chainhash.Hash) bool {
+	if views == nil {
+		return false
+	}
+	e, err := views.FetchBorderEntry(&hash)
+	return err == nil && e != nil
+}
+
+// borderRef returns the reference to the border from v to w. It is the border
+// or its reverse if either is defined in the chain, and otherwise the new
+// border, which is also returned to be defined.
+func borderRef(views *viewpoint.ViewPointSet, v, w token.VertexDef) (chainhash.Hash, *token.BorderDef) {
+	border := token.NewBorderDef(v, w, chainhash.Hash{})
+	h := border.Hash()
+	if borderExists(views, h) {
+		return h, nil
+	}
+	r := token.NewBorderDef(w, v, chainhash.Hash{}).Hash()
+	if borderExists(views, r) {
+		r[0] |= 1
+		return r, nil
+	}
+	return h, border
+}
+
+// Definitions returns the definitions of a polygon with the shape of mp: the
+// top level borders that are neither defined in the chain nor repeated within
+// mp, followed by the polygon itself. The first ring of each polygon of mp is
+// made counter clockwise and the other ones, its holes, clockwise, as a polygon
+// token requires.
+//
+// A border already defined in the chain, in either direction, is referenced
+// instead of being defined again. views may be nil to define all borders.
+func Definitions(mp MultiPolygon, views *viewpoint.ViewPointSet) ([]token.Definition, *token.PolygonDef, error) {
+	if len(mp) == 0 {
+		return nil, nil, fmt.Errorf("no polygon")
+	}
+
+	defs := make([]token.Definition, 0)
+	refs := make(map[chainhash.Hash]chainhash.Hash)
+	plg := &token.PolygonDef{Loops: make([]token.LoopDef, 0)}
+
+	for _, p := range mp {
+		for i, ring := range p {
+			vertices, err := ringVertices(ring)
+			if err != nil {
+				return nil, nil, err
+			}
+			if ccw(vertices) != (i == 0) {
+				for j, k := 0, len(vertices)-1; j < k; j, k = j+1, k-1 {
+					vertices[j], vertices[k] = vertices[k], vertices[j]
+				}
+			}
+
+			loop := make(token.LoopDef, 0, len(vertices))
+			for j, v := range vertices {
+				w := vertices[(j+1)%len(vertices)]
+				h := token.NewBorderDef(v, w, chainhash.Hash{}).Hash()
+				if ref, ok := refs[h]; ok {
+					loop = append(loop, ref)
+					continue
+				}
+
+				ref, border := borderRef(views, v, w)
+				if border != nil {
+					defs = append(defs, border)
+				}
+				loop = append(loop, ref)
+				r := token.NewBorderDef(w, v, chainhash.Hash{}).Hash()
+
+				// the border may be met again in either direction
+				refs[h] = ref
+				ref[0] ^= 1
+				refs[r] = ref
+			}
+			plg.Loops = append(plg.Loops, loop)
+		}
+	}
+
+	defs = append(defs, plg)
+	return defs, plg, nil
+}
+
+// borderVertices appends to vertices the vertices of border hash in the
+// direction of the border reference, each but the last one, expanding the
+// borders made of child borders.
+func borderVertices(views *viewpoint.ViewPointSet, hash chainhash.Hash, vertices []token.VertexDef) ([]token.VertexDef, error) {
+	rev := hash[0]&1 == 1
+	hash[0] &= 0xFE
+
+	b, err := views.FetchBorderEntry(&hash)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, fmt.Errorf("border %s does not exist", hash.String())
+	}
+
+	if len(b.Children) == 0 {
+		if rev {
+			return append(vertices, b.End), nil
+		}
+		return append(vertices, b.Begin), nil
+	}
+
+	for i := range b.Children {
+		c := b.Children[i]
+		if rev {
+			c = b.Children[len(b.Children)-1-i]
+			c[0] ^= 1
+		}
+		if vertices, err = borderVertices(views, c, vertices); err != nil {
+			return nil, err
+		}
+	}
+	return vertices, nil
+}
+
+// Export returns the shape of the polygon defined in the chain. Its loops are
+// expanded down to the borders without children. A counter clockwise loop
+// starts a new polygon of the result and a clockwise loop is a hole of the
+// last polygon. Rings are closed.
+func Export(views *viewpoint.ViewPointSet, polygon *chainhash.Hash) (MultiPolygon, error) {
+	plg, err := views.FetchPolygonEntry(polygon)
+	if err != nil {
+		return nil, err
+	}
+	if plg == nil {
+		return nil, fmt.Errorf("polygon %s does not exist", polygon.String())
+	}
+
+	mp := make(MultiPolygon, 0)
+	for _, loop := range views.Flattern(plg.Loops) {
+		if len(loop) == 0 {
+			continue
+		}
+		vertices := make([]token.VertexDef, 0, len(loop))
+		for _, b := range loop {
+			if vertices, err = borderVertices(views, b, vertices); err != nil {
+				return nil, err
+			}
+		}
+
+		ring := make(Ring, 0, len(vertices)+1)
+		for _, v := range vertices {
+			ring = append(ring, position(v))
+		}
+		ring = append(ring, ring[0])
+
+		if len(mp) == 0 || ccw(vertices) {
+			mp = append(mp, Polygon{ring})
+		} else {
+			mp[len(mp)-1] = append(mp[len(mp)-1], ring)
+		}
+	}
+	return mp, nil
+}
@@ -0,0 +1,149 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package geo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/omegasuite/omega/token"
+)
+
+// square is a square with a hole, the outer ring clockwise and the hole
+// counter clockwise, i.e. both in the wrong direction.
+var square = MultiPolygon{{
+	{{0, 0}, {0, 1}, {1, 1}, {1, 0}, {0, 0}},
+	{{0.25, 0.25}, {0.75, 0.25}, {0.75, 0.75}, {0.25, 0.75}, {0.25, 0.25}},
+}}
+
+// TestParse ensures GeoJSON and WKT texts of the same shape parse alike and
+// that formatting them back gives the same shape.
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		wkt  bool
+		want MultiPolygon
+	}{
+		{"geojson polygon", `{"type":"Polygon","coordinates":[` +
+			`[[0,0],[0,1],[1,1],[1,0],[0,0]],` +
+			`[[0.25,0.25],[0.75,0.25],[0.75,0.75],[0.25,0.75],[0.25,0.25]]]}`,
+			false, square},
+		{"geojson feature", `{"type":"Feature","properties":{},"geometry":` +
+			`{"type":"MultiPolygon","coordinates":[[[[0,0,5],[0,1,5],[1,1,5],[1,0,5],[0,0,5]]]]}}`,
+			false, MultiPolygon{{{{0, 0}, {0, 1}, {1, 1}, {1, 0}, {0, 0}}}}},
+		{"wkt polygon", "POLYGON ((0 0, 0 1, 1 1, 1 0, 0 0), " +
+			"(0.25 0.25, 0.75 0.25, 0.75 0.75, 0.25 0.75, 0.25 0.25))",
+			true, square},
+		{"wkt multipolygon", "MULTIPOLYGON Z (((0 0 5, 0 1 5, 1 1 5, 1 0 5, 0 0 5)))",
+			true, MultiPolygon{{{{0, 0}, {0, 1}, {1, 1}, {1, 0}, {0, 0}}}}},
+	}
+
+	for _, test := range tests {
+		var mp MultiPolygon
+		var err error
+		if test.wkt {
+			mp, err = ParseWKT(test.text)
+		} else {
+			mp, err = ParseGeoJSON([]byte(test.text))
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(mp, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, mp, test.want)
+			continue
+		}
+
+		data, err := MarshalGeoJSON(mp)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if back, err := ParseGeoJSON(data); err != nil || !reflect.DeepEqual(back, mp) {
+			t.Errorf("%s: GeoJSON round trip gives %v, %v", test.name, back, err)
+		}
+		if back, err := ParseWKT(FormatWKT(mp)); err != nil || !reflect.DeepEqual(back, mp) {
+			t.Errorf("%s: WKT round trip gives %v, %v", test.name, back, err)
+		}
+	}
+
+	for _, bad := range []string{"POINT (1 2)", "POLYGON EMPTY", "POLYGON ((0 0, 1 1)",
+		"POLYGON ((0 0, 1))", "POLYGON ((0 0, 0 1, 1 1)) x"} {
+		if _, err := ParseWKT(bad); err == nil {
+			t.Errorf("%q: parsed", bad)
+		}
+	}
+}
+
+// TestDefinitions ensures the definitions of a polygon have a border for each
+// edge, loops in the direction a polygon token requires and a single border
+// for an edge shared by two polygons.
+func TestDefinitions(t *testing.T) {
+	defs, plg, err := Definitions(square, nil)
+	if err != nil {
+		t.Fatalf("Definitions: %v", err)
+	}
+	if len(defs) != 9 || defs[8] != token.Definition(plg) {
+		t.Fatalf("got %d definitions, want 8 borders and the polygon", len(defs))
+	}
+	if len(plg.Loops) != 2 {
+		t.Fatalf("got %d loops, want 2", len(plg.Loops))
+	}
+
+	// the outer loop must have been made counter clockwise and the hole
+	// clockwise.
+	for i, want := range []bool{true, false} {
+		vertices := make([]token.VertexDef, 4)
+		for j := range vertices {
+			vertices[j] = defs[i*4+j].(*token.BorderDef).Begin
+		}
+		if ccw(vertices) != want {
+			t.Errorf("loop %d is in the wrong direction", i)
+		}
+	}
+	for i, loop := range plg.Loops {
+		for j, h := range loop {
+			if want := defs[i*4+j].Hash(); !h.IsEqual(&want) {
+				t.Errorf("loop %d border %d does not reference its definition", i, j)
+			}
+		}
+	}
+
+	// two squares sharing the edge x = 1 define it once, the second
+	// square referencing it reversed.
+	pair := MultiPolygon{
+		{{{0, 0}, {1, 0}, {1, 1}, {0, 1}}},
+		{{{1, 0}, {2, 0}, {2, 1}, {1, 1}}},
+	}
+	defs, plg, err = Definitions(pair, nil)
+	if err != nil {
+		t.Fatalf("Definitions: %v", err)
+	}
+	if len(defs) != 8 {
+		t.Fatalf("got %d definitions, want 7 borders and the polygon", len(defs))
+	}
+	shared := plg.Loops[0][1]
+	reversed := plg.Loops[1][3]
+	if reversed[0]&1 != 1 {
+		t.Errorf("shared border is not referenced reversed")
+	}
+	reversed[0] &^= 1
+	if !reversed.IsEqual(&shared) {
+		t.Errorf("shared border is defined twice")
+	}
+
+	if _, _, err := Definitions(MultiPolygon{{{{0, 0}, {1, 1}, {0, 0}}}}, nil); err == nil {
+		t.Errorf("degenerate ring accepted")
+	}
+	if _, _, err := Definitions(MultiPolygon{{{{0, 0}, {200, 0}, {0, 1}}}}, nil); err == nil {
+		t.Errorf("out of range position accepted")
+	}
+}
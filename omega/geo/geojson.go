@@ -0,0 +1,111 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// geometry is a GeoJSON geometry object, or a feature object with a geometry.
+type geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates,omitempty"`
+	Geometry    *geometry       `json:"geometry,omitempty"`
+}
+
+// positions decodes GeoJSON positions, dropping the altitudes.
+func positions(coords [][]float64) (Ring, error) {
+	ring := make(Ring, len(coords))
+	for i, c := range coords {
+		if len(c) < 2 {
+			return nil, fmt.Errorf("position has less than 2 coordinates")
+		}
+		ring[i] = Position{c[0], c[1]}
+	}
+	return ring, nil
+}
+
+func polygonCoords(coords [][][]float64) (Polygon, error) {
+	if len(coords) == 0 {
+		return nil, fmt.Errorf("polygon has no ring")
+	}
+	p := make(Polygon, len(coords))
+	for i, c := range coords {
+		ring, err := positions(c)
+		if err != nil {
+			return nil, err
+		}
+		p[i] = ring
+	}
+	return p, nil
+}
+
+// ParseGeoJSON parses a GeoJSON Polygon or MultiPolygon geometry, or a feature
+// with such a geometry.
+func ParseGeoJSON(data []byte) (MultiPolygon, error) {
+	var g geometry
+	if err := json.Unmarshal(data, &g); err != nil {
+		return nil, err
+	}
+	if g.Type == "Feature" {
+		if g.Geometry == nil {
+			return nil, fmt.Errorf("feature has no geometry")
+		}
+		g = *g.Geometry
+	}
+
+	switch g.Type {
+	case "Polygon":
+		var coords [][][]float64
+		if err := json.Unmarshal(g.Coordinates, &coords); err != nil {
+			return nil, err
+		}
+		p, err := polygonCoords(coords)
+		if err != nil {
+			return nil, err
+		}
+		return MultiPolygon{p}, nil
+
+	case "MultiPolygon":
+		var coords [][][][]float64
+		if err := json.Unmarshal(g.Coordinates, &coords); err != nil {
+			return nil, err
+		}
+		if len(coords) == 0 {
+			return nil, fmt.Errorf("multipolygon has no polygon")
+		}
+		mp := make(MultiPolygon, len(coords))
+		for i, c := range coords {
+			p, err := polygonCoords(c)
+			if err != nil {
+				return nil, err
+			}
+			mp[i] = p
+		}
+		return mp, nil
+	}
+
+	return nil, fmt.Errorf("unsupported geometry type %q", g.Type)
+}
+
+// MarshalGeoJSON returns the GeoJSON geometry of mp, a Polygon if it has
+// a single polygon and a MultiPolygon otherwise.
+func MarshalGeoJSON(mp MultiPolygon) ([]byte, error) {
+	if len(mp) == 1 {
+		return json.Marshal(struct {
+			Type        string  `json:"type"`
+			Coordinates Polygon `json:"coordinates"`
+		}{"Polygon", mp[0]})
+	}
+	return json.Marshal(struct {
+		Type        string       `json:"type"`
+		Coordinates MultiPolygon `json:"coordinates"`
+	}{"MultiPolygon", mp})
+}
@@ -0,0 +1,362 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package geo
+
+import (
+	"math"
+	"sort"
+
+	"github.com/omegasuite/omega/token"
+)
+
+const (
+	// SemiMajorAxis is the equatorial radius in meters of the WGS84
+	// ellipsoid. Areas and lengths are measured on it.
+	SemiMajorAxis = 6378137.0
+
+	// Flattening is the flattening of the WGS84 ellipsoid.
+	Flattening = 1 / 298.257223563
+)
+
+var (
+	// e2 and ecc are the squared eccentricity and the eccentricity of the
+	// ellipsoid.
+	e2  = Flattening * (2 - Flattening)
+	ecc = math.Sqrt(e2)
+)
+
+// Nodes and weights of the 10 point Gauss-Legendre quadrature on [-1, 1].
+// The nodes are symmetric, so only the positive ones are listed.
+var (
+	gaussNodes   = [5]float64{0.1488743389816312, 0.4333953941292472, 0.6794095682990244, 0.8650633666889845, 0.9739065285171717}
+	gaussWeights = [5]float64{0.2955242247147529, 0.2692667193099963, 0.2190863625159820, 0.1494513491505806, 0.0666713443086881}
+)
+
+// edgeMean returns the mean of f over the edge from p to q, as a function of
+// the latitude and of the direction of the edge, the edge being straight in
+// latitude and longitude. Each pair of nodes symmetric about the midpoint is
+// added first, so that the mean is the same for both directions of an edge.
+func edgeMean(p, q fpoint, f func(lat, dlat, dlng float64) float64) float64 {
+	lat1, lat2 := radians(p.y), radians(q.y)
+	dlat, dlng := lat2-lat1, radians(q.x-p.x)
+	m, h := (lat1+lat2)/2, dlat/2
+
+	sum := 0.0
+	for i, x := range gaussNodes {
+		sum += gaussWeights[i] * (f(m+x*h, dlat, dlng) + f(m-x*h, dlat, dlng))
+	}
+	return sum / 2
+}
+
+// point is a vertex in the fixed point coordinates of the chain, x being
+// the longitude and y the latitude.
+type point struct {
+	x, y int64
+}
+
+// fpoint is a point between vertices, such as the crossing of two edges.
+type fpoint struct {
+	x, y float64
+}
+
+func (p point) sub(q point) point {
+	return point{p.x - q.x, p.y - q.y}
+}
+
+// cross and dot products of coordinate differences fit in int64 as the
+// coordinates are less than 2^30 in magnitude.
+func cross(p, q point) int64 {
+	return p.x*q.y - p.y*q.x
+}
+
+func dot(p, q point) int64 {
+	return p.x*q.x + p.y*q.y
+}
+
+// shape is a set of rings defining a region by the even-odd rule. Each ring
+// is oriented with the region on the left of its edges.
+type shape [][]point
+
+// newShape returns the shape of mp.
+func newShape(mp MultiPolygon) (shape, error) {
+	s := make(shape, 0)
+	vs := make([][]token.VertexDef, 0)
+	for _, p := range mp {
+		for _, ring := range p {
+			vertices, err := ringVertices(ring)
+			if err != nil {
+				return nil, err
+			}
+			vs = append(vs, vertices)
+			r := make([]point, len(vertices))
+			for i, v := range vertices {
+				r[i] = point{int64(v.Lng()), int64(v.Lat())}
+			}
+			s = append(s, r)
+		}
+	}
+
+	// a ring inside an even number of other rings bounds the region from
+	// outside and must be counter clockwise, a ring inside an odd number of
+	// them bounds a hole and must be clockwise.
+	for i, ring := range s {
+		m := s.testPoint(i)
+		depth := 0
+		for j := range s {
+			if j != i && s[j:j+1].inside(m) {
+				depth++
+			}
+		}
+		if ccw(vs[i]) != (depth%2 == 0) {
+			for j, k := 0, len(ring)-1; j < k; j, k = j+1, k-1 {
+				ring[j], ring[k] = ring[k], ring[j]
+			}
+		}
+	}
+	return s, nil
+}
+
+// testPoint returns the midpoint of an edge of ring i that is not on any
+// other ring, if there is one.
+func (s shape) testPoint(i int) fpoint {
+	ring := s[i]
+	for j, a := range ring {
+		b := ring[(j+1)%len(ring)]
+		m := point{a.x + b.x, a.y + b.y} // doubled coordinates
+		on := false
+		for k, other := range s {
+			if k == i {
+				continue
+			}
+			c := other[len(other)-1]
+			for _, d := range other {
+				c2, d2 := point{2 * c.x, 2 * c.y}, point{2 * d.x, 2 * d.y}
+				if cross(d2.sub(c2), m.sub(c2)) == 0 && dot(m.sub(c2), m.sub(d2)) <= 0 {
+					on = true
+				}
+				c = d
+			}
+		}
+		if !on {
+			return fpoint{float64(m.x) / 2, float64(m.y) / 2}
+		}
+	}
+	a, b := ring[0], ring[1]
+	return fpoint{float64(a.x+b.x) / 2, float64(a.y+b.y) / 2}
+}
+
+// inside returns whether p, which must not be on an edge, is in the region of
+// s.
+func (s shape) inside(p fpoint) bool {
+	in := false
+	for _, ring := range s {
+		a := ring[len(ring)-1]
+		for _, b := range ring {
+			ay, by := float64(a.y), float64(b.y)
+			if (ay > p.y) != (by > p.y) {
+				x := float64(a.x) + (p.y-ay)*float64(b.x-a.x)/(by-ay)
+				if x > p.x {
+					in = !in
+				}
+			}
+			a = b
+		}
+	}
+	return in
+}
+
+// Positions of a piece of edge relative to a shape.
+const (
+	outside = iota
+	inside
+	sameBoundary     // on an edge of the same direction
+	oppositeBoundary // on an edge of the opposite direction
+)
+
+// pieces splits the edges of s where they meet the edges of t and calls f
+// with each piece and its position relative to t.
+func pieces(s, t shape, f func(p, q fpoint, where int)) {
+	for _, ring := range s {
+		a := ring[len(ring)-1]
+		for _, b := range ring {
+			r := b.sub(a)
+			ts := []float64{0, 1}
+			collinear := make([][2]point, 0)
+
+			for _, other := range t {
+				c := other[len(other)-1]
+				for _, d := range other {
+					q, w := d.sub(c), c.sub(a)
+					den := cross(r, q)
+					if den == 0 {
+						if cross(w, r) == 0 {
+							collinear = append(collinear, [2]point{c, d})
+							rr := float64(dot(r, r))
+							for _, e := range []point{w, d.sub(a)} {
+								if u := float64(dot(e, r)) / rr; u > 0 && u < 1 {
+									ts = append(ts, u)
+								}
+							}
+						}
+						c = d
+						continue
+					}
+					tn, un := cross(w, q), cross(w, r)
+					if den < 0 {
+						den, tn, un = -den, -tn, -un
+					}
+					if tn >= 0 && tn <= den && un >= 0 && un <= den {
+						ts = append(ts, float64(tn)/float64(den))
+					}
+					c = d
+				}
+			}
+
+			sort.Float64s(ts)
+			at := func(u float64) fpoint {
+				return fpoint{float64(a.x) + u*float64(r.x), float64(a.y) + u*float64(r.y)}
+			}
+			for i := 1; i < len(ts); i++ {
+				if ts[i] <= ts[i-1] {
+					continue
+				}
+				m := at((ts[i-1] + ts[i]) / 2)
+				where := outside
+				for _, e := range collinear {
+					q := e[1].sub(e[0])
+					u := ((m.x-float64(e[0].x))*float64(q.x) + (m.y-float64(e[0].y))*float64(q.y)) /
+						float64(dot(q, q))
+					if u > 0 && u < 1 {
+						where = oppositeBoundary
+						if dot(r, q) > 0 {
+							where = sameBoundary
+						}
+						break
+					}
+				}
+				if where == outside && t.inside(m) {
+					where = inside
+				}
+				f(at(ts[i-1]), at(ts[i]), where)
+			}
+			a = b
+		}
+	}
+}
+
+// radians converts a fixed point coordinate to radians.
+func radians(c float64) float64 {
+	return c / token.CoordPrecision * math.Pi / 180
+}
+
+// authalic returns the integral from the equator to lat of the area element
+// of the ellipsoid, (1 - e2) cos(lat) / (1 - e2 sin(lat)^2)^2 dlat, in units of
+// the squared semi-major axis.
+func authalic(lat float64) float64 {
+	s := math.Sin(lat)
+	return (1 - e2) / 2 * (s/(1-e2*s*s) + math.Atanh(ecc*s)/ecc)
+}
+
+// edgeArea returns the contribution of the edge from p to q to the area, on
+// the ellipsoid, of the region on its left, in units of the squared
+// semi-major axis. The edge is straight in latitude and longitude, and the
+// contributions are those of Green's theorem applied to the area element of
+// the ellipsoid, so that they sum to the area over any set of closed rings.
+// The integral along the edge is exact for edges along a parallel and
+// accurate to the precision of float64 otherwise.
+func edgeArea(p, q fpoint) float64 {
+	return edgeMean(p, q, func(lat, dlat, dlng float64) float64 {
+		return -dlng * authalic(lat)
+	})
+}
+
+// edgeLength returns the length on the ellipsoid of the edge from p to q,
+// straight in latitude and longitude, in units of the semi-major axis. The
+// relative error of the quadrature is below 1e-10, a fraction of a millimeter
+// over the longest edges.
+func edgeLength(p, q fpoint) float64 {
+	return edgeMean(p, q, func(lat, dlat, dlng float64) float64 {
+		s := math.Sin(lat)
+		w := 1 - e2*s*s
+		meridian := (1 - e2) / (w * math.Sqrt(w)) * dlat // radius of curvature of the meridian
+		parallel := math.Cos(lat) / math.Sqrt(w) * dlng  // radius of the parallel
+		return math.Hypot(meridian, parallel)
+	})
+}
+
+// Measure returns the area in square meters and the perimeter in meters of
+// mp on the WGS84 ellipsoid. The region is bounded by edges straight in
+// latitude and longitude, as the chain checks geometry, rather than by
+// geodesics between the vertices. The result depends only on the fixed point
+// coordinates of the vertices, so every node computes the same values for a
+// polygon.
+func Measure(mp MultiPolygon) (float64, float64, error) {
+	s, err := newShape(mp)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	area, perimeter := 0.0, 0.0
+	for _, ring := range s {
+		a := ring[len(ring)-1]
+		for _, b := range ring {
+			p, q := fpoint{float64(a.x), float64(a.y)}, fpoint{float64(b.x), float64(b.y)}
+			area += edgeArea(p, q)
+			perimeter += edgeLength(p, q)
+			a = b
+		}
+	}
+	return area * SemiMajorAxis * SemiMajorAxis, perimeter * SemiMajorAxis, nil
+}
+
+// Overlap returns the area in square meters of the intersection of a and b,
+// measured as Measure does, whether a contains b and whether b contains a.
+// Shared boundaries do not make an intersection, so two adjacent polygons
+// overlap with an area of 0.
+func Overlap(a, b MultiPolygon) (float64, bool, bool, error) {
+	sa, err := newShape(a)
+	if err != nil {
+		return 0, false, false, err
+	}
+	sb, err := newShape(b)
+	if err != nil {
+		return 0, false, false, err
+	}
+
+	// the boundary of the intersection is made of the pieces of edges of
+	// either shape inside the other one, plus those of the edges the shapes
+	// share in the same direction, which are counted once.
+	area := 0.0
+	aContainsB, bContainsA := true, true
+	pieces(sa, sb, func(p, q fpoint, where int) {
+		if where == inside || where == sameBoundary {
+			area += edgeArea(p, q)
+		}
+		if where == inside {
+			aContainsB = false
+		}
+		if where == outside || where == oppositeBoundary {
+			bContainsA = false
+		}
+	})
+	pieces(sb, sa, func(p, q fpoint, where int) {
+		if where == inside {
+			area += edgeArea(p, q)
+			bContainsA = false
+		}
+		if where == outside || where == oppositeBoundary {
+			aContainsB = false
+		}
+	})
+
+	if area < 0 {
+		area = 0
+	}
+	return area * SemiMajorAxis * SemiMajorAxis, aContainsB, bContainsA, nil
+}
@@ -0,0 +1,158 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package geo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/omegasuite/omega/token"
+)
+
+// box returns a rectangle in degrees.
+func box(west, south, east, north float64) Polygon {
+	return Polygon{{{west, south}, {east, south}, {east, north}, {west, north}}}
+}
+
+// ellipsoidBox returns the area in square meters of a rectangle in degrees on
+// the WGS84 ellipsoid, by the authalic latitude function q.
+func ellipsoidBox(west, south, east, north float64) float64 {
+	rad := math.Pi / 180
+	q := func(lat float64) float64 {
+		s := math.Sin(lat * rad)
+		return (1 - e2) * (s/(1-e2*s*s) - math.Log((1-ecc*s)/(1+ecc*s))/(2*ecc))
+	}
+	return SemiMajorAxis * SemiMajorAxis * (east - west) * rad * (q(north) - q(south)) / 2
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-6*math.Max(1, math.Abs(b))
+}
+
+// TestMeasure ensures areas and perimeters are those on the WGS84 ellipsoid
+// regardless of the direction of the rings.
+func TestMeasure(t *testing.T) {
+	area, _, err := Measure(square)
+	if err != nil {
+		t.Fatalf("Measure: %v", err)
+	}
+	want := ellipsoidBox(0, 0, 1, 1) - ellipsoidBox(0.25, 0.25, 0.75, 0.75)
+	if !near(area, want) {
+		t.Errorf("square area %f, want %f", area, want)
+	}
+
+	area, _, err = Measure(MultiPolygon{box(10, 40, 12, 41)})
+	if err != nil {
+		t.Fatalf("Measure: %v", err)
+	}
+	if want := ellipsoidBox(10, 40, 12, 41); !near(area, want) {
+		t.Errorf("box area %f, want %f", area, want)
+	}
+
+	// the surface area of the WGS84 ellipsoid is 510065621.7241 km2
+	area, perimeter, err := Measure(MultiPolygon{box(-180, -90, 180, 90)})
+	if err != nil {
+		t.Fatalf("Measure: %v", err)
+	}
+	if math.Abs(area-510065621724100) > 1e6 {
+		t.Errorf("ellipsoid area %f, want 510065621724100", area)
+	}
+
+	// twice the meridian from pole to pole, whose quarter is 10001965.729
+	// m. The edges along the poles have no length.
+	want = 4 * 10001965.729
+	if math.Abs(perimeter-want) > 0.01 {
+		t.Errorf("ellipsoid perimeter %f, want %f", perimeter, want)
+	}
+
+	// a degree of longitude along the equator and a degree of latitude from
+	// the equator
+	_, perimeter, err = Measure(MultiPolygon{box(0, 0, 1, 1)})
+	if err != nil {
+		t.Fatalf("Measure: %v", err)
+	}
+	rad := math.Pi / 180
+	s := math.Sin(rad)
+	top := SemiMajorAxis * math.Cos(rad) / math.Sqrt(1-e2*s*s) * rad
+	want = SemiMajorAxis*rad + top + 2*110574.389
+	if math.Abs(perimeter-want) > 0.01 {
+		t.Errorf("box perimeter %f, want %f", perimeter, want)
+	}
+}
+
+// TestEdgeSums ensures the area and the length of a slanted edge are the sums
+// of those of its pieces, and are the opposite for the reverse edge.
+func TestEdgeSums(t *testing.T) {
+	c := float64(token.CoordPrecision)
+	p, q := fpoint{-30 * c, -60 * c}, fpoint{100 * c, 80 * c}
+
+	const n = 1000
+	area, length := 0.0, 0.0
+	a := p
+	for i := 1; i <= n; i++ {
+		b := fpoint{p.x + (q.x-p.x)*float64(i)/n, p.y + (q.y-p.y)*float64(i)/n}
+		area += edgeArea(a, b)
+		length += edgeLength(a, b)
+		a = b
+	}
+
+	if math.Abs(area-edgeArea(p, q)) > 1e-12 {
+		t.Errorf("edge area %g, sum of pieces %g", edgeArea(p, q), area)
+	}
+	if math.Abs(length-edgeLength(p, q)) > 1e-10*length {
+		t.Errorf("edge length %g, sum of pieces %g", edgeLength(p, q), length)
+	}
+	if edgeArea(q, p) != -edgeArea(p, q) || edgeLength(q, p) != edgeLength(p, q) {
+		t.Error("reverse edge does not measure the opposite")
+	}
+}
+
+// TestOverlap ensures intersection areas and containment of overlapping,
+// adjacent, nested and identical polygons.
+func TestOverlap(t *testing.T) {
+	tests := []struct {
+		name       string
+		a, b       MultiPolygon
+		area       float64
+		aContainsB bool
+		bContainsA bool
+	}{
+		{"overlapping", MultiPolygon{box(0, 0, 2, 2)}, MultiPolygon{box(1, 1, 3, 3)},
+			ellipsoidBox(1, 1, 2, 2), false, false},
+		{"adjacent", MultiPolygon{box(0, 0, 1, 1)}, MultiPolygon{box(1, 0, 2, 1)},
+			0, false, false},
+		{"disjoint", MultiPolygon{box(0, 0, 1, 1)}, MultiPolygon{box(5, 5, 6, 6)},
+			0, false, false},
+		{"nested", MultiPolygon{box(0, 0, 4, 4)}, MultiPolygon{box(1, 1, 2, 2)},
+			ellipsoidBox(1, 1, 2, 2), true, false},
+		{"nested on boundary", MultiPolygon{box(0, 0, 4, 4)}, MultiPolygon{box(0, 0, 2, 2)},
+			ellipsoidBox(0, 0, 2, 2), true, false},
+		{"identical", MultiPolygon{box(0, 0, 1, 1)}, MultiPolygon{box(0, 0, 1, 1)},
+			ellipsoidBox(0, 0, 1, 1), true, true},
+		{"in the hole", square, MultiPolygon{box(0.25, 0.25, 0.75, 0.75)},
+			0, false, false},
+		{"over the hole", square, MultiPolygon{box(0.125, 0.125, 0.875, 0.875)},
+			ellipsoidBox(0.125, 0.125, 0.875, 0.875) - ellipsoidBox(0.25, 0.25, 0.75, 0.75), false, false},
+	}
+
+	for _, test := range tests {
+		area, ab, ba, err := Overlap(test.a, test.b)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !near(area, test.area) {
+			t.Errorf("%s: area %f, want %f", test.name, area, test.area)
+		}
+		if ab != test.aContainsB || ba != test.bContainsA {
+			t.Errorf("%s: containment %v, %v, want %v, %v", test.name,
+				ab, ba, test.aContainsB, test.bContainsA)
+		}
+	}
+}
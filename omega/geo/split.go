@@ -0,0 +1,493 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package geo
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/database"
+	"github.com/omegasuite/btcd/wire"
+	"github.com/omegasuite/btcutil"
+	"github.com/omegasuite/omega/token"
+	"github.com/omegasuite/omega/validate"
+	"github.com/omegasuite/omega/viewpoint"
+)
+
+// snapDistance is how far, in fixed point units, an end of a cut may be from
+// the boundary of the polygon it cuts. It allows for the rounding of positions
+// in degrees to vertices.
+const snapDistance = 3
+
+// piece is a border reference in the direction of a loop, with the vertices it
+// goes between in that direction.
+type piece struct {
+	ref        chainhash.Hash
+	begin, end token.VertexDef
+}
+
+// leaf is a piece of a border without children.
+type leaf struct {
+	piece
+	entry *viewpoint.BorderEntry
+}
+
+// fetchBorder returns the entry of the border referenced by ref.
+func fetchBorder(views *viewpoint.ViewPointSet, ref chainhash.Hash) (*viewpoint.BorderEntry, error) {
+	ref[0] &^= 1
+	e, err := views.FetchBorderEntry(&ref)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, fmt.Errorf("border %s does not exist", ref.String())
+	}
+	return e, nil
+}
+
+// newPiece returns the piece of border e referenced by ref.
+func newPiece(ref chainhash.Hash, e *viewpoint.BorderEntry) piece {
+	if ref[0]&1 == 1 {
+		return piece{ref, e.End, e.Begin}
+	}
+	return piece{ref, e.Begin, e.End}
+}
+
+// expandLeaves appends to leaves the borders without children that border ref
+// is made of, in the direction of the reference.
+func expandLeaves(views *viewpoint.ViewPointSet, ref chainhash.Hash, leaves []leaf) ([]leaf, error) {
+	e, err := fetchBorder(views, ref)
+	if err != nil {
+		return nil, err
+	}
+	if len(e.Children) == 0 {
+		return append(leaves, leaf{newPiece(ref, e), e}), nil
+	}
+
+	for _, c := range viewpoint.ReorderChildren(e.Children, ref[0]&1 == 1) {
+		if leaves, err = expandLeaves(views, c, leaves); err != nil {
+			return nil, err
+		}
+	}
+	return leaves, nil
+}
+
+// loopLeaves returns the leaves of loop.
+func loopLeaves(views *viewpoint.ViewPointSet, loop []chainhash.Hash) ([]leaf, error) {
+	leaves := make([]leaf, 0, len(loop))
+	for _, ref := range loop {
+		var err error
+		if leaves, err = expandLeaves(views, ref, leaves); err != nil {
+			return nil, err
+		}
+	}
+	return leaves, nil
+}
+
+// ringOf returns the ring of points of leaves.
+func ringOf(leaves []leaf) []point {
+	ring := make([]point, len(leaves))
+	for i, l := range leaves {
+		ring[i] = point{int64(l.begin.Lng()), int64(l.begin.Lat())}
+	}
+	return ring
+}
+
+// verticesOf returns the vertices the leaves begin at.
+func verticesOf(leaves []leaf) []token.VertexDef {
+	vertices := make([]token.VertexDef, len(leaves))
+	for i, l := range leaves {
+		vertices[i] = l.begin
+	}
+	return vertices
+}
+
+// polygonToken is the polygon token of an output to spend.
+type polygonToken struct {
+	outpoint wire.OutPoint
+	rights   *chainhash.Hash
+	pkScript []byte
+	loops    []token.LoopDef
+}
+
+// fetchPolygonToken returns the unspent polygon token of outpoint with the
+// loops of its polygon flattened to loops of borders.
+func fetchPolygonToken(views *viewpoint.ViewPointSet, outpoint wire.OutPoint) (*polygonToken, error) {
+	if err := views.Utxo.FetchUtxosMain(views.Db, map[wire.OutPoint]struct{}{outpoint: {}}); err != nil {
+		return nil, err
+	}
+	entry := views.Utxo.LookupEntry(outpoint)
+	if entry == nil || entry.IsSpent() {
+		return nil, fmt.Errorf("output %s does not exist or is spent", outpoint.String())
+	}
+	out := entry.ToTxOut()
+	if out.TokenType != 3 {
+		return nil, fmt.Errorf("output %s is not a polygon token", outpoint.String())
+	}
+
+	hash := out.Value.(*token.HashToken).Hash
+	plg, err := views.FetchPolygonEntry(&hash)
+	if err != nil {
+		return nil, err
+	}
+	if plg == nil {
+		return nil, fmt.Errorf("polygon %s does not exist", hash.String())
+	}
+
+	loops := make([]token.LoopDef, 0, len(plg.Loops))
+	for _, loop := range views.Flattern(plg.Loops) {
+		if len(loop) > 0 {
+			loops = append(loops, loop)
+		}
+	}
+	if len(loops) == 0 {
+		return nil, fmt.Errorf("polygon %s has no loop", hash.String())
+	}
+
+	return &polygonToken{
+		outpoint: outpoint,
+		rights:   out.Rights,
+		pkScript: out.PkScript,
+		loops:    loops,
+	}, nil
+}
+
+func sameRights(a, b *chainhash.Hash) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.IsEqual(b)
+}
+
+// polygonTx returns a transaction spending the polygon tokens ins and paying
+// tokens of polygons outs with the rights and to the owner of the first
+// token spent. defs are the definitions the polygons need. Polygons already
+// defined in the chain are not defined again. The transaction is checked
+// under the rules of block version.
+func polygonTx(views *viewpoint.ViewPointSet, ins []*polygonToken, defs []token.Definition,
+	outs []*token.PolygonDef, version uint32) (*wire.MsgTx, error) {
+	mtx := wire.NewMsgTx(wire.TxVersion | wire.TxNoLock)
+	for _, in := range ins {
+		mtx.AddTxIn(wire.NewTxIn(&in.outpoint, 0))
+	}
+
+	for _, def := range defs {
+		mtx.AddDef(def)
+	}
+	for _, plg := range outs {
+		h := plg.Hash()
+		if e, err := views.FetchPolygonEntry(&h); err != nil || e == nil {
+			mtx.AddDef(plg)
+		}
+		mtx.AddTxOut(wire.NewTxOut(3, &token.HashToken{Hash: h}, ins[0].rights, ins[0].pkScript))
+	}
+
+	if err := verify(views.Db, mtx, version); err != nil {
+		return nil, fmt.Errorf("transaction would be rejected: %v", err)
+	}
+	return mtx, nil
+}
+
+// verify checks the definitions and the geometry of mtx as a node would, on a
+// fresh view of the chain, in a block of the given version.
+func verify(db database.DB, mtx *wire.MsgTx, version uint32) error {
+	if err := validate.CheckDefinitions(mtx); err != nil {
+		return err
+	}
+	if err := validate.CheckDefinitionsVersion(mtx, version); err != nil {
+		return err
+	}
+
+	views := viewpoint.NewViewPointSet(db)
+	outpoints := make(map[wire.OutPoint]struct{})
+	for _, txIn := range mtx.TxIn {
+		outpoints[txIn.PreviousOutPoint] = struct{}{}
+	}
+	if err := views.Utxo.FetchUtxosMain(db, outpoints); err != nil {
+		return err
+	}
+
+	tx := btcutil.NewTx(mtx)
+	if err := validate.CheckTransactionInputs(tx, views); err != nil {
+		return err
+	}
+	if !validate.CheckGeometryIntegrity(tx, views, version) {
+		return fmt.Errorf("polygons out do not cover the polygons in")
+	}
+	return nil
+}
+
+// locate returns the index of the piece starting at the vertex of pieces
+// closest to v.
+func locate(pieces []piece, v token.VertexDef) (int, error) {
+	best, bestd := -1, math.Inf(1)
+	for i, p := range pieces {
+		d := math.Hypot(float64(p.begin.Lng())-float64(v.Lng()), float64(p.begin.Lat())-float64(v.Lat()))
+		if d < bestd {
+			best, bestd = i, d
+		}
+	}
+	if bestd > snapDistance {
+		return 0, fmt.Errorf("cut does not end at a vertex of the outer boundary of the polygon")
+	}
+	return best, nil
+}
+
+// SplitPolygon returns an unsigned transaction spending the polygon token of
+// outpoint and paying, with the same rights and to the same owner, tokens of
+// the two polygons cut runs between. cut is a polyline with ends at vertices
+// of the outer boundary of the polygon and the other positions inside it.
+//
+// The vertices are those of the borders the polygon references. Borders are
+// not divided, so that they are met on both sides of the transaction as they
+// are. The cut itself is defined as new top level borders. The transaction is
+// checked to pass the definition and geometry integrity checks of a node, in a
+// block of the given version, before it is returned.
+func SplitPolygon(views *viewpoint.ViewPointSet, outpoint wire.OutPoint, cut []Position, version uint32) (*wire.MsgTx, error) {
+	in, err := fetchPolygonToken(views, outpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	line := make([]token.VertexDef, 0, len(cut))
+	for _, p := range cut {
+		v, err := vertex(p)
+		if err != nil {
+			return nil, err
+		}
+		if n := len(line); n == 0 || !line[n-1].IsEqual(&v) {
+			line = append(line, v)
+		}
+	}
+	if len(line) < 2 {
+		return nil, fmt.Errorf("cut has less than 2 distinct positions")
+	}
+
+	pieces := make([]piece, len(in.loops[0]))
+	for i, ref := range in.loops[0] {
+		e, err := fetchBorder(views, ref)
+		if err != nil {
+			return nil, err
+		}
+		pieces[i] = newPiece(ref, e)
+	}
+
+	// the cut runs from the boundary at start to the boundary at end
+	var ends [2]int
+	for j, k := range []int{0, len(line) - 1} {
+		i, err := locate(pieces, line[k])
+		if err != nil {
+			return nil, err
+		}
+		ends[j] = i
+		line[k] = pieces[i].begin
+	}
+	start, end := ends[0], ends[1]
+	if start == end {
+		return nil, fmt.Errorf("cut starts and ends at the same vertex")
+	}
+
+	// the cut as borders from its start to its end
+	defs := make([]token.Definition, 0, len(line)-1)
+	cutRefs := make([]chainhash.Hash, len(line)-1)
+	for i := range cutRefs {
+		ref, border := borderRef(views, line[i], line[i+1])
+		if border != nil {
+			defs = append(defs, border)
+		}
+		cutRefs[i] = ref
+	}
+
+	// the first polygon goes along the boundary from the start of the cut to
+	// its end and back along the cut, the second from its end to its start
+	// and along the cut.
+	loops := [2]token.LoopDef{make(token.LoopDef, 0), make(token.LoopDef, 0)}
+	for i := start; i != end; i = (i + 1) % len(pieces) {
+		loops[0] = append(loops[0], pieces[i].ref)
+	}
+	for i := len(cutRefs) - 1; i >= 0; i-- {
+		r := cutRefs[i]
+		r[0] ^= 1
+		loops[0] = append(loops[0], r)
+	}
+	for i := end; i != start; i = (i + 1) % len(pieces) {
+		loops[1] = append(loops[1], pieces[i].ref)
+	}
+	loops[1] = append(loops[1], cutRefs...)
+
+	// the leaves are looked up on a view with the new borders
+	shapes := [2]shape{}
+	check := viewpoint.NewViewPointSet(views.Db)
+	for _, def := range defs {
+		check.AddOneBorder(def.(*token.BorderDef))
+	}
+	outs := make([]*token.PolygonDef, 2)
+	for i, loop := range loops {
+		leaves, err := loopLeaves(check, loop)
+		if err != nil {
+			return nil, err
+		}
+		if !ccw(verticesOf(leaves)) {
+			return nil, fmt.Errorf("cut is not inside the polygon")
+		}
+		shapes[i] = shape{ringOf(leaves)}
+		outs[i] = &token.PolygonDef{Loops: []token.LoopDef{loop}}
+	}
+
+	// each hole goes to the polygon it is in
+	for _, hole := range in.loops[1:] {
+		leaves, err := loopLeaves(views, hole)
+		if err != nil {
+			return nil, err
+		}
+		ring := shape{ringOf(leaves)}
+		p := ring.testPoint(0)
+		i := 1
+		if shapes[0].inside(p) {
+			i = 0
+		}
+		outs[i].Loops = append(outs[i].Loops, hole)
+	}
+
+	return polygonTx(views, []*polygonToken{in}, defs, outs, version)
+}
+
+// MergePolygons returns an unsigned transaction spending the polygon tokens of
+// outpoints and paying, with the same rights and to the owner of the first
+// one, a token of the polygon they make together. The tokens must have the
+// same rights and their polygons must be adjacent.
+//
+// Borders the polygons share are dropped. They must share them at the same
+// level of the border tree, so that the borders of the polygons are met on
+// both sides of the transaction as they are. The transaction is checked to
+// pass the definition and geometry integrity checks of a node, in a block of
+// the given version, before it is returned.
+func MergePolygons(views *viewpoint.ViewPointSet, outpoints []wire.OutPoint, version uint32) (*wire.MsgTx, error) {
+	if len(outpoints) < 2 {
+		return nil, fmt.Errorf("less than 2 polygons to merge")
+	}
+
+	ins := make([]*polygonToken, len(outpoints))
+	refs := make([]chainhash.Hash, 0)
+	for i, outpoint := range outpoints {
+		in, err := fetchPolygonToken(views, outpoint)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 && !sameRights(in.rights, ins[0].rights) {
+			return nil, fmt.Errorf("polygon tokens have different rights")
+		}
+		ins[i] = in
+		for _, loop := range in.loops {
+			refs = append(refs, loop...)
+		}
+	}
+
+	// a border and one of its descendants cover the same line, they can not
+	// be matched with each other
+	ancestors := make(map[chainhash.Hash]struct{})
+	for _, ref := range refs {
+		e, err := fetchBorder(views, ref)
+		if err != nil {
+			return nil, err
+		}
+		for f := e.Father; f != (chainhash.Hash{}); {
+			ancestors[f] = struct{}{}
+			fe, err := fetchBorder(views, f)
+			if err != nil {
+				return nil, err
+			}
+			f = fe.Father
+		}
+	}
+	for _, ref := range refs {
+		key := ref
+		key[0] &^= 1
+		if _, ok := ancestors[key]; ok {
+			return nil, fmt.Errorf("polygons share borders at different levels of the border tree")
+		}
+	}
+
+	// drop the shared borders, met once in each direction
+	count := make(map[chainhash.Hash]int)
+	for _, ref := range refs {
+		r := ref
+		r[0] ^= 1
+		if count[r] > 0 {
+			count[r]--
+		} else if count[ref] > 0 {
+			return nil, fmt.Errorf("polygons overlap")
+		} else {
+			count[ref]++
+		}
+	}
+
+	// chain the borders left into loops
+	next := make(map[token.VertexDef][]piece)
+	remain := make([]piece, 0)
+	for _, ref := range refs {
+		if count[ref] == 0 {
+			continue
+		}
+		count[ref] = 0
+		e, err := fetchBorder(views, ref)
+		if err != nil {
+			return nil, err
+		}
+		p := newPiece(ref, e)
+		next[p.begin] = append(next[p.begin], p)
+		remain = append(remain, p)
+	}
+
+	used := make(map[chainhash.Hash]struct{})
+	var outer token.LoopDef
+	holes := make([]token.LoopDef, 0)
+	for _, first := range remain {
+		if _, ok := used[first.ref]; ok {
+			continue
+		}
+		loop := make(token.LoopDef, 0)
+		for p := first; ; {
+			used[p.ref] = struct{}{}
+			loop = append(loop, p.ref)
+			if p.end.IsEqual(&first.begin) {
+				break
+			}
+			var cand []piece
+			for _, q := range next[p.end] {
+				if _, ok := used[q.ref]; !ok {
+					cand = append(cand, q)
+				}
+			}
+			if len(cand) != 1 {
+				return nil, fmt.Errorf("merged polygon touches itself at a vertex")
+			}
+			p = cand[0]
+		}
+
+		leaves, err := loopLeaves(views, loop)
+		if err != nil {
+			return nil, err
+		}
+		if !ccw(verticesOf(leaves)) {
+			holes = append(holes, loop)
+		} else if outer != nil {
+			return nil, fmt.Errorf("polygons are not adjacent")
+		} else {
+			outer = loop
+		}
+	}
+	if outer == nil {
+		return nil, fmt.Errorf("polygons are not adjacent")
+	}
+
+	plg := &token.PolygonDef{Loops: append([]token.LoopDef{outer}, holes...)}
+	return polygonTx(views, ins, nil, []*token.PolygonDef{plg}, version)
+}
@@ -0,0 +1,157 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package geo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/database"
+	_ "github.com/omegasuite/btcd/database/ffldb"
+	"github.com/omegasuite/btcd/wire"
+	"github.com/omegasuite/btcd/wire/common"
+	"github.com/omegasuite/omega/internal/testutil"
+	"github.com/omegasuite/omega/token"
+	"github.com/omegasuite/omega/viewpoint"
+)
+
+// squareToken creates a database at dbPath with a token of a 2 by 2 degrees
+// square polygon. It returns the database, the hash of the transaction paying
+// the token and the rights of the token.
+func squareToken(t *testing.T, dbPath string) (database.DB, *chainhash.Hash, chainhash.Hash) {
+	_ = os.RemoveAll(dbPath)
+	db, err := database.Create("ffldb", dbPath, common.MainNet)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	err = db.Update(func(dbTx database.Tx) error {
+		for _, name := range []string{"utxosetv2", "borders", "polygons", "rights"} {
+			if _, err := dbTx.Metadata().CreateBucket([]byte(name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("buckets: %v", err)
+	}
+
+	defs, plg, err := Definitions(MultiPolygon{box(0, 0, 2, 2)}, nil)
+	if err != nil {
+		t.Fatalf("Definitions: %v", err)
+	}
+	right := token.NewRightDef(chainhash.Hash{}, []byte("land"), 0)
+	rights := right.Hash()
+	mtx := wire.NewMsgTx(wire.TxVersion)
+	mtx.AddDef(right)
+	for _, d := range defs {
+		mtx.AddDef(d)
+	}
+	// pay to a public key hash
+	pkScript := make([]byte, 25)
+	pkScript[1] = 1
+	pkScript[21] = 0x41
+	mtx.AddTxOut(wire.NewTxOut(3, &token.HashToken{Hash: plg.Hash()}, &rights, pkScript))
+	return db, testutil.Apply(t, db, mtx), rights
+}
+
+// TestSplitMerge ensures a polygon split along its diagonal and the two
+// polygons it is split into merged back pass the checks of a node.
+func TestSplitMerge(t *testing.T) {
+	dbPath := filepath.Join(os.TempDir(), "geo-splitmerge")
+	db, txid, rights := squareToken(t, dbPath)
+	defer os.RemoveAll(dbPath)
+	defer db.Close()
+
+	// cut it in triangles from corner to corner
+	views := viewpoint.NewViewPointSet(db)
+	split, err := SplitPolygon(views, *wire.NewOutPoint(txid, 0), []Position{{0, 0}, {2, 2}}, wire.Version7)
+	if err != nil {
+		t.Fatalf("SplitPolygon: %v", err)
+	}
+	if len(split.TxOut) != 2 {
+		t.Fatalf("split pays %d tokens, want 2", len(split.TxOut))
+	}
+
+	// the cut and 2 polygons
+	if len(split.TxDef) != 3 {
+		t.Errorf("split has %d definitions, want the cut and 2 polygons", len(split.TxDef))
+	}
+	for _, out := range split.TxOut {
+		if !out.Rights.IsEqual(&rights) {
+			t.Errorf("split token has different rights")
+		}
+	}
+	for i, out := range split.TxOut {
+		h := out.Value.(*token.HashToken).Hash
+		var loops []token.LoopDef
+		for _, d := range split.TxDef {
+			if p, ok := d.(*token.PolygonDef); ok && p.Hash() == h {
+				loops = p.Loops
+			}
+		}
+		if len(loops) != 1 || len(loops[0]) != 3 {
+			t.Errorf("split polygon %d has loops %v, want 1 loop of 3 borders", i, loops)
+		}
+	}
+
+	if _, err := SplitPolygon(viewpoint.NewViewPointSet(db), *wire.NewOutPoint(txid, 0),
+		[]Position{{0, 0.5}, {2, 2}}, wire.Version7); err == nil {
+		t.Errorf("cut starting inside the polygon accepted")
+	}
+
+	// merge the halves back
+	splitid := testutil.Apply(t, db, split)
+	merged, err := MergePolygons(viewpoint.NewViewPointSet(db),
+		[]wire.OutPoint{*wire.NewOutPoint(splitid, 0), *wire.NewOutPoint(splitid, 1)}, wire.Version7)
+	if err != nil {
+		t.Fatalf("MergePolygons: %v", err)
+	}
+	if len(merged.TxOut) != 1 {
+		t.Fatalf("merge pays %d tokens, want 1", len(merged.TxOut))
+	}
+	for _, d := range merged.TxDef {
+		if p, ok := d.(*token.PolygonDef); ok && (len(p.Loops) != 1 || len(p.Loops[0]) != 4) {
+			t.Errorf("merged polygon has loops %v, want the 4 borders of the square", p.Loops)
+		}
+	}
+}
+
+// TestSplitVersion checks that the builder keeps to the borders of the polygon
+// as they are: a cut between vertices passes the checks in any version, and
+// one across the middle of borders is refused in any version.
+func TestSplitVersion(t *testing.T) {
+	dbPath := filepath.Join(os.TempDir(), "geo-splitversion")
+	db, txid, _ := squareToken(t, dbPath)
+	defer os.RemoveAll(dbPath)
+	defer db.Close()
+
+	tests := []struct {
+		name    string
+		cut     []Position
+		version uint32
+		valid   bool
+	}{
+		{"across borders before Version7", []Position{{1, 0}, {1, 2}}, wire.Version6, false},
+		{"across borders in Version7", []Position{{1, 0}, {1, 2}}, wire.Version7, false},
+		{"diagonal before Version7", []Position{{0, 0}, {2, 2}}, wire.Version6, true},
+		{"diagonal in Version7", []Position{{0, 0}, {2, 2}}, wire.Version7, true},
+	}
+	for _, test := range tests {
+		_, err := SplitPolygon(viewpoint.NewViewPointSet(db), *wire.NewOutPoint(txid, 0), test.cut, test.version)
+		if test.valid && err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("%s: split accepted", test.name)
+		}
+	}
+}
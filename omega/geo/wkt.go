@@ -0,0 +1,204 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package geo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// wktParser is a recursive descent parser of WKT text.
+type wktParser struct {
+	tokens []string
+	pos    int
+}
+
+// tokenize splits s into parentheses, commas and words.
+func tokenize(s string) []string {
+	tokens := make([]string, 0)
+	word := strings.Builder{}
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for _, c := range s {
+		switch {
+		case c == '(' || c == ')' || c == ',':
+			flush()
+			tokens = append(tokens, string(c))
+		case unicode.IsSpace(c):
+			flush()
+		default:
+			word.WriteRune(c)
+		}
+	}
+	flush()
+	return tokens
+}
+
+func (p *wktParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *wktParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *wktParser) expect(t string) error {
+	if s := p.next(); s != t {
+		return fmt.Errorf("expected %q, got %q", t, s)
+	}
+	return nil
+}
+
+// list parses a parenthesized, comma separated list calling item for each
+// element.
+func (p *wktParser) list(item func() error) error {
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	for {
+		if err := item(); err != nil {
+			return err
+		}
+		switch t := p.next(); t {
+		case ",":
+		case ")":
+			return nil
+		default:
+			return fmt.Errorf("expected \",\" or \")\", got %q", t)
+		}
+	}
+}
+
+// ring parses a ring, dropping the coordinates beyond the second one.
+func (p *wktParser) ring() (Ring, error) {
+	ring := make(Ring, 0)
+	err := p.list(func() error {
+		var pos Position
+		n := 0
+		for t := p.peek(); t != "," && t != ")" && t != ""; t = p.peek() {
+			f, err := strconv.ParseFloat(p.next(), 64)
+			if err != nil {
+				return err
+			}
+			if n < 2 {
+				pos[n] = f
+			}
+			n++
+		}
+		if n < 2 {
+			return fmt.Errorf("position has less than 2 coordinates")
+		}
+		ring = append(ring, pos)
+		return nil
+	})
+	return ring, err
+}
+
+func (p *wktParser) polygon() (Polygon, error) {
+	plg := make(Polygon, 0)
+	err := p.list(func() error {
+		ring, err := p.ring()
+		plg = append(plg, ring)
+		return err
+	})
+	return plg, err
+}
+
+// ParseWKT parses a WKT POLYGON or MULTIPOLYGON.
+func ParseWKT(s string) (MultiPolygon, error) {
+	p := &wktParser{tokens: tokenize(s)}
+
+	kind := strings.ToUpper(p.next())
+	switch strings.ToUpper(p.peek()) {
+	case "Z", "M", "ZM":
+		p.next()
+	case "EMPTY":
+		return nil, fmt.Errorf("empty geometry")
+	}
+
+	var mp MultiPolygon
+	switch kind {
+	case "POLYGON":
+		plg, err := p.polygon()
+		if err != nil {
+			return nil, err
+		}
+		mp = MultiPolygon{plg}
+
+	case "MULTIPOLYGON":
+		err := p.list(func() error {
+			plg, err := p.polygon()
+			mp = append(mp, plg)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported geometry type %q", kind)
+	}
+
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q after geometry", p.peek())
+	}
+	return mp, nil
+}
+
+func formatRings(b *strings.Builder, p Polygon) {
+	b.WriteString("(")
+	for i, ring := range p {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString("(")
+		for j, pos := range ring {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(strconv.FormatFloat(pos[0], 'f', -1, 64))
+			b.WriteString(" ")
+			b.WriteString(strconv.FormatFloat(pos[1], 'f', -1, 64))
+		}
+		b.WriteString(")")
+	}
+	b.WriteString(")")
+}
+
+// FormatWKT returns the WKT of mp, a POLYGON if it has a single polygon and
+// a MULTIPOLYGON otherwise.
+func FormatWKT(mp MultiPolygon) string {
+	b := strings.Builder{}
+	if len(mp) == 1 {
+		b.WriteString("POLYGON ")
+		formatRings(&b, mp[0])
+		return b.String()
+	}
+
+	b.WriteString("MULTIPOLYGON (")
+	for i, p := range mp {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		formatRings(&b, p)
+	}
+	b.WriteString(")")
+	return b.String()
+}
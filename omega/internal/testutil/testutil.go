@@ -0,0 +1,68 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+// Package testutil provides the geometry fixtures shared by the tests of the
+// omega packages.
+package testutil
+
+import (
+	"testing"
+
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/database"
+	"github.com/omegasuite/btcd/wire"
+	"github.com/omegasuite/btcutil"
+	"github.com/omegasuite/omega/token"
+	"github.com/omegasuite/omega/viewpoint"
+)
+
+// Square returns the definitions of the borders and the counter clockwise
+// polygon of the square from (west, south) to (east, north), in CoordPrecision
+// units. The polygon is the last definition.
+func Square(west, south, east, north int32) ([]token.Definition, *token.PolygonDef) {
+	corners := []*token.VertexDef{
+		token.NewVertexDef(south, west, 0),
+		token.NewVertexDef(south, east, 0),
+		token.NewVertexDef(north, east, 0),
+		token.NewVertexDef(north, west, 0),
+	}
+	defs := make([]token.Definition, 0, 5)
+	loop := make(token.LoopDef, 0, 4)
+	for i, v := range corners {
+		b := token.NewBorderDef(*v, *corners[(i+1)%4], chainhash.Hash{})
+		defs = append(defs, b)
+		loop = append(loop, b.Hash())
+	}
+	plg := token.NewPolygonDef([]token.LoopDef{loop})
+	return append(defs, plg), plg
+}
+
+// Apply stores the definitions and outputs of mtx in db as if it were
+// connected to the chain, without spending its inputs.
+func Apply(t *testing.T, db database.DB, mtx *wire.MsgTx) *chainhash.Hash {
+	tx := btcutil.NewTx(mtx)
+	err := db.Update(func(dbTx database.Tx) error {
+		views := viewpoint.NewViewPointSet(db)
+
+		// the borders referenced must be in the view to count references
+		for _, d := range mtx.TxDef {
+			if p, ok := d.(*token.PolygonDef); ok {
+				for _, loop := range p.Loops {
+					for _, ref := range loop {
+						views.FetchBorderEntry(&ref)
+					}
+				}
+			}
+		}
+		return viewpoint.DbPutGensisTransaction(dbTx, tx, views)
+	})
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	return tx.Hash()
+}
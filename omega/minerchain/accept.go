@@ -278,12 +278,17 @@ func (m *MinerChain) factorPOW(baseh uint32, best chainhash.Hash) int64 {
 		case p.Data.GetNonce() > 0:
 			d += wire.POWRotate
 
-		case p.Data.GetNonce() <= -wire.MINER_RORATE_FREQ:
-			h = -(p.Data.GetNonce() + wire.MINER_RORATE_FREQ)
+		case p.Data.GetNonce() <= -m.blockChain.RotateFreq(p):
+			h = -(p.Data.GetNonce() + m.blockChain.RotateFreq(p))
 			break hit
 		}
 	}
 
+	committee, err := m.blockChain.Committee(h)
+	if err != nil {
+		return -1
+	}
+	desirable := committee.DesirableCandidates
 	h += d
 
 	if h < 0 {
@@ -292,19 +297,19 @@ func (m *MinerChain) factorPOW(baseh uint32, best chainhash.Hash) int64 {
 
 	d = int32(baseh) - h
 
-	if d - wire.DESIRABLE_MINER_CANDIDATES > wire.SCALEFACTORCAP {
+	if d - desirable > wire.SCALEFACTORCAP {
 		return int64(1) << wire.SCALEFACTORCAP
-	} else if d < wire.DESIRABLE_MINER_CANDIDATES / 2 {
-		m := wire.DESIRABLE_MINER_CANDIDATES / 2 - d
+	} else if d < desirable / 2 {
+		m := desirable / 2 - d
 		if m > 10 {
 			m = 10
 		}
 		return (-1) << m
-	} else if d <= wire.DESIRABLE_MINER_CANDIDATES {
+	} else if d <= desirable {
 		return 1
 	}
 
-	return int64(1) << (d - wire.DESIRABLE_MINER_CANDIDATES)
+	return int64(1) << (d - desirable)
 }
 
 // checkBlockContext peforms several validation checks on the block which depend
@@ -327,16 +332,17 @@ func (b *MinerChain) checkBlockContext(block *wire.MinerBlock, prevNode *chainut
 
 	header := block.MsgBlock()
 
-	for p, i := prevNode, 0; p != nil && i < wire.MinerGap; i++ {
+	gap := b.chainParams.Committee(header.Version).MinerGap
+	for p, i := prevNode, int32(0); p != nil && i < gap; i++ {
 		h := NodetoHeader(p)
 		if bytes.Compare(h.Connection, block.MsgBlock().Connection) == 0 {
 			str := "Miner's IP/port has appeared in the past %d blocks"
-			str = fmt.Sprintf(str, wire.MinerGap)
+			str = fmt.Sprintf(str, gap)
 			return ruleError(ErrRotationViolation, str)
 		}
 		if bytes.Compare(h.Miner[:], block.MsgBlock().Miner[:]) == 0 {
 			str := "Miner has appeared in the past %d blocks"
-			str = fmt.Sprintf(str, wire.MinerGap)
+			str = fmt.Sprintf(str, gap)
 			return ruleError(ErrRotationViolation, str)
 		}
 		p = p.Parent
@@ -372,9 +378,9 @@ func (b *MinerChain) checkBlockContext(block *wire.MinerBlock, prevNode *chainut
 	// hash of: PrevBlock + ReferredBlock + BestBlock + Newnode + Nonce must be within Bits Difficulty target, which is
 	// set periodically according to NewNodeBlock chain data. The target is to set based on the number of miner
 	// candidates as decided by the height of NewNodeBlock chain and the height of NewNodeBlock referred by
-	// latest committee in main chain upto ReferredBlock. If this is below MINER_RORATE_FREQ, the difficulty
-	// is set to generate 2 NewNodeBlock every MINER_RORATE_FREQ block time. Once number of miner candidates reaches
-	// MINER_RORATE_FREQ, the difficulty increases 20% for every one more candidate.
+	// latest committee in main chain upto ReferredBlock. If this is below RotateFreq, the difficulty
+	// is set to generate 2 NewNodeBlock every RotateFreq block time. Once number of miner candidates reaches
+	// RotateFreq, the difficulty increases 20% for every one more candidate.
 
 	xf := blockchain.BFNone
 	if block.Height() > 2200 || block.MsgBlock().Version >= 0x20000 {
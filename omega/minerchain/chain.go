@@ -278,14 +278,12 @@ func (b *MinerChain) getReorganizeNodes(node *chainutil.BlockNode) (*list.List,
 	rotate := int32(best.LastRotation) - b.blockChain.TotalRotate(txdetachNodes)
 
 	// examine signers are in committee
-	miners := make([]*[20]byte, wire.CommitteeSize)
-	for i := int32(0); i < wire.CommitteeSize; i++ {
-		if blk, _ := b.BlockByHeight(int32(rotate) - wire.CommitteeSize + i + 1); blk != nil {
-			if _, err := b.blockChain.CheckCollateral(blk, &blk.MsgBlock().BestBlock, blockchain.BFNone); err == nil {
-				miners[i] = &blk.MsgBlock().Miner
-			}
-		}
+	committee, err := b.blockChain.Committee(rotate)
+	if err != nil {
+		log.Infof("getReorganizeNodes: %s", err.Error())
+		return list.New(), list.New(), list.New(), list.New()
 	}
+	miners := b.resizeCommittee(nil, rotate, committee.Size)
 
 	x, y, p := attachNodes.Front(), txattachNodes.Front(), forkNode
 
@@ -296,12 +294,12 @@ func (b *MinerChain) getReorganizeNodes(node *chainutil.BlockNode) (*list.List,
 	}
 
 	for x != nil && rotate >= p.Height {
-		if p.Height > rotate - wire.CommitteeSize {
+		if size := int32(len(miners)); p.Height > rotate - size {
 			hdr := NodetoHeader(p)
 			if _, err := b.blockChain.CheckCollateral(wire.NewMinerBlock(&hdr), &hdr.BestBlock, blockchain.BFNone); err == nil {
-				miners[p.Height-(rotate-wire.CommitteeSize+1)] = &hdr.Miner
+				miners[p.Height-(rotate-size+1)] = &hdr.Miner
 			} else {
-				miners[p.Height-(rotate-wire.CommitteeSize+1)] = nil
+				miners[p.Height-(rotate-size+1)] = nil
 			}
 		}
 		x = x.Next()
@@ -338,19 +336,19 @@ func (b *MinerChain) getReorganizeNodes(node *chainutil.BlockNode) (*list.List,
 		// try to rotate miners
 		if shift > 0 {
 			contain = false
-			j := 0
-			for k := shift; k < wire.CommitteeSize; k++ {
-				miners[j] = miners[k]
-				j++
-			}
+			size := len(miners)
+			var last *wire.MingingRightBlock	// the miner block at rotate, if it is attached
 			for k := int32(0); k < shift; k++ {
 				rotate++
 
 				hdr := NodetoHeader(n)
+				if k == shift - 1 {
+					last = &hdr
+				}
 				if _, err := b.blockChain.CheckCollateral(wire.NewMinerBlock(&hdr), &hdr.BestBlock, blockchain.BFNone); err == nil {
-					miners[j] = &hdr.Miner
+					miners = append(miners, &hdr.Miner)
 				} else {
-					miners[j] = nil
+					miners = append(miners, nil)
 				}
 
 				x = x.Next()
@@ -359,8 +357,18 @@ func (b *MinerChain) getReorganizeNodes(node *chainutil.BlockNode) (*list.List,
 				} else {
 					break
 				}
-				j++
 			}
+
+			// the committee is resized by the rotation
+			var committee *chaincfg.CommitteeParams
+			if last != nil {
+				committee = b.blockChain.ChainParams.Committee(last.Version)
+			} else if committee, err = b.blockChain.Committee(rotate); err != nil {
+				skipList(txattachNodes, y)
+				y = nil
+				continue
+			}
+			miners = b.resizeCommittee(miners[len(miners)-size:], rotate, committee.Size)
 		}
 		y = y.Next()
 	}
@@ -368,6 +376,23 @@ func (b *MinerChain) getReorganizeNodes(node *chainutil.BlockNode) (*list.List,
 	return detachNodes, attachNodes, txdetachNodes, txattachNodes
 }
 
+// resizeCommittee fits miners, the committee of rotation rotate, to size by
+// dropping its most senior members or adding the ones before them.
+func (b *MinerChain) resizeCommittee(miners []*[20]byte, rotate, size int32) []*[20]byte {
+	if n := int32(len(miners)); n >= size {
+		return miners[n-size:]
+	}
+	senior := make([]*[20]byte, size-int32(len(miners)), size)
+	for i := range senior {
+		if blk, _ := b.BlockByHeight(rotate - size + int32(i) + 1); blk != nil {
+			if _, err := b.blockChain.CheckCollateral(blk, &blk.MsgBlock().BestBlock, blockchain.BFNone); err == nil {
+				senior[i] = &blk.MsgBlock().Miner
+			}
+		}
+	}
+	return append(senior, miners...)
+}
+
 // connectBlock handles connecting the passed node/block to the end of the main
 // (best) chain.
 //
@@ -803,9 +828,9 @@ func (b *MinerChain) reorganizeChain(detachNodes, attachNodes *list.List) error
 		for rot >= detachedHeight {
 			txdetachNodes.PushBack(txtip)
 			if txtip.Nonce() > 0 {
-				rot -= wire.CommitteeSize / 2 + 1
+				rot -= wire.POWRotate
 				restore++
-			} else if txtip.Nonce() <= -wire.MINER_RORATE_FREQ {
+			} else if txtip.Nonce() <= -b.blockChain.RotateFreq(txtip) {
 				rot--
 				restore = 0
 			}
@@ -986,8 +1011,8 @@ func (b *MinerChain) connectBestChain(node *chainutil.BlockNode, block *wire.Min
 			if p.Header().Nonce > 0 {
 				blk, _ := b.blockChain.BlockByHash(p.Hash())
 				b.blockChain.AddOrphanBlock(blk)
-				rotated -= wire.CommitteeSize/2 + 1
-			} else if p.Header().Nonce <= -wire.MINER_RORATE_FREQ {
+				rotated -= wire.POWRotate
+			} else if p.Header().Nonce <= -b.blockChain.RotateFreq(p) {
 				rotated--
 			}
 
@@ -1014,13 +1039,13 @@ func (b *MinerChain) connectBestChain(node *chainutil.BlockNode, block *wire.Min
 func (b *MinerChain) WorkSum(node *chainutil.BlockNode) * big.Int {
 	s := node.Data.WorkSum()
 	bb := b.blockChain.NodeByHash(&node.Data.(*blockchainNodeData).block.BestBlock)
-	for bb != nil && bb.Data.GetNonce() > -wire.MINER_RORATE_FREQ {
+	for bb != nil && bb.Data.GetNonce() > -b.blockChain.RotateFreq(bb) {
 		bb = bb.Parent
 	}
 	if bb == nil {
 		return s
 	}
-	mb := b.BestChain.NodeByHeight(-bb.Data.GetNonce() - wire.MINER_RORATE_FREQ)
+	mb := b.BestChain.NodeByHeight(-bb.Data.GetNonce() - b.blockChain.RotateFreq(bb))
 	if mb == nil {
 		return s
 	}
@@ -1609,19 +1634,19 @@ func New(config *blockchain.Config) (*blockchain.BlockChain, error) {
 	}
 
 	txtop := s.BestChain.Tip()
-	for txtop != nil && txtop.Data.GetNonce() > -wire.MINER_RORATE_FREQ {
+	for txtop != nil && txtop.Data.GetNonce() > -s.RotateFreq(txtop) {
 		txtop = txtop.Parent
 	}
-	if txtop != nil && mbest.Height < -txtop.Data.GetNonce() - wire.MINER_RORATE_FREQ {
+	if txtop != nil && mbest.Height < -txtop.Data.GetNonce() - s.RotateFreq(txtop) {
 		ok = false
 		// roll back tx chain to the point where a rotation references to a valid
 		// miner block and non rotation blocks that follows
 
 		rp, sp := txtop, txtop
 		for sp != nil {
-			if sp.Data.GetNonce() > -wire.MINER_RORATE_FREQ {
+			if sp.Data.GetNonce() > -s.RotateFreq(sp) {
 				sp = sp.Parent
-			} else if mbest.Height < -sp.Data.GetNonce() - wire.MINER_RORATE_FREQ {
+			} else if mbest.Height < -sp.Data.GetNonce() - s.RotateFreq(sp) {
 				rp = sp
 				sp = sp.Parent
 			} else {
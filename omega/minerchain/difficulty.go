@@ -332,7 +332,7 @@ func (b *MinerChain) calcNextRequiredDifficulty(lastNode *chainutil.BlockNode, n
 		}
 		mb, h, dh := bb, int32(-1), int32(0)
 		// find out length of waiting list. this is a simplified, ignores POW tx blocks
-		for mb != nil && (mb.Data.GetNonce() > -wire.MINER_RORATE_FREQ) {
+		for mb != nil && (mb.Data.GetNonce() > -b.blockChain.RotateFreq(mb)) {
 			if mb.Data.GetNonce() < 0 {
 				mb = b.blockChain.NodeByHeight(mb.Height + mb.Data.GetNonce())
 				continue
@@ -347,8 +347,18 @@ func (b *MinerChain) calcNextRequiredDifficulty(lastNode *chainutil.BlockNode, n
 			mb = pmb
 		}
 		if mb != nil {
-			h = - mb.Data.GetNonce() - wire.MINER_RORATE_FREQ
+			h = - mb.Data.GetNonce() - b.blockChain.RotateFreq(mb)
 		}
+		rotation := h
+		if rotation < 0 {
+			// no rotation yet, it is the committee of the genesis miner block
+			rotation = 0
+		}
+		committee, err := b.blockChain.Committee(rotation)
+		if err != nil {
+			return lastNode.Data.(*blockchainNodeData).block.Bits, coll, err
+		}
+		desirable := int(committee.DesirableCandidates)
 		if lastNode.Data.GetVersion() >= chaincfg.Version2 {
 			h += dh
 		}
@@ -360,12 +370,12 @@ func (b *MinerChain) calcNextRequiredDifficulty(lastNode *chainutil.BlockNode, n
 		// if there is an difficulty adjustment (increase) due to waiting list control
 		// adjusting dt as it the block is generated faster, this will cause increase
 		// in difficulty target, so we will less likely run into long waiting list
-		if d - wire.DESIRABLE_MINER_CANDIDATES > wire.SCALEFACTORCAP {
+		if d - desirable > wire.SCALEFACTORCAP {
 			dt = dt >> wire.SCALEFACTORCAP
-		} else if d > wire.DESIRABLE_MINER_CANDIDATES {
-			dt = dt >> (d - wire.DESIRABLE_MINER_CANDIDATES)
-		} else if v2 && d < wire.DESIRABLE_MINER_CANDIDATES / 2 {
-			m := wire.DESIRABLE_MINER_CANDIDATES / 2 - d
+		} else if d > desirable {
+			dt = dt >> (d - desirable)
+		} else if v2 && d < desirable / 2 {
+			m := desirable / 2 - d
 			if m > 10 {
 				m = 10
 			}
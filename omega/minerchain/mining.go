@@ -220,21 +220,27 @@ func (m *CPUMiner) factorPOW(prevh int32, best chainhash.Hash) int64 {	// *big.I
 		return int64(1) << wire.SCALEFACTORCAP
 	}
 
+	committee, err := m.g.Chain.Committee(h)
+	if err != nil {
+		return int64(1) << wire.SCALEFACTORCAP
+	}
+
 	d := prevh - h
+	desirable := committee.DesirableCandidates
 
-	if d - wire.DESIRABLE_MINER_CANDIDATES > wire.SCALEFACTORCAP {
+	if d - desirable > wire.SCALEFACTORCAP {
 		return int64(1) << wire.SCALEFACTORCAP
-	} else if d < wire.DESIRABLE_MINER_CANDIDATES / 2 {
-		m := wire.DESIRABLE_MINER_CANDIDATES / 2 - d
+	} else if d < desirable / 2 {
+		m := desirable / 2 - d
 		if m > 10 {
 			m = 10
 		}
 		return (-1) << m
-	} else if d <= wire.DESIRABLE_MINER_CANDIDATES {
+	} else if d <= desirable {
 		return 1
 	}
 
-	return int64(1) << (d - wire.DESIRABLE_MINER_CANDIDATES)
+	return int64(1) << (d - desirable)
 }
 
 // solveBlock attempts to find some combination of a nonce, extra nonce, and
@@ -355,11 +361,13 @@ func (g * MinerChain) QualifiedMier(privKeys map[btcutil.Address]*btcec.PrivateK
 	curHeight := g.BestSnapshot().Height
 	// Choose a payment address at random.
 
+	gap := g.chainParams.Committee(g.BestChain.Tip().Data.GetVersion()).MinerGap
+
 	good := false
 	for addr, _ := range privKeys {
 		good = true
-		for i := 0; i < wire.MinerGap && int32(i) <= curHeight; i++ {
-			p, _ := g.BlockByHeight(curHeight - int32(i))
+		for i := int32(0); i < gap && i <= curHeight; i++ {
+			p, _ := g.BlockByHeight(curHeight - i)
 			if bytes.Compare(p.MsgBlock().Miner[:], addr.ScriptAddress()) == 0 {
 				good = false
 				break
@@ -453,13 +461,21 @@ out:
 
 //		h := m.g.Chain.BestSnapshot().LastRotation	// .LastRotation(h0)
 //		d := curHeight - int32(h)
-		if d > wire.DESIRABLE_MINER_CANDIDATES + 10 {
+		committee, err := m.g.Chain.Committee(int32(m.g.Chain.BestSnapshot().LastRotation))
+		if err != nil {
+			m.submitBlockLock.Unlock()
+			m.Stale = true
+			log.Infof("miner.generateBlocks: sleep because the committee is not known: %s", err.Error())
+			time.Sleep(time.Second * 5)
+			continue
+		}
+		if d > committee.DesirableCandidates + 10 {
 			m.submitBlockLock.Unlock()
 			m.Stale = true
 			log.Infof("miner.generateBlocks: sleep because of too many candidates %d", d)
 			select {
 			case <-m.quit:
-			case <-time.After(time.Second * time.Duration(5 * (d -3 - wire.DESIRABLE_MINER_CANDIDATES ))):
+			case <-time.After(time.Second * time.Duration(5 * (d -3 - committee.DesirableCandidates ))):
 			}
 			continue
 		}
@@ -474,7 +490,7 @@ out:
 		mtch := false
 		qc := chainChoice
 		es := ""
-		for i := 0; i < wire.MinerGap && qc != nil && !mtch; i++ {
+		for i := int32(0); i < committee.MinerGap && qc != nil && !mtch; i++ {
 			p := NodetoHeader(qc)
 			qc = qc.Parent
 			for _,s := range m.cfg.ExternalIPs {
@@ -502,7 +518,6 @@ out:
 		// in the memory pool as a source of transactions to potentially
 		// include in the block.
 		var template *mining.BlockTemplate
-		var err error
 
 		signAddr := m.cfg.MiningAddrs[rnd]
 
@@ -0,0 +1,208 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package abi
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// TestMethodID ensures method codes match those of deployed contracts.
+func TestMethodID(t *testing.T) {
+	tests := []struct {
+		sig string
+		id  [4]byte
+	}{
+		{"oracle", [4]byte{2, 0, 2, 9}},
+		{"owner", [4]byte{0, 1, 0, 4}},
+		{"minted()uint64", [4]byte{0, 0, 8, 0}},
+	}
+
+	for _, test := range tests {
+		if id := MethodID(test.sig); id != test.id {
+			t.Errorf("MethodID(%q) = %x, expected %x", test.sig, id, test.id)
+		}
+	}
+}
+
+func TestTopic(t *testing.T) {
+	if topic := Topic("oracle"); topic != 0x09020002 {
+		t.Errorf("Topic(oracle) = %08x, expected 09020002", topic)
+	}
+}
+
+func TestNewMethod(t *testing.T) {
+	m, err := NewMethod("issue([21]byte, uint64, []byte)")
+	if err != nil {
+		t.Fatalf("NewMethod: %v", err)
+	}
+	if m.Name != "issue" || m.Sig != "issue([21]byte,uint64,[]byte)" || len(m.Inputs) != 3 ||
+		len(m.Outputs) != 0 {
+		t.Errorf("unexpected method %+v", m)
+	}
+	if m.Inputs[0].Kind != FixedBytesKind || m.Inputs[0].Size != 21 ||
+		m.Inputs[1].Kind != UintKind || m.Inputs[1].Size != 8 || m.Inputs[2].Kind != BytesKind {
+		t.Errorf("unexpected inputs %+v", m.Inputs)
+	}
+
+	m, err = NewMethod("balance(address)(uint64,bool)")
+	if err != nil {
+		t.Fatalf("NewMethod: %v", err)
+	}
+	if len(m.Outputs) != 2 || m.Outputs[1].Kind != BoolKind {
+		t.Errorf("unexpected outputs %+v", m.Outputs)
+	}
+
+	for _, sig := range []string{"", "()", "f(", "f(uint7)", "f()(uint8", "f([0]byte)"} {
+		if _, err := NewMethod(sig); err == nil {
+			t.Errorf("NewMethod(%q): expected error", sig)
+		}
+	}
+}
+
+func TestPack(t *testing.T) {
+	m, err := NewMethod("issue([21]byte,uint64,[]byte)")
+	if err != nil {
+		t.Fatalf("NewMethod: %v", err)
+	}
+
+	var address [21]byte
+	address[0] = 0x6f
+	address[20] = 1
+	data, err := m.Pack(address, uint64(0x0102), []byte{0x30, 0x01, 0xff})
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	id := m.ID()
+	params, _ := hex.DecodeString("6f0000000000000000000000000000000000000001" + // address
+		"0201000000000000" + "3001ff")
+	expected := append(id[:], params...)
+	if !bytes.Equal(data, expected) {
+		t.Errorf("Pack = %x, expected %x", data, expected)
+	}
+
+	// JSON arguments
+	var args []interface{}
+	if err := json.Unmarshal([]byte(`["6f0000000000000000000000000000000000000001", 258, "3001ff"]`), &args); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := m.Pack(args...); err != nil || !bytes.Equal(data, expected) {
+		t.Errorf("Pack of JSON args = %x, %v, expected %x", data, err, expected)
+	}
+
+	bad := [][]interface{}{
+		{address, uint64(1)},
+		{address[:20], uint64(1), []byte{}},
+		{address, -1, []byte{}},
+		{address, 1.5, []byte{}},
+		{address, uint64(1), "xyz"},
+	}
+	for _, args := range bad {
+		if _, err := m.Pack(args...); err == nil {
+			t.Errorf("Pack(%v): expected error", args)
+		}
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	types, err := parseTypes("bool,uint8,int16,uint32,int64,[]byte,string,hash,string")
+	if err != nil {
+		t.Fatalf("parseTypes: %v", err)
+	}
+	values := []interface{}{
+		true, uint8(7), int16(-2), uint32(1 << 31), int64(-1 << 40), []byte{1, 2},
+		"abc", make([]byte, 32), "rest",
+	}
+
+	data, err := Encode(types, values)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if len(data) != 1+1+2+4+8+4+2+4+3+32+4 {
+		t.Errorf("encoded %d bytes: %x", len(data), data)
+	}
+
+	decoded, err := Decode(types, data)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, values) {
+		t.Errorf("Decode = %v, expected %v", decoded, values)
+	}
+
+	if _, err := Decode(types, data[:20]); err == nil {
+		t.Error("expected decoding short data to fail")
+	}
+}
+
+func TestIntRange(t *testing.T) {
+	tests := []struct {
+		typ string
+		v   interface{}
+		ok  bool
+	}{
+		{"uint8", 255, true},
+		{"uint8", 256, false},
+		{"int8", -128, true},
+		{"int8", 128, false},
+		{"uint64", "18446744073709551615", true},
+		{"int64", "-9223372036854775808", true},
+		{"int64", uint64(1 << 63), false},
+		{"uint16", "0x10000", false},
+		{"uint32", json.Number("42"), true},
+	}
+
+	for _, test := range tests {
+		typ, err := NewType(test.typ)
+		if err != nil {
+			t.Fatalf("NewType(%q): %v", test.typ, err)
+		}
+		if _, err := toInt(typ, test.v); (err == nil) != test.ok {
+			t.Errorf("toInt(%s, %v): got error %v", test.typ, test.v, err)
+		}
+	}
+}
+
+// TestJSONValues ensures values sent as JSON encode to the same data.
+func TestJSONValues(t *testing.T) {
+	types, err := parseTypes("bool,uint64,int64,uint64,address,[]byte,string")
+	if err != nil {
+		t.Fatalf("parseTypes: %v", err)
+	}
+	var addr [21]byte
+	addr[0] = 0x6f
+	values := []interface{}{
+		true, uint64(1<<63 + 1), int64(-1 << 60), uint64(5), addr, []byte{1, 2}, "abc",
+	}
+
+	data, err := Encode(types, values)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	j, err := json.Marshal(JSONValues(values))
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var unmarshalled []interface{}
+	if err := json.Unmarshal(j, &unmarshalled); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	d, err := Encode(types, unmarshalled)
+	if err != nil {
+		t.Fatalf("Encode %s: %v", j, err)
+	}
+	if !bytes.Equal(d, data) {
+		t.Errorf("%s encoded to %x, expected %x", j, d, data)
+	}
+}
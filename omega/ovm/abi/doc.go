@@ -0,0 +1,22 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+/*
+Package abi encodes calls to OVM contracts and decodes their return data.
+
+A contract function is identified by its signature, such as
+"issue([21]byte,uint64,[]byte)" or "minted()uint64". Call data is the 4-byte
+method code of the signature followed by the arguments. Contract code finds
+the method code at gi8 and the arguments from gi12 on.
+
+	m, _ := abi.NewMethod("issue([21]byte,uint64,[]byte)")
+	input, err := m.Pack(address, uint64(100), sig)
+
+See Encode for the layout of values.
+*/
+package abi
@@ -0,0 +1,138 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package abi
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// MethodID returns the 4-byte method code of a contract function with
+// signature such as "transfer([21]byte,uint64)", in the order it is in call
+// data. The code is made of the first 4 hex digits of the sha256 hash of the
+// signature, each digit a byte with a-f taken as 0. Contract code compares
+// it as a little-endian dword, written abi("signature") in assembly.
+func MethodID(signature string) [4]byte {
+	h := sha256.Sum256([]byte(signature))
+	s := hex.EncodeToString(h[:2])
+
+	var id [4]byte
+	for i := 0; i < 4; i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			id[3-i] = s[i] - '0'
+		}
+	}
+	return id
+}
+
+// Topic returns the topic of events with signature, such as
+// "Transfer([21]byte,[21]byte,uint64)". It is the method code of the
+// signature as a little-endian dword, the operand abi("signature") of an
+// EVENT instruction.
+func Topic(signature string) uint32 {
+	id := MethodID(signature)
+	return binary.LittleEndian.Uint32(id[:])
+}
+
+// Method is a contract function.
+type Method struct {
+	Name    string
+	Inputs  []Type
+	Outputs []Type
+
+	// Sig is the signature of the method, e.g. "minted()uint64", which
+	// its method code is made of.
+	Sig string
+}
+
+// NewMethod parses signature of the form name(params)results, where params
+// are types separated by commas and results are a type or types in
+// parentheses. Whitespace is ignored.
+func NewMethod(signature string) (*Method, error) {
+	sig := strings.Join(strings.Fields(signature), "")
+
+	open := strings.IndexByte(sig, '(')
+	if open <= 0 {
+		return nil, fmt.Errorf("invalid signature %q: missing method name or parameters", signature)
+	}
+	end := strings.IndexByte(sig[open:], ')')
+	if end < 0 {
+		return nil, fmt.Errorf("invalid signature %q: missing ')'", signature)
+	}
+	end += open
+
+	m := &Method{Name: sig[:open], Sig: sig}
+
+	var err error
+	if m.Inputs, err = parseTypes(sig[open+1 : end]); err != nil {
+		return nil, fmt.Errorf("invalid signature %q: %v", signature, err)
+	}
+
+	results := sig[end+1:]
+	if strings.HasPrefix(results, "(") {
+		if !strings.HasSuffix(results, ")") {
+			return nil, fmt.Errorf("invalid signature %q: missing ')'", signature)
+		}
+		results = results[1 : len(results)-1]
+	}
+	if m.Outputs, err = parseTypes(results); err != nil {
+		return nil, fmt.Errorf("invalid signature %q: %v", signature, err)
+	}
+
+	return m, nil
+}
+
+func parseTypes(s string) ([]Type, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var types []Type
+	for _, name := range strings.Split(s, ",") {
+		t, err := NewType(name)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, t)
+	}
+	return types, nil
+}
+
+// ID returns the method code of m.
+func (m *Method) ID() [4]byte {
+	return MethodID(m.Sig)
+}
+
+// Pack returns the call data of m with args: the method code followed by
+// the encoded args.
+func (m *Method) Pack(args ...interface{}) ([]byte, error) {
+	if len(args) != len(m.Inputs) {
+		return nil, fmt.Errorf("%s takes %d arguments, %d given", m.Name, len(m.Inputs), len(args))
+	}
+
+	data, err := Encode(m.Inputs, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", m.Name, err)
+	}
+
+	id := m.ID()
+	return append(id[:], data...), nil
+}
+
+// Unpack decodes the return data of m.
+func (m *Method) Unpack(data []byte) ([]interface{}, error) {
+	values, err := Decode(m.Outputs, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", m.Name, err)
+	}
+	return values, nil
+}
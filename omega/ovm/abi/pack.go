@@ -0,0 +1,273 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package abi
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/omegasuite/btcutil/base58"
+)
+
+// Encode encodes values of types. Values are laid out one after another with
+// no padding. Integers are little-endian. A []byte or string is preceded by
+// its length as a uint32 unless it is the last value, which takes the rest of
+// the data.
+func Encode(types []Type, values []interface{}) ([]byte, error) {
+	if len(values) != len(types) {
+		return nil, fmt.Errorf("%d values for %d types", len(values), len(types))
+	}
+
+	var data []byte
+	for i, t := range types {
+		b, err := encodeValue(t, values[i])
+		if err != nil {
+			return nil, fmt.Errorf("value %d: %v", i, err)
+		}
+		if (t.Kind == BytesKind || t.Kind == StringKind) && i != len(types)-1 {
+			var n [4]byte
+			binary.LittleEndian.PutUint32(n[:], uint32(len(b)))
+			data = append(data, n[:]...)
+		}
+		data = append(data, b...)
+	}
+	return data, nil
+}
+
+// Decode decodes data encoded by Encode. Values are returned as bool, uint8
+// to uint64, int8 to int64, []byte for [N]byte and []byte, and string.
+func Decode(types []Type, data []byte) ([]interface{}, error) {
+	values := make([]interface{}, 0, len(types))
+	for i, t := range types {
+		n := t.Size
+		if t.Kind == BytesKind || t.Kind == StringKind {
+			if i == len(types)-1 {
+				n = len(data)
+			} else {
+				if len(data) < 4 {
+					return nil, fmt.Errorf("value %d: data too short", i)
+				}
+				n = int(binary.LittleEndian.Uint32(data))
+				data = data[4:]
+			}
+		}
+		if len(data) < n {
+			return nil, fmt.Errorf("value %d: data too short", i)
+		}
+		values = append(values, decodeValue(t, data[:n]))
+		data = data[n:]
+	}
+	return values, nil
+}
+
+func decodeValue(t Type, b []byte) interface{} {
+	switch t.Kind {
+	case BoolKind:
+		return b[0] != 0
+
+	case UintKind:
+		switch t.Size {
+		case 1:
+			return b[0]
+		case 2:
+			return binary.LittleEndian.Uint16(b)
+		case 4:
+			return binary.LittleEndian.Uint32(b)
+		}
+		return binary.LittleEndian.Uint64(b)
+
+	case IntKind:
+		switch t.Size {
+		case 1:
+			return int8(b[0])
+		case 2:
+			return int16(binary.LittleEndian.Uint16(b))
+		case 4:
+			return int32(binary.LittleEndian.Uint32(b))
+		}
+		return int64(binary.LittleEndian.Uint64(b))
+
+	case StringKind:
+		return string(b)
+	}
+
+	r := make([]byte, len(b))
+	copy(r, b)
+	return r
+}
+
+func encodeValue(t Type, v interface{}) ([]byte, error) {
+	switch t.Kind {
+	case BoolKind:
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%v is not a bool", v)
+		}
+		if b {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+
+	case UintKind, IntKind:
+		n, err := toInt(t, v)
+		if err != nil {
+			return nil, err
+		}
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], n)
+		return b[:t.Size], nil
+
+	case StringKind:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("%v is not a string", v)
+		}
+		return []byte(s), nil
+	}
+
+	b, err := toBytes(t, v)
+	if err != nil {
+		return nil, err
+	}
+	if t.Kind == FixedBytesKind && len(b) != t.Size {
+		return nil, fmt.Errorf("%d bytes given for %s", len(b), t)
+	}
+	return b, nil
+}
+
+// toInt converts v to an integer of type t. v may be any Go integer, an
+// integral float64 as JSON numbers are unmarshalled to, a json.Number or a
+// string in a form accepted by strconv.ParseInt with base 0.
+func toInt(t Type, v interface{}) (uint64, error) {
+	var u uint64
+	var signed bool
+
+	switch x := v.(type) {
+	case float64:
+		if x != math.Trunc(x) || x < math.MinInt64 || x >= math.MaxUint64 {
+			return 0, fmt.Errorf("%v is not an integer", v)
+		}
+		if x < 0 {
+			u, signed = uint64(int64(x)), true
+		} else {
+			u = uint64(x)
+		}
+
+	case json.Number:
+		return toInt(t, string(x))
+
+	case string:
+		if n, err := strconv.ParseUint(x, 0, 64); err == nil {
+			u = n
+		} else if n, err := strconv.ParseInt(x, 0, 64); err == nil {
+			u, signed = uint64(n), true
+		} else {
+			return 0, fmt.Errorf("%q is not an integer", x)
+		}
+
+	default:
+		rv := reflect.ValueOf(v)
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			u, signed = uint64(rv.Int()), rv.Int() < 0
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			u = rv.Uint()
+		default:
+			return 0, fmt.Errorf("%v is not an integer", v)
+		}
+	}
+
+	bits := uint(t.Size * 8)
+	if t.Kind == UintKind {
+		if signed || (bits < 64 && u>>bits != 0) {
+			return 0, fmt.Errorf("%v overflows %s", v, t)
+		}
+		return u, nil
+	}
+
+	n := int64(u)
+	if (!signed && n < 0) || (bits < 64 && (n < -1<<(bits-1) || n >= 1<<(bits-1))) {
+		return 0, fmt.Errorf("%v overflows %s", v, t)
+	}
+	return u, nil
+}
+
+// toBytes converts v to bytes. v may be a []byte, a byte array or a string
+// in hex. An address may also be given as a base58 encoded string.
+func toBytes(t Type, v interface{}) ([]byte, error) {
+	switch x := v.(type) {
+	case []byte:
+		return x, nil
+
+	case string:
+		s := strings.TrimPrefix(x, "0x")
+		if b, err := hex.DecodeString(s); err == nil {
+			return b, nil
+		}
+		if t.Kind == FixedBytesKind && t.Size == AddressSize {
+			if b, netID, err := base58.CheckDecode(x); err == nil && len(b) == AddressSize-1 {
+				return append([]byte{netID}, b...), nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not in hex", x)
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Array && rv.Type().Elem().Kind() == reflect.Uint8 {
+		b := make([]byte, rv.Len())
+		reflect.Copy(reflect.ValueOf(b), rv)
+		return b, nil
+	}
+	return nil, fmt.Errorf("%v is not bytes", v)
+}
+
+// maxJSONInt is the largest integer a JSON number holds exactly in most JSON
+// implementations.
+const maxJSONInt = 1<<53 - 1
+
+// JSONValues returns values in the form Encode accepts from JSON, so that they
+// may be sent as JSON arguments or returned as JSON results: bytes and byte
+// arrays are in hex, and integers beyond 2^53 in magnitude are decimal
+// strings. Other values are returned as they are.
+func JSONValues(values []interface{}) []interface{} {
+	r := make([]interface{}, len(values))
+	for i, v := range values {
+		switch x := v.(type) {
+		case []byte:
+			r[i] = hex.EncodeToString(x)
+			continue
+		case uint64:
+			if x > maxJSONInt {
+				r[i] = strconv.FormatUint(x, 10)
+				continue
+			}
+		case int64:
+			if x > maxJSONInt || x < -maxJSONInt {
+				r[i] = strconv.FormatInt(x, 10)
+				continue
+			}
+		}
+
+		rv := reflect.ValueOf(v)
+		if rv.Kind() == reflect.Array && rv.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			r[i] = hex.EncodeToString(b)
+			continue
+		}
+		r[i] = v
+	}
+	return r
+}
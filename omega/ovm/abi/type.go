@@ -0,0 +1,90 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package abi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kind is the kind of an ABI type.
+type Kind int
+
+const (
+	BoolKind       Kind = iota // bool, 1 byte
+	UintKind                   // uint8 to uint64, little-endian
+	IntKind                    // int8 to int64, little-endian
+	FixedBytesKind             // [N]byte
+	BytesKind                  // []byte
+	StringKind                 // string
+)
+
+// AddressSize is the size of an address in contract data: the net ID
+// followed by the 20-byte hash.
+const AddressSize = 21
+
+// Type is the type of a parameter or return value of a contract function.
+type Type struct {
+	Kind Kind
+
+	// Size is the number of bytes of a value of fixed size types, 0 for
+	// []byte and string.
+	Size int
+
+	name string
+}
+
+// String returns the type as written in a signature.
+func (t Type) String() string {
+	return t.name
+}
+
+// NewType returns the type named s. Types are bool, uint8, uint16, uint32,
+// uint64, int8, int16, int32, int64, byte, [N]byte, []byte and string.
+// address and hash are aliases of [21]byte and [32]byte.
+func NewType(s string) (Type, error) {
+	switch s {
+	case "bool":
+		return Type{Kind: BoolKind, Size: 1, name: s}, nil
+	case "byte", "uint8":
+		return Type{Kind: UintKind, Size: 1, name: s}, nil
+	case "uint16":
+		return Type{Kind: UintKind, Size: 2, name: s}, nil
+	case "uint32":
+		return Type{Kind: UintKind, Size: 4, name: s}, nil
+	case "uint64":
+		return Type{Kind: UintKind, Size: 8, name: s}, nil
+	case "int8":
+		return Type{Kind: IntKind, Size: 1, name: s}, nil
+	case "int16":
+		return Type{Kind: IntKind, Size: 2, name: s}, nil
+	case "int32":
+		return Type{Kind: IntKind, Size: 4, name: s}, nil
+	case "int64":
+		return Type{Kind: IntKind, Size: 8, name: s}, nil
+	case "address":
+		return Type{Kind: FixedBytesKind, Size: AddressSize, name: s}, nil
+	case "hash":
+		return Type{Kind: FixedBytesKind, Size: 32, name: s}, nil
+	case "[]byte":
+		return Type{Kind: BytesKind, name: s}, nil
+	case "string":
+		return Type{Kind: StringKind, name: s}, nil
+	}
+
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]byte") {
+		n, err := strconv.Atoi(s[1 : len(s)-5])
+		if err == nil && n > 0 {
+			return Type{Kind: FixedBytesKind, Size: n, name: s}, nil
+		}
+	}
+
+	return Type{}, fmt.Errorf("unsupported type %q", s)
+}
@@ -1,670 +1,400 @@
-// Copyright 2020 The omega suite Authors. All rights reserved.
-// This file is part of the omega library.
-//
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
 
-package main
+package asm
 
 import (
-	"github.com/btcsuite/btcd/wire/common"
-	"github.com/btcsuite/omega/ovm"
-	//	"io"
-	"math/big"
-
-	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/omegasuite/omega/ovm"
 )
 
-const (
-	BCOperand		= 0x80
-	BCGlobal		= 0x40
-	BCNegative		= 0x08
-	BCHeadOffset	= 0x20
-	BCTailOffset	= 0x10
-)
+// maxDepth is the limit of nesting of included files and macro expansions.
+const maxDepth = 32
 
-func getNums(param []byte, dataType byte) ([]byte, int) {
-	ln := len(param)
-	hex := false
-	nums := [3]int64{0, 0, 0}
-	tmp := int64(0)
-	sign := int64(1)
-	offset := 0
+// Assembler assembles OVM contract code.
+type Assembler struct {
+	// IncludePaths are the directories searched for included files not
+	// found relative to the file including them.
+	IncludePaths []string
 
-	indirects := 0
+	// ReadFile reads source files. ioutil.ReadFile is used if it is nil.
+	ReadFile func(filename string) ([]byte, error)
+}
 
-	head := byte(BCOperand)
-	result := make([]byte, 1, 20)
+// Assemble assembles src with a default Assembler.
+func Assemble(src []byte) ([]byte, error) {
+	code, _, err := new(Assembler).Assemble("", src)
+	return code, err
+}
 
-	for j := 0; j < ln; j++ {
-		switch param[j] {
-		case 0x30, 0x31, 0x32, 0x33, 0x34, 0x35, 0x36, 0x37, 0x38, 0x39: // 0 - 9
-			if hex {
-				tmp = tmp*16 + int64(param[j]-0x30)
-			} else {
-				tmp = tmp*10 + int64(param[j]-0x30)
-			}
-			nums[offset] = tmp
+// Assemble assembles src read from file. It returns the contract code and
+// the source map from instructions to lines of src. Instructions from macros
+// and included files are mapped to the line of src using them.
+func (a *Assembler) Assemble(file string, src []byte) ([]byte, *ovm.SourceMap, error) {
+	s := &assembly{
+		Assembler: a,
+		consts:    make(map[string]string),
+		labels:    make(map[string]int),
+		macros:    make(map[string]*macro),
+	}
+	if err := s.source(file, src, 0, 0, 0); err != nil {
+		return nil, nil, err
+	}
+	return s.code(file)
+}
 
-		case 0x61, 0x62, 0x63, 0x64, 0x65, 0x66: // 0 - 9
-			hex = true
-			tmp = tmp*16 + int64(param[j]-0x61) + 10
-			nums[offset] = tmp
+// AssembleFile assembles the source in file.
+func (a *Assembler) AssembleFile(file string) ([]byte, *ovm.SourceMap, error) {
+	src, err := a.readFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	return a.Assemble(file, src)
+}
 
-		case 0x78: // x
-			hex = true
+func (a *Assembler) readFile(file string) ([]byte, error) {
+	if a.ReadFile != nil {
+		return a.ReadFile(file)
+	}
+	return ioutil.ReadFile(file)
+}
 
-		case 0x6e:	// n
-			head |= BCNegative
-			sign = -1
+// line is a line of source.
+type line struct {
+	text string
+	at   position
+}
 
-		case 0x69:	// i
-			head++
-			indirects++
-			if indirects > 6 {
-				return nil, j
-			}
+// instruction is an assembled instruction before labels are resolved.
+type instruction struct {
+	op       ovm.OpCode
+	operands *text
+	at       position
+	scope    int
+	line     int // line in the main source
+}
 
-		case 0x67:	// g
-			head |= BCGlobal
-
-		case 0x22:	// " - head offset
-			head |= BCHeadOffset
-			offset = 1
-			tmp = 0
-			hex = false
-
-		case 0x27:	// " - tail offset
-			head |= BCTailOffset
-			offset = 2
-			tmp = 0
-			hex = false
-
-		case 0x2c:	// ,
-			if head & 0x7 != 0 {
-				result[0] = head
-				// is indirect, the next number is an 32-bit address
-				var d [4]byte
-				common.LittleEndian.PutUint32(d[:], uint32(nums[0]))
-				result = append(result, d[:]...)
-				if head & BCHeadOffset != 0 {
-					common.LittleEndian.PutUint32(d[:], uint32(nums[1]))
-					result = append(result, d[:]...)
-				}
-				if head & BCTailOffset != 0 {
-					common.LittleEndian.PutUint32(d[:], uint32(nums[2]))
-					result = append(result, d[:]...)
-				}
-				return result, j
-			}
-			head &^= BCNegative
-			result[0] = head
-			tmp = nums[0] * sign
+type macro struct {
+	params []string
+	body   []line
+}
 
-			var d [8]byte
+// assembly is the state of an Assemble call.
+type assembly struct {
+	*Assembler
+	insts      []instruction
+	consts     map[string]string
+	labels     map[string]int
+	macros     map[string]*macro
+	expansions int
+	files      []string // files being assembled, the last included last
+}
 
-			switch dataType {
-			case 0x42:	// byte
-				result = append(result, byte(tmp))
+// scoped returns the key of name defined in scope. Scope 0 is global, other
+// scopes are macro expansions.
+func scoped(name string, scope int) string {
+	if scope == 0 {
+		return name
+	}
+	return name + "@" + strconv.Itoa(scope)
+}
+
+func (s *assembly) constant(scope int) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		if v, ok := s.consts[scoped(name, scope)]; ok {
+			return v, true
+		}
+		v, ok := s.consts[name]
+		return v, ok
+	}
+}
 
-			case 0x57:	// word
-				common.LittleEndian.PutUint16(d[:], uint16(tmp))
-				result = append(result, d[:2]...)
+func (s *assembly) label(name string, scope int) (int, bool) {
+	if pc, ok := s.labels[scoped(name, scope)]; ok {
+		return pc, true
+	}
+	pc, ok := s.labels[name]
+	return pc, ok
+}
+
+// source assembles the lines of src. top is the line of the main source
+// including file, 0 if file is the main source.
+func (s *assembly) source(file string, src []byte, scope, top, depth int) error {
+	var lines []line
+	for i, l := range strings.Split(string(src), "\n") {
+		lines = append(lines, line{
+			text: strings.TrimSuffix(l, "\r"),
+			at:   position{file: file, line: i + 1, col: 1},
+		})
+	}
 
-			case 0x44:	// dword
-				common.LittleEndian.PutUint32(d[:], uint32(tmp))
-				result = append(result, d[:4]...)
+	s.files = append(s.files, file)
+	defer func() { s.files = s.files[:len(s.files)-1] }()
 
-			case 0x51:	// qword
-				common.LittleEndian.PutUint64(d[:], uint64(tmp))
-				result = append(result, d[:]...)
-			}
-			return result, j
-		}
+	return s.lines(lines, scope, top, depth)
+}
+
+// firstWord splits s into its first word and the rest. It returns the word,
+// its column, the rest and the column of the rest.
+func firstWord(s string, col int) (string, int, string, int) {
+	i := 0
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+		i++
+	}
+	j := i
+	for j < len(s) && s[j] != ' ' && s[j] != '\t' {
+		j++
 	}
-	return nil, ln
+	return s[i:j], col + i, s[j:], col + j
 }
 
-func getBigs(param []byte) ([]byte, int) {
-	ln := len(param)
-	hex := false
-	num := *bigZero
-	nums := [3]int64{0, 0, 0}
-	tmp := int64(0)
-	sign := *bigOne
-	offset := 0
-
-	indirects := 0
-
-	head := byte(BCOperand)
-	result := make([]byte, 1, 20)
-
-	for j := 0; j < ln; j++ {
-		switch param[j] {
-		case 0x30, 0x31, 0x32, 0x33, 0x34, 0x35, 0x36, 0x37, 0x38, 0x39: // 0 - 9
-			if offset == 0 {
-				if hex {
-					tmp = tmp*16 + int64(param[j]-0x30)
-					num = *num.Add(num.Mul(&num, big.NewInt(16)), big.NewInt(int64(param[j]-0x30)))
-				} else {
-					tmp = tmp*10 + int64(param[j]-0x30)
-					num = *num.Add(num.Mul(&num, big.NewInt(10)), big.NewInt(int64(param[j]-0x30)))
-				}
-			} else {
-				if hex {
-					tmp = tmp*16 + int64(param[j]-0x30)
-				} else {
-					tmp = tmp*10 + int64(param[j]-0x30)
+// fields splits s by whitespace. Whitespace in quoted strings does not split.
+func fields(s string) []string {
+	var r []string
+	for i := 0; i < len(s); {
+		if s[i] == ' ' || s[i] == '\t' {
+			i++
+			continue
+		}
+		j := i
+		for j < len(s) && s[j] != ' ' && s[j] != '\t' {
+			if s[j] == '"' && stringStart(s, j) {
+				if k := stringEnd(s, j); k > 0 {
+					j = k
+					continue
 				}
 			}
-			nums[offset] = tmp
-
-		case 0x61, 0x62, 0x63, 0x64, 0x65, 0x66: // 0 - 9
-			hex = true
-			num = *num.Add(num.Mul(&num, big.NewInt(16)), big.NewInt(int64(param[j] - 0x30)))
-			tmp = tmp*16 + int64(param[j]-0x61) + 10
-			nums[offset] = tmp
-
-		case 0x78: // x
-			hex = true
-
-		case 0x6e:	// n
-			head |= BCNegative
-			sign = *bigNegOne
-
-		case 0x69:	// i
-			head++
-			indirects++
-			if indirects > 6 {
-				return nil, j
-			}
+			j++
+		}
+		r = append(r, s[i:j])
+		i = j
+	}
+	return r
+}
 
-		case 0x67:	// g
-			head |= BCGlobal
-
-		case 0x22:	// " - head offset
-			head |= BCHeadOffset
-			offset = 1
-			tmp = 0
-			hex = false
-
-		case 0x27:	// " - tail offset
-			head |= BCTailOffset
-			offset = 2
-			tmp = 0
-			hex = false
-
-		case 0x2c:	// ,
-			if head & 0x7 != 0 {
-				result[0] = head
-				// is indirect, the next number is an 32-bit address
-				var d [4]byte
-				common.LittleEndian.PutUint32(d[:], uint32(nums[0]))
-				result = append(result, d[:]...)
-				if head & BCHeadOffset != 0 {
-					common.LittleEndian.PutUint32(d[:], uint32(nums[1]))
-					result = append(result, d[:]...)
-				}
-				if head & BCTailOffset != 0 {
-					common.LittleEndian.PutUint32(d[:], uint32(nums[2]))
-					result = append(result, d[:]...)
-				}
-				return result, j
-			}
-			head &^= BCNegative
-			result[0] = head
-			num = *num.Mul(&num, &sign)
-			h := ovm.BigToHash(&num)
-			result = append(result, h[:]...)
-			return result, j
+// isOpCode returns whether c is an instruction in contract code.
+func isOpCode(c byte) bool {
+	op := ovm.OpCode(c)
+	return ovm.StringToOp(op.String()) == op
+}
+
+// isMnemonic returns whether word is written as an instruction name rather
+// than in the form of contract code.
+func isMnemonic(word string) bool {
+	if len(word) < 2 {
+		return false
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'A' || word[i] > 'Z' {
+			return false
 		}
 	}
-	return nil, ln
+	return true
 }
 
-func getHash(param []byte) ([]byte, int) {
-	ln := len(param)
-	hex := false
-	var num [64]byte
-	d := 0
-	nums := [3]int64{0, 0, 0}
-	tmp := int64(0)
-	offset := 0
-	indirects := 0
-
-	head := byte(BCOperand)
-	result := make([]byte, 1, 40)
-
-	for j := 0; j < ln; j++ {
-		switch param[j] {
-		case 0x30, 0x31, 0x32, 0x33, 0x34, 0x35, 0x36, 0x37, 0x38, 0x39: // 0 - 9
-			if hex {
-				tmp = tmp*16 + int64(param[j]-0x30)
-			} else {
-				tmp = tmp*10 + int64(param[j]-0x30)
+func (s *assembly) lines(lines []line, scope, top, depth int) error {
+	for i := 0; i < len(lines); i++ {
+		l := lines[i]
+		ln := top
+		if ln == 0 {
+			ln = l.at.line
+		}
+
+		text := stripComment(l.text)
+		word, wcol, rest, rcol := firstWord(text, 1)
+		if word == "" {
+			continue
+		}
+		at := position{file: l.at.file, line: l.at.line, col: wcol}
+
+		switch strings.TrimPrefix(word, ".") {
+		case "define":
+			name, ncol, value, vcol := firstWord(rest, rcol)
+			if !isName(name) {
+				return errorf(position{l.at.file, l.at.line, ncol}, "invalid name %q", name)
+			}
+			value = strings.TrimSpace(value)
+			if value == "" {
+				return errorf(at, "missing value of %s", name)
 			}
-			nums[offset] = tmp
-			if offset == 0 {
-				if !hex {
-					return nil, j - 1
+			if value == "." {
+				key := scoped(name, scope)
+				if _, ok := s.labels[key]; ok {
+					return errorf(position{l.at.file, l.at.line, ncol}, "label %s redefined", name)
 				}
-				num[d] = param[j] - 0x30
-				d++
+				s.labels[key] = len(s.insts)
+			} else {
+				v := substitute(value, vcol, s.constant(scope), false, false)
+				s.consts[scoped(name, scope)] = string(v.s)
 			}
 
-		case 0x61, 0x62, 0x63, 0x64, 0x65, 0x66: // 0 - 9
-			hex = true
-			tmp = tmp*16 + int64(param[j]-0x61) + 10
-			nums[offset] = tmp
-			if offset == 0 {
-				num[d] = param[j] - 0x61 + 10
-				d++
+		case "macro":
+			f := fields(rest)
+			if len(f) == 0 || !isName(f[0]) {
+				return errorf(at, "missing or invalid macro name")
 			}
-
-		case 0x78: // x
-			hex = true
-
-		case 0x69:	// i
-			head++
-			indirects++
-			if indirects > 6 {
-				return nil, j
+			if _, ok := s.macros[f[0]]; ok {
+				return errorf(at, "macro %s redefined", f[0])
 			}
-
-		case 0x67:	// g
-			head |= BCGlobal
-
-		case 0x22:	// " - head offset
-			head |= BCHeadOffset
-			offset = 1
-			tmp = 0
-			hex = false
-
-		case 0x27:	// " - tail offset
-			head |= BCTailOffset
-			offset = 2
-			tmp = 0
-			hex = false
-
-		case 0x2c:	// ,
-			if head & 0x7 != 0 {
-				result[0] = head
-				// is indirect, the next number is an 32-bit address
-				var d [4]byte
-				common.LittleEndian.PutUint32(d[:], uint32(nums[0]))
-				result = append(result, d[:]...)
-				if head & BCHeadOffset != 0 {
-					common.LittleEndian.PutUint32(d[:], uint32(nums[1]))
-					result = append(result, d[:]...)
-				}
-				if head & BCTailOffset != 0 {
-					common.LittleEndian.PutUint32(d[:], uint32(nums[2]))
-					result = append(result, d[:]...)
+			m := &macro{params: f[1:]}
+			for _, p := range m.params {
+				if !isName(p) {
+					return errorf(at, "invalid macro parameter %q", p)
 				}
-				return result, j
 			}
-			var h chainhash.Hash
-			for i := 0; i < 32; i++ {
-				if i >= d {
-					i = 32
-					continue
+			j := i + 1
+			for ; j < len(lines); j++ {
+				w, _, _, _ := firstWord(stripComment(lines[j].text), 1)
+				w = strings.TrimPrefix(w, ".")
+				if w == "endm" {
+					break
 				}
-				if i & 1 == 0 {
-					h[i / 2] = num[d - i - 1]
-				} else {
-					h[i / 2] = num[d - i - 1] << 4
+				if w == "macro" {
+					return errorf(lines[j].at, "macro definition in macro %s", f[0])
 				}
+				m.body = append(m.body, line{stripComment(lines[j].text), lines[j].at})
 			}
-			result = append(result, h[:]...)
-			return result, j
-		}
-	}
-	return nil, ln
-}
+			if j == len(lines) {
+				return errorf(at, "macro %s has no endm", f[0])
+			}
+			s.macros[f[0]] = m
+			i = j
 
-func getAddress(param []byte) ([]byte, int) {
-	ln := len(param)
-	hex := false
-	var num [40]byte
-	d := 0
-	nums := [3]int64{0, 0, 0}
-	tmp := int64(0)
-	offset := 0
-	indirects := 0
-
-	head := byte(BCOperand)
-	result := make([]byte, 1, 40)
-
-	for j := 0; j < ln; j++ {
-		switch param[j] {
-		case 0x30, 0x31, 0x32, 0x33, 0x34, 0x35, 0x36, 0x37, 0x38, 0x39: // 0 - 9
-			if hex {
-				tmp = tmp*16 + int64(param[j]-0x30)
-			} else {
-				tmp = tmp*10 + int64(param[j]-0x30)
+		case "endm":
+			return errorf(at, "endm without macro")
+
+		case "include":
+			f := fields(rest)
+			if len(f) != 1 || len(f[0]) < 2 || f[0][0] != '"' || f[0][len(f[0])-1] != '"' {
+				return errorf(at, "expected include \"file\"")
 			}
-			nums[offset] = tmp
-			if offset == 0 {
-				if !hex {
-					return nil, j - 1
-				}
-				num[d] = param[j] - 0x30
-				d++
+			if depth >= maxDepth {
+				return errorf(at, "includes nested too deeply")
 			}
-
-		case 0x61, 0x62, 0x63, 0x64, 0x65, 0x66: // 0 - 9
-			hex = true
-			tmp = tmp*16 + int64(param[j]-0x61) + 10
-			nums[offset] = tmp
-			if offset == 0 {
-				num[d] = param[j] - 0x61 + 10
-				d++
+			file, src, err := s.include(l.at.file, f[0][1:len(f[0])-1])
+			if err != nil {
+				return errorf(at, "%v", err)
 			}
-
-		case 0x78: // x
-			hex = true
-
-		case 0x69:	// i
-			head++
-			indirects++
-			if indirects > 6 {
-				return nil, j
+			for _, g := range s.files {
+				if g == file {
+					return errorf(at, "%s includes itself", file)
+				}
+			}
+			if err := s.source(file, src, scope, ln, depth+1); err != nil {
+				return err
 			}
 
-		case 0x67:	// g
-			head |= BCGlobal
-
-		case 0x22:	// " - head offset
-			head |= BCHeadOffset
-			offset = 1
-			tmp = 0
-			hex = false
-
-		case 0x27:	// " - tail offset
-			head |= BCTailOffset
-			offset = 2
-			tmp = 0
-			hex = false
-
-		case 0x2c:	// ,
-			if head & 0x7 != 0 {
-				result[0] = head
-				// is indirect, the next number is an 32-bit address
-				var d [4]byte
-				common.LittleEndian.PutUint32(d[:], uint32(nums[0]))
-				result = append(result, d[:]...)
-				if head & BCHeadOffset != 0 {
-					common.LittleEndian.PutUint32(d[:], uint32(nums[1]))
-					result = append(result, d[:]...)
-				}
-				if head & BCTailOffset != 0 {
-					common.LittleEndian.PutUint32(d[:], uint32(nums[2]))
-					result = append(result, d[:]...)
+		default:
+			if m, ok := s.macros[word]; ok {
+				if err := s.expand(m, word, rest, at, ln, depth); err != nil {
+					return err
 				}
-				return result, j
+				continue
 			}
-			var h Address
-			for i := 0; i < 32; i++ {
-				if i >= d {
-					i = 32
-					continue
-				}
-				if i & 1 == 0 {
-					h[i / 2] = num[d - i - 1]
-				} else {
-					h[i / 2] = num[d - i - 1] << 4
+
+			op := ovm.StringToOp(word)
+			if op == 0 {
+				if !isOpCode(word[0]) || isMnemonic(word) {
+					return errorf(at, "unknown instruction %s", word)
 				}
+				op = ovm.OpCode(word[0])
+				rest, rcol = word[1:]+rest, wcol+1
 			}
-			result = append(result, h[:]...)
-			return result, j
+			s.insts = append(s.insts, instruction{
+				op:       op,
+				operands: substitute(rest, rcol, s.constant(scope), false, false),
+				at:       at,
+				scope:    scope,
+				line:     ln,
+			})
 		}
 	}
-	return nil, ln
+	return nil
 }
 
-func opEval(param []byte, opType byte) []byte {
-	ln := len(param)
-	dataType := byte(0xFF)
-	result := make([]byte, 0, 20)
-
-	for j := 0; j < ln; j++ {
-		switch param[j] {
-		case 0x30, 0x31, 0x32, 0x33, 0x34, 0x35,
-			0x36, 0x37, 0x38, 0x39, 0x61, 0x62,
-			0x63, 0x64, 0x65, 0x66, 0x78, 0x6e,
-			0x69, 0x67, 0x2c:	// 0 - 9
-			t, tl := getNums(param[j:], dataType)
-			result = append(result, t...)
-			dataType = opType
-			j += tl
-
-		default:
-			result = append(result, param[j])
-		}
+// expand expands macro m, named name, with args at the position at.
+func (s *assembly) expand(m *macro, name, args string, at position, top, depth int) error {
+	f := fields(args)
+	if len(f) != len(m.params) {
+		return errorf(at, "macro %s takes %d arguments, %d given", name, len(m.params), len(f))
+	}
+	if depth >= maxDepth {
+		return errorf(at, "macro %s expanded too deeply", name)
 	}
 
-	return result
-}
-
-func opEval8(param []byte) []byte {
-	return opEval(param, 0x42)
-}
-
-func opEval16(param []byte) []byte {
-	return opEval(param, 0x57)
-}
+	params := make(map[string]string)
+	for i, p := range m.params {
+		params[p] = f[i]
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := params[name]
+		return v, ok
+	}
 
-func opEval32(param []byte) []byte {
-	return opEval(param, 0x44)
-}
+	body := make([]line, len(m.body))
+	for i, l := range m.body {
+		body[i] = line{string(substitute(l.text, 1, lookup, true, true).s), l.at}
+	}
 
-func opEval64(param []byte) []byte {
-	return opEval(param, 0x51)
+	s.expansions++
+	return s.lines(body, s.expansions, top, depth+1)
 }
 
-var (
-	bigZero = big.NewInt(0)
-	bigOne = big.NewInt(1)
-	bigNegOne = big.NewInt(-1)
-)
-
-func opEval256(param []byte) []byte {
-	ln := len(param)
-	dataType := byte(0xFF)
-	result := make([]byte, 0, 20)
-
-	for j := 0; j < ln; j++ {
-		switch param[j] {
-		case 0x30, 0x31, 0x32, 0x33, 0x34, 0x35,
-			0x36, 0x37, 0x38, 0x39, 0x61, 0x62,
-			0x63, 0x64, 0x65, 0x66, 0x78, 0x6e,
-			0x69, 0x67, 0x2c:	// 0 - 9
-			if dataType == 0xFF {
-				p, tl := getNums(param[j:], 0xFF)
-				result = append(result, p...)
-				j += tl
-			} else {
-				h, tl := getBigs(param[j:])
-				result = append(result, h...)
-				j += tl
-			}
-			dataType = 0x48
+// include reads the file name included by from.
+func (s *assembly) include(from, name string) (string, []byte, error) {
+	paths := []string{filepath.Dir(from)}
+	if filepath.IsAbs(name) {
+		paths = []string{""}
+	} else {
+		paths = append(paths, s.IncludePaths...)
+	}
 
-		case 0x2b, 0x2d, 0x2a, 0x2f, 0x25, 0x23,
-			0x7c, 0x26, 0x5e, 0x7e, 0x3e, 0x3c, 0x3d, 0x29, 0x28, 0x21, 0x3f:	// ?
-			result = append(result, param[j])
+	var err error
+	for _, p := range paths {
+		file := filepath.Join(p, name)
+		var src []byte
+		if src, err = s.readFile(file); err == nil {
+			return file, src, nil
+		}
+		if !os.IsNotExist(err) {
+			break
 		}
 	}
-	return result
+	return "", nil, err
 }
 
-func opGeneric(param []byte, dataType []byte) []byte {
-	ln := len(param)
-	result := make([]byte, 0, 20)
-
-	top := 0
-
-	for j := 0; j < ln; j++ {
-		switch param[j] {
-		case 0x30, 0x31, 0x32, 0x33, 0x34, 0x35, 0x36, 0x37, 0x38, 0x39,
-			0x61, 0x62, 0x63, 0x64, 0x65, 0x66, 0x78, 0x69, 0x67:	// 0 - 9
-			if dataType[top] == 0x41 {
-				h, tl := getAddress(param[j:])
-				result = append(result, h...)
-				j += tl
-			} else if dataType[top] == 0x48 {
-				h, tl := getBigs(param[j:])
-				result = append(result, h...)
-				j += tl
-			} else if dataType[top] == 0x68 {
-				h, tl := getHash(param[j:])
-				result = append(result, h...)
-				j += tl
-			} else {
-				t, tl := getNums(param[j:], dataType[top])
-				j += tl
-				result = append(result, t...)
-			}
-			if top < len(dataType) - 1 {
-				top++
-			}
+// code returns the contract code of the instructions assembled.
+func (s *assembly) code(file string) ([]byte, *ovm.SourceMap, error) {
+	var code bytes.Buffer
+	m := &ovm.SourceMap{File: file}
 
-		default:
-			result = append(result, param[j])
+	for pc := range s.insts {
+		in := &s.insts[pc]
+		operands, err := s.translate(in, pc)
+		if err != nil {
+			return nil, nil, err
 		}
+		code.WriteByte(byte(in.op))
+		code.WriteString(operands)
+		code.WriteByte('\n')
+		m.Lines = append(m.Lines, in.line)
 	}
-	return result
-}
-
-func opConv(param []byte) []byte {
-	return opGeneric(param, []byte{0x42, 0xFF, 0x42, 0xFF})
-}
-
-func opHash(param []byte) []byte {
-	return opGeneric(param, []byte{0xFF, 0xFF, 0x44})
-}
-
-func opHash160(param []byte) []byte {
-	return opHash(param)
-}
-
-func opSigCheck(param []byte) []byte {
-	return opGeneric(param, []byte{0xFF, 0x68, 0xFF, 0xFF, 0x44})
-}
-
-func opIf(param []byte) []byte {
-	return opGeneric(param, []byte{0x42, 0x44})
-}
-
-func opCall(param []byte) []byte {
-	return opGeneric(param, []byte{0x41, 0x44, 0x42})
-}
-
-func opLoad(param []byte) []byte {
-	return opGeneric(param, []byte{0xFF, 0x42, 0x68})
-}
-
-func opStore(param []byte) []byte {
-	return opGeneric(param, []byte{0x42, 0x68, 0x68})
-}
-
-func opDel(param []byte) []byte {
-	return opGeneric(param, []byte{0x42, 0x68})
-}
-
-func opReceived(param []byte) []byte {
-	return opGeneric(param, []byte{0xFF})
-}
-
-func opExec(param []byte) []byte {
-	return opGeneric(param, []byte{0xFF, 0x41, 0xFF, 0xFF, 0x44})
-}
 
-func opLibLoad(param []byte) []byte {
-	return opGeneric(param, []byte{0x42, 0x48})
-}
-
-func opMalloc(param []byte) []byte {
-	return opGeneric(param, []byte{0xFF, 0x44})
-}
-
-func opAlloc(param []byte) []byte {
-	return opGeneric(param, []byte{0xFF, 0x44})
-}
-
-func opCopy(param []byte) []byte {
-	return opGeneric(param, []byte{0xFF, 0xFF, 0x44})
-}
-
-func opCopyImm(param []byte) []byte {
-	return opGeneric(param, []byte{0xFF, 0x42})
-}
-
-func opCodeCopy(param []byte) []byte {
-	return opGeneric(param, []byte{0x44, 0xFF, 0x44})
-}
-
-func opSuicide(param []byte) []byte {
-	if len(param) != 0 {
-		return opGeneric(param, []byte{0x41})
+	if pc, err := ovm.ValidateByteCode(code.Bytes()); err != nil {
+		in := &s.insts[pc]
+		return nil, nil, errorf(in.at, "invalid operands %q of %s", string(in.operands.s), in.op)
 	}
-	return []byte{}
-}
-
-func opRevert(param []byte) []byte {
-	return []byte{}
-}
-
-func opStop(param []byte) []byte {
-	return []byte{}
-}
-
-func opReturn(param []byte) []byte {
-	return []byte{}
-}
-
-func opTxIOCount(param []byte) []byte {
-	return opGeneric(param, []byte{0xFF, 0xFF, 0xFF})
-}
-
-func opGetTxIn(param []byte) []byte {
-	return opGeneric(param, []byte{0xFF, 0x44})
-}
-
-func opGetTxOut(param []byte) []byte {
-	return opGeneric(param, []byte{0xFF, 0x44})
-}
-
-func opSpend(param []byte) []byte {
-	return opGeneric(param, []byte{0x44})
-}
-
-func opAddRight(param []byte) []byte {
-	return opGeneric(param, []byte{0xFF})
-}
-
-func opAddTxOut(param []byte) []byte {
-	return opGeneric(param, []byte{0xFF})
-}
-
-func opGetDefinition(param []byte) []byte {
-	return opGeneric(param, []byte{0xFF, 0x68, 0x42})
-}
-
-func opGetCoin(param []byte) []byte {
-	return opGeneric(param, []byte{0xFF})
-}
 
-func opGetUtxo(param []byte) []byte {
-	return opGeneric(param, []byte{0xFF, 0x68, 0x44})
+	return code.Bytes(), m, nil
 }
-
-func opAddSignText(param []byte) []byte {
-	return opGeneric(param, []byte{0x42, 0x68})
-}
-
-func opMint(param []byte) []byte {
-	return opGeneric(param, []byte{0xFF, 0x42, 0x48, 0x68})
-}
-
-func opMeta(param []byte) []byte {
-	return opGeneric(param, []byte{0xFF, 0x42, 0x68})
-}
\ No newline at end of file
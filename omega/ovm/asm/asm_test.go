@@ -0,0 +1,260 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package asm
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/omegasuite/omega/ovm"
+)
+
+// TestBOC ensures the BOC contract source assembles to the code deployed.
+func TestBOC(t *testing.T) {
+	code, m, err := new(Assembler).AssembleFile("../../contracts/BOC.asm")
+	if err != nil {
+		t.Fatalf("AssembleFile: %v", err)
+	}
+
+	bin, err := ioutil.ReadFile("../../contracts/BOC.bin")
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	bin = bytes.Replace(bin, []byte("\r"), nil, -1)
+
+	lines := strings.Split(strings.TrimSuffix(string(code), "\n"), "\n")
+	expected := strings.Split(string(bin), "\n")
+	for i, l := range lines {
+		if l != expected[i] {
+			t.Errorf("inst %d: got %q, expected %q", i, l, expected[i])
+		}
+	}
+	if len(m.Lines) != len(lines) || m.Line(9) != 10 {
+		t.Errorf("unexpected source map %v", m.Lines)
+	}
+}
+
+func TestAssemble(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		code string
+	}{
+		{
+			name: "raw",
+			src:  "Cgi0,4,\nz\n",
+			code: "Cgi0,4,\nz\n",
+		},
+		{
+			name: "operands",
+			src:  "META gi4, 4, \"mint\",\t; comment\nSTORE abi(\"owner\"),D0,\n",
+			code: "kgi4,4,x6d696e74,\nOx04000100,D0,\n",
+		},
+		{
+			name: "constants",
+			src: "define tmp gii0\"16\ndefine amount gi8\n" +
+				"EVAL64 Qamount,tmp,\ndefine tmp gii0'80\nEVAL32 tmp,0,\n",
+			code: "DQgi8,gii0\"16,\nCgii0'80,0,\n",
+		},
+		{
+			name: "labels",
+			src: "EVAL32 i4,BODY,\nIF i0,.end,\ndefine loop .\nEVAL32 i0,i0,1,-\n" +
+				"IF i0,.loop,\ndefine end .\nSTOP\ndefine BODY .\nRETURN\n",
+			code: "Ci4,5,\nKi0,3,\nCi0,i0,1,-\nKi0,n1,\nz\nY\n",
+		},
+		{
+			name: "macro",
+			src: "macro DISPATCH sig target\nEVAL32 gii0\"16,abi(sig),gi8,=\n" +
+				"IF gii0\"16,.target,\nendm\nDISPATCH \"owner\" get\nDISPATCH \"oracle\" get\n" +
+				"REVERT\ndefine get .\nSTOP\n",
+			code: "Cgii0\"16,x04000100,gi8,=\nKgii0\"16,4,\n" +
+				"Cgii0\"16,x09020002,gi8,=\nKgii0\"16,2,\nX\nz\n",
+		},
+		{
+			name: "local labels",
+			src: ".macro SKIP cond\nIF cond,.skip,\nREVERT\ndefine skip .\n.endm\n" +
+				"SKIP i0\nSKIP i4\nSTOP\n",
+			code: "Ki0,2,\nX\nKi4,2,\nX\nz\n",
+		},
+	}
+
+	for _, test := range tests {
+		code, err := Assemble([]byte(test.src))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if string(code) != test.code {
+			t.Errorf("%s: got %q, expected %q", test.name, code, test.code)
+		}
+	}
+}
+
+func TestInclude(t *testing.T) {
+	files := map[string]string{
+		"main.asm":          "include \"abi.inc\"\nREVERT\ndefine owner .\nLOAD gi0,_OWNER,\nSTOP\n",
+		"abi.inc":           ".include \"consts.inc\"\nEVAL32 gii0\"16,_OWNER,gi8,=\nIF gii0\"16,.owner,\n",
+		"lib/consts.inc":    "define _OWNER abi(\"owner\")\n",
+		"lib/recursive.asm": "include \"recursive.asm\"\n",
+	}
+	a := &Assembler{
+		IncludePaths: []string{"lib"},
+		ReadFile: func(name string) ([]byte, error) {
+			if src, ok := files[name]; ok {
+				return []byte(src), nil
+			}
+			return nil, os.ErrNotExist
+		},
+	}
+
+	code, m, err := a.AssembleFile("main.asm")
+	if err != nil {
+		t.Fatalf("AssembleFile: %v", err)
+	}
+	expected := "Cgii0\"16,x04000100,gi8,=\nKgii0\"16,2,\nX\nNgi0,x04000100,\nz\n"
+	if string(code) != expected {
+		t.Errorf("got %q, expected %q", code, expected)
+	}
+	if m.Line(0) != 1 || m.Line(1) != 1 || m.Line(2) != 2 || m.Line(4) != 5 {
+		t.Errorf("unexpected source map %v", m.Lines)
+	}
+
+	if _, _, err := a.AssembleFile("lib/recursive.asm"); err == nil {
+		t.Error("expected recursive include to fail")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		src string
+		err string
+	}{
+		{"STOP\n  FOO i0,\n", "<input>:2:3: unknown instruction FOO"},
+		{"EVAL32 i0,4,\nIF i0,  .missing,\n", "<input>:2:9: undefined label missing"},
+		{"STOP\nEVAL32 i0,\n", "<input>:2:1: invalid operands \"i0,\" of EVAL32"},
+		{"META gi4,4,\"mint,\n", "<input>:1:12: unterminated string"},
+		{"STORE abi(owner),D0,\n", "<input>:1:7: malformed abi(), expected abi(\"signature\")"},
+		{"define x .\ndefine x .\n", "<input>:2:8: label x redefined"},
+		{"macro M a\nSTOP\n", "<input>:1:1: macro M has no endm"},
+		{"macro M a\nIF a,1,\nendm\nM\n", "<input>:4:1: macro M takes 1 arguments, 0 given"},
+		{"endm\n", "<input>:1:1: endm without macro"},
+	}
+
+	for _, test := range tests {
+		_, err := Assemble([]byte(test.src))
+		if err == nil {
+			t.Errorf("%q: expected error %s", test.src, test.err)
+			continue
+		}
+		if _, ok := err.(*Error); !ok {
+			t.Errorf("%q: error %v is not an *Error", test.src, err)
+		}
+		if err.Error() != test.err {
+			t.Errorf("%q: got error %q, expected %q", test.src, err.Error(), test.err)
+		}
+	}
+}
+
+// TestDisassemble ensures disassembled code assembles to the same code.
+func TestDisassemble(t *testing.T) {
+	code, _, err := new(Assembler).AssembleFile("../../contracts/BOC.asm")
+	if err != nil {
+		t.Fatalf("AssembleFile: %v", err)
+	}
+
+	src := ovm.Disassemble(code, []string{"owner", "oracle", "minted()uint64"})
+	if !bytes.Contains(src, []byte(`STORE abi("owner"),`)) {
+		t.Errorf("method code not shown as abi():\n%s", src)
+	}
+
+	c, err := Assemble(src)
+	if err != nil {
+		t.Fatalf("Assemble: %v", err)
+	}
+	if !bytes.Equal(c, code) {
+		t.Errorf("got\n%s\nexpected\n%s", c, code)
+	}
+}
+
+// TestDisassembleRoundTrip ensures the code of every contract in the repo
+// assembles to the same code once disassembled, with and without the method
+// codes shown as abi().
+func TestDisassembleRoundTrip(t *testing.T) {
+	files, err := filepath.Glob("../../contracts/*.asm")
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	signatures := []string{"owner", "oracle", "minted()uint64",
+		"issue([21]byte,uint64,[]byte)", "transfer([21]byte,uint64)"}
+
+	n := 0
+	for _, file := range files {
+		code, _, err := new(Assembler).AssembleFile(file)
+		if err != nil {
+			// contracts in the old dialect do not assemble
+			continue
+		}
+		n++
+
+		for _, sigs := range [][]string{nil, signatures} {
+			src := ovm.Disassemble(code, sigs)
+			c, err := Assemble(src)
+			if err != nil {
+				t.Errorf("%s: Assemble: %v", file, err)
+				continue
+			}
+			if !bytes.Equal(c, code) {
+				t.Errorf("%s: got\n%s\nexpected\n%s", file, c, code)
+			}
+		}
+	}
+	if n == 0 {
+		t.Fatal("no contract assembled")
+	}
+}
+
+// TestDisassembleOperands ensures operands in every syntax of the assembler
+// round trip, and that only immediates are shown as abi().
+func TestDisassembleOperands(t *testing.T) {
+	owner := ovm.ABILiteral("owner")
+	tests := []string{
+		"Cgi0,4,\n",
+		"Cgi0\"8,ii4'2,\n",
+		"kgi4,4,x6d696e74,\n",
+		"O" + owner + ",D0,\n",
+		"Cgi0,i" + owner + ",\n",
+		"Cn" + owner[1:] + ",4,\n",
+		"rx01020304,gi0,4,\n",
+		"z\n",
+	}
+
+	for _, code := range tests {
+		src := ovm.Disassemble([]byte(code), []string{"owner"})
+		c, err := Assemble(src)
+		if err != nil {
+			t.Errorf("%q: Assemble %q: %v", code, src, err)
+			continue
+		}
+		if string(c) != code {
+			t.Errorf("%q: disassembled to %q, assembled to %q", code, src, c)
+		}
+	}
+
+	src := ovm.Disassemble([]byte("Cgi0,i"+owner+",\n"), []string{"owner"})
+	if bytes.Contains(src, []byte("abi(")) {
+		t.Errorf("pointer operand shown as abi(): %s", src)
+	}
+	if want := `abi("owner")`; !bytes.Contains(ovm.Disassemble([]byte("O"+owner+",D0,\n"), []string{"owner"}), []byte(want)) {
+		t.Errorf("immediate method code not shown as %s", want)
+	}
+}
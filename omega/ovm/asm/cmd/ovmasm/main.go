@@ -0,0 +1,113 @@
+// Copyright (C) 2019-2021 Omegasuite developer
+// Use of this code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Ovmasm assembles OVM contract source into contract code, or disassembles
+// contract code with -d.
+//
+//	ovmasm [-I dir]... [-o output] file.asm
+//	ovmasm -d [-abi file] [-o output] file.bin
+//
+// Unless -o is given, code is written to file.bin and disassembly to stdout.
+// Use -o - for stdout.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/omegasuite/omega/ovm"
+	"github.com/omegasuite/omega/ovm/asm"
+)
+
+type includePaths []string
+
+func (p *includePaths) String() string {
+	return strings.Join(*p, string(os.PathListSeparator))
+}
+
+func (p *includePaths) Set(s string) error {
+	*p = append(*p, s)
+	return nil
+}
+
+// signatures reads function signatures from file, one on a line.
+func signatures(file string) ([]string, error) {
+	if file == "" {
+		return nil, nil
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var sigs []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if s := strings.TrimSpace(scanner.Text()); s != "" {
+			sigs = append(sigs, s)
+		}
+	}
+	return sigs, scanner.Err()
+}
+
+func main() {
+	var includes includePaths
+	flag.Var(&includes, "I", "directory to search for included files")
+	output := flag.String("o", "", "output file")
+	disasm := flag.Bool("d", false, "disassemble contract code")
+	abi := flag.String("abi", "", "file of function signatures, one on a line, to show method codes as abi() when disassembling")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: ovmasm [-d] [-I dir]... [-abi file] [-o output] file")
+		os.Exit(2)
+	}
+	input := flag.Arg(0)
+
+	var result []byte
+	out := *output
+	if *disasm {
+		if out == "" {
+			out = "-"
+		}
+		code, err := ioutil.ReadFile(input)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		sigs, err := signatures(*abi)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		result = ovm.Disassemble(bytes.Replace(code, []byte("\r"), nil, -1), sigs)
+	} else {
+		a := &asm.Assembler{IncludePaths: includes}
+		code, _, err := a.AssembleFile(input)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		result = code
+	}
+
+	if out == "" {
+		out = strings.TrimSuffix(input, filepath.Ext(input)) + ".bin"
+	}
+	if out == "-" {
+		os.Stdout.Write(result)
+		return
+	}
+	if err := ioutil.WriteFile(out, result, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,44 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+/*
+Package asm implements an assembler of OVM contract code.
+
+Source is written an instruction per line, as an instruction name followed by
+its operands in the same syntax as in contract code, e.g.
+
+	EVAL32 gii0"16,abi("transfer([21]byte,uint64)"),gi8,=	; comment
+
+An instruction may also be written in the form of contract code, e.g.
+Cgi0,4, for EVAL32 gi0,4,. Text following a ';' is a comment. Besides the
+operand syntax of contract code, operands may use:
+
+	abi("sig")	the method code of function signature sig
+	"text"		the ASCII bytes of text as a hex number
+	.name		offset from the current instruction to label name
+	name		the value of constant name or the PC of label name
+
+A name may follow a data type letter, e.g. Qamount for Q followed by the value
+of amount. The following directives are recognized, with or without a leading
+'.':
+
+	define name value	define a constant used by the lines that follow
+	define name .		define a label at the instruction that follows
+	macro name params...	begin the definition of a macro
+	endm			end the definition of a macro
+	include "file"		assemble file in place of the line
+
+A line starting with the name of a macro expands to the lines of the macro
+with params replaced by the arguments that follow it, separated by
+whitespace. Labels and constants defined in a macro are local to an
+expansion. An included file is looked up relative to the file including it,
+then in the include paths of the Assembler.
+
+Errors are reported as *Error, giving the file, line and column at fault.
+*/
+package asm
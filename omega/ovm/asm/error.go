@@ -0,0 +1,46 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package asm
+
+import "fmt"
+
+// Error is an error in assembly source.
+type Error struct {
+	File string
+	Line int
+	Col  int
+	Msg  string
+}
+
+// Error satisfies the error interface and prints the position of the error
+// in the form file:line:col.
+func (e *Error) Error() string {
+	file := e.File
+	if file == "" {
+		file = "<input>"
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", file, e.Line, e.Col, e.Msg)
+}
+
+// position is a location in source.
+type position struct {
+	file string
+	line int
+	col  int
+}
+
+// errorf returns an *Error at p.
+func errorf(p position, format string, args ...interface{}) *Error {
+	return &Error{
+		File: p.file,
+		Line: p.line,
+		Col:  p.col,
+		Msg:  fmt.Sprintf(format, args...),
+	}
+}
@@ -0,0 +1,244 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package asm
+
+import (
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/omegasuite/omega/ovm"
+)
+
+// typePrefix are the data type letters a name may follow in an operand.
+const typePrefix = "BWDQHRrhkK"
+
+// text is operand text with the source column of each byte.
+type text struct {
+	s    []byte
+	cols []int
+}
+
+// add appends s to t. All bytes of s are attributed to col.
+func (t *text) add(s string, col int) {
+	for i := 0; i < len(s); i++ {
+		t.s = append(t.s, s[i])
+		t.cols = append(t.cols, col)
+	}
+}
+
+// col returns the source column of t.s[i].
+func (t *text) col(i int) int {
+	if i < len(t.cols) {
+		return t.cols[i]
+	}
+	if len(t.cols) > 0 {
+		return t.cols[len(t.cols)-1] + 1
+	}
+	return 1
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
+
+// isName returns whether s is a valid name of a constant, label or macro.
+func isName(s string) bool {
+	if s == "" || !isNameStart(s[0]) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if !isNameChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// nameEnd returns the end of the name starting at s[i].
+func nameEnd(s string, i int) int {
+	for i < len(s) && isNameChar(s[i]) {
+		i++
+	}
+	return i
+}
+
+// stringStart returns whether the '"' at s[i] begins a quoted string. Inside
+// an operand, such as gii0"16, it is the tail offset mark.
+func stringStart(s string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	switch s[i-1] {
+	case ' ', '\t', ',', '(':
+		return true
+	}
+	return false
+}
+
+// stringEnd returns the index following the quoted string starting at s[i],
+// -1 if the string is not terminated.
+func stringEnd(s string, i int) int {
+	j := strings.IndexByte(s[i+1:], '"')
+	if j < 0 {
+		return -1
+	}
+	return i + j + 2
+}
+
+// stripComment returns s without its comment.
+func stripComment(s string) string {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			if stringStart(s, i) {
+				if j := stringEnd(s, i); j > 0 {
+					i = j - 1
+				}
+			}
+		case ';':
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// substitute replaces the names in s that lookup finds. A name may follow a
+// data type letter, e.g. Qamount. Quoted strings are left alone, so are label
+// references of the form .name unless dot is set. Whitespace is removed
+// unless keepSpace is set. col is the column of s[0].
+func substitute(s string, col int, lookup func(string) (string, bool), dot, keepSpace bool) *text {
+	t := &text{}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			if keepSpace {
+				t.add(s[i:i+1], col+i)
+			}
+			i++
+
+		case c == '"' && stringStart(s, i):
+			j := stringEnd(s, i)
+			if j < 0 {
+				j = len(s)
+			}
+			for ; i < j; i++ {
+				t.add(s[i:i+1], col+i)
+			}
+
+		case c == '.' && i+1 < len(s) && isNameStart(s[i+1]):
+			j := nameEnd(s, i+1)
+			t.add(".", col+i)
+			if v, ok := lookup(s[i+1 : j]); ok && dot {
+				t.add(v, col+i+1)
+			} else {
+				for k := i + 1; k < j; k++ {
+					t.add(s[k:k+1], col+k)
+				}
+			}
+			i = j
+
+		case isNameStart(c):
+			j := nameEnd(s, i)
+			name := s[i:j]
+			if v, ok := lookup(name); ok {
+				t.add(v, col+i)
+			} else if v, ok := lookup(name[1:]); ok && len(name) > 1 &&
+				strings.IndexByte(typePrefix, c) >= 0 {
+				t.add(name[:1], col+i)
+				t.add(v, col+i+1)
+			} else {
+				for k := i; k < j; k++ {
+					t.add(s[k:k+1], col+k)
+				}
+			}
+			i = j
+
+		default:
+			t.add(s[i:i+1], col+i)
+			i++
+		}
+	}
+	return t
+}
+
+// translate returns the operands of in as in contract code. pc is the
+// position of in in the code.
+func (s *assembly) translate(in *instruction, pc int) (string, error) {
+	t := in.operands
+	src := string(t.s)
+	at := func(i int) position {
+		return position{file: in.at.file, line: in.at.line, col: t.col(i)}
+	}
+
+	var r strings.Builder
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '"' && stringStart(src, i):
+			j := stringEnd(src, i)
+			if j < 0 {
+				return "", errorf(at(i), "unterminated string")
+			}
+			if j == i+2 {
+				return "", errorf(at(i), "empty string")
+			}
+			r.WriteString("x" + hex.EncodeToString([]byte(src[i+1:j-1])))
+			i = j
+
+		case strings.HasPrefix(src[i:], "abi("):
+			j := i + 4
+			k := -1
+			if j < len(src) && src[j] == '"' {
+				k = stringEnd(src, j)
+			}
+			if k < 0 || k >= len(src) || src[k] != ')' {
+				return "", errorf(at(i), "malformed abi(), expected abi(\"signature\")")
+			}
+			r.WriteString(ovm.ABILiteral(src[j+1 : k-1]))
+			i = k + 1
+
+		case c == '.' && i+1 < len(src) && isNameStart(src[i+1]):
+			j := nameEnd(src, i+1)
+			target, ok := s.label(src[i+1:j], in.scope)
+			if !ok {
+				return "", errorf(at(i), "undefined label %s", src[i+1:j])
+			}
+			if offset := target - pc; offset < 0 {
+				r.WriteString("n" + strconv.Itoa(-offset))
+			} else {
+				r.WriteString(strconv.Itoa(offset))
+			}
+			i = j
+
+		case isNameStart(c):
+			j := nameEnd(src, i)
+			name := src[i:j]
+			if target, ok := s.label(name, in.scope); ok {
+				r.WriteString(strconv.Itoa(target))
+			} else if target, ok := s.label(name[1:], in.scope); ok && len(name) > 1 &&
+				strings.IndexByte(typePrefix, c) >= 0 {
+				r.WriteString(name[:1] + strconv.Itoa(target))
+			} else {
+				r.WriteString(name)
+			}
+			i = j
+
+		default:
+			r.WriteByte(c)
+			i++
+		}
+	}
+	return r.String(), nil
+}
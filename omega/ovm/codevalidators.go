@@ -10,6 +10,8 @@ package ovm
 
 import (
 	"regexp"
+
+	"github.com/omegasuite/omega"
 )
 
 var patOperand = regexp.MustCompile(`^@*[BWDQkKrR@ngi]*(([xa-f][0-9a-f]+)|([0-9]+))(\'[0-9]+)?(\"[0-9]+)?,`)
@@ -648,3 +650,21 @@ var formatLog = []formatDesc{
 func opLogValidator(param []byte) int {
 	return formatParser(formatLog, param)
 }
+
+var formatEvent = []formatDesc{
+	{patOperand, 0}, {addrOperand, 0xFFFFFFFF}, {patOperand, MaxEventDataSize},
+}
+
+func opEventValidator(param []byte) int {
+	return formatParser(formatEvent, param)
+}
+
+// upgradeValidator validates the code replacing the code of a contract. It
+// must follow the same instruction rules as code of a new contract, and may
+// not be empty as the contract would be left without code.
+func upgradeValidator(code []inst, version uint32) omega.Err {
+	if len(code) == 0 {
+		return omega.ScriptError(omega.ErrInternal, "Empty contract code.")
+	}
+	return ByteCodeValidator(code, version)
+}
@@ -15,6 +15,7 @@ import (
 
 	//	"github.com/omegasuite/btcd/chaincfg/chainhash"
 	"github.com/omegasuite/omega/token"
+	"math"
 	"math/big"
 )
 
@@ -251,10 +252,35 @@ var validators = map[OpCode]codeValidator {
 	VERSION: opVersionValidator,
 	TOKENCONTRACT: opTokenContractValidator,
 	LOG: opLogValidator,
+	EVENT: opEventValidator,
 }
 
-func ByteCodeValidator(code []inst) omega.Err {
+// ValidateByteCode validates contract code against the instructions of the
+// latest block version. If the code is invalid, it returns the index of the
+// first illegal instruction and the error.
+func ValidateByteCode(code []byte) (int, omega.Err) {
+	insts := ByteCodeParser(code)
+	for i, c := range insts {
+		if err := ByteCodeValidator(insts[i : i+1], math.MaxUint32); err != nil {
+			// a jump is validated against the whole code
+			if v, ok := validators[c.op]; ok {
+				if offset := v(c.param); i+offset >= 0 && i+offset <= len(insts) {
+					continue
+				}
+			}
+			return i, err
+		}
+	}
+	return -1, nil
+}
+
+// ByteCodeValidator validates contract code against the instructions valid
+// in blocks of version.
+func ByteCodeValidator(code []inst, version uint32) omega.Err {
 	for i, c := range code {
+		if omegaInstructionSet[c.op].version > version {
+			return omega.ScriptError(omega.ErrInternal,fmt.Sprintf("Instruction %c is not valid in block version %x.", c.op, version))
+		}
 		if v, ok := validators[c.op]; ok {
 			offset := v(c.param)
 			if i+offset < 0 || i+offset > len(code) {
@@ -114,15 +114,16 @@ func calcSignatureHash(txinidx int, script []byte, vm * OVM) (chainhash.Hash, er
 
 // there are 2 sig verify methods: one in interpreter, one is here. the differernce is that
 // the one in interpreter is intended for client side. here is for the miner. here, verification
-// is deeper in that it checks monitering status, a tx will be rejected if monitor checing fails
-// while it may pass interpreter verification because only signature verification is done there
+// is deeper in that it checks monitering status (from Version7, see CheckMonitors), a tx will be
+// rejected if monitor checing fails while it may pass interpreter verification because only
+// signature verification is done there
 
 // sig verification includes all pk script type, e.g. multi sig, pkscripthash
 var zerohash chainhash.Hash
 
 var e error
 
-func VerifySigs(tx *btcutil.Tx, param *chaincfg.Params, skip int, views *viewpoint.ViewPointSet) omega.Err {
+func VerifySigs(tx *btcutil.Tx, param *chaincfg.Params, skip int, views *viewpoint.ViewPointSet, blkVersion uint32) omega.Err {
 	if tx.IsCoinBase() {
 		return nil
 	}
@@ -145,7 +146,10 @@ func VerifySigs(tx *btcutil.Tx, param *chaincfg.Params, skip int, views *viewpoi
 	}
 	
 	if nsigs == 0 {
-		return nil
+		if blkVersion < wire.Version7 {
+			return nil
+		}
+		return CheckMonitors(tx, param, skip, views, blkVersion)
 	}
 
 	// set up for concurrent execution
@@ -282,8 +286,9 @@ func VerifySigs(tx *btcutil.Tx, param *chaincfg.Params, skip int, views *viewpoi
 			break
 		}
 
-		// check if it is monitored
-		if utxo.TokenType == 3 {
+		// check if it is monitored. from Version7, monitors are called by
+		// CheckMonitors instead
+		if blkVersion < wire.Version7 && utxo.TokenType == 3 {
 			y := validate.TokenRights(views, utxo)
 
 			for _, r := range y {
@@ -427,7 +432,10 @@ func VerifySigs(tx *btcutil.Tx, param *chaincfg.Params, skip int, views *viewpoi
 		return omega.ScriptError(omega.ErrInternal, "Signature incorrect.")
 	}
 
-	return nil
+	if blkVersion < wire.Version7 {
+		return nil
+	}
+	return CheckMonitors(tx, param, skip, views, blkVersion)
 }
 
 func isContract(netid byte) bool {
@@ -531,7 +539,7 @@ func (ovm * OVM) TryContract(tx *btcutil.Tx, txHeight int32) ([]byte, error) {
 		}
 		switch t.(type) {
 		case *token.RightDef:
-			ovm.views.AddRight(t.(*token.RightDef))
+			ovm.views.AddRight(t.(*token.RightDef), ovm.BlockVersion())
 		case *token.RightSetDef:
 			ovm.views.Rights.AddRightSet(t.(*token.RightSetDef))
 		}
@@ -631,6 +639,56 @@ func (ovm * OVM) TryContract(tx *btcutil.Tx, txHeight int32) ([]byte, error) {
 	return nil, nil
 }
 
+// RunOutput executes the contract call in output idx of the transaction set up
+// by Context.Init and returns the call's return data. Unlike TryContract, the
+// state changes are kept in StateDB and written to the database by Commit. The
+// context functions (AddDef, BlockNumber etc.) must have been set by caller.
+func (ovm * OVM) RunOutput(idx int) ([]byte, omega.Err) {
+	tx := ovm.GetTx()
+	if tx == nil || idx < 0 || idx >= len(tx.MsgTx().TxOut) {
+		return nil, omega.ScriptError(omega.ErrInternal, "Output index out of range.")
+	}
+
+	txOut := tx.MsgTx().TxOut[idx]
+	version, addr, method, param := parsePkScript(txOut.PkScript)
+	if !isContract(version) || len(method) < 4 {
+		return nil, omega.ScriptError(omega.ErrInternal, "Output is not a contract call.")
+	}
+
+	var d Address
+	copy(d[:], addr)
+
+	creation := bytes.Compare(method, []byte{0,0,0,0}) == 0
+
+	if _,ok := ovm.StateDB[d]; !ok {
+		t := NewStateDB(ovm.views.Db, d)
+
+		if !t.Exists(true) && !creation {
+			err := omega.ScriptError(omega.ErrInternal, "Contract does not exist.")
+			err.ErrorLevel = omega.RecoverableLevel
+			return nil, err
+		}
+		if t.Exists(false) && creation {
+			return nil, omega.ScriptError(omega.ErrInternal, "Attempt to recreate a contract.")
+		}
+
+		ovm.StateDB[d] = t
+	} else if creation {
+		return nil, omega.ScriptError(omega.ErrInternal, "Attempt to recreate a contract.")
+	}
+
+	hash := *tx.Hash()
+	ovm.GetCurrentOutput = func() wire.OutPoint {
+		return wire.OutPoint{hash, uint32(idx)}
+	}
+
+	ovm.NoLoop = false
+	ovm.writeback = true
+	ovm.contractStack = []Address{d}
+
+	return ovm.Call(d, method, &txOut.Token, param, 0)
+}
+
 func (ovm * OVM) ExecContract(tx *btcutil.Tx, txHeight int32) (bool, omega.Err) {
 	// no need to make a copy of tx, if exec fails, the tx (even a block) will be abandoned
 	if tx.IsCoinBase() {
@@ -654,7 +712,7 @@ func (ovm * OVM) ExecContract(tx *btcutil.Tx, txHeight int32) (bool, omega.Err)
 
 		switch t.(type) {
 		case *token.RightDef:
-			ovm.views.AddRight(t.(*token.RightDef))
+			ovm.views.AddRight(t.(*token.RightDef), ovm.BlockVersion())
 		case *token.RightSetDef:
 			ovm.views.Rights.AddRightSet(t.(*token.RightSetDef))
 		}
@@ -713,6 +771,7 @@ func (ovm * OVM) ExecContract(tx *btcutil.Tx, txHeight int32) (bool, omega.Err)
 	savedTx := *tx.MsgTx().Copy()
 	haves := []bool {tx.HasDefs, tx.HasIns, tx.HasOuts}
 	hash := *tx.Hash()
+	events := len(ovm.events)
 
 	intx := len(tx.MsgTx().TxIn)
 
@@ -739,6 +798,7 @@ func (ovm * OVM) ExecContract(tx *btcutil.Tx, txHeight int32) (bool, omega.Err)
 			// we need to restore Tx
 			tx.HasDefs, tx.HasIns, tx.HasOuts = haves[0], haves[1], haves[2]
 			*tx.MsgTx() = savedTx
+			ovm.events = ovm.events[:events]
 			return false, err
 		}
 
@@ -748,6 +808,7 @@ func (ovm * OVM) ExecContract(tx *btcutil.Tx, txHeight int32) (bool, omega.Err)
 	if len(tx.MsgTx().TxOut) > wire.MaxTxOutPerMessage || len(tx.MsgTx().TxIn) > wire.MaxTxInPerMessage {
 		tx.HasDefs, tx.HasIns, tx.HasOuts = haves[0], haves[1], haves[2]
 		*tx.MsgTx() = savedTx
+		ovm.events = ovm.events[:events]
 		return false, omega.ScriptError(omega.ErrInternal, "Tx in/out exceeds the max.")
 	}
 
@@ -760,8 +821,9 @@ func (ovm * OVM) ExecContract(tx *btcutil.Tx, txHeight int32) (bool, omega.Err)
 			}
 		}
 		if needsv {
-			err := VerifySigs(tx, ovm.chainConfig, intx, ovm.views)
+			err := VerifySigs(tx, ovm.chainConfig, intx, ovm.views, ovm.BlockVersion())
 			if err != nil {
+				ovm.events = ovm.events[:events]
 				return false, err
 			}
 		}
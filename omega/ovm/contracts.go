@@ -10,6 +10,7 @@ package ovm
 
 import (
 	"fmt"
+	"github.com/omegasuite/btcd/wire"
 	"github.com/omegasuite/btcd/wire/common"
 	"github.com/omegasuite/btcutil"
 	"github.com/omegasuite/omega"
@@ -47,10 +48,22 @@ type codebytes struct {
 	contract *Contract
 }
 
+type upgrade struct {
+	ovm * OVM
+	contract *Contract
+}
+
+type upgradedelay struct {
+	ovm * OVM
+	contract *Contract
+}
+
 const (
 	OP_CREATE				= 0
 	OP_META					= 1
 	OP_CODEBYTES			= 2
+	OP_UPGRADE				= 3		// replace contract code. owner only
+	OP_UPGRADEDELAY			= 4		// set time lock of upgrades. owner only
 	OP_OWNER				= 0x10		// User supplied standard func. returns address of contract owner
 	OP_INIT					= 1		// User supplied standard func. for lib initialization. it's ok to
 							// hasve the same value as op meta because init is called automatically
@@ -84,6 +97,14 @@ var PrecompiledContracts = map[[4]byte]func(evm * OVM, contract *Contract) Preco
 		contract.Code = nil
 		return &codebytes{evm, contract}
 	},			// OP_CODEBYTES
+	([4]byte{OP_UPGRADE, 0, 0, 0}): func(evm * OVM, contract *Contract) PrecompiledContract {
+		contract.Code = nil
+		return &upgrade{evm, contract}
+	},			// upgrade contract code
+	([4]byte{OP_UPGRADEDELAY, 0, 0, 0}): func(evm * OVM, contract *Contract) PrecompiledContract {
+		contract.Code = nil
+		return &upgradedelay{evm, contract}
+	},			// set upgrade time lock
 	// pk script functions
 	([4]byte{OP_PAY2PKH, 0, 0, 0}): func(evm * OVM, contract *Contract) PrecompiledContract {
 		return &pay2pkh{}
@@ -104,6 +125,23 @@ var PrecompiledContracts = map[[4]byte]func(evm * OVM, contract *Contract) Preco
 	},			// pay to anyone
 }
 
+// PrecompileVersions are the block versions from which the pre-compiled
+// contracts added later are registered. Below its version, a call to the abi
+// of one of them runs the code of the contract as any other call.
+var PrecompileVersions = map[[4]byte]uint32 {
+	([4]byte{OP_UPGRADE, 0, 0, 0}):      wire.Version7,
+	([4]byte{OP_UPGRADEDELAY, 0, 0, 0}): wire.Version7,
+}
+
+// precompiledContract returns the pre-compiled contract of abi registered in
+// blocks of version, or nil.
+func precompiledContract(abi [4]byte, version uint32) func(evm * OVM, contract *Contract) PrecompiledContract {
+	if v, ok := PrecompileVersions[abi]; ok && version < v {
+		return nil
+	}
+	return PrecompiledContracts[abi]
+}
+
 type payanyone struct {}
 
 func (p *payanyone) Run(input []byte, vunits []vunit) ([]byte, omega.Err) {
@@ -449,4 +487,12 @@ func (c *meta) Run(input []byte, _ []vunit) ([]byte, omega.Err) {
 
 func (c *codebytes) Run(input []byte, _ []vunit) ([]byte, omega.Err) {
 	return c.ovm.GetCode(c.contract.self.Address()), nil
+}
+
+func (c *upgrade) Run(input []byte, _ []vunit) ([]byte, omega.Err) {
+	return c.ovm.Upgrade(input[4:], c.contract)
+}
+
+func (c *upgradedelay) Run(input []byte, _ []vunit) ([]byte, omega.Err) {
+	return c.ovm.SetUpgradeDelay(input[4:], c.contract)
 }
\ No newline at end of file
@@ -0,0 +1,737 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package ovm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/omegasuite/btcd/wire/common"
+	"github.com/omegasuite/omega"
+)
+
+// DebugEventKind identifies why a debug session is notified.
+type DebugEventKind byte
+
+const (
+	DebugAttached   = DebugEventKind(iota) // a contract starts to run in the session
+	DebugBreakpoint                        // a breakpoint or a NOP inst. is hit
+	DebugStep                              // a single step is done
+	DebugReturn                            // returned to caller after an 'up' command
+	DebugTerminated                        // contract execution is over
+	DebugDetached                          // session is closed
+)
+
+var debugEventNames = map[DebugEventKind]string{
+	DebugAttached:   "attached",
+	DebugBreakpoint: "breakpoint",
+	DebugStep:       "step",
+	DebugReturn:     "return",
+	DebugTerminated: "terminated",
+	DebugDetached:   "detached",
+}
+
+func (k DebugEventKind) String() string {
+	if s, ok := debugEventNames[k]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown DebugEventKind (%d)", int(k))
+}
+
+// WatchValue is the value of a watch expression at the time an event is sent.
+type WatchValue struct {
+	Expr  string
+	Value []byte
+	Err   error
+}
+
+// DebugEvent is sent to the notifier of a debug session whenever the state of
+// the contract under debugging changes. The execution is paused on all events
+// except DebugTerminated and DebugDetached, and stays paused until the session
+// receives Continue, Step, StepOut or Terminate.
+type DebugEvent struct {
+	Session  uint32
+	Kind     DebugEventKind
+	Contract Address
+	Creation bool // whether the contract is being created
+	PC       int
+	Line     int   // source line of PC, 0 if no source map is set
+	Stack    []int // return addresses, innermost first
+	Watches  []WatchValue
+	Result   []byte // return data of a terminated contract
+	Err      error  // error of a terminated contract
+}
+
+// SourceMap maps the instructions (PCs) of a contract to the lines in the
+// assembly source they are written. Lines are 1 based.
+type SourceMap struct {
+	File  string
+	Lines []int
+}
+
+// NewSourceMap returns the source map of code written directly in the
+// text format accepted by ByteCodeParser, where every line is an instruction.
+func NewSourceMap(file string, code []byte) *SourceMap {
+	m := &SourceMap{File: file}
+	for i := range ByteCodeParser(code) {
+		m.Lines = append(m.Lines, i+1)
+	}
+	return m
+}
+
+// Line returns the source line of inst. pc, 0 if unknown.
+func (m *SourceMap) Line(pc int) int {
+	if m == nil || pc < 0 || pc >= len(m.Lines) {
+		return 0
+	}
+	return m.Lines[pc]
+}
+
+// PC returns the first instruction written at or after line, -1 if none.
+func (m *SourceMap) PC(line int) int {
+	if m == nil {
+		return -1
+	}
+	pc := -1
+	for i, l := range m.Lines {
+		if l >= line && (pc < 0 || l < m.Lines[pc]) {
+			pc = i
+		}
+	}
+	return pc
+}
+
+// memExpr is a reference to the memory of the contract under debugging. It is
+// written as an indirect operand in the same syntax as in contract code followed
+// by a ':' and either a data type (B, W, D, Q, H, R) or a byte count. If the
+// size is omitted, a dword is assumed. e.g. "gi4,:Q" is the global qword at 4,
+// "ii8,:B" is the byte pointed by the pointer at 8.
+type memExpr struct {
+	operand  []byte
+	size     int
+	dataType byte
+}
+
+func parseMemExpr(s string) (*memExpr, error) {
+	s = strings.TrimSpace(s)
+	e := &memExpr{size: 4, dataType: 'D'}
+
+	if n := strings.IndexByte(s, ':'); n >= 0 {
+		t := strings.TrimSpace(s[n+1:])
+		s = strings.TrimSpace(s[:n])
+
+		if len(t) == 1 && sizeOfType[t[0]] != 0 {
+			e.dataType, e.size = t[0], int(sizeOfType[t[0]])
+		} else if n, err := strconv.Atoi(t); err == nil && n > 0 {
+			e.dataType, e.size = 0, n
+		} else {
+			return nil, fmt.Errorf("Invalid data size %s", t)
+		}
+	}
+
+	if len(s) == 0 {
+		return nil, fmt.Errorf("Missing operand")
+	}
+	if s[len(s)-1] != ',' {
+		s += ","
+	}
+	for _, c := range []byte(s[:len(s)-1]) {
+		if !strings.ContainsRune("0123456789abcdefxnig'\"", rune(c)) {
+			return nil, fmt.Errorf("Malformed operand %s", s)
+		}
+	}
+	if !strings.ContainsRune(s, 'i') {
+		return nil, fmt.Errorf("Operand %s is not a memory reference", s)
+	}
+	e.operand = []byte(s)
+
+	return e, nil
+}
+
+// read returns the memory referenced by e.
+func (e *memExpr) read(stack *Stack) ([]byte, error) {
+	p, _, err := stack.getNum(e.operand, 0xFF)
+	if err != nil {
+		return nil, err
+	}
+
+	return readMemory(stack, int32(p>>32), uint32(p), e.size)
+}
+
+// number returns the value of a numeric expression.
+func (e *memExpr) number(stack *Stack) (int64, error) {
+	b, err := e.read(stack)
+	if err != nil {
+		return 0, err
+	}
+
+	switch e.dataType {
+	case 'B':
+		return int64(b[0]), nil
+	case 'W':
+		return int64(int16(common.LittleEndian.Uint16(b))), nil
+	case 'D':
+		return int64(int32(common.LittleEndian.Uint32(b))), nil
+	case 'Q':
+		return int64(common.LittleEndian.Uint64(b)), nil
+	}
+	return 0, fmt.Errorf("%s is not a number", e.operand)
+}
+
+func readMemory(stack *Stack, frame int32, offset uint32, n int) ([]byte, error) {
+	f, ok := stack.data[frame]
+	if !ok {
+		return nil, fmt.Errorf("Frame %d does not exist", frame)
+	}
+	if int(offset)+n > len(f.space) {
+		return nil, fmt.Errorf("Address %d out of range in frame %d", offset, frame)
+	}
+
+	r := make([]byte, n)
+	copy(r, f.space[offset:])
+	return r, nil
+}
+
+// condition is the condition of a breakpoint in the form of
+// "<memory expression> <comparison> <number>", e.g. "gi4,:W >= 0x10". The
+// expression must be of type B, W, D or Q.
+type condition struct {
+	text  string
+	left  *memExpr
+	cmp   string
+	right int64
+}
+
+func parseCondition(s string) (*condition, error) {
+	for _, cmp := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		n := strings.Index(s, cmp)
+		if n < 0 {
+			continue
+		}
+
+		left, err := parseMemExpr(s[:n])
+		if err != nil {
+			return nil, err
+		}
+		if left.dataType == 0 || sizeOfType[left.dataType] > 8 {
+			return nil, fmt.Errorf("Condition must be on a number")
+		}
+
+		right, err := strconv.ParseInt(strings.TrimSpace(s[n+len(cmp):]), 0, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		return &condition{text: s, left: left, cmp: cmp, right: right}, nil
+	}
+	return nil, fmt.Errorf("Missing comparison operator in condition %s", s)
+}
+
+func (c *condition) eval(stack *Stack) (bool, error) {
+	v, err := c.left.number(stack)
+	if err != nil {
+		return false, err
+	}
+
+	switch c.cmp {
+	case "==":
+		return v == c.right, nil
+	case "!=":
+		return v != c.right, nil
+	case "<=":
+		return v <= c.right, nil
+	case ">=":
+		return v >= c.right, nil
+	case "<":
+		return v < c.right, nil
+	default:
+		return v > c.right, nil
+	}
+}
+
+// Breakpoint is a breakpoint set in a debug session.
+type Breakpoint struct {
+	PC        int
+	Condition string // empty for an unconditional breakpoint
+	Hits      int
+
+	cond *condition
+}
+
+const (
+	debugRun = iota
+	debugStep
+	debugStepOut
+	debugAttach
+)
+
+var errDebugTerminated = omega.ScriptError(omega.ErrInternal, "Execution terminated by debugger")
+
+// Debugger is a debug session. A session debugs contract at an address, or
+// any contract if the address is zero. Each session debugs one contract
+// execution at a time, contracts run while the session is busy are not
+// debugged by it. Sessions are independent of each other.
+type Debugger struct {
+	id       uint32
+	contract Address
+	notify   func(*DebugEvent)
+
+	mtx         sync.Mutex
+	closed      bool
+	idleTimeout time.Duration
+	busy        int32 // set atomically
+	source      *SourceMap
+	breakpoints map[int]*Breakpoint
+	watches     []string
+	mode        int
+	outDepth    int32
+	terminate   bool
+
+	// state of the contract under debugging
+	running *Contract
+	stack   *Stack
+	pc      int
+	paused  bool
+	resume  chan struct{}
+	quit    chan struct{}
+}
+
+// debug sessions
+var debuggers = struct {
+	sync.Mutex
+	sessions map[uint32]*Debugger
+	last     uint32
+}{sessions: make(map[uint32]*Debugger)}
+
+var numDebuggers int32
+
+// NewDebugger creates a debug session for contract at address, or any contract
+// if address is zero. Events of the session are sent to notify which must not
+// block for long as it is called from the contract execution.
+func NewDebugger(address Address, notify func(*DebugEvent)) *Debugger {
+	d := &Debugger{
+		contract:    address,
+		notify:      notify,
+		breakpoints: make(map[int]*Breakpoint),
+		resume:      make(chan struct{}, 1),
+		quit:        make(chan struct{}),
+	}
+
+	debuggers.Lock()
+	debuggers.last++
+	d.id = debuggers.last
+	debuggers.sessions[d.id] = d
+	debuggers.Unlock()
+
+	atomic.AddInt32(&numDebuggers, 1)
+
+	return d
+}
+
+// FindDebugger returns the debug session of id, nil if not exists.
+func FindDebugger(id uint32) *Debugger {
+	debuggers.Lock()
+	defer debuggers.Unlock()
+	return debuggers.sessions[id]
+}
+
+// acquireDebugger returns an idle session for contract at address and marks it
+// busy. Sessions for the specific address are preferred.
+func acquireDebugger(address Address) *Debugger {
+	debuggers.Lock()
+	defer debuggers.Unlock()
+
+	var catchall *Debugger
+	for _, d := range debuggers.sessions {
+		if atomic.LoadInt32(&d.busy) != 0 {
+			continue
+		}
+		if d.contract == address {
+			if atomic.CompareAndSwapInt32(&d.busy, 0, 1) {
+				return d
+			}
+		} else if d.contract == (Address{}) && catchall == nil {
+			catchall = d
+		}
+	}
+	if catchall != nil && atomic.CompareAndSwapInt32(&catchall.busy, 0, 1) {
+		return catchall
+	}
+	return nil
+}
+
+// ID returns the session id.
+func (d *Debugger) ID() uint32 {
+	return d.id
+}
+
+// Close ends the session. A paused contract continues to run without
+// debugging.
+func (d *Debugger) Close() {
+	d.mtx.Lock()
+	if d.closed {
+		d.mtx.Unlock()
+		return
+	}
+	d.closed = true
+	close(d.quit)
+	d.mtx.Unlock()
+
+	debuggers.Lock()
+	delete(debuggers.sessions, d.id)
+	debuggers.Unlock()
+
+	atomic.AddInt32(&numDebuggers, -1)
+
+	d.notify(&DebugEvent{Session: d.id, Kind: DebugDetached, Contract: d.contract})
+}
+
+// SetIdleTimeout sets how long a paused contract waits for a command. When it
+// expires, the session is closed and the contract continues. A zero timeout,
+// the default, waits until the session is closed.
+func (d *Debugger) SetIdleTimeout(timeout time.Duration) {
+	d.mtx.Lock()
+	d.idleTimeout = timeout
+	d.mtx.Unlock()
+}
+
+// IdleTimeout returns the idle timeout of the session, zero if none.
+func (d *Debugger) IdleTimeout() time.Duration {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	return d.idleTimeout
+}
+
+// SetSource sets the source map used to report source lines in events.
+func (d *Debugger) SetSource(m *SourceMap) {
+	d.mtx.Lock()
+	d.source = m
+	d.mtx.Unlock()
+}
+
+// Source returns the source map of the session.
+func (d *Debugger) Source() *SourceMap {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	return d.source
+}
+
+// SetBreakpoint sets a breakpoint at pc. If cond is not empty, execution breaks
+// only when the condition holds.
+func (d *Debugger) SetBreakpoint(pc int, cond string) error {
+	bp := &Breakpoint{PC: pc, Condition: cond}
+	if cond != "" {
+		c, err := parseCondition(cond)
+		if err != nil {
+			return err
+		}
+		bp.cond = c
+	}
+
+	d.mtx.Lock()
+	d.breakpoints[pc] = bp
+	d.mtx.Unlock()
+
+	return nil
+}
+
+// ClearBreakpoint removes the breakpoint at pc.
+func (d *Debugger) ClearBreakpoint(pc int) {
+	d.mtx.Lock()
+	delete(d.breakpoints, pc)
+	d.mtx.Unlock()
+}
+
+// Breakpoints returns the breakpoints set in the session.
+func (d *Debugger) Breakpoints() []Breakpoint {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	r := make([]Breakpoint, 0, len(d.breakpoints))
+	for _, bp := range d.breakpoints {
+		r = append(r, *bp)
+	}
+	return r
+}
+
+// AddWatch adds a watch expression. The values of all watch expressions are
+// reported in every event the contract is paused.
+func (d *Debugger) AddWatch(expr string) error {
+	if _, err := parseMemExpr(expr); err != nil {
+		return err
+	}
+
+	d.mtx.Lock()
+	d.watches = append(d.watches, expr)
+	d.mtx.Unlock()
+
+	return nil
+}
+
+// RemoveWatch removes a watch expression.
+func (d *Debugger) RemoveWatch(expr string) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	for i, w := range d.watches {
+		if w == expr {
+			d.watches = append(d.watches[:i], d.watches[i+1:]...)
+			return
+		}
+	}
+}
+
+// Continue resumes execution until the next breakpoint.
+func (d *Debugger) Continue() error {
+	return d.command(debugRun)
+}
+
+// Step executes one instruction.
+func (d *Debugger) Step() error {
+	return d.command(debugStep)
+}
+
+// StepOut resumes execution until the current function returns.
+func (d *Debugger) StepOut() error {
+	return d.command(debugStepOut)
+}
+
+// Terminate stops the contract with an error. A running contract is stopped
+// before its next instruction.
+func (d *Debugger) Terminate() error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	d.terminate = true
+	if d.paused {
+		d.resumeLocked(debugRun)
+	}
+	return nil
+}
+
+func (d *Debugger) command(mode int) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if !d.paused {
+		return fmt.Errorf("Contract is not paused")
+	}
+
+	d.resumeLocked(mode)
+	return nil
+}
+
+// resumeLocked resumes the paused contract. Must be called with mtx held.
+func (d *Debugger) resumeLocked(mode int) {
+	d.mode = mode
+	if mode == debugStepOut {
+		d.outDepth = d.stack.callTop
+	}
+
+	d.paused = false
+	d.resume <- struct{}{}
+}
+
+// Evaluate returns the value of a memory expression of the paused contract.
+func (d *Debugger) Evaluate(expr string) ([]byte, error) {
+	e, err := parseMemExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if !d.paused {
+		return nil, fmt.Errorf("Contract is not paused")
+	}
+	return e.read(d.stack)
+}
+
+// Memory returns n bytes at offset in a stack frame of the paused contract.
+func (d *Debugger) Memory(frame int32, offset uint32, n int) ([]byte, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if !d.paused {
+		return nil, fmt.Errorf("Contract is not paused")
+	}
+	return readMemory(d.stack, frame, offset, n)
+}
+
+// CallStack returns the PC and return addresses of the paused contract,
+// innermost first.
+func (d *Debugger) CallStack() ([]int, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if !d.paused {
+		return nil, fmt.Errorf("Contract is not paused")
+	}
+	return append([]int{d.pc}, d.returns()...), nil
+}
+
+func (d *Debugger) returns() []int {
+	r := make([]int, 0, d.stack.callTop)
+	for i := d.stack.callTop; i > 0; i-- {
+		r = append(r, d.stack.data[i].pc)
+	}
+	return r
+}
+
+// event builds an event for the current state. Must be called with mtx held.
+func (d *Debugger) event(kind DebugEventKind) *DebugEvent {
+	ev := &DebugEvent{
+		Session:  d.id,
+		Kind:     kind,
+		Contract: d.running.Address(),
+		Creation: d.running.isnew,
+		PC:       d.pc,
+		Line:     d.source.Line(d.pc),
+		Stack:    d.returns(),
+	}
+
+	for _, w := range d.watches {
+		v := WatchValue{Expr: w}
+		if e, err := parseMemExpr(w); err != nil {
+			v.Err = err
+		} else {
+			v.Value, v.Err = e.read(d.stack)
+		}
+		ev.Watches = append(ev.Watches, v)
+	}
+
+	return ev
+}
+
+// attach starts debugging of contract. The contract is paused before its first
+// instruction.
+func (d *Debugger) attach(contract *Contract, stack *Stack) {
+	d.mtx.Lock()
+	d.running, d.stack, d.mode, d.terminate = contract, stack, debugAttach, false
+	d.mtx.Unlock()
+}
+
+// terminated ends debugging of the contract and makes the session available
+// for the next contract.
+func (d *Debugger) terminated(ret []byte, err error) {
+	d.mtx.Lock()
+	ev := &DebugEvent{
+		Session:  d.id,
+		Kind:     DebugTerminated,
+		Contract: d.running.Address(),
+		Creation: d.running.isnew,
+		PC:       d.pc,
+		Line:     d.source.Line(d.pc),
+		Result:   ret,
+		Err:      err,
+	}
+	d.running, d.stack = nil, nil
+	closed := d.closed
+	d.mtx.Unlock()
+
+	atomic.StoreInt32(&d.busy, 0)
+
+	if !closed {
+		d.notify(ev)
+	}
+}
+
+// trace is called before inst. pc is executed. It pauses the contract when
+// it hits a breakpoint or the requested step is done.
+func (d *Debugger) trace(pc int, op OpCode, stack *Stack) omega.Err {
+	d.mtx.Lock()
+
+	if d.closed {
+		d.mtx.Unlock()
+		return nil
+	}
+	if d.terminate {
+		d.mtx.Unlock()
+		return errDebugTerminated
+	}
+
+	kind := DebugEventKind(0xFF)
+
+	switch {
+	case d.mode == debugAttach:
+		kind = DebugAttached
+
+	case d.mode == debugStep:
+		kind = DebugStep
+
+	case d.mode == debugStepOut && stack.callTop < d.outDepth:
+		kind = DebugReturn
+
+	case op == NOP:
+		kind = DebugBreakpoint
+
+	default:
+		// breakpoints are for the code of contract, not libs it loads
+		bp, ok := d.breakpoints[pc]
+		if ok && stack.data[stack.data[stack.callTop].gbase].inlib == [20]byte{} {
+			hit := true
+			if bp.cond != nil {
+				hit, _ = bp.cond.eval(stack)
+			}
+			if hit {
+				bp.Hits++
+				kind = DebugBreakpoint
+			}
+		}
+	}
+
+	d.mtx.Unlock()
+
+	if kind == 0xFF {
+		return nil
+	}
+	return d.pause(kind, pc)
+}
+
+// pause notifies the session and waits for a command.
+func (d *Debugger) pause(kind DebugEventKind, pc int) omega.Err {
+	d.mtx.Lock()
+	if d.closed {
+		d.mtx.Unlock()
+		return nil
+	}
+	d.pc, d.paused = pc, true
+	ev := d.event(kind)
+	timeout := d.idleTimeout
+	d.mtx.Unlock()
+
+	d.notify(ev)
+
+	var idle <-chan time.Time
+	if timeout > 0 {
+		idle = time.After(timeout)
+	}
+
+	select {
+	case <-d.resume:
+	case <-d.quit:
+	case <-idle:
+		log.Infof("Debug session %d idle for %s, closed.", d.id, timeout)
+		d.Close()
+	}
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	d.paused = false
+	if d.terminate {
+		return errDebugTerminated
+	}
+	return nil
+}
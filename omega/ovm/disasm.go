@@ -0,0 +1,120 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package ovm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/omegasuite/omega"
+	"github.com/omegasuite/omega/ovm/abi"
+)
+
+// MethodSelector returns the 4-byte method code of a contract function with
+// signature such as "transfer(address,uint64)", in the order it is in the
+// pkScript of a contract call.
+func MethodSelector(signature string) [4]byte {
+	return abi.MethodID(signature)
+}
+
+// ABILiteral returns the operand abi(signature) stands for in assembly source.
+// The method code is at input offset 8, so contract code compares it as a
+// little-endian dword.
+func ABILiteral(signature string) string {
+	s := MethodSelector(signature)
+	return fmt.Sprintf("x%08x", binary.LittleEndian.Uint32(s[:]))
+}
+
+// operand characters that may precede a number in the same operand. A number
+// following any of them is not an immediate.
+const operandPrefix = "0123456789abcdefxnig'\"hHkKrR"
+
+// Disassemble returns contract code as assembly source in the dialect of
+// omega/ovm/asm. Each instruction is on a line followed by a comment of its PC.
+// Immediate numbers equal to the method code of one of signatures are shown as
+// abi("signature"). Assembling the result gives the same instructions as in
+// code.
+func Disassemble(code []byte, signatures []string) []byte {
+	abi := make(map[string]string)
+	for _, s := range signatures {
+		l := ABILiteral(s)
+		if _, ok := abi[l]; !ok {
+			abi[l] = s
+		}
+	}
+
+	var r bytes.Buffer
+	for pc, c := range ByteCodeParser(code) {
+		var line bytes.Buffer
+
+		if name, ok := opCodeToString[c.op]; ok {
+			line.WriteString(name)
+			if len(c.param) > 0 {
+				line.WriteByte(' ')
+			}
+		} else {
+			line.WriteByte(byte(c.op))
+		}
+		line.Write(disasmParam(c.param, abi))
+
+		pad := 1
+		if line.Len() < 32 {
+			pad = 32 - line.Len()
+		}
+		line.WriteString(strings.Repeat(" ", pad))
+		fmt.Fprintf(&line, "; %d", pc)
+
+		r.Write(line.Bytes())
+		r.WriteByte('\n')
+	}
+	return r.Bytes()
+}
+
+// disasmParam returns param with immediates of method codes replaced by abi().
+func disasmParam(param []byte, abi map[string]string) []byte {
+	if len(abi) == 0 {
+		return param
+	}
+
+	var r []byte
+	for j := 0; j < len(param); j++ {
+		if param[j] != 'x' || (j > 0 && strings.IndexByte(operandPrefix, param[j-1]) >= 0) {
+			r = append(r, param[j])
+			continue
+		}
+
+		k := j + 1
+		for k < len(param) && strings.IndexByte("0123456789abcdef", param[k]) >= 0 {
+			k++
+		}
+		if k < len(param) && param[k] == ',' {
+			if s, ok := abi[string(param[j:k])]; ok {
+				r = append(r, "abi(\""+s+"\")"...)
+				j = k - 1
+				continue
+			}
+		}
+		r = append(r, param[j])
+	}
+	return r
+}
+
+// DisassembleContract returns the code of contract at address d as assembly
+// source.
+func (ovm *OVM) DisassembleContract(d Address, signatures []string) ([]byte, omega.Err) {
+	code := ovm.GetCode(d)
+	if code == nil {
+		err := omega.ScriptError(omega.ErrInternal, "code not found")
+		err.ErrorLevel = omega.RecoverableLevel
+		return nil, err
+	}
+	return Disassemble(code, signatures), nil
+}
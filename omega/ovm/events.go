@@ -0,0 +1,130 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package ovm
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/database"
+)
+
+// ContractEventsBucket maps transaction hashes to the events emitted by the
+// contracts the transactions call.
+var ContractEventsBucket = []byte("contractevents")
+
+// MaxEventDataSize is the largest data an event may carry.
+const MaxEventDataSize = 4096
+
+// eventHeaderSize is the size of the serialized contract, topic and data
+// length of an event.
+const eventHeaderSize = 20 + 4 + 4
+
+var errEventCorrupt = errors.New("corrupt contract event record")
+
+// Event is a record emitted by a contract with the EVENT instruction. Topic
+// identifies the kind of event, usually the abi.Topic of a signature such as
+// "Transfer([21]byte,[21]byte,uint64)", and Data are its values.
+type Event struct {
+	TxHash   chainhash.Hash
+	Contract Address
+	Topic    uint32
+	Data     []byte
+}
+
+// emit records an event of the transaction being executed.
+func (v *OVM) emit(contract Address, topic uint32, data []byte) {
+	e := Event{Contract: contract, Topic: topic, Data: data}
+	if v.GetTx != nil {
+		if tx := v.GetTx(); tx != nil {
+			e.TxHash = *tx.Hash()
+		}
+	}
+	v.events = append(v.events, e)
+}
+
+// Events returns the events emitted by the transactions executed since the
+// last Commit.
+func (v *OVM) Events() []Event {
+	return v.events
+}
+
+// PutEvents writes the events emitted by the transactions executed since the
+// last Commit to the database, keyed by transaction.
+func (v *OVM) PutEvents(dbTx database.Tx) error {
+	if len(v.events) == 0 {
+		return nil
+	}
+
+	bucket, err := dbTx.Metadata().CreateBucketIfNotExists(ContractEventsBucket)
+	if err != nil {
+		return err
+	}
+
+	records := make(map[chainhash.Hash][]byte)
+	for _, e := range v.events {
+		records[e.TxHash] = appendEvent(records[e.TxHash], &e)
+	}
+	for h, r := range records {
+		if err := bucket.Put(h[:], r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendEvent(b []byte, e *Event) []byte {
+	var n [4]byte
+	b = append(b, e.Contract[:]...)
+	binary.LittleEndian.PutUint32(n[:], e.Topic)
+	b = append(b, n[:]...)
+	binary.LittleEndian.PutUint32(n[:], uint32(len(e.Data)))
+	b = append(b, n[:]...)
+	return append(b, e.Data...)
+}
+
+// FetchTxEvents returns the events emitted by the transaction with hash in
+// the order they were emitted.
+func FetchTxEvents(dbTx database.Tx, hash *chainhash.Hash) ([]Event, error) {
+	bucket := dbTx.Metadata().Bucket(ContractEventsBucket)
+	if bucket == nil {
+		return nil, nil
+	}
+
+	b := bucket.Get(hash[:])
+	var events []Event
+	for len(b) > 0 {
+		if len(b) < eventHeaderSize {
+			return nil, errEventCorrupt
+		}
+		e := Event{TxHash: *hash}
+		copy(e.Contract[:], b)
+		e.Topic = binary.LittleEndian.Uint32(b[20:])
+		n := int(binary.LittleEndian.Uint32(b[24:]))
+		b = b[eventHeaderSize:]
+		if len(b) < n {
+			return nil, errEventCorrupt
+		}
+		e.Data = make([]byte, n)
+		copy(e.Data, b)
+		b = b[n:]
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// DbRemoveTxEvents removes the events of the transaction with hash.
+func DbRemoveTxEvents(dbTx database.Tx, hash *chainhash.Hash) error {
+	bucket := dbTx.Metadata().Bucket(ContractEventsBucket)
+	if bucket == nil {
+		return nil
+	}
+	return bucket.Delete(hash[:])
+}
@@ -0,0 +1,229 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package ovm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"github.com/omegasuite/btcd/database"
+)
+
+// StorageHistoryBucketName is the name of the bucket indexing the storage
+// changes in the rollback records by contract, key and height. The entry of a
+// key at a height holds the value of the key before the block at the height,
+// empty if there was none. The entry under createdKey of a contract holds the
+// height it was created at.
+var StorageHistoryBucketName = []byte("storagehistory")
+
+// createdKey is in place of the key length of the entry holding the height a
+// contract was created at.
+var createdKey = []byte{0xff, 0xff, 0xff, 0xff}
+
+// historyPrefix returns the prefix of the storage history entries of key of
+// contract: the contract address, key length and key.
+func historyPrefix(contract Address, key []byte) []byte {
+	prefix := make([]byte, len(contract)+4+len(key))
+	copy(prefix, contract[:])
+	binary.BigEndian.PutUint32(prefix[len(contract):], uint32(len(key)))
+	copy(prefix[len(contract)+4:], key)
+	return prefix
+}
+
+// historyKey returns the key of the storage history entry of key of contract
+// at height. Heights are big-endian so that entries sort by height.
+func historyKey(contract Address, key []byte, height uint64) []byte {
+	prefix := historyPrefix(contract, key)
+	k := make([]byte, len(prefix)+8)
+	copy(k, prefix)
+	binary.BigEndian.PutUint64(k[len(prefix):], height)
+	return k
+}
+
+// createdHistoryKey returns the key of the entry holding the height contract
+// was created at.
+func createdHistoryKey(contract Address) []byte {
+	return append(append([]byte{}, contract[:]...), createdKey...)
+}
+
+// dbPutStorageHistory indexes the storage changes of the rollback record of
+// the block at height.
+func dbPutStorageHistory(dbTx database.Tx, height uint64, rollBacks *BlockRollBack) error {
+	bucket := dbTx.Metadata().Bucket(StorageHistoryBucketName)
+	if bucket == nil {
+		return fmt.Errorf("storage history is not indexed")
+	}
+
+	for _, rb := range rollBacks.RollBacks {
+		if rb.NewContract {
+			var h [8]byte
+			binary.BigEndian.PutUint64(h[:], height)
+			if err := bucket.Put(createdHistoryKey(rb.Addr), h[:]); err != nil {
+				return err
+			}
+		}
+		for _, d := range rb.Data[0] {
+			if err := bucket.Put(historyKey(rb.Addr, d.Key, height), d.Value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dbRemoveStorageHistory removes the entries of the rollback record of the
+// block at height from the storage history. All the entries of a contract
+// created by the block are removed.
+func dbRemoveStorageHistory(dbTx database.Tx, height uint64, rollBacks *BlockRollBack) error {
+	bucket := dbTx.Metadata().Bucket(StorageHistoryBucketName)
+	if bucket == nil {
+		return fmt.Errorf("storage history is not indexed")
+	}
+
+	for _, rb := range rollBacks.RollBacks {
+		var keys [][]byte
+		if rb.NewContract {
+			c := bucket.Cursor()
+			for ok := c.Seek(rb.Addr[:]); ok && bytes.HasPrefix(c.Key(), rb.Addr[:]); ok = c.Next() {
+				keys = append(keys, append([]byte{}, c.Key()...))
+			}
+		} else {
+			for _, d := range rb.Data[0] {
+				keys = append(keys, historyKey(rb.Addr, d.Key, height))
+			}
+		}
+		for _, k := range keys {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// DbBuildStorageHistory creates the storage history if it does not exist and
+// indexes the storage changes of all the rollback records in the database.
+// It is used to upgrade databases created before the history was indexed.
+func DbBuildStorageHistory(dbTx database.Tx) error {
+	if _, err := dbTx.Metadata().CreateBucketIfNotExists(StorageHistoryBucketName); err != nil {
+		return err
+	}
+
+	for block := DbFetchVersion(dbTx, []byte("lastCommitBlock")); block > 0; {
+		rollBacks, err := fetchRollback(dbTx, block)
+		if err != nil {
+			return err
+		}
+		if err := dbPutStorageHistory(dbTx, block, rollBacks); err != nil {
+			return err
+		}
+		if rollBacks.PrevBlock >= block {
+			return fmt.Errorf("corrupt rollback record at height %d", block)
+		}
+		block = rollBacks.PrevBlock
+	}
+	return nil
+}
+
+// rollbackKey returns the key of the rollback record of the block at height.
+func rollbackKey(height uint64) []byte {
+	var rbkey [16]byte
+	copy(rbkey[:], []byte("Rollback"))
+	binary.LittleEndian.PutUint64(rbkey[8:], height)
+	return rbkey[:]
+}
+
+// fetchRollback returns the rollback record of the block at height.
+func fetchRollback(dbTx database.Tx, height uint64) (*BlockRollBack, error) {
+	data := dbTx.Metadata().Get(rollbackKey(height))
+	if data == nil {
+		return nil, fmt.Errorf("no rollback record at height %d", height)
+	}
+
+	rollBacks := &BlockRollBack{}
+	if err := json.Unmarshal(data, rollBacks); err != nil {
+		return nil, err
+	}
+	return rollBacks, nil
+}
+
+// StorageAt returns the storage of contract as it was after the block at
+// height was committed. If keys is nil, the whole storage is returned,
+// otherwise only the entries of keys that existed. Missing entries are
+// absent from the map.
+//
+// The value of a key is that of its first entry in the storage history above
+// height, or its current value if it has not changed since. An error is
+// returned if the contract did not exist at height.
+func StorageAt(dbTx database.Tx, contract Address, keys [][]byte, height uint64) (map[string][]byte, error) {
+	bucket := dbTx.Metadata().Bucket([]byte("storage" + string(contract[:])))
+	if bucket == nil {
+		return nil, fmt.Errorf("contract %x does not exist", contract)
+	}
+	history := dbTx.Metadata().Bucket(StorageHistoryBucketName)
+	if history == nil {
+		return nil, fmt.Errorf("storage history is not indexed")
+	}
+	if created := history.Get(createdHistoryKey(contract)); len(created) == 8 &&
+		binary.BigEndian.Uint64(created) > height {
+		return nil, fmt.Errorf("contract %x did not exist at height %d", contract, height)
+	}
+
+	storage := make(map[string][]byte)
+	set := func(k, v []byte) {
+		if len(v) == 0 {
+			delete(storage, string(k))
+		} else {
+			storage[string(k)] = append([]byte{}, v...)
+		}
+	}
+
+	if keys != nil {
+		c := history.Cursor()
+		for _, k := range keys {
+			prefix := historyPrefix(contract, k)
+			if c.Seek(historyKey(contract, k, height+1)) && bytes.HasPrefix(c.Key(), prefix) &&
+				len(c.Key()) == len(prefix)+8 {
+				set(k, c.Value())
+			} else {
+				set(k, bucket.Get(k))
+			}
+		}
+		return storage, nil
+	}
+
+	bucket.ForEach(func(k, v []byte) error {
+		if v != nil {
+			set(k, v)
+		}
+		return nil
+	})
+
+	// the entries of a key are contiguous and sorted by height, the first
+	// one above height holds its value
+	seen := make(map[string]struct{})
+	c := history.Cursor()
+	for ok := c.Seek(contract[:]); ok && bytes.HasPrefix(c.Key(), contract[:]); ok = c.Next() {
+		entry := c.Key()[len(contract):]
+		if bytes.HasPrefix(entry, createdKey) {
+			continue
+		}
+		n := binary.BigEndian.Uint32(entry)
+		k, at := entry[4:4+n], binary.BigEndian.Uint64(entry[4+n:])
+		if _, ok := seen[string(k)]; ok || at <= height {
+			continue
+		}
+		seen[string(k)] = struct{}{}
+		set(k, c.Value())
+	}
+
+	return storage, nil
+}
@@ -19,7 +19,6 @@ import (
 	"golang.org/x/crypto/ripemd160"
 	"math"
 	"math/big"
-	"time"
 	"github.com/omegasuite/btcd/chaincfg"
 
 	"bytes"
@@ -2090,6 +2089,9 @@ func opLoad(pc *int, evm *OVM, contract *Contract, stack *Stack) omega.Err {
 	}
 
 	d := evm.GetState(contract.self.Address(), string(h))
+	if evm.Tracer != nil {
+		evm.Tracer.CaptureState(&StateAccess{Op: LOAD, Key: h, Value: d})
+	}
 
 	var n uint32
 	n = uint32(len(d))
@@ -2216,6 +2218,9 @@ func opStore(pc *int, evm *OVM, contract *Contract, stack *Stack) omega.Err {
 	}
 
 	evm.SetState(contract.self.Address(), string(scratch[0]), scratch[1][:fdlen])
+	if evm.Tracer != nil {
+		evm.Tracer.CaptureState(&StateAccess{Op: STORE, Key: scratch[0], Value: scratch[1][:fdlen]})
+	}
 
 	return nil
 }
@@ -2256,6 +2261,9 @@ func opDel(pc *int, evm *OVM, contract *Contract, stack *Stack) omega.Err {
 		}
 	}
 	evm.DeleteState(contract.self.Address(), string(k))
+	if evm.Tracer != nil {
+		evm.Tracer.CaptureState(&StateAccess{Op: DEL, Key: k})
+	}
 
 	return nil
 }
@@ -3085,28 +3093,6 @@ func opReturn(pc *int, evm *OVM, contract *Contract, stack *Stack) omega.Err {
 	delete(stack.data, stack.callTop)
 	stack.callTop--
 
-	if !debugging || !dbgreturn {
-		return nil
-	}
-
-	dbgreturn = false
-
-	var buf [4]byte
-
-	log.Infof("opReturn: break at %d", *pc)
-
-	common.LittleEndian.PutUint32(buf[:], uint32((*pc) - dbgcodebase))
-	Control <- &DebugCmd{Reply: nil, Data: buf[:], Cmd: Breaked}
-
-	log.Infof("opReturn: waiting inspector")
-
-	select {
-	case <-inspector:
-	case <-time.After(5 * time.Minute):
-		// if no activity in 5 min., cancel debugging
-		debugging = false
-	}
-
 	return nil
 }
 
@@ -3675,35 +3661,7 @@ func opGetCoin(pc *int, evm *OVM, contract *Contract, stack *Stack) omega.Err {
 
 func opNul(pc *int, evm *OVM, contract *Contract, stack *Stack) omega.Err {
 	// this instruction is for debugging purpose, so we can insert a nul op in contract
-	// and set a break point here
-	if !debugging {
-		return nil
-	}
-
-	if dbgcontract == nil {
-		c := stack.data[stack.callTop].gbase
-		setdbgcontract(contract, Address(stack.data[c].inlib), stack)
-		log.Info("contract going")
-	}
-
-	if breakpoints[(*pc) - dbgcodebase] || stepping {
-		var buf [4]byte
-
-		log.Infof("opNul: break at %d", *pc)
-
-		common.LittleEndian.PutUint32(buf[:], uint32((*pc) - dbgcodebase))
-		Control <- &DebugCmd{Reply: nil, Data: buf[:], Cmd: Breaked}
-
-		log.Infof("opNul: waiting inspector")
-
-		select {
-		case <-inspector:
-		case <-time.After(5 * time.Minute):
-			// if no activity in 5 min., cancel debugging
-			debugging = false
-		}
-		log.Infof("opNul: continue")
-	}
+	// and it breaks there when the contract is run in a debugger
 	return nil
 }
 
@@ -4190,6 +4148,56 @@ func opLog(pc *int, ovm *OVM, contract *Contract, stack *Stack) omega.Err {
 
 	return nil
 }
+
+// opEvent emits an event. Operands are the topic, a dword usually written as
+// abi("Signature(types)") in assembly, the address of the event data and
+// its length.
+func opEvent(pc *int, evm *OVM, contract *Contract, stack *Stack) omega.Err {
+	param := contract.GetBytes(*pc)
+
+	var scratch [3]int64
+	ln := len(param)
+
+	top := 0
+	var err omega.Err
+	var tl int
+
+	dataType := []byte{0x44, 0xFF, 0x44}
+
+	for j := 0; j < ln && top < 3; j++ {
+		switch param[j] {
+		case '0', '1', '2', '3', '4', '5',
+			'6', '7', '8', '9', 'a', 'b', 'c',
+			'd', 'e', 'f', 'x', 'i', 'g':
+			if scratch[top], tl, err = stack.getNum(param[j:], dataType[top]); err != nil {
+				return err
+			}
+			j += tl
+			top++
+		}
+	}
+
+	if top != 3 {
+		return omega.ScriptError(omega.ErrInternal, "Missing operands")
+	}
+	if scratch[2] > MaxEventDataSize {
+		return omega.ScriptError(omega.ErrInternal, "Event data too large")
+	}
+
+	t := pointer(scratch[1])
+	a := t & 0xFFFFFFFF
+	b := a + pointer(scratch[2] & 0xFFFFFFFF)
+	if _,ok := stack.data[int32(t>>32)]; !ok || len(stack.data[int32(t>>32)].space) < int(b) {
+		return omega.ScriptError(omega.ErrInternal,"Memory address fault")
+	}
+
+	data := make([]byte, b - a)
+	copy(data, stack.data[int32(t>>32)].space[a:b])
+
+	evm.emit(contract.self.Address(), uint32(scratch[0]), data)
+
+	return nil
+}
 /*
 func opSignText(pc *int, ovm *OVM, contract *Contract, stack *Stack) omega.Err {
 	param := contract.GetBytes(*pc)
@@ -13,7 +13,6 @@ import (
 	"encoding/binary"
 	"github.com/omegasuite/btcd/wire/common"
 	"github.com/omegasuite/omega"
-	"time"
 
 	//	"github.com/omegasuite/btcd/chaincfg/chainhash"
 	//	"github.com/omegasuite/btcd/wire/common"
@@ -24,25 +23,6 @@ import (
 // passed evmironment to query external sources for state information.
 // The Interpreter will run the byte code VM based on the passed
 // configuration.
-type DebugCommand byte
-const (
-	Breakpoint = DebugCommand(iota)
-	Unbreak
-	Stepping
-	GoUp
-	Gorun
-	Getdata
-	Getstack
-	Breaked
-	Terminate
-	Terminated
-	Evaluate
-)
-type DebugCmd struct {
-	Cmd   DebugCommand
-	Data  []byte      // command come in
-	Reply chan []byte // returned info
-}
 type Interpreter struct {
 	evm      *OVM
 
@@ -52,45 +32,6 @@ type Interpreter struct {
 	returnData []byte // Last CALL's return Data for subsequent reuse
 }
 
-// smart contract debugger
-var debugNotifier chan []byte
-var debugging 	bool              // whether we are debugging
-var breakpoints map[int]bool    // breaks at inst. PC
-var Control 	chan *DebugCmd      // chan for receiving Control
-var inspector	chan *DebugCmd // chan for inspect inst to prog. code
-var stepping 	bool            // whether we are stepping
-var stop		bool
-var attaching	chan struct{}
-var dbgreturn bool				// where user has clicked 'up'
-
-func DebugSetup(enable bool, comm chan []byte) {
-	if comm == nil {
-		enable = false
-	}
-	if debugging == enable {
-		return
-	}
-
-	if attaching == nil {
-		attaching = make(chan struct{}, 10)
-	}
-
-	stepping, dbgreturn, stop = false, false, false
-
-	debugging = enable
-	if enable {
-		debugNotifier = comm
-		breakpoints = make(map[int]bool)
-		Control = make(chan *DebugCmd, 10)
-		go intrepdebug()
-	} else {
-		debugNotifier = nil
-		breakpoints = nil
-		Control = nil
-		inspector = nil
-	}
-}
-
 // NewInterpreter returns a new instance of the Interpreter.
 func NewInterpreter(evm *OVM) *Interpreter {
 	a := &Interpreter{
@@ -101,159 +42,6 @@ func NewInterpreter(evm *OVM) *Interpreter {
 	return a
 }
 
-var dbgcontract *Contract
-var dbgstack *Stack
-var readysent = false
-var dbgcodebase int
-
-func setdbgcontract(contract *Contract, addr Address, stack *Stack) {
-	dbgcontract, dbgstack, dbgcodebase = contract, stack, int(contract.libs[addr].address)
-
-	inspector = make(chan *DebugCmd, 10)
-
-	var prepend [2]byte
-	prepend[0] = 'C'
-	if dbgcontract.isnew {
-		prepend[1] = 'C'
-	} else {
-		prepend[1] = 'E'
-	}
-
-	debugNotifier <- append(prepend[:], addr[:]...)
-
-	readysent = false
-
-	<- attaching
-}
-
-func intrepdebug() {
-	var breakat int
-	var waitingchan chan []byte
-
-	for debugging {
-		select {
-		case ctrl,ok := <-Control:
-			if !ok {
-				debugging = false
-				break
-			}
-
-			switch ctrl.Cmd {
-			case Breakpoint:
-				inst := common.LittleEndian.Uint32(ctrl.Data)
-				breakpoints[int(inst)] = true
-				stepping = true
-
-			case Unbreak:
-				inst := common.LittleEndian.Uint32(ctrl.Data)
-				delete(breakpoints, int(inst))
-
-			case Stepping:
-				stepping = true
-				breakat = 0
-
-				waitingchan = ctrl.Reply
-
-				if !readysent {
-					readysent = true
-					attaching <- struct{}{}
-				} else {
-					inspector <- ctrl
-				}
-
-			case GoUp:
-				breakat = 0
-				stepping = false
-				dbgreturn = true
-
-				waitingchan = ctrl.Reply
-
-				if !readysent {
-					readysent = true
-					attaching <- struct{}{}
-				} else {
-					inspector <- ctrl
-				}
-
-			case Gorun:
-				breakat = 0
-				stepping = false
-
-				waitingchan = ctrl.Reply
-
-				if !readysent {
-					readysent = true
-					attaching <- struct{}{}
-				} else {
-					inspector <- ctrl
-				}
-
-			case Breaked:
-				breakat = int(common.LittleEndian.Uint32(ctrl.Data))
-				stepping = true
-
-				if waitingchan != nil {
-					waitingchan <- append([]byte{byte(Breaked)}, ctrl.Data...)
-				}
-
-			case Terminate:		// terminate by user. end debugging
-				inspector <- ctrl
-				debugging = false
-
-			case Terminated:	// natural termination of contract. in this case we will wait for next contract exec
-				if waitingchan != nil {
-					waitingchan <- []byte{byte(Terminated)}
-				}
-
-				if !readysent {
-					readysent = true
-					attaching <- struct{}{}
-				}
-
-			case Evaluate:
-				v := common.LittleEndian.Uint64(ctrl.Data)
-				l := common.LittleEndian.Uint32(ctrl.Data[8:])
-				if int(uint32(v)) >= len(dbgstack.data[int32(v>>32)].space) {
-					ctrl.Reply <- []byte{}
-				} else {
-					if int(uint32(v)+l) > len(dbgstack.data[int32(v>>32)].space) {
-						l = uint32(len(dbgstack.data[int32(v>>32)].space)) - uint32(v)
-					}
-					ctrl.Reply <- dbgstack.data[int32(v>>32)].space[uint32(v) : uint32(v)+l]
-				}
-
-			case Getdata:
-				if ctrl.Data != nil && breakat != 0 {
-					v, _, _ := dbgstack.getNum(ctrl.Data[:len(ctrl.Data)-4], 0xFF) // parse data ad address
-					l := common.LittleEndian.Uint32(ctrl.Data[len(ctrl.Data)-4:])
-					ctrl.Reply <- dbgstack.data[int32(v>>32)].space[uint32(v) : uint32(v)+l]
-				} else {
-					ctrl.Reply <- nil
-				}
-
-			case Getstack:
-				if breakat != 0 && dbgstack.callTop != 0 {
-					buf := make([]byte, dbgstack.callTop*4)
-					common.LittleEndian.PutUint32(buf[:], uint32(breakat))
-					for i, j := dbgstack.callTop-1, 4; i > 0; i-- {
-						common.LittleEndian.PutUint32(buf[j:], uint32(dbgstack.data[i].pc))
-						j += 4
-					}
-					ctrl.Reply <- buf
-				} else {
-					ctrl.Reply <- nil
-				}
-			}
-
-		case <-time.After(time.Minute * 10):
-			debugging = false
-		}
-	}
-
-	dbgcontract, dbgstack = nil, nil
-	debugging, stop, stepping, breakpoints = false, true, false, make(map[int]bool)
-}
-
 func NewSigInterpreter(evm *OVM) *Interpreter {
 	return &Interpreter{
 		evm:      evm,
@@ -273,6 +61,12 @@ func (in *Interpreter) enforceRestrictions(op OpCode, operation operation, stack
 	return nil
 }
 
+// validOp returns whether operation is valid in the block version of the
+// execution.
+func (in *Interpreter) validOp(operation operation) bool {
+	return operation.valid && (operation.version == 0 || in.evm.BlockVersion() >= operation.version)
+}
+
 func DisasmString(code []byte) string {
 	var (
 		op    OpCode        // current opcode
@@ -299,7 +93,7 @@ func (in *Interpreter) Step(code *inst) ([]byte, error) {
 
 	op := code.op
 	operation := in.JumpTable[op]
-	if !operation.valid {
+	if !in.validOp(operation) {
 			return nil, omega.ScriptError(omega.ErrInternal,fmt.Sprintf("invalid opcode 0x%x", int(op)))
 		}
 	if err := in.enforceRestrictions(op, operation, stack); err != nil {
@@ -325,16 +119,9 @@ func (in *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err om
 	// this is exec call depth, not func call depth
 	in.evm.depth++
 	defer func() {
-		if debugging {
-			Control <- &DebugCmd{ Terminated, nil, nil }
-		}
 		in.evm.depth--
 	}()
 
-	if debugging {
-		log.Infof("Contract run()")
-	}
-
 	// Reset the previous call's return Data. It's unimportant to preserve the old buffer
 	// as every returning call will return new Data anyway.
 	in.returnData = nil
@@ -373,11 +160,23 @@ func (in *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err om
 	}
  */
 
-	if debugging && in.evm.chainConfig.Net == common.TestNet {
-		log.Info("start intrepdebug")
-		if attaching == nil {
-			attaching = make(chan struct{}, 10)
-		}
+	// contract debugger is never available in main net
+	var dbg *Debugger
+	if atomic.LoadInt32(&numDebuggers) != 0 && in.evm.chainConfig.Net != common.MainNet {
+		dbg = acquireDebugger(contract.Address())
+	}
+	if dbg != nil {
+		defer func() {
+			dbg.terminated(ret, err)
+		}()
+		dbg.attach(contract, stack)
+	}
+
+	if tracer := in.evm.Tracer; tracer != nil {
+		tracer.CaptureStart(contract.Address(), input, in.evm.depth)
+		defer func() {
+			tracer.CaptureEnd(contract.Address(), ret, err, in.evm.depth)
+		}()
 	}
 
 //	debugging = true
@@ -407,7 +206,7 @@ func (in *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err om
 		// enough stack items available to perform the operation.
 		op = contract.GetOp(pc)
 		operation := in.JumpTable[op]
-		if !operation.valid {
+		if !in.validOp(operation) {
 			err := omega.ScriptError(omega.ErrInternal, fmt.Sprintf("invalid opcode 0x%x", int(op)))
 			return nil, err
 		}
@@ -421,6 +220,24 @@ func (in *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err om
 			return nil, omega.ScriptError(omega.ErrInternal,"State modification is not allowed")
 		}
 
+		if dbg != nil {
+			if err := dbg.trace(pc, op, stack); err != nil {
+				return nil, err
+			}
+		}
+
+		if in.evm.Tracer != nil {
+			in.evm.Tracer.CaptureStep(&TraceStep{
+				Contract:  contract.Address(),
+				Depth:     in.evm.depth,
+				Frame:     stack.callTop,
+				PC:        pc,
+				Op:        op,
+				Operands:  string(contract.GetBytes(pc)),
+				StepsLeft: in.evm.StepLimit,
+			})
+		}
+
 		// execute the operation
 		if printInst {
 			s := ""
@@ -449,9 +266,6 @@ func (in *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err om
 			return nil, err
 		case operation.reverts:
 			return stack.data[0].space[4:mln], errExecutionReverted
-		case stop:
-			stop = false
-			return nil, nil
 		case operation.halts:
 			return stack.data[0].space[4:mln], nil
 		case !operation.jumps:
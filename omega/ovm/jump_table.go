@@ -8,7 +8,10 @@
 
 package ovm
 
-import "github.com/omegasuite/omega"
+import (
+	"github.com/omegasuite/btcd/wire"
+	"github.com/omegasuite/omega"
+)
 
 type executionFunc func(pc *int, env *OVM, contract *Contract, stack *Stack) omega.Err
 
@@ -22,6 +25,8 @@ type operation struct {
 	valid   bool // indication whether the retrieved operation is valid and known
 	reverts bool // determines whether the operation reverts state (implicitly halts)
 	returns bool // determines whether the operations sets the return Data content
+
+	version uint32 // block version from which the operation is valid, 0 for all versions
 }
 
 var (
@@ -225,6 +230,12 @@ func NewOmegaInstructionSet() [256]operation {
 			execute:       opLog,
 			valid:         true,
 		},
+		EVENT: {
+			execute:       opEvent,
+			valid:         true,
+			writes:        true,
+			version:       wire.Version7,
+		},
 /*
 		SIGNTEXT: operation{
 			execute:       opSignText,
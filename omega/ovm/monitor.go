@@ -0,0 +1,322 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package ovm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/omegasuite/btcd/chaincfg"
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/wire"
+	"github.com/omegasuite/btcutil"
+	"github.com/omegasuite/omega"
+	"github.com/omegasuite/omega/token"
+	"github.com/omegasuite/omega/validate"
+	"github.com/omegasuite/omega/viewpoint"
+)
+
+// A right with the Monitored and IsMonitorCall flags names a monitor in its
+// description: the net id and address of a contract followed by the 4-byte
+// method to call. The monitor right, RightEntry.Monitoring() of the right,
+// has the same description. A token carrying the right, or a right split
+// from it, may only be spent in a transaction the monitor approves.
+//
+// The method is called read only with the arguments below. Integers are
+// little endian.
+//
+//	txin      uint32    index of the input carrying the monitored right
+//	right     [32]byte  the monitored right naming the monitor
+//	monitor   [32]byte  the monitor right
+//	ninputs   uint32    number of inputs, each followed by
+//	  outpoint  [36]byte  hash and index of the output spent
+//	  token     [93]byte  the output spent
+//	noutputs  uint32    number of outputs, each followed by
+//	  token     [93]byte
+//	nrights   uint32    number of rights involved, each followed by
+//	  hash      [32]byte
+//	  father    [32]byte
+//	  attrib    byte
+//	  desclen   uint32
+//	  desc      [desclen]byte
+//
+// A token is its type (uint64), its value (a uint64 padded to 32 bytes for
+// numeric tokens, the hash otherwise), its rights (zero if none) and the
+// first 21 bytes of its pkScript, that is the address it is paid to. The
+// rights involved are the rights, with right sets expanded, carried by the
+// inputs and outputs of the token type of the monitored input in the order
+// they first appear.
+//
+// The monitor approves the transaction by returning data starting with 1.
+// Any other return, or a failed call, rejects it.
+
+// MonitorTokenSize is the size of a token in the arguments of a monitor call.
+const MonitorTokenSize = 8 + 32 + chainhash.HashSize + 21
+
+// MonitorCall is a call of the monitor contract of a monitored right carried
+// by an input.
+type MonitorCall struct {
+	TxIn     int            // index of the input carrying the right
+	Right    chainhash.Hash // the monitored right naming the monitor
+	Monitor  chainhash.Hash // the monitor right
+	Contract Address
+	Method   [4]byte
+}
+
+// MonitorResult is the outcome of a MonitorCall.
+type MonitorResult struct {
+	MonitorCall
+	Approved bool
+	Return   []byte    // return data of the call
+	Err      omega.Err // the error of the call if it failed
+}
+
+// rightEntry returns the entry of the right of hash h, or nil if h is not
+// the hash of a right.
+func rightEntry(views *viewpoint.ViewPointSet, h *chainhash.Hash) *viewpoint.RightEntry {
+	e, _ := views.FetchRightEntry(h)
+	if re, ok := e.(*viewpoint.RightEntry); ok {
+		return re
+	}
+	return nil
+}
+
+// MonitorCalls returns the monitor calls required to spend the inputs of tx
+// from skip on. The utxos spent must be in views. Monitors whose contract no
+// longer exists are not called.
+func MonitorCalls(tx *btcutil.Tx, skip int, views *viewpoint.ViewPointSet) []MonitorCall {
+	calls := make([]MonitorCall, 0)
+	if skip >= len(tx.MsgTx().TxIn) {
+		return calls
+	}
+
+	for i, txin := range tx.MsgTx().TxIn[skip:] {
+		if txin.IsSeparator() {
+			break
+		}
+		if txin.IsSepadding() {
+			continue
+		}
+
+		utxo := views.Utxo.LookupEntry(txin.PreviousOutPoint)
+		if utxo == nil || utxo.TokenType&2 == 0 {
+			continue
+		}
+
+		called := make(map[chainhash.Hash]struct{})
+		for _, r := range validate.TokenRights(views, utxo) {
+			// all the way up to the right without Monitored flag, on the way
+			// find out all IsMonitorCall
+			re := rightEntry(views, &r)
+			for re != nil && re.Attrib&token.Monitored != 0 {
+				h := re.ToToken().Hash()
+				if _, ok := called[h]; !ok && re.Attrib&token.IsMonitorCall != 0 {
+					called[h] = struct{}{}
+
+					call := MonitorCall{
+						TxIn:    i + skip,
+						Right:   h,
+						Monitor: re.Monitoring(),
+					}
+					copy(call.Contract[:], re.Desc[1:21])
+					copy(call.Method[:], re.Desc[21:25])
+
+					if t := NewStateDB(views.Db, call.Contract); t.Exists(true) {
+						calls = append(calls, call)
+					}
+				}
+				if re.Father.IsEqual(&zerohash) {
+					break
+				}
+				re = rightEntry(views, &re.Father)
+			}
+		}
+	}
+
+	return calls
+}
+
+// putMonitorToken writes txo as a token in the arguments of a monitor call. A
+// nil txo is written as zeros.
+func putMonitorToken(w *bytes.Buffer, txo *wire.TxOut) {
+	var t [MonitorTokenSize]byte
+	if txo != nil {
+		binary.LittleEndian.PutUint64(t[:], txo.TokenType)
+		switch v := txo.Value.(type) {
+		case *token.NumToken:
+			binary.LittleEndian.PutUint64(t[8:], uint64(v.Val))
+		case *token.HashToken:
+			copy(t[8:], v.Hash[:])
+		}
+		if txo.Rights != nil {
+			copy(t[40:], txo.Rights[:])
+		}
+		copy(t[40+chainhash.HashSize:], txo.PkScript)
+	}
+	w.Write(t[:])
+}
+
+// MonitorArgs returns the arguments of call as defined by the monitor call
+// protocol. The utxos spent by tx must be in views.
+func MonitorArgs(tx *btcutil.Tx, call *MonitorCall, views *viewpoint.ViewPointSet) []byte {
+	var w bytes.Buffer
+	var n [4]byte
+	putUint32 := func(v uint32) {
+		binary.LittleEndian.PutUint32(n[:], v)
+		w.Write(n[:])
+	}
+
+	msgTx := tx.MsgTx()
+
+	var tokenType uint64
+	if utxo := views.Utxo.LookupEntry(msgTx.TxIn[call.TxIn].PreviousOutPoint); utxo != nil {
+		tokenType = utxo.TokenType
+	}
+
+	rights := make([]chainhash.Hash, 0)
+	involved := make(map[chainhash.Hash]struct{})
+	involve := func(txo *wire.TxOut) {
+		if txo == nil || txo.TokenType != tokenType {
+			return
+		}
+		for _, r := range validate.TokenRights(views, txo) {
+			if _, ok := involved[r]; !ok {
+				involved[r] = struct{}{}
+				rights = append(rights, r)
+			}
+		}
+	}
+
+	putUint32(uint32(call.TxIn))
+	w.Write(call.Right[:])
+	w.Write(call.Monitor[:])
+
+	ins := make([]*wire.TxIn, 0, len(msgTx.TxIn))
+	for _, txin := range msgTx.TxIn {
+		if txin.IsSeparator() {
+			break
+		}
+		if !txin.IsSepadding() {
+			ins = append(ins, txin)
+		}
+	}
+	putUint32(uint32(len(ins)))
+	for _, txin := range ins {
+		w.Write(txin.PreviousOutPoint.Hash[:])
+		putUint32(txin.PreviousOutPoint.Index)
+
+		var txo *wire.TxOut
+		if utxo := views.Utxo.LookupEntry(txin.PreviousOutPoint); utxo != nil {
+			txo = utxo.ToTxOut()
+		}
+		putMonitorToken(&w, txo)
+		involve(txo)
+	}
+
+	outs := make([]*wire.TxOut, 0, len(msgTx.TxOut))
+	for _, txo := range msgTx.TxOut {
+		if !txo.IsSeparator() {
+			outs = append(outs, txo)
+		}
+	}
+	putUint32(uint32(len(outs)))
+	for _, txo := range outs {
+		putMonitorToken(&w, txo)
+		involve(txo)
+	}
+
+	entries := make([]*viewpoint.RightEntry, 0, len(rights))
+	hashes := make([]chainhash.Hash, 0, len(rights))
+	for _, r := range rights {
+		if re := rightEntry(views, &r); re != nil {
+			entries = append(entries, re)
+			hashes = append(hashes, r)
+		}
+	}
+	putUint32(uint32(len(entries)))
+	for i, re := range entries {
+		w.Write(hashes[i][:])
+		w.Write(re.Father[:])
+		w.WriteByte(re.Attrib)
+		putUint32(uint32(len(re.Desc)))
+		w.Write(re.Desc)
+	}
+
+	return w.Bytes()
+}
+
+// CallMonitors calls the monitors of the inputs of tx from skip on and returns
+// their results. The calls are read only and run in the context the OVM has
+// been set up with. The utxos spent by tx must be in the view point of the OVM.
+func (ovm *OVM) CallMonitors(tx *btcutil.Tx, skip int) []MonitorResult {
+	return ovm.callMonitors(tx, MonitorCalls(tx, skip, ovm.views))
+}
+
+func (ovm *OVM) callMonitors(tx *btcutil.Tx, calls []MonitorCall) []MonitorResult {
+	writeback := ovm.writeback
+	ovm.writeback = false
+	defer func() {
+		ovm.writeback = writeback
+	}()
+
+	res := make([]MonitorResult, len(calls))
+	for i := range calls {
+		call := &calls[i]
+		outpoint := tx.MsgTx().TxIn[call.TxIn].PreviousOutPoint
+
+		ovm.GetCurrentOutput = func() wire.OutPoint {
+			return outpoint
+		}
+		ovm.contractStack = []Address{call.Contract}
+
+		ret, err := ovm.Call(call.Contract, call.Method[:], nil,
+			MonitorArgs(tx, call, ovm.views), PUREMASK)
+
+		res[i] = MonitorResult{
+			MonitorCall: *call,
+			Approved:    err == nil && len(ret) > 0 && ret[0] == 1,
+			Return:      ret,
+			Err:         err,
+		}
+	}
+
+	return res
+}
+
+// CheckMonitors returns an error if the monitor of a monitored right carried
+// by an input of tx from skip on does not approve tx in a block of the given
+// version. The utxos spent by tx must be in views. Monitors are called this
+// way from Version7.
+func CheckMonitors(tx *btcutil.Tx, param *chaincfg.Params, skip int, views *viewpoint.ViewPointSet, version uint32) omega.Err {
+	calls := MonitorCalls(tx, skip, views)
+	if len(calls) == 0 {
+		return nil
+	}
+
+	ovm := NewOVM(param)
+	ovm.SetViewPoint(views)
+	ovm.Init(tx, views)
+	ovm.GetCoinBase = func() *btcutil.Tx { return nil }
+	ovm.AddCoinBase = func(txo wire.TxOut) wire.OutPoint { return wire.OutPoint{} }
+	ovm.AddDef = func(t token.Definition, coinbase bool) chainhash.Hash { return chainhash.Hash{} }
+	ovm.BlockNumber = func() uint64 { return 0 }
+	ovm.BlockTime = func() uint32 { return 0 }
+	ovm.BlockVersion = func() uint32 { return version }
+	ovm.NoLoop = false
+
+	for _, r := range ovm.callMonitors(tx, calls) {
+		if !r.Approved {
+			return omega.ScriptError(omega.ErrMonitorRejected,
+				fmt.Sprintf("Monitor %x rejected spending input %d.", r.Contract[:], r.TxIn))
+		}
+	}
+
+	return nil
+}
@@ -68,6 +68,7 @@ const (
 	VERSION       // get tx version
 	TOKENCONTRACT	// contract address issuing a type of token
 	LOG				// print values to log
+	EVENT			// emit an event
 	
 	STOP	 OpCode = 0x7A	//  "z"
 )
@@ -121,6 +122,7 @@ var opCodeToString = map[OpCode]string{
 	VERSION: "VERSION",
 	TOKENCONTRACT: "TOKENCONTRACT",
 	LOG: "LOG",
+	EVENT: "EVENT",
 }
 
 func (o OpCode) String() string {
@@ -178,6 +180,7 @@ var stringToOp = map[string]OpCode{
 	"VERSION": 		 VERSION,
 	"TOKENCONTRACT": TOKENCONTRACT,
 	"LOG":			 LOG,
+	"EVENT":		 EVENT,
 }
 
 func StringToOp(str string) OpCode {
@@ -70,7 +70,7 @@ func run(evm *OVM, contract *Contract, input []byte) ([]byte, omega.Err) {
 	if contract.CodeAddr != nil {
 		var abi [4]byte
 		copy(abi[:], contract.CodeAddr)
-		p := PrecompiledContracts[abi]
+		p := precompiledContract(abi, evm.BlockVersion())
 		if p != nil {
 			return evm.interpreter.RunPrecompiledContract(p(evm, contract), input, contract)
 		}
@@ -230,6 +230,12 @@ type OVM struct {
 
 	DB database.DB
 
+	// Tracer, if set, is notified of every instruction executed.
+	Tracer Tracer
+
+	// events emitted since the last commit
+	events []Event
+
 //	CheckExecCost	bool	// whether we will check execution cost. This will be true only when packing blocks, not wen validating
 //	Paidfees int64
 }
@@ -310,9 +316,7 @@ func (v * OVM) Commit() {
 		panic("Unable to Marshal rollBacks")
 	}
 
-	var rbkey [16]byte
-	copy(rbkey[:], []byte("Rollback"))
-	binary.LittleEndian.PutUint64(rbkey[8:], v.BlockNumber())
+	rbkey := rollbackKey(v.BlockNumber())
 
 	v.DB.Update(func (dbTx  database.Tx) error {
 		bucket := dbTx.Metadata().Bucket(IssuedTokenTypes)
@@ -322,7 +326,10 @@ func (v * OVM) Commit() {
 			bucket.Put(mtk[:], a[:])
 		}
 		DbPutVersion(dbTx, []byte("lastCommitBlock"), v.BlockNumber())
-		return dbTx.Metadata().Put(rbkey[:], s)
+		if err := dbPutStorageHistory(dbTx, v.BlockNumber(), &rollBacks); err != nil {
+			return err
+		}
+		return dbTx.Metadata().Put(rbkey, s)
 	})
 
 	fmt.Printf("OVM.Commit rollback lastCommitBlock=%d:\n%s\n", v.BlockNumber(), spew.Sdump(rollBacks))
@@ -330,6 +337,7 @@ func (v * OVM) Commit() {
 	v.StateDB = make(map[Address]*stateDB)
 	v.TokenTypes = make(map[uint64]Address)
 	v.ExistingTokenTypes = make(map[uint64]Address)
+	v.events = nil
 	v.lastBlock = v.BlockNumber()
 	v.StepLimit = v.chainConfig.ContractExecLimit // step limit the contract can run, node decided policy
 }
@@ -340,13 +348,10 @@ func (d *OVM) Rollback() error {
 		return nil
 	}
 
-	var rbkey [16]byte
-	copy(rbkey[:], []byte("Rollback"))
-
-	binary.LittleEndian.PutUint64(rbkey[8:], d.lastBlock)
+	rbkey := rollbackKey(d.lastBlock)
 
 	return d.DB.Update(func (dbTx  database.Tx) error {
-		data := dbTx.Metadata().Get(rbkey[:])
+		data := dbTx.Metadata().Get(rbkey)
 
 		rollBacks := BlockRollBack{ }
 		err := json.Unmarshal(data, &rollBacks)
@@ -357,9 +362,12 @@ func (d *OVM) Rollback() error {
 		fmt.Printf("OVM.Rollback lastCommitBlock=%d:\n%s\n", d.lastBlock, spew.Sdump(rollBacks))
 
 //		d.rollbacks[d.lastBlock] = &rollBacks
+		if err := dbRemoveStorageHistory(dbTx, d.lastBlock, &rollBacks); err != nil {
+			return err
+		}
 		d.lastBlock = rollBacks.PrevBlock
 		DbPutVersion(dbTx, []byte("lastCommitBlock"), rollBacks.PrevBlock)
-		dbTx.Metadata().Delete(rbkey[:])
+		dbTx.Metadata().Delete(rbkey)
 
 		bucket := dbTx.Metadata().Bucket(IssuedTokenTypes)
 		for _,rb := range rollBacks.Tokentypes {
@@ -503,6 +511,7 @@ func (evm *OVM) Call(d Address, method []byte, sent * token.Token, params []byte
 	var (
 		snapshot = make(map[Address]*stateDB)
 		steplimit = evm.StepLimit
+		events = len(evm.events)
 	)
 	for adr, db := range evm.StateDB {
 		t := db.Copy()
@@ -540,6 +549,7 @@ func (evm *OVM) Call(d Address, method []byte, sent * token.Token, params []byte
 			evm.StepLimit = steplimit
 		}
 		evm.StateDB = snapshot
+		evm.events = evm.events[:events]
 	}
 	return ret, err
 }
@@ -605,7 +615,7 @@ func (ovm *OVM) Create(data []byte, contract *Contract) ([]byte, omega.Err) {
 	ovm.StateDB[d].fresh = true
 
 	contract.Code = ByteCodeParser(data)
-	if err := ByteCodeValidator(contract.Code); err != nil {
+	if err := ByteCodeValidator(contract.Code, ovm.BlockVersion()); err != nil {
 		return nil, err
 	}
 
@@ -626,7 +636,34 @@ func (ovm *OVM) Create(data []byte, contract *Contract) ([]byte, omega.Err) {
 		return nil, omega.ScriptError(omega.ErrInternal, "Fail to initialize contract.")
 	}
 
-	n := m.Index
+	// from Version7, the constructor may return an upgrade delay after the
+	// code start to make the contract upgradeable by its creator
+	var delay []byte
+	if ovm.BlockVersion() >= wire.Version7 && len(ret) >= 8 && common.LittleEndian.Uint32(ret[4:]) != 0 {
+		if common.LittleEndian.Uint32(ret[4:]) < MinUpgradeDelay {
+			return nil, omega.ScriptError(omega.ErrInternal, "Upgrade delay is too short.")
+		}
+		delay = append([]byte(nil), ret[4:8]...)
+	}
+
+	br := codeRegion(tx, m.Index, common.LittleEndian.Uint32(ret))
+
+	ovm.setMeta(d, "code", br)
+	ovm.setMeta(d, "creator", creator[:])
+	if delay != nil {
+		ovm.setMeta(d, "upgradedelay", delay)
+	}
+
+	log.Infof("Contract created: %x", d)
+
+	return nil, nil
+}
+
+// codeRegion returns the code meta of contract code carried by output n of
+// tx after the 25 bytes of address and method of its pkScript: the tx hash,
+// offset of the code in the serialized tx and length of the code. The first
+// start lines of the code are skipped.
+func codeRegion(tx *btcutil.Tx, n uint32, start uint32) []byte {
 	msg := tx.MsgTx()
 
 	p := 4
@@ -648,32 +685,185 @@ func (ovm *OVM) Create(data []byte, contract *Contract) ([]byte, omega.Err) {
 			p += ti.Token.SerializeSize() + 25 + common.VarIntSerializeSize(uint64(len(ti.PkScript)))
 		}
 	}
-	
-	start := common.LittleEndian.Uint32(ret)
+
 	ln := len(msg.TxOut[n].PkScript) - 25
 	pks := msg.TxOut[n].PkScript[25:]
-	dd := 0
 	for i := 0; start > 0; i++ {
 		if pks[i] == '\n' {
 			start--
 		}
 		p++
 		ln--
-		dd++
 	}
 
-	pks = pks[dd:]
-
 	br := make([]byte, 40)
 	copy(br, (*tx.Hash())[:])
 	common.LittleEndian.PutUint32(br[32:], uint32(p))
 	common.LittleEndian.PutUint32(br[36:], uint32(ln))
 
+	return br
+}
+
+// spentByCreator returns whether the transaction being executed spends a
+// coin of the creator of contract d. As the signatures of such inputs are
+// verified, the creator has authorized the transaction.
+func (ovm *OVM) spentByCreator(d Address) (bool, omega.Err) {
+	creator := ovm.GetMeta(d, "creator")
+	if len(creator) != 21 {
+		return false, nil
+	}
+
+	msg := ovm.GetTx().MsgTx()
+	ops := make(map[wire.OutPoint]struct{}, len(msg.TxIn))
+	for _, in := range msg.TxIn {
+		if !in.IsSeparator() {
+			ops[in.PreviousOutPoint] = struct{}{}
+		}
+	}
+	if err := ovm.views.Utxo.FetchUtxosMain(ovm.DB, ops); err != nil {
+		return false, omega.ScriptError(omega.ErrInternal, err.Error())
+	}
+
+	for _, in := range msg.TxIn {
+		if in.IsSeparator() {
+			continue
+		}
+		e := ovm.views.Utxo.LookupEntry(in.PreviousOutPoint)
+		if e == nil {
+			continue
+		}
+		version, addr, _, _ := parsePkScript(e.PkScript())
+		if version == creator[0] && bytes.Equal(addr, creator[1:]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ownerCall verifies that the precompile with the given method runs for the
+// output of the transaction calling it directly, that the contract declared
+// itself upgradeable when it was created and that the transaction is
+// authorized by the creator of the contract.
+func (ovm *OVM) ownerCall(contract *Contract, method byte) omega.Err {
+	if contract.pure&NOWRITE != 0 {
+		return omega.ScriptError(omega.ErrInternal, "State modification is not allowed")
+	}
+
+	d := contract.self.Address()
+	tx := ovm.GetTx()
+	if tx == nil {
+		return omega.ScriptError(omega.ErrInternal, "Contract owner call without a transaction.")
+	}
+
+	m := ovm.GetCurrentOutput()
+	if int(m.Index) >= len(tx.MsgTx().TxOut) {
+		return omega.ScriptError(omega.ErrInternal, "Contract owner call without an output.")
+	}
+	_, addr, abi, _ := parsePkScript(tx.MsgTx().TxOut[m.Index].PkScript)
+	if !bytes.Equal(addr, d[:]) || !bytes.Equal(abi, []byte{method, 0, 0, 0}) {
+		return omega.ScriptError(omega.ErrInternal, "Contract owner call must be made by a transaction output.")
+	}
+
+	if len(ovm.GetMeta(d, "upgradedelay")) != 4 {
+		return omega.ScriptError(omega.ErrInternal, "Contract is not upgradeable.")
+	}
+
+	ok, err := ovm.spentByCreator(d)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return omega.ScriptError(omega.ErrInternal, "Contract owner call is not authorized by the creator.")
+	}
+	return nil
+}
+
+// MinUpgradeDelay is the least number of blocks an upgrade of a contract
+// waits before it may be applied.
+const MinUpgradeDelay = 1008
+
+// Upgrade replaces the code of a contract with code while keeping its state.
+// The code is carried by the output calling the upgrade and must pass the
+// validation of contract code. The meta data entry "codeversion" counts the
+// upgrades of the contract.
+//
+// Only contracts whose constructor returned an upgrade delay may be upgraded.
+// The code becomes pending and the upgrade is applied by another upgrade
+// call, with no code, once the delay has passed. A pending upgrade is
+// replaced by a later one.
+func (ovm *OVM) Upgrade(code []byte, contract *Contract) ([]byte, omega.Err) {
+	d := contract.self.Address()
+	if err := ovm.ownerCall(contract, OP_UPGRADE); err != nil {
+		return nil, err
+	}
+
+	height := ovm.BlockNumber()
+
+	if len(code) == 0 {
+		pending := ovm.GetMeta(d, "pendingcode")
+		if len(pending) == 0 {
+			return nil, omega.ScriptError(omega.ErrInternal, "No pending contract upgrade.")
+		}
+		at := ovm.GetMeta(d, "pendingheight")
+		if len(at) != 8 || height < binary.LittleEndian.Uint64(at) {
+			return nil, omega.ScriptError(omega.ErrInternal, "Contract upgrade is time locked.")
+		}
+		ovm.setMeta(d, "pendingcode", nil)
+		ovm.setMeta(d, "pendingheight", nil)
+		ovm.setCode(d, pending)
+		return nil, nil
+	}
+
+	if err := upgradeValidator(ByteCodeParser(code), ovm.BlockVersion()); err != nil {
+		return nil, err
+	}
+
+	br := codeRegion(ovm.GetTx(), ovm.GetCurrentOutput().Index, 0)
+
+	delay := ovm.GetMeta(d, "upgradedelay")
+
+	var at [8]byte
+	binary.LittleEndian.PutUint64(at[:], height+uint64(binary.LittleEndian.Uint32(delay)))
+	ovm.setMeta(d, "pendingcode", br)
+	ovm.setMeta(d, "pendingheight", at[:])
+
+	log.Infof("Contract upgrade of %x pending until %d", d, binary.LittleEndian.Uint64(at[:]))
+
+	return nil, nil
+}
+
+// setCode sets the code meta of contract d and counts the upgrade.
+func (ovm *OVM) setCode(d Address, br []byte) {
+	var version [4]byte
+	if v := ovm.GetMeta(d, "codeversion"); len(v) == 4 {
+		binary.LittleEndian.PutUint32(version[:], binary.LittleEndian.Uint32(v)+1)
+	} else {
+		binary.LittleEndian.PutUint32(version[:], 1)
+	}
+
 	ovm.setMeta(d, "code", br)
-	ovm.setMeta(d, "creator", creator[:])
+	ovm.setMeta(d, "codeversion", version[:])
 
-	log.Infof("Contract created: %x", d)
+	log.Infof("Contract upgraded: %x version %d", d, binary.LittleEndian.Uint32(version[:]))
+}
+
+// SetUpgradeDelay sets the number of blocks an upgrade of a contract waits
+// before it may be applied, giving its users time to react. input holds the
+// delay as a little-endian uint32. The delay may only be increased.
+func (ovm *OVM) SetUpgradeDelay(input []byte, contract *Contract) ([]byte, omega.Err) {
+	d := contract.self.Address()
+	if err := ovm.ownerCall(contract, OP_UPGRADEDELAY); err != nil {
+		return nil, err
+	}
+
+	if len(input) != 4 {
+		return nil, omega.ScriptError(omega.ErrInternal, "Upgrade delay must be 4 bytes.")
+	}
+	if binary.LittleEndian.Uint32(input) < binary.LittleEndian.Uint32(ovm.GetMeta(d, "upgradedelay")) {
+		return nil, omega.ScriptError(omega.ErrInternal, "Upgrade delay may not be decreased.")
+	}
 
+	ovm.setMeta(d, "upgradedelay", input)
 	return nil, nil
 }
 
@@ -1,76 +1,86 @@
-// Copyright 2015 The go-ethereum Authors
-// This file is part of the go-ethereum library.
-//
-// The go-ethereum library is free software: you can redistribute it and/or modify
-// it under the terms of the GNU Lesser General Public License as published by
-// the Free Software Foundation, either version 3 of the License, or
-// (at your option) any later version.
-//
-// The go-ethereum library is distributed in the hope that it will be useful,
-// but WITHOUT ANY WARRANTY; without even the implied warranty of
-// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
-// GNU Lesser General Public License for more details.
-//
-// You should have received a copy of the GNU Lesser General Public License
-// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
 
 package runtime
 
 import (
-	"github.com/btcsuite"
-	"github.com/btcsuite/ovm"
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/wire"
+	"github.com/omegasuite/btcutil"
+	"github.com/omegasuite/omega/ovm"
+	"github.com/omegasuite/omega/token"
+	"github.com/omegasuite/omega/viewpoint"
 )
 
-func NewEnv(cfg *Config) *vm.EVM {
-	context := vm.Context{
-		GetHash:     func(uint64) common.Hash { return common.Hash{} },
-		TxTemplate:  wire.MsgTx{},
-
-		Origin:      cfg.Origin,
-		Coinbase:    cfg.Coinbase,
-		BlockNumber: cfg.BlockNumber,
-		Time:        cfg.Time,
-		Difficulty:  cfg.Difficulty,
-		GasLimit:    cfg.GasLimit,
-		GasPrice:    cfg.GasPrice,
+// NewEnv returns an OVM set up to execute tx against the state in cfg.DB. The
+// synthetic UTXOs in cfg are visible to the contract as if they were in the
+// UTXO set, and block information is taken from cfg instead of a chain.
+func NewEnv(cfg *Config, tx *btcutil.Tx) *ovm.OVM {
+	views := viewpoint.NewViewPointSet(cfg.DB)
+	for op, txo := range cfg.Utxos {
+		views.Utxo.AddRawTxOut(op, txo, false, int32(cfg.BlockHeight))
 	}
 
-	vm.GetTxTemplate = func() wire.MsgTx {
-		return vm.GetTxTemplate()
-	}
-	vm.Spend = func(t token.Token) bool {
-		return vm.Spend(t)
-	}
-	vm.AddTxOutput = func(wire.TxOut) bool {
-		return vm.AddTxOutput(t)
-	}
-	vm.AddTxDef = func(token.Definition) bool {
-		return vm.AddTxDef(t)
-	}
-	vm.SubmitTx = func() common.Hash {
-		return vm.SubmitTx()
+	evm := ovm.NewOVM(cfg.ChainParams)
+	evm.SetViewPoint(views)
+	evm.Init(tx, views)
+
+	evm.Tracer = cfg.Tracer
+	if cfg.StepLimit != 0 {
+		evm.StepLimit = cfg.StepLimit
 	}
 
-	return vm.NewEVM(context, cfg.State, cfg.ChainConfig, cfg.EVMConfig)
-}
+	evm.BlockNumber = func() uint64 { return cfg.BlockHeight }
+	evm.BlockTime = func() uint32 { return uint32(cfg.Time.Unix()) }
+	evm.BlockVersion = func() uint32 { return cfg.BlockVersion }
 
-func (context *vm.Context) GetTxTemplate() wire.MsgTx {
-	return context.TxTemplate
-}
-func (context *vm.Context) Spend() bool {
-	if true {	// check out UTXO to see if we have enough tockens, add those same kind as t to TxIn
-		context.TxTemplate.TxIn[] = wire.TxIn{}
-		return true
+	evm.AddDef = func(t token.Definition, coinbase bool) chainhash.Hash {
+		h := t.Hash()
+		e := views.Rights.GetRight(cfg.DB, h)
+		switch e.(type) {
+		case *viewpoint.RightEntry:
+			if e.(*viewpoint.RightEntry) != nil {
+				return h
+			}
+		case *viewpoint.RightSetEntry:
+			if e.(*viewpoint.RightSetEntry) != nil {
+				return h
+			}
+		}
+
+		switch t.(type) {
+		case *token.RightDef:
+			views.AddRight(t.(*token.RightDef), cfg.BlockVersion)
+		case *token.RightSetDef:
+			views.Rights.AddRightSet(t.(*token.RightSetDef))
+		}
+
+		if coinbase {
+			return evm.GetCoinBase().AddDef(t)
+		}
+		return tx.AddDef(t)
 	}
-	return false
-}
-func (context *vm.Context) AddTxOutput(wire.TxOut) bool {
-	context.TxTemplate.TxOut[] = wire.TxOut{}
-	return true
-}
-func (context *vm.Context) AddTxDef(token.Definition) bool {
-	context.TxTemplate.TxDef[] = wire.Definition{}
-	return true
-}
-func (context *vm.Context) SubmitTx() common.Hash {
+
+	cb := wire.MsgTx{}
+	coinBase := btcutil.NewTx(&cb)
+	coinBaseHash := *coinBase.Hash()
+	evm.AddCoinBase = func(txo wire.TxOut) wire.OutPoint {
+		if !coinBase.HasOuts {
+			// this servers as a separater. only TokenType is serialized
+			to := wire.TxOut{}
+			to.Token = token.Token{TokenType: token.DefTypeSeparator}
+			coinBase.MsgTx().AddTxOut(&to)
+			coinBase.HasOuts = true
+		}
+		coinBase.MsgTx().AddTxOut(&txo)
+		return wire.OutPoint{Hash: coinBaseHash, Index: uint32(len(coinBase.MsgTx().TxOut) - 1)}
+	}
+	evm.GetCoinBase = func() *btcutil.Tx { return coinBase }
+
+	return evm
 }
@@ -0,0 +1,723 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package runtime
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/database"
+	"github.com/omegasuite/btcd/wire"
+	"github.com/omegasuite/btcutil"
+)
+
+// memDB is a database.DB that keeps all metadata and blocks in memory. It is
+// the stand-in for the node's ffldb when contracts are run outside a node
+// and nothing it holds survives the process.
+//
+// Writable transactions are serialized and work on a private copy of the
+// metadata that replaces the committed copy on Commit. Read-only transactions
+// see the last committed copy, so a View inside an Update behaves as it does
+// with ffldb.
+type memDB struct {
+	writeLock sync.Mutex   // serializes writable transactions
+	mtx       sync.RWMutex // protects the fields below
+	root      *bucketData
+	blocks    map[chainhash.Hash][]byte
+	closed    bool
+}
+
+// bucketData is the content of a bucket: key/value pairs and nested buckets.
+type bucketData struct {
+	kv      map[string][]byte
+	buckets map[string]*bucketData
+}
+
+func newBucketData() *bucketData {
+	return &bucketData{
+		kv:      make(map[string][]byte),
+		buckets: make(map[string]*bucketData),
+	}
+}
+
+func (b *bucketData) clone() *bucketData {
+	c := &bucketData{
+		kv:      make(map[string][]byte, len(b.kv)),
+		buckets: make(map[string]*bucketData, len(b.buckets)),
+	}
+	for k, v := range b.kv {
+		c.kv[k] = v
+	}
+	for k, v := range b.buckets {
+		c.buckets[k] = v.clone()
+	}
+	return c
+}
+
+// NewMemDB returns an empty in-memory database.
+func NewMemDB() database.DB {
+	return &memDB{
+		root:   newBucketData(),
+		blocks: make(map[chainhash.Hash][]byte),
+	}
+}
+
+func makeDbErr(c database.ErrorCode, desc string, err error) database.Error {
+	return database.Error{ErrorCode: c, Description: desc, Err: err}
+}
+
+// Type returns the database driver type the current database instance was
+// created with.
+//
+// This function is part of the database.DB interface implementation.
+func (db *memDB) Type() string {
+	return "memdb"
+}
+
+// Begin starts a transaction which is either read-only or read-write depending
+// on the specified flag.
+//
+// This function is part of the database.DB interface implementation.
+func (db *memDB) Begin(writable bool) (database.Tx, error) {
+	if writable {
+		db.writeLock.Lock()
+	}
+
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	if db.closed {
+		if writable {
+			db.writeLock.Unlock()
+		}
+		return nil, makeDbErr(database.ErrDbNotOpen, "database is not open", nil)
+	}
+
+	tx := &memTx{
+		db:       db,
+		writable: writable,
+		root:     db.root,
+		blocks:   db.blocks,
+	}
+	if writable {
+		tx.root = db.root.clone()
+		tx.pending = make(map[chainhash.Hash][]byte)
+	}
+	return tx, nil
+}
+
+// rollbackOnPanic rolls the passed transaction back if the code in the calling
+// function panics so the write lock is not left held.
+func rollbackOnPanic(tx *memTx) {
+	if err := recover(); err != nil {
+		tx.close()
+		panic(err)
+	}
+}
+
+// View invokes the passed function in the context of a managed read-only
+// transaction.
+//
+// This function is part of the database.DB interface implementation.
+func (db *memDB) View(fn func(database.Tx) error) error {
+	dbTx, err := db.Begin(false)
+	if err != nil {
+		return err
+	}
+	tx := dbTx.(*memTx)
+
+	defer rollbackOnPanic(tx)
+
+	err = fn(tx)
+	tx.close()
+	return err
+}
+
+// Update invokes the passed function in the context of a managed read-write
+// transaction. The transaction is committed when fn returns nil and rolled
+// back otherwise.
+//
+// This function is part of the database.DB interface implementation.
+func (db *memDB) Update(fn func(database.Tx) error) error {
+	dbTx, err := db.Begin(true)
+	if err != nil {
+		return err
+	}
+	tx := dbTx.(*memTx)
+
+	defer rollbackOnPanic(tx)
+
+	if err = fn(tx); err != nil {
+		tx.close()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Close marks the database closed. All data is discarded.
+//
+// This function is part of the database.DB interface implementation.
+func (db *memDB) Close() error {
+	db.writeLock.Lock()
+	defer db.writeLock.Unlock()
+
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	if db.closed {
+		return makeDbErr(database.ErrDbNotOpen, "database is not open", nil)
+	}
+	db.closed = true
+	db.root = newBucketData()
+	db.blocks = make(map[chainhash.Hash][]byte)
+	return nil
+}
+
+// memTx implements database.Tx on a memDB.
+type memTx struct {
+	db       *memDB
+	writable bool
+	closed   bool
+	root     *bucketData
+	blocks   map[chainhash.Hash][]byte
+	pending  map[chainhash.Hash][]byte // blocks stored by this transaction
+}
+
+func (tx *memTx) checkClosed() error {
+	if tx.closed {
+		return makeDbErr(database.ErrTxClosed, "database tx is closed", nil)
+	}
+	return nil
+}
+
+func (tx *memTx) close() {
+	if tx.closed {
+		return
+	}
+	tx.closed = true
+	if tx.writable {
+		tx.db.writeLock.Unlock()
+	}
+}
+
+// Metadata returns the top-most bucket for all metadata storage.
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *memTx) Metadata() database.Bucket {
+	return &memBucket{tx: tx, data: tx.root}
+}
+
+func (tx *memTx) hasBlock(hash *chainhash.Hash) bool {
+	if _, ok := tx.pending[*hash]; ok {
+		return true
+	}
+	_, ok := tx.blocks[*hash]
+	return ok
+}
+
+func (tx *memTx) storeBlockBytes(hash *chainhash.Hash, blockBytes []byte) error {
+	if err := tx.checkClosed(); err != nil {
+		return err
+	}
+	if !tx.writable {
+		str := "store block requires a writable database transaction"
+		return makeDbErr(database.ErrTxNotWritable, str, nil)
+	}
+	if tx.hasBlock(hash) {
+		str := fmt.Sprintf("block %s already exists", hash)
+		return makeDbErr(database.ErrBlockExists, str, nil)
+	}
+	tx.pending[*hash] = blockBytes
+	return nil
+}
+
+// StoreBlock stores the provided block into the database.
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *memTx) StoreBlock(block *btcutil.Block) error {
+	blockBytes, err := block.Bytes()
+	if err != nil {
+		str := fmt.Sprintf("failed to get serialized bytes for block %s",
+			block.Hash())
+		return makeDbErr(database.ErrDriverSpecific, str, err)
+	}
+	return tx.storeBlockBytes(block.Hash(), blockBytes)
+}
+
+// StoreMinerBlock stores the provided miner block into the database.
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *memTx) StoreMinerBlock(block *wire.MinerBlock) error {
+	blockBytes, err := block.Bytes()
+	if err != nil {
+		str := fmt.Sprintf("failed to get serialized bytes for block %s",
+			block.Hash())
+		return makeDbErr(database.ErrDriverSpecific, str, err)
+	}
+	return tx.storeBlockBytes(block.Hash(), blockBytes)
+}
+
+// HasBlock returns whether or not a block with the given hash exists in the
+// database.
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *memTx) HasBlock(hash *chainhash.Hash) (bool, error) {
+	if err := tx.checkClosed(); err != nil {
+		return false, err
+	}
+	return tx.hasBlock(hash), nil
+}
+
+// HasBlocks returns whether or not the blocks with the provided hashes
+// exist in the database.
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *memTx) HasBlocks(hashes []chainhash.Hash) ([]bool, error) {
+	if err := tx.checkClosed(); err != nil {
+		return nil, err
+	}
+	results := make([]bool, len(hashes))
+	for i := range hashes {
+		results[i] = tx.hasBlock(&hashes[i])
+	}
+	return results, nil
+}
+
+func (tx *memTx) fetchBlock(hash *chainhash.Hash) ([]byte, error) {
+	if err := tx.checkClosed(); err != nil {
+		return nil, err
+	}
+	if b, ok := tx.pending[*hash]; ok {
+		return b, nil
+	}
+	if b, ok := tx.blocks[*hash]; ok {
+		return b, nil
+	}
+	str := fmt.Sprintf("block %s does not exist", hash)
+	return nil, makeDbErr(database.ErrBlockNotFound, str, nil)
+}
+
+// FetchBlockHeader returns the raw serialized bytes for the block header
+// identified by the given hash.
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *memTx) FetchBlockHeader(hash *chainhash.Hash) ([]byte, error) {
+	return tx.FetchBlockRegion(&database.BlockRegion{
+		Hash:   hash,
+		Offset: 0,
+		Len:    wire.MaxBlockHeaderPayload,
+	})
+}
+
+// FetchBlockHeaders returns the raw serialized bytes for the block headers
+// identified by the given hashes.
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *memTx) FetchBlockHeaders(hashes []chainhash.Hash) ([][]byte, error) {
+	headers := make([][]byte, len(hashes))
+	for i := range hashes {
+		h, err := tx.FetchBlockHeader(&hashes[i])
+		if err != nil {
+			return nil, err
+		}
+		headers[i] = h
+	}
+	return headers, nil
+}
+
+// FetchBlock returns the raw serialized bytes for the block identified by the
+// given hash.
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *memTx) FetchBlock(hash *chainhash.Hash) ([]byte, error) {
+	return tx.fetchBlock(hash)
+}
+
+// FetchBlocks returns the raw serialized bytes for the blocks identified by
+// the given hashes.
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *memTx) FetchBlocks(hashes []chainhash.Hash) ([][]byte, error) {
+	blocks := make([][]byte, len(hashes))
+	for i := range hashes {
+		b, err := tx.fetchBlock(&hashes[i])
+		if err != nil {
+			return nil, err
+		}
+		blocks[i] = b
+	}
+	return blocks, nil
+}
+
+// FetchBlockRegion returns the raw serialized bytes for the given block
+// region.
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *memTx) FetchBlockRegion(region *database.BlockRegion) ([]byte, error) {
+	b, err := tx.fetchBlock(region.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	endOffset := uint64(region.Offset) + uint64(region.Len)
+	if endOffset > uint64(len(b)) {
+		str := fmt.Sprintf("block %s region offset %d, length %d "+
+			"exceeds block length of %d", region.Hash,
+			region.Offset, region.Len, len(b))
+		return nil, makeDbErr(database.ErrBlockRegionInvalid, str, nil)
+	}
+	return b[region.Offset:endOffset:endOffset], nil
+}
+
+// FetchBlockRegions returns the raw serialized bytes for the given block
+// regions.
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *memTx) FetchBlockRegions(regions []database.BlockRegion) ([][]byte, error) {
+	res := make([][]byte, len(regions))
+	for i := range regions {
+		r, err := tx.FetchBlockRegion(&regions[i])
+		if err != nil {
+			return nil, err
+		}
+		res[i] = r
+	}
+	return res, nil
+}
+
+// Commit makes all changes of a writable transaction visible to later
+// transactions.
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *memTx) Commit() error {
+	if err := tx.checkClosed(); err != nil {
+		return err
+	}
+	if !tx.writable {
+		tx.close()
+		str := "Commit requires a writable database transaction"
+		return makeDbErr(database.ErrTxNotWritable, str, nil)
+	}
+
+	tx.db.mtx.Lock()
+	tx.db.root = tx.root
+	if len(tx.pending) > 0 {
+		blocks := make(map[chainhash.Hash][]byte, len(tx.db.blocks)+len(tx.pending))
+		for h, b := range tx.db.blocks {
+			blocks[h] = b
+		}
+		for h, b := range tx.pending {
+			blocks[h] = b
+		}
+		tx.db.blocks = blocks
+	}
+	tx.db.mtx.Unlock()
+
+	tx.close()
+	return nil
+}
+
+// Rollback undoes all changes made in the transaction.
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *memTx) Rollback() error {
+	if err := tx.checkClosed(); err != nil {
+		return err
+	}
+	tx.close()
+	return nil
+}
+
+// memBucket implements database.Bucket.
+type memBucket struct {
+	tx   *memTx
+	data *bucketData
+}
+
+func (b *memBucket) checkWritable() error {
+	if err := b.tx.checkClosed(); err != nil {
+		return err
+	}
+	if !b.tx.writable {
+		str := "setting a key requires a writable database transaction"
+		return makeDbErr(database.ErrTxNotWritable, str, nil)
+	}
+	return nil
+}
+
+// Bucket retrieves a nested bucket with the given key. Returns nil if the
+// bucket does not exist.
+//
+// This function is part of the database.Bucket interface implementation.
+func (b *memBucket) Bucket(key []byte) database.Bucket {
+	if c, ok := b.data.buckets[string(key)]; ok {
+		return &memBucket{tx: b.tx, data: c}
+	}
+	return nil
+}
+
+// CreateBucket creates and returns a new nested bucket with the given key.
+//
+// This function is part of the database.Bucket interface implementation.
+func (b *memBucket) CreateBucket(key []byte) (database.Bucket, error) {
+	if err := b.checkWritable(); err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		str := "create bucket requires a key"
+		return nil, makeDbErr(database.ErrBucketNameRequired, str, nil)
+	}
+	if _, ok := b.data.buckets[string(key)]; ok {
+		str := "bucket already exists"
+		return nil, makeDbErr(database.ErrBucketExists, str, nil)
+	}
+	c := newBucketData()
+	b.data.buckets[string(key)] = c
+	return &memBucket{tx: b.tx, data: c}, nil
+}
+
+// CreateBucketIfNotExists creates and returns a new nested bucket with the
+// given key if it does not already exist.
+//
+// This function is part of the database.Bucket interface implementation.
+func (b *memBucket) CreateBucketIfNotExists(key []byte) (database.Bucket, error) {
+	if err := b.checkWritable(); err != nil {
+		return nil, err
+	}
+	if c := b.Bucket(key); c != nil {
+		return c, nil
+	}
+	return b.CreateBucket(key)
+}
+
+// DeleteBucket removes a nested bucket with the given key.
+//
+// This function is part of the database.Bucket interface implementation.
+func (b *memBucket) DeleteBucket(key []byte) error {
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+	if _, ok := b.data.buckets[string(key)]; !ok {
+		str := fmt.Sprintf("bucket %q does not exist", key)
+		return makeDbErr(database.ErrBucketNotFound, str, nil)
+	}
+	delete(b.data.buckets, string(key))
+	return nil
+}
+
+// sortedKeys returns the keys of all pairs and nested buckets in order.
+func (b *memBucket) sortedKeys() []string {
+	keys := make([]string, 0, len(b.data.kv)+len(b.data.buckets))
+	for k := range b.data.kv {
+		keys = append(keys, k)
+	}
+	for k := range b.data.buckets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ForEach invokes the passed function with every key/value pair in the bucket.
+// Nested buckets are passed with a nil value.
+//
+// This function is part of the database.Bucket interface implementation.
+func (b *memBucket) ForEach(fn func(k, v []byte) error) error {
+	if err := b.tx.checkClosed(); err != nil {
+		return err
+	}
+	for _, k := range b.sortedKeys() {
+		if err := fn([]byte(k), b.data.kv[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ForEachBucket invokes the passed function with the key of every nested
+// bucket in the current bucket.
+//
+// This function is part of the database.Bucket interface implementation.
+func (b *memBucket) ForEachBucket(fn func(k []byte) error) error {
+	if err := b.tx.checkClosed(); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(b.data.buckets))
+	for k := range b.data.buckets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := fn([]byte(k)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Cursor returns a new cursor over the bucket. The set of keys is fixed when
+// the cursor is created.
+//
+// This function is part of the database.Bucket interface implementation.
+func (b *memBucket) Cursor() database.Cursor {
+	return &memCursor{bucket: b, keys: b.sortedKeys(), pos: -1}
+}
+
+// Writable returns whether or not the bucket is writable.
+//
+// This function is part of the database.Bucket interface implementation.
+func (b *memBucket) Writable() bool {
+	return b.tx.writable
+}
+
+// Put saves the specified key/value pair to the bucket.
+//
+// This function is part of the database.Bucket interface implementation.
+func (b *memBucket) Put(key, value []byte) error {
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+	if len(key) == 0 {
+		return makeDbErr(database.ErrKeyRequired, "put requires a key", nil)
+	}
+	if _, ok := b.data.buckets[string(key)]; ok {
+		str := "key is the name of a nested bucket"
+		return makeDbErr(database.ErrIncompatibleValue, str, nil)
+	}
+	v := make([]byte, len(value))
+	copy(v, value)
+	b.data.kv[string(key)] = v
+	return nil
+}
+
+// Get returns the value for the given key. Returns nil if the key does not
+// exist in this bucket.
+//
+// This function is part of the database.Bucket interface implementation.
+func (b *memBucket) Get(key []byte) []byte {
+	return b.data.kv[string(key)]
+}
+
+// Delete removes the specified key from the bucket. Deleting a key that does
+// not exist does not return an error.
+//
+// This function is part of the database.Bucket interface implementation.
+func (b *memBucket) Delete(key []byte) error {
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+	delete(b.data.kv, string(key))
+	return nil
+}
+
+// memCursor implements database.Cursor.
+type memCursor struct {
+	bucket *memBucket
+	keys   []string
+	pos    int
+}
+
+func (c *memCursor) valid() bool {
+	return c.pos >= 0 && c.pos < len(c.keys)
+}
+
+// Bucket returns the bucket the cursor was created for.
+//
+// This function is part of the database.Cursor interface implementation.
+func (c *memCursor) Bucket() database.Bucket {
+	return c.bucket
+}
+
+// Delete removes the current key/value pair the cursor is at.
+//
+// This function is part of the database.Cursor interface implementation.
+func (c *memCursor) Delete() error {
+	if err := c.bucket.checkWritable(); err != nil {
+		return err
+	}
+	if !c.valid() {
+		str := "cursor is exhausted"
+		return makeDbErr(database.ErrIncompatibleValue, str, nil)
+	}
+	k := c.keys[c.pos]
+	if _, ok := c.bucket.data.buckets[k]; ok {
+		str := "buckets may not be deleted from a cursor"
+		return makeDbErr(database.ErrIncompatibleValue, str, nil)
+	}
+	delete(c.bucket.data.kv, k)
+	return nil
+}
+
+// First positions the cursor at the first key/value pair.
+//
+// This function is part of the database.Cursor interface implementation.
+func (c *memCursor) First() bool {
+	c.pos = 0
+	return c.valid()
+}
+
+// Last positions the cursor at the last key/value pair.
+//
+// This function is part of the database.Cursor interface implementation.
+func (c *memCursor) Last() bool {
+	c.pos = len(c.keys) - 1
+	return c.valid()
+}
+
+// Next moves the cursor one key/value pair forward.
+//
+// This function is part of the database.Cursor interface implementation.
+func (c *memCursor) Next() bool {
+	if c.pos < len(c.keys) {
+		c.pos++
+	}
+	return c.valid()
+}
+
+// Prev moves the cursor one key/value pair backward.
+//
+// This function is part of the database.Cursor interface implementation.
+func (c *memCursor) Prev() bool {
+	if c.pos >= 0 {
+		c.pos--
+	}
+	return c.valid()
+}
+
+// Seek positions the cursor at the first key/value pair that is greater than
+// or equal to the passed seek key.
+//
+// This function is part of the database.Cursor interface implementation.
+func (c *memCursor) Seek(seek []byte) bool {
+	c.pos = sort.SearchStrings(c.keys, string(seek))
+	return c.valid()
+}
+
+// Key returns the current key the cursor is pointing to.
+//
+// This function is part of the database.Cursor interface implementation.
+func (c *memCursor) Key() []byte {
+	if !c.valid() {
+		return nil
+	}
+	return []byte(c.keys[c.pos])
+}
+
+// Value returns the current value the cursor is pointing to. This will be nil
+// for nested buckets.
+//
+// This function is part of the database.Cursor interface implementation.
+func (c *memCursor) Value() []byte {
+	if !c.valid() {
+		return nil
+	}
+	return c.bucket.data.kv[c.keys[c.pos]]
+}
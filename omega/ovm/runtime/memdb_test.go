@@ -0,0 +1,73 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package runtime
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/omegasuite/btcd/database"
+)
+
+func TestMemDBUpdate(t *testing.T) {
+	db := NewMemDB()
+
+	err := db.Update(func(dbTx database.Tx) error {
+		b, err := dbTx.Metadata().CreateBucket([]byte("b"))
+		if err != nil {
+			return err
+		}
+		for _, k := range []string{"c", "a", "b"} {
+			if err := b.Put([]byte(k), []byte(k+k)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	// a failed update must leave no trace
+	errFail := errors.New("fail")
+	err = db.Update(func(dbTx database.Tx) error {
+		dbTx.Metadata().Bucket([]byte("b")).Put([]byte("d"), []byte("dd"))
+		return errFail
+	})
+	if err != errFail {
+		t.Fatalf("Update returned %v, expected %v", err, errFail)
+	}
+
+	db.View(func(dbTx database.Tx) error {
+		b := dbTx.Metadata().Bucket([]byte("b"))
+		if b == nil {
+			t.Fatal("bucket b not found")
+		}
+		if v := b.Get([]byte("d")); v != nil {
+			t.Errorf("rolled back key d has value %x", v)
+		}
+		if err := b.Put([]byte("e"), nil); err == nil {
+			t.Error("expected Put in a read-only tx to fail")
+		}
+
+		var keys []byte
+		c := b.Cursor()
+		for ok := c.First(); ok; ok = c.Next() {
+			keys = append(keys, c.Key()...)
+		}
+		if !bytes.Equal(keys, []byte("abc")) {
+			t.Errorf("cursor returned keys %q, expected \"abc\"", keys)
+		}
+		if !c.Seek([]byte("bb")) || !bytes.Equal(c.Value(), []byte("cc")) {
+			t.Errorf("Seek positioned at %q", c.Key())
+		}
+		return nil
+	})
+}
@@ -0,0 +1,123 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package runtime
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/wire"
+	"github.com/omegasuite/btcutil"
+	"github.com/omegasuite/omega"
+	"github.com/omegasuite/omega/ovm"
+	"github.com/omegasuite/omega/token"
+	"github.com/omegasuite/omega/viewpoint"
+)
+
+// TestMonitors ensures spending a token carrying a monitored right calls the
+// monitor contract named by the right with the monitor call arguments, and
+// that the monitor's return decides whether the transaction is approved from
+// Version7.
+func TestMonitors(t *testing.T) {
+	cfg := new(Config)
+	if err := setDefaults(cfg); err != nil {
+		t.Fatalf("setDefaults: %v", err)
+	}
+
+	// approve returns 1, reject returns 0
+	approve, err := deploy([]byte("Ci0,1,\nCi4,1,\nz\n"), cfg)
+	if err != nil {
+		t.Fatalf("deploy: %v", err)
+	}
+	reject, err := deploy([]byte("Ci0,1,\nCi4,0,\nz\n"), cfg)
+	if err != nil {
+		t.Fatalf("deploy: %v", err)
+	}
+
+	pkScript := make([]byte, 25)
+	pkScript[0] = cfg.ChainParams.PubKeyHashAddrID
+	pkScript[1] = 1
+	pkScript[21] = ovm.OP_PAY2ANY
+
+	spend := func(monitor ovm.Address) (*btcutil.Tx, *viewpoint.ViewPointSet, *token.RightDef) {
+		desc := append([]byte{cfg.ChainParams.ContractAddrID}, monitor[:]...)
+		desc = append(desc, 1, 2, 3, 4)
+		right := token.NewRightDef(chainhash.Hash{}, desc, token.Monitored|token.IsMonitorCall)
+		rights := right.Hash()
+
+		views := viewpoint.NewViewPointSet(cfg.DB)
+		if views.AddRight(right, wire.Version6) {
+			t.Fatalf("monitored right naming a contract accepted before Version7")
+		}
+		if !views.AddRight(right, wire.Version7) {
+			t.Fatalf("monitored right rejected")
+		}
+
+		prev := wire.OutPoint{Hash: chainhash.HashH(monitor[:])}
+		views.Utxo.AddRawTxOut(prev, wire.NewTxOut(3, &token.HashToken{Hash: chainhash.HashH([]byte("land"))},
+			&rights, pkScript), false, 1)
+
+		msg := wire.NewMsgTx(wire.TxVersion)
+		msg.AddTxIn(wire.NewTxIn(&prev, 0))
+		msg.SignatureScripts = [][]byte{{}}
+		msg.AddTxOut(wire.NewTxOut(3, &token.HashToken{Hash: chainhash.HashH([]byte("land"))}, &rights, pkScript))
+		return btcutil.NewTx(msg), views, right
+	}
+
+	tx, views, right := spend(approve)
+	calls := ovm.MonitorCalls(tx, 0, views)
+	if len(calls) != 1 {
+		t.Fatalf("%d monitor calls, want 1", len(calls))
+	}
+	call := calls[0]
+	entry := &viewpoint.RightEntry{Father: right.Father, Desc: right.Desc, Attrib: right.Attrib}
+	if call.TxIn != 0 || call.Right != right.Hash() || call.Monitor != entry.Monitoring() ||
+		call.Contract != approve || call.Method != [4]byte{1, 2, 3, 4} {
+		t.Errorf("monitor call %v", call)
+	}
+
+	args := ovm.MonitorArgs(tx, &call, views)
+	if n := 4 + 64 + 4 + 36 + ovm.MonitorTokenSize + 4 + ovm.MonitorTokenSize + 4 +
+		64 + 1 + 4 + len(right.Desc); len(args) != n {
+		t.Fatalf("monitor call arguments are %d bytes, want %d", len(args), n)
+	}
+	hash := right.Hash()
+	if !bytes.Equal(args[4:36], hash[:]) || binary.LittleEndian.Uint32(args[68:]) != 1 ||
+		!bytes.Equal(args[len(args)-len(right.Desc):], right.Desc) {
+		t.Errorf("monitor call arguments %x", args)
+	}
+
+	if err := ovm.CheckMonitors(tx, cfg.ChainParams, 0, views, wire.Version7); err != nil {
+		t.Errorf("transfer not approved: %v", err)
+	}
+
+	tx, views, _ = spend(reject)
+	err = ovm.CheckMonitors(tx, cfg.ChainParams, 0, views, wire.Version7)
+	if err == nil || !omega.IsErrorCode(err, omega.ErrMonitorRejected) {
+		t.Errorf("transfer rejected by the monitor accepted: %v", err)
+	}
+
+	// the monitor is called in verifying signatures from Version7 only
+	err = ovm.VerifySigs(tx, cfg.ChainParams, 0, views, wire.Version7)
+	if err == nil || !omega.IsErrorCode(err, omega.ErrMonitorRejected) {
+		t.Errorf("transfer rejected by the monitor verified: %v", err)
+	}
+	if err := ovm.VerifySigs(tx, cfg.ChainParams, 0, views, wire.Version6); err != nil {
+		t.Errorf("transfer not verified before Version7: %v", err)
+	}
+
+	vm := NewEnv(cfg, tx)
+	vm.SetViewPoint(views)
+	res := vm.CallMonitors(tx, 0)
+	if len(res) != 1 || res[0].Approved || res[0].Contract != reject || !bytes.Equal(res[0].Return, []byte{0}) {
+		t.Errorf("monitor results %v", res)
+	}
+}
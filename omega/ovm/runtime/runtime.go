@@ -1,159 +1,423 @@
-// Copyright 2015 The go-ethereum Authors
-// This file is part of the go-ethereum library.
-//
-// The go-ethereum library is free software: you can redistribute it and/or modify
-// it under the terms of the GNU Lesser General Public License as published by
-// the Free Software Foundation, either version 3 of the License, or
-// (at your option) any later version.
-//
-// The go-ethereum library is distributed in the hope that it will be useful,
-// but WITHOUT ANY WARRANTY; without even the implied warranty of
-// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
-// GNU Lesser General Public License for more details.
-//
-// You should have received a copy of the GNU Lesser General Public License
-// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
 
 package runtime
 
 import (
-	"math"
-	"math/big"
+	"bytes"
+	"encoding/binary"
+	"errors"
 	"time"
 
-	"github.com/btcsuite/ovm"
+	"github.com/omegasuite/btcd/chaincfg"
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/database"
+	"github.com/omegasuite/btcd/wire"
+	"github.com/omegasuite/btcutil"
+	"github.com/omegasuite/omega/ovm"
+	"github.com/omegasuite/omega/token"
+	"golang.org/x/crypto/ripemd160"
+)
+
+var (
+	// txIndexKey and hashByIDIndexBucketName are the buckets of the tx
+	// index. OVM.GetCode finds contract code through them.
+	txIndexKey              = []byte("txbyhashidx")
+	hashByIDIndexBucketName = []byte("hashbyididx")
+
+	// blockIDKey holds the ID of the last synthetic block stored.
+	blockIDKey = []byte("runtimeblockid")
+
+	// lastCommitKey is the key OVM.Commit uses to record the last height
+	// committed.
+	lastCommitKey = []byte("lastCommitBlock")
+
+	// buckets is the list of buckets the OVM and viewpoints expect to be
+	// present in the metadata.
+	buckets = [][]byte{
+		[]byte("utxosetv2"),
+		[]byte("utxobyaddr"),
+		[]byte("borders"),
+		[]byte("borderboxes"),
+		[]byte("polygons"),
+		[]byte("polyhedra"),
+		[]byte("geometryrefs"),
+		[]byte("geoarchive"),
+		[]byte("rights"),
+		[]byte("rightchildren"),
+		[]byte("rightsetmembers"),
+		ovm.IssuedTokenTypes,
+		ovm.StorageHistoryBucketName,
+		txIndexKey,
+		hashByIDIndexBucketName,
+	}
+
+	errNoDB = errors.New("runtime: Call requires a config with DB set")
 )
 
-// Config is a basic type specifying certain configuration flags for running
-// the EVM.
+// Config is a basic type specifying the environment a contract is run in.
+// Zero fields are filled with defaults by the functions in this package.
 type Config struct {
-	ChainConfig *params.ChainConfig
-	Difficulty  *big.Int
-	Origin      common.Address
-	Coinbase    common.Address
-	BlockNumber *big.Int
-	Time        *big.Int
-	GasLimit    uint64
-	Value       *big.Int
-	Debug       bool
-	EVMConfig   vm.Config
-
-	State     *state.StateDB
-	GetHashFn func(n uint64) common.Hash
+	ChainParams  *chaincfg.Params
+	Origin       [21]byte // pubkey hash address (with net id) of the contract creator
+	BlockHeight  uint64
+	Time         time.Time
+	BlockVersion uint32
+	StepLimit    int64        // 0 means ChainParams.ContractExecLimit
+	Value        *token.Token // token sent to the contract with a call
+
+	// Utxos are synthetic UTXOs visible to the contract, e.g. coins owned
+	// by the contract that it may spend.
+	Utxos map[wire.OutPoint]*wire.TxOut
+
+	// DB holds contract state between runs. A new in-memory database is
+	// created when it is nil.
+	DB database.DB
+
+	// Tracer, if set, is notified of the instructions executed.
+	Tracer ovm.Tracer
 }
 
 // sets defaults on the config
-func setDefaults(cfg *Config) {
-	if cfg.ChainConfig == nil {
-		cfg.ChainConfig = &params.ChainConfig{
-		}
+func setDefaults(cfg *Config) error {
+	if cfg.ChainParams == nil {
+		cfg.ChainParams = &chaincfg.RegressionNetParams
 	}
-
-	if cfg.Difficulty == nil {
-		cfg.Difficulty = new(big.Int)
+	if cfg.Origin == [21]byte{} {
+		cfg.Origin[0] = cfg.ChainParams.PubKeyHashAddrID
+		copy(cfg.Origin[1:], btcutil.Hash160([]byte("origin")))
 	}
-	if cfg.Time == nil {
-		cfg.Time = big.NewInt(time.Now().Unix())
+	if cfg.BlockHeight == 0 {
+		cfg.BlockHeight = 1
 	}
-	if cfg.GasLimit == 0 {
-		cfg.GasLimit = math.MaxUint64
+	if cfg.Time.IsZero() {
+		cfg.Time = time.Now()
 	}
-	if cfg.GasPrice == nil {
-		cfg.GasPrice = new(big.Int)
+	if cfg.BlockVersion == 0 {
+		cfg.BlockVersion = wire.Version4
 	}
 	if cfg.Value == nil {
-		cfg.Value = new(big.Int)
+		cfg.Value = &token.Token{TokenType: 0, Value: &token.NumToken{Val: 0}}
 	}
-	if cfg.BlockNumber == nil {
-		cfg.BlockNumber = new(big.Int)
+	if cfg.DB == nil {
+		cfg.DB = NewMemDB()
 	}
-	if cfg.GetHashFn == nil {
-		cfg.GetHashFn = func(n uint64) common.Hash {
-			return common.BytesToHash(crypto.Keccak256([]byte(new(big.Int).SetUint64(n).String())))
+
+	return cfg.DB.Update(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		for _, b := range buckets {
+			if _, err := meta.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
 		}
-	}
+		return nil
+	})
+}
+
+// ContractAddress returns the address a contract with the given code will be
+// created at.
+func ContractAddress(code []byte) ovm.Address {
+	h := ripemd160.New()
+	h.Write(code)
+
+	var addr ovm.Address
+	copy(addr[:], h.Sum(nil))
+	return addr
+}
+
+// contractScript returns the pkScript calling method on the contract at
+// address. params follow the 4-byte method.
+func contractScript(cfg *Config, address ovm.Address, input []byte) []byte {
+	script := make([]byte, 0, 21+len(input))
+	script = append(script, cfg.ChainParams.ContractAddrID)
+	script = append(script, address[:]...)
+	return append(script, input...)
 }
 
 // Execute executes the code using the input as call data during the execution.
-// It returns the EVM's return value, the new state and an error if it failed.
+// It returns the OVM's return value, the database holding the resulting state
+// and an error if it failed.
 //
-// Executes sets up a in memory, temporarily, environment for the execution of
-// the given code. It makes sure that it's restored to it's original state afterwards.
-func Execute(code, input []byte, cfg *Config) ([]byte, *state.StateDB, error) {
+// Unlike Create, the code is installed as is without running a constructor,
+// and input starts with the 4-byte method being called.
+func Execute(code, input []byte, cfg *Config) ([]byte, database.DB, error) {
 	if cfg == nil {
 		cfg = new(Config)
 	}
-	setDefaults(cfg)
-
-	if cfg.State == nil {
-		db, _ := ethdb.NewMemDatabase()
-		cfg.State, _ = state.New(common.Hash{}, state.NewDatabase(db))
-	}
-	var (
-		address = common.StringToAddress("contract")
-		vmenv   = NewEnv(cfg)
-		sender  = vm.AccountRef(cfg.Origin)
-	)
-	cfg.State.CreateAccount(address)
-	// set the receiver's (the executing contract) code for execution.
-	cfg.State.SetCode(address, code)
-	// Call the code with the given configuration.
-	ret, _, err := vmenv.Call(
-		sender,
-		common.StringToAddress("contract"),
-		input,
-		cfg.GasLimit,
-		cfg.Value,
-	)
-
-	return ret, cfg.State, err
+	if err := setDefaults(cfg); err != nil {
+		return nil, nil, err
+	}
+
+	address, err := deploy(code, cfg)
+	if err != nil {
+		return nil, cfg.DB, err
+	}
+
+	ret, err := Call(address, input, cfg)
+	return ret, cfg.DB, err
 }
 
-// Create executes the code using the EVM create method
-func Create(input []byte, cfg *Config) ([]byte, common.Address, uint64, error) {
+// Create creates a contract through the create precompile: the code is run as
+// the contract constructor and the contract is stored in cfg.DB. The creating
+// transaction spends a synthetic coin of cfg.Origin, who becomes the creator.
+func Create(code []byte, cfg *Config) (ovm.Address, error) {
 	if cfg == nil {
 		cfg = new(Config)
 	}
-	setDefaults(cfg)
-
-	if cfg.State == nil {
-		db, _ := ethdb.NewMemDatabase()
-		cfg.State, _ = state.New(common.Hash{}, state.NewDatabase(db))
-	}
-	var (
-		vmenv  = NewEnv(cfg)
-		sender = vm.AccountRef(cfg.Origin)
-	)
-
-	// Call the code with the given configuration.
-	code, address, leftOverGas, err := vmenv.Create(
-		sender,
-		input,
-		cfg.GasLimit,
-		cfg.Value,
-	)
-	return code, address, leftOverGas, err
+	if err := setDefaults(cfg); err != nil {
+		return ovm.Address{}, err
+	}
+
+	address := ContractAddress(code)
+	if err := originCall(cfg, contractScript(cfg, address,
+		append([]byte{ovm.OP_CREATE, 0, 0, 0}, code...))); err != nil {
+		return ovm.Address{}, err
+	}
+	return address, nil
+}
+
+// Upgrade replaces the code of the contract at address with code through
+// the upgrade precompile, in a transaction spending a synthetic coin of
+// cfg.Origin. The upgrade becomes pending and an Upgrade with no code
+// applies it once the upgrade delay of the contract has passed.
+func Upgrade(address ovm.Address, code []byte, cfg *Config) error {
+	if cfg == nil || cfg.DB == nil {
+		return errNoDB
+	}
+	if err := setDefaults(cfg); err != nil {
+		return err
+	}
+
+	return originCall(cfg, contractScript(cfg, address,
+		append([]byte{ovm.OP_UPGRADE, 0, 0, 0}, code...)))
+}
+
+// SetUpgradeDelay sets the number of blocks upgrades of the contract at
+// address wait before they may be applied, in a transaction spending a
+// synthetic coin of cfg.Origin.
+func SetUpgradeDelay(address ovm.Address, blocks uint32, cfg *Config) error {
+	if cfg == nil || cfg.DB == nil {
+		return errNoDB
+	}
+	if err := setDefaults(cfg); err != nil {
+		return err
+	}
+
+	input := []byte{ovm.OP_UPGRADEDELAY, 0, 0, 0, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint32(input[4:], blocks)
+	return originCall(cfg, contractScript(cfg, address, input))
+}
+
+// originCall runs the contract call in script in a transaction whose only
+// input is a synthetic coin of cfg.Origin, stores the transaction so that
+// code it carries can be found, and commits the state changes.
+func originCall(cfg *Config, script []byte) error {
+	funding := wire.OutPoint{Hash: chainhash.DoubleHashH(append(cfg.Origin[:], script...))}
+	utxos := make(map[wire.OutPoint]*wire.TxOut, len(cfg.Utxos)+1)
+	for op, txo := range cfg.Utxos {
+		utxos[op] = txo
+	}
+	utxos[funding] = wire.NewTxOut(0, &token.NumToken{Val: 0}, nil,
+		append(cfg.Origin[:], []byte{ovm.OP_PAY2PKH, 0, 0, 0}...))
+
+	env := *cfg
+	env.Utxos = utxos
+
+	msg := wire.NewMsgTx(wire.TxVersion)
+	msg.AddTxIn(wire.NewTxIn(&funding, 0))
+	msg.AddTxOut(wire.NewTxOut(0, &token.NumToken{Val: 0}, nil, script))
+	tx := btcutil.NewTx(msg)
+
+	evm := NewEnv(&env, tx)
+	if _, err := evm.RunOutput(0); err != nil {
+		return err
+	}
+
+	if _, err := storeTx(cfg, tx); err != nil {
+		return err
+	}
+
+	return commit(evm, cfg)
 }
 
-// Call executes the code given by the contract's address. It will return the
-// EVM's return value or an error if it failed.
+// Call executes the code of the contract at address. It will return the OVM's
+// return value or an error if it failed.
 //
-// Call, unlike Execute, requires a config and also requires the State field to
-// be set.
-func Call(address common.Address, input []byte, cfg *Config) ([]byte, uint64, error) {
-	setDefaults(cfg)
-
-	vmenv := NewEnv(cfg)
-
-	sender := cfg.State.GetOrNewStateObject(cfg.Origin)
-	// Call the code with the given configuration.
-	ret, leftOverGas, err := vmenv.Call(
-		sender,
-		address,
-		input,
-		cfg.GasLimit,
-		cfg.Value,
-	)
-
-	return ret, leftOverGas, err
+// Call, unlike Execute, requires a config and also requires the DB field to
+// be set to a database holding the contract. State changes made by the
+// contract are written to it.
+func Call(address ovm.Address, input []byte, cfg *Config) ([]byte, error) {
+	if cfg == nil || cfg.DB == nil {
+		return nil, errNoDB
+	}
+	if err := setDefaults(cfg); err != nil {
+		return nil, err
+	}
+
+	msg := wire.NewMsgTx(wire.TxVersion)
+	msg.AddTxOut(wire.NewTxOut(cfg.Value.TokenType, cfg.Value.Value, cfg.Value.Rights,
+		contractScript(cfg, address, input)))
+	tx := btcutil.NewTx(msg)
+
+	evm := NewEnv(cfg, tx)
+	ret, err := evm.RunOutput(0)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, commit(evm, cfg)
+}
+
+// GetState returns the value stored under key by the contract at address.
+func GetState(db database.DB, address ovm.Address, key []byte) []byte {
+	var val []byte
+	db.View(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket([]byte("storage" + string(address[:])))
+		if bucket == nil {
+			return nil
+		}
+		if v := bucket.Get(key); v != nil {
+			val = make([]byte, len(v))
+			copy(val, v)
+		}
+		return nil
+	})
+	return val
+}
+
+// commit writes the state changes and events held by evm to cfg.DB.
+// OVM.Commit writes at most once for a block height, so the last committed
+// height is moved back to let several runs at the same height accumulate
+// state.
+func commit(evm *ovm.OVM, cfg *Config) error {
+	err := cfg.DB.Update(func(dbTx database.Tx) error {
+		if err := evm.PutEvents(dbTx); err != nil {
+			return err
+		}
+		if ovm.DbFetchVersion(dbTx, lastCommitKey) >= cfg.BlockHeight {
+			return ovm.DbPutVersion(dbTx, lastCommitKey, cfg.BlockHeight-1)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	evm.Commit()
+	return nil
+}
+
+// storeTx saves tx in a synthetic block of its own and adds it to the tx
+// index the way the tx indexer does. It returns the serialized tx as stored.
+func storeTx(cfg *Config, tx *btcutil.Tx) ([]byte, error) {
+	block := btcutil.NewBlock(&wire.MsgBlock{
+		Header: wire.BlockHeader{
+			Version:    cfg.BlockVersion,
+			MerkleRoot: *tx.Hash(),
+			Timestamp:  cfg.Time,
+		},
+		Transactions: []*wire.MsgTx{tx.MsgTx()},
+	})
+
+	blockBytes, err := block.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	txLocs, err := block.TxLoc()
+	if err != nil {
+		return nil, err
+	}
+	loc := txLocs[0]
+
+	err = cfg.DB.Update(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		if meta.Bucket(txIndexKey).Get(tx.Hash()[:]) != nil {
+			return nil
+		}
+
+		if err := dbTx.StoreBlock(block); err != nil {
+			return err
+		}
+
+		id := uint32(ovm.DbFetchVersion(dbTx, blockIDKey)) + 1
+		if err := ovm.DbPutVersion(dbTx, blockIDKey, uint64(id)); err != nil {
+			return err
+		}
+
+		var serializedID [4]byte
+		binary.LittleEndian.PutUint32(serializedID[:], id)
+		if err := meta.Bucket(hashByIDIndexBucketName).Put(serializedID[:], block.Hash()[:]); err != nil {
+			return err
+		}
+
+		var entry [12]byte
+		binary.LittleEndian.PutUint32(entry[:], id)
+		binary.LittleEndian.PutUint32(entry[4:], uint32(loc.TxStart))
+		binary.LittleEndian.PutUint32(entry[8:], uint32(loc.TxLen))
+		return meta.Bucket(txIndexKey).Put(tx.Hash()[:], entry[:])
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return blockBytes[loc.TxStart : loc.TxStart+loc.TxLen], nil
+}
+
+// deploy installs code as a contract without running it as a constructor. The
+// contract is stored the same way the create precompile stores it, so it is
+// indistinguishable from a created contract except for not being initialized.
+func deploy(code []byte, cfg *Config) (ovm.Address, error) {
+	address := ContractAddress(code)
+	mainbkt := []byte("contract" + string(address[:]))
+
+	exists := false
+	cfg.DB.View(func(dbTx database.Tx) error {
+		exists = dbTx.Metadata().Bucket(mainbkt) != nil
+		return nil
+	})
+	if exists {
+		return address, nil
+	}
+
+	script := contractScript(cfg, address, append([]byte{ovm.OP_CREATE, 0, 0, 0}, code...))
+
+	msg := wire.NewMsgTx(wire.TxVersion)
+	msg.AddTxOut(wire.NewTxOut(0, &token.NumToken{Val: 0}, nil, script))
+	tx := btcutil.NewTx(msg)
+
+	txBytes, err := storeTx(cfg, tx)
+	if err != nil {
+		return address, err
+	}
+
+	// code meta: tx hash, offset of code in tx, length of code
+	offset := bytes.LastIndex(txBytes, script) + len(script) - len(code)
+	br := make([]byte, 40)
+	copy(br, tx.Hash()[:])
+	binary.LittleEndian.PutUint32(br[32:], uint32(offset))
+	binary.LittleEndian.PutUint32(br[36:], uint32(len(code)))
+
+	return address, cfg.DB.Update(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		bucket, err := meta.CreateBucket(mainbkt)
+		if err != nil {
+			return err
+		}
+		if _, err := meta.CreateBucket([]byte("storage" + string(address[:]))); err != nil {
+			return err
+		}
+
+		if err := bucket.Put([]byte("code"), br); err != nil {
+			return err
+		}
+		if err := bucket.Put([]byte("creator"), cfg.Origin[:]); err != nil {
+			return err
+		}
+		return bucket.Put([]byte("address"), address[:])
+	})
 }
@@ -1,147 +1,550 @@
-// Copyright 2015 The go-ethereum Authors
-// This file is part of the go-ethereum library.
-//
-// The go-ethereum library is free software: you can redistribute it and/or modify
-// it under the terms of the GNU Lesser General Public License as published by
-// the Free Software Foundation, either version 3 of the License, or
-// (at your option) any later version.
-//
-// The go-ethereum library is distributed in the hope that it will be useful,
-// but WITHOUT ANY WARRANTY; without even the implied warranty of
-// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
-// GNU Lesser General Public License for more details.
-//
-// You should have received a copy of the GNU Lesser General Public License
-// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
 
 package runtime
 
 import (
-	"math/big"
-	"strings"
+	"bytes"
 	"testing"
+	"time"
 
-	"github.com/btcsuite/ovm"
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/database"
+	"github.com/omegasuite/btcd/wire"
+	"github.com/omegasuite/omega/ovm"
 )
 
+// counterCode stores 7 under key 1 in its constructor. The regular code,
+// starting at line 5, returns the value stored.
+var counterCode = []byte("Ox01,D7,\nRgi0,8,\nCi0,4,\nCi4,5,\nz\nNi0,x01,\nz\n")
+
+// upgradeableCode is counterCode with a constructor that also returns an
+// upgrade delay of 1008 blocks. Its regular code starts at line 6.
+var upgradeableCode = []byte("Ox01,D7,\nRgi0,12,\nCi0,8,\nCi4,6,\nCi8,1008,\nz\nNi0,x01,\nz\n")
+
 func TestDefaults(t *testing.T) {
 	cfg := new(Config)
-	setDefaults(cfg)
-
-	if cfg.Difficulty == nil {
-		t.Error("expected difficulty to be non nil")
+	if err := setDefaults(cfg); err != nil {
+		t.Fatalf("setDefaults: %v", err)
 	}
 
-	if cfg.Time == nil {
-		t.Error("expected time to be non nil")
+	if cfg.ChainParams == nil {
+		t.Error("expected chain params to be non nil")
+	}
+	if cfg.Origin[0] != cfg.ChainParams.PubKeyHashAddrID {
+		t.Errorf("expected origin to be a pubkey hash address, got %x", cfg.Origin)
+	}
+	if cfg.BlockHeight == 0 {
+		t.Error("didn't expect block height to be zero")
 	}
-	if cfg.GasLimit == 0 {
-		t.Error("didn't expect gaslimit to be zero")
+	if cfg.Time.IsZero() {
+		t.Error("expected time to be set")
 	}
-	if cfg.GasPrice == nil {
-		t.Error("expected time to be non nil")
+	if cfg.BlockVersion != wire.Version4 {
+		t.Errorf("expected block version %x, got %x", wire.Version4, cfg.BlockVersion)
 	}
 	if cfg.Value == nil {
-		t.Error("expected time to be non nil")
+		t.Error("expected value to be non nil")
 	}
-	if cfg.GetHashFn == nil {
-		t.Error("expected time to be non nil")
+	if cfg.DB == nil {
+		t.Error("expected DB to be non nil")
 	}
-	if cfg.BlockNumber == nil {
-		t.Error("expected block number to be non nil")
+}
+
+func TestExecute(t *testing.T) {
+	ret, db, err := Execute([]byte("Ci0,4,\nCi4,10,\nz\n"), []byte{1, 2, 3, 4}, nil)
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	if db == nil {
+		t.Fatal("expected a database holding the state")
+	}
+	if !bytes.Equal(ret, []byte{10, 0, 0, 0}) {
+		t.Errorf("Expected 10, got %x", ret)
 	}
 }
 
-func TestEVM(t *testing.T) {
-	defer func() {
-		if r := recover(); r != nil {
-			t.Fatalf("crashed with: %v", r)
-		}
-	}()
+func TestCreateAndCall(t *testing.T) {
+	cfg := &Config{Time: time.Unix(1600000000, 0)}
+	address, err := Create(counterCode, cfg)
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	if address != ContractAddress(counterCode) {
+		t.Errorf("contract created at %x, expected %x", address, ContractAddress(counterCode))
+	}
 
-	Execute([]byte{
-		byte(vm.DIFFICULTY),
-		byte(vm.TIMESTAMP),
-		byte(vm.GASLIMIT),
-		byte(vm.PUSH1),
-		byte(vm.ORIGIN),
-		byte(vm.BLOCKHASH),
-		byte(vm.COINBASE),
-	}, nil, nil)
-}
+	if v := GetState(cfg.DB, address, []byte{1, 0, 0, 0}); !bytes.Equal(v, []byte{7, 0, 0, 0}) {
+		t.Errorf("constructor stored %x, expected 07000000", v)
+	}
 
-func TestExecute(t *testing.T) {
-	ret, _, err := Execute([]byte{
-		byte(vm.PUSH1), 10,
-		byte(vm.PUSH1), 0,
-		byte(vm.MSTORE),
-		byte(vm.PUSH1), 32,
-		byte(vm.PUSH1), 0,
-		byte(vm.RETURN),
-	}, nil, nil)
+	ret, err := Call(address, []byte{1, 2, 3, 4}, cfg)
 	if err != nil {
 		t.Fatal("didn't expect error", err)
 	}
+	if !bytes.Equal(ret, []byte{7, 0, 0, 0}) {
+		t.Errorf("Expected 7, got %x", ret)
+	}
 
-	num := new(big.Int).SetBytes(ret)
-	if num.Cmp(big.NewInt(10)) != 0 {
-		t.Error("Expected 10, got", num)
+	if _, err := Create(counterCode, cfg); err == nil {
+		t.Error("expected recreating a contract to fail")
 	}
 }
 
 func TestCall(t *testing.T) {
-	db, _ := ethdb.NewMemDatabase()
-	state, _ := state.New(common.Hash{}, state.NewDatabase(db))
-	address := common.HexToAddress("0x0a")
-	state.SetCode(address, []byte{
-		byte(vm.PUSH1), 10,
-		byte(vm.PUSH1), 0,
-		byte(vm.MSTORE),
-		byte(vm.PUSH1), 32,
-		byte(vm.PUSH1), 0,
-		byte(vm.RETURN),
-	})
+	if _, err := Call(ContractAddress(counterCode), []byte{1, 2, 3, 4}, nil); err == nil {
+		t.Error("expected Call without a database to fail")
+	}
 
-	ret, _, err := Call(address, nil, &Config{State: state})
+	cfg := &Config{DB: NewMemDB()}
+	if _, err := Call(ContractAddress(counterCode), []byte{1, 2, 3, 4}, cfg); err == nil {
+		t.Error("expected calling a missing contract to fail")
+	}
+}
+
+func TestStepLimit(t *testing.T) {
+	_, _, err := Execute([]byte("Ci0,4,\nCi4,10,\nz\n"), []byte{1, 2, 3, 4},
+		&Config{StepLimit: 2})
+	if err == nil {
+		t.Error("expected execution to exceed the step limit")
+	}
+}
+
+func TestTrace(t *testing.T) {
+	cfg := &Config{Time: time.Unix(1600000000, 0)}
+	address, err := Create(counterCode, cfg)
 	if err != nil {
 		t.Fatal("didn't expect error", err)
 	}
 
-	num := new(big.Int).SetBytes(ret)
-	if num.Cmp(big.NewInt(10)) != 0 {
-		t.Error("Expected 10, got", num)
+	tracer := ovm.NewTraceLogger()
+	cfg.Tracer = tracer
+	if _, err := Call(address, []byte{1, 2, 3, 4}, cfg); err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+
+	entries := tracer.Entries()
+	if tracer.Steps() != 2 || len(entries) != 2 {
+		t.Fatalf("traced %d steps, %d entries, expected 2", tracer.Steps(), len(entries))
+	}
+	if e := entries[0]; e.Op != ovm.LOAD || e.PC != 0 || e.Operands != "i0,x01," ||
+		e.Contract != address {
+		t.Errorf("unexpected first step %+v", e.TraceStep)
+	}
+	if s := entries[0].State; len(s) != 1 || s[0].Op != ovm.LOAD ||
+		!bytes.Equal(s[0].Key, []byte{1, 0, 0, 0}) || !bytes.Equal(s[0].Value, []byte{7, 0, 0, 0}) {
+		t.Errorf("unexpected state access %+v", s)
+	}
+	if entries[0].StepsLeft-entries[1].StepsLeft != 1 {
+		t.Errorf("steps left %d, %d", entries[0].StepsLeft, entries[1].StepsLeft)
+	}
+
+	// a loop of 3 instructions run 10 times, limited to 5 recorded steps
+	tracer = ovm.NewTraceLogger()
+	tracer.Limit = 5
+	_, _, err = Execute([]byte("Ci0,10,\nCi0,i0,1,-\nCi4,i0,0,>\nKi4,n2,\nz\n"), []byte{1, 2, 3, 4},
+		&Config{Tracer: tracer})
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	if tracer.Steps() != 32 || len(tracer.Entries()) != 5 {
+		t.Errorf("traced %d steps, %d entries", tracer.Steps(), len(tracer.Entries()))
+	}
+	h := tracer.HotSpots()
+	if len(h) != 5 || h[0].Count != 10 || h[0].PC != 1 || h[3].PC != 0 || h[3].Count != 1 {
+		t.Errorf("unexpected hot spots %+v", h)
 	}
 }
 
-func BenchmarkCall(b *testing.B) {
-	var definition = `[{"constant":true,"inputs":[],"name":"seller","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"abort","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"value","outputs":[{"name":"","type":"uint256"}],"type":"function"},{"constant":false,"inputs":[],"name":"refund","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"buyer","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmReceived","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"state","outputs":[{"name":"","type":"uint8"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmPurchase","outputs":[],"type":"function"},{"inputs":[],"type":"constructor"},{"anonymous":false,"inputs":[],"name":"Aborted","type":"event"},{"anonymous":false,"inputs":[],"name":"PurchaseConfirmed","type":"event"},{"anonymous":false,"inputs":[],"name":"ItemReceived","type":"event"},{"anonymous":false,"inputs":[],"name":"Refunded","type":"event"}]`
+func TestStorageAt(t *testing.T) {
+	// the constructor stores 7 under key 1, the regular code 9
+	code := []byte("Ox01,D7,\nRgi0,8,\nCi0,4,\nCi4,5,\nz\nOx01,D9,\nz\n")
+	cfg := &Config{Time: time.Unix(1600000000, 0), BlockHeight: 1}
+	address, err := Create(code, cfg)
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+
+	cfg.BlockHeight = 3
+	if _, err := Call(address, []byte{1, 2, 3, 4}, cfg); err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+
+	key := []byte{1, 0, 0, 0}
+	tests := []struct {
+		height uint64
+		value  []byte
+	}{
+		{1, []byte{7, 0, 0, 0}},
+		{2, []byte{7, 0, 0, 0}},
+		{3, []byte{9, 0, 0, 0}},
+		{10, []byte{9, 0, 0, 0}},
+	}
+
+	check := func(dbTx database.Tx) error {
+		for _, test := range tests {
+			storage, err := ovm.StorageAt(dbTx, address, nil, test.height)
+			if err != nil {
+				t.Errorf("StorageAt(%d): %v", test.height, err)
+				continue
+			}
+			if len(storage) != 1 || !bytes.Equal(storage[string(key)], test.value) {
+				t.Errorf("storage at %d: %x, expected %x", test.height, storage, test.value)
+			}
+			storage, err = ovm.StorageAt(dbTx, address, [][]byte{key}, test.height)
+			if err != nil || len(storage) != 1 || !bytes.Equal(storage[string(key)], test.value) {
+				t.Errorf("storage of key at %d: %x, %v, expected %x", test.height, storage, err, test.value)
+			}
+		}
+
+		if storage, err := ovm.StorageAt(dbTx, address, [][]byte{{2, 0, 0, 0}}, 1); err != nil || len(storage) != 0 {
+			t.Errorf("StorageAt of a missing key: %x, %v", storage, err)
+		}
+		if _, err := ovm.StorageAt(dbTx, address, nil, 0); err == nil {
+			t.Error("expected error for state before the contract was created")
+		}
+		return nil
+	}
+	cfg.DB.View(check)
+
+	// the history rebuilt from the rollback records gives the same state
+	err = cfg.DB.Update(func(dbTx database.Tx) error {
+		if err := dbTx.Metadata().DeleteBucket(ovm.StorageHistoryBucketName); err != nil {
+			return err
+		}
+		return ovm.DbBuildStorageHistory(dbTx)
+	})
+	if err != nil {
+		t.Fatalf("DbBuildStorageHistory: %v", err)
+	}
+	cfg.DB.View(check)
+}
+
+func TestUpgrade(t *testing.T) {
+	cfg := &Config{Time: time.Unix(1600000000, 0), BlockHeight: 1, BlockVersion: wire.Version7}
+	address, err := Create(upgradeableCode, cfg)
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+
+	call := func(expected byte) {
+		t.Helper()
+		ret, err := Call(address, []byte{1, 2, 3, 4}, cfg)
+		if err != nil {
+			t.Fatal("didn't expect error", err)
+		}
+		if !bytes.Equal(ret, []byte{expected, 0, 0, 0}) {
+			t.Errorf("height %d: expected %d, got %x", cfg.BlockHeight, expected, ret)
+		}
+	}
+	version := func() []byte {
+		var v []byte
+		cfg.DB.View(func(dbTx database.Tx) error {
+			v = dbTx.Metadata().Bucket([]byte("contract" + string(address[:]))).Get([]byte("codeversion"))
+			return nil
+		})
+		return v
+	}
+
+	// the upgrade waits the 1008 blocks declared by the constructor
+	cfg.BlockHeight = 2
+	if err := Upgrade(address, []byte("Ci0,4,\nCi4,10,\nz\n"), cfg); err != nil {
+		t.Fatalf("Upgrade: %v", err)
+	}
+	call(7)
+	if v := version(); v != nil {
+		t.Errorf("code version %x before the upgrade is applied", v)
+	}
+
+	cfg.BlockHeight = 3
+	if err := Upgrade(address, []byte("Ci0,4,\n!\nz\n"), cfg); err == nil {
+		t.Error("expected upgrading to invalid code to fail")
+	}
+	other := *cfg
+	copy(other.Origin[1:], []byte("someone else"))
+	if err := Upgrade(address, []byte("Ci0,4,\nCi4,11,\nz\n"), &other); err == nil {
+		t.Error("expected upgrade by someone else to fail")
+	}
+	if err := SetUpgradeDelay(address, ovm.MinUpgradeDelay-1, cfg); err == nil {
+		t.Error("expected decreasing the upgrade delay to fail")
+	}
 
-	var code = common.Hex2Bytes("6060604052361561006c5760e060020a600035046308551a53811461007457806335a063b4146100865780633fa4f245146100a6578063590e1ae3146100af5780637150d8ae146100cf57806373fac6f0146100e1578063c19d93fb146100fe578063d696069714610112575b610131610002565b610133600154600160a060020a031681565b610131600154600160a060020a0390811633919091161461015057610002565b61014660005481565b610131600154600160a060020a039081163391909116146102d557610002565b610133600254600160a060020a031681565b610131600254600160a060020a0333811691161461023757610002565b61014660025460ff60a060020a9091041681565b61013160025460009060ff60a060020a9091041681146101cc57610002565b005b600160a060020a03166060908152602090f35b6060908152602090f35b60025460009060a060020a900460ff16811461016b57610002565b600154600160a060020a03908116908290301631606082818181858883f150506002805460a060020a60ff02191660a160020a179055506040517f72c874aeff0b183a56e2b79c71b46e1aed4dee5e09862134b8821ba2fddbf8bf9250a150565b80546002023414806101dd57610002565b6002805460a060020a60ff021973ffffffffffffffffffffffffffffffffffffffff1990911633171660a060020a1790557fd5d55c8a68912e9a110618df8d5e2e83b8d83211c57a8ddd1203df92885dc881826060a15050565b60025460019060a060020a900460ff16811461025257610002565b60025460008054600160a060020a0390921691606082818181858883f150508354604051600160a060020a0391821694503090911631915082818181858883f150506002805460a060020a60ff02191660a160020a179055506040517fe89152acd703c9d8c7d28829d443260b411454d45394e7995815140c8cbcbcf79250a150565b60025460019060a060020a900460ff1681146102f057610002565b6002805460008054600160a060020a0390921692909102606082818181858883f150508354604051600160a060020a0391821694503090911631915082818181858883f150506002805460a060020a60ff02191660a160020a179055506040517f8616bbbbad963e4e65b1366f1d75dfb63f9e9704bbbf91fb01bec70849906cf79250a15056")
+	cfg.BlockHeight = 1009
+	if err := Upgrade(address, nil, cfg); err == nil {
+		t.Error("expected applying a time locked upgrade to fail")
+	}
+	cfg.BlockHeight = 1010
+	if err := Upgrade(address, nil, cfg); err != nil {
+		t.Fatalf("applying the upgrade: %v", err)
+	}
+	call(10)
+	if v := version(); !bytes.Equal(v, []byte{1, 0, 0, 0}) {
+		t.Errorf("code version %x, expected 1", v)
+	}
+	if v := GetState(cfg.DB, address, []byte{1, 0, 0, 0}); !bytes.Equal(v, []byte{7, 0, 0, 0}) {
+		t.Errorf("upgrade changed storage to %x", v)
+	}
+	if err := Upgrade(address, nil, cfg); err == nil {
+		t.Error("expected error without a pending upgrade")
+	}
 
-	abi, err := abi.JSON(strings.NewReader(definition))
+	// a longer delay applies to later upgrades
+	cfg.BlockHeight = 1011
+	if err := SetUpgradeDelay(address, 2000, cfg); err != nil {
+		t.Fatalf("SetUpgradeDelay: %v", err)
+	}
+	if err := Upgrade(address, []byte("Ci0,4,\nCi4,12,\nz\n"), cfg); err != nil {
+		t.Fatalf("Upgrade: %v", err)
+	}
+	cfg.BlockHeight = 3010
+	if err := Upgrade(address, nil, cfg); err == nil {
+		t.Error("expected applying a time locked upgrade to fail")
+	}
+	cfg.BlockHeight = 3011
+	if err := Upgrade(address, nil, cfg); err != nil {
+		t.Fatalf("applying the upgrade: %v", err)
+	}
+	call(12)
+	if v := version(); !bytes.Equal(v, []byte{2, 0, 0, 0}) {
+		t.Errorf("code version %x, expected 2", v)
+	}
+}
+
+// TestUpgradeOptIn ensures only contracts created from Version7 by a
+// constructor returning a long enough upgrade delay may be upgraded.
+func TestUpgradeOptIn(t *testing.T) {
+	cfg := &Config{Time: time.Unix(1600000000, 0), BlockHeight: 1, BlockVersion: wire.Version7}
+	address, err := Create(counterCode, cfg)
 	if err != nil {
-		b.Fatal(err)
+		t.Fatal("didn't expect error", err)
+	}
+	cfg.BlockHeight = 2
+	if err := Upgrade(address, []byte("Ci0,4,\nCi4,10,\nz\n"), cfg); err == nil {
+		t.Error("expected upgrading a contract without an upgrade delay to fail")
+	}
+	if err := SetUpgradeDelay(address, 2000, cfg); err == nil {
+		t.Error("expected setting the upgrade delay of a contract without one to fail")
+	}
+
+	short := []byte("Ox01,D7,\nRgi0,12,\nCi0,8,\nCi4,6,\nCi8,5,\nz\nNi0,x01,\nz\n")
+	if _, err := Create(short, cfg); err == nil {
+		t.Error("expected creating a contract with a short upgrade delay to fail")
 	}
 
-	cpurchase, err := abi.Pack("confirmPurchase")
+	// the delay returned by the constructor of a contract created before
+	// Version7 is ignored
+	old := &Config{Time: time.Unix(1600000000, 0), BlockHeight: 1}
+	address, err = Create(upgradeableCode, old)
 	if err != nil {
-		b.Fatal(err)
+		t.Fatal("didn't expect error", err)
+	}
+	old.BlockHeight = 2
+	old.BlockVersion = wire.Version7
+	if err := Upgrade(address, []byte("Ci0,4,\nCi4,10,\nz\n"), old); err == nil {
+		t.Error("expected upgrading a contract created before Version7 to fail")
 	}
-	creceived, err := abi.Pack("confirmReceived")
+}
+
+// TestUpgradeInactive ensures the upgrade methods are inert before Version7:
+// calls to them run the code of the contract, which is left alone.
+func TestUpgradeInactive(t *testing.T) {
+	cfg := &Config{Time: time.Unix(1600000000, 0), BlockHeight: 1}
+	address, err := Create(counterCode, cfg)
 	if err != nil {
-		b.Fatal(err)
+		t.Fatal("didn't expect error", err)
+	}
+	code := func() []byte {
+		var c []byte
+		cfg.DB.View(func(dbTx database.Tx) error {
+			c = append(c, dbTx.Metadata().Bucket([]byte("contract"+string(address[:]))).Get([]byte("code"))...)
+			return nil
+		})
+		return c
+	}
+	before := code()
+
+	cfg.BlockHeight = 2
+	SetUpgradeDelay(address, 5, cfg)
+	Upgrade(address, []byte("Ci0,4,\nCi4,10,\nz\n"), cfg)
+
+	if c := code(); !bytes.Equal(c, before) {
+		t.Errorf("code changed to %x", c)
 	}
-	refund, err := abi.Pack("refund")
+	cfg.DB.View(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata().Bucket([]byte("contract" + string(address[:])))
+		for _, k := range []string{"codeversion", "upgradedelay", "pendingcode"} {
+			if v := meta.Get([]byte(k)); v != nil {
+				t.Errorf("%s set to %x", k, v)
+			}
+		}
+		return nil
+	})
+}
+
+func TestEvents(t *testing.T) {
+	// emit the 4 bytes at i0 under the topic of "oracle"
+	code := []byte("Ci0,x04030201,\nrx09020002,gi0,4,\nz\n")
+
+	// EVENT is not valid before Version7, neither in the code of a new
+	// contract nor when executed
+	if _, _, err := Execute(code, []byte{1, 2, 3, 4}, nil); err == nil {
+		t.Error("expected EVENT to fail to execute in a Version4 block")
+	}
+	ctor := []byte("Rgi0,8,\nCi0,x04030201,\nrx09020002,gi0,4,\nz\n")
+	if _, err := Create(ctor, nil); err == nil {
+		t.Error("expected a contract with EVENT to be rejected in a Version4 block")
+	}
+	if _, err := Create(ctor, &Config{BlockVersion: wire.Version7}); err != nil {
+		t.Errorf("creating a contract with EVENT in a Version7 block: %v", err)
+	}
+
+	_, db, err := Execute(code, []byte{1, 2, 3, 4}, &Config{BlockVersion: wire.Version7})
 	if err != nil {
-		b.Fatal(err)
+		t.Fatal("didn't expect error", err)
+	}
+
+	var events []ovm.Event
+	db.View(func(dbTx database.Tx) error {
+		return dbTx.Metadata().Bucket(ovm.ContractEventsBucket).ForEach(func(k, v []byte) error {
+			var h chainhash.Hash
+			copy(h[:], k)
+			e, err := ovm.FetchTxEvents(dbTx, &h)
+			if err != nil {
+				t.Errorf("FetchTxEvents: %v", err)
+			}
+			events = append(events, e...)
+			return nil
+		})
+	})
+
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
 	}
+	e := events[0]
+	if e.Contract != ContractAddress(code) || e.Topic != 0x09020002 ||
+		!bytes.Equal(e.Data, []byte{1, 2, 3, 4}) {
+		t.Errorf("unexpected event %x %08x %x", e.Contract, e.Topic, e.Data)
+	}
+}
+
+func TestDebugger(t *testing.T) {
+	events := make(chan *ovm.DebugEvent, 10)
+	d := ovm.NewDebugger(ovm.Address{}, func(ev *ovm.DebugEvent) { events <- ev })
+	defer d.Close()
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		for j := 0; j < 400; j++ {
-			Execute(code, cpurchase, nil)
-			Execute(code, creceived, nil)
-			Execute(code, refund, nil)
+	code := []byte("Ci0,4,\nCi4,10,\nz\n")
+	d.SetSource(ovm.NewSourceMap("test.asm", code))
+	if err := d.AddWatch("i4,:D"); err != nil {
+		t.Fatalf("AddWatch: %v", err)
+	}
+	if err := d.SetBreakpoint(2, "i4,:D == 10"); err != nil {
+		t.Fatalf("SetBreakpoint: %v", err)
+	}
+
+	done := make(chan error)
+	go func() {
+		_, _, err := Execute(code, []byte{1, 2, 3, 4}, nil)
+		done <- err
+	}()
+
+	next := func(kind ovm.DebugEventKind, pc int) *ovm.DebugEvent {
+		select {
+		case ev := <-events:
+			if ev.Kind != kind || ev.PC != pc {
+				t.Fatalf("got %s event at %d, expected %s at %d", ev.Kind, ev.PC, kind, pc)
+			}
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for %s event", kind)
 		}
+		return nil
+	}
+
+	next(ovm.DebugAttached, 0)
+	if err := d.Step(); err != nil {
+		t.Fatalf("Step: %v", err)
+	}
+	ev := next(ovm.DebugStep, 1)
+	if ev.Line != 2 {
+		t.Errorf("inst 1 reported at line %d, expected 2", ev.Line)
+	}
+
+	d.Continue()
+	ev = next(ovm.DebugBreakpoint, 2)
+	if len(ev.Watches) != 1 || !bytes.Equal(ev.Watches[0].Value, []byte{10, 0, 0, 0}) {
+		t.Errorf("unexpected watches %v", ev.Watches)
+	}
+	if v, err := d.Evaluate("i0,:D"); err != nil || !bytes.Equal(v, []byte{4, 0, 0, 0}) {
+		t.Errorf("Evaluate returned %x, %v", v, err)
+	}
+
+	d.Continue()
+	ev = next(ovm.DebugTerminated, 2)
+	if !bytes.Equal(ev.Result, []byte{10, 0, 0, 0}) {
+		t.Errorf("terminated with result %x", ev.Result)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Execute: %v", err)
+	}
+}
+
+func TestDebuggerIdleTimeout(t *testing.T) {
+	events := make(chan *ovm.DebugEvent, 10)
+	d := ovm.NewDebugger(ovm.Address{}, func(ev *ovm.DebugEvent) { events <- ev })
+	defer d.Close()
+
+	code := []byte("Ci0,4,\nz\n")
+	run := func() chan error {
+		done := make(chan error)
+		go func() {
+			_, _, err := Execute(code, []byte{1, 2, 3, 4}, nil)
+			done <- err
+		}()
+		return done
+	}
+	next := func() *ovm.DebugEvent {
+		select {
+		case ev := <-events:
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for event")
+		}
+		return nil
+	}
+
+	// without an idle timeout, a paused contract waits for a command
+	done := run()
+	if ev := next(); ev.Kind != ovm.DebugAttached {
+		t.Fatalf("got %s event, expected attached", ev.Kind)
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("got %s event while paused", ev.Kind)
+	case <-time.After(200 * time.Millisecond):
+	}
+	d.Continue()
+	if ev := next(); ev.Kind != ovm.DebugTerminated {
+		t.Fatalf("got %s event, expected terminated", ev.Kind)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	// with an idle timeout, the session is closed and the contract continues
+	d.SetIdleTimeout(50 * time.Millisecond)
+	done = run()
+	if ev := next(); ev.Kind != ovm.DebugAttached {
+		t.Fatalf("got %s event, expected attached", ev.Kind)
+	}
+	if ev := next(); ev.Kind != ovm.DebugDetached {
+		t.Fatalf("got %s event, expected detached", ev.Kind)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Execute: %v", err)
 	}
 }
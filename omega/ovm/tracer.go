@@ -0,0 +1,161 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package ovm
+
+import (
+	"sort"
+)
+
+// Tracer is notified of the execution of contract code when set in
+// OVM.Tracer. It is called in the goroutine running the contract.
+type Tracer interface {
+	// CaptureStart is called when contract code starts to run at the call
+	// depth.
+	CaptureStart(contract Address, input []byte, depth int)
+
+	// CaptureStep is called before an instruction executes.
+	CaptureStep(step *TraceStep)
+
+	// CaptureState is called when the instruction executing accesses the
+	// state of its contract.
+	CaptureState(access *StateAccess)
+
+	// CaptureEnd is called when contract code started at the call depth
+	// exits.
+	CaptureEnd(contract Address, ret []byte, err error, depth int)
+}
+
+// TraceStep is an instruction executed.
+type TraceStep struct {
+	Contract Address
+	Depth    int   // call depth
+	Frame    int32 // function call frame in the contract
+	PC       int
+	Op       OpCode
+	Operands string
+
+	// StepsLeft is the step limit left after the instruction. Every
+	// instruction takes a step.
+	StepsLeft int64
+}
+
+// StateAccess is an access of contract state by LOAD, STORE or DEL.
+type StateAccess struct {
+	Op    OpCode
+	Key   []byte
+	Value []byte // value read or written, nil for DEL
+}
+
+// TraceEntry is a step in the trace recorded by TraceLogger.
+type TraceEntry struct {
+	TraceStep
+	State []StateAccess
+}
+
+// HotSpot is the number of times an instruction was executed.
+type HotSpot struct {
+	Contract Address
+	PC       int
+	Op       OpCode
+	Count    int64
+}
+
+type hotSpotKey struct {
+	contract Address
+	pc       int
+}
+
+// DefaultTraceLimit is the number of steps TraceLogger records by default.
+const DefaultTraceLimit = 100000
+
+// TraceLogger is a Tracer recording the steps executed and counting the
+// executions of each instruction.
+type TraceLogger struct {
+	// Limit is the number of steps recorded. Steps beyond the limit are
+	// counted but not recorded.
+	Limit int
+
+	entries  []TraceEntry
+	steps    int64
+	hotSpots map[hotSpotKey]*HotSpot
+}
+
+// NewTraceLogger returns a TraceLogger recording up to DefaultTraceLimit
+// steps.
+func NewTraceLogger() *TraceLogger {
+	return &TraceLogger{
+		Limit:    DefaultTraceLimit,
+		hotSpots: make(map[hotSpotKey]*HotSpot),
+	}
+}
+
+// CaptureStart implements Tracer.
+func (t *TraceLogger) CaptureStart(contract Address, input []byte, depth int) {}
+
+// CaptureStep implements Tracer.
+func (t *TraceLogger) CaptureStep(step *TraceStep) {
+	t.steps++
+
+	k := hotSpotKey{step.Contract, step.PC}
+	h, ok := t.hotSpots[k]
+	if !ok {
+		h = &HotSpot{Contract: step.Contract, PC: step.PC, Op: step.Op}
+		t.hotSpots[k] = h
+	}
+	h.Count++
+
+	if len(t.entries) < t.Limit {
+		t.entries = append(t.entries, TraceEntry{TraceStep: *step})
+	}
+}
+
+// CaptureState implements Tracer.
+func (t *TraceLogger) CaptureState(access *StateAccess) {
+	if t.steps == 0 || int(t.steps) > len(t.entries) {
+		return
+	}
+	e := &t.entries[len(t.entries)-1]
+	a := StateAccess{Op: access.Op}
+	a.Key = append(a.Key, access.Key...)
+	if access.Value != nil {
+		a.Value = append([]byte{}, access.Value...)
+	}
+	e.State = append(e.State, a)
+}
+
+// CaptureEnd implements Tracer.
+func (t *TraceLogger) CaptureEnd(contract Address, ret []byte, err error, depth int) {}
+
+// Steps returns the number of steps executed.
+func (t *TraceLogger) Steps() int64 {
+	return t.steps
+}
+
+// Entries returns the steps recorded.
+func (t *TraceLogger) Entries() []TraceEntry {
+	return t.entries
+}
+
+// HotSpots returns the instructions executed, the most executed first.
+func (t *TraceLogger) HotSpots() []HotSpot {
+	r := make([]HotSpot, 0, len(t.hotSpots))
+	for _, h := range t.hotSpots {
+		r = append(r, *h)
+	}
+	sort.Slice(r, func(i, j int) bool {
+		if r[i].Count != r[j].Count {
+			return r[i].Count > r[j].Count
+		}
+		if r[i].Contract != r[j].Contract {
+			return string(r[i].Contract[:]) < string(r[j].Contract[:])
+		}
+		return r[i].PC < r[j].PC
+	})
+	return r
+}
@@ -0,0 +1,194 @@
+// Copyright (C) 2019-2021 Omegasuite developer
+// Use of this code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Omgsignd signs blocks and committee messages for omgd with the keys of an
+// encrypted keystore, so that the keys are kept out of the omgd process. It
+// serves omgd started with --signer on a Unix socket.
+//
+//	omgsignd -keystore file -socket path [-journal file]
+//	omgsignd -create -keystore file [-rsa key.pem]
+//
+// The passphrase of the keystore is read from the first line of stdin. With
+// -create, the WIF private keys to keep in the new keystore are read from the
+// following lines, one on a line.
+//
+// With -journal, every block signed and every candidacy consented to is
+// journaled in file before it is signed, and a key does not sign two blocks at
+// a height, whatever omgd asks for.
+package main
+
+import (
+	"bufio"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/omegasuite/btcd/btcec"
+	"github.com/omegasuite/btclog"
+	"github.com/omegasuite/btcutil"
+	"github.com/omegasuite/omega/consensus"
+	"github.com/omegasuite/omega/signer"
+)
+
+var log btclog.Logger
+
+// readLine returns the next line of r without its line ending.
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// create writes a keystore of the WIF keys read from stdin and of the RSA key
+// in the PEM file rsaFile, if any, to the file at path.
+func create(path, rsaFile string) error {
+	stdin := bufio.NewReader(os.Stdin)
+	passphrase, err := readLine(stdin)
+	if err != nil {
+		return fmt.Errorf("unable to read passphrase: %v", err)
+	}
+	if passphrase == "" {
+		return errors.New("empty passphrase")
+	}
+
+	var keys []*btcec.PrivateKey
+	for {
+		line, err := readLine(stdin)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		wif, err := btcutil.DecodeWIF(line)
+		if err != nil {
+			return fmt.Errorf("bad private key: %v", err)
+		}
+		keys = append(keys, wif.PrivKey)
+	}
+	if len(keys) == 0 {
+		return errors.New("no private key given")
+	}
+
+	var rsaKey *rsa.PrivateKey
+	if rsaFile != "" {
+		b, err := ioutil.ReadFile(rsaFile)
+		if err != nil {
+			return err
+		}
+		block, _ := pem.Decode(b)
+		if block == nil {
+			return fmt.Errorf("%s is not a PEM file", rsaFile)
+		}
+		if rsaKey, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return err
+		}
+	}
+
+	return signer.NewKeystore(keys, rsaKey).WriteFile(path, []byte(passphrase))
+}
+
+// serve serves the keys of the keystore at path on the Unix socket at socket
+// until interrupted.
+func serve(path, socket, journalFile string) error {
+	passphrase, err := readLine(bufio.NewReader(os.Stdin))
+	if err != nil {
+		return fmt.Errorf("unable to read passphrase: %v", err)
+	}
+	ks, err := signer.OpenKeystore(path, []byte(passphrase))
+	if err != nil {
+		return err
+	}
+
+	var policy signer.Policy
+	if journalFile != "" {
+		journal, err := consensus.OpenJournal(journalFile)
+		if err != nil {
+			return err
+		}
+		defer journal.Close()
+		policy = journal
+	}
+
+	// remove the socket left by a daemon that did not exit cleanly
+	if fi, err := os.Lstat(socket); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		os.Remove(socket)
+	}
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(socket, 0600); err != nil {
+		l.Close()
+		return err
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		<-interrupt
+		close(done)
+		l.Close()
+	}()
+
+	signers := ks.Signers()
+	for _, s := range signers {
+		log.Infof("Signing for %x", signer.Name(s.PubKey()))
+	}
+
+	err = signer.NewDaemon(signers, ks.Decrypter(), policy).Serve(l)
+	select {
+	case <-done:
+		// interrupted
+		return nil
+	default:
+		l.Close()
+		return err
+	}
+}
+
+func main() {
+	keystore := flag.String("keystore", "", "keystore file")
+	socket := flag.String("socket", "", "Unix socket to serve omgd on")
+	journal := flag.String("journal", "", "file journaling the blocks signed, to refuse signing two blocks at a height")
+	doCreate := flag.Bool("create", false, "create the keystore from WIF keys read from stdin")
+	rsaFile := flag.String("rsa", "", "PEM file of the RSA key of invitations to keep in the new keystore")
+	flag.Parse()
+
+	if *keystore == "" || (!*doCreate && *socket == "") || flag.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "usage: omgsignd -keystore file -socket path [-journal file]")
+		fmt.Fprintln(os.Stderr, "       omgsignd -create -keystore file [-rsa key.pem]")
+		os.Exit(2)
+	}
+
+	log = btclog.NewBackend(os.Stderr).Logger("SGND", 0xFFFF)
+	signer.UseLogger(log)
+
+	var err error
+	if *doCreate {
+		err = create(*keystore, *rsaFile)
+	} else {
+		err = serve(*keystore, *socket, *journal)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,443 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package signer
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/omegasuite/btcd/btcec"
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/wire"
+)
+
+// callTimeout is the time a client waits for the daemon to answer a call.
+const callTimeout = 10 * time.Second
+
+// daemon operations
+const (
+	opKeys    = "keys"
+	opSign    = "sign"
+	opRSAKey  = "rsakey"
+	opDecrypt = "decrypt"
+)
+
+// daemonRequest is a call to the daemon. Calls and answers are JSON objects,
+// one per line. A call to sign carries what is signed, the daemon makes the
+// digest itself.
+type daemonRequest struct {
+	Op      string `json:"op"`
+	Key     []byte `json:"key,omitempty"` // name of the key signing
+	Kind    Kind   `json:"kind"`
+	Height  int32  `json:"height"`
+	Hash    []byte `json:"hash,omitempty"`
+	Command string `json:"command,omitempty"` // command of the message signed
+	Msg     []byte `json:"msg,omitempty"`     // message or invitation signed, or ciphertext
+	Label   []byte `json:"label,omitempty"`
+}
+
+// daemonResponse is the answer of the daemon to a call.
+type daemonResponse struct {
+	Error string   `json:"error,omitempty"`
+	Keys  [][]byte `json:"keys,omitempty"` // compressed public keys
+	RSA   []byte   `json:"rsa,omitempty"`  // PKCS #1 RSA public key
+	Sig   []byte   `json:"sig,omitempty"`  // DER signature
+	Plain []byte   `json:"plain,omitempty"`
+}
+
+// Daemon signs requests received over connections with its signers once they
+// pass its policy. It lets a node sign with keys it does not hold.
+type Daemon struct {
+	// mtx serializes signing, so that the policy sees the requests of all
+	// connections one after another.
+	mtx       sync.Mutex
+	signers   map[[20]byte]Signer
+	keys      [][]byte
+	decrypter Decrypter
+	policy    Policy
+}
+
+// NewDaemon returns a daemon signing with signers after checking requests
+// against policy, and decrypting invitations with decrypter. A nil policy
+// accepts every request, and a nil decrypter decrypts none.
+func NewDaemon(signers []Signer, decrypter Decrypter, policy Policy) *Daemon {
+	d := &Daemon{
+		signers:   make(map[[20]byte]Signer, len(signers)),
+		decrypter: decrypter,
+		policy:    policy,
+	}
+	for _, s := range signers {
+		d.signers[Name(s.PubKey())] = s
+		d.keys = append(d.keys, s.PubKey().SerializeCompressed())
+	}
+	return d
+}
+
+// Serve serves the connections accepted by l until it is closed. It returns
+// the error that closed l.
+func (d *Daemon) Serve(l net.Listener) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				continue
+			}
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.serveConn(conn)
+		}()
+	}
+}
+
+// serveConn answers the calls received over conn until it is closed.
+func (d *Daemon) serveConn(conn net.Conn) {
+	defer conn.Close()
+
+	dec := json.NewDecoder(bufio.NewReader(conn))
+	enc := json.NewEncoder(conn)
+	for {
+		var req daemonRequest
+		if err := dec.Decode(&req); err != nil {
+			return
+		}
+		if err := enc.Encode(d.handle(&req)); err != nil {
+			return
+		}
+	}
+}
+
+// handle returns the answer to req.
+func (d *Daemon) handle(req *daemonRequest) *daemonResponse {
+	switch req.Op {
+	case opKeys:
+		return &daemonResponse{Keys: d.keys}
+
+	case opSign:
+		var name [20]byte
+		if len(req.Key) != len(name) {
+			return &daemonResponse{Error: ErrBadRequest.Error()}
+		}
+		copy(name[:], req.Key)
+		r, err := req.request()
+		if err != nil {
+			log.Warnf("Refuse to sign %v at %d for %x: %v", req.Kind, req.Height, name, err)
+			return &daemonResponse{Error: err.Error()}
+		}
+
+		sig, err := d.sign(name, r)
+		if err != nil {
+			log.Warnf("Refuse to sign %v at %d for %x: %v", r.Kind, r.Height, name, err)
+			return &daemonResponse{Error: err.Error()}
+		}
+		return &daemonResponse{Sig: sig.Serialize()}
+
+	case opRSAKey:
+		if d.decrypter == nil {
+			return &daemonResponse{Error: ErrNoDecrypter.Error()}
+		}
+		return &daemonResponse{RSA: x509.MarshalPKCS1PublicKey(d.decrypter.PublicKey())}
+
+	case opDecrypt:
+		if d.decrypter == nil {
+			return &daemonResponse{Error: ErrNoDecrypter.Error()}
+		}
+		plain, err := d.decrypter.Decrypt(req.Msg, req.Label)
+		if err != nil {
+			return &daemonResponse{Error: err.Error()}
+		}
+		return &daemonResponse{Plain: plain}
+	}
+
+	return &daemonResponse{Error: fmt.Sprintf("unknown operation %q", req.Op)}
+}
+
+// request returns the request to sign made from what req says is signed.
+func (req *daemonRequest) request() (*Request, error) {
+	var r *Request
+	switch req.Kind {
+	case KindBlock:
+		if len(req.Hash) != chainhash.HashSize {
+			return nil, fmt.Errorf("%v: %d bytes hash", ErrBadRequest, len(req.Hash))
+		}
+		var hash chainhash.Hash
+		copy(hash[:], req.Hash)
+		r = BlockRequest(req.Height, hash)
+
+	case KindConsent, KindMessage:
+		msg, err := newMessage(req.Command)
+		if err != nil {
+			return nil, err
+		}
+		if err := msg.OmcDecode(bytes.NewReader(req.Msg), 0, wire.BaseEncoding); err != nil {
+			return nil, fmt.Errorf("%v: %v", ErrBadRequest, err)
+		}
+		r = MessageRequest(msg)
+
+	case KindInvitation:
+		var inv wire.Invitation
+		if err := inv.Deserialize(bytes.NewReader(req.Msg)); err != nil {
+			return nil, fmt.Errorf("%v: %v", ErrBadRequest, err)
+		}
+		r = InvitationRequest(&inv)
+
+	default:
+		return nil, fmt.Errorf("%v: %v", ErrBadRequest, req.Kind)
+	}
+
+	if r.Kind != req.Kind || r.Height != req.Height {
+		return nil, fmt.Errorf("%v: %v at %d is %v at %d", ErrBadRequest, req.Kind, req.Height, r.Kind, r.Height)
+	}
+	return r, nil
+}
+
+// newMessage returns an empty committee message of command.
+func newMessage(command string) (wire.OmegaMessage, error) {
+	switch command {
+	case wire.CmdCandidate:
+		return &wire.MsgCandidate{}, nil
+
+	case wire.CmdCandidateReply:
+		return &wire.MsgCandidateResp{}, nil
+
+	case wire.CmdRelease:
+		return &wire.MsgRelease{}, nil
+
+	case wire.CmdConsensus:
+		return &wire.MsgConsensus{}, nil
+
+	case wire.CmdSignature:
+		return &wire.MsgSignature{}, nil
+
+	case wire.CmdKnowledge:
+		return &wire.MsgKnowledge{}, nil
+
+	case wire.CmdKnowledgeDone:
+		return &wire.MsgKnowledgeDone{}, nil
+	}
+	return nil, fmt.Errorf("%v: unknown message %q", ErrBadRequest, command)
+}
+
+// sign signs req with the key of name once it passes the policy.
+func (d *Daemon) sign(name [20]byte, req *Request) (*btcec.Signature, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	s, ok := d.signers[name]
+	if !ok {
+		return nil, fmt.Errorf("%v %x", ErrUnknownKey, name)
+	}
+	if err := req.Check(); err != nil {
+		return nil, err
+	}
+	if d.policy != nil {
+		if err := d.policy.Check(name, req); err != nil {
+			return nil, err
+		}
+	}
+	return s.Sign(req)
+}
+
+// Client is a connection to a signing daemon. It redials the daemon when the
+// connection is lost.
+type Client struct {
+	path string
+
+	mtx  sync.Mutex
+	conn net.Conn
+	dec  *json.Decoder
+
+	signers   []Signer
+	decrypter Decrypter
+}
+
+// Dial connects to the signing daemon listening on the Unix socket at path and
+// gets the keys it signs for.
+func Dial(path string) (*Client, error) {
+	c := &Client{path: path}
+
+	resp, err := c.call(&daemonRequest{Op: opKeys})
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	for _, k := range resp.Keys {
+		pub, err := btcec.ParsePubKey(k, btcec.S256())
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		c.signers = append(c.signers, &remoteSigner{client: c, pub: pub, name: Name(pub)})
+	}
+
+	if resp, err := c.call(&daemonRequest{Op: opRSAKey}); err == nil {
+		pub, err := x509.ParsePKCS1PublicKey(resp.RSA)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		c.decrypter = &remoteDecrypter{client: c, pub: pub}
+	}
+
+	return c, nil
+}
+
+// Signers returns a signer for each key of the daemon.
+func (c *Client) Signers() []Signer {
+	return c.signers
+}
+
+// Decrypter returns the decrypter of invitations of the daemon, or nil if it
+// has no RSA key.
+func (c *Client) Decrypter() Decrypter {
+	return c.decrypter
+}
+
+// Close closes the connection to the daemon.
+func (c *Client) Close() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// call sends req to the daemon and returns its answer. The daemon is dialed
+// again once if the connection fails.
+func (c *Client) call(req *daemonRequest) (*daemonResponse, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	var resp *daemonResponse
+	var err error
+	for try := 0; try < 2; try++ {
+		if c.conn == nil {
+			conn, err := net.DialTimeout("unix", c.path, callTimeout)
+			if err != nil {
+				return nil, err
+			}
+			c.conn = conn
+			c.dec = json.NewDecoder(bufio.NewReader(conn))
+		}
+
+		if resp, err = c.roundTrip(req); err == nil {
+			break
+		}
+		c.conn.Close()
+		c.conn = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+func (c *Client) roundTrip(req *daemonRequest) (*daemonResponse, error) {
+	c.conn.SetDeadline(time.Now().Add(callTimeout))
+	if err := json.NewEncoder(c.conn).Encode(req); err != nil {
+		return nil, err
+	}
+	var resp daemonResponse
+	if err := c.dec.Decode(&resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// remoteSigner is a Signer of a key held by a signing daemon.
+type remoteSigner struct {
+	client *Client
+	pub    *btcec.PublicKey
+	name   [20]byte
+}
+
+// PubKey is part of the Signer interface.
+func (s *remoteSigner) PubKey() *btcec.PublicKey {
+	return s.pub
+}
+
+// Sign is part of the Signer interface.
+func (s *remoteSigner) Sign(req *Request) (*btcec.Signature, error) {
+	if err := req.Check(); err != nil {
+		return nil, err
+	}
+	call := &daemonRequest{
+		Op:     opSign,
+		Key:    s.name[:],
+		Kind:   req.Kind,
+		Height: req.Height,
+		Hash:   req.Hash[:],
+	}
+	var w bytes.Buffer
+	switch {
+	case req.Message != nil:
+		req.Message.OmcEncode(&w, 0, wire.BaseEncoding)
+		call.Command = req.Message.Command()
+		call.Msg = w.Bytes()
+
+	case req.Invitation != nil:
+		req.Invitation.Serialize(&w)
+		call.Msg = w.Bytes()
+	}
+
+	resp, err := s.client.call(call)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := btcec.ParseDERSignature(resp.Sig, btcec.S256())
+	if err != nil {
+		return nil, err
+	}
+	if !sig.Verify(req.Digest, s.pub) {
+		return nil, errors.New("signing daemon returned an invalid signature")
+	}
+	return sig, nil
+}
+
+// remoteDecrypter is a Decrypter of an RSA key held by a signing daemon.
+type remoteDecrypter struct {
+	client *Client
+	pub    *rsa.PublicKey
+}
+
+// PublicKey is part of the Decrypter interface.
+func (d *remoteDecrypter) PublicKey() *rsa.PublicKey {
+	return d.pub
+}
+
+// Decrypt is part of the Decrypter interface.
+func (d *remoteDecrypter) Decrypt(msg, label []byte) ([]byte, error) {
+	resp, err := d.client.call(&daemonRequest{Op: opDecrypt, Msg: msg, Label: label})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Plain, nil
+}
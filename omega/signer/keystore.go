@@ -0,0 +1,211 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package signer
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/omegasuite/btcd/btcec"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+const keystoreVersion = 1
+
+// scrypt parameters of new keystores. The parameters of a keystore are kept
+// in it, so they may be changed without breaking existing keystores.
+var (
+	scryptN = 1 << 18
+	scryptR = 8
+	scryptP = 1
+)
+
+// ErrPassphrase describes a keystore that does not open with the passphrase
+// given, or that has been tampered with.
+var ErrPassphrase = errors.New("wrong passphrase or corrupt keystore")
+
+// keystoreFile is the content of a keystore file. Data is the secretbox of
+// the JSON encoding of keystoreData, under the key derived from the
+// passphrase by scrypt with Salt, N, R and P.
+type keystoreFile struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	N       int    `json:"n"`
+	R       int    `json:"r"`
+	P       int    `json:"p"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+type keystoreData struct {
+	Keys [][]byte `json:"keys"`          // secp256k1 private keys
+	RSA  []byte   `json:"rsa,omitempty"` // PKCS #1 RSA private key
+}
+
+// Keystore holds the keys of a miner read from a keystore file encrypted with
+// a passphrase.
+type Keystore struct {
+	keys   []*btcec.PrivateKey
+	rsaKey *rsa.PrivateKey
+}
+
+// NewKeystore returns a keystore of keys and, if not nil, the RSA key of
+// invitations.
+func NewKeystore(keys []*btcec.PrivateKey, rsaKey *rsa.PrivateKey) *Keystore {
+	return &Keystore{keys: keys, rsaKey: rsaKey}
+}
+
+// Signers returns a signer for each key of the keystore.
+func (ks *Keystore) Signers() []Signer {
+	signers := make([]Signer, len(ks.keys))
+	for i, k := range ks.keys {
+		signers[i] = NewKeySigner(k)
+	}
+	return signers
+}
+
+// Decrypter returns the decrypter of invitations of the keystore, or nil if it
+// has no RSA key.
+func (ks *Keystore) Decrypter() Decrypter {
+	if ks.rsaKey == nil {
+		return nil
+	}
+	return NewRSADecrypter(ks.rsaKey)
+}
+
+// Write encrypts the keystore with passphrase and writes it to w.
+func (ks *Keystore) Write(w io.Writer, passphrase []byte) error {
+	var data keystoreData
+	for _, k := range ks.keys {
+		data.Keys = append(data.Keys, k.Serialize())
+	}
+	if ks.rsaKey != nil {
+		data.RSA = x509.MarshalPKCS1PrivateKey(ks.rsaKey)
+	}
+	plain, err := json.Marshal(&data)
+	if err != nil {
+		return err
+	}
+
+	f := keystoreFile{
+		Version: keystoreVersion,
+		Salt:    make([]byte, 32),
+		N:       scryptN,
+		R:       scryptR,
+		P:       scryptP,
+		Nonce:   make([]byte, 24),
+	}
+	if _, err := io.ReadFull(rand.Reader, f.Salt); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(rand.Reader, f.Nonce); err != nil {
+		return err
+	}
+
+	key, err := f.key(passphrase)
+	if err != nil {
+		return err
+	}
+	var nonce [24]byte
+	copy(nonce[:], f.Nonce)
+	f.Data = secretbox.Seal(nil, plain, &nonce, key)
+
+	return json.NewEncoder(w).Encode(&f)
+}
+
+// WriteFile encrypts the keystore with passphrase and writes it to the file at
+// path, which must not exist.
+func (ks *Keystore) WriteFile(path string, passphrase []byte) error {
+	fp, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if err = ks.Write(fp, passphrase); err == nil {
+		err = fp.Sync()
+	}
+	if cerr := fp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
+
+// ReadKeystore reads a keystore from r and decrypts it with passphrase.
+func ReadKeystore(r io.Reader, passphrase []byte) (*Keystore, error) {
+	var f keystoreFile
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, err
+	}
+	if f.Version != keystoreVersion {
+		return nil, fmt.Errorf("unsupported keystore version %d", f.Version)
+	}
+	if len(f.Nonce) != 24 {
+		return nil, ErrPassphrase
+	}
+
+	key, err := f.key(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	var nonce [24]byte
+	copy(nonce[:], f.Nonce)
+	plain, ok := secretbox.Open(nil, f.Data, &nonce, key)
+	if !ok {
+		return nil, ErrPassphrase
+	}
+
+	var data keystoreData
+	if err := json.Unmarshal(plain, &data); err != nil {
+		return nil, err
+	}
+
+	ks := &Keystore{}
+	for _, k := range data.Keys {
+		priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), k)
+		ks.keys = append(ks.keys, priv)
+	}
+	if data.RSA != nil {
+		if ks.rsaKey, err = x509.ParsePKCS1PrivateKey(data.RSA); err != nil {
+			return nil, err
+		}
+	}
+	return ks, nil
+}
+
+// OpenKeystore reads the keystore file at path and decrypts it with
+// passphrase.
+func OpenKeystore(path string, passphrase []byte) (*Keystore, error) {
+	fp, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+
+	return ReadKeystore(fp, passphrase)
+}
+
+// key derives the key of the secretbox of f from passphrase.
+func (f *keystoreFile) key(passphrase []byte) (*[32]byte, error) {
+	k, err := scrypt.Key(passphrase, f.Salt, f.N, f.R, f.P, 32)
+	if err != nil {
+		return nil, err
+	}
+	var key [32]byte
+	copy(key[:], k)
+	return &key, nil
+}
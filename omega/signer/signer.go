@@ -0,0 +1,338 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+// Package signer signs blocks and committee messages with the keys of a miner
+// without handing the keys to the code that asks for signatures. A Signer is
+// given a Request telling what is signed, so that a signer kept out of the
+// node, such as the daemon served by Serve, can check it against its policy.
+//
+// The keys are held in memory by a KeySigner, read from an encrypted Keystore
+// file, or held by a signing daemon reached over a Unix socket with Dial.
+package signer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/omegasuite/btcd/btcec"
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/wire"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// Kind is the kind of what is signed.
+type Kind uint8
+
+const (
+	// KindBlock is the signature of a tx block by a member of the committee
+	// or a solo miner. Signing two blocks at a height forfeits the
+	// collateral of the miner.
+	KindBlock Kind = iota
+
+	// KindConsent is a consent to the candidacy of a block at a height.
+	KindConsent
+
+	// KindMessage is any other committee message.
+	KindMessage
+
+	// KindInvitation is an invitation to connect sent to another miner.
+	KindInvitation
+)
+
+var kindStrings = map[Kind]string{
+	KindBlock:      "block",
+	KindConsent:    "consent",
+	KindMessage:    "message",
+	KindInvitation: "invitation",
+}
+
+// String returns the Kind in human-readable form.
+func (k Kind) String() string {
+	if s, ok := kindStrings[k]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown Kind (%d)", uint8(k))
+}
+
+var (
+	// ErrUnknownKey describes a request for a key the signer does not have.
+	ErrUnknownKey = errors.New("unknown key")
+
+	// ErrBadRequest describes a request whose digest is not the one of what
+	// it says is signed.
+	ErrBadRequest = errors.New("digest does not match the request")
+
+	// ErrNoDecrypter describes a request to decrypt an invitation to a
+	// signer that has no RSA key.
+	ErrNoDecrypter = errors.New("no invitation key")
+)
+
+// blockSigPrefix starts what is hashed for the signature of a block. Nothing
+// else signed may start with it.
+const blockSigPrefix = "Omega chain Miner block "
+
+// Request is a request for a signature.
+type Request struct {
+	Kind Kind
+
+	// Height is the height of the block signed, or of the block the
+	// message is about.
+	Height int32
+
+	// Hash is the block signed or consented to. It is not used by other
+	// kinds.
+	Hash chainhash.Hash
+
+	// Digest is what is signed. For a block it is BlockSigHash of Height and
+	// Hash, for a message or an invitation the double hash of its encoding.
+	Digest []byte
+
+	// Message is the committee message signed by a consent or a message
+	// request.
+	Message wire.OmegaMessage
+
+	// Invitation is the invitation signed by an invitation request.
+	Invitation *wire.Invitation
+}
+
+// BlockRequest returns a request for the signature of block hash at height.
+func BlockRequest(height int32, hash chainhash.Hash) *Request {
+	return &Request{
+		Kind:   KindBlock,
+		Height: height,
+		Hash:   hash,
+		Digest: BlockSigHash(height, hash),
+	}
+}
+
+// MessageRequest returns a request for the signature of the committee message
+// msg. A candidate reply consenting to a block is a consent to it, any other
+// message is of KindMessage.
+func MessageRequest(msg wire.OmegaMessage) *Request {
+	req := &Request{
+		Kind:    KindMessage,
+		Message: msg,
+	}
+	if m, ok := msg.(*wire.MsgCandidateResp); ok && m.Reply == "cnst" {
+		req.Kind = KindConsent
+		req.Hash = m.M
+	}
+	if m, ok := msg.(blockMessage); ok {
+		req.Height = m.Block()
+	}
+
+	var w bytes.Buffer
+	msg.OmcEncode(&w, 0, wire.BaseEncoding)
+	req.Digest = chainhash.DoubleHashB(w.Bytes())
+	return req
+}
+
+// InvitationRequest returns a request for the signature of inv.
+func InvitationRequest(inv *wire.Invitation) *Request {
+	var w bytes.Buffer
+	inv.Serialize(&w)
+
+	return &Request{
+		Kind:       KindInvitation,
+		Height:     inv.Height,
+		Digest:     chainhash.DoubleHashB(w.Bytes()),
+		Invitation: inv,
+	}
+}
+
+// blockMessage is a committee message about the block at a height.
+type blockMessage interface {
+	Block() int32
+}
+
+// Check returns an error if the digest of req is not the one of what it says
+// is signed. The digest is made again from the block, the message or the
+// invitation of req, so a request may not pass for one of another kind.
+func (req *Request) Check() error {
+	var want *Request
+	var enc bytes.Buffer
+
+	switch req.Kind {
+	case KindBlock:
+		want = BlockRequest(req.Height, req.Hash)
+
+	case KindConsent, KindMessage:
+		if req.Message == nil {
+			return fmt.Errorf("%v: %v without a message", ErrBadRequest, req.Kind)
+		}
+		if _, ok := req.Message.(blockMessage); !ok {
+			return fmt.Errorf("%v: %s is not a committee message", ErrBadRequest, req.Message.Command())
+		}
+		want = MessageRequest(req.Message)
+		req.Message.OmcEncode(&enc, 0, wire.BaseEncoding)
+		if req.Kind == KindMessage && isConsent(enc.Bytes()) {
+			return fmt.Errorf("%v: message encoded as a consent", ErrBadRequest)
+		}
+
+	case KindInvitation:
+		if req.Invitation == nil {
+			return fmt.Errorf("%v: %v without an invitation", ErrBadRequest, req.Kind)
+		}
+		want = InvitationRequest(req.Invitation)
+		req.Invitation.Serialize(&enc)
+
+	default:
+		return fmt.Errorf("%v: %v", ErrBadRequest, req.Kind)
+	}
+
+	if bytes.HasPrefix(enc.Bytes(), []byte(blockSigPrefix)) {
+		return fmt.Errorf("%v: %v encoded as a block", ErrBadRequest, req.Kind)
+	}
+	if want.Kind != req.Kind || want.Height != req.Height || want.Hash != req.Hash ||
+		!bytes.Equal(want.Digest, req.Digest) {
+		return fmt.Errorf("%v: %v %s at %d", ErrBadRequest, req.Kind, req.Hash.String(), req.Height)
+	}
+	return nil
+}
+
+// isConsent returns whether enc is the encoding of a consent, so that no other
+// message signed is taken for one.
+func isConsent(enc []byte) bool {
+	var m wire.MsgCandidateResp
+	r := bytes.NewReader(enc)
+	if err := m.OmcDecode(r, 0, wire.BaseEncoding); err != nil {
+		return false
+	}
+	return r.Len() == 0 && m.Reply == "cnst"
+}
+
+// BlockSigHash returns the hash signed by a miner signing block hash at
+// height.
+func BlockSigHash(height int32, hash chainhash.Hash) []byte {
+	s1 := blockSigPrefix
+	s2 := " at height "
+	lenth := 36 + len(s1) + len(s2)
+	t := make([]byte, lenth)
+	copy(t[:], []byte(s1))
+	copy(t[len(s1):], hash[:])
+	binary.LittleEndian.PutUint32(t[len(s1)+32:], uint32(height))
+	return chainhash.DoubleHashB(t[:])
+}
+
+// Signer signs for a key.
+type Signer interface {
+	// PubKey returns the public key of the signer.
+	PubKey() *btcec.PublicKey
+
+	// Sign returns the signature of req.Digest once req passes the checks
+	// of the signer.
+	Sign(req *Request) (*btcec.Signature, error)
+}
+
+// Name returns the hash of the compressed public key pub, by which a miner is
+// known.
+func Name(pub *btcec.PublicKey) [20]byte {
+	sha := sha256.Sum256(pub.SerializeCompressed())
+	h := ripemd160.New()
+	h.Write(sha[:])
+
+	var name [20]byte
+	copy(name[:], h.Sum(nil))
+	return name
+}
+
+// Sign signs req with s and returns the signature preceded by the compressed
+// public key of s, the form signatures take in blocks and committee messages.
+func Sign(s Signer, req *Request) ([]byte, error) {
+	sig, err := s.Sign(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ss := sig.Serialize()
+	ssig := make([]byte, btcec.PubKeyBytesLenCompressed+len(ss))
+
+	copy(ssig, s.PubKey().SerializeCompressed())
+	copy(ssig[btcec.PubKeyBytesLenCompressed:], ss)
+
+	return ssig, nil
+}
+
+// ForMessages returns s as the signer of the committee messages of package
+// wire.
+func ForMessages(s Signer) wire.MessageSigner {
+	return messageSigner{s}
+}
+
+type messageSigner struct {
+	Signer
+}
+
+// SignMessage is part of the wire.MessageSigner interface.
+func (s messageSigner) SignMessage(msg wire.OmegaMessage) ([]byte, error) {
+	return Sign(s.Signer, MessageRequest(msg))
+}
+
+// KeySigner is a Signer holding its private key in memory.
+type KeySigner struct {
+	key *btcec.PrivateKey
+}
+
+// NewKeySigner returns a signer signing with key.
+func NewKeySigner(key *btcec.PrivateKey) *KeySigner {
+	return &KeySigner{key: key}
+}
+
+// PubKey is part of the Signer interface.
+func (s *KeySigner) PubKey() *btcec.PublicKey {
+	return s.key.PubKey()
+}
+
+// Sign is part of the Signer interface.
+func (s *KeySigner) Sign(req *Request) (*btcec.Signature, error) {
+	if err := req.Check(); err != nil {
+		return nil, err
+	}
+	return s.key.Sign(req.Digest)
+}
+
+// Decrypter decrypts the invitations sent to a miner. They are encrypted with
+// its RSA public key.
+type Decrypter interface {
+	// PublicKey returns the RSA public key invitations are encrypted with.
+	PublicKey() *rsa.PublicKey
+
+	// Decrypt decrypts an invitation encrypted with RSA-OAEP and SHA-256.
+	Decrypt(msg, label []byte) ([]byte, error)
+}
+
+// RSADecrypter is a Decrypter holding its private key in memory.
+type RSADecrypter struct {
+	key *rsa.PrivateKey
+}
+
+// NewRSADecrypter returns a decrypter decrypting with key.
+func NewRSADecrypter(key *rsa.PrivateKey) *RSADecrypter {
+	return &RSADecrypter{key: key}
+}
+
+// PublicKey is part of the Decrypter interface.
+func (d *RSADecrypter) PublicKey() *rsa.PublicKey {
+	return &d.key.PublicKey
+}
+
+// Decrypt is part of the Decrypter interface.
+func (d *RSADecrypter) Decrypt(msg, label []byte) ([]byte, error) {
+	return rsa.DecryptOAEP(sha256.New(), rand.Reader, d.key, msg, label)
+}
+
+// Policy decides whether a key may sign a request. It is checked by the
+// signing daemon before it signs.
+type Policy interface {
+	Check(name [20]byte, req *Request) error
+}
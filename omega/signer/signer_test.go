@@ -0,0 +1,242 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package signer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/omegasuite/btcd/btcec"
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/wire"
+)
+
+func newKey(t *testing.T) *btcec.PrivateKey {
+	key, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// TestKeystore ensures a keystore reads back the keys written to it, and only
+// with the passphrase it was written with.
+func TestKeystore(t *testing.T) {
+	defer func(n int) { scryptN = n }(scryptN)
+	scryptN = 1 << 10
+
+	key := newKey(t)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := NewKeystore([]*btcec.PrivateKey{key}, rsaKey).Write(&buf, []byte("secret")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadKeystore(bytes.NewReader(buf.Bytes()), []byte("wrong")); err != ErrPassphrase {
+		t.Fatalf("opened with a wrong passphrase: %v", err)
+	}
+
+	ks, err := ReadKeystore(bytes.NewReader(buf.Bytes()), []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signers := ks.Signers()
+	if len(signers) != 1 || !signers[0].PubKey().IsEqual(key.PubKey()) {
+		t.Fatal("keystore does not read back its key")
+	}
+
+	hash := chainhash.Hash{1}
+	req := BlockRequest(5, hash)
+	sig, err := signers[0].Sign(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sig.Verify(req.Digest, key.PubKey()) {
+		t.Fatal("bad signature")
+	}
+
+	d := ks.Decrypter()
+	if d == nil {
+		t.Fatal("keystore does not read back its RSA key")
+	}
+	msg, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, d.PublicKey(), []byte("hello"), []byte("invitation"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain, err := d.Decrypt(msg, []byte("invitation")); err != nil || string(plain) != "hello" {
+		t.Fatalf("decrypt: %q %v", plain, err)
+	}
+}
+
+// heightPolicy refuses to sign two blocks at a height.
+type heightPolicy map[[20]byte]map[int32]chainhash.Hash
+
+func (p heightPolicy) Check(name [20]byte, req *Request) error {
+	if req.Kind != KindBlock {
+		return nil
+	}
+	if p[name] == nil {
+		p[name] = make(map[int32]chainhash.Hash)
+	}
+	if h, ok := p[name][req.Height]; ok && h != req.Hash {
+		return fmt.Errorf("another block has been signed at %d", req.Height)
+	}
+	p[name][req.Height] = req.Hash
+	return nil
+}
+
+// TestDaemon ensures a client signs with the keys of a daemon, and that the
+// daemon refuses the requests its policy refuses.
+func TestDaemon(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "sock")
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := newKey(t)
+	d := NewDaemon([]Signer{NewKeySigner(key)}, nil, heightPolicy{})
+	served := make(chan error, 1)
+	go func() { served <- d.Serve(l) }()
+	defer func() {
+		l.Close()
+		<-served
+	}()
+
+	c, err := Dial(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	signers := c.Signers()
+	if len(signers) != 1 || !signers[0].PubKey().IsEqual(key.PubKey()) {
+		t.Fatal("client does not get the key of the daemon")
+	}
+	if c.Decrypter() != nil {
+		t.Fatal("client has a decrypter the daemon does not have")
+	}
+	s := signers[0]
+
+	if _, err := Sign(s, BlockRequest(7, chainhash.Hash{1})); err != nil {
+		t.Fatal(err)
+	}
+	// signing the same block again is allowed
+	if _, err := Sign(s, BlockRequest(7, chainhash.Hash{1})); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Sign(s, BlockRequest(7, chainhash.Hash{2})); err == nil {
+		t.Fatal("daemon signed a second block at a height")
+	}
+
+	// a block request must be for the digest of its block
+	req := BlockRequest(8, chainhash.Hash{3})
+	req.Digest = BlockSigHash(8, chainhash.Hash{4})
+	if _, err := s.Sign(req); err == nil {
+		t.Fatal("signed a block request with a digest of another block")
+	}
+	// the daemon makes the digest from what it is told is signed, and a
+	// consent may not pass for a message
+	var w bytes.Buffer
+	cnst := &wire.MsgCandidateResp{Height: 8, Reply: "cnst", M: chainhash.Hash{3}}
+	cnst.OmcEncode(&w, 0, wire.BaseEncoding)
+	resp := d.handle(&daemonRequest{Op: opSign, Key: s.(*remoteSigner).name[:],
+		Kind: KindMessage, Height: 8, Command: cnst.Command(), Msg: w.Bytes()})
+	if resp.Error == "" {
+		t.Fatal("daemon signed a consent as a message")
+	}
+
+	// the client redials a daemon whose connection is lost
+	c.mtx.Lock()
+	c.conn.Close()
+	c.mtx.Unlock()
+	if _, err := Sign(s, MessageRequest(cnst)); err != nil {
+		t.Fatal(err)
+	}
+	inv := &wire.Invitation{Height: 8, IP: []byte("127.0.0.1")}
+	copy(inv.Pubkey[:], key.PubKey().SerializeCompressed())
+	if _, err := Sign(s, InvitationRequest(inv)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestCheck ensures a request is refused unless its digest is the one of what
+// it says is signed.
+func TestCheck(t *testing.T) {
+	hash := chainhash.Hash{1}
+
+	rls := &wire.MsgRelease{Height: 7, M: hash}
+	cnst := &wire.MsgCandidateResp{Height: 7, Reply: "cnst", M: hash}
+	inv := &wire.Invitation{Height: 7, IP: []byte("127.0.0.1")}
+	for _, req := range []*Request{BlockRequest(7, hash), MessageRequest(rls),
+		MessageRequest(cnst), InvitationRequest(inv)} {
+		if err := req.Check(); err != nil {
+			t.Fatalf("%v: %v", req.Kind, err)
+		}
+	}
+	if req := MessageRequest(cnst); req.Kind != KindConsent || req.Hash != hash {
+		t.Fatalf("consent is a request of %v for %s", req.Kind, req.Hash.String())
+	}
+
+	tests := []struct {
+		name string
+		req  *Request
+	}{
+		{"message signing a block", func() *Request {
+			req := MessageRequest(rls)
+			req.Digest = BlockSigHash(7, hash)
+			return req
+		}()},
+		{"consent as a message", func() *Request {
+			req := MessageRequest(cnst)
+			req.Kind, req.Hash = KindMessage, chainhash.Hash{}
+			return req
+		}()},
+		{"consent to another block", func() *Request {
+			req := MessageRequest(cnst)
+			req.Hash = chainhash.Hash{2}
+			return req
+		}()},
+		{"message at another height", func() *Request {
+			req := MessageRequest(rls)
+			req.Height = 8
+			return req
+		}()},
+		{"message without a message", &Request{Kind: KindMessage, Height: 7,
+			Digest: rls.DoubleHashB()}},
+		{"invitation at another height", func() *Request {
+			req := InvitationRequest(inv)
+			req.Height = 8
+			return req
+		}()},
+		{"unknown kind", &Request{Kind: KindInvitation + 1, Digest: make([]byte, 32)}},
+	}
+	for _, test := range tests {
+		if err := test.req.Check(); err == nil {
+			t.Errorf("%s: request passes", test.name)
+		}
+	}
+}
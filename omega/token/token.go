@@ -29,7 +29,7 @@ const (
 	DefTypeVertex = 0
 	DefTypeBorder = 1
 	DefTypePolygon = 2		// also a loop, can be mixed
-	//	DefTypePolyhedron = 3
+	DefTypePolyhedron = 3	// a polygon extruded between two altitudes
 	DefTypeRight = 4
 	DefTypeRightSet = 5
 
@@ -466,6 +466,111 @@ func (t * PolygonDef) MemWrite(w io.Writer, pver uint32) error {
 	return nil
 }
 
+// PolyhedronDef is a prism: the volume above the polygon Polygon between the
+// altitudes Floor and Ceiling, in AltPrecision fixed point meters. It is used
+// as a polygon token for air rights and underground rights. Vertex altitudes
+// are not used for the purpose as they are nonces in the hashes of borders.
+type PolyhedronDef struct {
+	hash * chainhash.Hash
+	Polygon chainhash.Hash
+	Floor int32
+	Ceiling int32
+}
+
+func (s * PolyhedronDef) Match(p Definition) bool {
+	switch p.(type) {
+	case * PolyhedronDef:
+		t := p.(* PolyhedronDef)
+		return s.Polygon.IsEqual(&t.Polygon) && s.Floor == t.Floor && s.Ceiling == t.Ceiling
+	default:
+		return false
+	}
+}
+
+func (t * PolyhedronDef) DefType() uint8 {
+	return DefTypePolyhedron
+}
+
+func (t * PolyhedronDef) IsSeparator() bool {
+	return false
+}
+
+// Hash returns the hash of the polyhedron. The hashed data is 40 bytes long,
+// so it never collides with the hash of a polygon, which hashes a multiple
+// of 32 bytes.
+func (t * PolyhedronDef) Hash() chainhash.Hash {
+	if t.hash == nil {
+		b := make([]byte, chainhash.HashSize + 8)
+		copy(b[:], t.Polygon[:])
+		binary.LittleEndian.PutUint32(b[chainhash.HashSize:], uint32(t.Floor))
+		binary.LittleEndian.PutUint32(b[chainhash.HashSize + 4:], uint32(t.Ceiling))
+
+		hash := chainhash.HashH(b)
+		t.hash = &hash
+	}
+	return * t.hash
+}
+
+func (t * PolyhedronDef) SerializeSize() int {
+	return chainhash.HashSize + 8
+}
+
+func (t * PolyhedronDef) Size() int {
+	return chainhash.HashSize + 8
+}
+
+// Overlaps returns whether the altitude ranges of two polyhedra overlap.
+func (t * PolyhedronDef) Overlaps(s * PolyhedronDef) bool {
+	return t.Floor < s.Ceiling && s.Floor < t.Ceiling
+}
+
+func NewPolyhedronDef(polygon chainhash.Hash, floor, ceiling int32) (* PolyhedronDef) {
+	if floor >= ceiling {
+		return nil
+	}
+	t := PolyhedronDef{}
+	t.Polygon = polygon
+	t.Floor = floor
+	t.Ceiling = ceiling
+
+	return &t
+}
+
+func (t * PolyhedronDef) MemRead(r io.Reader, pver uint32) error {
+	return t.Read(r, pver)
+}
+
+func (t * PolyhedronDef) Read(r io.Reader, pver uint32) error {
+	if _, err := io.ReadFull(r, t.Polygon[:]); err != nil {
+		return err
+	}
+
+	var b [8]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return err
+	}
+	t.Floor = int32(binary.LittleEndian.Uint32(b[:]))
+	t.Ceiling = int32(binary.LittleEndian.Uint32(b[4:]))
+
+	return nil
+}
+
+func (t * PolyhedronDef) MemWrite(w io.Writer, pver uint32) error {
+	return t.Write(w, pver)
+}
+
+func (t * PolyhedronDef) Write(w io.Writer, pver uint32) error {
+	var b [8]byte
+	binary.LittleEndian.PutUint32(b[:], uint32(t.Floor))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.Ceiling))
+
+	if _, err := w.Write(t.Polygon[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(b[:])
+	return err
+}
+
 type RightDef struct {
 	hash * chainhash.Hash
 	Father chainhash.Hash
@@ -849,6 +954,13 @@ func RemapDef(txDef []Definition, to Definition) Definition {
 		}
 		return p
 
+	case DefTypePolyhedron:
+		p := to.(*PolyhedronDef)
+		if r := NeedRemap(p.Polygon[:]); len(r) > 0 {
+			p.Polygon = txDef[Bytetoint(r[1])].Hash()
+		}
+		return p
+
 	case DefTypeRight:
 		r := to.(*RightDef)
 		if t := NeedRemap(r.Father[:]); len(t) > 0 {
@@ -897,6 +1009,15 @@ func (c *PolygonDef) Dup() Definition {
 	return &newDefinitions
 }
 
+func (c *PolyhedronDef) Dup() Definition {
+	newDefinitions := PolyhedronDef{
+		Floor:   c.Floor,
+		Ceiling: c.Ceiling,
+	}
+	newDefinitions.Polygon.SetBytes(c.Polygon[:])
+	return &newDefinitions
+}
+
 func (c *RightDef) Dup() Definition {
 	newDefinitions := RightDef{
 		Attrib: c.Attrib,
@@ -959,6 +1080,11 @@ func ReadDefinition(r io.Reader, pver uint32, version int32) (Definition, error)
 		err = c.Read(r, pver)
 		return &c, err
 
+	case DefTypePolyhedron:
+		c := PolyhedronDef{}
+		err = c.Read(r, pver)
+		return &c, err
+
 	case DefTypeRight:
 		c := RightDef{}
 		err = c.Read(r, pver)
@@ -998,6 +1124,10 @@ func WriteDefinition(w io.Writer, pver uint32, version int32, ti Definition) err
 		c := ti.(*PolygonDef)
 		err = c.Write(w, pver)
 		break;
+	case DefTypePolyhedron:
+		c := ti.(*PolyhedronDef)
+		err = c.Write(w, pver)
+		break;
 	case DefTypeRight:
 		c := ti.(*RightDef)
 		err = c.Write(w, pver)
@@ -22,6 +22,20 @@ func CheckDefinitions(msgTx *wire.MsgTx) error {
 	return e
 }
 
+// CheckDefinitionsVersion checks that the types of definitions in msgTx are
+// active in blocks of the given version. Polyhedra are from Version7.
+func CheckDefinitionsVersion(msgTx *wire.MsgTx, version uint32) error {
+	if version >= wire.Version7 {
+		return nil
+	}
+	for _, def := range msgTx.TxDef {
+		if def.DefType() == token.DefTypePolyhedron {
+			return ruleError(1, "Polyhedron definitions are not active in this block version.")
+		}
+	}
+	return nil
+}
+
 func ScanDefinitions(msgTx *wire.MsgTx) (error, map[chainhash.Hash]*token.RightDef, map[chainhash.Hash]*token.RightSetDef) {
 	// for every definition, if it is a new vertex, it must be referenced by a border definition
 	// in the same tx. for every top border (father=nil) definition, it must be referenced by a polygon definition
@@ -85,6 +99,11 @@ func ScanDefinitions(msgTx *wire.MsgTx) (error, map[chainhash.Hash]*token.RightD
 								break checked
 							}
 						}
+					case *token.PolyhedronDef:
+						if q.(*token.PolyhedronDef).Polygon.IsEqual(&h) {
+							refd = true
+							break checked
+						}
 					}
 				}
 			}
@@ -93,6 +112,25 @@ func ScanDefinitions(msgTx *wire.MsgTx) (error, map[chainhash.Hash]*token.RightD
 				return ruleError(1, str), nil, nil
 			}
 
+		case *token.PolyhedronDef:
+			v := def.(*token.PolyhedronDef)
+			refd := false
+			h := v.Hash()
+			for _,to := range msgTx.TxOut {
+				if to.IsSeparator() || (to.TokenType != 3 && to.TokenType != 1) {
+					continue
+				}
+				n := to.Value.(*token.HashToken).Hash
+				if n.IsEqual(&h) {
+					refd = true
+					break
+				}
+			}
+			if !refd {
+				str := fmt.Sprintf("Polyhedron %s is defined but not referenced.", h.String())
+				return ruleError(1, str), nil, nil
+			}
+
 		case *token.RightDef:
 			v := def.(*token.RightDef)
 			refd := false
@@ -277,9 +315,10 @@ func CheckTransactionInputs(tx *btcutil.Tx, views * viewpoint.ViewPointSet) erro
 		if utxo.TokenType != 3 {
 			continue
 		}
-		plg,err := views.FetchPolygonEntry(&utxo.Amount.(*token.HashToken).Hash)
-		if err != nil {
-			return err
+		plg,_ := views.TokenPolygon(&utxo.Amount.(*token.HashToken).Hash)
+		if plg == nil {
+			str := fmt.Sprintf("Polygon token %s does not exist.", utxo.Amount.(*token.HashToken).Hash.String())
+			return ruleError(1, str)
 		}
 		ccws := make([]string, 0, 1)
 		cs := plg.Loops[0].CheckSum()
@@ -324,19 +363,10 @@ func CheckTransactionInputs(tx *btcutil.Tx, views * viewpoint.ViewPointSet) erro
 			}
 			
 			// a newly defined polygon must be used in this Tx. it is either a polygon in txout,
-			// or be used by other polygon. if it is used in a txout, the first loop must be ccw,
-			// otherwise cw.
-			ccw := false
+			// the footprint of a polyhedron, or be used by other polygon. if it is used in a
+			// txout or as a footprint, the first loop must be ccw, otherwise cw.
 			th := p.Hash()
-			for _,out := range tx.MsgTx().TxOut {
-				if out.IsSeparator() {
-					continue
-				}
-				if out.TokenType == 3 && out.Value.(*token.HashToken).Hash.IsEqual(&th) {
-					ccw = true
-					break
-				}
-			}
+			ccw := viewpoint.TokenPolygonDefined(tx, th)
 			var rcw bool
 			var bx viewpoint.BoundingBox
 			if rcw,bx = views.PolygonInfo(p); rcw != ccw {
@@ -344,6 +374,20 @@ func CheckTransactionInputs(tx *btcutil.Tx, views * viewpoint.ViewPointSet) erro
 			}
 			views.AddOnePolygon(p, ccw, bx)
 
+		case *token.PolyhedronDef:
+			p := d.(*token.PolyhedronDef)
+			if p.Floor >= p.Ceiling {
+				return ruleError(1, "Illegal Polyhedron definition.")
+			}
+			// the footprint must be a polygon that may be used as a token
+			plg,_ := views.FetchPolygonEntry(&p.Polygon)
+			if plg == nil || plg.FirstCW {
+				return ruleError(1, "Illegal Polyhedron definition.")
+			}
+			if !views.AddOnePolyhedron(p) {
+				return ruleError(1, "Illegal Polyhedron definition.")
+			}
+
 		case *token.BorderDef:
 			ft,_ := views.FetchBorderEntry(&h)
 			if ft != nil {		// no repeat definition
@@ -528,7 +572,10 @@ func CheckTransactionAdditionalInputs(tx *btcutil.Tx, views * viewpoint.ViewPoin
 
 func appeared(p * chainhash.Hash, as map[chainhash.Hash]struct{}, views * viewpoint.ViewPointSet) int32 {
 	sum := int32(0)
-	plg,_ := views.FetchPolygonEntry(p)
+	plg,_ := views.TokenPolygon(p)
+	if plg == nil {
+		return 0
+	}
 	for _, loop := range plg.Loops {
 		if len(loop) == 1 {
 			// it is a polygon
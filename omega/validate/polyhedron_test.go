@@ -0,0 +1,139 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package validate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/database"
+	_ "github.com/omegasuite/btcd/database/ffldb"
+	"github.com/omegasuite/btcd/wire"
+	"github.com/omegasuite/btcd/wire/common"
+	"github.com/omegasuite/btcutil"
+	"github.com/omegasuite/omega/internal/testutil"
+	"github.com/omegasuite/omega/token"
+	"github.com/omegasuite/omega/viewpoint"
+)
+
+// verify checks the definitions and the geometry of mtx as a node would in a
+// block of the given version.
+func verify(db database.DB, mtx *wire.MsgTx, version uint32) error {
+	if err := CheckDefinitions(mtx); err != nil {
+		return err
+	}
+	if err := CheckDefinitionsVersion(mtx, version); err != nil {
+		return err
+	}
+
+	views := viewpoint.NewViewPointSet(db)
+	outpoints := make(map[wire.OutPoint]struct{})
+	for _, txIn := range mtx.TxIn {
+		outpoints[txIn.PreviousOutPoint] = struct{}{}
+	}
+	if err := views.Utxo.FetchUtxosMain(db, outpoints); err != nil {
+		return err
+	}
+
+	tx := btcutil.NewTx(mtx)
+	if err := CheckTransactionInputs(tx, views); err != nil {
+		return err
+	}
+	if !CheckGeometryIntegrity(tx, views, version) {
+		return ruleError(1, "geometry is not integral")
+	}
+	return nil
+}
+
+// TestPolyhedra ensures polyhedra are accepted from Version7 only and may be
+// split vertically only into layers that do not overlap and fill the original.
+func TestPolyhedra(t *testing.T) {
+	dbPath := filepath.Join(os.TempDir(), "validate-polyhedra")
+	_ = os.RemoveAll(dbPath)
+	db, err := database.Create("ffldb", dbPath, common.MainNet)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+	defer db.Close()
+
+	err = db.Update(func(dbTx database.Tx) error {
+		for _, name := range []string{"utxosetv2", "borders", "polygons", "polyhedra", "rights"} {
+			if _, err := dbTx.Metadata().CreateBucket([]byte(name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("buckets: %v", err)
+	}
+
+	pkScript := make([]byte, 25)
+	pkScript[1] = 1
+	pkScript[21] = 0x41
+
+	// the air above a 2 by 2 degrees square up to 100 m
+	defs, plg := testutil.Square(0, 0, 2*token.CoordPrecision, 2*token.CoordPrecision)
+	right := token.NewRightDef(chainhash.Hash{}, []byte("air"), 0)
+	rights := right.Hash()
+	air := token.NewPolyhedronDef(plg.Hash(), 0, 100*token.AltPrecision)
+	mtx := wire.NewMsgTx(wire.TxVersion)
+	mtx.AddDef(right)
+	for _, d := range defs {
+		mtx.AddDef(d)
+	}
+	mtx.AddDef(air)
+	mtx.AddTxOut(wire.NewTxOut(3, &token.HashToken{Hash: air.Hash()}, &rights, pkScript))
+	if err := verify(db, mtx, wire.Version6); err == nil {
+		t.Errorf("polyhedron accepted before Version7")
+	}
+	if err := verify(db, mtx, wire.Version7); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	txid := testutil.Apply(t, db, mtx)
+
+	// layers of the air
+	layers := func(alts ...int32) *wire.MsgTx {
+		mtx := wire.NewMsgTx(wire.TxVersion)
+		mtx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(txid, 0), 0))
+		for i := 1; i < len(alts); i++ {
+			p := token.NewPolyhedronDef(plg.Hash(), alts[i-1]*token.AltPrecision, alts[i]*token.AltPrecision)
+			mtx.AddDef(p)
+			mtx.AddTxOut(wire.NewTxOut(3, &token.HashToken{Hash: p.Hash()}, &rights, pkScript))
+		}
+		return mtx
+	}
+
+	if err := verify(db, layers(0, 40, 100), wire.Version7); err != nil {
+		t.Errorf("split into layers rejected: %v", err)
+	}
+	if err := verify(db, layers(0, 40, 100), wire.Version6); err == nil {
+		t.Errorf("split into layers accepted before Version7")
+	}
+	if err := verify(db, layers(0, 40, 70, 100), wire.Version7); err != nil {
+		t.Errorf("split into 3 layers rejected: %v", err)
+	}
+	if err := verify(db, layers(0, 40), wire.Version7); err == nil {
+		t.Errorf("split losing the upper layer accepted")
+	}
+	if err := verify(db, layers(0, 40, 120), wire.Version7); err == nil {
+		t.Errorf("split above the ceiling accepted")
+	}
+
+	overlap := layers(0, 60)
+	p := token.NewPolyhedronDef(plg.Hash(), 40*token.AltPrecision, 100*token.AltPrecision)
+	overlap.AddDef(p)
+	overlap.AddTxOut(wire.NewTxOut(3, &token.HashToken{Hash: p.Hash()}, &rights, pkScript))
+	if err := verify(db, overlap, wire.Version7); err == nil {
+		t.Errorf("vertically overlapping layers accepted")
+	}
+}
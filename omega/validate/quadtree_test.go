@@ -0,0 +1,122 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package validate
+
+import (
+	"testing"
+
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/wire"
+	"github.com/omegasuite/omega/viewpoint"
+)
+
+// TestQuadtree ensures the border matching of the geometry check keeps its
+// behavior before Version7 and applies the Version7 rules from it.
+func TestQuadtree(t *testing.T) {
+	const (
+		root = uint64(0x8000000080000000)
+		ne   = uint64(0xC0000000C0000000)
+	)
+
+	border := chainhash.Hash{0x10, 1}
+	reverse := border
+	reverse[0] ^= 1
+
+	type edge struct {
+		h  chainhash.Hash
+		x  uint64
+		in bool
+	}
+
+	tests := []struct {
+		name    string
+		version uint32
+		edges   []edge
+		added   bool // whether all borders were put in the tree
+		same    bool // whether the borders match
+	}{
+		{
+			name:    "in and out cancel before Version7",
+			version: wire.Version6,
+			edges:   []edge{{border, root, true}, {border, root, false}},
+			added:   true,
+			same:    true,
+		},
+		{
+			name:    "in and out cancel from Version7",
+			version: wire.Version7,
+			edges:   []edge{{border, root, true}, {border, root, false}},
+			added:   true,
+			same:    true,
+		},
+		{
+			name:    "border and reverse do not cancel before Version7",
+			version: wire.Version6,
+			edges:   []edge{{border, root, true}, {reverse, root, true}},
+			added:   true,
+			same:    false,
+		},
+		{
+			name:    "border and reverse cancel from Version7",
+			version: wire.Version7,
+			edges:   []edge{{border, root, true}, {reverse, root, true}},
+			added:   true,
+			same:    true,
+		},
+		{
+			name:    "unmatched border from Version7",
+			version: wire.Version7,
+			edges:   []edge{{border, ne, true}},
+			added:   true,
+			same:    false,
+		},
+		{
+			name:    "in and out cancel in a quadrant from Version7",
+			version: wire.Version7,
+			edges:   []edge{{border, ne, true}, {border, ne, false}},
+			added:   true,
+			same:    true,
+		},
+		{
+			name:    "no box of the index from Version7",
+			version: wire.Version7,
+			edges:   []edge{{border, 0, true}},
+			added:   false,
+		},
+	}
+
+	for _, test := range tests {
+		tree := quadtree{version: test.version}
+		tree.reset(root)
+		added := true
+		for _, e := range test.edges {
+			if !tree.insert(e.h, &viewpoint.BorderEntry{}, e.x, e.in) {
+				added = false
+			}
+		}
+		if added != test.added {
+			t.Errorf("%s: insert got %v, want %v", test.name, added, test.added)
+			continue
+		}
+		if !added {
+			continue
+		}
+		if same := tree.expand(nil); same != test.same {
+			t.Errorf("%s: expand got %v, want %v", test.name, same, test.same)
+		}
+	}
+
+	// from Version7, a border goes to the quadrant of its box
+	tree := quadtree{version: wire.Version7}
+	tree.reset(root)
+	tree.insert(border, &viewpoint.BorderEntry{}, ne, true)
+	if s, ok := tree.substrees[ne]; !ok || len(s.inedges) != 1 {
+		t.Errorf("border is not in the box of index %x", ne)
+	}
+}
@@ -408,7 +408,7 @@ func QuickCheckRight(tx *btcutil.Tx, views *viewpoint.ViewPointSet, ver uint32)
 
 	for _, txDef := range msgtx.TxDef {
 		switch txDef.(type) {
-		case *token.PolygonDef:
+		case *token.PolygonDef, *token.PolyhedronDef:
 			checkPolygon = false
 		}
 	}
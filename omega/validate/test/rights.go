@@ -154,7 +154,7 @@ func addRighttoDB(r rdef) {
 			Desc: x,
 			Attrib: uint8(r.Attrib),
 		}
-		views.AddRight(&p)
+		views.AddRight(&p, wire.Version5)
 	} else if r.Type == 5 {
 		p := token.RightSetDef {
 			Rights: []chainhash.Hash{},
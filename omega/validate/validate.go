@@ -10,8 +10,10 @@ package validate
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/wire"
 	"github.com/omegasuite/btcutil"
 	"github.com/omegasuite/omega/token"
 	"github.com/omegasuite/omega/viewpoint"
@@ -457,6 +459,8 @@ func intersect(v *edge, dir float64, vp *edge, l *edge) bool {
 
 type quadtree struct {
 	index uint64
+	level int
+	version uint32
 	substrees map[uint64]*quadtree
 	inedges map[chainhash.Hash]*viewpoint.BorderEntry
 	outedges map[chainhash.Hash]*viewpoint.BorderEntry
@@ -469,6 +473,16 @@ func (t * quadtree) reset(x uint64) {
 	t.outedges = make(map[chainhash.Hash]*viewpoint.BorderEntry)
 }
 
+// fetch returns the entry of border h. From Version7, h may be the reverse
+// of the border as the borders of polygons and the children of borders are.
+func (t * quadtree) fetch(view *viewpoint.ViewPointSet, h chainhash.Hash) *viewpoint.BorderEntry {
+	if t.version >= wire.Version7 {
+		h[0] &^= 1
+	}
+	e, _ := view.FetchBorderEntry(&h)
+	return e
+}
+
 func (t * quadtree) add(h chainhash.Hash, e *viewpoint.BorderEntry, x uint64, in bool) {
 	if in {
 		if _,ok := t.outedges[h]; ok {
@@ -515,6 +529,82 @@ func (t * quadtree) add(h chainhash.Hash, e *viewpoint.BorderEntry, x uint64, in
 	t.substrees[ni].add(h, e, x, in)
 }
 
+// insert puts border h in the box of index x, with add before Version7 and
+// with add7 from it. It returns false if the border can not be put anywhere.
+func (t * quadtree) insert(h chainhash.Hash, e *viewpoint.BorderEntry, x uint64, in bool) bool {
+	if t.version < wire.Version7 {
+		t.add(h, e, x, in)
+		return true
+	}
+	return t.add7(h, e, x, in)
+}
+
+// add7 is add from Version7. A border and its reverse on the same side cancel
+// out, and a border goes to the quadrant of the box containing it. It returns
+// false if the box is not found within the 32 levels of the tree. Such a box
+// does not exist, and a border not put anywhere would never be matched.
+func (t * quadtree) add7(h chainhash.Hash, e *viewpoint.BorderEntry, x uint64, in bool) bool {
+	if in {
+		if _,ok := t.outedges[h]; ok {
+			delete(t.outedges, h)
+			return true
+		}
+	} else {
+		if _,ok := t.inedges[h]; ok {
+			delete(t.inedges, h)
+			return true
+		}
+	}
+	if t.index == x {
+		r := h
+		r[0] ^= 1
+		if in {
+			if _,ok := t.inedges[r]; ok {
+				delete(t.inedges, r)
+			} else {
+				t.inedges[h] = e
+			}
+		} else {
+			if _,ok := t.outedges[r]; ok {
+				delete(t.outedges, r)
+			} else {
+				t.outedges[h] = e
+			}
+		}
+		return true
+	}
+
+	if t.level >= 32 {
+		return false
+	}
+
+	// the index is the center of the box in both coordinates, its lowest
+	// set bit being half the size of the box. x is the index of a smaller
+	// box inside it, so it goes to the quadrant with the center on its side.
+	hi := t.index & 0xFFFFFFFF
+	hi &= -hi
+	hi >>= 1
+	mx := t.index & 0xFFFFFFFF
+	my := t.index >> 32
+	if (x & 0xFFFFFFFF) > mx {
+		mx += hi
+	} else {
+		mx -= hi
+	}
+	if (x >> 32) > my {
+		my += hi
+	} else {
+		my -= hi
+	}
+	ni := mx | (my << 32)
+	if _,ok := t.substrees[ni]; !ok {
+		s := &quadtree{level: t.level + 1, version: t.version}
+		s.reset(ni)
+		t.substrees[ni] = s
+	}
+	return t.substrees[ni].add7(h, e, x, in)
+}
+
 func (t * quadtree) expand(view *viewpoint.ViewPointSet) bool {
 	check := true
 	for check {
@@ -527,8 +617,10 @@ func (t * quadtree) expand(view *viewpoint.ViewPointSet) bool {
 					if _, ok := t.outedges[p]; ok {
 						delete(t.outedges, p)
 					} else {
-						be, _ := view.FetchBorderEntry(&p)
-						t.add(p, be, be.Boxindex(), true)
+						be := t.fetch(view, p)
+						if !t.insert(p, be, be.Boxindex(), true) {
+							return false
+						}
 						check = true
 					}
 				}
@@ -547,8 +639,10 @@ func (t * quadtree) expand(view *viewpoint.ViewPointSet) bool {
 					if _, ok := t.inedges[p]; ok {
 						delete(t.inedges, p)
 					} else {
-						be, _ := view.FetchBorderEntry(&p)
-						t.add(p, be, be.Boxindex(), false)
+						be := t.fetch(view, p)
+						if !t.insert(p, be, be.Boxindex(), false) {
+							return false
+						}
 						check = true
 					}
 				}
@@ -569,7 +663,7 @@ func (t * quadtree) expand(view *viewpoint.ViewPointSet) bool {
 	return true
 }
 
-func CheckGeometryIntegrity(tx *btcutil.Tx, views *viewpoint.ViewPointSet) bool {
+func CheckGeometryIntegrity(tx *btcutil.Tx, views *viewpoint.ViewPointSet, version uint32) bool {
 	rset := parseRights(tx, views, false, 0)	// monitored
 
 	// basic right set
@@ -577,6 +671,8 @@ func CheckGeometryIntegrity(tx *btcutil.Tx, views *viewpoint.ViewPointSet) bool
 
 	// Group geometries by their rights. map[right][in/out]polygon
 	groups := make(map[tokenElement][2]map[chainhash.Hash]struct{})
+	// polyhedra are grouped separately as they do not convert to polygons
+	solids := make(map[tokenElement][2]map[chainhash.Hash]struct{})
 	tokens := ioTokens(tx, views)
 
 	for io, tks := range tokens {
@@ -587,6 +683,11 @@ func CheckGeometryIntegrity(tx *btcutil.Tx, views *viewpoint.ViewPointSet) bool
 			}
 			y := TokenRights(views, &emt)
 
+			gs := groups
+			if _, solid := views.TokenPolygon(&emt.value.(*token.HashToken).Hash); solid != nil {
+				gs = solids
+			}
+
 			for s, _ := range basicRS {
 				emt.right = s
 				e, _ := views.FetchRightEntry(&s)
@@ -611,13 +712,13 @@ decendent:
 					continue
 				}
 
-				if _, ok := groups[emt.tokenElement]; !ok {
-					groups[emt.tokenElement] = [2]map[chainhash.Hash]struct{}{
+				if _, ok := gs[emt.tokenElement]; !ok {
+					gs[emt.tokenElement] = [2]map[chainhash.Hash]struct{}{
 						make(map[chainhash.Hash]struct{}),
 						make(map[chainhash.Hash]struct{})}
 				}
-				if _, ok := groups[emt.tokenElement][io][emt.value.(*token.HashToken).Hash]; !ok {
-					groups[emt.tokenElement][io][emt.value.(*token.HashToken).Hash] = struct{}{}
+				if _, ok := gs[emt.tokenElement][io][emt.value.(*token.HashToken).Hash]; !ok {
+					gs[emt.tokenElement][io][emt.value.(*token.HashToken).Hash] = struct{}{}
 				} else {
 					return false // duplicated combination
 				}
@@ -649,31 +750,7 @@ decendent:
 			return false
 		}
 
-		// map is always passed as reference in func calls
-		ingeo := make(map[chainhash.Hash]struct{})
-		outgeo := make(map[chainhash.Hash]struct{})
-
-		Borders(g[0], views, ingeo)
-		Borders(g[1], views, outgeo)
-
-		for b, _ := range ingeo {
-			if _, ok := outgeo[b]; ok {
-				delete(outgeo, b)
-				delete(ingeo, b)
-			}
-		}
-
-		var root quadtree
-		root.reset(0x8000000080000000)
-		for b, _ := range ingeo {
-			e,_ := views.FetchBorderEntry(&b)
-			root.add(b, e, e.Boxindex(), true)
-		}
-		for b, _ := range outgeo {
-			e,_ := views.FetchBorderEntry(&b)
-			root.add(b, e, e.Boxindex(), false)
-		}
-		if !root.expand(views) {
+		if !sameArea(g[0], g[1], views, version) {
 			return false
 		}
 /*
@@ -747,6 +824,116 @@ decendent:
 		 */
 	}
 
+	for _, g := range solids {
+		if !sameVolume(g[0], g[1], views, version) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sameArea returns whether the polygons in and out cover the same area. Both
+// sides must be free of overlaps. Before Version7, borders divided on one side
+// only are not matched with their children.
+func sameArea(in, out map[chainhash.Hash]struct{}, views *viewpoint.ViewPointSet, version uint32) bool {
+	// map is always passed as reference in func calls
+	ingeo := make(map[chainhash.Hash]struct{})
+	outgeo := make(map[chainhash.Hash]struct{})
+
+	Borders(in, views, ingeo)
+	Borders(out, views, outgeo)
+
+	for b, _ := range ingeo {
+		if _, ok := outgeo[b]; ok {
+			delete(outgeo, b)
+			delete(ingeo, b)
+		}
+	}
+
+	root := quadtree{version: version}
+	root.reset(0x8000000080000000)
+	for b, _ := range ingeo {
+		e := root.fetch(views, b)
+		if !root.insert(b, e, e.Boxindex(), true) {
+			return false
+		}
+	}
+	for b, _ := range outgeo {
+		e := root.fetch(views, b)
+		if !root.insert(b, e, e.Boxindex(), false) {
+			return false
+		}
+	}
+	return root.expand(views)
+}
+
+// sameVolume returns whether the polyhedra in and out fill the same volume.
+// The volume is cut into layers at the floors and ceilings of all the
+// polyhedra. In each layer, the footprints of the polyhedra spanning it must
+// cover the same area on both sides, and no footprint may appear twice on a
+// side, which means vertically overlapping polyhedra.
+func sameVolume(in, out map[chainhash.Hash]struct{}, views *viewpoint.ViewPointSet, version uint32) bool {
+	for p, _ := range in {
+		if _, ok := out[p]; ok {
+			delete(in, p)
+			delete(out, p)
+		}
+	}
+	if len(in) == 0 && len(out) == 0 {
+		return true
+	}
+	if len(in) == 0 || len(out) == 0 {
+		return false
+	}
+
+	sides := [2][]*viewpoint.PolyhedronEntry{}
+	alts := make([]int32, 0, 2 * (len(in) + len(out)))
+	for i, side := range [2]map[chainhash.Hash]struct{}{in, out} {
+		for p, _ := range side {
+			e, _ := views.FetchPolyhedronEntry(&p)
+			if e == nil {
+				return false
+			}
+			sides[i] = append(sides[i], e)
+			alts = append(alts, e.Floor, e.Ceiling)
+		}
+	}
+	sort.Slice(alts, func(i, j int) bool { return alts[i] < alts[j] })
+
+	for k := 1; k < len(alts); k++ {
+		floor, ceiling := alts[k-1], alts[k]
+		if floor == ceiling {
+			continue
+		}
+		layer := [2]map[chainhash.Hash]struct{}{
+			make(map[chainhash.Hash]struct{}),
+			make(map[chainhash.Hash]struct{})}
+		for i, side := range sides {
+			for _, e := range side {
+				if e.Floor > floor || e.Ceiling < ceiling {
+					continue
+				}
+				if _, ok := layer[i][e.Polygon]; ok {
+					return false
+				}
+				layer[i][e.Polygon] = struct{}{}
+			}
+		}
+		for p, _ := range layer[0] {
+			if _, ok := layer[1][p]; ok {
+				delete(layer[0], p)
+				delete(layer[1], p)
+			}
+		}
+		if len(layer[0]) == 0 && len(layer[1]) == 0 {
+			continue
+		}
+		if len(layer[0]) == 0 || len(layer[1]) == 0 || !sameArea(layer[0], layer[1], views, version) {
+			return false
+		}
+	}
+
 	return true
 }
 
@@ -0,0 +1,115 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package viewpoint
+
+import (
+	"bytes"
+
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/database"
+	"github.com/omegasuite/btcd/wire"
+)
+
+// AddrUtxoSize is the size of the address part of the keys of the address
+// index of the utxo set: the net id followed by the address hash, as at the
+// beginning of a pkScript.
+const AddrUtxoSize = 21
+
+// addrUtxoKey returns the key of the entry of the utxo of key paid to
+// pkScript in the address index, or nil if the pkScript has no address.
+func addrUtxoKey(pkScript []byte, key []byte) []byte {
+	if len(pkScript) < AddrUtxoSize {
+		return nil
+	}
+	k := make([]byte, AddrUtxoSize+len(key))
+	copy(k, pkScript[:AddrUtxoSize])
+	copy(k[AddrUtxoSize:], key)
+	return k
+}
+
+// dbPutAddrUtxo adds the utxo of key paid to pkScript to the address index,
+// or removes it from the index if del is set. The index is skipped if its
+// bucket does not exist.
+func dbPutAddrUtxo(dbTx database.Tx, pkScript []byte, key []byte, del bool) error {
+	bucket := dbTx.Metadata().Bucket(AddrUtxoBucketName)
+	k := addrUtxoKey(pkScript, key)
+	if bucket == nil || k == nil {
+		return nil
+	}
+	if del {
+		return bucket.Delete(k)
+	}
+	return bucket.Put(k, nil)
+}
+
+// DbFetchAddrUtxos returns the unspent outputs paid to addr, the net id and
+// hash of an address as returned by ScriptNetAddress.
+func DbFetchAddrUtxos(dbTx database.Tx, addr []byte) (map[wire.OutPoint]*UtxoEntry, error) {
+	res := make(map[wire.OutPoint]*UtxoEntry)
+	bucket := dbTx.Metadata().Bucket(AddrUtxoBucketName)
+	if bucket == nil || len(addr) != AddrUtxoSize {
+		return res, nil
+	}
+
+	cursor := bucket.Cursor()
+	for ok := cursor.Seek(addr); ok && bytes.HasPrefix(cursor.Key(), addr); ok = cursor.Next() {
+		key := cursor.Key()[AddrUtxoSize:]
+		if len(key) <= chainhash.HashSize {
+			continue
+		}
+		op := Key2Outpoint(key)
+		entry, err := DbFetchUtxoEntry(dbTx, op)
+		if err != nil {
+			return nil, err
+		}
+		if entry != nil {
+			res[op] = entry
+		}
+	}
+	return res, nil
+}
+
+// DbBuildAddrUtxoIndex creates the address index of the utxo set if it does
+// not exist and indexes all the unspent outputs in the database. It is used
+// to upgrade databases created before the index was introduced.
+func DbBuildAddrUtxoIndex(dbTx database.Tx) error {
+	meta := dbTx.Metadata()
+	bucket, err := meta.CreateBucketIfNotExists(AddrUtxoBucketName)
+	if err != nil {
+		return err
+	}
+
+	// collect the keys first as the utxo set must not be modified while it
+	// is iterated. The utxo bucket also houses monitor entries keyed by an
+	// address and a hash, which are longer than outpoint keys.
+	var keys [][]byte
+	err = meta.Bucket(utxoSetBucketName).ForEach(func(k, v []byte) error {
+		if len(k) > chainhash.HashSize+maxUint32VLQSerializeSize {
+			return nil
+		}
+		entry, err := DeserializeUtxoEntry(v)
+		if err != nil {
+			return err
+		}
+		if key := addrUtxoKey(entry.PkScript(), k); key != nil {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err := bucket.Put(key, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package viewpoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/database"
+	_ "github.com/omegasuite/btcd/database/ffldb"
+	"github.com/omegasuite/btcd/wire"
+	"github.com/omegasuite/btcd/wire/common"
+	"github.com/omegasuite/omega/token"
+)
+
+// TestAddrUtxoIndex ensures the address index follows the utxo set written
+// to the database and may be rebuilt from it.
+func TestAddrUtxoIndex(t *testing.T) {
+	dbPath := filepath.Join(os.TempDir(), "viewpoint-addrutxo")
+	_ = os.RemoveAll(dbPath)
+	db, err := database.Create("ffldb", dbPath, common.MainNet)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+	defer db.Close()
+
+	err = db.Update(func(dbTx database.Tx) error {
+		for _, name := range [][]byte{utxoSetBucketName, AddrUtxoBucketName} {
+			if _, err := dbTx.Metadata().CreateBucket(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("buckets: %v", err)
+	}
+
+	payee := func(id byte) []byte {
+		s := make([]byte, 25)
+		s[1] = id
+		s[21] = 0x41
+		return s
+	}
+	fetch := func(id byte) map[wire.OutPoint]*UtxoEntry {
+		var utxos map[wire.OutPoint]*UtxoEntry
+		err := db.View(func(dbTx database.Tx) error {
+			var err error
+			utxos, err = DbFetchAddrUtxos(dbTx, payee(id)[:AddrUtxoSize])
+			return err
+		})
+		if err != nil {
+			t.Fatalf("DbFetchAddrUtxos: %v", err)
+		}
+		return utxos
+	}
+	put := func(view *UtxoViewpoint) {
+		err := db.Update(func(dbTx database.Tx) error {
+			return DbPutUtxoView(dbTx, view)
+		})
+		if err != nil {
+			t.Fatalf("DbPutUtxoView: %v", err)
+		}
+	}
+
+	hash := chainhash.HashH([]byte("tx"))
+	rights := chainhash.HashH([]byte("right"))
+	outs := []*wire.TxOut{
+		wire.NewTxOut(0, &token.NumToken{Val: 100}, nil, payee(1)),
+		wire.NewTxOut(3, &token.HashToken{Hash: chainhash.HashH([]byte("land"))}, &rights, payee(1)),
+		wire.NewTxOut(0, &token.NumToken{Val: 50}, nil, payee(2)),
+	}
+
+	view := NewUtxoViewpoint()
+	for i, out := range outs {
+		view.AddRawTxOut(*wire.NewOutPoint(&hash, uint32(i)), out, false, 1)
+	}
+	put(view)
+
+	utxos := fetch(1)
+	if len(utxos) != 2 {
+		t.Fatalf("address 1 has %d utxos, want 2", len(utxos))
+	}
+	if e := utxos[*wire.NewOutPoint(&hash, 1)]; e == nil || e.TokenType != 3 || !e.Rights.IsEqual(&rights) {
+		t.Errorf("hash token of address 1 %v", e)
+	}
+	if n := len(fetch(2)); n != 1 {
+		t.Errorf("address 2 has %d utxos, want 1", n)
+	}
+
+	// spend the numeric output of address 1
+	view = NewUtxoViewpoint()
+	view.AddRawTxOut(*wire.NewOutPoint(&hash, 0), outs[0], false, 1).Spend()
+	put(view)
+
+	utxos = fetch(1)
+	if _, ok := utxos[*wire.NewOutPoint(&hash, 1)]; len(utxos) != 1 || !ok {
+		t.Errorf("address 1 has %v after spending, want only output 1", utxos)
+	}
+
+	// rebuild the index of a database without it
+	err = db.Update(func(dbTx database.Tx) error {
+		if err := dbTx.Metadata().DeleteBucket(AddrUtxoBucketName); err != nil {
+			return err
+		}
+		return DbBuildAddrUtxoIndex(dbTx)
+	})
+	if err != nil {
+		t.Fatalf("DbBuildAddrUtxoIndex: %v", err)
+	}
+	if n, m := len(fetch(1)), len(fetch(2)); n != 1 || m != 1 {
+		t.Errorf("rebuilt index has %d and %d utxos, want 1 and 1", n, m)
+	}
+}
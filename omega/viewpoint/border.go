@@ -945,7 +945,7 @@ func DbPutBorderView(dbTx database.Tx, view *BorderViewpoint) error {
 //				removebbox(boxbucket, boxindex, 0x80000000, hash)
 //			}
 			entry.PackedFlags &^= TfModified
-			return nil
+			continue
 		}
 
 		// Serialize and store the utxo entry.
@@ -1021,6 +1021,12 @@ func DbFetchBorderEntry(dbTx database.Tx, hash *chainhash.Hash) (*BorderEntry, e
 		return nil, ViewPointError(str)
 	}
 
+	return deserializeBorderEntry(serialized), nil
+}
+
+// deserializeBorderEntry decodes a border entry from the format returned by
+// serializeBorderEntry.
+func deserializeBorderEntry(serialized []byte) *BorderEntry {
 	b := BorderEntry{}
 
 	copy(b.Father[:], serialized[:chainhash.HashSize])
@@ -1037,7 +1043,7 @@ func DbFetchBorderEntry(dbTx database.Tx, hash *chainhash.Hash) (*BorderEntry, e
 	p += 4
 
 	if len(serialized) == p {
-		return &b, nil
+		return &b
 	}
 
 	b.Bound = &BoundingBox{}
@@ -1046,7 +1052,7 @@ func DbFetchBorderEntry(dbTx database.Tx, hash *chainhash.Hash) (*BorderEntry, e
 	b.Bound.south = int32(binary.LittleEndian.Uint32(serialized[p + 8:]))
 	b.Bound.north = int32(binary.LittleEndian.Uint32(serialized[p + 12:]))
 
-	return &b, nil
+	return &b
 }
 
 func dbRemoveBorder(dbTx database.Tx, hash *chainhash.Hash) error {
@@ -34,6 +34,8 @@ type PolygonEntry struct {
 	Bound BoundingBox
 	FirstCW	bool
 	Depth uint8
+	RefCnt int32		// number of utxos of the polygon or of polyhedra on it
+	refChg int32		// change in reference count. not stored.
 
 	// packedFlags contains additional info about vertex. Currently unused.
 	PackedFlags txoFlags
@@ -42,7 +44,7 @@ type PolygonEntry struct {
 // isModified returns whether or not the output has been modified since it was
 // loaded.
 func (entry * PolygonEntry) isModified() bool {
-	return entry.PackedFlags & TfModified == TfModified
+	return entry.PackedFlags & TfModified == TfModified || entry.refChg != 0
 }
 
 func (entry * PolygonEntry) toDelete() bool {
@@ -60,11 +62,13 @@ func (entry * PolygonEntry) Clone() *PolygonEntry {
 		Bound:   entry.Bound,
 		FirstCW: entry.FirstCW,
 		Depth: entry.Depth,
+		RefCnt: entry.RefCnt,
 		PackedFlags: entry.PackedFlags,
 	}
 }
 
 func (entry * PolygonEntry) deReference(view * ViewPointSet) {
+	entry.refChg--
 	loops :=  view.Flattern(entry.Loops)
 	for _, loop := range loops {
 		for _, b := range loop {
@@ -76,6 +80,7 @@ func (entry * PolygonEntry) deReference(view * ViewPointSet) {
 }
 
 func (entry * PolygonEntry) reference(view * ViewPointSet) {
+	entry.refChg++
 	loops :=  view.Flattern(entry.Loops)
 	for _, loop := range loops {
 		for _, b := range loop {
@@ -735,6 +740,80 @@ func (view * ViewPointSet) InsidePoint(lp * token.LoopDef, p token.VertexDef) in
 	return -1
 }
 
+// PolygonCovers returns whether point p is inside or on the border of the
+// polygon. A point is inside if it is inside an odd number of the loops of the
+// polygon, i.e. inside an outer loop and not inside its holes.
+func (view * ViewPointSet) PolygonCovers(polygon * chainhash.Hash, p token.VertexDef) (bool, error) {
+	plg, err := view.FetchPolygonEntry(polygon)
+	if err != nil {
+		return false, err
+	}
+	if plg.Bound.west > p.Lng() || plg.Bound.east < p.Lng() ||
+		plg.Bound.south > p.Lat() || plg.Bound.north < p.Lat() {
+		return false, nil
+	}
+
+	inside := 0
+	for _, loop := range view.Flattern(plg.Loops) {
+		if len(loop) == 0 {
+			continue
+		}
+		switch view.InsidePoint(&loop, p) {
+		case 0:
+			return true, nil
+		case 1:
+			inside++
+		}
+	}
+	return inside & 1 == 1, nil
+}
+
+// BordersInBox returns the borders of the polygon whose bounding boxes
+// intersect box. Borders made of child borders are replaced by those of their
+// children intersecting box down to lod levels.
+func (view * ViewPointSet) BordersInBox(polygon * chainhash.Hash, box * BoundingBox, lod byte) ([]chainhash.Hash, error) {
+	plg, err := view.FetchPolygonEntry(polygon)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []chainhash.Hash
+	var search func(b chainhash.Hash, lod byte) error
+	search = func(b chainhash.Hash, lod byte) error {
+		b[0] &= 0xFE
+		fe, err := view.FetchBorderEntry(&b)
+		if err != nil {
+			return err
+		}
+		if fe == nil {
+			return fmt.Errorf("border %s does not exist", b.String())
+		}
+		bound := fe.GetBound()
+		if !bound.Intersects(box, true) {
+			return nil
+		}
+		if lod == 0 || len(fe.Children) == 0 {
+			res = append(res, b)
+			return nil
+		}
+		for _, c := range fe.Children {
+			if err := search(c, lod - 1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, loop := range view.Flattern(plg.Loops) {
+		for _, b := range loop {
+			if err := search(b, lod); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return res, nil
+}
+
 func abs(in int32) int32 {
 	if in < 0 {
 		return -in
@@ -793,17 +872,7 @@ func (view * ViewPointSet) AddPolygon(tx *btcutil.Tx) bool {
 	for _, txVtx := range tx.MsgTx().TxDef {
 		switch txVtx.(type) {
 			case *token.PolygonDef:
-				ccw := false
-				th := txVtx.Hash()
-				for _,out := range tx.MsgTx().TxOut {
-					if out.IsSeparator() {
-						continue
-					}
-					if out.TokenType == 3 && out.Value.(*token.HashToken).Hash.IsEqual(&th) {
-						ccw = true
-						break
-					}
-				}
+				ccw := TokenPolygonDefined(tx, txVtx.Hash())
 				var rcw bool
 				var bx BoundingBox
 				if rcw,bx = view.PolygonInfo(txVtx.(*token.PolygonDef)); rcw != ccw {
@@ -842,6 +911,7 @@ func (view * ViewPointSet) FetchPolygonEntry(hash *chainhash.Hash) (*PolygonEntr
 			Bound:       e.Bound,
 			FirstCW:     e.FirstCW,
 			Depth:		 e.Depth,
+			RefCnt:		 e.RefCnt,
 			PackedFlags: 0,
 		}
 		view.Polygon.entries[*hash] = entry
@@ -1000,6 +1070,9 @@ func DbPutPolygonView(dbTx database.Tx, view *PolygonViewpoint) error {
 			if err := bucket.Delete(hash[:]); err != nil {
 				return err
 			}
+			if err := dbPutPolygonRefs(dbTx, hash, entry, true); err != nil {
+				return err
+			}
 			continue
 		}
 
@@ -1012,6 +1085,9 @@ func DbPutPolygonView(dbTx database.Tx, view *PolygonViewpoint) error {
 		if err = bucket.Put(hash[:], serialized); err != nil {
 			return err
 		}
+		if err = dbPutPolygonRefs(dbTx, hash, entry, false); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -1025,7 +1101,12 @@ func serializePolygonEntry(entry *PolygonEntry) ([]byte, error) {
 		return nil, nil
 	}
 
-	size := bccompress.SerializeSizeVLQ(uint64(len(entry.Loops))) + 16 + 1 + 1
+	if entry.refChg != 0 {
+		entry.RefCnt += entry.refChg
+		entry.refChg = 0
+	}
+
+	size := bccompress.SerializeSizeVLQ(uint64(len(entry.Loops))) + 16 + 1 + 1 + 4
 	for _, l := range entry.Loops {
 		size += bccompress.SerializeSizeVLQ(uint64(len(l))) + len(l) * chainhash.HashSize
 	}
@@ -1048,6 +1129,7 @@ func serializePolygonEntry(entry *PolygonEntry) ([]byte, error) {
 	if entry.FirstCW {
 		serialized[p + 17] = 1
 	}
+	byteOrder.PutUint32(serialized[p + 18:], uint32(entry.RefCnt))
 
 	return serialized, nil
 }
@@ -1062,6 +1144,13 @@ func DbFetchPolygon(dbTx database.Tx, hash *chainhash.Hash) (*PolygonEntry, erro
 		return nil, bccompress.ErrNotInMainChain(str)
 	}
 
+	return deserializePolygonEntry(serialized), nil
+}
+
+// deserializePolygonEntry decodes a polygon entry from the format returned by
+// serializePolygonEntry. Entries stored before the reference count was added
+// have a zero count.
+func deserializePolygonEntry(serialized []byte) *PolygonEntry {
 	b := PolygonEntry {}
 
 	loops, pos := bccompress.DeserializeVLQ(serialized)
@@ -1084,8 +1173,11 @@ func DbFetchPolygon(dbTx database.Tx, hash *chainhash.Hash) (*PolygonEntry, erro
 	if len(serialized) > pos + 17 {
 		b.FirstCW = (serialized[pos + 17] != 0)
 	}
+	if len(serialized) >= pos + 22 {
+		b.RefCnt = int32(byteOrder.Uint32(serialized[pos + 18:]))
+	}
 
-	return &b, nil
+	return &b
 }
 
 func dbRemovePolygon(dbTx database.Tx, hash *chainhash.Hash) error {
@@ -0,0 +1,430 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package viewpoint
+
+import (
+	"fmt"
+
+	"github.com/omegasuite/btcd/blockchain/bccompress"
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/database"
+	"github.com/omegasuite/btcutil"
+	"github.com/omegasuite/omega/token"
+)
+
+// PolyhedronEntry houses details about an individual polyhedron definition
+// in a definition view.
+type PolyhedronEntry struct {
+	Polygon chainhash.Hash
+	Floor   int32
+	Ceiling int32
+
+	// packedFlags contains additional info about the polyhedron.
+	PackedFlags txoFlags
+}
+
+// isModified returns whether or not the entry has been modified since it was
+// loaded.
+func (entry *PolyhedronEntry) isModified() bool {
+	return entry.PackedFlags&TfModified == TfModified
+}
+
+func (entry *PolyhedronEntry) toDelete() bool {
+	return entry.PackedFlags&TfSpent == TfSpent
+}
+
+// RollBack marks the entry as to be deleted.
+func (entry *PolyhedronEntry) RollBack() {
+	if entry.toDelete() {
+		return
+	}
+	entry.PackedFlags |= TfSpent | TfModified
+}
+
+func (entry *PolyhedronEntry) ToToken() *token.PolyhedronDef {
+	return &token.PolyhedronDef{
+		Polygon: entry.Polygon,
+		Floor:   entry.Floor,
+		Ceiling: entry.Ceiling,
+	}
+}
+
+// Overlaps returns whether the altitude ranges of two polyhedra overlap.
+func (entry *PolyhedronEntry) Overlaps(e *PolyhedronEntry) bool {
+	return entry.Floor < e.Ceiling && e.Floor < entry.Ceiling
+}
+
+// PolyhedronViewpoint represents a view into the set of polyhedron
+// definitions from a specific point of view in the chain.
+type PolyhedronViewpoint struct {
+	entries  map[chainhash.Hash]*PolyhedronEntry
+	bestHash chainhash.Hash
+}
+
+// NewPolyhedronViewpoint returns a new empty polyhedron view.
+func NewPolyhedronViewpoint() *PolyhedronViewpoint {
+	return &PolyhedronViewpoint{
+		entries: make(map[chainhash.Hash]*PolyhedronEntry),
+	}
+}
+
+// BestHash returns the hash of the best block in the chain the view currently
+// respresents.
+func (view *PolyhedronViewpoint) BestHash() *chainhash.Hash {
+	return &view.bestHash
+}
+
+// SetBestHash sets the hash of the best block in the chain the view currently
+// respresents.
+func (view *PolyhedronViewpoint) SetBestHash(hash *chainhash.Hash) {
+	view.bestHash = *hash
+}
+
+// LookupEntry returns the entry of a polyhedron in the view, or nil if the
+// polyhedron is not in the view.
+func (view *PolyhedronViewpoint) LookupEntry(p chainhash.Hash) *PolyhedronEntry {
+	return view.entries[p]
+}
+
+// RemoveEntry removes the given polyhedron from the view.
+func (view *PolyhedronViewpoint) RemoveEntry(hash chainhash.Hash) {
+	delete(view.entries, hash)
+}
+
+// Entries returns the underlying map that stores of all the polyhedron entries.
+func (view *PolyhedronViewpoint) Entries() map[chainhash.Hash]*PolyhedronEntry {
+	return view.entries
+}
+
+// commit. this is to be called after data has been committed to db
+func (view *PolyhedronViewpoint) commit() {
+	for hash, entry := range view.entries {
+		if entry == nil || entry.toDelete() {
+			delete(view.entries, hash)
+			continue
+		}
+		entry.PackedFlags &^= TfModified
+	}
+}
+
+// AddOnePolyhedron adds a polyhedron definition to the view. It returns false
+// if the polyhedron exists, its footprint does not, or its floor is not below
+// its ceiling.
+func (view *ViewPointSet) AddOnePolyhedron(b *token.PolyhedronDef) bool {
+	if b.Floor >= b.Ceiling {
+		return false
+	}
+	h := b.Hash()
+	if e, _ := view.FetchPolyhedronEntry(&h); e != nil {
+		return false
+	}
+	if p, _ := view.FetchPolygonEntry(&b.Polygon); p == nil {
+		return false
+	}
+	view.Polyhedron.entries[h] = &PolyhedronEntry{
+		Polygon:     b.Polygon,
+		Floor:       b.Floor,
+		Ceiling:     b.Ceiling,
+		PackedFlags: TfModified,
+	}
+	return true
+}
+
+// AddPolyhedron adds all polyhedron definitions in the passed transaction to
+// the view.
+func (view *ViewPointSet) AddPolyhedron(tx *btcutil.Tx) bool {
+	for _, d := range tx.MsgTx().TxDef {
+		if p, ok := d.(*token.PolyhedronDef); ok {
+			if !view.AddOnePolyhedron(p) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// FetchPolyhedronEntry attempts to find a polyhedron for the given hash by
+// searching the entire view. It checks the view first and then falls back to
+// the database if needed.
+func (view *ViewPointSet) FetchPolyhedronEntry(hash *chainhash.Hash) (*PolyhedronEntry, error) {
+	entry := view.Polyhedron.LookupEntry(*hash)
+	if entry != nil {
+		return entry, nil
+	}
+
+	err := view.Db.View(func(dbTx database.Tx) error {
+		e, err := DbFetchPolyhedron(dbTx, hash)
+		if err != nil {
+			return err
+		}
+		entry = e
+		view.Polyhedron.entries[*hash] = entry
+		return nil
+	})
+
+	return entry, err
+}
+
+// TokenPolygon returns the polygon of the polygon token of hash: the polygon
+// of hash itself, or the footprint of the polyhedron of hash together with
+// the polyhedron. It returns nil if hash is neither.
+func (view *ViewPointSet) TokenPolygon(hash *chainhash.Hash) (*PolygonEntry, *PolyhedronEntry) {
+	if p, _ := view.FetchPolygonEntry(hash); p != nil {
+		return p, nil
+	}
+	e, _ := view.FetchPolyhedronEntry(hash)
+	if e == nil {
+		return nil, nil
+	}
+	p, _ := view.FetchPolygonEntry(&e.Polygon)
+	if p == nil {
+		return nil, nil
+	}
+	return p, e
+}
+
+// TokenPolygonDefined returns whether the polygon of hash is used by tx as
+// the value of a polygon token or as the footprint of a polyhedron defined in
+// tx. The first loop of such a polygon must be ccw.
+func TokenPolygonDefined(tx *btcutil.Tx, hash chainhash.Hash) bool {
+	for _, out := range tx.MsgTx().TxOut {
+		if out.IsSeparator() {
+			continue
+		}
+		if out.TokenType == 3 && out.Value.(*token.HashToken).Hash.IsEqual(&hash) {
+			return true
+		}
+	}
+	for _, d := range tx.MsgTx().TxDef {
+		if p, ok := d.(*token.PolyhedronDef); ok && p.Polygon.IsEqual(&hash) {
+			return true
+		}
+	}
+	return false
+}
+
+// PolyhedronCovers returns whether point p, including its altitude, is inside
+// or on the surface of the polyhedron.
+func (view *ViewPointSet) PolyhedronCovers(polyhedron *chainhash.Hash, p token.VertexDef) (bool, error) {
+	e, err := view.FetchPolyhedronEntry(polyhedron)
+	if err != nil {
+		return false, err
+	}
+	if p.Alt() < e.Floor || p.Alt() > e.Ceiling {
+		return false, nil
+	}
+	return view.PolygonCovers(&e.Polygon, p)
+}
+
+// where returns 1 if p is inside the polygon, 0 if it is on its border and -1
+// if it is outside.
+func (view *ViewPointSet) where(plg *PolygonEntry, p token.VertexDef) int {
+	inside := 0
+	for _, loop := range view.Flattern(plg.Loops) {
+		if len(loop) == 0 {
+			continue
+		}
+		switch view.InsidePoint(&loop, p) {
+		case 0:
+			return 0
+		case 1:
+			inside++
+		}
+	}
+	if inside&1 == 1 {
+		return 1
+	}
+	return -1
+}
+
+// segments returns the leaf borders of all the loops of the polygon.
+func (view *ViewPointSet) segments(plg *PolygonEntry) ([]DirectedBorder, error) {
+	var res []DirectedBorder
+	for _, loop := range view.Flattern(plg.Loops) {
+		if len(loop) == 0 {
+			continue
+		}
+		t, _ := view.ExpandLoop(&loop)
+		if t == nil {
+			return nil, fmt.Errorf("polygon has undefined borders")
+		}
+		res = append(res, t...)
+	}
+	return res, nil
+}
+
+// crosses returns whether borders a and b cross each other at a point that
+// is not an end point of either of them.
+func crosses(a, b *BorderEntry) bool {
+	side := func(p, q, r token.VertexDef) int64 {
+		d := int64(q.Lng()-p.Lng())*int64(r.Lat()-p.Lat()) -
+			int64(q.Lat()-p.Lat())*int64(r.Lng()-p.Lng())
+		switch {
+		case d > 0:
+			return 1
+		case d < 0:
+			return -1
+		}
+		return 0
+	}
+	return side(a.Begin, a.End, b.Begin)*side(a.Begin, a.End, b.End) < 0 &&
+		side(b.Begin, b.End, a.Begin)*side(b.Begin, b.End, a.End) < 0
+}
+
+// PolygonContains returns whether the polygon inner is inside or the same as
+// the polygon outer.
+func (view *ViewPointSet) PolygonContains(outer, inner *chainhash.Hash) (bool, error) {
+	if outer.IsEqual(inner) {
+		return true, nil
+	}
+	op, err := view.FetchPolygonEntry(outer)
+	if err != nil {
+		return false, err
+	}
+	ip, err := view.FetchPolygonEntry(inner)
+	if err != nil {
+		return false, err
+	}
+	if !op.Bound.Contain(&ip.Bound) {
+		return false, nil
+	}
+
+	os, err := view.segments(op)
+	if err != nil {
+		return false, err
+	}
+	is, err := view.segments(ip)
+	if err != nil {
+		return false, err
+	}
+
+	for _, s := range is {
+		for _, t := range os {
+			if crosses(s.border, t.border) {
+				return false, nil
+			}
+		}
+
+		// both ends and the middle of every border of inner must be
+		// inside outer or on its border
+		var mid token.VertexDef
+		mid.SetLat(int32((int64(s.border.Begin.Lat()) + int64(s.border.End.Lat())) / 2))
+		mid.SetLng(int32((int64(s.border.Begin.Lng()) + int64(s.border.End.Lng())) / 2))
+		for _, p := range []token.VertexDef{s.border.Begin, mid} {
+			if view.where(op, p) < 0 {
+				return false, nil
+			}
+		}
+	}
+
+	// no hole of outer may be inside inner
+	for _, t := range os {
+		if view.where(ip, t.border.Begin) > 0 {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// PolyhedronContains returns whether the polyhedron inner is inside or the
+// same as the polyhedron outer.
+func (view *ViewPointSet) PolyhedronContains(outer, inner *chainhash.Hash) (bool, error) {
+	oe, err := view.FetchPolyhedronEntry(outer)
+	if err != nil {
+		return false, err
+	}
+	ie, err := view.FetchPolyhedronEntry(inner)
+	if err != nil {
+		return false, err
+	}
+	if ie.Floor < oe.Floor || ie.Ceiling > oe.Ceiling {
+		return false, nil
+	}
+	return view.PolygonContains(&oe.Polygon, &ie.Polygon)
+}
+
+// disconnectPolyhedronTransactions rolls back all polyhedra defined in the
+// passed block and sets the best hash for the view to the block before it.
+func (view *ViewPointSet) disconnectPolyhedronTransactions(block *btcutil.Block) error {
+	for _, tx := range block.Transactions() {
+		for _, d := range tx.MsgTx().TxDef {
+			if _, ok := d.(*token.PolyhedronDef); !ok {
+				continue
+			}
+			h := d.Hash()
+			if p, _ := view.FetchPolyhedronEntry(&h); p != nil {
+				p.RollBack()
+			}
+		}
+	}
+
+	view.Polyhedron.SetBestHash(&block.MsgBlock().Header.PrevBlock)
+	return nil
+}
+
+// DbPutPolyhedronView uses an existing database transaction to update the
+// polyhedron set in the database based on the provided view contents.
+func DbPutPolyhedronView(dbTx database.Tx, view *PolyhedronViewpoint) error {
+	bucket := dbTx.Metadata().Bucket(PolyhedronSetBucketName)
+	for hash, entry := range view.Entries() {
+		if entry == nil || !entry.isModified() {
+			continue
+		}
+
+		if entry.toDelete() {
+			if err := bucket.Delete(hash[:]); err != nil {
+				return err
+			}
+			if err := dbPutGeometryRef(dbTx, &entry.Polygon, &hash, true); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := bucket.Put(hash[:], serializePolyhedronEntry(entry)); err != nil {
+			return err
+		}
+		if err := dbPutGeometryRef(dbTx, &entry.Polygon, &hash, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// serializePolyhedronEntry returns the entry serialized to a format that is
+// suitable for long-term storage: the polygon hash followed by the floor and
+// the ceiling.
+func serializePolyhedronEntry(entry *PolyhedronEntry) []byte {
+	serialized := make([]byte, chainhash.HashSize+8)
+	copy(serialized, entry.Polygon[:])
+	byteOrder.PutUint32(serialized[chainhash.HashSize:], uint32(entry.Floor))
+	byteOrder.PutUint32(serialized[chainhash.HashSize+4:], uint32(entry.Ceiling))
+	return serialized
+}
+
+func DbFetchPolyhedron(dbTx database.Tx, hash *chainhash.Hash) (*PolyhedronEntry, error) {
+	var serialized []byte
+	if bucket := dbTx.Metadata().Bucket(PolyhedronSetBucketName); bucket != nil {
+		serialized = bucket.Get(hash[:])
+	}
+
+	if len(serialized) != chainhash.HashSize+8 {
+		str := fmt.Sprintf("polyhedron %s does not exist in the main chain", hash)
+		return nil, bccompress.ErrNotInMainChain(str)
+	}
+
+	e := &PolyhedronEntry{
+		Floor:   int32(byteOrder.Uint32(serialized[chainhash.HashSize:])),
+		Ceiling: int32(byteOrder.Uint32(serialized[chainhash.HashSize+4:])),
+	}
+	copy(e.Polygon[:], serialized)
+	return e, nil
+}
@@ -0,0 +1,95 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package viewpoint_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/database"
+	_ "github.com/omegasuite/btcd/database/ffldb"
+	"github.com/omegasuite/btcd/wire"
+	"github.com/omegasuite/btcd/wire/common"
+	"github.com/omegasuite/omega/internal/testutil"
+	"github.com/omegasuite/omega/token"
+	"github.com/omegasuite/omega/viewpoint"
+)
+
+// TestPolyhedra checks containment of polyhedra and vertices in them.
+func TestPolyhedra(t *testing.T) {
+	dbPath := filepath.Join(os.TempDir(), "viewpoint-polyhedra")
+	_ = os.RemoveAll(dbPath)
+	db, err := database.Create("ffldb", dbPath, common.MainNet)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+	defer db.Close()
+
+	err = db.Update(func(dbTx database.Tx) error {
+		for _, name := range []string{"utxosetv2", "borders", "polygons", "polyhedra", "rights"} {
+			if _, err := dbTx.Metadata().CreateBucket([]byte(name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("buckets: %v", err)
+	}
+
+	pkScript := make([]byte, 25)
+	pkScript[1] = 1
+	pkScript[21] = 0x41
+
+	// the air above a 2 by 2 degrees square up to 100 m, and a smaller
+	// square inside it from 10 m to 20 m
+	right := token.NewRightDef(chainhash.Hash{}, []byte("air"), 0)
+	rights := right.Hash()
+	outer, outerPlg := testutil.Square(0, 0, 2*token.CoordPrecision, 2*token.CoordPrecision)
+	inner, innerPlg := testutil.Square(token.CoordPrecision/2, token.CoordPrecision/2,
+		3*token.CoordPrecision/2, 3*token.CoordPrecision/2)
+	air := token.NewPolyhedronDef(outerPlg.Hash(), 0, 100*token.AltPrecision)
+	room := token.NewPolyhedronDef(innerPlg.Hash(), 10*token.AltPrecision, 20*token.AltPrecision)
+
+	mtx := wire.NewMsgTx(wire.TxVersion)
+	mtx.AddDef(right)
+	for _, d := range append(outer, inner...) {
+		mtx.AddDef(d)
+	}
+	mtx.AddDef(air)
+	mtx.AddDef(room)
+	mtx.AddTxOut(wire.NewTxOut(3, &token.HashToken{Hash: air.Hash()}, &rights, pkScript))
+	mtx.AddTxOut(wire.NewTxOut(3, &token.HashToken{Hash: room.Hash()}, &rights, pkScript))
+	testutil.Apply(t, db, mtx)
+
+	views := viewpoint.NewViewPointSet(db)
+	airHash, roomHash := air.Hash(), room.Hash()
+	if ok, err := views.PolyhedronContains(&airHash, &roomHash); !ok || err != nil {
+		t.Errorf("room is not in the air: %v", err)
+	}
+	if ok, _ := views.PolyhedronContains(&roomHash, &airHash); ok {
+		t.Errorf("air is in the room")
+	}
+
+	var v token.VertexDef
+	v.SetLat(token.CoordPrecision)
+	v.SetLng(token.CoordPrecision)
+	for _, c := range []struct {
+		alt  int32
+		want bool
+	}{{15, true}, {20, true}, {25, false}, {5, false}} {
+		v.SetAlt(c.alt * token.AltPrecision)
+		if ok, err := views.PolyhedronCovers(&roomHash, v); ok != c.want || err != nil {
+			t.Errorf("room covers the center at %d m: %v, want %v (%v)", c.alt, ok, c.want, err)
+		}
+	}
+}
@@ -0,0 +1,465 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package viewpoint
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/database"
+	"github.com/omegasuite/omega/token"
+)
+
+const (
+	// GeometryPruneDepth is the number of blocks after the block spending
+	// the last output of a polygon that the polygon is pruned, if no output
+	// holds it by then. Reorgs shallower than it never detach a block that
+	// needs the pruned geometry before the block pruning it is detached.
+	GeometryPruneDepth = 100
+
+	// GeometryArchiveDepth is the number of blocks pruned geometry is kept in
+	// the archive for restoring it when the block that pruned it is
+	// disconnected.
+	GeometryArchiveDepth = 2016
+
+	// kinds of geometry in the archive
+	geometryBorder  = byte(0)
+	geometryPolygon = byte(1)
+)
+
+// geometryPruneStatsKeyName is the name of the db key used to store the
+// geometry pruning statistics.
+var geometryPruneStatsKeyName = []byte("geoprunestats")
+
+// GeometryPruneStats houses the statistics of geometry pruning. The counts are
+// those of geometry currently pruned, i.e. pruned and not restored.
+type GeometryPruneStats struct {
+	// Height is the height of the last block that pruned geometry.
+	Height int32
+
+	Borders  uint64
+	Polygons uint64
+
+	// Bytes is the size of the keys and values removed from the border and
+	// polygon sets.
+	Bytes uint64
+
+	// Archived and ArchivedBytes are the number of entries in the archive
+	// and their size.
+	Archived      uint64
+	ArchivedBytes uint64
+}
+
+// DbFetchGeometryPruneStats returns the geometry pruning statistics.
+func DbFetchGeometryPruneStats(dbTx database.Tx) *GeometryPruneStats {
+	stats := &GeometryPruneStats{}
+	serialized := dbTx.Metadata().Get(geometryPruneStatsKeyName)
+	if len(serialized) != 44 {
+		return stats
+	}
+	stats.Height = int32(byteOrder.Uint32(serialized))
+	stats.Borders = byteOrder.Uint64(serialized[4:])
+	stats.Polygons = byteOrder.Uint64(serialized[12:])
+	stats.Bytes = byteOrder.Uint64(serialized[20:])
+	stats.Archived = byteOrder.Uint64(serialized[28:])
+	stats.ArchivedBytes = byteOrder.Uint64(serialized[36:])
+	return stats
+}
+
+func dbPutGeometryPruneStats(dbTx database.Tx, stats *GeometryPruneStats) error {
+	serialized := make([]byte, 44)
+	byteOrder.PutUint32(serialized, uint32(stats.Height))
+	byteOrder.PutUint64(serialized[4:], stats.Borders)
+	byteOrder.PutUint64(serialized[12:], stats.Polygons)
+	byteOrder.PutUint64(serialized[20:], stats.Bytes)
+	byteOrder.PutUint64(serialized[28:], stats.Archived)
+	byteOrder.PutUint64(serialized[36:], stats.ArchivedBytes)
+	return dbTx.Metadata().Put(geometryPruneStatsKeyName, serialized)
+}
+
+// geometryRefKey returns the key of the reference to a by b.
+func geometryRefKey(a, b *chainhash.Hash) []byte {
+	key := make([]byte, chainhash.HashSize*2)
+	copy(key, a[:])
+	copy(key[chainhash.HashSize:], b[:])
+	return key
+}
+
+// dbPutGeometryRef adds the reference to a by b to the geometry reference
+// index, or removes it if del is set. The index is skipped if its bucket
+// does not exist.
+func dbPutGeometryRef(dbTx database.Tx, a, b *chainhash.Hash, del bool) error {
+	bucket := dbTx.Metadata().Bucket(GeometryRefsBucketName)
+	if bucket == nil {
+		return nil
+	}
+	if del {
+		return bucket.Delete(geometryRefKey(a, b))
+	}
+	return bucket.Put(geometryRefKey(a, b), nil)
+}
+
+// dbPutPolygonRefs adds the references of the polygon of hash to the borders
+// and polygons in its loops to the geometry reference index, or removes them
+// if del is set.
+func dbPutPolygonRefs(dbTx database.Tx, hash chainhash.Hash, entry *PolygonEntry, del bool) error {
+	for _, loop := range entry.Loops {
+		for _, b := range loop {
+			if len(loop) != 1 {
+				b[0] &= 0xFE
+			}
+			if err := dbPutGeometryRef(dbTx, &b, &hash, del); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// geometryReferenced returns whether any polygon or polyhedron is defined on
+// the border or polygon of hash.
+func geometryReferenced(bucket database.Bucket, hash *chainhash.Hash) bool {
+	cursor := bucket.Cursor()
+	return cursor.Seek(hash[:]) && bytes.HasPrefix(cursor.Key(), hash[:])
+}
+
+// geometryArchiveKey returns the archive key of geometry of hash pruned by the
+// block of height.
+func geometryArchiveKey(height int32, kind byte, hash *chainhash.Hash) []byte {
+	key := make([]byte, 5+chainhash.HashSize)
+	binary.BigEndian.PutUint32(key, uint32(height))
+	key[4] = kind
+	copy(key[5:], hash[:])
+	return key
+}
+
+// geometryPruner removes unused geometry from the database and archives it.
+type geometryPruner struct {
+	dbTx     database.Tx
+	view     *ViewPointSet
+	height   int32
+	borders  database.Bucket
+	polygons database.Bucket
+	refs     database.Bucket
+	archive  database.Bucket
+	stats    *GeometryPruneStats
+}
+
+// remove moves the entry of hash from bucket to the archive.
+func (p *geometryPruner) remove(bucket database.Bucket, kind byte, hash *chainhash.Hash, serialized []byte) error {
+	key := geometryArchiveKey(p.height, kind, hash)
+	if err := p.archive.Put(key, serialized); err != nil {
+		return err
+	}
+	if err := bucket.Delete(hash[:]); err != nil {
+		return err
+	}
+	p.stats.Bytes += uint64(chainhash.HashSize + len(serialized))
+	p.stats.Archived++
+	p.stats.ArchivedBytes += uint64(len(key) + len(serialized))
+	return nil
+}
+
+// polygon prunes the polygon of hash if no output holds it and no other
+// geometry is defined on it, then prunes the polygons and borders it was the
+// last user of.
+func (p *geometryPruner) polygon(hash chainhash.Hash) error {
+	serialized := p.polygons.Get(hash[:])
+	if serialized == nil {
+		return nil
+	}
+	entry := deserializePolygonEntry(serialized)
+	if entry.RefCnt != 0 || geometryReferenced(p.refs, &hash) {
+		return nil
+	}
+
+	if err := p.remove(p.polygons, geometryPolygon, &hash, serialized); err != nil {
+		return err
+	}
+	if err := dbPutPolygonRefs(p.dbTx, hash, entry, true); err != nil {
+		return err
+	}
+	p.view.Polygon.RemoveEntry(hash)
+	p.stats.Polygons++
+
+	for _, loop := range entry.Loops {
+		if len(loop) == 1 {
+			if err := p.polygon(loop[0]); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, b := range loop {
+			b[0] &= 0xFE
+			if err := p.border(b); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// border prunes the family of the border of hash, i.e. its top most ancestor
+// and all the descendants of it, if no polygon is defined on any of them.
+func (p *geometryPruner) border(hash chainhash.Hash) error {
+	var entry *BorderEntry
+	for {
+		serialized := p.borders.Get(hash[:])
+		if serialized == nil {
+			return nil
+		}
+		entry = deserializeBorderEntry(serialized)
+		if entry.Father.IsEqual(&zerohash) {
+			break
+		}
+		hash = entry.Father
+	}
+
+	family := []chainhash.Hash{hash}
+	for i := 0; i < len(family); i++ {
+		if geometryReferenced(p.refs, &family[i]) {
+			return nil
+		}
+		if i > 0 {
+			serialized := p.borders.Get(family[i][:])
+			if serialized == nil {
+				continue
+			}
+			entry = deserializeBorderEntry(serialized)
+		}
+		family = append(family, entry.Children...)
+	}
+
+	for i := range family {
+		serialized := p.borders.Get(family[i][:])
+		if serialized == nil {
+			continue
+		}
+		if err := p.remove(p.borders, geometryBorder, &family[i], serialized); err != nil {
+			return err
+		}
+		p.view.Border.RemoveEntry(family[i])
+		p.stats.Borders++
+	}
+	return nil
+}
+
+// DbPruneGeometry prunes, when connecting the block of height, the polygons
+// held by the outputs spent by the block GeometryPruneDepth blocks before it
+// that no output holds any more, together with the borders and polygons no
+// longer used by any other geometry. Pruned geometry is archived so the view
+// may restore it when the block is disconnected, and is removed from the view.
+// Archived geometry older than GeometryArchiveDepth blocks is discarded.
+//
+// It must be called after the views of the block have been written to the
+// database.
+func DbPruneGeometry(dbTx database.Tx, view *ViewPointSet, height int32, spent []SpentTxOut) error {
+	meta := dbTx.Metadata()
+	p := &geometryPruner{
+		dbTx:     dbTx,
+		view:     view,
+		height:   height,
+		borders:  meta.Bucket(borderSetBucketName),
+		polygons: meta.Bucket(polygonSetBucketName),
+		refs:     meta.Bucket(GeometryRefsBucketName),
+		archive:  meta.Bucket(GeometryArchiveBucketName),
+		stats:    DbFetchGeometryPruneStats(dbTx),
+	}
+	if p.refs == nil || p.archive == nil {
+		return nil
+	}
+
+	pruned := p.stats.Borders + p.stats.Polygons
+	for _, s := range spent {
+		if s.TokenType != 3 {
+			continue
+		}
+		if err := p.polygon(s.Amount.(*token.HashToken).Hash); err != nil {
+			return err
+		}
+	}
+	if p.stats.Borders+p.stats.Polygons != pruned {
+		p.stats.Height = height
+	}
+
+	// discard the archive of blocks too deep to be disconnected
+	var expired [][]byte
+	limit := make([]byte, 4)
+	if height > GeometryArchiveDepth {
+		binary.BigEndian.PutUint32(limit, uint32(height-GeometryArchiveDepth))
+	}
+	cursor := p.archive.Cursor()
+	for ok := cursor.First(); ok && bytes.Compare(cursor.Key()[:4], limit) < 0; ok = cursor.Next() {
+		expired = append(expired, append([]byte(nil), cursor.Key()...))
+		p.stats.Archived--
+		p.stats.ArchivedBytes -= uint64(len(cursor.Key()) + len(cursor.Value()))
+	}
+	for _, key := range expired {
+		if err := p.archive.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	return dbPutGeometryPruneStats(dbTx, p.stats)
+}
+
+// RestoreGeometry adds the geometry pruned when the block of height was
+// connected to the view, so that it is written back to the database with the
+// view when the block is disconnected.
+func (view *ViewPointSet) RestoreGeometry(height int32) error {
+	if view.Db == nil {
+		return nil
+	}
+	return view.Db.View(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(GeometryArchiveBucketName)
+		if bucket == nil {
+			return nil
+		}
+		prefix := make([]byte, 4)
+		binary.BigEndian.PutUint32(prefix, uint32(height))
+		cursor := bucket.Cursor()
+		for ok := cursor.Seek(prefix); ok && bytes.HasPrefix(cursor.Key(), prefix); ok = cursor.Next() {
+			var hash chainhash.Hash
+			copy(hash[:], cursor.Key()[5:])
+			switch cursor.Key()[4] {
+			case geometryBorder:
+				entry := deserializeBorderEntry(cursor.Value())
+				entry.PackedFlags = TfModified
+				view.Border.entries[hash] = entry
+			case geometryPolygon:
+				entry := deserializePolygonEntry(cursor.Value())
+				entry.PackedFlags = TfModified
+				view.Polygon.entries[hash] = entry
+			}
+		}
+		return nil
+	})
+}
+
+// DbRemoveGeometryArchive removes the archive of the geometry pruned by the
+// block of height from the database once the geometry has been restored.
+func DbRemoveGeometryArchive(dbTx database.Tx, height int32) error {
+	bucket := dbTx.Metadata().Bucket(GeometryArchiveBucketName)
+	if bucket == nil {
+		return nil
+	}
+	stats := DbFetchGeometryPruneStats(dbTx)
+
+	var keys [][]byte
+	prefix := make([]byte, 4)
+	binary.BigEndian.PutUint32(prefix, uint32(height))
+	cursor := bucket.Cursor()
+	for ok := cursor.Seek(prefix); ok && bytes.HasPrefix(cursor.Key(), prefix); ok = cursor.Next() {
+		keys = append(keys, append([]byte(nil), cursor.Key()...))
+		if cursor.Key()[4] == geometryBorder {
+			stats.Borders--
+		} else {
+			stats.Polygons--
+		}
+		stats.Bytes -= uint64(chainhash.HashSize + len(cursor.Value()))
+		stats.Archived--
+		stats.ArchivedBytes -= uint64(len(cursor.Key()) + len(cursor.Value()))
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	for _, key := range keys {
+		if err := bucket.Delete(key); err != nil {
+			return err
+		}
+	}
+	return dbPutGeometryPruneStats(dbTx, stats)
+}
+
+// DbBuildGeometryRefs creates the geometry reference index and the archive if
+// they do not exist, indexes the geometry defined on all the borders and
+// polygons in the database and counts the outputs holding each polygon. It is
+// used to upgrade databases created before geometry pruning was introduced.
+func DbBuildGeometryRefs(dbTx database.Tx) error {
+	meta := dbTx.Metadata()
+	for _, name := range [][]byte{GeometryRefsBucketName, GeometryArchiveBucketName} {
+		if _, err := meta.CreateBucketIfNotExists(name); err != nil {
+			return err
+		}
+	}
+
+	// collect the entries first as the buckets must not be modified while
+	// they are iterated
+	polygons := make(map[chainhash.Hash]*PolygonEntry)
+	err := meta.Bucket(polygonSetBucketName).ForEach(func(k, v []byte) error {
+		var hash chainhash.Hash
+		copy(hash[:], k)
+		entry := deserializePolygonEntry(v)
+		entry.RefCnt = 0
+		polygons[hash] = entry
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	polyhedra := make(map[chainhash.Hash]*PolyhedronEntry)
+	err = meta.Bucket(PolyhedronSetBucketName).ForEach(func(k, v []byte) error {
+		var hash chainhash.Hash
+		copy(hash[:], k)
+		entry, err := DbFetchPolyhedron(dbTx, &hash)
+		if entry != nil {
+			polyhedra[hash] = entry
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// count the utxos of each polygon. The utxo bucket also houses monitor
+	// entries keyed by an address and a hash, which are longer than outpoint
+	// keys.
+	err = meta.Bucket(utxoSetBucketName).ForEach(func(k, v []byte) error {
+		if len(k) > chainhash.HashSize+maxUint32VLQSerializeSize {
+			return nil
+		}
+		entry, err := DeserializeUtxoEntry(v)
+		if err != nil {
+			return err
+		}
+		if entry.TokenType != 3 {
+			return nil
+		}
+		hash := entry.Amount.(*token.HashToken).Hash
+		if e, ok := polyhedra[hash]; ok {
+			hash = e.Polygon
+		}
+		if p, ok := polygons[hash]; ok {
+			p.RefCnt++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	bucket := meta.Bucket(polygonSetBucketName)
+	for hash, entry := range polygons {
+		serialized, _ := serializePolygonEntry(entry)
+		if err := bucket.Put(hash[:], serialized); err != nil {
+			return err
+		}
+		if err := dbPutPolygonRefs(dbTx, hash, entry, false); err != nil {
+			return err
+		}
+	}
+	for hash, entry := range polyhedra {
+		if err := dbPutGeometryRef(dbTx, &entry.Polygon, &hash, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package viewpoint_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/database"
+	_ "github.com/omegasuite/btcd/database/ffldb"
+	"github.com/omegasuite/btcd/wire"
+	"github.com/omegasuite/btcd/wire/common"
+	"github.com/omegasuite/btcutil"
+	"github.com/omegasuite/omega/internal/testutil"
+	"github.com/omegasuite/omega/token"
+	"github.com/omegasuite/omega/viewpoint"
+)
+
+// TestPruneGeometry ensures a polygon no longer held by any output is pruned
+// together with its borders and restored when the block pruning it is
+// disconnected.
+func TestPruneGeometry(t *testing.T) {
+	dbPath := filepath.Join(os.TempDir(), "viewpoint-prune")
+	_ = os.RemoveAll(dbPath)
+	db, err := database.Create("ffldb", dbPath, common.MainNet)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+	defer db.Close()
+
+	err = db.Update(func(dbTx database.Tx) error {
+		for _, name := range [][]byte{[]byte("utxosetv2"), []byte("borders"), []byte("polygons"),
+			[]byte("polyhedra"), []byte("rights"), viewpoint.GeometryRefsBucketName,
+			viewpoint.GeometryArchiveBucketName} {
+			if _, err := dbTx.Metadata().CreateBucket(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("buckets: %v", err)
+	}
+
+	pkScript := make([]byte, 25)
+	pkScript[1] = 1
+	pkScript[21] = 0x41
+
+	// a square held by two outputs
+	defs, plg := testutil.Square(0, 0, 2*token.CoordPrecision, 2*token.CoordPrecision)
+	right := token.NewRightDef(chainhash.Hash{}, []byte("land"), 0)
+	rights := right.Hash()
+	mtx := wire.NewMsgTx(wire.TxVersion)
+	mtx.AddDef(right)
+	for _, d := range defs {
+		mtx.AddDef(d)
+	}
+	for i := 0; i < 2; i++ {
+		mtx.AddTxOut(wire.NewTxOut(3, &token.HashToken{Hash: plg.Hash()}, &rights, pkScript))
+	}
+	txid := testutil.Apply(t, db, mtx)
+	hash := plg.Hash()
+
+	borders := func() int {
+		n := 0
+		db.View(func(dbTx database.Tx) error {
+			return dbTx.Metadata().Bucket([]byte("borders")).ForEach(func(k, v []byte) error {
+				n++
+				return nil
+			})
+		})
+		return n
+	}
+	nborders := borders()
+
+	// spend the outputs one by one in blocks, pruning as the blocks at the
+	// reorg-safe depth above them do
+	spend := func(i uint32, height int32) []viewpoint.SpentTxOut {
+		spender := wire.NewMsgTx(wire.TxVersion)
+		spender.AddTxIn(wire.NewTxIn(wire.NewOutPoint(txid, i), 0))
+		spender.AddTxOut(wire.NewTxOut(0, &token.NumToken{Val: 1}, nil, pkScript))
+		coinbase := wire.NewMsgTx(wire.TxVersion)
+		coinbase.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{}, uint32(height)), 0))
+		coinbase.AddTxOut(wire.NewTxOut(0, &token.NumToken{Val: 50}, nil, pkScript))
+		msg := wire.NewMsgBlock(&wire.BlockHeader{})
+		msg.AddTransaction(coinbase)
+		msg.AddTransaction(spender)
+		block := btcutil.NewBlock(msg)
+		block.SetHeight(height)
+
+		views := viewpoint.NewViewPointSet(db)
+		if err := views.FetchInputUtxos(block); err != nil {
+			t.Fatalf("FetchInputUtxos: %v", err)
+		}
+		var stxos []viewpoint.SpentTxOut
+		if err := views.ConnectTransactions(block, &stxos); err != nil {
+			t.Fatalf("ConnectTransactions: %v", err)
+		}
+		err := db.Update(func(dbTx database.Tx) error {
+			if err := viewpoint.DbPutViews(dbTx, views); err != nil {
+				return err
+			}
+			return viewpoint.DbPruneGeometry(dbTx, views, height+viewpoint.GeometryPruneDepth, stxos)
+		})
+		if err != nil {
+			t.Fatalf("DbPruneGeometry: %v", err)
+		}
+		return stxos
+	}
+	exists := func() bool {
+		views := viewpoint.NewViewPointSet(db)
+		p, _ := views.FetchPolygonEntry(&hash)
+		return p != nil
+	}
+
+	spend(0, 1)
+	if !exists() || borders() != nborders {
+		t.Fatalf("polygon held by an output pruned")
+	}
+
+	stxos := spend(1, 2)
+	if len(stxos) != 1 {
+		t.Fatalf("%d outputs spent, want 1", len(stxos))
+	}
+	if exists() {
+		t.Fatalf("polygon not pruned")
+	}
+	if n := borders(); n != 0 {
+		t.Errorf("%d borders left after pruning, want 0", n)
+	}
+
+	var stats *viewpoint.GeometryPruneStats
+	db.View(func(dbTx database.Tx) error {
+		stats = viewpoint.DbFetchGeometryPruneStats(dbTx)
+		return nil
+	})
+	if stats.Polygons != 1 || stats.Borders != uint64(nborders) || stats.Bytes == 0 ||
+		stats.Archived != uint64(nborders+1) || stats.Height != 2+viewpoint.GeometryPruneDepth {
+		t.Errorf("stats after pruning %v", *stats)
+	}
+
+	// disconnect the block that pruned the polygon
+	views := viewpoint.NewViewPointSet(db)
+	if err := views.RestoreGeometry(2 + viewpoint.GeometryPruneDepth); err != nil {
+		t.Fatalf("RestoreGeometry: %v", err)
+	}
+	err = db.Update(func(dbTx database.Tx) error {
+		if err := viewpoint.DbPutViews(dbTx, views); err != nil {
+			return err
+		}
+		return viewpoint.DbRemoveGeometryArchive(dbTx, 2+viewpoint.GeometryPruneDepth)
+	})
+	if err != nil {
+		t.Fatalf("DbRemoveGeometryArchive: %v", err)
+	}
+	if !exists() || borders() != nborders {
+		t.Fatalf("geometry not restored")
+	}
+	db.View(func(dbTx database.Tx) error {
+		stats = viewpoint.DbFetchGeometryPruneStats(dbTx)
+		return nil
+	})
+	if stats.Polygons != 0 || stats.Borders != 0 || stats.Bytes != 0 || stats.Archived != 0 {
+		t.Errorf("stats after restoring %v", *stats)
+	}
+
+	// the references are restored too, so the polygon is pruned again
+	err = db.Update(func(dbTx database.Tx) error {
+		return viewpoint.DbPruneGeometry(dbTx, views, 3+viewpoint.GeometryPruneDepth, stxos)
+	})
+	if err != nil {
+		t.Fatalf("DbPruneGeometry: %v", err)
+	}
+	if exists() || borders() != 0 {
+		t.Errorf("restored geometry not pruned again")
+	}
+}
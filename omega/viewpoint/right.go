@@ -9,11 +9,12 @@
 package viewpoint
 
 import (
+	"bytes"
 	"fmt"
 
 	"github.com/omegasuite/btcd/chaincfg/chainhash"
 	"github.com/omegasuite/btcd/database"
-//	"github.com/omegasuite/btcd/wire"
+	"github.com/omegasuite/btcd/wire"
 	"github.com/omegasuite/btcutil"
 	"github.com/omegasuite/omega/token"
 	"github.com/omegasuite/omega"
@@ -262,8 +263,14 @@ func (view * RightViewpoint) LookupEntry(p chainhash.Hash) interface{} {
 	return view.entries[p]
 }
 
-func isContract(netid byte) bool {
-	return netid & 64 == 64
+// isContract returns whether netid is the net id of a contract address in
+// blocks of the given version. Before Version7, bit 64 was checked instead,
+// which contract addresses do not have.
+func isContract(netid byte, version uint32) bool {
+	if version < wire.Version7 {
+		return netid & 64 == 64
+	}
+	return netid & 0x88 == 0x88
 }
 
 func (view * ViewPointSet) contractExists(contract []byte) bool {
@@ -279,8 +286,9 @@ func (view * ViewPointSet) contractExists(contract []byte) bool {
 	return err == nil
 }
 
-// addRight adds the specified right to the view.
-func (view * ViewPointSet) AddRight(b *token.RightDef) bool {
+// addRight adds the specified right to the view. version is the version of
+// the block defining it.
+func (view * ViewPointSet) AddRight(b *token.RightDef, version uint32) bool {
 	h := b.Hash()
 	entry := view.Rights.LookupRightEntry(h)
 	if entry == nil {
@@ -300,7 +308,7 @@ func (view * ViewPointSet) AddRight(b *token.RightDef) bool {
 		}
 
 		if b.Attrib & token.IsMonitorCall != 0 && (len(b.Desc) < 25 ||
-			!isContract(b.Desc[0]) || !view.contractExists(b.Desc[1:21])) {
+			!isContract(b.Desc[0], version) || !view.contractExists(b.Desc[1:21])) {
 			// right description must be a contract call. check whether the contract exists
 			return false
 		}
@@ -359,13 +367,13 @@ func (view * RightViewpoint) AddRightSet(b *token.RightSetDef) bool {
 }
 
 // AddVertices adds all vertex definitions in the passed transaction to the view.
-func (view * ViewPointSet) AddRights(tx *btcutil.Tx) bool {
+func (view * ViewPointSet) AddRights(tx *btcutil.Tx, version uint32) bool {
 	// Loop all of the vertex definitions
 
 	for _, txVtx := range tx.MsgTx().TxDef {
 		switch txVtx.(type) {
 		case *token.RightDef:
-			if !view.AddRight(txVtx.(*token.RightDef)) {
+			if !view.AddRight(txVtx.(*token.RightDef), version) {
 				return false
 			}
 			break
@@ -638,6 +646,9 @@ func DbPutRightView(dbTx database.Tx, view *RightViewpoint) error {
 			if err := bucket.Delete(hash[:]); err != nil {
 				return err
 			}
+			if err := dbPutRightIndex(dbTx, hash, entry, true); err != nil {
+				return err
+			}
 			continue
 		}
 
@@ -650,8 +661,128 @@ func DbPutRightView(dbTx database.Tx, view *RightViewpoint) error {
 		if err = bucket.Put(hash[:], serialized); err != nil {
 			return err
 		}
+		if err = dbPutRightIndex(dbTx, hash, entry, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rightIndexKey returns the key of the index entry of b under a.
+func rightIndexKey(a, b *chainhash.Hash) []byte {
+	key := make([]byte, chainhash.HashSize * 2)
+	copy(key, a[:])
+	copy(key[chainhash.HashSize:], b[:])
+	return key
+}
+
+// dbPutRightIndex adds the right or right set entry of hash to the child
+// and right set member indices, or removes it from them if del is set. The
+// indices are skipped if their buckets do not exist.
+func dbPutRightIndex(dbTx database.Tx, hash chainhash.Hash, entry interface{}, del bool) error {
+	var bucket database.Bucket
+	var keys [][]byte
+
+	switch e := entry.(type) {
+	case *RightEntry:
+		if e.Father.IsEqual(&zerohash) {
+			return nil
+		}
+		bucket = dbTx.Metadata().Bucket(RightChildrenBucketName)
+		keys = [][]byte{rightIndexKey(&e.Father, &hash)}
+
+	case *RightSetEntry:
+		bucket = dbTx.Metadata().Bucket(RightSetMembersBucketName)
+		for i := range e.Rights {
+			keys = append(keys, rightIndexKey(&e.Rights[i], &hash))
+		}
+	}
+
+	if bucket == nil {
+		return nil
+	}
+
+	for _, key := range keys {
+		var err error
+		if del {
+			err = bucket.Delete(key)
+		} else {
+			err = bucket.Put(key, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dbFetchRightIndex returns the hashes indexed under hash in the given index
+// bucket.
+func dbFetchRightIndex(dbTx database.Tx, name []byte, hash *chainhash.Hash) []chainhash.Hash {
+	bucket := dbTx.Metadata().Bucket(name)
+	if bucket == nil {
+		return nil
+	}
+
+	var res []chainhash.Hash
+	cursor := bucket.Cursor()
+	for ok := cursor.Seek(hash[:]); ok; ok = cursor.Next() {
+		key := cursor.Key()
+		if len(key) != chainhash.HashSize * 2 || !bytes.Equal(key[:chainhash.HashSize], hash[:]) {
+			break
+		}
+		var h chainhash.Hash
+		copy(h[:], key[chainhash.HashSize:])
+		res = append(res, h)
 	}
+	return res
+}
 
+// DbFetchRightChildren returns the hashes of the rights whose father is hash.
+func DbFetchRightChildren(dbTx database.Tx, hash *chainhash.Hash) []chainhash.Hash {
+	return dbFetchRightIndex(dbTx, RightChildrenBucketName, hash)
+}
+
+// DbFetchRightSets returns the hashes of the right sets containing the right
+// of hash.
+func DbFetchRightSets(dbTx database.Tx, hash *chainhash.Hash) []chainhash.Hash {
+	return dbFetchRightIndex(dbTx, RightSetMembersBucketName, hash)
+}
+
+// DbBuildRightIndex creates the child and right set member index buckets if
+// they do not exist and indexes all the rights and right sets in the
+// database. It is used to upgrade databases created before the indices were
+// introduced.
+func DbBuildRightIndex(dbTx database.Tx) error {
+	meta := dbTx.Metadata()
+	for _, name := range [][]byte{RightChildrenBucketName, RightSetMembersBucketName} {
+		if _, err := meta.CreateBucketIfNotExists(name); err != nil {
+			return err
+		}
+	}
+
+	// collect the entries first as the bucket must not be modified while
+	// it is iterated
+	entries := make(map[chainhash.Hash]interface{})
+	err := meta.Bucket(rightSetBucketName).ForEach(func(k, v []byte) error {
+		var hash chainhash.Hash
+		copy(hash[:], k)
+		entry, err := DbFetchRight(dbTx, &hash)
+		if entry != nil {
+			entries[hash] = entry
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for hash, entry := range entries {
+		if err := dbPutRightIndex(dbTx, hash, entry, false); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
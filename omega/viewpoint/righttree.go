@@ -0,0 +1,190 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package viewpoint
+
+import (
+	"fmt"
+
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/database"
+	"github.com/omegasuite/omega/token"
+)
+
+// RightNode is a right in a RightTree together with the relationships of
+// the right that are not part of its definition.
+type RightNode struct {
+	Hash chainhash.Hash
+	*RightEntry
+
+	// Sibling is the hash of the positive counterpart of the right, or of
+	// the right on the other side of the monitor for a right with a monitor
+	// call. It may not have been defined.
+	Sibling chainhash.Hash
+
+	// Monitoring is the hash of the monitor right of a monitored right
+	// with a contract call. It is zero for other rights.
+	Monitoring chainhash.Hash
+
+	// Children are the hashes of the rights whose father is this right.
+	Children []chainhash.Hash
+
+	// Sets are the hashes of the right sets containing this right.
+	Sets []chainhash.Hash
+}
+
+// Negative returns whether the description of the right is negative.
+func (n *RightNode) Negative() bool {
+	return n.Attrib&token.NegativeRight != 0
+}
+
+// Unsplittable returns whether the right may not be split further.
+func (n *RightNode) Unsplittable() bool {
+	return n.Attrib&token.Unsplittable != 0
+}
+
+// Monitored returns whether tokens with the right are monitored.
+func (n *RightNode) Monitored() bool {
+	return n.Attrib&token.Monitored != 0
+}
+
+// Monitor returns whether the right is for a monitoring token.
+func (n *RightNode) Monitor() bool {
+	return n.Attrib&token.Monitor != 0
+}
+
+// MonitorCall returns whether the description of the right is a contract
+// call for monitoring.
+func (n *RightNode) MonitorCall() bool {
+	return n.Attrib&token.IsMonitorCall != 0
+}
+
+// RightTree is the hierarchy around a right.
+type RightTree struct {
+	Right RightNode
+
+	// Ancestors are the ancestors of the right from its father to its root.
+	Ancestors []RightNode
+
+	// Descendants are the descendants of the right in breadth first order.
+	Descendants []RightNode
+}
+
+// rightIndex returns the hashes indexed under hash in the given index bucket
+// as modified by the entries in the view that have not been written to the
+// database.
+func (views *ViewPointSet) rightIndex(name []byte, hash *chainhash.Hash) []chainhash.Hash {
+	var stored []chainhash.Hash
+	views.Db.View(func(dbTx database.Tx) error {
+		stored = dbFetchRightIndex(dbTx, name, hash)
+		return nil
+	})
+
+	index := make(map[chainhash.Hash]bool)
+	res := make([]chainhash.Hash, 0, len(stored))
+	for _, h := range stored {
+		index[h] = true
+	}
+
+	for h, entry := range views.Rights.entries {
+		var indexed, modified, deleted bool
+		switch e := entry.(type) {
+		case *RightEntry:
+			indexed = e.Father.IsEqual(hash)
+			modified, deleted = e.isModified(), e.toDelete()
+		case *RightSetEntry:
+			for _, r := range e.Rights {
+				indexed = indexed || r.IsEqual(hash)
+			}
+			modified, deleted = e.isModified(), e.toDelete()
+		}
+		if indexed && modified {
+			index[h] = !deleted
+		}
+	}
+
+	for _, h := range stored {
+		if index[h] {
+			res = append(res, h)
+		}
+		delete(index, h)
+	}
+	for h, ok := range index {
+		if ok {
+			res = append(res, h)
+		}
+	}
+	return res
+}
+
+// RightChildren returns the hashes of the rights whose father is hash.
+func (views *ViewPointSet) RightChildren(hash *chainhash.Hash) []chainhash.Hash {
+	return views.rightIndex(RightChildrenBucketName, hash)
+}
+
+// RightSets returns the hashes of the right sets containing the right of hash.
+func (views *ViewPointSet) RightSets(hash *chainhash.Hash) []chainhash.Hash {
+	return views.rightIndex(RightSetMembersBucketName, hash)
+}
+
+// rightNode returns the node of the right of hash.
+func (views *ViewPointSet) rightNode(hash chainhash.Hash) (*RightNode, error) {
+	e, _ := views.FetchRightEntry(&hash)
+	entry, ok := e.(*RightEntry)
+	if !ok || entry.toDelete() {
+		return nil, fmt.Errorf("%s is not a right", hash.String())
+	}
+
+	return &RightNode{
+		Hash:       hash,
+		RightEntry: entry,
+		Sibling:    entry.Sibling(),
+		Monitoring: entry.Monitoring(),
+		Children:   views.RightChildren(&hash),
+		Sets:       views.RightSets(&hash),
+	}, nil
+}
+
+// RightTree returns the ancestors and the descendants of the right of hash.
+// Descendants deeper than maxDepth levels below the right are not included
+// unless maxDepth is 0.
+func (views *ViewPointSet) RightTree(hash chainhash.Hash, maxDepth int32) (*RightTree, error) {
+	node, err := views.rightNode(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := &RightTree{Right: *node}
+
+	for f := node.Father; !f.IsEqual(&zerohash); {
+		n, err := views.rightNode(f)
+		if err != nil {
+			return nil, err
+		}
+		tree.Ancestors = append(tree.Ancestors, *n)
+		f = n.Father
+	}
+
+	level := []*RightNode{node}
+	for depth := int32(1); len(level) > 0 && (maxDepth == 0 || depth <= maxDepth); depth++ {
+		var next []*RightNode
+		for _, p := range level {
+			for _, c := range p.Children {
+				n, err := views.rightNode(c)
+				if err != nil {
+					return nil, err
+				}
+				tree.Descendants = append(tree.Descendants, *n)
+				next = append(next, n)
+			}
+		}
+		level = next
+	}
+
+	return tree, nil
+}
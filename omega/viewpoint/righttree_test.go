@@ -0,0 +1,184 @@
+/* Copyright (C) 2019-2021 Omegasuite developers - All Rights Reserved
+* This file is part of the omega chain library.
+*
+* Use of this source code is governed by license that can be
+* found in the LICENSE file.
+*
+ */
+
+package viewpoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/omegasuite/btcd/chaincfg/chainhash"
+	"github.com/omegasuite/btcd/database"
+	_ "github.com/omegasuite/btcd/database/ffldb"
+	"github.com/omegasuite/btcd/wire"
+	"github.com/omegasuite/btcd/wire/common"
+	"github.com/omegasuite/omega/token"
+)
+
+// hasHashes returns whether hs holds exactly the hashes of want.
+func hasHashes(hs []chainhash.Hash, want ...chainhash.Hash) bool {
+	if len(hs) != len(want) {
+		return false
+	}
+	m := make(map[chainhash.Hash]struct{})
+	for _, h := range hs {
+		m[h] = struct{}{}
+	}
+	for _, h := range want {
+		if _, ok := m[h]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// TestRightTree ensures the child and right set member indices follow rights
+// written to and rolled back from the database and the tree built from them.
+func TestRightTree(t *testing.T) {
+	dbPath := filepath.Join(os.TempDir(), "viewpoint-righttree")
+	_ = os.RemoveAll(dbPath)
+	db, err := database.Create("ffldb", dbPath, common.MainNet)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+	defer db.Close()
+
+	err = db.Update(func(dbTx database.Tx) error {
+		for _, name := range [][]byte{rightSetBucketName, RightChildrenBucketName, RightSetMembersBucketName} {
+			if _, err := dbTx.Metadata().CreateBucket(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("buckets: %v", err)
+	}
+
+	root := token.NewRightDef(chainhash.Hash{}, []byte("land"), 0)
+	a := token.NewRightDef(root.Hash(), []byte("farm"), 0)
+	b := token.NewRightDef(root.Hash(), []byte("farm"), token.NegativeRight|token.Unsplittable)
+	c := token.NewRightDef(a.Hash(), []byte("crop"), 0)
+	set := &token.RightSetDef{Rights: []chainhash.Hash{a.Hash(), b.Hash()}}
+	rootHash, aHash, bHash := root.Hash(), a.Hash(), b.Hash()
+
+	views := NewViewPointSet(db)
+	for _, r := range []*token.RightDef{root, a, b, c} {
+		if !views.AddRight(r, wire.Version7) {
+			t.Fatalf("AddRight %v failed", r.Hash())
+		}
+	}
+	views.Rights.AddRightSet(set)
+
+	// the view is seen before it is written
+	if ch := views.RightChildren(&rootHash); !hasHashes(ch, a.Hash(), b.Hash()) {
+		t.Errorf("unwritten children of root %v", ch)
+	}
+
+	err = db.Update(func(dbTx database.Tx) error {
+		return DbPutRightView(dbTx, views.Rights)
+	})
+	if err != nil {
+		t.Fatalf("DbPutRightView: %v", err)
+	}
+
+	tree, err := NewViewPointSet(db).RightTree(root.Hash(), 0)
+	if err != nil {
+		t.Fatalf("RightTree: %v", err)
+	}
+	if len(tree.Ancestors) != 0 || len(tree.Descendants) != 3 {
+		t.Errorf("root has %d ancestors and %d descendants, want 0 and 3",
+			len(tree.Ancestors), len(tree.Descendants))
+	}
+	if !hasHashes(tree.Right.Children, a.Hash(), b.Hash()) {
+		t.Errorf("children of root %v", tree.Right.Children)
+	}
+	if d := tree.Descendants[len(tree.Descendants)-1]; d.Hash != c.Hash() || d.Depth != 2 {
+		t.Errorf("last descendant %v at depth %d, want %v at 2", d.Hash, d.Depth, c.Hash())
+	}
+	for _, d := range tree.Descendants {
+		switch d.Hash {
+		case a.Hash():
+			if !hasHashes(d.Sets, set.Hash()) || d.Negative() || d.Sibling != a.Hash() {
+				t.Errorf("right a: sets %v, negative %v, sibling %v", d.Sets, d.Negative(), d.Sibling)
+			}
+		case b.Hash():
+			positive := token.NewRightDef(root.Hash(), []byte("farm"), token.Unsplittable).Hash()
+			if !d.Negative() || !d.Unsplittable() || d.Monitored() || len(d.Children) != 0 || d.Sibling != positive {
+				t.Errorf("right b: flags %x, children %v, sibling %v", d.Attrib, d.Children, d.Sibling)
+			}
+		}
+	}
+
+	tree, err = NewViewPointSet(db).RightTree(root.Hash(), 1)
+	if err != nil {
+		t.Fatalf("RightTree: %v", err)
+	}
+	if len(tree.Descendants) != 2 {
+		t.Errorf("root has %d descendants within 1 level, want 2", len(tree.Descendants))
+	}
+
+	tree, err = NewViewPointSet(db).RightTree(c.Hash(), 0)
+	if err != nil {
+		t.Fatalf("RightTree: %v", err)
+	}
+	if len(tree.Ancestors) != 2 || tree.Ancestors[0].Hash != a.Hash() || tree.Ancestors[1].Hash != root.Hash() {
+		t.Errorf("ancestors of c %v", tree.Ancestors)
+	}
+
+	if _, err := NewViewPointSet(db).RightTree(set.Hash(), 0); err == nil {
+		t.Errorf("tree of a right set returned")
+	}
+
+	// roll back c and the right set
+	views = NewViewPointSet(db)
+	for _, h := range []chainhash.Hash{c.Hash(), set.Hash()} {
+		e, _ := views.FetchRightEntry(&h)
+		switch e := e.(type) {
+		case *RightEntry:
+			e.RollBack()
+		case *RightSetEntry:
+			e.RollBack()
+		}
+	}
+	if ch := views.RightChildren(&aHash); len(ch) != 0 {
+		t.Errorf("rolled back children of a %v", ch)
+	}
+	err = db.Update(func(dbTx database.Tx) error {
+		return DbPutRightView(dbTx, views.Rights)
+	})
+	if err != nil {
+		t.Fatalf("DbPutRightView: %v", err)
+	}
+
+	views = NewViewPointSet(db)
+	if ch := views.RightChildren(&aHash); len(ch) != 0 {
+		t.Errorf("children of a after roll back %v", ch)
+	}
+	if sets := views.RightSets(&bHash); len(sets) != 0 {
+		t.Errorf("sets of b after roll back %v", sets)
+	}
+
+	// rebuild the indices of a database without them
+	err = db.Update(func(dbTx database.Tx) error {
+		for _, name := range [][]byte{RightChildrenBucketName, RightSetMembersBucketName} {
+			if err := dbTx.Metadata().DeleteBucket(name); err != nil {
+				return err
+			}
+		}
+		return DbBuildRightIndex(dbTx)
+	})
+	if err != nil {
+		t.Fatalf("DbBuildRightIndex: %v", err)
+	}
+	if ch := NewViewPointSet(db).RightChildren(&rootHash); !hasHashes(ch, a.Hash(), b.Hash()) {
+		t.Errorf("rebuilt children of root %v", ch)
+	}
+}
@@ -459,7 +459,9 @@ func (view *ViewPointSet) disconnectTransactions(db database.DB, block *btcutil.
 			}
 
 			if txOut.TokenType == 3 {
-				view.Polygon.LookupEntry(txOut.Token.Value.(*token.HashToken).Hash).deReference(view)
+				if p, _ := view.TokenPolygon(&txOut.Token.Value.(*token.HashToken).Hash); p != nil {
+					p.deReference(view)
+				}
 			}
 
 			entry.Spend()
@@ -536,7 +538,9 @@ func (view *ViewPointSet) disconnectTransactions(db database.DB, block *btcutil.
 			}
 
 			if entry.TokenType&3 == 3 {
-				view.Polygon.LookupEntry(entry.Amount.(*token.HashToken).Hash).reference(view)
+				if p, _ := view.TokenPolygon(&entry.Amount.(*token.HashToken).Hash); p != nil {
+					p.reference(view)
+				}
 			}
 
 			// if it has a monitor right, add a monitor index
@@ -963,6 +967,9 @@ func DbPutUtxoView(dbTx database.Tx, view *UtxoViewpoint) error {
 		if entry.IsSpent() {
 			key := outpointKey(outpoint)
 			err := utxoBucket.Delete(*key)
+			if err == nil {
+				err = dbPutAddrUtxo(dbTx, entry.pkScript, *key, true)
+			}
 			recycleOutpointKey(key)
 			if err != nil {
 				return err
@@ -990,6 +997,9 @@ func DbPutUtxoView(dbTx database.Tx, view *UtxoViewpoint) error {
 		if err != nil {
 			return err
 		}
+		if err = dbPutAddrUtxo(dbTx, entry.pkScript, *key, false); err != nil {
+			return err
+		}
 
 		if entry.packedFlags & TfMonitoring != 0 {
 			utxoBucket.Put(entry.monitor, *key)
@@ -36,10 +36,41 @@ var (
 	// polygon definition set.
 	polygonSetBucketName = []byte("polygons")
 
+	// PolyhedronSetBucketName is the name of the db bucket used to house the
+	// polyhedron definition set.
+	PolyhedronSetBucketName = []byte("polyhedra")
+
 	// rightSetBucketName is the name of the db bucket used to house the
 	// right definition set.
 	rightSetBucketName = []byte("rights")
 
+	// AddrUtxoBucketName is the name of the db bucket used to index the
+	// unspent transaction output set by address. Keys are the address, as
+	// at the beginning of the pkScript, followed by the outpoint key.
+	AddrUtxoBucketName = []byte("utxobyaddr")
+
+	// GeometryRefsBucketName is the name of the db bucket used to index
+	// borders and polygons by the polygons and polyhedra defined on them.
+	// Keys are the hash of the border or polygon followed by the hash of the
+	// polygon or polyhedron using it.
+	GeometryRefsBucketName = []byte("geometryrefs")
+
+	// GeometryArchiveBucketName is the name of the db bucket used to keep
+	// pruned borders and polygons for restoring them on reorgs. Keys are the
+	// height of the block that pruned them, a byte for the kind of geometry
+	// and the hash.
+	GeometryArchiveBucketName = []byte("geoarchive")
+
+	// RightChildrenBucketName is the name of the db bucket used to index
+	// rights by their father. Keys are the father hash followed by the right
+	// hash.
+	RightChildrenBucketName = []byte("rightchildren")
+
+	// RightSetMembersBucketName is the name of the db bucket used to index
+	// right sets by the rights in them. Keys are the right hash followed by
+	// the right set hash.
+	RightSetMembersBucketName = []byte("rightsetmembers")
+
 	// byteOrder is the preferred byte order used for serializing numeric
 	// fields for storage in the database.
 	byteOrder = binary.LittleEndian
@@ -56,6 +87,7 @@ type ViewPointSet struct {
 	Utxo * UtxoViewpoint
 	Border * BorderViewpoint
 	Polygon * PolygonViewpoint
+	Polyhedron * PolyhedronViewpoint
 	Rights * RightViewpoint
 }
 
@@ -65,6 +97,7 @@ func NewViewPointSet(db database.DB) * ViewPointSet {
 	t.Utxo = NewUtxoViewpoint()
 	t.Border = NewBorderViewpoint()
 	t.Polygon = NewPolygonViewpoint()
+	t.Polyhedron = NewPolyhedronViewpoint()
 	t.Rights = NewRightViewpoint()
 
 	return &t
@@ -73,12 +106,23 @@ func NewViewPointSet(db database.DB) * ViewPointSet {
 func (t * ViewPointSet) SetBestHash(hash * chainhash.Hash) {
 	t.Rights.bestHash = *hash
 	t.Polygon.bestHash = *hash
+	t.Polyhedron.bestHash = *hash
 	t.Border.bestHash = *hash
 	t.Utxo.bestHash = *hash
 }
 
 func (t * ViewPointSet) DisconnectTransactions(db database.DB, block *btcutil.Block, stxos []SpentTxOut) error {
-	err := t.disconnectRightTransactions(block)
+	// bring back the geometry pruned when the block was connected first as
+	// the block may not be disconnected without it
+	err := t.RestoreGeometry(block.Height())
+	if err != nil {
+		return err
+	}
+	err = t.disconnectRightTransactions(block)
+	if err != nil {
+		return err
+	}
+	err = t.disconnectPolyhedronTransactions(block)
 	if err != nil {
 		return err
 	}
@@ -123,6 +167,7 @@ func (t * ViewPointSet) DisconnectTransactions(db database.DB, block *btcutil.Bl
 
 func (t * ViewPointSet) Commit() {
 	t.Rights.commit()
+	t.Polyhedron.commit()
 	t.Polygon.commit()
 	t.Border.commit()
 	t.Utxo.commit()
@@ -131,6 +176,7 @@ func (t * ViewPointSet) Commit() {
 func DbPutViews(dbTx database.Tx,  view * ViewPointSet) error {
 	DbPutUtxoView(dbTx, view.Utxo)
 	DbPutPolygonView(dbTx, view.Polygon)
+	DbPutPolyhedronView(dbTx, view.Polyhedron)
 	DbPutBorderView(dbTx, view.Border)
 
 	return DbPutRightView(dbTx, view.Rights)
@@ -173,15 +219,30 @@ func DbPutGensisTransaction(dbTx database.Tx, tx *btcutil.Tx, view * ViewPointSe
 				}
 			}
 			break;
+		case *token.PolyhedronDef:
+			view.AddOnePolyhedron(d.(*token.PolyhedronDef))
+			break;
 		case *token.RightDef:
-			view.AddRight(d.(*token.RightDef))
+			// the genesis block predates all deployments
+			view.AddRight(d.(*token.RightDef), 0)
 			break;
 		}
 	}
 
+	// count the polygon tokens paid by the transaction
+	for _, out := range tx.MsgTx().TxOut {
+		if out.IsSeparator() || out.TokenType != 3 {
+			continue
+		}
+		if p, _ := view.TokenPolygon(&out.Token.Value.(*token.HashToken).Hash); p != nil {
+			p.refChg++
+		}
+	}
+
 	DbPutBorderView(dbTx, bdrview)
 	DbPutRightView(dbTx, rtview)
 	DbPutPolygonView(dbTx, plgview)
+	DbPutPolyhedronView(dbTx, view.Polyhedron)
 
 	view.AddTxOuts(tx, 0)
 
@@ -214,12 +275,15 @@ func (view * ViewPointSet) ConnectTransactions(block *btcutil.Block, stxos *[]Sp
 		if !view.AddBorder(tx) {
 			return fmt.Errorf("Attempt to add illegal border.")
 		}
-		if !view.AddRights(tx) {
+		if !view.AddRights(tx, block.MsgBlock().Header.Version) {
 			return fmt.Errorf("Attempt to add illegal rights.")
 		}
 		if !view.AddPolygon(tx) {
 			return fmt.Errorf("Attempt to add illegal polygon.")
 		}
+		if !view.AddPolyhedron(tx) {
+			return fmt.Errorf("Attempt to add illegal polyhedron.")
+		}
 
 		if !tx.IsCoinBase() {
 			for _, in := range tx.MsgTx().TxIn {
@@ -232,11 +296,7 @@ func (view * ViewPointSet) ConnectTransactions(block *btcutil.Block, stxos *[]Sp
 				}
 
 				if entry.TokenType&3 == 3 {
-					p := view.Polygon.LookupEntry(entry.Amount.(*token.HashToken).Hash)
-					if p == nil {
-						view.FetchPolygonEntry(&entry.Amount.(*token.HashToken).Hash)
-						p = view.Polygon.LookupEntry(entry.Amount.(*token.HashToken).Hash)
-					}
+					p, _ := view.TokenPolygon(&entry.Amount.(*token.HashToken).Hash)
 					if p != nil {
 						p.deReference(view)
 					}
@@ -248,7 +308,9 @@ func (view * ViewPointSet) ConnectTransactions(block *btcutil.Block, stxos *[]Sp
 				continue
 			}
 			if out.TokenType == 3 {
-				view.Polygon.LookupEntry(out.Token.Value.(*token.HashToken).Hash).reference(view)
+				if p, _ := view.TokenPolygon(&out.Token.Value.(*token.HashToken).Hash); p != nil {
+					p.reference(view)
+				}
 			}
 		}
 		view.ConnectTransaction(tx, block.Height(), stxos)
@@ -9,9 +9,7 @@ import (
 	"crypto/rsa"
 	"encoding/json"
 	"github.com/omegasuite/btcd/blockchain"
-	"github.com/omegasuite/btcd/btcec"
 	"github.com/omegasuite/btcd/connmgr"
-	"github.com/omegasuite/omega/consensus"
 	"github.com/omegasuite/omega/minerchain"
 	"math/big"
 	"net"
@@ -21,9 +19,9 @@ import (
 //	"github.com/omegasuite/btcd/peer"
 	"github.com/omegasuite/btcd/wire"
 	"github.com/omegasuite/btcutil"
+	"github.com/omegasuite/omega/signer"
 )
 
-const advanceCommitteeConnection = wire.CommitteeSize	// # of miner blocks we should prepare for connection
 const maxFailedAttempts = 25
 
 // This must be a go routine
@@ -121,7 +119,7 @@ func (p * peerState) CommitteeOut(s * committeeState) {
 			if ok && r {
 				reply := wire.MsgKnowledgeDone(*m)
 				reply.From = s.member
-				consensus.HandleMessage(&reply)
+				p.consensus.HandleMessage(&reply)
 			}
 		}
 	}
@@ -130,8 +128,8 @@ func (p * peerState) CommitteeOut(s * committeeState) {
 func (sp *serverPeer) OnAckInvitation(_ *peer.Peer, msg *wire.MsgAckInvitation) {
 	sp.server.peerState.print()
 
-	if (sp.server.chain.BestSnapshot().LastRotation > uint32(msg.Invitation.Height)+wire.CommitteeSize) ||
-		(sp.server.chain.BestSnapshot().LastRotation+advanceCommitteeConnection < uint32(msg.Invitation.Height)) {
+	if (sp.server.chain.BestSnapshot().LastRotation > uint32(msg.Invitation.Height)+uint32(sp.server.chainParams.MaxCommitteeSize())) ||
+		(sp.server.chain.BestSnapshot().LastRotation+uint32(sp.server.advanceCommitteeConnection()) < uint32(msg.Invitation.Height)) {
 		// expired or too early for me
 		return
 	}
@@ -237,7 +235,7 @@ func (sp *serverPeer) OnInvitation(_ *peer.Peer, msg *wire.MsgInvitation) {
 	sp.server.peerState.print()
 
 	// 1. check if the message has expired or too far out, if yes, do nothing
-	if sp.server.chain.BestSnapshot().LastRotation > msg.Expire || msg.Expire - sp.server.chain.BestSnapshot().LastRotation > 5 * wire.CommitteeSize {
+	if sp.server.chain.BestSnapshot().LastRotation > msg.Expire || msg.Expire - sp.server.chain.BestSnapshot().LastRotation > 5 * uint32(sp.server.chainParams.MaxCommitteeSize()) {
 		return
 	}
 
@@ -246,15 +244,15 @@ func (sp *serverPeer) OnInvitation(_ *peer.Peer, msg *wire.MsgInvitation) {
 	// 2. check if we are invited, if yes take it by connecting to the peer
 	// decode the message
 	// try to decode the message with my RSA priv key
-	if sp.server.rsaPrivateKey != nil {
-		m, err := rsa.DecryptOAEP(sha256.New(), nil, sp.server.rsaPrivateKey, msg.Msg, []byte("invitation"))
+	if sp.server.invitationKey != nil {
+		m, err := sp.server.invitationKey.Decrypt(msg.Msg, []byte("invitation"))
 		if err == nil {
 			// this mesage is for me
 			inv := wire.Invitation{}
 			inv.Deserialize(bytes.NewReader(m))
 
-			if (sp.server.chain.BestSnapshot().LastRotation > uint32(inv.Height)+wire.CommitteeSize) ||
-				(sp.server.chain.BestSnapshot().LastRotation+advanceCommitteeConnection < uint32(inv.Height)) {
+			if (sp.server.chain.BestSnapshot().LastRotation > uint32(inv.Height)+uint32(sp.server.chainParams.MaxCommitteeSize())) ||
+				(sp.server.chain.BestSnapshot().LastRotation+uint32(sp.server.advanceCommitteeConnection()) < uint32(inv.Height)) {
 				// expired or too early for me
 				return
 			}
@@ -411,15 +409,26 @@ func (s *server) phaseoutCommittee(r int32) {
 	s.peerState.cmutex.Unlock()
 }
 
+// advanceCommitteeConnection returns the # of miner blocks we should prepare
+// for connection.
+func (s *server) advanceCommitteeConnection() int32 {
+	return s.chainParams.MaxCommitteeSize()
+}
+
 func (s *server) MyPlaceInCommittee(r int32) int32 {
 	if s.signAddress == nil {
 		return 0
 	}
 
+	committee, err := s.chain.Committee(r)
+	if err != nil {
+		return 0
+	}
+
 	minerTop := s.chain.Miners.BestSnapshot().Height
 
-	for i := r - wire.CommitteeSize + 1; i < r + advanceCommitteeConnection; i++ {
-		// scan wire.CommitteeSize records before and after r to determine
+	for i := r - committee.Size + 1; i < r + s.advanceCommitteeConnection(); i++ {
+		// scan committee size records before and after r to determine
 		// if we are in the committee
 		if i < 0 || i >= minerTop {
 			continue
@@ -462,7 +471,7 @@ func (s * server) makeInvitation(me int32, miner []byte) (* wire.Invitation, * b
 			continue
 		}
 
-		pk := s.privKeys[j].PubKey()
+		pk := s.signers[j].PubKey()
 
 		copy(inv.Pubkey[:], pk.SerializeCompressed())
 		//	copy(inv.Pubkey[:], s.privKeys.PubKey().SerializeUncompressed())
@@ -481,7 +490,7 @@ func (s * server) makeInvitationMsg(me int32, miner []byte, conn []byte) * wire.
 	}
 
 	m := wire.MsgInvitation{
-		Expire: uint32(me) + wire.CommitteeSize + uint32(randomUint16Number(10)),
+		Expire: uint32(me) + uint32(s.chainParams.MaxCommitteeSize()) + uint32(randomUint16Number(10)),
 	}
 
 	copy(m.To[:], miner)
@@ -489,11 +498,10 @@ func (s * server) makeInvitationMsg(me int32, miner []byte, conn []byte) * wire.
 
 	if inv != nil {
 		inv.Serialize(&w)
-		hash := chainhash.DoubleHashH(w.Bytes())
 
-		for i, key := range s.privKeys {
+		for i, key := range s.signers {
 			if sa == &s.signAddress[i] {
-				if sig, err := key.Sign(hash[:]); err == nil {
+				if sig, err := key.Sign(signer.InvitationRequest(inv)); err == nil {
 					m.Sig = sig.Serialize()
 				}
 			}
@@ -652,7 +660,13 @@ func (s *server) handleCommitteRotation(r int32) {
 		}
 	}
 
-	s.phaseoutCommittee(r - 2 * wire.CommitteeSize)
+	committee, err := b.Committee(r)
+	if err != nil {
+		btcdLog.Infof("handleCommitteRotation: %s", err.Error())
+		return
+	}
+	size := committee.Size
+	s.phaseoutCommittee(r - 2 * size)
 
 	me := s.MyPlaceInCommittee(r)
 	if me == 0 {
@@ -668,14 +682,14 @@ func (s *server) handleCommitteRotation(r int32) {
 
 	minerTop := s.chain.Miners.BestSnapshot().Height
 
-	// block me is myself, check CommitteeSize miners before and advanceCommitteeConnection
+	// block me is myself, check committee size miners before and advanceCommitteeConnection
 	// miners afetr me to connect to them
-	bot := me - wire.CommitteeSize + 1
+	bot := me - size + 1
 	if r > me {
-		bot = r - wire.CommitteeSize + 1
+		bot = r - size + 1
 	}
 
-	for j := bot; j < me + advanceCommitteeConnection; j++ {
+	for j := bot; j < me + s.advanceCommitteeConnection(); j++ {
 		if me == j || j < 0 || j >= minerTop {
 			continue
 		}
@@ -882,10 +896,10 @@ func (s *server) NewConsusBlock(m * btcutil.Block) {
 	}
 }
 
-func (s *server) GetPrivKey(who [20]byte) * btcec.PrivateKey {
+func (s *server) GetSigner(who [20]byte) signer.Signer {
 	for i,k := range s.signAddress {
 		if bytes.Compare(who[:], k.ScriptAddress()) == 0 {
-			return cfg.privateKeys[i]
+			return s.signers[i]
 		}
 	}
 	return nil
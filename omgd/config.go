@@ -11,7 +11,6 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
-	"github.com/omegasuite/btcd/btcec"
 	"io"
 	"net"
 	"os"
@@ -32,6 +31,7 @@ import (
 	"github.com/omegasuite/btcd/wire"
 	"github.com/omegasuite/btcutil"
 	"github.com/omegasuite/go-socks/socks"
+	"github.com/omegasuite/omega/signer"
 	flags "github.com/jessevdk/go-flags"
 )
 
@@ -117,6 +117,7 @@ type config struct {
 	TestNet            bool     `long:"testnet" description:"Use the test network"`
 	RegressionTest     bool     `long:"regtest" description:"Use the regression test network"`
 	SimNet             bool     `long:"simnet" description:"Use the simulation test network"`
+	CommitteeSize      int32    `long:"committeesize" description:"Sign blocks by committees of the specified size, from 1 to 64, from the genesis block on -- Only allowed with --regtest or --simnet"`
 	AddCheckpoints     []string `long:"addcheckpoint" description:"Add a custom checkpoint.  Format: '<height>:<hash>'"`
 	DisableCheckpoints bool     `long:"nocheckpoints" description:"Disable built-in checkpoints.  Don't do this unless you know what you're doing."`
 	DbType             string   `long:"dbtype" description:"Database backend to use for the Block Chain"`
@@ -140,6 +141,8 @@ type config struct {
 	MiningAddrs        []string      `long:"miningaddr" description:"Add the specified payment address to the list of addresses to use for generated blocks -- At least one address is required if the generate option is set"`
 	PrivKeys           []string      `long:"privkeys" description:"Set the specified private key to the list of keys to sign for generated blocks -- One key is required if the generate option is set"`
 	RsaPrivateKey      string        `long:"rsaprivatekey" description:"Add the specified RSA private key to decode invitation -- At least one key is required if the generate option is set"`
+	Keystore           string        `long:"keystore" description:"Sign generated blocks with the keys of the specified encrypted keystore file, and decode invitations with its RSA key. The passphrase is read from standard input on start up"`
+	Signer             string        `long:"signer" description:"Sign generated blocks with the keys of the signing daemon listening on the specified Unix socket, and decode invitations with its RSA key"`
 	BlockPrioritySize  uint32        `long:"blockprioritysize" description:"Size in bytes for high-priority/low-fee transactions when creating a block"`
 	MinBlockWeight     uint32        `long:"minblockweight" description:"Minimal desired transactions in a block"`
 	UserAgentComments  []string      `long:"uacomment" description:"Comment to add to the user agent -- See BIP 14 for more information."`
@@ -152,6 +155,14 @@ type config struct {
 	DropTxIndex    bool          `long:"droptxindex" description:"Deletes the hash-based transaction index from the database on start up and then exits."`
 	AddrIndex      bool          `long:"addrindex" description:"Maintain a full address-based transaction index which makes the searchrawtransactions RPC available"`
 	DropAddrIndex  bool          `long:"dropaddrindex" description:"Deletes the address-based transaction index from the database on start up and then exits."`
+	ContractEventIndex     bool      `long:"contracteventindex" description:"Maintain an index of the events emitted by contracts, by contract and topic, which makes the getcontractevents RPC available"`
+	DropContractEventIndex bool      `long:"dropcontracteventindex" description:"Deletes the contract event index from the database on start up and then exits."`
+	PolygonIndex           bool      `long:"polygonindex" description:"Maintain a spatial index of the polygons of unspent polygon tokens, which makes the searchborder, polygonsat and polygonsinbox RPCs available"`
+	DropPolygonIndex       bool      `long:"droppolygonindex" description:"Deletes the polygon index from the database on start up and then exits."`
+	TokenIndex             bool      `long:"tokenindex" description:"Maintain a registry of the token types issued on the chain, which makes the listtokens and gettokeninfo RPCs available"`
+	DropTokenIndex         bool      `long:"droptokenindex" description:"Deletes the token registry index from the database on start up and then exits."`
+	ExportSignJournal string     `long:"exportsignjournal" description:"Exports the journal of blocks signed by the committee keys to the specified file on start up and then exits. Import it on the node the keys are moved to."`
+	ImportSignJournal string     `long:"importsignjournal" description:"Imports the journal of blocks signed by the committee keys from the specified file, as exported on the node the keys are moved from, on start up."`
 	RelayNonStd    bool          `long:"relaynonstd" description:"Relay non-standard transactions regardless of the default settings for the active network."`
 	RejectNonStd   bool          `long:"rejectnonstd" description:"Reject non-standard transactions regardless of the default settings for the active network."`
 	ShareMining    bool          `long:"sharemining" description:"Enable Shared Mining."`
@@ -161,9 +172,10 @@ type config struct {
 	addCheckpoints []chaincfg.Checkpoint
 	miningAddrs    []btcutil.Address
 
-	// signAddress is the address for privateKeys
+	// signAddress is the address for signers
 	signAddress    []btcutil.Address
-	privateKeys    []*btcec.PrivateKey
+	signers        []signer.Signer
+	invitationKey  signer.Decrypter
 
 	minRelayTxFee btcutil.Amount
 	whitelists    []*net.IPNet
@@ -563,6 +575,26 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// The committees may only be resized on the test networks that are
+	// set up locally, as all nodes of a network must agree on them.
+	if cfg.CommitteeSize != 0 {
+		if !cfg.RegressionTest && !cfg.SimNet {
+			str := "%s: The committeesize option is only allowed with " +
+				"the regtest and simnet params"
+			err := fmt.Errorf(str, funcName)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		committees := []chaincfg.CommitteeParams{chaincfg.NewCommittee(0, cfg.CommitteeSize)}
+		if err := activeNetParams.Params.SetCommittees(committees); err != nil {
+			err := fmt.Errorf("%s: %v", funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+
 	// Set the default policy for relaying non-standard transactions
 	// according to the default of the active network. The set
 	// configuration value takes precedence over the default value for the
@@ -835,6 +867,36 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// --contracteventindex and --dropcontracteventindex do not mix.
+	if cfg.ContractEventIndex && cfg.DropContractEventIndex {
+		err := fmt.Errorf("%s: the --contracteventindex and --dropcontracteventindex "+
+			"options may not be activated at the same time",
+			funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --polygonindex and --droppolygonindex do not mix.
+	if cfg.PolygonIndex && cfg.DropPolygonIndex {
+		err := fmt.Errorf("%s: the --polygonindex and --droppolygonindex "+
+			"options may not be activated at the same time",
+			funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --tokenindex and --droptokenindex do not mix.
+	if cfg.TokenIndex && cfg.DropTokenIndex {
+		err := fmt.Errorf("%s: the --tokenindex and --droptokenindex "+
+			"options may not be activated at the same time",
+			funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Check mining addresses are valid and saved parsed versions.
 	cfg.miningAddrs = make([]btcutil.Address, 0, len(cfg.MiningAddrs) + len(cfg.PrivKeys))
 	for _, strAddr := range cfg.MiningAddrs {
@@ -856,7 +918,7 @@ func loadConfig() (*config, []string, error) {
 		cfg.miningAddrs = append(cfg.miningAddrs, addr)
 	}
 
-	cfg.privateKeys = make([]*btcec.PrivateKey, 0, len(cfg.PrivKeys))
+	cfg.signers = make([]signer.Signer, 0, len(cfg.PrivKeys))
 	cfg.signAddress = make([]btcutil.Address, 0, len(cfg.PrivKeys))
 	if len(cfg.PrivKeys) > 0 {
 		for _, pk := range cfg.PrivKeys {
@@ -884,7 +946,7 @@ func loadConfig() (*config, []string, error) {
 				}
 				cfg.miningAddrs = append(cfg.miningAddrs, addr)
 				cfg.signAddress = append(cfg.signAddress, addr)
-				cfg.privateKeys = append(cfg.privateKeys, privKey)
+				cfg.signers = append(cfg.signers, signer.NewKeySigner(privKey))
 			}
 		}
 	}
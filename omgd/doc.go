@@ -76,6 +76,9 @@ Application Options:
       --testnet             Use the test network
       --regtest             Use the regression test network
       --simnet              Use the simulation test network
+      --committeesize=      Sign blocks by committees of the specified size,
+                            from 1 to 64, from the genesis block on -- Only
+                            allowed with --regtest or --simnet
       --addcheckpoint=      Add a custom checkpoint.  Format: '<height>:<hash>'
       --nocheckpoints       Disable built-in checkpoints.  Don't do this unless
                             you know what you're doing.
@@ -26,6 +26,7 @@ import (
 	"github.com/omegasuite/omega/consensus"
 	"github.com/omegasuite/omega/minerchain"
 	"github.com/omegasuite/omega/ovm"
+	"github.com/omegasuite/omega/signer"
 	"github.com/omegasuite/omega/token"
 )
 
@@ -75,6 +76,7 @@ var (
 	ovmLog = backendLog.Logger("OVM", 0xFFFF)
 	consensusLog = backendLog.Logger("CNSS", 0xFFFF)
 	minerLog = backendLog.Logger("MNER", 0xFFFF)
+	sgnrLog = backendLog.Logger("SGNR", 0xFFFF)
 	tokenLog = backendLog.Logger("TKN", 0xFFFF)
 )
 
@@ -107,6 +109,7 @@ func init() {
 //	ovm.UseLogger(btclog.Disabled)
 	consensus.UseLogger(consensusLog)
 	minerchain.UseLogger(minerLog)
+	signer.UseLogger(sgnrLog)
 //	minerchain.UseLogger(btclog.Disabled)
 	token.UseLogger(btclog.Disabled)	// tokenLog)
 
@@ -147,6 +150,7 @@ var subsystemLoggers = map[string]btclog.Logger{
 	"SYNC": syncLog,
 	"TXMP": txmpLog,
 	"CNSS": consensusLog,
+	"SGNR": sgnrLog,
 }
 
 // initLogRotator initializes the logging rotater to write logs to logFile and
@@ -6,11 +6,15 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"io"
+	"strings"
 	"github.com/omegasuite/btcd/btcjson"
 	"github.com/omegasuite/btcutil"
-	"github.com/omegasuite/omega/consensus"
 	"io/ioutil"
 //	"strconv"
 //	"strings"
@@ -31,6 +35,8 @@ import (
 	"github.com/omegasuite/btcd/blockchain/indexers"
 	"github.com/omegasuite/btcd/database"
 	"github.com/omegasuite/btcd/limits"
+	"github.com/omegasuite/omega/consensus"
+	"github.com/omegasuite/omega/signer"
 )
 
 const (
@@ -39,6 +45,10 @@ const (
 	// database name.
 	blockDbNamePrefix = "blocks"
 	minerDbNamePrefix = "miners"
+
+	// signJournalName is the name of the file of the journal of blocks
+	// signed by the committee keys of the node.
+	signJournalName = "signjournal"
 )
 
 var (
@@ -175,10 +185,74 @@ func btcdMain(serverChan chan<- *server) error {
 
 		return nil
 	}
+	if cfg.DropTokenIndex {
+		if err := indexers.DropTokenIndex(db, interrupt); err != nil {
+			btcdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
+	if cfg.DropPolygonIndex {
+		if err := indexers.DropPolygonIndex(db, interrupt); err != nil {
+			btcdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
+	if cfg.DropContractEventIndex {
+		if err := indexers.DropContractEventIndex(db, interrupt); err != nil {
+			btcdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
+
+	// Open the journal of blocks signed by the committee keys. It must stay
+	// with the keys, so export it and exit, or import the records exported
+	// on another node, if requested.
+	if err := os.MkdirAll(cfg.DataDir, 0700); err != nil {
+		btcdLog.Errorf("%v", err)
+		return err
+	}
+	journal, err := consensus.OpenJournal(filepath.Join(cfg.DataDir, signJournalName))
+	if err != nil {
+		btcdLog.Errorf("Unable to open signing journal: %v", err)
+		return err
+	}
+	defer journal.Close()
+
+	if cfg.ExportSignJournal != "" {
+		if err := exportSignJournal(journal, cfg.ExportSignJournal); err != nil {
+			btcdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
+	if cfg.ImportSignJournal != "" {
+		if err := importSignJournal(journal, cfg.ImportSignJournal); err != nil {
+			btcdLog.Errorf("%v", err)
+			return err
+		}
+	}
 
 	activeNetParams.Params.MinRelayTxFee = int64(cfg.minRelayTxFee)
 
-	if cfg.Generate && len(cfg.privateKeys) == 0 {
+	// Sign with the keys of the keystore and of the signing daemon, if any,
+	// so that they need not be in the config.
+	signerClient, err := loadSigners()
+	if err != nil {
+		btcdLog.Errorf("%v", err)
+		return err
+	}
+	if signerClient != nil {
+		defer signerClient.Close()
+	}
+
+	if cfg.Generate && len(cfg.signers) == 0 {
 		// read from stdin. for security.
 		// expect user to do something like: echo privkey | btcd
 		fmt.Printf("Private Key in GIF ... ")
@@ -202,7 +276,7 @@ func btcdMain(serverChan chan<- *server) error {
 					if addr.IsForNet(activeNetParams.Params) {
 						cfg.miningAddrs = append(cfg.miningAddrs, addr)
 						cfg.signAddress = append(cfg.signAddress, addr)
-						cfg.privateKeys = append(cfg.privateKeys, privKey)
+						cfg.signers = append(cfg.signers, signer.NewKeySigner(privKey))
 					}
 				}
 			}
@@ -219,7 +293,7 @@ func btcdMain(serverChan chan<- *server) error {
 
 	// Create server and start it.
 	server, err := newServer(cfg.Listeners, db, minerdb, activeNetParams.Params,
-		interrupt)
+		journal, interrupt)
 	if err != nil {
 		// TODO: this logging could do with some beautifying.
 		btcdLog.Errorf("Unable to start server on %v: %v",
@@ -227,9 +301,9 @@ func btcdMain(serverChan chan<- *server) error {
 		return err
 	}
 	defer func() {
-		if len(cfg.privateKeys) != 0 && cfg.Generate {
+		if server.consensus != nil {
 			btcdLog.Infof("Gracefully shutting down consensus server...")
-			consensus.Shutdown()
+			server.consensus.Shutdown()
 			btcdLog.Infof("consensus Server shutdown complete")
 		}
 
@@ -241,8 +315,8 @@ func btcdMain(serverChan chan<- *server) error {
 		btcdLog.Infof("Server shutdown complete")
 	}()
 
-	if len(cfg.privateKeys) != 0 && cfg.Generate {
-		go consensus.Consensus(server, cfg.DataDir, cfg.signAddress, activeNetParams.Params)
+	if server.consensus != nil {
+		go server.consensus.Run()
 		for _,sa := range cfg.signAddress {
 			btcdLog.Infof("Address of miner %s", sa.String())
 		}
@@ -510,6 +584,109 @@ func loadMinerDB() (database.DB, error) {
 	return db, nil
 }
 
+// loadSigners adds the signers of the keystore and of the signing daemon given
+// in the config to those of the private keys in it, and loads the RSA key of
+// invitations. It returns the connection to the signing daemon, if any.
+func loadSigners() (*signer.Client, error) {
+	var signers []signer.Signer
+	var client *signer.Client
+
+	if cfg.Keystore != "" {
+		fmt.Printf("Passphrase of keystore %s: ", cfg.Keystore)
+		passphrase, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && (err != io.EOF || passphrase == "") {
+			return nil, fmt.Errorf("unable to read keystore passphrase: %v", err)
+		}
+		passphrase = strings.TrimRight(passphrase, "\r\n")
+
+		ks, err := signer.OpenKeystore(cfg.Keystore, []byte(passphrase))
+		if err != nil {
+			return nil, fmt.Errorf("unable to open keystore %s: %v", cfg.Keystore, err)
+		}
+		signers = append(signers, ks.Signers()...)
+		cfg.invitationKey = ks.Decrypter()
+	}
+
+	if cfg.Signer != "" {
+		var err error
+		client, err = signer.Dial(cfg.Signer)
+		if err != nil {
+			return nil, fmt.Errorf("unable to connect to signing daemon %s: %v", cfg.Signer, err)
+		}
+		signers = append(signers, client.Signers()...)
+		if cfg.invitationKey == nil {
+			cfg.invitationKey = client.Decrypter()
+		}
+	}
+
+	if cfg.RsaPrivateKey != "" {
+		b, err := ioutil.ReadFile(cfg.RsaPrivateKey)
+		if err != nil {
+			return client, err
+		}
+		block, _ := pem.Decode(b)
+		if block == nil {
+			return client, fmt.Errorf("%s is not a PEM file", cfg.RsaPrivateKey)
+		}
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return client, err
+		}
+		cfg.invitationKey = signer.NewRSADecrypter(key)
+	}
+
+	for _, s := range signers {
+		pkaddr, err := btcutil.NewAddressPubKey(s.PubKey().SerializeCompressed(), activeNetParams.Params)
+		if err != nil {
+			return client, err
+		}
+		addr := pkaddr.AddressPubKeyHash()
+		cfg.miningAddrs = append(cfg.miningAddrs, addr)
+		cfg.signAddress = append(cfg.signAddress, addr)
+		cfg.signers = append(cfg.signers, s)
+	}
+
+	return client, nil
+}
+
+// exportSignJournal writes the records of the signing journal to the file at
+// path.
+func exportSignJournal(journal *consensus.Journal, path string) error {
+	fp, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if err = journal.Export(fp); err == nil {
+		err = fp.Sync()
+	}
+	if cerr := fp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	btcdLog.Infof("Signing journal exported to %s", path)
+	return nil
+}
+
+// importSignJournal adds the records in the file at path to the signing
+// journal. Nothing is imported if they conflict with the journal.
+func importSignJournal(journal *consensus.Journal, path string) error {
+	fp, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+
+	if err := journal.Import(fp); err != nil {
+		return fmt.Errorf("unable to import signing journal %s: %v", path, err)
+	}
+
+	btcdLog.Infof("Signing journal imported from %s", path)
+	return nil
+}
+
 func main() {
 	// Use all processor cores.
 	runtime.GOMAXPROCS(runtime.NumCPU())
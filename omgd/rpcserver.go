@@ -36,8 +36,10 @@ import (
 	"github.com/omegasuite/btcd/wire"
 	"github.com/omegasuite/btcd/wire/common"
 	"github.com/omegasuite/btcutil"
+	"github.com/omegasuite/omega/geo"
 	"github.com/omegasuite/omega/minerchain"
 	"github.com/omegasuite/omega/ovm"
+	"github.com/omegasuite/omega/ovm/abi"
 	"github.com/omegasuite/omega/token"
 	"github.com/omegasuite/omega/viewpoint"
 	"github.com/omegasuite/websocket"
@@ -48,6 +50,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -162,11 +165,22 @@ var rpcHandlersBeforeInit = map[string]commandHandler{
 	"getminerblockcount":    handleGetMinerBlockCount,	// New
 	"getminerblockhash":     handleGetMinerBlockHash,	// New
 	"getblocktxhashes":      handleGetBlockTxHases,	// New
-//	"searchborder":   		 handleSearchBorder,	// New
+	"searchborder":   		 handleSearchBorder,	// New
+	"polygonsat":            handlePolygonsAt,
+	"polygonsinbox":         handlePolygonsInBox,
+	"exportpolygon":         handleExportPolygon,
+	"buildpolygontx":        handleBuildPolygonTx,
+	"polygoninfo":           handlePolygonInfo,
+	"splitpolygon":          handleSplitPolygon,
+	"mergepolygons":         handleMergePolygons,
 	"contractcall":   		 handleContractCall,	// New
 	"trycontract":   		 handleTryContract,	// New
+	"getcontractstate":      handleGetContractState,
+	"getcontractevents":     handleGetContractEvents,
 	"miningpolicy":   		 handleMiningPolicy,	// New. miner specific policy
 	"tokenaddress":   		 handleTokenAddress,	// New
+	"listtokens":            handleListTokens,
+	"gettokeninfo":          handleGetTokenInfo,
 
 //	"getblocktemplate":      handleGetBlockTemplate,
 	"getcfilter":            handleGetCFilter,
@@ -189,7 +203,10 @@ var rpcHandlersBeforeInit = map[string]commandHandler{
 	"getrawtransaction":     handleGetRawTransaction,
 	"gettxout":              handleGetTxOut,
 	"listutxos":             handleListUtxos,
+	"getbalances":           handleGetBalances,
+	"getgeometrypruneinfo":  handleGetGeometryPruneInfo,
 	"getdefine":             handleGetDefine,
+	"getrighttree":          handleGetRightTree,
 	"help":                  handleHelp,
 	"node":                  handleNode,
 	"ping":                  handlePing,
@@ -300,6 +317,15 @@ var rpcLimited = map[string]struct{}{
 	"getblockhash":          {},
 	"getblocktxhashes":      {},
 	"searchborder":			 {},
+	"polygonsat":            {},
+	"listtokens":            {},
+	"gettokeninfo":          {},
+	"polygonsinbox":         {},
+	"exportpolygon":         {},
+	"buildpolygontx":        {},
+	"polygoninfo":           {},
+	"splitpolygon":          {},
+	"mergepolygons":         {},
 	"getblockheader":        {},
 	"getminerblockcount":    {},
 	"getminerblockhash":     {},
@@ -316,9 +342,14 @@ var rpcLimited = map[string]struct{}{
 	"getrawtransaction":     {},
 	"gettxout":              {},
 	"listutxos":             {},
+	"getbalances":           {},
+	"getgeometrypruneinfo":  {},
 	"getdefine":             {},
+	"getrighttree":          {},
 	"contractcall":          {},
 	"trycontract":   		 {},
+	"getcontractstate":      {},
+	"getcontractevents":     {},
 	"searchrawtransactions": {},
 	"sendrawtransaction":    {},
 	"confirmations":		 {},
@@ -558,153 +589,6 @@ func handleGenMultiSigAddr(s *rpcServer, cmd interface{}, closeChan <-chan struc
 	return reply, nil
 }
 
-var debugchan chan []byte
-var pendattach bool
-
-func handleVMDebug(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
-	if s.cfg.ChainParams.Net != common.TestNet {
-		return nil, fmt.Errorf("Vm debugging is only enabled in testNet")
-	}
-
-	c := cmd.(*btcjson.VMDebugCmd)
-
-	gotype := map[string]ovm.DebugCommand{"go":ovm.Gorun, "step":ovm.Stepping, "Up":ovm.GoUp, "clearbreakpoint":ovm.Unbreak, "breakpoint":ovm.Breakpoint}
-
-	switch c.DbgCmd {
-	case "attach":
-		if debugchan == nil {
-			debugchan = make(chan []byte, 50)
-		}
-
-		ovm.DebugSetup(true, debugchan)
-		pendattach = true
-		d := <-debugchan
-		pendattach = false
-
-		for true {
-			select {
-			case d = <- debugchan:
-
-			default:
-				return "C" + string(d[1]) + hex.EncodeToString(d[2:]), nil
-			}
-		}
-
-	case "detach":
-		if pendattach {
-			debugchan <- []byte{0,0,0}
-		}
-		fallthrough
-
-	case "stop":
-		ovm.Control <- &ovm.DebugCmd{
-			Cmd:   ovm.Terminate,
-			Data:  nil,
-			Reply: nil,
-		}
-
-		ovm.DebugSetup(false, nil)
-		debugchan = nil
-
-		return "Terminated", nil
-	}
-
-	reply := make(chan []byte)
-
-	switch c.DbgCmd {
-	case "go", "step", "up":
-		ovm.Control <- &ovm.DebugCmd {
-			Cmd: gotype[c.DbgCmd],
-			Data: nil,
-			Reply: reply,
-		}
-
-		d := <- reply
-
-		if d[0] == byte(ovm.Terminated) {
-			rdata := &btcjson.DebugReply {
-				Result:	"Terminated",
-			}
-			return rdata, nil
-		} else if d[0] == byte(ovm.Breaked) {
-			v := common.LittleEndian.Uint32(d[1:])
-			rdata := &btcjson.DebugReply {
-				Result:	fmt.Sprintf("Break at inst %d", v),
-				Line: v,
-			}
-			return rdata, nil
-		} else {
-			rdata := &btcjson.DebugReply {
-				Result:	"Unexpected result: " + hex.EncodeToString(d),
-			}
-			return rdata, nil
-		}
-
-	case "breakpoint", "clearbreakpoint":
-		var b [4]byte
-
-		common.LittleEndian.PutUint32(b[:], uint32(*c.Value))
-
-		ovm.Control <- &ovm.DebugCmd {
-			Cmd: gotype[c.DbgCmd],
-			Data: b[:],
-			Reply: nil,
-		}
-
-		return "Done", nil
-
-	case "getdata":
-		buf := make([]byte, len(*c.Param) + 5)
-		copy(buf, []byte(*c.Param))
-		buf[len(*c.Param)] = ','
-		common.LittleEndian.PutUint32(buf[len(buf) - 4:], uint32(*c.Value))
-		ovm.Control <- &ovm.DebugCmd {
-			Cmd: ovm.Getdata,
-			Data: buf,
-			Reply: reply,
-		}
-
-		d := <- reply
-
-		return hex.EncodeToString(d), nil
-
-	case "getstack":
-		ovm.Control <- &ovm.DebugCmd {
-			Cmd: ovm.Getstack,
-			Data: nil,
-			Reply: reply,
-		}
-
-		d := <- reply
-
-		stack := make([]int, len(d) / 4)
-		for i := 0; i < len(d); i += 4 {
-			v := common.LittleEndian.Uint32(d[i:])
-			stack[i / 4] = int(v)
-		}
-
-		return stack, nil
-
-	case "evaluate":
-		var buf [12]byte
-		hlen, _ := hex.Decode(buf[:], []byte(*c.Param))
-		if hlen == 0 {
-			return nil, nil
-		}
-		common.LittleEndian.PutUint32(buf[8:], uint32(*c.Value))
-		ovm.Control <- &ovm.DebugCmd {
-			Cmd: ovm.Evaluate,
-			Data: buf[:],
-			Reply: reply,
-		}
-
-		d := <- reply
-
-		return hex.EncodeToString(d), nil
-	}
-	return nil, nil
-}
-
 // handleNode handles node commands.
 func handleNode(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*btcjson.NodeCmd)
@@ -847,6 +731,88 @@ func handleTokenAddress(s *rpcServer, cmd interface{}, closeChan <-chan struct{}
 	return address.EncodeAddress(), nil
 }
 
+// tokenIndexRequired returns an error if the token registry index is not
+// enabled.
+func tokenIndexRequired(s *rpcServer) error {
+	if s.cfg.TokenIndex == nil {
+		return &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "Token registry index must be enabled (--tokenindex)",
+		}
+	}
+	return nil
+}
+
+// tokenInfoResult converts a token registry entry to its RPC representation.
+func tokenInfoResult(s *rpcServer, info *indexers.TokenInfo) *btcjson.TokenInfoResult {
+	result := &btcjson.TokenInfoResult{
+		TokenType: info.TokenType,
+		Minted:    info.Minted,
+		Supply:    info.Supply,
+		Holders:   info.Holders,
+		Height:    info.Height,
+	}
+	if address, err := btcutil.NewAddressContract(info.Issuer[:], s.cfg.ChainParams); err == nil {
+		result.Issuer = address.EncodeAddress()
+	}
+	return result
+}
+
+// handleListTokens handles listtokens commands.
+func handleListTokens(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.ListTokensCmd)
+
+	if err := tokenIndexRequired(s); err != nil {
+		return nil, err
+	}
+
+	skip, count := 0, 100
+	if c.Skip != nil {
+		skip = *c.Skip
+	}
+	if c.Count != nil {
+		count = *c.Count
+	}
+	if skip < 0 || count <= 0 {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Skip must not be negative and count must be positive",
+		}
+	}
+
+	infos, err := s.cfg.TokenIndex.Tokens(skip, count)
+	if err != nil {
+		return nil, internalRPCError(err.Error(), "Failed to list tokens")
+	}
+
+	result := make([]btcjson.TokenInfoResult, len(infos))
+	for i := range infos {
+		result[i] = *tokenInfoResult(s, &infos[i])
+	}
+	return result, nil
+}
+
+// handleGetTokenInfo handles gettokeninfo commands.
+func handleGetTokenInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetTokenInfoCmd)
+
+	if err := tokenIndexRequired(s); err != nil {
+		return nil, err
+	}
+
+	info, err := s.cfg.TokenIndex.Token(c.TokenType)
+	if err != nil {
+		return nil, internalRPCError(err.Error(), "Failed to fetch token")
+	}
+	if info == nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Tokentype 0x%x has not been minted by a contract", c.TokenType),
+		}
+	}
+	return tokenInfoResult(s, info), nil
+}
+
 // handleTryContract handles TryContract commands.
 func handleTryContract(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*btcjson.TryContractCmd)
@@ -868,8 +834,37 @@ func handleTryContract(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 		}
 	}
 
+	var method *abi.Method
+	if c.Method != nil {
+		if method, err = abi.NewMethod(*c.Method); err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParameter,
+				Message: err.Error(),
+			}
+		}
+		if c.Args != nil {
+			input, err := method.Pack(*c.Args...)
+			if err == nil {
+				err = setContractCallData(&msgTx, input)
+			}
+			if err != nil {
+				return nil, &btcjson.RPCError{
+					Code:    btcjson.ErrRPCInvalidParameter,
+					Message: err.Error(),
+				}
+			}
+		}
+	}
+
+	tx := btcutil.NewTx(&msgTx)
+
+	// the utxos spent are needed to find the monitors to call
+	views, err := s.cfg.Chain.FetchUtxoView(tx)
+	if err != nil {
+		return nil, internalRPCError(err.Error(), "Failed to fetch utxos spent")
+	}
+
 	vm := ovm.NewOVM(s.cfg.ChainParams)
-	views := s.cfg.Chain.NewViewPointSet()
 	vm.SetViewPoint(views)
 
 	best := s.cfg.Chain.BestSnapshot()
@@ -885,11 +880,15 @@ func handleTryContract(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 		vm.StepLimit = mb.Data.GetContractExec()
 	}
 
-	tx := btcutil.NewTx(&msgTx)
+	var tracer *ovm.TraceLogger
+	if c.Trace != nil && *c.Trace {
+		tracer = ovm.NewTraceLogger()
+		vm.Tracer = tracer
+	}
 
-	result, err := vm.TryContract(tx, best.Height)
-	if err != nil {
-		return nil, err
+	result, execErr := vm.TryContract(tx, best.Height)
+	if execErr != nil && tracer == nil {
+		return nil, execErr
 	}
 
 	// Return the serialized and hex-encoded transaction.  Note that this
@@ -906,6 +905,232 @@ func handleTryContract(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 		Tx: mtxHex,
 	}
 
+	if method != nil && execErr == nil {
+		outputs, err := method.Unpack(result)
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCMisc,
+				Message: "Failed to decode return data: " + err.Error(),
+			}
+		}
+		reply.Outputs = abi.JSONValues(outputs)
+	}
+
+	if execErr == nil {
+		for _, m := range vm.CallMonitors(tx, 0) {
+			r := btcjson.TryMonitorResult{
+				TxIn:     m.TxIn,
+				Right:    m.Right.String(),
+				Monitor:  m.Monitor.String(),
+				Contract: hex.EncodeToString(m.Contract[:]),
+				Method:   hex.EncodeToString(m.Method[:]),
+				Approved: m.Approved,
+				Result:   hex.EncodeToString(m.Return),
+			}
+			if m.Err != nil {
+				r.Error = m.Err.Error()
+			}
+			reply.Monitors = append(reply.Monitors, r)
+		}
+	}
+
+	if tracer != nil {
+		if execErr != nil {
+			reply.Error = execErr.Error()
+		}
+		reply.Steps = tracer.Steps()
+		reply.Trace, reply.HotSpots = marshalContractTrace(tracer)
+	}
+
+	return reply, nil
+}
+
+// setContractCallData replaces the call data of the first contract call in tx,
+// which is the one trycontract runs, with input.
+func setContractCallData(tx *wire.MsgTx, input []byte) error {
+	for _, txOut := range tx.TxOut {
+		if len(txOut.PkScript) < 21 || txOut.PkScript[0]&0x88 != 0x88 {
+			continue
+		}
+		txOut.PkScript = append(txOut.PkScript[:21:21], input...)
+		return nil
+	}
+	return errors.New("transaction does not call a contract")
+}
+
+// marshalContractTrace converts the steps and hot spots recorded by tracer to
+// their JSON-RPC results.
+func marshalContractTrace(tracer *ovm.TraceLogger) ([]btcjson.TraceStepResult, []btcjson.TraceHotSpotResult) {
+	entries := tracer.Entries()
+	steps := make([]btcjson.TraceStepResult, len(entries))
+	for i, e := range entries {
+		steps[i] = btcjson.TraceStepResult{
+			Contract:  hex.EncodeToString(e.Contract[:]),
+			Depth:     e.Depth,
+			Frame:     e.Frame,
+			PC:        e.PC,
+			Op:        e.Op.String(),
+			Operands:  e.Operands,
+			StepsLeft: e.StepsLeft,
+		}
+		for _, a := range e.State {
+			steps[i].State = append(steps[i].State, btcjson.TraceStateResult{
+				Op:    a.Op.String(),
+				Key:   hex.EncodeToString(a.Key),
+				Value: hex.EncodeToString(a.Value),
+			})
+		}
+	}
+
+	hotSpots := tracer.HotSpots()
+	spots := make([]btcjson.TraceHotSpotResult, len(hotSpots))
+	for i, h := range hotSpots {
+		spots[i] = btcjson.TraceHotSpotResult{
+			Contract: hex.EncodeToString(h.Contract[:]),
+			PC:       h.PC,
+			Op:       h.Op.String(),
+			Count:    h.Count,
+		}
+	}
+
+	return steps, spots
+}
+
+// handleGetContractState implements the getcontractstate command.
+func handleGetContractState(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetContractStateCmd)
+
+	contract, err := ovm.AddressFromString(c.Contract)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Contract)
+	}
+
+	var keys [][]byte
+	if c.Keys != nil {
+		keys = make([][]byte, 0, len(*c.Keys))
+		for _, k := range *c.Keys {
+			key, err := hex.DecodeString(k)
+			if err != nil {
+				return nil, rpcDecodeHexError(k)
+			}
+			keys = append(keys, key)
+		}
+	}
+
+	best := s.cfg.Chain.BestSnapshot()
+	height := best.Height
+	switch {
+	case c.Height != nil:
+		height = *c.Height
+
+	case c.BlockHash != nil:
+		hash, err := chainhash.NewHashFromStr(*c.BlockHash)
+		if err != nil {
+			return nil, rpcDecodeHexError(*c.BlockHash)
+		}
+		if height, err = s.cfg.Chain.BlockHeightByHash(hash); err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCBlockNotFound,
+				Message: "Block not found in the main chain",
+			}
+		}
+	}
+	if height < 0 || height > best.Height {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCOutOfRange,
+			Message: fmt.Sprintf("Block number %d out of range", height),
+		}
+	}
+
+	hash, err := s.cfg.Chain.BlockHashByHeight(height)
+	if err != nil {
+		context := "Failed to fetch block hash"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	var storage map[string][]byte
+	err = s.cfg.DB.View(func(dbTx database.Tx) error {
+		var err error
+		storage, err = ovm.StorageAt(dbTx, contract, keys, uint64(height))
+		return err
+	})
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: err.Error(),
+		}
+	}
+
+	reply := &btcjson.ContractStateResult{
+		Height:   height,
+		Hash:     hash.String(),
+		Contract: hex.EncodeToString(contract[:]),
+		Storage:  make(map[string]string, len(storage)),
+	}
+	for k, v := range storage {
+		reply.Storage[hex.EncodeToString([]byte(k))] = hex.EncodeToString(v)
+	}
+
+	return reply, nil
+}
+
+// contractEventResult returns the JSON-RPC representation of event, the index
+// event of the transaction at txIndex in the block at height.
+func contractEventResult(e *ovm.Event, height int32, txIndex, index uint32) btcjson.ContractEventResult {
+	return btcjson.ContractEventResult{
+		TxID:     e.TxHash.String(),
+		Height:   height,
+		TxIndex:  txIndex,
+		Index:    index,
+		Contract: hex.EncodeToString(e.Contract[:]),
+		Topic:    e.Topic,
+		Data:     hex.EncodeToString(e.Data),
+	}
+}
+
+// handleGetContractEvents implements the getcontractevents command.
+func handleGetContractEvents(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	if s.cfg.ContractEventIndex == nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "Contract event index must be enabled (--contracteventindex)",
+		}
+	}
+
+	c := cmd.(*btcjson.GetContractEventsCmd)
+
+	contract, err := ovm.AddressFromString(c.Contract)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Contract)
+	}
+
+	var fromHeight int32
+	if c.FromHeight != nil {
+		fromHeight = *c.FromHeight
+	}
+	count := 100
+	if c.Count != nil {
+		count = *c.Count
+	}
+	if count <= 0 {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "count must be positive",
+		}
+	}
+
+	events, err := s.cfg.ContractEventIndex.Events(contract, c.Topic, fromHeight, count)
+	if err != nil {
+		context := "Failed to fetch contract events"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	reply := make([]btcjson.ContractEventResult, len(events))
+	for i := range events {
+		e := &events[i]
+		reply[i] = contractEventResult(&e.Event, e.Height, e.TxIndex, e.Index)
+	}
+
 	return reply, nil
 }
 
@@ -1578,189 +1803,716 @@ func handleGetAddedNodeInfo(s *rpcServer, cmd interface{}, closeChan <-chan stru
 		return results, nil
 	}
 
-	// With the dns flag, the result is an array of JSON objects which
-	// include the result of DNS lookups for each peer.
-	results := make([]*btcjson.GetAddedNodeInfoResult, 0, len(peers))
-	for _, rpcPeer := range peers {
-		// Set the "address" of the peer which could be an ip address
-		// or a domain name.
-		peer := rpcPeer.ToPeer()
-		var result btcjson.GetAddedNodeInfoResult
-		result.AddedNode = peer.Addr()
-		result.Connected = btcjson.Bool(peer.Connected())
-
-		// Split the address into host and port portions so we can do
-		// a DNS lookup against the host.  When no port is specified in
-		// the address, just use the address as the host.
-		host, _, err := net.SplitHostPort(peer.Addr())
-		if err != nil {
-			host = peer.Addr()
+	// With the dns flag, the result is an array of JSON objects which
+	// include the result of DNS lookups for each peer.
+	results := make([]*btcjson.GetAddedNodeInfoResult, 0, len(peers))
+	for _, rpcPeer := range peers {
+		// Set the "address" of the peer which could be an ip address
+		// or a domain name.
+		peer := rpcPeer.ToPeer()
+		var result btcjson.GetAddedNodeInfoResult
+		result.AddedNode = peer.Addr()
+		result.Connected = btcjson.Bool(peer.Connected())
+
+		// Split the address into host and port portions so we can do
+		// a DNS lookup against the host.  When no port is specified in
+		// the address, just use the address as the host.
+		host, _, err := net.SplitHostPort(peer.Addr())
+		if err != nil {
+			host = peer.Addr()
+		}
+
+		var ipList []string
+		switch {
+		case net.ParseIP(host) != nil, strings.HasSuffix(host, ".onion"):
+			ipList = make([]string, 1)
+			ipList[0] = host
+		default:
+			// Do a DNS lookup for the address.  If the lookup fails, just
+			// use the host.
+			ips, err := btcdLookup(host)
+			if err != nil {
+				ipList = make([]string, 1)
+				ipList[0] = host
+				break
+			}
+			ipList = make([]string, 0, len(ips))
+			for _, ip := range ips {
+				ipList = append(ipList, ip.String())
+			}
+		}
+
+		// Add the addresses and connection info to the result.
+		addrs := make([]btcjson.GetAddedNodeInfoResultAddr, 0, len(ipList))
+		for _, ip := range ipList {
+			var addr btcjson.GetAddedNodeInfoResultAddr
+			addr.Address = ip
+			addr.Connected = "false"
+			if ip == host && peer.Connected() {
+				addr.Connected = directionString(peer.Inbound())
+			}
+			addrs = append(addrs, addr)
+		}
+		result.Addresses = &addrs
+		results = append(results, &result)
+	}
+	return results, nil
+}
+
+// handleGetBestBlock implements the getbestblock command.
+func handleGetBestBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	// All other "get block" commands give either the height, the
+	// hash, or both but require the block SHA.  This gets both for
+	// the best block.
+	best := s.cfg.Chain.BestSnapshot()
+	mbest := s.cfg.Chain.Miners.BestSnapshot()
+	result := &btcjson.GetBestBlockResult{
+		Hash:   best.Hash.String(),
+		Height: best.Height,
+		MinerHash:   mbest.Hash.String(),
+		MinerHeight: mbest.Height,
+	}
+	return result, nil
+}
+
+// handleGetBestBlockHash implements the getbestblockhash command.
+func handleGetBestBlockHash(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	best := s.cfg.Chain.BestSnapshot()
+	return best.Hash.String(), nil
+}
+
+// handleGetBestMinerBlockHash implements the getbestminerblockhash command.
+func handleGetBestMinerBlockHash(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	best := s.cfg.Chain.Miners.BestSnapshot()
+	return best.Hash.String(), nil
+}
+
+// getDifficultyRatio returns the proof-of-work difficulty as a multiple of the
+// minimum difficulty using the passed bits field from the header of a block.
+func getDifficultyRatio(bits uint32, params *chaincfg.Params) float64 {
+	// The minimum difficulty is the max possible proof-of-work limit bits
+	// converted back to a number.  Note this is not the same as the proof of
+	// work limit directly because the block difficulty is encoded in a block
+	// with the compact form which loses precision.
+	max := blockchain.CompactToBig(params.PowLimitBits)
+
+	if bits == 0 {
+		bits = params.PowLimitBits
+	}
+	target := blockchain.CompactToBig(bits)
+
+	difficulty := new(big.Rat).SetFrac(max, target)
+	outString := difficulty.FloatString(8)
+	diff, err := strconv.ParseFloat(outString, 64)
+	if err != nil {
+		rpcsLog.Errorf("Cannot get difficulty: %v", err)
+		return 0
+	}
+	return diff
+}
+
+func handleContractCall(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.ContractCallCmd)
+
+	vm := ovm.NewOVM(s.cfg.ChainParams)
+	views := s.cfg.Chain.NewViewPointSet()
+	vm.SetViewPoint(views)
+
+	best := s.cfg.Chain.BestSnapshot()
+	vm.BlockTime = func() uint32 { return uint32(best.MedianTime.Unix()) }
+	vm.BlockNumber = func() uint64 { return uint64(best.Height) }
+	vm.BlockVersion = func() uint32 { return wire.Version4 }
+
+	mb := s.cfg.Chain.Miners.NodeByHeight(int32(best.LastRotation))
+	if mb == nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "Chain stalled.",
+		}
+	}
+	if mb.Data.GetContractExec() > vm.StepLimit {
+		vm.StepLimit = mb.Data.GetContractExec()
+	}
+
+	contract, err := ovm.AddressFromString(c.Contract)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.ContainsRune(c.Input, '(') {
+		m, err := abi.NewMethod(c.Input)
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParameter,
+				Message: err.Error(),
+			}
+		}
+		var args []interface{}
+		if c.Args != nil {
+			args = *c.Args
+		}
+		input, err := m.Pack(args...)
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParameter,
+				Message: err.Error(),
+			}
+		}
+		ret, err := vm.ContractCall(contract, input)
+		if err != nil {
+			return nil, err
+		}
+		outputs, err := m.Unpack(ret)
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCMisc,
+				Message: "Failed to decode return data: " + err.Error(),
+			}
+		}
+		return &btcjson.ContractCallResult{
+			Result:  hex.EncodeToString(ret),
+			Outputs: abi.JSONValues(outputs),
+		}, nil
+	}
+
+	var srcBytes []byte
+	if len(c.Input)%2 == 0 {
+		srcBytes = []byte(c.Input)
+	} else {
+		srcBytes = make([]byte, 1+len(c.Input))
+		srcBytes[0] = '0'
+		copy(srcBytes[1:], c.Input)
+	}
+
+	input := make([]byte, len(srcBytes) / 2)
+	if _, err := hex.Decode(input, srcBytes); err != nil {
+		return nil, err
+	}
+
+	ret, err := vm.ContractCall(contract, input)
+	return hex.EncodeToString(ret), err
+}
+
+// wgs84Box validates a box given in WGS84 degrees and returns it in the fixed
+// point coordinates of vertices.
+func wgs84Box(west, east, south, north float64) (*viewpoint.BoundingBox, error) {
+	if west < -180 || east > 180 || south < -90 || north > 90 ||
+		west > east || south > north {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Invalid box",
+		}
+	}
+
+	var box viewpoint.BoundingBox
+	box.Reset()
+	box.Expand(int32(south*token.CoordPrecision), int32(west*token.CoordPrecision))
+	box.Expand(int32(north*token.CoordPrecision), int32(east*token.CoordPrecision))
+	return &box, nil
+}
+
+// polygonIndexRequired returns an error if the polygon index is not enabled.
+func polygonIndexRequired(s *rpcServer) error {
+	if s.cfg.PolygonIndex == nil {
+		return &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "Polygon index must be enabled (--polygonindex)",
+		}
+	}
+	return nil
+}
+
+// polygonResult returns the result of polygon p with the unspent outputs of
+// its tokens.
+func polygonResult(s *rpcServer, p *indexers.IndexedPolygon) (*btcjson.PolygonResult, error) {
+	ops, err := s.cfg.PolygonIndex.Utxos(&p.Hash)
+	if err != nil {
+		context := "Failed to fetch polygon outputs"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	result := &btcjson.PolygonResult{
+		Polygon: p.Hash.String(),
+		West:    float64(p.Bound.West()) / token.CoordPrecision,
+		East:    float64(p.Bound.East()) / token.CoordPrecision,
+		South:   float64(p.Bound.South()) / token.CoordPrecision,
+		North:   float64(p.Bound.North()) / token.CoordPrecision,
+		Utxos:   make([]btcjson.PolygonUtxoResult, 0, len(ops)),
+	}
+
+	for _, op := range ops {
+		entry, err := s.cfg.Chain.FetchUtxoEntry(op)
+		if err != nil {
+			context := "Failed to fetch polygon output"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		if entry == nil || entry.IsSpent() {
+			continue
+		}
+
+		txo := entry.ToTxOut()
+		addrs, _, _ := indexers.ExtractPkScriptAddrs(txo.PkScript,
+			s.cfg.ChainParams)
+		addresses := make([]string, len(addrs))
+		for i, addr := range addrs {
+			addresses[i] = addr.EncodeAddress()
+		}
+
+		r := ""
+		if txo.Rights != nil {
+			r = txo.Rights.String()
+		}
+
+		result.Utxos = append(result.Utxos, btcjson.PolygonUtxoResult{
+			TxID:      op.Hash.String(),
+			Vout:      op.Index,
+			Height:    entry.BlockHeight(),
+			Rights:    r,
+			Addresses: addresses,
+		})
+	}
+
+	return result, nil
+}
+
+// handleSearchBorder implements the searchborder command.
+func handleSearchBorder(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	if err := polygonIndexRequired(s); err != nil {
+		return nil, err
+	}
+
+	c := cmd.(*btcjson.SearchBorderCmd)
+
+	box, err := wgs84Box(c.Left, c.Right, c.Bottom, c.Top)
+	if err != nil {
+		return nil, err
+	}
+
+	polygons, err := s.cfg.PolygonIndex.PolygonsInBox(box, 0)
+	if err != nil {
+		context := "Failed to search polygons"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	views := viewpoint.NewViewPointSet(s.cfg.DB)
+	found := make(map[chainhash.Hash]struct{})
+	result := make([]string, 0)
+
+	for i := range polygons {
+		borders, err := views.BordersInBox(&polygons[i].Hash, box, c.Lod)
+		if err != nil {
+			context := "Failed to search borders"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		for _, h := range borders {
+			if _, ok := found[h]; ok {
+				continue
+			}
+			found[h] = struct{}{}
+			result = append(result, h.String())
+			if len(result) >= 5000 {
+				return result, nil
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// handlePolygonsAt implements the polygonsat command.
+func handlePolygonsAt(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	if err := polygonIndexRequired(s); err != nil {
+		return nil, err
+	}
+
+	c := cmd.(*btcjson.PolygonsAtCmd)
+
+	box, err := wgs84Box(c.Lng, c.Lng, c.Lat, c.Lat)
+	if err != nil {
+		return nil, err
+	}
+	point := token.NewVertexDef(box.North(), box.East(), 0)
+
+	candidates, err := s.cfg.PolygonIndex.PolygonsAt(point.Lat(), point.Lng())
+	if err != nil {
+		context := "Failed to search polygons"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	// the index only knows the bounding boxes, check the point against the
+	// polygons themselves.
+	views := viewpoint.NewViewPointSet(s.cfg.DB)
+	result := make([]*btcjson.PolygonResult, 0)
+	for i := range candidates {
+		covers, err := views.PolygonCovers(&candidates[i].Hash, *point)
+		if err != nil {
+			context := "Failed to fetch polygon"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		if !covers {
+			continue
+		}
+		r, err := polygonResult(s, &candidates[i])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, r)
+	}
+
+	return result, nil
+}
+
+// handlePolygonsInBox implements the polygonsinbox command.
+func handlePolygonsInBox(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	if err := polygonIndexRequired(s); err != nil {
+		return nil, err
+	}
+
+	c := cmd.(*btcjson.PolygonsInBoxCmd)
+
+	count := 100
+	if c.Count != nil {
+		count = *c.Count
+	}
+	if count <= 0 {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "count must be positive",
+		}
+	}
+
+	box, err := wgs84Box(c.West, c.East, c.South, c.North)
+	if err != nil {
+		return nil, err
+	}
+
+	polygons, err := s.cfg.PolygonIndex.PolygonsInBox(box, count)
+	if err != nil {
+		context := "Failed to search polygons"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	result := make([]*btcjson.PolygonResult, 0, len(polygons))
+	for i := range polygons {
+		r, err := polygonResult(s, &polygons[i])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, r)
+	}
+
+	return result, nil
+}
+
+// parseGeometry parses a polygon given in format, either "geojson" or "wkt".
+func parseGeometry(geometry string, format *string) (geo.MultiPolygon, error) {
+	var mp geo.MultiPolygon
+	var err error
+	switch {
+	case format == nil || *format == "geojson":
+		mp, err = geo.ParseGeoJSON([]byte(geometry))
+	case *format == "wkt":
+		mp, err = geo.ParseWKT(geometry)
+	default:
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Unknown format " + *format,
+		}
+	}
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Invalid geometry: " + err.Error(),
+		}
+	}
+	return mp, nil
+}
+
+// definitionCmd returns the border or polygon definition def in the form
+// createrawtransaction takes it.
+func definitionCmd(def token.Definition) btcjson.Definition {
+	vertex := func(v token.VertexDef) map[string]interface{} {
+		return map[string]interface{}{"lat": v.Lat(), "lng": v.Lng(), "alt": v.Alt()}
+	}
+
+	d := btcjson.Definition{DefType: uint32(def.DefType())}
+	switch def := def.(type) {
+	case *token.BorderDef:
+		d.DefData = map[string]interface{}{
+			"father": def.Father.String(),
+			"begin":  vertex(def.Begin),
+			"end":    vertex(def.End),
+		}
+
+	case *token.PolygonDef:
+		loops := make([][]string, len(def.Loops))
+		for i, loop := range def.Loops {
+			loops[i] = make([]string, len(loop))
+			for j, b := range loop {
+				loops[i][j] = b.String()
+			}
+		}
+		d.DefData = map[string]interface{}{"loops": loops}
+	}
+	return d
+}
+
+// handleExportPolygon implements the exportpolygon command.
+func handleExportPolygon(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.ExportPolygonCmd)
+
+	polygon, err := chainhash.NewHashFromStr(c.Polygon)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Polygon)
+	}
+	if c.Format != nil && *c.Format != "geojson" && *c.Format != "wkt" {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Unknown format " + *c.Format,
+		}
+	}
+
+	mp, err := geo.Export(viewpoint.NewViewPointSet(s.cfg.DB), polygon)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Unable to export polygon: " + err.Error(),
+		}
+	}
+
+	if c.Format != nil && *c.Format == "wkt" {
+		return geo.FormatWKT(mp), nil
+	}
+	data, err := geo.MarshalGeoJSON(mp)
+	if err != nil {
+		context := "Failed to marshal polygon"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	return string(data), nil
+}
+
+// handleBuildPolygonTx implements the buildpolygontx command.
+func handleBuildPolygonTx(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.BuildPolygonTxCmd)
+
+	if _, err := chainhash.NewHashFromStr(c.Rights); err != nil {
+		return nil, rpcDecodeHexError(c.Rights)
+	}
+
+	mp, err := parseGeometry(c.Geometry, c.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	defs, polygon, err := geo.Definitions(mp, viewpoint.NewViewPointSet(s.cfg.DB))
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Invalid geometry: " + err.Error(),
 		}
+	}
 
-		var ipList []string
-		switch {
-		case net.ParseIP(host) != nil, strings.HasSuffix(host, ".onion"):
-			ipList = make([]string, 1)
-			ipList[0] = host
-		default:
-			// Do a DNS lookup for the address.  If the lookup fails, just
-			// use the host.
-			ips, err := btcdLookup(host)
-			if err != nil {
-				ipList = make([]string, 1)
-				ipList[0] = host
-				break
-			}
-			ipList = make([]string, 0, len(ips))
-			for _, ip := range ips {
-				ipList = append(ipList, ip.String())
-			}
-		}
+	result := &btcjson.BuildPolygonTxResult{
+		Polygon: polygon.Hash().String(),
+		Borders: make([]string, 0, len(defs)-1),
+	}
 
-		// Add the addresses and connection info to the result.
-		addrs := make([]btcjson.GetAddedNodeInfoResultAddr, 0, len(ipList))
-		for _, ip := range ipList {
-			var addr btcjson.GetAddedNodeInfoResultAddr
-			addr.Address = ip
-			addr.Connected = "false"
-			if ip == host && peer.Connected() {
-				addr.Connected = directionString(peer.Inbound())
-			}
-			addrs = append(addrs, addr)
+	// the transaction is assembled as createrawtransaction would with the
+	// definitions and a polygon token output.
+	create := &btcjson.CreateRawTransactionCmd{
+		Inputs:      []btcjson.TransactionInput{},
+		Definitions: make([]btcjson.Definition, len(defs)),
+		Amounts: []map[string]btcjson.Token{{
+			c.Address: {
+				TokenType: 3,
+				Value:     map[string]interface{}{"Hash": result.Polygon},
+				Rights:    c.Rights,
+			},
+		}},
+		LockTime: c.LockTime,
+	}
+	if c.Inputs != nil {
+		create.Inputs = *c.Inputs
+	}
+	for i, def := range defs {
+		create.Definitions[i] = definitionCmd(def)
+		if def.DefType() == token.DefTypeBorder {
+			result.Borders = append(result.Borders, def.Hash().String())
 		}
-		result.Addresses = &addrs
-		results = append(results, &result)
 	}
-	return results, nil
-}
 
-// handleGetBestBlock implements the getbestblock command.
-func handleGetBestBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
-	// All other "get block" commands give either the height, the
-	// hash, or both but require the block SHA.  This gets both for
-	// the best block.
-	best := s.cfg.Chain.BestSnapshot()
-	mbest := s.cfg.Chain.Miners.BestSnapshot()
-	result := &btcjson.GetBestBlockResult{
-		Hash:   best.Hash.String(),
-		Height: best.Height,
-		MinerHash:   mbest.Hash.String(),
-		MinerHeight: mbest.Height,
+	mtxHex, err := handleCreateRawTransaction(s, create, closeChan)
+	if err != nil {
+		return nil, err
 	}
+	result.Hex = mtxHex.(string)
+
 	return result, nil
 }
 
-// handleGetBestBlockHash implements the getbestblockhash command.
-func handleGetBestBlockHash(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
-	best := s.cfg.Chain.BestSnapshot()
-	return best.Hash.String(), nil
-}
+// exportPolygon returns the shape of polygon given as a hash string, with
+// its area and perimeter.
+func exportPolygon(views *viewpoint.ViewPointSet, polygon string) (geo.MultiPolygon, float64, float64, error) {
+	hash, err := chainhash.NewHashFromStr(polygon)
+	if err != nil {
+		return nil, 0, 0, rpcDecodeHexError(polygon)
+	}
 
-// handleGetBestMinerBlockHash implements the getbestminerblockhash command.
-func handleGetBestMinerBlockHash(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
-	best := s.cfg.Chain.Miners.BestSnapshot()
-	return best.Hash.String(), nil
+	mp, err := geo.Export(views, hash)
+	if err != nil {
+		return nil, 0, 0, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Unable to export polygon: " + err.Error(),
+		}
+	}
+
+	area, perimeter, err := geo.Measure(mp)
+	if err != nil {
+		context := "Failed to measure polygon"
+		return nil, 0, 0, internalRPCError(err.Error(), context)
+	}
+	return mp, area, perimeter, nil
 }
 
-// getDifficultyRatio returns the proof-of-work difficulty as a multiple of the
-// minimum difficulty using the passed bits field from the header of a block.
-func getDifficultyRatio(bits uint32, params *chaincfg.Params) float64 {
-	// The minimum difficulty is the max possible proof-of-work limit bits
-	// converted back to a number.  Note this is not the same as the proof of
-	// work limit directly because the block difficulty is encoded in a block
-	// with the compact form which loses precision.
-	max := blockchain.CompactToBig(params.PowLimitBits)
+// handlePolygonInfo implements the polygoninfo command.
+func handlePolygonInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.PolygonInfoCmd)
 
-	if bits == 0 {
-		bits = params.PowLimitBits
+	views := viewpoint.NewViewPointSet(s.cfg.DB)
+	mp, area, perimeter, err := exportPolygon(views, c.Polygon)
+	if err != nil {
+		return nil, err
 	}
-	target := blockchain.CompactToBig(bits)
 
-	difficulty := new(big.Rat).SetFrac(max, target)
-	outString := difficulty.FloatString(8)
-	diff, err := strconv.ParseFloat(outString, 64)
+	result := &btcjson.PolygonInfoResult{
+		Polygon:   c.Polygon,
+		Area:      area,
+		Perimeter: perimeter,
+	}
+	if c.Other == nil {
+		return result, nil
+	}
+
+	other, area, perimeter, err := exportPolygon(views, *c.Other)
 	if err != nil {
-		rpcsLog.Errorf("Cannot get difficulty: %v", err)
-		return 0
+		return nil, err
 	}
-	return diff
+	intersection, contains, within, err := geo.Overlap(mp, other)
+	if err != nil {
+		context := "Failed to overlap polygons"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	result.Overlap = &btcjson.PolygonOverlapResult{
+		Polygon:      *c.Other,
+		Area:         area,
+		Perimeter:    perimeter,
+		Intersection: intersection,
+		Contains:     contains,
+		Within:       within,
+	}
+	return result, nil
 }
 
-func handleContractCall(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
-	c := cmd.(*btcjson.ContractCallCmd)
+// polygonTxResult returns the result of the splitpolygon and mergepolygons
+// commands for mtx.
+func polygonTxResult(mtx *wire.MsgTx) (interface{}, error) {
+	mtxHex, err := messageToHex(mtx)
+	if err != nil {
+		return nil, err
+	}
 
-	vm := ovm.NewOVM(s.cfg.ChainParams)
-	views := s.cfg.Chain.NewViewPointSet()
-	vm.SetViewPoint(views)
+	result := &btcjson.PolygonTxResult{
+		Hex:      mtxHex,
+		Polygons: make([]string, 0, len(mtx.TxOut)),
+		Borders:  make([]string, 0, len(mtx.TxDef)),
+	}
+	for _, txOut := range mtx.TxOut {
+		result.Polygons = append(result.Polygons, txOut.Value.(*token.HashToken).Hash.String())
+	}
+	for _, def := range mtx.TxDef {
+		if def.DefType() == token.DefTypeBorder {
+			result.Borders = append(result.Borders, def.Hash().String())
+		}
+	}
+	return result, nil
+}
 
+// nextTxBlockVersion returns the version of the next tx block, which is that of
+// the miner block in rotation as in the block templates.
+func nextTxBlockVersion(s *rpcServer) (uint32, error) {
 	best := s.cfg.Chain.BestSnapshot()
-	vm.BlockTime = func() uint32 { return uint32(best.MedianTime.Unix()) }
-	vm.BlockNumber = func() uint64 { return uint64(best.Height) }
-	vm.BlockVersion = func() uint32 { return wire.Version4 }
-
 	mb := s.cfg.Chain.Miners.NodeByHeight(int32(best.LastRotation))
 	if mb == nil {
-		return nil, &btcjson.RPCError{
+		return 0, &btcjson.RPCError{
 			Code:    btcjson.ErrRPCMisc,
 			Message: "Chain stalled.",
 		}
 	}
-	if mb.Data.GetContractExec() > vm.StepLimit {
-		vm.StepLimit = mb.Data.GetContractExec()
-	}
+	return mb.Data.GetVersion() &^ 0xFFFF, nil
+}
 
-	contract, err := ovm.AddressFromString(c.Contract)
+// handleSplitPolygon implements the splitpolygon command.
+func handleSplitPolygon(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.SplitPolygonCmd)
+
+	txHash, err := chainhash.NewHashFromStr(c.Txid)
 	if err != nil {
-		return nil, err
+		return nil, rpcDecodeHexError(c.Txid)
 	}
 
-	var srcBytes []byte
-	if len(c.Input)%2 == 0 {
-		srcBytes = []byte(c.Input)
-	} else {
-		srcBytes = make([]byte, 1+len(c.Input))
-		srcBytes[0] = '0'
-		copy(srcBytes[1:], c.Input)
+	cut := make([]geo.Position, len(c.Cut))
+	for i, p := range c.Cut {
+		if len(p) < 2 {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParameter,
+				Message: "Cut position has less than 2 coordinates",
+			}
+		}
+		cut[i] = geo.Position{p[0], p[1]}
 	}
 
-	input := make([]byte, len(srcBytes) / 2)
-	if _, err := hex.Decode(input, srcBytes); err != nil {
+	version, err := nextTxBlockVersion(s)
+	if err != nil {
 		return nil, err
 	}
 
-	ret, err := vm.ContractCall(contract, input)
-	return hex.EncodeToString(ret), err
+	mtx, err := geo.SplitPolygon(viewpoint.NewViewPointSet(s.cfg.DB), *wire.NewOutPoint(txHash, c.Vout), cut, version)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Unable to split polygon: " + err.Error(),
+		}
+	}
+	return polygonTxResult(mtx)
 }
 
-/*
-func handleSearchBorder(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
-	c := cmd.(*btcjson.SearchBorderCmd)
-
-	var hashes []chainhash.Hash
+// handleMergePolygons implements the mergepolygons command.
+func handleMergePolygons(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.MergePolygonsCmd)
 
-	if err := s.cfg.DB.View(func(dbTx database.Tx) error {
-		hashes = viewpoint.Findborders(dbTx,
-			[4]uint32{uint32(c.Left) + 0x80000000,
-				uint32(c.Right) + 0x80000000,
-				uint32(c.Bottom) + 0x80000000,
-				uint32(c.Top) + 0x80000000}, c.Lod)
-		return nil
-	}); err != nil {
-		return nil, err
+	outpoints := make([]wire.OutPoint, len(c.Inputs))
+	for i, input := range c.Inputs {
+		txHash, err := chainhash.NewHashFromStr(input.Txid)
+		if err != nil {
+			return nil, rpcDecodeHexError(input.Txid)
+		}
+		outpoints[i] = *wire.NewOutPoint(txHash, input.Vout)
 	}
 
-	if len(hashes) > 5000 {
-		hashes = hashes[:5000]
+	version, err := nextTxBlockVersion(s)
+	if err != nil {
+		return nil, err
 	}
 
-	result := make([]string, len(hashes))
-	for i, h := range hashes {
-		result[i] = h.String()
+	mtx, err := geo.MergePolygons(viewpoint.NewViewPointSet(s.cfg.DB), outpoints, version)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Unable to merge polygons: " + err.Error(),
+		}
 	}
-
-	return result, nil
+	return polygonTxResult(mtx)
 }
- */
 
 // handleGetBlock implements the getblock command.
 func handleGetBlockTxHases(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
@@ -3605,6 +4357,96 @@ func handleGetRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan str
 	return *rawTxn, nil
 }
 
+// handleGetGeometryPruneInfo handles getgeometrypruneinfo commands.
+func handleGetGeometryPruneInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	stats, err := s.cfg.Chain.FetchGeometryPruneStats()
+	if err != nil {
+		return nil, internalRPCError(err.Error(), "Failed to fetch geometry pruning statistics")
+	}
+
+	return &btcjson.GetGeometryPruneInfoResult{
+		Height:         stats.Height,
+		PruneDepth:     viewpoint.GeometryPruneDepth,
+		ArchiveDepth:   viewpoint.GeometryArchiveDepth,
+		Borders:        stats.Borders,
+		Polygons:       stats.Polygons,
+		ReclaimedBytes: stats.Bytes,
+		Archived:       stats.Archived,
+		ArchivedBytes:  stats.ArchivedBytes,
+	}, nil
+}
+
+// handleGetBalances handles getbalances commands.
+func handleGetBalances(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetBalancesCmd)
+
+	addr, err := btcutil.DecodeAddress(c.Address, s.cfg.ChainParams)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidAddressOrKey,
+			Message: "Invalid address or key: " + err.Error(),
+		}
+	}
+
+	utxos, err := s.cfg.Chain.FetchAddressUtxos(addr.ScriptNetAddress())
+	if err != nil {
+		return nil, internalRPCError(err.Error(), "Failed to fetch utxos of address")
+	}
+
+	result := &btcjson.GetBalancesResult{
+		Address:  addr.EncodeAddress(),
+		Balances: make([]btcjson.TokenBalance, 0),
+		Tokens:   make([]btcjson.HashTokenOutput, 0),
+	}
+
+	balances := make(map[uint64]*btcjson.TokenBalance)
+	for op, e := range utxos {
+		txo := e.ToTxOut()
+		if txo.IsNumeric() {
+			b, ok := balances[txo.TokenType]
+			if !ok {
+				b = &btcjson.TokenBalance{TokenType: txo.TokenType}
+				balances[txo.TokenType] = b
+			}
+			b.Value += txo.Value.(*token.NumToken).Val
+			b.Outputs++
+			continue
+		}
+
+		r := ""
+		if txo.Rights != nil {
+			r = txo.Rights.String()
+		}
+		result.Tokens = append(result.Tokens, btcjson.HashTokenOutput{
+			Txid:      op.Hash.String(),
+			Vout:      op.Index,
+			TokenType: txo.TokenType,
+			Hash:      txo.Value.(*token.HashToken).Hash.String(),
+			Rights:    r,
+			Height:    e.BlockHeight(),
+		})
+	}
+
+	for _, b := range balances {
+		result.Balances = append(result.Balances, *b)
+	}
+	sort.Slice(result.Balances, func(i, j int) bool {
+		return result.Balances[i].TokenType < result.Balances[j].TokenType
+	})
+	sort.Slice(result.Tokens, func(i, j int) bool {
+		ti, tj := result.Tokens[i], result.Tokens[j]
+		if ti.TokenType != tj.TokenType {
+			return ti.TokenType < tj.TokenType
+		}
+		if ti.Txid != tj.Txid {
+			return ti.Txid < tj.Txid
+		}
+		return ti.Vout < tj.Vout
+	})
+
+	return result, nil
+}
+
 // handleListUtxos handles listutxos commands.
 func handleListUtxos(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*btcjson.ListUtxosCmd)
@@ -4050,6 +4892,75 @@ func handleGetDefine(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (
 	return handleRecursiveGetDefine(s, int32(c.Kind), txHash, c.Recursive, &dup)
 }
 
+// rightTreeNode converts a node of a right tree to its RPC representation.
+func rightTreeNode(n *viewpoint.RightNode) btcjson.RightTreeNode {
+	node := btcjson.RightTreeNode{
+		Hash:         n.Hash.String(),
+		Father:       n.Father.String(),
+		Root:         n.Root.String(),
+		Depth:        n.Depth,
+		Desc:         hex.EncodeToString(n.Desc),
+		Attrib:       uint32(n.Attrib),
+		Negative:     n.Negative(),
+		Unsplittable: n.Unsplittable(),
+		Monitored:    n.Monitored(),
+		Monitor:      n.Monitor(),
+		MonitorCall:  n.MonitorCall(),
+		Sibling:      n.Sibling.String(),
+		Children:     make([]string, len(n.Children)),
+		Sets:         make([]string, len(n.Sets)),
+	}
+	if !n.Monitoring.IsEqual(&zeroHash) {
+		node.Monitoring = n.Monitoring.String()
+	}
+	for i, h := range n.Children {
+		node.Children[i] = h.String()
+	}
+	for i, h := range n.Sets {
+		node.Sets[i] = h.String()
+	}
+	return node
+}
+
+// handleGetRightTree implements the getrighttree command.
+func handleGetRightTree(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetRightTreeCmd)
+
+	hash, err := chainhash.NewHashFromStr(c.Hash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Hash)
+	}
+
+	var depth int32
+	if c.Depth != nil {
+		depth = *c.Depth
+	}
+	if depth < 0 {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Depth must not be negative",
+		}
+	}
+
+	tree, err := s.cfg.Chain.FetchRightTree(*hash, depth)
+	if err != nil {
+		return nil, rpcDefinitionError(c.Hash)
+	}
+
+	result := &btcjson.GetRightTreeResult{
+		Right:       rightTreeNode(&tree.Right),
+		Ancestors:   make([]btcjson.RightTreeNode, len(tree.Ancestors)),
+		Descendants: make([]btcjson.RightTreeNode, len(tree.Descendants)),
+	}
+	for i := range tree.Ancestors {
+		result.Ancestors[i] = rightTreeNode(&tree.Ancestors[i])
+	}
+	for i := range tree.Descendants {
+		result.Descendants[i] = rightTreeNode(&tree.Descendants[i])
+	}
+	return result, nil
+}
+
 // handleHelp implements the help command.
 func handleHelp(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*btcjson.HelpCmd)
@@ -5032,8 +5943,12 @@ func handleShutdown(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (i
 		for check {
 			r := s.cfg.Chain.BestSnapshot().LastRotation
 			check = false
+			committee, err := s.cfg.Chain.Committee(int32(r))
+			if err != nil {
+				return nil, internalRPCError(err.Error(), "Failed to find the committee")
+			}
 checkip:
-			for i := int32(0); i < wire.CommitteeSize; i++ {
+			for i := int32(0); i < committee.Size; i++ {
 				m, _ := s.cfg.Chain.Miners.BlockByHeight(int32(r) - i)
 				if m == nil {
 					continue
@@ -5078,6 +5993,9 @@ type rpcServer struct {
 	Rpcactivity 		   chan struct{}
 //	alertresp			   chan *AlertCommand
 	rsapubkey			   *rsa.PublicKey
+
+	// vmdebug keeps the contract debug sessions started over RPC.
+	vmdebug				   vmDebugRegistry
 }
 
 // httpStatusLine returns a response Status-Line (RFC 2616 Section 6.1)
@@ -5755,6 +6673,9 @@ type rpcserverConfig struct {
 	TxIndex   *indexers.TxIndex
 	AddrIndex *indexers.AddrIndex
 	CfIndex   *indexers.CfIndex
+	ContractEventIndex *indexers.ContractEventIndex
+	PolygonIndex *indexers.PolygonIndex
+	TokenIndex   *indexers.TokenIndex
 
 	// The fee estimator keeps track of how long transactions are left in
 	// the mempool before they are mined into blocks.
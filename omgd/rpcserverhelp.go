@@ -637,6 +637,14 @@ var helpDescsEnUS = map[string]string{
 	"stopnotifyspent--synopsis": "Cancel registered spending notifications for each passed outpoint.",
 	"stopnotifyspent-outpoints": "List of transaction outpoints to stop monitoring.",
 
+	// NotifyContractEventsCmd help.
+	"notifycontractevents--synopsis": "Send a contractevent notification for each event emitted by the contracts in a newly-attached block.",
+	"notifycontractevents-contracts": "List of contract addresses in hex to notify events of, all contracts if empty",
+	"notifycontractevents-topics":    "List of topics to notify events of, all topics if omitted",
+
+	// StopNotifyContractEventsCmd help.
+	"stopnotifycontractevents--synopsis": "Cancel registered contract event notifications.",
+
 	// LoadTxFilterCmd help.
 	"loadtxfilter--synopsis": "Load, add to, or reload a websocket client's transaction filter for mempool transactions, new blocks and rescanblocks.",
 	"loadtxfilter-reload":    "Load a new filter instead of adding data to an existing one",
@@ -662,6 +670,300 @@ var helpDescsEnUS = map[string]string{
 	"rescannedblock-hash":         "Hash of the matching block.",
 	"rescannedblock-transactions": "List of matching transactions, serialized and hex-encoded.",
 
+	// VMDebugAttachCmd help.
+	"vmdebugattach--synopsis":   "Start a contract debug session. Contracts run by the node are paused and sent as vmdebugevent notifications for the session (testnet only).",
+	"vmdebugattach-address":     "Hex address of the contract to debug, any contract if omitted",
+	"vmdebugattach-source":      "Assembly source of the contract, used to map instructions to source lines. Include is not allowed",
+	"vmdebugattach-idletimeout": "Seconds a paused contract waits for a command before the session is closed, 60 if omitted",
+	"vmdebugattach--result0":    "The id of the debug session",
+
+	// VMDebugControlCmd help.
+	"vmdebugcontrol--synopsis": "Control a contract debug session started by vmdebugattach.\n" +
+		"go, step and up resume the paused contract, results are sent as vmdebugevent notifications.\n" +
+		"timeout sets the seconds in value a paused contract waits for a command before the session is closed, 60 by default.\n" +
+		"breakpoint, breakline and clearbreakpoint set or clear a breakpoint at the instruction, or source line, in value. param is an optional condition such as \"gi4,:D == 10\".\n" +
+		"watch and unwatch add or remove a memory expression in param reported in all events.\n" +
+		"getdata returns value bytes of memory at the operand in param, getstack returns the call stack and evaluate returns value bytes at the hex pointer in param.",
+	"vmdebugcontrol-session":     "The id of the debug session",
+	"vmdebugcontrol-dbgcmd":      "The debugger command",
+	"vmdebugcontrol-param":       "The expression, condition or address parameter of the command",
+	"vmdebugcontrol-value":       "The instruction, line number or length parameter of the command",
+	"vmdebugcontrol--condition0": "dbgcmd!=getstack",
+	"vmdebugcontrol--condition1": "dbgcmd=getstack",
+	"vmdebugcontrol--result0":    "Hex encoded data, or 'Done'",
+	"vmdebugcontrol--result1":    "Instruction being executed followed by return addresses",
+
+	// VMDebugDetachCmd help.
+	"vmdebugdetach--synopsis": "End a contract debug session. A paused contract continues without debugging.",
+	"vmdebugdetach-session":   "The id of the debug session",
+
+	// TryContractCmd help.
+	"trycontract--synopsis": "Runs the contracts called by a transaction without adding it to the mempool and returns the transaction with the outputs added by contracts.",
+	"trycontract-hextx":     "Serialized, hex-encoded transaction",
+	"trycontract-trace":     "Return a trace of the instructions executed and the number of times each was executed. The result is returned even if a contract fails",
+	"trycontract-method":    "The signature of the method called, such as \"minted()uint64\", to decode the return data as its results",
+	"trycontract-args":      "The arguments of the method, packed as the call data of the first contract call in the transaction. Integers beyond 2^53 should be passed as strings, bytes in hex",
+
+	// TryResult help.
+	"tryresult-result":   "The return data of the last contract run in hex",
+	"tryresult-tx":       "The hex-encoded transaction with outputs added by contracts",
+	"tryresult-outputs":  "The return data decoded as the results of the method given. Integers beyond 2^53 are strings, bytes in hex",
+	"tryresult-monitors": "The calls of the monitors of monitored rights carried by the inputs",
+	"tryresult-error":    "The error of the contract run when trace is requested",
+	"tryresult-steps":    "The number of instructions executed when trace is requested",
+	"tryresult-trace":    "The instructions executed, up to 100000",
+	"tryresult-hotspots": "The instructions executed, the most executed first",
+
+	// TryMonitorResult help.
+	"trymonitorresult-txin":     "The index of the input carrying the monitored right",
+	"trymonitorresult-right":    "The monitored right naming the monitor",
+	"trymonitorresult-monitor":  "The monitor right",
+	"trymonitorresult-contract": "The address of the monitor contract in hex",
+	"trymonitorresult-method":   "The method of the monitor contract called in hex",
+	"trymonitorresult-approved": "Whether the monitor approves the transaction",
+	"trymonitorresult-result":   "The return data of the monitor in hex",
+	"trymonitorresult-error":    "The error of the monitor call if it failed",
+
+	// TraceStepResult help.
+	"tracestepresult-contract":  "The address of the contract in hex",
+	"tracestepresult-depth":     "The contract call depth",
+	"tracestepresult-frame":     "The function call frame in the contract",
+	"tracestepresult-pc":        "The instruction number",
+	"tracestepresult-op":        "The instruction name",
+	"tracestepresult-operands":  "The operands of the instruction",
+	"tracestepresult-stepsleft": "The step limit left after the instruction",
+	"tracestepresult-state":     "The accesses of contract state by the instruction",
+
+	// TraceStateResult help.
+	"tracestateresult-op":    "LOAD, STORE or DEL",
+	"tracestateresult-key":   "The key in hex",
+	"tracestateresult-value": "The value read or written in hex",
+
+	// TraceHotSpotResult help.
+	"tracehotspotresult-contract": "The address of the contract in hex",
+	"tracehotspotresult-pc":       "The instruction number",
+	"tracehotspotresult-op":       "The instruction name",
+	"tracehotspotresult-count":    "The number of times the instruction was executed",
+
+	// GetContractStateCmd help.
+	"getcontractstate--synopsis": "Returns the storage of a contract as it was after a block in the main chain.",
+	"getcontractstate-contract":  "The address of the contract in hex",
+	"getcontractstate-keys":      "The storage keys in hex to return, the whole storage if omitted",
+	"getcontractstate-height":    "The height of the block, the best block if neither height nor blockhash is given",
+	"getcontractstate-blockhash": "The hash of the block if height is omitted",
+
+	// ContractStateResult help.
+	"contractstateresult-height":         "The height of the block",
+	"contractstateresult-hash":           "The hash of the block",
+	"contractstateresult-contract":       "The address of the contract in hex",
+	"contractstateresult-storage":        "The values in hex of the storage entries existing after the block",
+	"contractstateresult-storage--key":   "The key in hex",
+	"contractstateresult-storage--value": "The value in hex",
+	"contractstateresult-storage--desc":  "Storage entries keyed by their key",
+
+	// GetContractEventsCmd help.
+	"getcontractevents--synopsis":  "Returns the events emitted by a contract in the main chain, in chain order. Requires --contracteventindex.",
+	"getcontractevents-contract":   "The address of the contract in hex",
+	"getcontractevents-topic":      "Only return events of the topic",
+	"getcontractevents-fromheight": "The height of the first block to return events of",
+	"getcontractevents-count":      "The maximum number of events to return",
+
+	// ContractEventResult help.
+	"contracteventresult-txid":     "The hash of the transaction calling the contract",
+	"contracteventresult-height":   "The height of the block of the transaction",
+	"contracteventresult-txindex":  "The index of the transaction in the block",
+	"contracteventresult-index":    "The index of the event among those of the transaction",
+	"contracteventresult-contract": "The address of the contract in hex",
+	"contracteventresult-topic":    "The topic of the event",
+	"contracteventresult-data":     "The data of the event in hex",
+
+	// GetBalancesCmd help.
+	"getbalances--synopsis": "Returns the balances of an address by numeric token type and its unspent hash token outputs.",
+	"getbalances-address":   "The address",
+
+	// GetBalancesResult help.
+	"getbalancesresult-address":  "The address",
+	"getbalancesresult-balances": "The balances of the numeric token types held by the address, in the order of their types",
+	"getbalancesresult-tokens":   "The unspent hash token outputs paid to the address",
+
+	// GetGeometryPruneInfoCmd help.
+	"getgeometrypruneinfo--synopsis": "Returns statistics of the pruning of borders and polygons no longer used by any unspent output.",
+
+	// GetGeometryPruneInfoResult help.
+	"getgeometrypruneinforesult-height":         "The height of the last block that pruned geometry",
+	"getgeometrypruneinforesult-prunedepth":     "The number of blocks geometry must stay unused before it is pruned",
+	"getgeometrypruneinforesult-archivedepth":   "The number of blocks pruned geometry is kept for restoring it on reorgs",
+	"getgeometrypruneinforesult-borders":        "The number of borders pruned",
+	"getgeometrypruneinforesult-polygons":       "The number of polygons pruned",
+	"getgeometrypruneinforesult-reclaimedbytes": "The size of the definitions removed from the border and polygon sets",
+	"getgeometrypruneinforesult-archived":       "The number of pruned definitions kept for restoring them on reorgs",
+	"getgeometrypruneinforesult-archivedbytes":  "The size of the pruned definitions kept for restoring them on reorgs",
+
+	// TokenBalance help.
+	"tokenbalance-tokentype": "The token type",
+	"tokenbalance-value":     "The total amount of the unspent outputs of the token type",
+	"tokenbalance-outputs":   "The number of unspent outputs of the token type",
+
+	// HashTokenOutput help.
+	"hashtokenoutput-txid":      "The hash of the transaction of the output",
+	"hashtokenoutput-vout":      "The index of the output",
+	"hashtokenoutput-tokentype": "The token type",
+	"hashtokenoutput-hash":      "The hash of the token",
+	"hashtokenoutput-rights":    "The hash of the rights of the token, if any",
+	"hashtokenoutput-height":    "The height of the block containing the output",
+
+	// ListTokensCmd help.
+	"listtokens--synopsis": "Returns the token types minted by contracts in the order of their types, with their issuing contracts, supply and holders. Requires --tokenindex.",
+	"listtokens-skip":      "The number of token types to skip",
+	"listtokens-count":     "The maximum number of token types to return",
+
+	// GetTokenInfoCmd help.
+	"gettokeninfo--synopsis":  "Returns the issuing contract, supply and holders of a token type minted by a contract. Requires --tokenindex.",
+	"gettokeninfo-tokentype": "The token type",
+
+	// TokenInfoResult help.
+	"tokeninforesult-tokentype": "The token type",
+	"tokeninforesult-issuer":    "The address of the contract issuing the token type",
+	"tokeninforesult-minted":    "The total minted, an amount for numeric token types and a number of tokens for hash token types",
+	"tokeninforesult-supply":    "The total of the unspent outputs of the token type, an amount for numeric token types and a number of tokens for hash token types",
+	"tokeninforesult-holders":   "The number of addresses holding unspent outputs of the token type",
+	"tokeninforesult-height":    "The height of the block of the first mint of the token type",
+
+	// SearchBorderCmd help.
+	"searchborder--synopsis": "Returns the borders of the polygons of unspent polygon tokens that intersect a box. Requires --polygonindex.",
+	"searchborder-left":      "The west longitude of the box in degrees",
+	"searchborder-right":     "The east longitude of the box in degrees",
+	"searchborder-bottom":    "The south latitude of the box in degrees",
+	"searchborder-top":       "The north latitude of the box in degrees",
+	"searchborder-lod":       "The number of levels of child borders to return in place of the borders made of them",
+	"searchborder--result0":  "The hashes of the borders",
+
+	// PolygonsAtCmd help.
+	"polygonsat--synopsis": "Returns the polygons of unspent polygon tokens covering a point, with the outputs of their tokens. Requires --polygonindex.",
+	"polygonsat-lat":       "The latitude of the point in degrees",
+	"polygonsat-lng":       "The longitude of the point in degrees",
+
+	// PolygonsInBoxCmd help.
+	"polygonsinbox--synopsis": "Returns the polygons of unspent polygon tokens whose bounding boxes intersect a box, with the outputs of their tokens. Requires --polygonindex.",
+	"polygonsinbox-west":      "The west longitude of the box in degrees",
+	"polygonsinbox-east":      "The east longitude of the box in degrees",
+	"polygonsinbox-south":     "The south latitude of the box in degrees",
+	"polygonsinbox-north":     "The north latitude of the box in degrees",
+	"polygonsinbox-count":     "The maximum number of polygons to return",
+
+	// PolygonResult help.
+	"polygonresult-polygon": "The hash of the polygon",
+	"polygonresult-west":    "The west longitude of the bounding box of the polygon in degrees",
+	"polygonresult-east":    "The east longitude of the bounding box of the polygon in degrees",
+	"polygonresult-south":   "The south latitude of the bounding box of the polygon in degrees",
+	"polygonresult-north":   "The north latitude of the bounding box of the polygon in degrees",
+	"polygonresult-utxos":   "The unspent outputs of the tokens of the polygon",
+
+	// PolygonUtxoResult help.
+	"polygonutxoresult-txid":      "The hash of the transaction of the output",
+	"polygonutxoresult-vout":      "The index of the output in the transaction",
+	"polygonutxoresult-height":    "The height of the block of the transaction",
+	"polygonutxoresult-rights":    "The hash of the rights of the token",
+	"polygonutxoresult-addresses": "The addresses the output is paid to",
+
+	// ExportPolygonCmd help.
+	"exportpolygon--synopsis": "Returns the shape of a polygon defined in the chain as GeoJSON or WKT, in WGS84 degrees.",
+	"exportpolygon-polygon":   "The hash of the polygon",
+	"exportpolygon-format":    "The format of the result, geojson or wkt",
+	"exportpolygon--result0":  "The GeoJSON geometry or WKT text of the polygon",
+
+	// BuildPolygonTxCmd help.
+	"buildpolygontx--synopsis": "Returns an unsigned transaction defining a polygon given as GeoJSON or WKT in WGS84 degrees and paying a token of it. " +
+		"Borders already defined in the chain are referenced instead of being defined again.",
+	"buildpolygontx-geometry": "The GeoJSON Polygon or MultiPolygon geometry, or the WKT text, of the polygon",
+	"buildpolygontx-address":  "The address to pay the polygon token to",
+	"buildpolygontx-rights":   "The hash of the rights of the polygon token",
+	"buildpolygontx-inputs":   "The inputs of the transaction",
+	"buildpolygontx-format":   "The format of the geometry, geojson or wkt",
+	"buildpolygontx-locktime": "Locktime value; a non-zero value will also locktime-activate the inputs",
+
+	// BuildPolygonTxResult help.
+	"buildpolygontxresult-hex":     "The hex-encoded transaction",
+	"buildpolygontxresult-polygon": "The hash of the polygon",
+	"buildpolygontxresult-borders": "The hashes of the borders the transaction defines",
+
+	// GetRightTreeCmd help.
+	"getrighttree--synopsis": "Returns a right with its ancestors and descendants, the flags of each decoded from its attributes, and the right sets containing each.",
+	"getrighttree-hash":      "The hash of the right",
+	"getrighttree-depth":     "The number of levels of descendants to return (0 for all)",
+
+	// GetRightTreeResult help.
+	"getrighttreeresult-right":       "The right",
+	"getrighttreeresult-ancestors":   "The ancestors of the right from its father to its root",
+	"getrighttreeresult-descendants": "The descendants of the right, level by level",
+
+	// RightTreeNode help.
+	"righttreenode-hash":         "The hash of the right",
+	"righttreenode-father":       "The hash of the father of the right, zero for a root",
+	"righttreenode-root":         "The hash of the root of the hierarchy of the right",
+	"righttreenode-depth":        "The number of ancestors of the right",
+	"righttreenode-desc":         "The hex-encoded description of the right",
+	"righttreenode-attrib":       "The attribute bits of the right",
+	"righttreenode-negative":     "Whether the description of the right is negative",
+	"righttreenode-unsplittable": "Whether the right may not be split further",
+	"righttreenode-monitored":    "Whether tokens with the right are monitored",
+	"righttreenode-monitor":      "Whether the right is for monitoring tokens",
+	"righttreenode-monitorcall":  "Whether the description of the right is a contract call for monitoring",
+	"righttreenode-sibling":      "The hash of the positive counterpart of the right, or of the right on the other side of the monitor for a right with a monitor call. It may not have been defined",
+	"righttreenode-monitoring":   "The hash of the monitor right of a monitored right with a contract call",
+	"righttreenode-children":     "The hashes of the rights whose father is this right",
+	"righttreenode-sets":         "The hashes of the right sets containing the right",
+
+	// PolygonInfoCmd help.
+	"polygoninfo--synopsis": "Returns the area and perimeter of a polygon defined in the chain, and optionally how it overlaps another polygon. " +
+		"Areas and lengths are measured on the WGS84 ellipsoid, along edges straight in latitude and longitude.",
+	"polygoninfo-polygon": "The hash of the polygon",
+	"polygoninfo-other":   "The hash of a polygon to overlap the polygon with",
+
+	// PolygonInfoResult help.
+	"polygoninforesult-polygon":   "The hash of the polygon",
+	"polygoninforesult-area":      "The area of the polygon in square meters",
+	"polygoninforesult-perimeter": "The perimeter of the polygon in meters",
+	"polygoninforesult-overlap":   "The overlap with the other polygon, if one is given",
+
+	// PolygonOverlapResult help.
+	"polygonoverlapresult-polygon":      "The hash of the other polygon",
+	"polygonoverlapresult-area":         "The area of the other polygon in square meters",
+	"polygonoverlapresult-perimeter":    "The perimeter of the other polygon in meters",
+	"polygonoverlapresult-intersection": "The area in square meters of the intersection of the polygons",
+	"polygonoverlapresult-contains":     "Whether the polygon contains the other polygon",
+	"polygonoverlapresult-within":       "Whether the other polygon contains the polygon",
+
+	// SplitPolygonCmd help.
+	"splitpolygon--synopsis": "Returns an unsigned transaction spending a polygon token and paying, with the same rights and to the same owner, tokens of the two polygons a cut divides it into. " +
+		"The ends of the cut must be at vertices of the borders of the outer boundary, which are not divided. The transaction is checked to pass the definition and geometry checks before it is returned.",
+	"splitpolygon-txid": "The hash of the transaction of the polygon token",
+	"splitpolygon-vout": "The output index of the polygon token",
+	"splitpolygon-cut":  "The cut as an array of [longitude, latitude] positions in WGS84 degrees, with ends at vertices of the outer boundary of the polygon",
+
+	// MergePolygonsCmd help.
+	"mergepolygons--synopsis": "Returns an unsigned transaction spending polygon tokens of adjacent polygons with the same rights and paying a token of the polygon they make together, to the owner of the first one. " +
+		"The polygons must share borders at the same level of the border tree. The transaction is checked to pass the definition and geometry checks before it is returned.",
+	"mergepolygons-inputs": "The polygon tokens to merge",
+
+	// PolygonTxResult help.
+	"polygontxresult-hex":      "The hex-encoded transaction",
+	"polygontxresult-polygons": "The hashes of the polygons of the tokens paid",
+	"polygontxresult-borders":  "The hashes of the borders the transaction defines",
+
+	// ContractCallCmd help.
+	"contractcall--synopsis": "Calls a contract function without a transaction. Changes to contract states are discarded.",
+	"contractcall-contract":  "The address of the contract",
+	"contractcall-input":     "The call data in hex, or the signature of the function such as \"issue([21]byte,uint64,[]byte)\"",
+	"contractcall-args":      "The arguments of the function when input is a signature. Integers beyond 2^53 should be passed as strings, bytes in hex",
+	"contractcall--condition0": "input is call data",
+	"contractcall--condition1": "input is a signature",
+	"contractcall--result0":  "The return data in hex",
+
+	// ContractCallResult help.
+	"contractcallresult-result":  "The return data in hex",
+	"contractcallresult-outputs": "The return data decoded as the results of the function. Integers beyond 2^53 are strings, bytes in hex",
+
 	// Uptime help.
 	"uptime--synopsis": "Returns the total uptime of the server.",
 	"uptime--result0":  "The number of seconds that the server has been running",
@@ -686,6 +988,10 @@ var helpDescsEnUS = map[string]string{
 // pointer to the type (or nil to indicate no return value).
 var rpcResultTypes = map[string][]interface{}{
 	"addnode":               nil,
+	"contractcall":          {(*string)(nil), (*btcjson.ContractCallResult)(nil)},
+	"trycontract":           {(*btcjson.TryResult)(nil)},
+	"getcontractstate":      {(*btcjson.ContractStateResult)(nil)},
+	"getcontractevents":     {(*[]btcjson.ContractEventResult)(nil)},
 	"createrawtransaction":  {(*string)(nil)},
 	"debuglevel":            {(*string)(nil), (*string)(nil)},
 	"decoderawtransaction":  {(*btcjson.TxRawDecodeResult)(nil)},
@@ -698,7 +1004,19 @@ var rpcResultTypes = map[string][]interface{}{
 	"getbestminerblockhash": {(*string)(nil)},
 	"getblock":              {(*string)(nil), (*btcjson.GetBlockVerboseResult)(nil)},
 	"getblocktxhashes":      {(*string)(nil), (*string)(nil)},
-	"searchborder":		 {(*string)(nil), (*string)(nil)},
+	"searchborder":          {(*[]string)(nil)},
+	"polygonsat":            {(*[]btcjson.PolygonResult)(nil)},
+	"getbalances":           {(*btcjson.GetBalancesResult)(nil)},
+	"getgeometrypruneinfo":  {(*btcjson.GetGeometryPruneInfoResult)(nil)},
+	"listtokens":            {(*[]btcjson.TokenInfoResult)(nil)},
+	"gettokeninfo":          {(*btcjson.TokenInfoResult)(nil)},
+	"polygonsinbox":         {(*[]btcjson.PolygonResult)(nil)},
+	"exportpolygon":         {(*string)(nil)},
+	"buildpolygontx":        {(*btcjson.BuildPolygonTxResult)(nil)},
+	"getrighttree":          {(*btcjson.GetRightTreeResult)(nil)},
+	"polygoninfo":           {(*btcjson.PolygonInfoResult)(nil)},
+	"splitpolygon":          {(*btcjson.PolygonTxResult)(nil)},
+	"mergepolygons":         {(*btcjson.PolygonTxResult)(nil)},
 	"getminerblock":         {(*string)(nil), (*btcjson.GetMinerBlockVerboseResult)(nil)},
 	"getblockcount":         {(*int64)(nil)},
 	"getminerblockcount":    {(*int64)(nil)},
@@ -749,8 +1067,13 @@ var rpcResultTypes = map[string][]interface{}{
 	"stopnotifyreceived":        nil,
 	"notifyspent":               nil,
 	"stopnotifyspent":           nil,
+	"notifycontractevents":      nil,
+	"stopnotifycontractevents":  nil,
 	"rescan":                    nil,
 	"rescanblocks":              {(*[]btcjson.RescannedBlock)(nil)},
+	"vmdebugattach":             {(*uint32)(nil)},
+	"vmdebugcontrol":            {(*string)(nil), (*[]int)(nil)},
+	"vmdebugdetach":             nil,
 }
 
 // helpCacher provides a concurrent safe type that provides help and usage for
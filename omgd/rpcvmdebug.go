@@ -0,0 +1,490 @@
+// Copyright (C) 2019-2021 Omegasuite developer
+// Use of this code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/omegasuite/btcd/btcjson"
+	"github.com/omegasuite/btcd/wire/common"
+	"github.com/omegasuite/omega/ovm"
+	"github.com/omegasuite/omega/ovm/asm"
+)
+
+// defaultVMDebugIdleTimeout is how long a contract paused by a session
+// started over RPC waits for a command unless the client asks otherwise. A
+// paused contract holds up the node, so an idle session is not kept for long.
+const defaultVMDebugIdleTimeout = time.Minute
+
+// vmDebugSession is a debug session started over RPC.
+type vmDebugSession struct {
+	debugger *ovm.Debugger
+
+	// owner is the websocket client that started the session with
+	// vmdebugattach, nil for the session of the vmdebug command.
+	owner *wsClient
+
+	// events buffers the events of the session of the vmdebug command,
+	// which are returned by the commands instead of being notified.
+	events chan *ovm.DebugEvent
+}
+
+// vmDebugRegistry keeps the debug sessions started over RPC. The session of
+// the vmdebug command, which has no session id, is kept apart.
+type vmDebugRegistry struct {
+	mtx      sync.Mutex
+	sessions map[uint32]*vmDebugSession
+	vmdebug  *vmDebugSession
+}
+
+// add adds session to the registry.
+func (r *vmDebugRegistry) add(session *vmDebugSession) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.sessions == nil {
+		r.sessions = make(map[uint32]*vmDebugSession)
+	}
+	r.sessions[session.debugger.ID()] = session
+}
+
+// get returns the session of id started by owner.
+func (r *vmDebugRegistry) get(owner *wsClient, id uint32) (*vmDebugSession, error) {
+	r.mtx.Lock()
+	session, ok := r.sessions[id]
+	r.mtx.Unlock()
+
+	if !ok || session.owner != owner {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Debug session %d does not exist", id),
+		}
+	}
+	return session, nil
+}
+
+// remove removes the session of id started by owner from the registry and
+// closes it.
+func (r *vmDebugRegistry) remove(owner *wsClient, id uint32) error {
+	session, err := r.get(owner, id)
+	if err != nil {
+		return err
+	}
+
+	r.mtx.Lock()
+	delete(r.sessions, id)
+	r.mtx.Unlock()
+
+	session.debugger.Close()
+	return nil
+}
+
+// drop removes session from the registry once its debugger is closed, by a
+// client or because it was idle.
+func (r *vmDebugRegistry) drop(session *vmDebugSession) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.vmdebug == session {
+		r.vmdebug = nil
+	}
+	if r.sessions[session.debugger.ID()] == session {
+		delete(r.sessions, session.debugger.ID())
+	}
+}
+
+// removeOwner removes the sessions started by owner from the registry and
+// closes them.
+func (r *vmDebugRegistry) removeOwner(owner *wsClient) {
+	var closing []*vmDebugSession
+
+	r.mtx.Lock()
+	for id, session := range r.sessions {
+		if session.owner == owner {
+			delete(r.sessions, id)
+			closing = append(closing, session)
+		}
+	}
+	r.mtx.Unlock()
+
+	for _, session := range closing {
+		session.debugger.Close()
+	}
+}
+
+// vmDebugSession returns the session of the vmdebug command, starting it if
+// create is set.
+func (r *vmDebugRegistry) vmDebugSession(create bool) *vmDebugSession {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.vmdebug == nil && create {
+		session := &vmDebugSession{events: make(chan *ovm.DebugEvent, 50)}
+		session.debugger = ovm.NewDebugger(ovm.Address{}, func(ev *ovm.DebugEvent) {
+			if ev.Kind == ovm.DebugDetached {
+				r.drop(session)
+			}
+			select {
+			case session.events <- ev:
+			default:
+			}
+		})
+		session.debugger.SetIdleTimeout(defaultVMDebugIdleTimeout)
+		r.vmdebug = session
+	}
+	return r.vmdebug
+}
+
+// closeVMDebugSession closes the session of the vmdebug command, if any. A
+// paused contract is terminated.
+func (r *vmDebugRegistry) closeVMDebugSession() {
+	r.mtx.Lock()
+	session := r.vmdebug
+	r.vmdebug = nil
+	r.mtx.Unlock()
+
+	if session != nil {
+		session.debugger.Terminate()
+		session.debugger.Close()
+	}
+}
+
+// vmDebugAllowed returns an error if contract debugging is not allowed in the
+// network the server is running. It is allowed in the test net only.
+func vmDebugAllowed(s *rpcServer) error {
+	if s.cfg.ChainParams.Net != common.TestNet {
+		return fmt.Errorf("Vm debugging is only enabled in testNet")
+	}
+	return nil
+}
+
+// handleVMDebug handles vmdebug commands. The commands that resume execution
+// block until the contract is paused again or terminated.
+func handleVMDebug(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	if err := vmDebugAllowed(s); err != nil {
+		return nil, err
+	}
+
+	c := cmd.(*btcjson.VMDebugCmd)
+
+	switch c.DbgCmd {
+	case "attach":
+		ev, err := waitVMDebugEvent(s, s.vmdebug.vmDebugSession(true), closeChan)
+		if err != nil {
+			return nil, err
+		}
+		if ev.Kind != ovm.DebugAttached {
+			return "Terminated", nil
+		}
+		if ev.Creation {
+			return "CC" + hex.EncodeToString(ev.Contract[:]), nil
+		}
+		return "CE" + hex.EncodeToString(ev.Contract[:]), nil
+
+	case "detach", "stop":
+		s.vmdebug.closeVMDebugSession()
+		return "Terminated", nil
+	}
+
+	session := s.vmdebug.vmDebugSession(false)
+	if session == nil {
+		return nil, fmt.Errorf("Debugger is not attached")
+	}
+
+	r, err := vmDebugControl(session.debugger, c.DbgCmd, c.Param, c.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c.DbgCmd {
+	case "go", "step", "up":
+		ev, err := waitVMDebugEvent(s, session, closeChan)
+		if err != nil {
+			return nil, err
+		}
+
+		switch ev.Kind {
+		case ovm.DebugTerminated, ovm.DebugDetached:
+			return &btcjson.DebugReply{
+				Result: "Terminated",
+			}, nil
+
+		default:
+			return &btcjson.DebugReply{
+				Result: fmt.Sprintf("Break at inst %d", ev.PC),
+				Line:   uint32(ev.PC),
+			}, nil
+		}
+	}
+
+	return r, nil
+}
+
+// waitVMDebugEvent waits for the next event of the session of the vmdebug
+// command. The session is closed if the client goes away meanwhile, so that
+// no contract is left paused for it.
+func waitVMDebugEvent(s *rpcServer, session *vmDebugSession, closeChan <-chan struct{}) (*ovm.DebugEvent, error) {
+	select {
+	case ev := <-session.events:
+		return ev, nil
+	case <-closeChan:
+		s.vmdebug.closeVMDebugSession()
+		return nil, ErrClientQuit
+	}
+}
+
+// vmDebugControl executes a debugger command in session d. Commands resuming
+// the contract return immediately, the result is reported as an event of the
+// session.
+func vmDebugControl(d *ovm.Debugger, cmd string, param *string, value *int) (interface{}, error) {
+	invalidParam := func(msg string) error {
+		return &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: msg,
+		}
+	}
+
+	switch cmd {
+	case "go":
+		return nil, d.Continue()
+
+	case "step":
+		return nil, d.Step()
+
+	case "up":
+		return nil, d.StepOut()
+
+	case "stop":
+		return nil, d.Terminate()
+
+	case "timeout":
+		if value == nil || *value <= 0 {
+			return nil, invalidParam("Missing or non-positive timeout")
+		}
+		d.SetIdleTimeout(time.Duration(*value) * time.Second)
+		return "Done", nil
+
+	case "breakpoint", "breakline", "clearbreakpoint":
+		if value == nil {
+			return nil, invalidParam("Missing instruction or line number")
+		}
+
+		pc := *value
+		if cmd == "breakline" {
+			if pc = d.Source().PC(*value); pc < 0 {
+				return nil, invalidParam(fmt.Sprintf("No code at line %d", *value))
+			}
+		}
+
+		if cmd == "clearbreakpoint" {
+			d.ClearBreakpoint(pc)
+			return "Done", nil
+		}
+
+		cond := ""
+		if param != nil {
+			cond = *param
+		}
+		if err := d.SetBreakpoint(pc, cond); err != nil {
+			return nil, invalidParam(err.Error())
+		}
+		return "Done", nil
+
+	case "watch", "unwatch":
+		if param == nil {
+			return nil, invalidParam("Missing watch expression")
+		}
+		if cmd == "unwatch" {
+			d.RemoveWatch(*param)
+			return "Done", nil
+		}
+		if err := d.AddWatch(*param); err != nil {
+			return nil, invalidParam(err.Error())
+		}
+		return "Done", nil
+
+	case "getdata":
+		if param == nil || value == nil {
+			return nil, invalidParam("Missing address or length")
+		}
+		data, err := d.Evaluate(*param + ":" + strconv.Itoa(*value))
+		if err != nil {
+			return nil, err
+		}
+		return hex.EncodeToString(data), nil
+
+	case "getstack":
+		return d.CallStack()
+
+	case "evaluate":
+		// param is the 8-byte pointer in hex: offset followed by frame
+		var buf [8]byte
+		if param == nil || value == nil {
+			return nil, invalidParam("Missing pointer or length")
+		}
+		if n, err := hex.Decode(buf[:], []byte(*param)); err != nil || n != 8 {
+			return nil, invalidParam("Invalid pointer " + *param)
+		}
+		p := common.LittleEndian.Uint64(buf[:])
+		data, err := d.Memory(int32(p>>32), uint32(p), *value)
+		if err != nil {
+			return nil, err
+		}
+		return hex.EncodeToString(data), nil
+	}
+
+	return nil, invalidParam("Unknown debugger command " + cmd)
+}
+
+// marshalVMDebugEvent converts a debug event to its JSON-RPC notification.
+func marshalVMDebugEvent(ev *ovm.DebugEvent) ([]byte, error) {
+	e := btcjson.VMDebugEvent{
+		Kind:     ev.Kind.String(),
+		Contract: hex.EncodeToString(ev.Contract[:]),
+		Creation: ev.Creation,
+		PC:       ev.PC,
+		Line:     ev.Line,
+		Stack:    ev.Stack,
+		Result:   hex.EncodeToString(ev.Result),
+	}
+	if ev.Err != nil {
+		e.Error = ev.Err.Error()
+	}
+	for _, w := range ev.Watches {
+		v := btcjson.VMDebugWatch{Expr: w.Expr}
+		if w.Err != nil {
+			v.Error = w.Err.Error()
+		} else {
+			v.Value = hex.EncodeToString(w.Value)
+		}
+		e.Watches = append(e.Watches, v)
+	}
+
+	return btcjson.MarshalCmd(nil, btcjson.NewVMDebugEventNtfn(ev.Session, e))
+}
+
+// errVMDebugInclude is returned when the source of a debug session includes a
+// file, which the server does not read for clients.
+var errVMDebugInclude = errors.New("include is not allowed in debug sources")
+
+// vmDebugSourceMap assembles src with the assembler of omega/ovm/asm and
+// returns the map from instructions to the lines of src.
+func vmDebugSourceMap(src string) (*ovm.SourceMap, error) {
+	a := &asm.Assembler{
+		ReadFile: func(string) ([]byte, error) {
+			return nil, errVMDebugInclude
+		},
+	}
+	_, m, err := a.Assemble("", []byte(src))
+	return m, err
+}
+
+// handleVMDebugAttach implements the vmdebugattach command extension for
+// websocket connections. It starts a debug session for the client and returns
+// the session id. Events of the session are sent as vmdebugevent
+// notifications.
+func handleVMDebugAttach(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	cmd, ok := icmd.(*btcjson.VMDebugAttachCmd)
+	if !ok {
+		return nil, btcjson.ErrRPCInternal
+	}
+	if err := vmDebugAllowed(wsc.server); err != nil {
+		return nil, err
+	}
+
+	var address ovm.Address
+	if cmd.Address != nil {
+		a, err := ovm.AddressFromString(*cmd.Address)
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParameter,
+				Message: "Invalid contract address: " + err.Error(),
+			}
+		}
+		address = a
+	}
+
+	var source *ovm.SourceMap
+	if cmd.Source != nil {
+		m, err := vmDebugSourceMap(*cmd.Source)
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParameter,
+				Message: "Invalid contract source: " + err.Error(),
+			}
+		}
+		source = m
+	}
+
+	idleTimeout := defaultVMDebugIdleTimeout
+	if cmd.IdleTimeout != nil {
+		if *cmd.IdleTimeout <= 0 {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParameter,
+				Message: "Non-positive idle timeout",
+			}
+		}
+		idleTimeout = time.Duration(*cmd.IdleTimeout) * time.Second
+	}
+
+	session := &vmDebugSession{owner: wsc}
+	d := ovm.NewDebugger(address, func(ev *ovm.DebugEvent) {
+		if ev.Kind == ovm.DebugDetached {
+			wsc.server.vmdebug.drop(session)
+		}
+		marshalled, err := marshalVMDebugEvent(ev)
+		if err != nil {
+			rpcsLog.Errorf("Failed to marshal debug event: %v", err)
+			return
+		}
+		wsc.QueueNotification(marshalled)
+	})
+	if source != nil {
+		d.SetSource(source)
+	}
+	d.SetIdleTimeout(idleTimeout)
+
+	session.debugger = d
+	wsc.server.vmdebug.add(session)
+
+	return d.ID(), nil
+}
+
+// handleVMDebugControl implements the vmdebugcontrol command extension for
+// websocket connections.
+func handleVMDebugControl(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	cmd, ok := icmd.(*btcjson.VMDebugControlCmd)
+	if !ok {
+		return nil, btcjson.ErrRPCInternal
+	}
+
+	session, err := wsc.server.vmdebug.get(wsc, cmd.Session)
+	if err != nil {
+		return nil, err
+	}
+
+	return vmDebugControl(session.debugger, cmd.DbgCmd, cmd.Param, cmd.Value)
+}
+
+// handleVMDebugDetach implements the vmdebugdetach command extension for
+// websocket connections.
+func handleVMDebugDetach(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	cmd, ok := icmd.(*btcjson.VMDebugDetachCmd)
+	if !ok {
+		return nil, btcjson.ErrRPCInternal
+	}
+
+	return nil, wsc.server.vmdebug.remove(wsc, cmd.Session)
+}
+
+// closeDebugSessions closes all debug sessions of a disconnected client.
+func closeDebugSessions(wsc *wsClient) {
+	wsc.server.vmdebug.removeOwner(wsc)
+}
@@ -0,0 +1,55 @@
+// Copyright (C) 2019-2021 Omegasuite developer
+// Use of this code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/omegasuite/btcd/chaincfg"
+)
+
+// TestVMDebugAllowed ensures contracts may be debugged over RPC in the test
+// net only.
+func TestVMDebugAllowed(t *testing.T) {
+	tests := []struct {
+		params  *chaincfg.Params
+		allowed bool
+	}{
+		{&chaincfg.MainNetParams, false},
+		{&chaincfg.TestNet3Params, true},
+		{&chaincfg.RegressionNetParams, false},
+		{&chaincfg.SimNetParams, false},
+	}
+	for _, test := range tests {
+		s := &rpcServer{cfg: rpcserverConfig{ChainParams: test.params}}
+		if err := vmDebugAllowed(s); (err == nil) != test.allowed {
+			t.Errorf("%s: allowed %v, want %v", test.params.Name, err == nil, test.allowed)
+		}
+	}
+}
+
+// TestVMDebugSession ensures the session of the vmdebug command closes when
+// idle and is dropped from the registry once closed.
+func TestVMDebugSession(t *testing.T) {
+	var r vmDebugRegistry
+
+	session := r.vmDebugSession(true)
+	if timeout := session.debugger.IdleTimeout(); timeout != defaultVMDebugIdleTimeout {
+		t.Fatalf("idle timeout %v, want %v", timeout, defaultVMDebugIdleTimeout)
+	}
+	if r.vmDebugSession(true) != session {
+		t.Fatal("a second session is started while one is open")
+	}
+
+	session.debugger.Close()
+	if r.vmDebugSession(false) != nil {
+		t.Fatal("closed session is kept in the registry")
+	}
+	next := r.vmDebugSession(true)
+	if next == nil || next == session {
+		t.Fatal("no new session is started once the last one is closed")
+	}
+	r.closeVMDebugSession()
+}
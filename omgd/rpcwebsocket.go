@@ -31,6 +31,7 @@ import (
 	"github.com/omegasuite/btcd/wire"
 	"github.com/omegasuite/btcd/wire/common"
 	"github.com/omegasuite/btcutil"
+	"github.com/omegasuite/omega/ovm"
 	"github.com/omegasuite/omega/token"
 	"github.com/omegasuite/websocket"
 )
@@ -74,13 +75,18 @@ var wsHandlersBeforeInit = map[string]wsCommandHandler{
 	"notifynewtransactions":     handleNotifyNewTransactions,
 	"notifyreceived":            handleNotifyReceived,
 	"notifyspent":               handleNotifySpent,
+	"notifycontractevents":      handleNotifyContractEvents,
 	"session":                   handleSession,
 	"stopnotifyblocks":          handleStopNotifyBlocks,
 	"stopnotifynewtransactions": handleStopNotifyNewTransactions,
 	"stopnotifyspent":           handleStopNotifySpent,
 	"stopnotifyreceived":        handleStopNotifyReceived,
+	"stopnotifycontractevents":  handleStopNotifyContractEvents,
 	"rescan":                    handleRescan,
 	"rescanblocks":              handleRescanBlocks,
+	"vmdebugattach":             handleVMDebugAttach,
+	"vmdebugcontrol":            handleVMDebugControl,
+	"vmdebugdetach":             handleVMDebugDetach,
 }
 
 // WebsocketHandler handles a new websocket client by creating a new wsClient,
@@ -118,6 +124,7 @@ func (s *rpcServer) WebsocketHandler(conn *websocket.Conn, remoteAddr string,
 	client.Start()
 	client.WaitForShutdown()
 	s.ntfnMgr.RemoveClient(client)
+	closeDebugSessions(client)
 	rpcsLog.Infof("Disconnected websocket client %s", remoteAddr)
 }
 
@@ -505,6 +512,11 @@ type notificationUnregisterAddr struct {
 	wsc  *wsClient
 	addr string
 }
+type notificationRegisterContractEvents struct {
+	wsc    *wsClient
+	filter *contractEventFilter
+}
+type notificationUnregisterContractEvents wsClient
 
 // notificationHandler reads notifications and control messages from the queue
 // handler and processes one at a time.
@@ -524,6 +536,7 @@ func (m *wsNotificationManager) notificationHandler() {
 	txNotifications := make(map[chan struct{}]*wsClient)
 	watchedOutPoints := make(map[wire.OutPoint]map[chan struct{}]*wsClient)
 	watchedAddrs := make(map[string]map[chan struct{}]*wsClient)
+	contractEventNotifications := make(map[chan struct{}]*notificationRegisterContractEvents)
 
 out:
 	for {
@@ -553,6 +566,11 @@ out:
 						block)
 				}
 
+				if len(contractEventNotifications) != 0 {
+					m.notifyContractEvents(contractEventNotifications,
+						block)
+				}
+
 			case *notificationMinerBlockConnected:
 				block := (*wire.MinerBlock)(n)
 
@@ -604,6 +622,7 @@ out:
 				// the client itself.
 				delete(blockNotifications, wsc.quit)
 				delete(txNotifications, wsc.quit)
+				delete(contractEventNotifications, wsc.quit)
 				for k := range wsc.spentRequests {
 					op := k
 					m.removeSpentRequest(watchedOutPoints, wsc, &op)
@@ -633,6 +652,13 @@ out:
 				wsc := (*wsClient)(n)
 				delete(txNotifications, wsc.quit)
 
+			case *notificationRegisterContractEvents:
+				contractEventNotifications[n.wsc.quit] = n
+
+			case *notificationUnregisterContractEvents:
+				wsc := (*wsClient)(n)
+				delete(contractEventNotifications, wsc.quit)
+
 			default:
 				rpcsLog.Warn("Unhandled notification type")
 			}
@@ -910,6 +936,84 @@ func (m *wsNotificationManager) UnregisterNewMempoolTxsUpdates(wsc *wsClient) {
 	m.queueNotification <- (*notificationUnregisterNewMempoolTxs)(wsc)
 }
 
+// contractEventFilter selects the contract events notified to a websocket
+// client. Empty sets match any contract or topic.
+type contractEventFilter struct {
+	contracts map[ovm.Address]struct{}
+	topics    map[uint32]struct{}
+}
+
+// match returns whether e passes the filter.
+func (f *contractEventFilter) match(e *ovm.Event) bool {
+	if len(f.contracts) != 0 {
+		if _, ok := f.contracts[e.Contract]; !ok {
+			return false
+		}
+	}
+	if len(f.topics) != 0 {
+		if _, ok := f.topics[e.Topic]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// RegisterContractEvents requests notifications to the passed websocket client
+// of the contract events passing filter in connected blocks. It replaces any
+// previous request of the client.
+func (m *wsNotificationManager) RegisterContractEvents(wsc *wsClient, filter *contractEventFilter) {
+	m.queueNotification <- &notificationRegisterContractEvents{
+		wsc:    wsc,
+		filter: filter,
+	}
+}
+
+// UnregisterContractEvents removes contract event notifications for the passed
+// websocket client.
+func (m *wsNotificationManager) UnregisterContractEvents(wsc *wsClient) {
+	m.queueNotification <- (*notificationUnregisterContractEvents)(wsc)
+}
+
+// notifyContractEvents notifies websocket clients that have registered for
+// contract events of the events emitted by the transactions of a newly
+// connected block.
+func (m *wsNotificationManager) notifyContractEvents(clients map[chan struct{}]*notificationRegisterContractEvents, block *btcutil.Block) {
+	for i, tx := range block.Transactions() {
+		var events []ovm.Event
+		err := m.server.cfg.DB.View(func(dbTx database.Tx) error {
+			var err error
+			events, err = ovm.FetchTxEvents(dbTx, tx.Hash())
+			return err
+		})
+		if err != nil {
+			rpcsLog.Errorf("Failed to fetch contract events of tx %s: %v",
+				tx.Hash(), err)
+			continue
+		}
+
+		for j := range events {
+			e := &events[j]
+			var marshalled []byte
+			for _, c := range clients {
+				if !c.filter.match(e) {
+					continue
+				}
+				if marshalled == nil {
+					ntfn := btcjson.NewContractEventNtfn(contractEventResult(e,
+						block.Height(), uint32(i), uint32(j)))
+					marshalled, err = btcjson.MarshalCmd(nil, ntfn)
+					if err != nil {
+						rpcsLog.Errorf("Failed to marshal contract "+
+							"event notification: %v", err)
+						return
+					}
+				}
+				c.wsc.QueueNotification(marshalled)
+			}
+		}
+	}
+}
+
 // notifyForNewTx notifies websocket clients that have registered for updates
 // when a new transaction is added to the memory pool.
 func (m *wsNotificationManager) notifyForNewTx(clients map[chan struct{}]*wsClient, tx *btcutil.Tx) {
@@ -1983,6 +2087,42 @@ func handleStopNotifyNewTransactions(wsc *wsClient, icmd interface{}) (interface
 	return nil, nil
 }
 
+// handleNotifyContractEvents implements the notifycontractevents command
+// extension for websocket connections.
+func handleNotifyContractEvents(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	cmd, ok := icmd.(*btcjson.NotifyContractEventsCmd)
+	if !ok {
+		return nil, btcjson.ErrRPCInternal
+	}
+
+	filter := &contractEventFilter{
+		contracts: make(map[ovm.Address]struct{}, len(cmd.Contracts)),
+		topics:    make(map[uint32]struct{}),
+	}
+	for _, c := range cmd.Contracts {
+		addr, err := ovm.AddressFromString(c)
+		if err != nil {
+			return nil, rpcDecodeHexError(c)
+		}
+		filter.contracts[addr] = struct{}{}
+	}
+	if cmd.Topics != nil {
+		for _, t := range *cmd.Topics {
+			filter.topics[t] = struct{}{}
+		}
+	}
+
+	wsc.server.ntfnMgr.RegisterContractEvents(wsc, filter)
+	return nil, nil
+}
+
+// handleStopNotifyContractEvents implements the stopnotifycontractevents
+// command extension for websocket connections.
+func handleStopNotifyContractEvents(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	wsc.server.ntfnMgr.UnregisterContractEvents(wsc)
+	return nil, nil
+}
+
 // handleNotifyReceived implements the notifyreceived command extension for
 // websocket connections.
 func handleNotifyReceived(wsc *wsClient, icmd interface{}) (interface{}, error) {
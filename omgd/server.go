@@ -12,14 +12,11 @@ import (
 	//	"bufio"
 	"bytes"
 	"crypto/rand"
-	"crypto/rsa"
 	//	"crypto/sha256"
 	"crypto/tls"
-	"crypto/x509"
 	"encoding/binary"
 	//	"encoding/hex"
 	"encoding/json"
-	"encoding/pem"
 	"errors"
 	"fmt"
 	"github.com/davecgh/go-spew/spew"
@@ -55,6 +52,8 @@ import (
 	"github.com/omegasuite/btcd/wire/common"
 	"github.com/omegasuite/btcutil"
 	"github.com/omegasuite/btcutil/bloom"
+	"github.com/omegasuite/omega/consensus"
+	"github.com/omegasuite/omega/signer"
 	"github.com/omegasuite/omega/viewpoint"
 	"github.com/omegasuite/omgd/ukey"
 )
@@ -193,6 +192,7 @@ type peerState struct {
 	cmutex			sync.Mutex
 	qmutex			sync.Mutex
 	committee       map[[20]byte]*committeeState
+	consensus       *consensus.Engine
 }
 
 func (p * peerState) NewCommitteeState(m [20]byte, h int32, addr string) * committeeState {
@@ -337,6 +337,7 @@ type server struct {
 	txMemPool            *mempool.TxPool
 	cpuMiner             *cpuminer.CPUMiner
 	minerMiner			 *minerchain.CPUMiner
+	consensus            *consensus.Engine
 	modifyRebroadcastInv chan interface{}
 	newPeers             chan *serverPeer
 	donePeers            chan *serverPeer
@@ -362,6 +363,9 @@ type server struct {
 	addrIndex *indexers.AddrIndex
 	addrUseIndex *indexers.AddrUseIndex
 	cfIndex   *indexers.CfIndex
+	contractEventIndex *indexers.ContractEventIndex
+	polygonIndex *indexers.PolygonIndex
+	tokenIndex *indexers.TokenIndex
 
 	// The fee estimator keeps track of how long transactions are left in
 	// the mempool before they are mined into blocks.
@@ -372,8 +376,8 @@ type server struct {
 	cfCheckptCaches    map[wire.FilterType][]cfHeaderKV
 	cfCheckptCachesMtx sync.RWMutex
 	signAddress  	   []btcutil.Address
-	privKeys 		   []*btcec.PrivateKey
-	rsaPrivateKey	   *rsa.PrivateKey
+	signers 		   []signer.Signer
+	invitationKey	   signer.Decrypter
 	peerState 		   * peerState
 
 //	Violations          map[[20]byte]struct{}
@@ -953,6 +957,7 @@ func (sp *serverPeer) OnGetBlocks(p *peer.Peer, msg *wire.MsgGetBlocks) {
 
 	var mblock * wire.MinerBlock
 	nonce := int32(-1)
+	freq := chain.ChainParams.Committee(0).RotateFreq	// of the block of nonce
 
 	if len(hashList) > 0 {
 		p := chain.NodeByHash(&hashList[0])
@@ -965,14 +970,15 @@ func (sp *serverPeer) OnGetBlocks(p *peer.Peer, msg *wire.MsgGetBlocks) {
 			case p.Data.GetNonce() > 0:
 				d += wire.POWRotate
 
-			case p.Data.GetNonce() <= -wire.MINER_RORATE_FREQ:
-				r = -(p.Data.GetNonce() + wire.MINER_RORATE_FREQ)
+			case p.Data.GetNonce() <= -chain.RotateFreq(p):
+				r = -(p.Data.GetNonce() + chain.RotateFreq(p))
 			}
 		}
 		rot = r + d
 		blk,err := chain.HeaderByHash(&hashList[0])
 		if err == nil && blk.Nonce < 0 {
 			nonce = blk.Nonce
+			freq = chain.ChainParams.Committee(blk.Version).RotateFreq
 		}
 	}
 
@@ -985,7 +991,7 @@ func (sp *serverPeer) OnGetBlocks(p *peer.Peer, msg *wire.MsgGetBlocks) {
 			break
 		}
 		if i < len(hashList) && (mblock == nil ||
-			(nonce > 2 - wire.MINER_RORATE_FREQ && rot + 1 < mblock.Height())) {
+			(nonce > 2 - freq && rot + 1 < mblock.Height())) {
 			th := hashList[i]
 			iv := wire.NewInvVect(common.InvTypeWitnessBlock, &th)
 			invMsg.AddInvVect(iv)
@@ -994,10 +1000,11 @@ func (sp *serverPeer) OnGetBlocks(p *peer.Peer, msg *wire.MsgGetBlocks) {
 			i++
 			if i < len(hashList) {
 				h, _ := chain.HeaderByHash(&th)
+				freq = chain.ChainParams.Committee(h.Version).RotateFreq
 				if h.Nonce > 0 {
 					rot += wire.POWRotate
-				} else if h.Nonce <= -wire.MINER_RORATE_FREQ {
-					rot = -(h.Nonce + wire.MINER_RORATE_FREQ)
+				} else if h.Nonce <= -freq {
+					rot = -(h.Nonce + freq)
 				}
 				nonce = h.Nonce
 			} else {
@@ -2627,6 +2634,7 @@ func newPeerConfig(sp *serverPeer) *peer.Config {
 		DisableRelayTx:    cfg.BlocksOnly,
 		ProtocolVersion:   peer.MaxProtocolVersion,
 		TrickleInterval:   cfg.TrickleInterval,
+		Consensus:         sp.server.consensus,
 	}
 }
 
@@ -2731,6 +2739,7 @@ func (s *server) peerHandler() {
 		banned:          make(map[string]time.Time),
 		outboundGroups:  make(map[string]int),
 		committee: 		 make(map[[20]byte]*committeeState),
+		consensus:       s.consensus,
 	}
 
 	s.peerState = state
@@ -2757,7 +2766,7 @@ func (s *server) peerHandler() {
 
 			block := msg.Data.(*btcutil.Block)
 			nonce := block.MsgBlock().Header.Nonce
-			if nonce <= -wire.MINER_RORATE_FREQ || nonce > 0 {
+			if nonce <= -s.chainParams.Committee(block.MsgBlock().Header.Version).RotateFreq || nonce > 0 {
 				newBlock <- int32(s.chain.BestSnapshot().LastRotation)
 			}
 		}
@@ -2931,7 +2940,7 @@ func (s *server) UpdatePeerHeights(latestBlkHash *chainhash.Hash, latestHeight i
 		newHeight:  latestHeight,
 		originPeer: updateSource,
 	}
-//	consensus.UpdateChainHeight(s.chain.BestSnapshot().Height)
+//	s.consensus.UpdateChainHeight(s.chain.BestSnapshot().Height)
 }
 
 func (s *server) UpdatePeerMinerHeights(latestBlkHash *chainhash.Hash, latestHeight int32, updateSource *peer.Peer) {
@@ -3288,7 +3297,7 @@ func setupRPCListeners() ([]net.Listener, error) {
 // newServer returns a new btcd server configured to listen on addr for the
 // bitcoin network type specified by chainParams.  Use start to begin accepting
 // connections from peers.
-func newServer(listenAddrs []string, db, minerdb database.DB, chainParams *chaincfg.Params, interrupt <-chan struct{}) (*server, error) {
+func newServer(listenAddrs []string, db, minerdb database.DB, chainParams *chaincfg.Params, journal *consensus.Journal, interrupt <-chan struct{}) (*server, error) {
 	services := defaultServices
 	if cfg.NoPeerBloomFilters {
 		services &^= common.SFNodeBloom
@@ -3397,24 +3406,13 @@ func newServer(listenAddrs []string, db, minerdb database.DB, chainParams *chain
 //		hashCache:            NewHashCache(cfg.SigCacheMaxSize),
 		cfCheckptCaches:      make(map[wire.FilterType][]cfHeaderKV),
 		signAddress:		  cfg.signAddress,
-		privKeys:			  cfg.privateKeys,
+		signers:			  cfg.signers,
+		invitationKey:		  cfg.invitationKey,
 //		BlackList:            make(map[[20]byte]struct{}),
 //		PendingBlackList:     make(map[[20]byte]uint32),
 		Broadcasted:		  make(map[chainhash.Hash]int64),
 	}
 
-	if cfg.RsaPrivateKey != "" {
-		if file, err := os.Open(cfg.RsaPrivateKey); err == nil {
-			defer file.Close()
-			if fileinfo, err := os.Stat(cfg.RsaPrivateKey); err == nil {
-				fileStream := make([]byte, fileinfo.Size())
-				file.Read(fileStream)
-				block, _ := pem.Decode(fileStream)
-				s.rsaPrivateKey, _ = x509.ParsePKCS1PrivateKey(block.Bytes)
-			}
-		}
-	}
-
 	if cfg.Generate && !cfg.TxIndex {	// must allow txindex when mining
 		return nil, errors.New("Must enable tx index (width full history) when mining.")
 	}
@@ -3452,6 +3450,24 @@ func newServer(listenAddrs []string, db, minerdb database.DB, chainParams *chain
 	s.addrUseIndex = indexers.NewAddrUseIndex(db, chainParams)
 	indexes = append(indexes, s.addrUseIndex)
 
+	if cfg.ContractEventIndex {
+		indxLog.Info("Contract event index is enabled")
+		s.contractEventIndex = indexers.NewContractEventIndex(db)
+		indexes = append(indexes, s.contractEventIndex)
+	}
+
+	if cfg.PolygonIndex {
+		indxLog.Info("Polygon index is enabled")
+		s.polygonIndex = indexers.NewPolygonIndex(db)
+		indexes = append(indexes, s.polygonIndex)
+	}
+
+	if cfg.TokenIndex {
+		indxLog.Info("Token registry index is enabled")
+		s.tokenIndex = indexers.NewTokenIndex(db)
+		indexes = append(indexes, s.tokenIndex)
+	}
+
 	if !cfg.NoCFilters {
 		indxLog.Info("Committed filter index is enabled")
 		s.cfIndex = indexers.NewCfIndex(db, chainParams)
@@ -3482,7 +3498,6 @@ func newServer(listenAddrs []string, db, minerdb database.DB, chainParams *chain
 //		SigCache:     s.sigCache,
 		IndexManager: indexManager,
 		Miner:		  cfg.signAddress,
-		PrivKey:	  cfg.privateKeys,
 		AddrUsage:    s.addrUseIndex.Usage,
 //		HashCache:    s.hashCache,
 	})
@@ -3557,6 +3572,12 @@ func newServer(listenAddrs []string, db, minerdb database.DB, chainParams *chain
 
 //	s.chain.Blacklist = &s
 
+	// The consensus engine signs blocks with the keys of the committee
+	// members this node runs, journaling what they sign.
+	if len(cfg.signers) != 0 && cfg.Generate {
+		s.consensus = consensus.NewEngine(chainParams, &s, nil, journal, cfg.signAddress)
+	}
+
 	s.syncManager, err = netsync.New(&netsync.Config{
 		PeerNotifier:       &s,
 		Chain:              s.chain,
@@ -3565,6 +3586,7 @@ func newServer(listenAddrs []string, db, minerdb database.DB, chainParams *chain
 		DisableCheckpoints: cfg.DisableCheckpoints,
 		MaxPeers:           cfg.MaxPeers,
 		FeeEstimator:       s.feeEstimator,
+		Consensus:          s.consensus,
 	})
 	if err != nil {
 		return nil, err
@@ -3589,7 +3611,7 @@ func newServer(listenAddrs []string, db, minerdb database.DB, chainParams *chain
 		BlockTemplateGenerator: blockTemplateGenerator,
 		MiningAddrs:            cfg.miningAddrs,
 		SignAddress:            cfg.signAddress,
-		PrivKeys:               cfg.privateKeys,
+		Signers:                cfg.signers,
 		DisablePOWMining:       cfg.DisablePOWMining,
 		EnablePOWMining:        cfg.EnablePOWMining,
 		ProcessBlock:           s.syncManager.ProcessBlock,
@@ -3614,12 +3636,13 @@ func newServer(listenAddrs []string, db, minerdb database.DB, chainParams *chain
 			return true
 		},
 		Generate:	cfg.Generate,
+		Consensus:	s.consensus,
 	})
 
 	// This is the miner for miner chain
 	var rsa []byte
-	if s.rsaPrivateKey != nil {
-		rsa, _ = json.Marshal(s.rsaPrivateKey.Public())
+	if s.invitationKey != nil {
+		rsa, _ = json.Marshal(s.invitationKey.PublicKey())
 	}
 
 	if cfg.GenerateMiner {
@@ -3706,6 +3729,7 @@ func newServer(listenAddrs []string, db, minerdb database.DB, chainParams *chain
 		OnAccept:       s.inboundPeerConnected,
 		RetryDuration:  connectionRetryInterval,
 		TargetOutbound: uint32(targetOutbound),
+		CommitteeSize:  s.chainParams.MaxCommitteeSize(),
 		Dial:           btcdDial,
 		OnConnection:   s.outboundPeerConnected,
 		GetNewAddress:  newAddressFunc,
@@ -3760,12 +3784,15 @@ func newServer(listenAddrs []string, db, minerdb database.DB, chainParams *chain
 			TxIndex:      s.txIndex,
 			AddrIndex:    s.addrIndex,
 			CfIndex:      s.cfIndex,
+			ContractEventIndex: s.contractEventIndex,
+			PolygonIndex: s.polygonIndex,
+			TokenIndex:   s.tokenIndex,
 			FeeEstimator: s.feeEstimator,
 			ShareMining:  cfg.ShareMining,
 		})
 
-		if s.rsaPrivateKey != nil {
-			s.rpcServer.rsapubkey = &s.rsaPrivateKey.PublicKey
+		if s.invitationKey != nil {
+			s.rpcServer.rsapubkey = s.invitationKey.PublicKey()
 		}
 
 		if err != nil {